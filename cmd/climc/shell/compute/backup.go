@@ -38,6 +38,7 @@ func init() {
 	dbCmd.Create(&compute.DiskBackupCreateOptions{})
 	dbCmd.Perform("recovery", &compute.DiskBackupRecoveryOptions{})
 	dbCmd.Perform("syncstatus", &compute.DiskBackupSyncstatusOptions{})
+	dbCmd.Perform("verify", &compute.DiskBackupIdOptions{})
 
 	ibCmd := shell.NewResourceCmd(&modules.InstanceBackups)
 	ibCmd.List(&compute.InstanceBackupListOptions{})
@@ -48,5 +49,32 @@ func init() {
 	// ibCmd.PerformClass("create-from-package", &compute.InstanceBackupManagerCreateFromPackageOptions{})
 	ibCmd.Create(&compute.InstanceBackupManagerCreateFromPackageOptions{})
 	ibCmd.Perform("syncstatus", &compute.DiskBackupSyncstatusOptions{})
+	ibCmd.Perform("verify", &compute.InstanceBackupIdOptions{})
 	ibCmd.Perform("set-class-metadata", &options.ResourceMetadataOptions{})
+
+	bpCmd := shell.NewResourceCmd(&modules.BackupPolicies)
+	bpCmd.List(&compute.BackupPolicyListOptions{})
+	bpCmd.Show(&compute.BackupPolicyIdOptions{})
+	bpCmd.Create(&compute.BackupPolicyCreateOptions{})
+	bpCmd.Update(&compute.BackupPolicyUpdateOptions{})
+	bpCmd.Delete(&compute.BackupPolicyIdOptions{})
+	bpCmd.Perform("bind", &compute.BackupPolicyBindOptions{})
+	bpCmd.Perform("unbind", &compute.BackupPolicyBindOptions{})
+
+	bprCmd := shell.NewResourceCmd(&modules.BackupPolicyRuns)
+	bprCmd.List(&compute.BackupPolicyRunListOptions{})
+
+	dpgCmd := shell.NewResourceCmd(&modules.DrProtectionGroups)
+	dpgCmd.List(&compute.DrProtectionGroupListOptions{})
+	dpgCmd.Show(&compute.DrProtectionGroupIdOptions{})
+	dpgCmd.Create(&compute.DrProtectionGroupCreateOptions{})
+	dpgCmd.Update(&compute.DrProtectionGroupUpdateOptions{})
+	dpgCmd.Delete(&compute.DrProtectionGroupIdOptions{})
+	dpgCmd.Perform("add-servers", &compute.DrProtectionGroupServersOptions{})
+	dpgCmd.Perform("remove-servers", &compute.DrProtectionGroupServersOptions{})
+	dpgCmd.Perform("replicate", &compute.DrProtectionGroupIdOptions{})
+	dpgCmd.Perform("test-failover", &compute.DrProtectionGroupIdOptions{})
+	dpgCmd.Perform("cleanup-test", &compute.DrProtectionGroupIdOptions{})
+	dpgCmd.Perform("failover", &compute.DrProtectionGroupFailoverOptions{})
+	dpgCmd.Perform("failback", &compute.DrProtectionGroupIdOptions{})
 }
@@ -42,6 +42,7 @@ import (
 func init() {
 	cmd := shell.NewResourceCmd(&modules.Disks)
 	cmd.Perform("set-class-metadata", &options.ResourceMetadataOptions{})
+	cmd.Perform("set-performance-class", &compute_options.DiskSetPerformanceClassOptions{})
 
 	type DiskListOptions struct {
 		options.BaseListOptions
@@ -60,6 +61,8 @@ func init() {
 		BillingType string `help:"billing type" choices:"postpaid|prepaid"`
 
 		SnapshotpolicyId string `help:"snapshotpolicy id"`
+
+		DiskPerformanceClass string `help:"disk performance class id or name" json:"disk_performance_class_id"`
 	}
 	R(&DiskListOptions{}, "disk-list", "List virtual disks", func(s *mcclient.ClientSession, opts *DiskListOptions) error {
 		params, err := options.ListStructToParams(opts)
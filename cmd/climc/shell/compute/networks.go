@@ -67,6 +67,11 @@ func init() {
 		ENDIP       string `help:"End of IPv4 address rnage"`
 		NETMASK     int64  `help:"Length of network mask"`
 		Gateway     string `help:"Default gateway"`
+		StartIp6    string `help:"Start of IPv6 address range"`
+		EndIp6      string `help:"End of IPv6 address range"`
+		NetMask6    int64  `help:"Length of IPv6 network mask"`
+		Gateway6    string `help:"Default IPv6 gateway"`
+		Dns6        string `help:"IPv6 DNS servers, separated by comma"`
 		VlanId      int64  `help:"Vlan ID" default:"1"`
 		IfnameHint  string `help:"Hint for ifname generation"`
 		AllocPolicy string `help:"Address allocation policy" choices:"none|stepdown|stepup|random"`
@@ -84,6 +89,17 @@ func init() {
 		if len(args.Gateway) > 0 {
 			params.Add(jsonutils.NewString(args.Gateway), "guest_gateway")
 		}
+		if len(args.StartIp6) > 0 {
+			params.Add(jsonutils.NewString(args.StartIp6), "guest_ip6_start")
+			params.Add(jsonutils.NewString(args.EndIp6), "guest_ip6_end")
+			params.Add(jsonutils.NewInt(args.NetMask6), "guest_ip6_mask")
+		}
+		if len(args.Gateway6) > 0 {
+			params.Add(jsonutils.NewString(args.Gateway6), "guest_gateway6")
+		}
+		if len(args.Dns6) > 0 {
+			params.Add(jsonutils.NewString(args.Dns6), "guest_dns6")
+		}
 		if args.VlanId > 0 {
 			params.Add(jsonutils.NewInt(args.VlanId), "vlan_id")
 		}
@@ -31,4 +31,5 @@ func init() {
 	cmd.BatchPerform("set-scope", new(options.SchedtagSetScopeOptions))
 	cmd.PerformWithKeyword("set-user-metadata", "user-metadata", new(options.ResourceMetadataOptions))
 	cmd.Perform("set-resource", new(options.SchedtagSetResource))
+	cmd.Perform("rebalance", new(options.SchedtagRebalanceOptions))
 }
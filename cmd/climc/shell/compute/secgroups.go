@@ -36,4 +36,6 @@ func init() {
 	cmd.Perform("purge", &options.SecgroupIdOptions{})
 	cmd.Perform("change-owner", &options.SecgroupChangeOwnerOptions{})
 	cmd.Perform("import-rules", &options.SecgroupImportRulesOptions{})
+	cmd.Perform("set-flow-log", &options.SecgroupSetFlowLogOptions{})
+	cmd.Get("flow-logs", &options.SecgroupFlowLogOptions{})
 }
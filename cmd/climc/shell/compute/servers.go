@@ -101,6 +101,7 @@ func init() {
 	cmd.Perform("have-agent", &options.ServerHaveAgentOptions{})
 	cmd.Perform("change-disk-storage", &options.ServerChangeDiskStorageOptions{})
 	cmd.Perform("change-storage", &options.ServerChangeStorageOptions{})
+	cmd.Perform("cancel-change-disk-storage", &options.ServerCancelChangeDiskStorageOptions{})
 	cmd.PerformClass("batch-user-metadata", &options.ServerBatchMetadataOptions{})
 	cmd.PerformClass("batch-set-user-metadata", &options.ServerBatchMetadataOptions{})
 	cmd.Perform("user-metadata", &baseoptions.ResourceMetadataOptions{})
@@ -128,6 +129,7 @@ func init() {
 	cmd.Get("status", new(options.ServerIdOptions))
 	cmd.Get("iso", new(options.ServerIdOptions))
 	cmd.Get("create-params", new(options.ServerIdOptions))
+	cmd.Get("secgroup-flow-logs", new(options.ServerSecgroupFlowLogOptions))
 	cmd.Get("sshable", new(options.ServerIdOptions))
 	cmd.Get("make-sshable-cmd", new(options.ServerIdOptions))
 	cmd.Get("change-owner-candidate-domains", new(options.ServerChangeOwnerCandidateDomainsOptions))
@@ -705,6 +707,8 @@ func init() {
 		DisablePvpanic    string `help:"disable pvpanic device" choices:"true|false"`
 		DisableUsbKbd     string `help:"disable usb kbd" choices:"true|false"`
 		UsbControllerType string `help:"usb controller type" choices:"usb-ehci|qemu-xhci"`
+		EnableVtpm        string `help:"enable virtual tpm device" choices:"true|false"`
+		SecureBoot        string `help:"enable uefi secure boot" choices:"true|false"`
 	}
 
 	R(&ServerQemuParams{}, "server-set-qemu-params", "config qemu params", func(s *mcclient.ClientSession,
@@ -722,6 +726,12 @@ func init() {
 		if len(opts.UsbControllerType) > 0 {
 			params.Set("usb_controller_type", jsonutils.NewString(opts.UsbControllerType))
 		}
+		if len(opts.EnableVtpm) > 0 {
+			params.Set("enable_vtpm", jsonutils.NewString(opts.EnableVtpm))
+		}
+		if len(opts.SecureBoot) > 0 {
+			params.Set("secure_boot", jsonutils.NewString(opts.SecureBoot))
+		}
 		result, err := modules.Servers.PerformAction(s, opts.ID, "set-qemu-params", params)
 		if err != nil {
 			return err
@@ -733,6 +743,7 @@ func init() {
 		ID         string `help:"ID or name of VM" json:"-"`
 		SNAPSHOT   string `help:"Instance snapshot name" json:"name"`
 		WithMemory bool   `help:"Save memory state" json:"with_memory"`
+		Consistent bool   `help:"Freeze guest filesystems via guest agent to take application-consistent snapshot" json:"consistent"`
 	}
 	R(&ServerCreateSnapshot{}, "instance-snapshot-create", "create instance snapshot", func(s *mcclient.ClientSession, opts *ServerCreateSnapshot) error {
 		params := jsonutils.Marshal(opts)
@@ -747,6 +758,8 @@ func init() {
 		ID              string `help:"ID or name of VM" json:"-"`
 		BACKUP          string `help:"Instance backup name" json:"name"`
 		BACKUPSTORAGEID string `help:"backup storage id" json:"backup_storage_id"`
+		Consistent      bool   `help:"Freeze guest filesystems via guest agent to take application-consistent backup" json:"consistent"`
+		Incremental     bool   `help:"Backup disks of running guest incrementally based on last backups if possible" json:"incremental"`
 	}
 	R(&ServerCreateBackup{}, "server-create-instance-backup", "create instance backup", func(s *mcclient.ClientSession, opts *ServerCreateBackup) error {
 		params := jsonutils.Marshal(opts)
@@ -101,8 +101,9 @@ func init() {
 	})
 
 	type SnapshotCreateOptions struct {
-		Disk string `help:"Id of disk to take snapshot" json:"disk" required:"true"`
-		NAME string `help:"Name of snapshot" json:"name"`
+		Disk       string `help:"Id of disk to take snapshot" json:"disk" required:"true"`
+		NAME       string `help:"Name of snapshot" json:"name"`
+		Consistent bool   `help:"Freeze guest filesystems via guest agent to take application-consistent snapshot" json:"consistent"`
 	}
 	R(&SnapshotCreateOptions{}, "snapshot-create", "Create a snapshot", func(s *mcclient.ClientSession, args *SnapshotCreateOptions) error {
 		params, err := options.StructToParams(args)
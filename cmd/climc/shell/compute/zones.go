@@ -28,6 +28,7 @@ func init() {
 	cmd.Delete(&compute.ZoneIdOptions{})
 	cmd.Get("capability", &compute.ZoneCapabilityOptions{})
 	cmd.Perform("purge", &compute.ZonePurgeOptions{})
+	cmd.Perform("rebalance", &compute.ZoneRebalanceOptions{})
 	cmd.Create(&compute.ZoneCreateOptions{})
 	cmd.PerformWithKeyword("update-status", "status", &compute.ZoneStatusOptions{})
 }
@@ -61,6 +61,7 @@ type ImageOptionalOptions struct {
 	NetDriver          string   `help:"Preferred network driver" choices:"virtio|e1000|vmxnet3"`
 	DisableUsbKbd      bool     `help:"Disable usb keyboard on this image(for hypervisor kvm)"`
 	BootMode           string   `help:"UEFI support" choices:"UEFI|BIOS"`
+	SecureBoot         bool     `help:"Require UEFI Secure Boot, implies UEFI support(for hypervisor kvm)"`
 	VdiProtocol        string   `help:"VDI protocol" choices:"vnc|spice"`
 }
 
@@ -148,6 +149,10 @@ func addImageOptionalOptions(s *mcclient.ClientSession, params *jsonutils.JSONDi
 	} else if args.BootMode == "BIOS" {
 		params.Add(jsonutils.JSONFalse, "properties", "uefi_support")
 	}
+	if args.SecureBoot {
+		params.Add(jsonutils.JSONTrue, "properties", "uefi_support")
+		params.Add(jsonutils.NewString("true"), "properties", "secure_boot")
+	}
 	if len(args.VdiProtocol) > 0 {
 		params.Add(jsonutils.NewString(args.VdiProtocol), "properties", "vdi_protocol")
 	}
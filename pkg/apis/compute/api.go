@@ -254,6 +254,10 @@ type DiskConfig struct {
 	// 范围: 125-1000
 	Throughput int `json:"throughput"`
 
+	// 磁盘性能等级ID或名称, 仅KVM有效, 不指定则使用存储的默认性能等级
+	// requried: false
+	PerformanceClass string `json:"performance_class"`
+
 	// NVNe device
 	NVMEDevice *IsolatedDeviceConfig `json:"nvme_device"`
 }
@@ -470,6 +474,12 @@ type ServerCreateInput struct {
 	// emulate: pc, q35
 	Machine string `json:"machine"`
 
+	// 启用虚拟TPM设备(swtpm), 仅KVM支持, 若镜像要求vTPM则自动启用
+	EnableVtpm bool `json:"enable_vtpm"`
+
+	// 启用UEFI安全启动, 仅KVM支持, 要求UEFI启动方式, 若镜像要求安全启动则自动启用
+	SecureBoot bool `json:"secure_boot"`
+
 	// 启动顺序
 	// c: cdrome
 	// d: disk
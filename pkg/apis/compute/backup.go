@@ -22,9 +22,15 @@ import (
 
 const (
 	BACKUPSTORAGE_TYPE_NFS       = "nfs"
+	BACKUPSTORAGE_TYPE_OBJECT    = "object"
 	BACKUPSTORAGE_STATUS_ONLINE  = "online"
 	BACKUPSTORAGE_STATUS_OFFLINE = "offline"
 
+	// backups are saved as image files
+	BACKUPSTORAGE_FORMAT_FILE = "file"
+	// backups are saved as deduplicated and compressed chunks with manifests
+	BACKUPSTORAGE_FORMAT_CHUNK = "chunk"
+
 	BACKUP_STATUS_CREATING                = "creating"
 	BACKUP_STATUS_CREATE_FAILED           = "create_failed"
 	BACKUP_STATUS_SNAPSHOT                = "snapshot"
@@ -38,10 +44,15 @@ const (
 	BACKUP_STATUS_READY                   = "ready"
 	BACKUP_STATUS_RECOVERY                = "recovery"
 	BACKUP_STATUS_RECOVERY_FAILED         = "recovery_failed"
+	BACKUP_STATUS_VERIFYING               = "verifying"
+	BACKUP_STATUS_VERIFY_FAILED           = "verify_failed"
 	BACKUP_STATUS_UNKNOWN                 = "unknown"
 
 	BACKUP_EXIST     = "exist"
 	BACKUP_NOT_EXIST = "not_exist"
+
+	BACKUP_MODE_FULL        = "full"
+	BACKUP_MODE_INCREMENTAL = "incremental"
 )
 
 const (
@@ -52,7 +63,7 @@ type BackupStorageCreateInput struct {
 	apis.EnabledStatusInfrasResourceBaseCreateInput
 
 	// description: storage type
-	// enum: nfs
+	// enum: nfs, object
 	StorageType string `json:"storage_type"`
 
 	// description: host of nfs, storage_type 为 nfs 时, 此参数必传
@@ -63,6 +74,21 @@ type BackupStorageCreateInput struct {
 	// example: /nfs_root/
 	NfsSharedDir string `json:"nfs_shared_dir"`
 
+	// description: bucket url of s3 compatible object storage, storage_type 为 object 时, 此参数必传
+	// example: https://minio.example.com:9000/backups
+	ObjectBucketUrl string `json:"object_bucket_url"`
+
+	// description: access key of object storage, storage_type 为 object 时, 此参数必传
+	ObjectAccessKey string `json:"object_access_key"`
+
+	// description: secret of object storage, storage_type 为 object 时, 此参数必传
+	ObjectSecret string `json:"object_secret"`
+
+	// description: format of backups in storage
+	// enum: file, chunk
+	// default: file
+	BackupFormat string `json:"backup_format"`
+
 	// description: Capacity size in MB
 	CapacityMb int `json:"capacity_mb"`
 }
@@ -76,6 +102,11 @@ type BackupStorageDetails struct {
 
 	NfsHost      string
 	NfsSharedDir string
+
+	ObjectBucketUrl string
+	ObjectAccessKey string
+
+	BackupFormat string
 }
 
 type BackupStorageListInput struct {
@@ -95,6 +126,11 @@ type DiskBackupListInput struct {
 	IsInstanceBackup *bool `json:"is_instance_backup"`
 	// 按硬盘名称排序
 	OrderByDiskName string `json:"order_by_disk_name"`
+	// description: backup mode
+	// enum: full,incremental
+	BackupMode string `json:"backup_mode"`
+	// description: parent backup id of incremental backup
+	ParentBackupId string `json:"parent_backup_id"`
 }
 
 type DiskBackupDetails struct {
@@ -123,6 +159,9 @@ type DiskBackupCreateInput struct {
 	CloudregionId string `json:"cloudregion_id"`
 	// swagger:ignore
 	ManagerId string `json:"manager_id"`
+
+	// description: 增量备份, 基于上一个备份只备份变化的数据, 仅支持运行中的KVM虚拟机磁盘
+	Incremental bool `json:"incremental"`
 }
 
 type DiskBackupRecoveryInput struct {
@@ -133,6 +172,9 @@ type DiskBackupRecoveryInput struct {
 type DiskBackupSyncstatusInput struct {
 }
 
+type DiskBackupVerifyInput struct {
+}
+
 type DiskBackupPackMetadata struct {
 	OsArch     string
 	SizeMb     int
@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/onecloud/pkg/apis"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/util/bitmap"
+)
+
+const (
+	BACKUP_POLICY_STATUS_READY = "ready"
+
+	BACKUP_POLICY_RESOURCE_DISK   = "disk"
+	BACKUP_POLICY_RESOURCE_SERVER = "server"
+
+	BACKUP_POLICY_RUN_STATUS_RUNNING   = "running"
+	BACKUP_POLICY_RUN_STATUS_SUCCEEDED = "succeeded"
+	BACKUP_POLICY_RUN_STATUS_FAILED    = "failed"
+)
+
+type BackupPolicyCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 备份执行的星期, 1~7, 1 is Monday
+	// example: [1, 4]
+	RepeatWeekdays []int `json:"repeat_weekdays"`
+	// 备份执行的时间点, 0~23
+	// example: [2]
+	TimePoints []int `json:"time_points"`
+
+	// 至少保留最近的备份个数, 0表示不按个数保留
+	RetentionCount int `json:"retention_count"`
+	// 保留最近多少天内的备份, 0表示不按天数保留
+	RetentionDays int `json:"retention_days"`
+	// 保留最近多少天每天的最后一个备份
+	KeepDaily int `json:"keep_daily"`
+	// 保留最近多少周每周的最后一个备份
+	KeepWeekly int `json:"keep_weekly"`
+	// 保留最近多少月每月的最后一个备份
+	KeepMonthly int `json:"keep_monthly"`
+
+	// 备份存储
+	// required: true
+	BackupStorageId string `json:"backup_storage_id"`
+
+	// 是否尽量基于上次备份增量备份, 主机备份在主机运行时才能增量
+	Incremental bool `json:"incremental"`
+	// 主机备份是否通过guest agent冻结文件系统保证应用一致性
+	Consistent bool `json:"consistent"`
+}
+
+func (input BackupPolicyCreateInput) GetRepeatWeekdays() (uint8, error) {
+	if len(input.RepeatWeekdays) == 0 {
+		return 0, httperrors.NewMissingParameterError("repeat_weekdays")
+	}
+	err := daysCheck(input.RepeatWeekdays, 1, 7)
+	if err != nil {
+		return 0, httperrors.NewInputParameterError("repeat_weekdays: %v", err)
+	}
+	return uint8(bitmap.IntArray2Uint(input.RepeatWeekdays)), nil
+}
+
+func (input BackupPolicyCreateInput) GetTimePoints() (uint32, error) {
+	if len(input.TimePoints) == 0 {
+		return 0, httperrors.NewMissingParameterError("time_points")
+	}
+	err := daysCheck(input.TimePoints, 0, 23)
+	if err != nil {
+		return 0, httperrors.NewInputParameterError("time_points: %v", err)
+	}
+	return bitmap.IntArray2Uint(input.TimePoints), nil
+}
+
+type BackupPolicyUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	RepeatWeekdays []int `json:"repeat_weekdays"`
+	TimePoints     []int `json:"time_points"`
+
+	RetentionCount *int `json:"retention_count"`
+	RetentionDays  *int `json:"retention_days"`
+	KeepDaily      *int `json:"keep_daily"`
+	KeepWeekly     *int `json:"keep_weekly"`
+	KeepMonthly    *int `json:"keep_monthly"`
+
+	BackupStorageId string `json:"backup_storage_id"`
+
+	Incremental *bool `json:"incremental"`
+	Consistent  *bool `json:"consistent"`
+	IsActivated *bool `json:"is_activated"`
+}
+
+type BackupPolicyListInput struct {
+	apis.VirtualResourceListInput
+
+	BackupStorageId string `json:"backup_storage_id"`
+	IsActivated     *bool  `json:"is_activated"`
+}
+
+type BackupPolicyDetails struct {
+	apis.VirtualResourceDetails
+
+	SBackupPolicy
+
+	RepeatWeekdaysDisplay []int `json:"repeat_weekdays_display"`
+	TimePointsDisplay     []int `json:"time_points_display"`
+
+	BackupStorage string `json:"backup_storage"`
+
+	BindingDiskCount   int `json:"binding_disk_count"`
+	BindingServerCount int `json:"binding_server_count"`
+}
+
+type BackupPolicyBindInput struct {
+	// 绑定的磁盘
+	Disks []string `json:"disks"`
+	// 绑定的主机, 执行主机备份
+	Servers []string `json:"servers"`
+}
+
+type BackupPolicyUnbindInput struct {
+	Disks   []string `json:"disks"`
+	Servers []string `json:"servers"`
+}
+
+type BackupPolicyRunListInput struct {
+	apis.StatusStandaloneResourceListInput
+
+	BackuppolicyId string `json:"backuppolicy_id"`
+	ResourceType   string `json:"resource_type"`
+	ResourceId     string `json:"resource_id"`
+}
+
+type BackupPolicyRunDetails struct {
+	apis.StatusStandaloneResourceDetails
+
+	SBackupPolicyRun
+
+	Backuppolicy string `json:"backuppolicy"`
+}
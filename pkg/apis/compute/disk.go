@@ -160,6 +160,9 @@ type DiskListInput struct {
 	// swagger:ignore
 	// Deprecated
 	Snapshot string `json:"snapshot" yunion-deprecated-by:"snapshot_id"`
+
+	// 磁盘性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
 }
 
 type DiskResourceInput struct {
@@ -216,6 +219,8 @@ type DiskDetails struct {
 
 	// 所挂载的虚拟机
 	Guests []SimpleGuest `json:"guests"`
+	// 生效的磁盘性能等级
+	DiskPerformanceClass string `json:"disk_performance_class"`
 	// 所挂载的虚拟机
 	Guest string `json:"guest"`
 	// 所挂载虚拟机的数量
@@ -0,0 +1,97 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	DISK_PERFORMANCE_CLASS_STATUS_READY = "ready"
+)
+
+type DiskPerformanceClassCreateInput struct {
+	apis.EnabledStatusInfrasResourceBaseCreateInput
+
+	// 基础IOPS, 按容量计算的IOPS低于此值时使用此值, 0表示不限制
+	Iops int `json:"iops"`
+	// 每GB容量的IOPS, 0表示不按容量计算
+	// example: 30
+	IopsPerGb int `json:"iops_per_gb"`
+	// IOPS上限, 0表示不设上限
+	MaxIops int `json:"max_iops"`
+	// 吞吐量限制, 单位MB/s, 0表示不限制
+	Bps int `json:"bps"`
+
+	// 突发IOPS, 需大于等于IOPS限制
+	BurstIops int `json:"burst_iops"`
+	// 突发吞吐量, 单位MB/s, 需大于等于吞吐量限制
+	BurstBps int `json:"burst_bps"`
+	// 突发持续时间, 单位秒
+	// default: 1
+	BurstSeconds int `json:"burst_seconds"`
+
+	// 同一主机上使用此性能等级的磁盘是否共享限额
+	SharedBudget bool `json:"shared_budget"`
+
+	// 介质类型, 作为调度条件, 为空表示不限制
+	// enum: rotate, ssd, hybrid
+	MediumType string `json:"medium_type"`
+}
+
+type DiskPerformanceClassUpdateInput struct {
+	apis.EnabledStatusInfrasResourceBaseUpdateInput
+
+	Iops      *int `json:"iops"`
+	IopsPerGb *int `json:"iops_per_gb"`
+	MaxIops   *int `json:"max_iops"`
+	Bps       *int `json:"bps"`
+
+	BurstIops    *int `json:"burst_iops"`
+	BurstBps     *int `json:"burst_bps"`
+	BurstSeconds *int `json:"burst_seconds"`
+
+	SharedBudget *bool `json:"shared_budget"`
+}
+
+type DiskPerformanceClassListInput struct {
+	apis.EnabledStatusInfrasResourceBaseListInput
+
+	MediumType []string `json:"medium_type"`
+}
+
+type DiskPerformanceClassDetails struct {
+	apis.EnabledStatusInfrasResourceBaseDetails
+
+	SDiskPerformanceClass
+
+	// 使用此性能等级的磁盘数量
+	DiskCount int `json:"disk_count"`
+	// 以此性能等级为默认值的存储数量
+	StorageCount int `json:"storage_count"`
+}
+
+type DiskSetPerformanceClassInput struct {
+	// 磁盘性能等级, 为空表示使用存储的默认性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
+}
+
+// DiskIoThrottle is io limits of disk derived from its performance class
+type DiskIoThrottle struct {
+	Iops          int    `json:"iops"`
+	Bps           int    `json:"bps"`
+	BurstIops     int    `json:"burst_iops"`
+	BurstBps      int    `json:"burst_bps"`
+	BurstSeconds  int    `json:"burst_seconds"`
+	ThrottleGroup string `json:"throttle_group"`
+}
@@ -0,0 +1,201 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	DR_PROTECTION_GROUP_STATUS_READY                = "ready"
+	DR_PROTECTION_GROUP_STATUS_TEST_FAILING_OVER    = "test_failing_over"
+	DR_PROTECTION_GROUP_STATUS_TESTING              = "testing"
+	DR_PROTECTION_GROUP_STATUS_TEST_FAILOVER_FAILED = "test_failover_failed"
+	DR_PROTECTION_GROUP_STATUS_FAILING_OVER         = "failing_over"
+	DR_PROTECTION_GROUP_STATUS_FAILED_OVER          = "failed_over"
+	DR_PROTECTION_GROUP_STATUS_FAILOVER_FAILED      = "failover_failed"
+	DR_PROTECTION_GROUP_STATUS_FAILING_BACK         = "failing_back"
+	DR_PROTECTION_GROUP_STATUS_FAILBACK_FAILED      = "failback_failed"
+
+	DR_PROTECTION_GROUP_MIN_RPO_MINUTES = 10
+
+	// name infix of instance backups replicated by protection group
+	DR_REPLICA_NAME_INFIX = "-dr-"
+)
+
+type SDrNetworkMapping struct {
+	// 源端子网
+	SourceNetworkId string `json:"source_network_id"`
+	// 目标可用区子网, 故障切换后主机使用此子网并尽量保留原IP地址
+	TargetNetworkId string `json:"target_network_id"`
+	// 目标可用区隔离测试子网, 演练时主机使用此子网
+	TestNetworkId string `json:"test_network_id"`
+}
+
+type SDrNetworkMappings []SDrNetworkMapping
+
+func (mappings SDrNetworkMappings) String() string {
+	return jsonutils.Marshal(mappings).String()
+}
+
+func (mappings SDrNetworkMappings) IsZero() bool {
+	return len(mappings) == 0
+}
+
+func (mappings SDrNetworkMappings) Find(sourceNetworkId string) *SDrNetworkMapping {
+	for i := range mappings {
+		if mappings[i].SourceNetworkId == sourceNetworkId {
+			return &mappings[i]
+		}
+	}
+	return nil
+}
+
+// SDrServerNic records nic of protected server, it is used to recreate the
+// server with the same network config
+type SDrServerNic struct {
+	Index     int    `json:"index"`
+	NetworkId string `json:"network_id"`
+	IpAddr    string `json:"ip_addr"`
+	Ip6Addr   string `json:"ip6_addr"`
+	MacAddr   string `json:"mac_addr"`
+	Driver    string `json:"driver"`
+	BwLimit   int    `json:"bw_limit"`
+}
+
+type SDrServerNics []SDrServerNic
+
+func (nics SDrServerNics) String() string {
+	return jsonutils.Marshal(nics).String()
+}
+
+func (nics SDrServerNics) IsZero() bool {
+	return len(nics) == 0
+}
+
+// SDrReplicaIds are ids of instance backups created as replicas of a
+// protected server, from the oldest to the latest
+type SDrReplicaIds []string
+
+func (ids SDrReplicaIds) String() string {
+	return jsonutils.Marshal(ids).String()
+}
+
+func (ids SDrReplicaIds) IsZero() bool {
+	return len(ids) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SDrNetworkMappings{}), func() gotypes.ISerializable {
+		return &SDrNetworkMappings{}
+	})
+	gotypes.RegisterSerializable(reflect.TypeOf(&SDrServerNics{}), func() gotypes.ISerializable {
+		return &SDrServerNics{}
+	})
+	gotypes.RegisterSerializable(reflect.TypeOf(&SDrReplicaIds{}), func() gotypes.ISerializable {
+		return &SDrReplicaIds{}
+	})
+}
+
+type DrProtectionGroupCreateInput struct {
+	apis.VirtualResourceCreateInput
+
+	// 目标可用区
+	// required: true
+	TargetZoneId string `json:"target_zone_id"`
+	// 备份存储, 源端和目标可用区都需要能访问
+	// required: true
+	BackupStorageId string `json:"backup_storage_id"`
+
+	// 恢复点目标, 单位分钟
+	// default: 60
+	RpoMinutes int `json:"rpo_minutes"`
+	// 复制间隔, 单位分钟, 默认为RPO的一半
+	ReplicateIntervalMinutes int `json:"replicate_interval_minutes"`
+	// 每台主机保留的副本个数
+	// default: 3
+	RetentionCount int `json:"retention_count"`
+	// 复制时是否通过guest agent冻结文件系统保证应用一致性, 此时不做增量复制
+	Consistent bool `json:"consistent"`
+
+	// 子网映射
+	NetworkMappings SDrNetworkMappings `json:"network_mappings"`
+}
+
+type DrProtectionGroupUpdateInput struct {
+	apis.VirtualResourceBaseUpdateInput
+
+	RpoMinutes               *int  `json:"rpo_minutes"`
+	ReplicateIntervalMinutes *int  `json:"replicate_interval_minutes"`
+	RetentionCount           *int  `json:"retention_count"`
+	Consistent               *bool `json:"consistent"`
+
+	NetworkMappings SDrNetworkMappings `json:"network_mappings"`
+}
+
+type DrProtectionGroupListInput struct {
+	apis.VirtualResourceListInput
+
+	TargetZoneId    string `json:"target_zone_id"`
+	BackupStorageId string `json:"backup_storage_id"`
+	// 仅列出有主机不满足RPO的保护组
+	RpoViolated *bool `json:"rpo_violated"`
+}
+
+type DrProtectionGroupMemberDetails struct {
+	GuestId          string    `json:"guest_id"`
+	Guest            string    `json:"guest"`
+	SourceZoneId     string    `json:"source_zone_id"`
+	LastReplicaId    string    `json:"last_replica_id"`
+	LastReplicatedAt time.Time `json:"last_replicated_at"`
+	ReplicatingId    string    `json:"replicating_id"`
+	RpoCompliant     bool      `json:"rpo_compliant"`
+	TestGuestId      string    `json:"test_guest_id"`
+	FailoverGuestId  string    `json:"failover_guest_id"`
+}
+
+type DrProtectionGroupDetails struct {
+	apis.VirtualResourceDetails
+
+	SDrProtectionGroup
+
+	TargetZone    string `json:"target_zone"`
+	BackupStorage string `json:"backup_storage"`
+
+	MemberCount      int `json:"member_count"`
+	RpoViolatedCount int `json:"rpo_violated_count"`
+
+	Members []DrProtectionGroupMemberDetails `json:"members"`
+}
+
+type DrProtectionGroupAddServersInput struct {
+	// 加入保护组的主机
+	Servers []string `json:"servers"`
+}
+
+type DrProtectionGroupRemoveServersInput struct {
+	Servers []string `json:"servers"`
+}
+
+type DrProtectionGroupFailoverInput struct {
+	// 故障切换前不尝试关闭源端主机
+	SkipStopSource bool `json:"skip_stop_source"`
+}
@@ -416,6 +416,18 @@ const (
 	VM_METADATA_OS_VERSION          = "os_version"
 	VM_METADATA_CGROUP_CPUSET       = "cgroup_cpuset"
 	VM_METADATA_ENABLE_MEMCLEAN     = "enable_memclean"
+	VM_METADATA_ENABLE_VTPM         = "enable_vtpm"
+	VM_METADATA_SECURE_BOOT         = "secure_boot"
+	// gzip compressed and base64 encoded OVMF vars of UEFI guest, synced by host
+	VM_METADATA_UEFI_NVRAM = "__uefi_nvram"
+	// bounds in MB the memory balloon of guest could be adjusted within,
+	// default to host options if not set
+	VM_METADATA_BALLOON_MIN_MEM_MB = "balloon_min_mem_mb"
+	VM_METADATA_BALLOON_MAX_MEM_MB = "balloon_max_mem_mb"
+	// actual memory size in MB of guest after ballooning, reported by host
+	VM_METADATA_BALLOON_ACTUAL_MEM_MB = "__balloon_actual_mem_mb"
+	// guest memory is shared with virtiofsd, set once a file share is added
+	VM_METADATA_ENABLE_VIRTIOFS = "__enable_virtiofs"
 )
 
 func Hypervisors2HostTypes(hypervisors []string) []string {
@@ -93,6 +93,12 @@ type GuestdiskJsonDesc struct {
 	IsSSD            bool   `json:"is_ssd"`
 	NumQueues        uint8  `json:"num_queues"`
 
+	// burst limits and throttle group derived from disk performance class
+	BurstIops     int    `json:"burst_iops"`
+	BurstBps      int    `json:"burst_bps"`
+	BurstSeconds  int    `json:"burst_seconds"`
+	ThrottleGroup string `json:"throttle_group"`
+
 	// esxi
 	ImageInfo struct {
 		ImageType          string `json:"image_type"`
@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import "yunion.io/x/onecloud/pkg/apis"
+
+const (
+	GUEST_FILE_SHARE_STATUS_READY = "ready"
+
+	// virtio-fs limits mount tag to 36 bytes
+	GUEST_FILE_SHARE_MOUNT_TAG_MAX_LEN = 36
+)
+
+type GuestFileShareListInput struct {
+	apis.StatusStandaloneResourceListInput
+
+	ServerId  string `json:"server_id"`
+	StorageId string `json:"storage_id"`
+}
+
+type GuestFileShareCreateInput struct {
+	apis.StatusStandaloneResourceCreateInput
+
+	// guest the directory is shared to
+	ServerId string `json:"server_id"`
+	// shared file storage the directory locates on, e.g. nfs, gpfs,
+	// if empty, path is a host local directory and guest can't be migrated
+	StorageId string `json:"storage_id"`
+	// path relative to storage mount point, or absolute path of host local directory
+	Path string `json:"path"`
+	// tag used to mount in guest, e.g. mount -t virtiofs <mount_tag> /mnt
+	// default: name of the share
+	MountTag string `json:"mount_tag"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+type GuestFileShareDetails struct {
+	apis.StatusStandaloneResourceDetails
+
+	Guest   string `json:"guest"`
+	Storage string `json:"storage"`
+}
+
+type GuestFileShareJsonDesc struct {
+	Id        string `json:"id"`
+	MountTag  string `json:"mount_tag"`
+	StorageId string `json:"storage_id"`
+	Path      string `json:"path"`
+	ReadOnly  bool   `json:"read_only"`
+}
@@ -101,6 +101,11 @@ type GuestnetworkBaseDesc struct {
 	RxTrafficLimit int64                `json:"rx_traffic_limit"`
 	TxTrafficLimit int64                `json:"tx_traffic_limit"`
 
+	Ip6      string `json:"ip6,omitempty"`
+	Gateway6 string `json:"gateway6,omitempty"`
+	Masklen6 int8   `json:"masklen6,omitempty"`
+	Dns6     string `json:"dns6,omitempty"`
+
 	Bridge    string `json:"bridge"`
 	WireId    string `json:"wire_id"`
 	Interface string `json:"interface"`
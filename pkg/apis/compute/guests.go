@@ -808,6 +808,8 @@ type GuestJsonDesc struct {
 
 	IsolatedDevices []*IsolatedDeviceJsonDesc `json:"isolated_devices"`
 
+	FileShares []*GuestFileShareJsonDesc `json:"file_shares"`
+
 	Domain string `json:"domain"`
 
 	Nics  []*GuestnetworkJsonDesc `json:"nics"`
@@ -879,6 +881,8 @@ type ServerSetDiskIoThrottleInput struct {
 type ServerChangeStorageInput struct {
 	TargetStorageId string `json:"target_storage_id"`
 	KeepOriginDisk  bool   `json:"keep_origin_disk"`
+	// 运行中虚拟机磁盘数据同步带宽限制, 单位MB/s, 0表示不限制
+	MaxBandwidthMb int64 `json:"max_bandwidth_mb"`
 }
 
 type ServerChangeStorageInternalInput struct {
@@ -892,6 +896,8 @@ type ServerChangeDiskStorageInput struct {
 	DiskId          string `json:"disk_id"`
 	TargetStorageId string `json:"target_storage_id"`
 	KeepOriginDisk  bool   `json:"keep_origin_disk"`
+	// 运行中虚拟机磁盘数据同步带宽限制, 单位MB/s, 0表示不限制
+	MaxBandwidthMb int64 `json:"max_bandwidth_mb"`
 }
 
 type ServerChangeDiskStorageInternalInput struct {
@@ -907,6 +913,11 @@ type ServerChangeDiskStorageInternalInput struct {
 	CloneDiskCount     int `json:"disk_count"`
 }
 
+type ServerCancelChangeDiskStorageInput struct {
+	// 取消迁移的磁盘, 为空表示取消所有磁盘的迁移
+	DiskId string `json:"disk_id"`
+}
+
 type ServerSetExtraOptionInput struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
@@ -956,6 +967,8 @@ type ServerSnapshotAndCloneInput struct {
 type ServerInstanceSnapshot struct {
 	ServerCreateSnapshotParams
 	WithMemory bool `json:"with_memory"`
+	// 通过guest agent冻结文件系统创建应用一致性快照
+	Consistent bool `json:"consistent"`
 }
 
 type ServerCreateSnapshotParams struct {
@@ -334,6 +334,7 @@ type HostRegisterMetadata struct {
 	SysWarn                      string `json:"sys_warn,allowempty"`
 	RootPartitionTotalCapacityMB int64  `json:"root_partition_total_capacity_mb"`
 	RootPartitionUsedCapacityMB  int64  `json:"root_partition_used_capacity_mb"`
+	OvmfSecboot                  bool   `json:"ovmf_secboot,allowfalse"`
 }
 
 type HostAccessAttributes struct {
@@ -516,6 +517,22 @@ type SHostStorageStat struct {
 	ActualCapacityUsedMb int64 `json:"actual_capacity_used_mb"`
 }
 
+type SHostNumaNodeStat struct {
+	NodeId int `json:"node_id"`
+
+	// logical processors not reserved on this node
+	CpuCount int `json:"cpu_count"`
+	// vcpus of guests pinned to this node
+	VcpuCount int `json:"vcpu_count"`
+
+	MemSizeMb     int `json:"mem_size_mb"`
+	FreeMemSizeMb int `json:"free_mem_size_mb"`
+
+	HugepageSizeKb int `json:"hugepage_size_kb"`
+	HugepageNr     int `json:"hugepage_nr"`
+	FreeHugepageNr int `json:"free_hugepage_nr"`
+}
+
 type SHostPingInput struct {
 	WithData bool `json:"with_data"`
 
@@ -524,6 +541,18 @@ type SHostPingInput struct {
 	RootPartitionUsedCapacityMb int `json:"root_partition_used_capacity_mb"`
 
 	StorageStats []SHostStorageStat `json:"storage_stats"`
+
+	NumaNodeStats []SHostNumaNodeStat `json:"numa_node_stats"`
+
+	GuestMemoryStats []SGuestMemoryStat `json:"guest_memory_stats"`
+}
+
+type SGuestMemoryStat struct {
+	GuestId string `json:"guest_id"`
+	// memory size in MB guest booted with
+	MemSizeMb int64 `json:"mem_size_mb"`
+	// memory size in MB guest could use after ballooning
+	ActualMemSizeMb int64 `json:"actual_mem_size_mb"`
 }
 
 type HostReserveCpusInput struct {
@@ -178,4 +178,9 @@ const (
 
 const (
 	HOSTMETA_RESERVED_CPUS_INFO = "reserved_cpus_info"
+	HOSTMETA_NUMA_NODE_STATS    = "numa_node_stats"
+	// host has OVMF build with secure boot and enrolled vars template
+	HOSTMETA_OVMF_SECBOOT = "ovmf_secboot"
+	// memory in MB reclaimed from running guests by balloon
+	HOSTMETA_GUEST_MEMORY_BALLOONED_MB = "guest_memory_ballooned_mb"
 )
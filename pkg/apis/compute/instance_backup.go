@@ -30,6 +30,8 @@ const (
 	INSTANCE_BACKUP_STATUS_READY           = "ready"
 	INSTANCE_BACKUP_STATUS_PACK            = "pack"
 	INSTANCE_BACKUP_STATUS_PACK_FAILED     = "pack_failed"
+	INSTANCE_BACKUP_STATUS_VERIFYING       = "verifying"
+	INSTANCE_BACKUP_STATUS_VERIFY_FAILED   = "verify_failed"
 
 	INSTANCE_BACKUP_STATUS_CREATING_FROM_PACKAGE      = "creating_from_package"
 	INSTANCE_BACKUP_STATUS_CREATE_FROM_PACKAGE_FAILED = "create_from_package_failed"
@@ -106,6 +108,9 @@ type InstanceBackupPackInput struct {
 	PackageName string
 }
 
+type InstanceBackupVerifyInput struct {
+}
+
 type InstanceBackupManagerCreateFromPackageInput struct {
 	apis.VirtualResourceCreateInput
 
@@ -0,0 +1,115 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"reflect"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/gotypes"
+
+	"yunion.io/x/onecloud/pkg/apis"
+)
+
+const (
+	MAINTENANCE_CAMPAIGN_STATUS_READY     = "ready"
+	MAINTENANCE_CAMPAIGN_STATUS_RUNNING   = "running"
+	MAINTENANCE_CAMPAIGN_STATUS_PAUSED    = "paused"
+	MAINTENANCE_CAMPAIGN_STATUS_COMPLETED = "completed"
+	MAINTENANCE_CAMPAIGN_STATUS_CANCELLED = "cancelled"
+
+	// host is waiting to be drained
+	MAINTENANCE_CAMPAIGN_HOST_PENDING = "pending"
+	// guests are migrating off the host
+	MAINTENANCE_CAMPAIGN_HOST_DRAINING = "draining"
+	// host is drained and disabled, waiting for health hook before re-enabled
+	MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY = "wait_healthy"
+	MAINTENANCE_CAMPAIGN_HOST_DONE         = "done"
+	MAINTENANCE_CAMPAIGN_HOST_FAILED       = "failed"
+)
+
+type SMaintenanceCampaignHost struct {
+	HostId string `json:"host_id"`
+	Host   string `json:"host"`
+	Status string `json:"status"`
+	// batch number this host is drained in, start from 1
+	Batch     int       `json:"batch"`
+	DrainedAt time.Time `json:"drained_at"`
+	Reason    string    `json:"reason"`
+}
+
+type SMaintenanceCampaignHosts []SMaintenanceCampaignHost
+
+func (hosts SMaintenanceCampaignHosts) String() string {
+	return jsonutils.Marshal(hosts).String()
+}
+
+func (hosts SMaintenanceCampaignHosts) IsZero() bool {
+	return len(hosts) == 0
+}
+
+func init() {
+	gotypes.RegisterSerializable(reflect.TypeOf(&SMaintenanceCampaignHosts{}), func() gotypes.ISerializable {
+		return &SMaintenanceCampaignHosts{}
+	})
+}
+
+type MaintenanceCampaignListInput struct {
+	apis.StatusStandaloneResourceListInput
+
+	ZoneId     string `json:"zone_id"`
+	SchedtagId string `json:"schedtag_id"`
+}
+
+type MaintenanceCampaignCreateInput struct {
+	apis.StatusStandaloneResourceCreateInput
+
+	// hosts to maintain, could be combined with zone and schedtag
+	Hosts []string `json:"hosts"`
+	// maintain all hypervisor hosts of the zone
+	ZoneId string `json:"zone_id"`
+	// maintain all hypervisor hosts bound to the schedtag
+	SchedtagId string `json:"schedtag_id"`
+
+	// max hosts drained at the same time
+	// default: 1
+	MaxUnavailable int `json:"max_unavailable"`
+
+	// health hook, drained host is re-enabled after it reports this host agent version,
+	// if empty, any ping after drained is treated as healthy
+	HostAgentVersion string `json:"host_agent_version"`
+	// campaign is paused if drained host is not healthy in time
+	// default: 60
+	HealthTimeoutMinutes int `json:"health_timeout_minutes"`
+}
+
+type MaintenanceCampaignDetails struct {
+	apis.StatusStandaloneResourceDetails
+
+	HostCount   int `json:"host_count"`
+	DoneCount   int `json:"done_count"`
+	FailedCount int `json:"failed_count"`
+	// percent of finished hosts
+	Progress float64 `json:"progress"`
+}
+
+type MaintenanceCampaignUpdateInput struct {
+	apis.StatusStandaloneResourceBaseUpdateInput
+
+	MaxUnavailable       *int    `json:"max_unavailable"`
+	HostAgentVersion     *string `json:"host_agent_version"`
+	HealthTimeoutMinutes *int    `json:"health_timeout_minutes"`
+}
@@ -16,6 +16,12 @@ package compute
 
 import "yunion.io/x/onecloud/pkg/apis"
 
+const (
+	// dnat port range is realized with one ovn load balancer vip for each
+	// port, all of them are passed to ovn-nbctl in one command
+	NAT_DNAT_PORT_RANGE_MAX = 1024
+)
+
 type SNatSCreateInput struct {
 	apis.Meta
 
@@ -42,7 +48,9 @@ type SNatDCreateInput struct {
 	Eip          string `json:"eip"`
 	ExternalIpId string `json:"external_ip_id"  yunion-deprecated-by:"eip"`
 	ExternalPort int
-	IpProtocol   string
+	// 端口范围结束, 外部端口ExternalPort至ExternalPortEnd依次转发至内部端口InternalPort起始的端口, 最多1024个端口
+	ExternalPortEnd int
+	IpProtocol      string
 }
 
 type NatDEntryDetails struct {
@@ -214,6 +214,26 @@ type NetworkCreateInput struct {
 	// example: cn.pool.ntp.org,0.cn.pool.ntp.org
 	GuestNtp string `json:"guest_ntp"`
 
+	// description: ipv6 range of guest ip start
+	// example: fd00:1::10
+	GuestIp6Start string `json:"guest_ip6_start"`
+
+	// description: ipv6 range of guest ip end
+	// example: fd00:1::ff
+	GuestIp6End string `json:"guest_ip6_end"`
+
+	// description: ipv6 prefix length
+	// example: 64
+	GuestIp6Mask int8 `json:"guest_ip6_mask"`
+
+	// description: guest ipv6 gateway
+	// example: fd00:1::1
+	GuestGateway6 string `json:"guest_gateway6"`
+
+	// description: guest ipv6 dns
+	// example: 2001:4860:4860::8888
+	GuestDns6 string `json:"guest_dns6"`
+
 	// swagger:ignore
 	WireId string `json:"wire_id"`
 
@@ -409,6 +429,19 @@ type NetworkUpdateInput struct {
 
 	GuestDomain string `json:"guest_domain"`
 
+	// IPv6起始地址
+	GuestIp6Start string `json:"guest_ip6_start"`
+	// IPv6结束地址
+	GuestIp6End string `json:"guest_ip6_end"`
+	// IPv6前缀长度
+	GuestIp6Mask *int8 `json:"guest_ip6_mask"`
+	// IPv6网关地址
+	GuestGateway6 string `json:"guest_gateway6"`
+	// IPv6 DNS
+	GuestDns6 string `json:"guest_dns6"`
+
+	GuestDomain6 string `json:"guest_domain6"`
+
 	VlanId *int `json:"vlan_id"`
 
 	// 分配策略
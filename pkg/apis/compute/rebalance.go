@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+const (
+	REBALANCE_MIGRATION_PENDING         = "pending"
+	REBALANCE_MIGRATION_MIGRATING       = "migrating"
+	REBALANCE_MIGRATION_DONE            = "done"
+	REBALANCE_MIGRATION_FAILED          = "failed"
+	REBALANCE_MIGRATION_ROLLED_BACK     = "rolled_back"
+	REBALANCE_MIGRATION_ROLLBACK_FAILED = "rollback_failed"
+)
+
+type RebalanceInput struct {
+	// 只生成迁移计划, 不执行迁移
+	DryRun bool `json:"dry_run"`
+
+	// 负载最高的宿主机超出平均负载的百分比阈值, 超出才进行迁移
+	// example: 10
+	Threshold *float64 `json:"threshold"`
+
+	// 一次计划最多迁移的虚拟机数量
+	MaxMigrations int `json:"max_migrations"`
+
+	// 同时进行热迁移的虚拟机数量
+	MaxConcurrentMigrations int `json:"max_concurrent_migrations"`
+}
+
+type RebalanceMigration struct {
+	GuestId      string `json:"guest_id"`
+	Guest        string `json:"guest"`
+	SourceHostId string `json:"source_host_id"`
+	SourceHost   string `json:"source_host"`
+	TargetHostId string `json:"target_host_id"`
+	TargetHost   string `json:"target_host"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+type RebalancePlan struct {
+	// 迁移前负载最高的宿主机超出平均负载的百分比
+	ImbalanceBefore float64 `json:"imbalance_before"`
+	// 执行计划后负载最高的宿主机超出平均负载的百分比
+	ImbalanceAfter float64 `json:"imbalance_after"`
+
+	Migrations []RebalanceMigration `json:"migrations"`
+}
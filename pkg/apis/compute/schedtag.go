@@ -84,6 +84,9 @@ type SchedtagDetails struct {
 	OtherCount           int    `json:"other_count"`
 	ResourceCount        int    `json:"resource_count"`
 	JoinModelKeyword     string `json:"join_model_keyword"`
+
+	// 存储调度标签下存储的容量增长趋势
+	StorageForecast *StorageCapacityForecast `json:"storage_forecast,omitempty"`
 }
 
 type SchedtagResourceInfo struct {
@@ -25,3 +25,12 @@ const (
 )
 
 var STRATEGY_LIST = []string{STRATEGY_REQUIRE, STRATEGY_EXCLUDE, STRATEGY_PREFER, STRATEGY_AVOID}
+
+const (
+	// score host load by committed cpu and memory ratio
+	SCHED_LOAD_PRIORITY_COMMIT = "commit"
+	// score host load by real utilization collected in tsdb
+	SCHED_LOAD_PRIORITY_METRICS = "metrics"
+)
+
+var SCHED_LOAD_PRIORITY_LIST = []string{SCHED_LOAD_PRIORITY_COMMIT, SCHED_LOAD_PRIORITY_METRICS}
@@ -16,6 +16,7 @@ package compute
 
 import (
 	"fmt"
+	"time"
 
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/regutils"
@@ -140,6 +141,11 @@ type SSecgroupCreateInput struct {
 	// 规则列表
 	// required: false
 	Rules []SSecgroupRuleCreateInput `json:"rules"`
+
+	// 流日志记录模式, 仅对OVN VPC内的KVM虚拟机生效
+	// enum: off, deny, all
+	// default: off
+	FlowLog string `json:"flow_log"`
 }
 
 type SecgroupListInput struct {
@@ -333,3 +339,49 @@ func (self *SSecurityGroupRef) Sum() {
 type SecurityGroupCacheInput struct {
 	VpcId string `json:"vpc_id"`
 }
+
+type SecgroupSetFlowLogInput struct {
+	// 流日志记录模式
+	// enum: off, deny, all
+	FlowLog string `json:"flow_log"`
+}
+
+type SecgroupFlowLogInput struct {
+	// 查询起始时间, 默认为一小时前
+	StartTime time.Time `json:"start_time"`
+	// 查询截止时间, 默认为当前时间
+	EndTime time.Time `json:"end_time"`
+	// 按动作过滤
+	// enum: allow, drop
+	Verdict string `json:"verdict"`
+	// 返回记录条数上限, 默认100, 最大1000
+	Limit int `json:"limit"`
+}
+
+type SecgroupFlowLogRecord struct {
+	Time time.Time `json:"time"`
+
+	HostId string `json:"host_id"`
+	// 命中的安全组, 虚拟机流日志中默认拒绝规则记录为虚拟机的全部安全组, 以逗号分隔
+	SecgroupId string `json:"secgroup_id"`
+	RuleId     string `json:"rule_id"`
+	// allow, drop
+	Verdict string `json:"verdict"`
+	// from-lport 为虚拟机发出, to-lport 为发往虚拟机
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+
+	SrcMac  string `json:"src_mac"`
+	DstMac  string `json:"dst_mac"`
+	SrcIp   string `json:"src_ip"`
+	DstIp   string `json:"dst_ip"`
+	SrcPort int    `json:"src_port"`
+	DstPort int    `json:"dst_port"`
+
+	// 采集周期内相同五元组的报文数
+	Packets int64 `json:"packets"`
+}
+
+type SecgroupFlowLogOutput struct {
+	Records []SecgroupFlowLogRecord `json:"records"`
+}
@@ -23,3 +23,20 @@ const (
 
 	SECGROUP_DEFAULT_ID = "default"
 )
+
+const (
+	SECGROUP_FLOW_LOG_OFF  = "off"  // 不记录
+	SECGROUP_FLOW_LOG_DENY = "deny" // 记录拒绝的流量
+	SECGROUP_FLOW_LOG_ALL  = "all"  // 记录允许和拒绝的流量
+
+	// 安全组流日志在时序数据库中的表名
+	SECGROUP_FLOW_LOG_MEASUREMENT = "secgroup_flow_log"
+	// 虚拟机网卡默认拒绝规则在流日志中的规则id
+	SECGROUP_FLOW_LOG_DEFAULT_DENY_RULE = "default-deny"
+)
+
+var SECGROUP_FLOW_LOG_MODES = []string{
+	SECGROUP_FLOW_LOG_OFF,
+	SECGROUP_FLOW_LOG_DENY,
+	SECGROUP_FLOW_LOG_ALL,
+}
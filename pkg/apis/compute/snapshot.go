@@ -46,6 +46,10 @@ type SnapshotCreateInput struct {
 	ManagerId string `json:"manager_id"`
 	// swagger:ignore
 	OsArch string `json:"os_arch"`
+
+	// 通过guest agent冻结文件系统创建应用一致性快照, 仅对KVM云主机生效
+	// guest agent不可用时退化为崩溃一致性快照
+	Consistent bool `json:"consistent"`
 }
 
 type SnapshotListInput struct {
@@ -50,6 +50,9 @@ type SSnapshotPolicyCreateInput struct {
 	RetentionDays  int    `json:"retention_days"`
 	RepeatWeekdays string `json:"repeat_weekdays"`
 	TimePoints     string `json:"time_points"`
+
+	// 自动快照是否通过guest agent冻结文件系统保证应用一致性
+	Consistent bool `json:"consistent"`
 }
 
 func (self SSnapshotPolicyCreateInput) GetRepeatWeekdays(limit int) (uint32, error) {
@@ -111,6 +114,8 @@ type SSnapshotPolicyUpdateInput struct {
 
 	RepeatWeekdays *string `json:"repeat_weekdays"`
 	TimePoints     *string `json:"time_points"`
+
+	Consistent *bool `json:"consistent"`
 }
 
 func (self SSnapshotPolicyUpdateInput) GetRepeatWeekdays(limit int) (uint32, error) {
@@ -64,6 +64,12 @@ type StorageCreateInput struct {
 	// default: ssd
 	MediumType string `json:"medium_type"`
 
+	// 存储上磁盘的默认性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
+
+	// swagger:ignore
+	ManagerId string `json:"manager_id"`
+
 	ZoneResourceInput
 
 	// ceph认证主机, storage_type为 rbd 时,此参数为必传项
@@ -104,6 +110,30 @@ type StorageCreateInput struct {
 	// 网络文件系统共享目录, storage_type 为 nfs 时, 此参数必传
 	// example: /nfs_root/
 	NfsSharedDir string `json:"nfs_shared_dir"`
+
+	// CIFS/SMB服务器地址, storage_type 为 cifs 时, 此参数必传
+	// example: 192.168.222.2
+	CifsHost string `json:"cifs_host"`
+
+	// CIFS/SMB共享名称, storage_type 为 cifs 时, 此参数必传
+	// example: vmstore
+	CifsShare string `json:"cifs_share"`
+
+	// CIFS/SMB认证用户名, storage_type 为 cifs 时, 此参数必传
+	// example: administrator
+	CifsUsername string `json:"cifs_username"`
+
+	// CIFS/SMB认证密码
+	CifsPassword string `json:"cifs_password"`
+
+	// CIFS/SMB认证域
+	// example: WORKGROUP
+	CifsDomain string `json:"cifs_domain"`
+
+	// 共享LVM卷组名称, storage_type 为 slvm 时, 此参数必传
+	// 卷组需位于各宿主机均可访问的共享块设备上(如SAN多路径LUN), 并已启用lvmlockd
+	// example: vg_san
+	SlvmVgName string `json:"slvm_vg_name"`
 }
 
 type RbdTimeoutInput struct {
@@ -165,6 +195,35 @@ type StorageDetails struct {
 
 	// 超分比
 	CommitBound float32 `json:"commit_bound"`
+
+	// 默认磁盘性能等级
+	DiskPerformanceClass string `json:"disk_performance_class"`
+
+	// 按实际使用容量增长趋势预计存满天数, 容量未增长时为空
+	DaysUntilFull *int `json:"days_until_full,omitempty"`
+	// 按分配容量增长趋势预计分配满天数, 分配容量未增长时为空
+	CommitDaysUntilFull *int `json:"commit_days_until_full,omitempty"`
+}
+
+type StorageCapacityForecast struct {
+	// 容量大小, 单位Mb
+	Capacity int64 `json:"capacity"`
+	// 实际使用容量, 单位Mb
+	ActualUsed int64 `json:"actual_used"`
+	// 虚拟容量大小, 单位Mb
+	VirtualCapacity int64 `json:"virtual_capacity"`
+	// 分配容量, 单位Mb
+	CommitUsed int64 `json:"commit_used"`
+
+	// 实际使用容量日增长量, 单位Mb
+	UsageGrowthRate float64 `json:"usage_growth_rate"`
+	// 分配容量日增长量, 单位Mb
+	CommitGrowthRate float64 `json:"commit_growth_rate"`
+
+	// 按实际使用容量增长趋势预计存满天数, 容量未增长时为空
+	DaysUntilFull *int `json:"days_until_full,omitempty"`
+	// 按分配容量增长趋势预计分配满天数, 分配容量未增长时为空
+	CommitDaysUntilFull *int `json:"commit_days_until_full,omitempty"`
 }
 
 func (self StorageDetails) GetMetricTags() map[string]string {
@@ -226,6 +285,12 @@ type StorageUpdateInput struct {
 
 	RbdTimeoutInput
 
+	// lvm存储使用的thin pool, 由宿主机上报
+	LvmThinPool string `json:"lvm_thin_pool"`
+
+	// 存储上磁盘的默认性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
+
 	// swagger:ignore
 	StorageConf *jsonutils.JSONDict
 
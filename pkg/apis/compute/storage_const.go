@@ -34,6 +34,7 @@ const (
 	STORAGE_NVME_PT   = "nvme_pt" // nvme passthrough
 	STORAGE_NVME      = "nvme"    // nvme sriov
 	STORAGE_LVM       = "lvm"
+	STORAGE_SLVM      = "slvm" // shared lvm on san, coordinated by lvmlockd
 
 	STORAGE_PUBLIC_CLOUD     = compute.STORAGE_PUBLIC_CLOUD
 	STORAGE_CLOUD_EFFICIENCY = compute.STORAGE_CLOUD_EFFICIENCY
@@ -131,6 +132,12 @@ const (
 	DISK_TYPE_HYBRID = compute.DISK_TYPE_HYBRID
 )
 
+const (
+	// 存储容量采样及预测指标, 写入telegraf数据库
+	STORAGE_CAPACITY_MEASUREMENT          = "storage_capacity"
+	STORAGE_SCHEDTAG_CAPACITY_MEASUREMENT = "storage_schedtag_capacity"
+)
+
 const (
 	RBD_DEFAULT_MON_TIMEOUT   = 5       //5 seconds 连接超时时间
 	RBD_DEFAULT_OSD_TIMEOUT   = 20 * 60 //20 minute 操作超时时间
@@ -149,7 +156,7 @@ var (
 	STORAGE_ALL_TYPES     = []string{
 		STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_SHEEPDOG,
 		STORAGE_RBD, STORAGE_DOCKER, STORAGE_NAS, STORAGE_VSAN,
-		STORAGE_NFS, STORAGE_GPFS, STORAGE_CIFS, STORAGE_NVME_PT, STORAGE_NVME, STORAGE_LVM,
+		STORAGE_NFS, STORAGE_GPFS, STORAGE_CIFS, STORAGE_NVME_PT, STORAGE_NVME, STORAGE_LVM, STORAGE_SLVM,
 	}
 	STORAGE_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_SHEEPDOG,
 		STORAGE_RBD, STORAGE_DOCKER, STORAGE_NAS, STORAGE_VSAN, STORAGE_NFS,
@@ -162,18 +169,18 @@ var (
 		STORAGE_OPENSTACK_ISCSI, STORAGE_UCLOUD_CLOUD_NORMAL, STORAGE_UCLOUD_CLOUD_SSD,
 		STORAGE_UCLOUD_LOCAL_NORMAL, STORAGE_UCLOUD_LOCAL_SSD, STORAGE_UCLOUD_EXCLUSIVE_LOCAL_DISK,
 		STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_ZSTACK_CEPH, STORAGE_GPFS, STORAGE_CIFS,
-		STORAGE_NVME_PT, STORAGE_NVME, STORAGE_LVM,
+		STORAGE_NVME_PT, STORAGE_NVME, STORAGE_LVM, STORAGE_SLVM,
 	}
 
 	HOST_STORAGE_LOCAL_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_ZSTACK_LOCAL_STORAGE, STORAGE_OPENSTACK_NOVA}
 
-	STORAGE_LIMITED_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_NAS, STORAGE_RBD, STORAGE_NFS, STORAGE_GPFS, STORAGE_VSAN, STORAGE_CIFS}
+	STORAGE_LIMITED_TYPES = []string{STORAGE_LOCAL, STORAGE_BAREMETAL, STORAGE_NAS, STORAGE_RBD, STORAGE_NFS, STORAGE_GPFS, STORAGE_VSAN, STORAGE_CIFS, STORAGE_SLVM}
 
-	SHARED_FILE_STORAGE = []string{STORAGE_NFS, STORAGE_GPFS}
-	FIEL_STORAGE        = []string{STORAGE_LOCAL, STORAGE_NFS, STORAGE_GPFS}
+	SHARED_FILE_STORAGE = []string{STORAGE_NFS, STORAGE_GPFS, STORAGE_CIFS}
+	FIEL_STORAGE        = []string{STORAGE_LOCAL, STORAGE_NFS, STORAGE_GPFS, STORAGE_CIFS}
 
 	// 目前来说只支持这些
-	SHARED_STORAGE = []string{STORAGE_NFS, STORAGE_GPFS, STORAGE_RBD}
+	SHARED_STORAGE = []string{STORAGE_NFS, STORAGE_GPFS, STORAGE_CIFS, STORAGE_RBD, STORAGE_SLVM}
 )
 
 func IsDiskTypeMatch(t1, t2 string) bool {
@@ -71,6 +71,26 @@ func VpcInterExtIP2() netutils.IPV4Addr {
 	return vpcInterExtIP2
 }
 
+const (
+	// transit subnets of on-premise vpc peering connections, one /30 for
+	// each connection
+	sVpcPeerTransitCidr     = "100.65.128.0/17"
+	VpcPeerTransitMask      = 30
+	VpcPeerTransitIndexMax  = 1<<(VpcPeerTransitMask-17) - 1
+	VpcPeerTransitIndexNone = 0
+)
+
+var (
+	vpcPeerTransitCidr netutils.IPV4Prefix
+)
+
+// VpcPeerTransitIPs returns addresses of the requester and accepter vpc
+// router ports in the transit subnet with the index
+func VpcPeerTransitIPs(index int) (netutils.IPV4Addr, netutils.IPV4Addr) {
+	netAddr := vpcPeerTransitCidr.Address + netutils.IPV4Addr(index<<(32-VpcPeerTransitMask))
+	return netAddr + 1, netAddr + 2
+}
+
 const (
 	sVpcMappedCidr      = "100.64.0.0/17"
 	VpcMappedIPMask     = 17
@@ -135,6 +155,8 @@ func init() {
 	vpcInterExtIP1 = mi(netutils.NewIPV4Addr(sVpcInterExtIP1))
 	vpcInterExtIP2 = mi(netutils.NewIPV4Addr(sVpcInterExtIP2))
 
+	vpcPeerTransitCidr = mp(netutils.NewIPV4Prefix(sVpcPeerTransitCidr))
+
 	vpcMappedCidr = mp(netutils.NewIPV4Prefix(sVpcMappedCidr))
 	vpcMappedGatewayIP = mi(netutils.NewIPV4Addr(sVpcMappedGatewayIP))
 
@@ -87,6 +87,47 @@ type SBackupDiskConfig struct {
 	Name string `json:"name"`
 }
 
+// SBackupPolicy is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBackupPolicy.
+type SBackupPolicy struct {
+	apis.SVirtualResourceBase
+	// 1~7, 1 is Monday
+	RepeatWeekdays byte `json:"repeat_weekdays"`
+	// 0~23
+	TimePoints      uint32 `json:"time_points"`
+	RetentionCount  int    `json:"retention_count"`
+	RetentionDays   int    `json:"retention_days"`
+	KeepDaily       int    `json:"keep_daily"`
+	KeepWeekly      int    `json:"keep_weekly"`
+	KeepMonthly     int    `json:"keep_monthly"`
+	BackupStorageId string `json:"backup_storage_id"`
+	Incremental     bool   `json:"incremental"`
+	Consistent      bool   `json:"consistent"`
+	IsActivated     *bool  `json:"is_activated,omitempty"`
+}
+
+// SBackupPolicyResource is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBackupPolicyResource.
+type SBackupPolicyResource struct {
+	apis.SStandaloneAnonResourceBase
+	BackuppolicyId string    `json:"backuppolicy_id"`
+	ResourceType   string    `json:"resource_type"`
+	ResourceId     string    `json:"resource_id"`
+	NextRunTime    time.Time `json:"next_run_time"`
+}
+
+// SBackupPolicyRun is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBackupPolicyRun.
+type SBackupPolicyRun struct {
+	apis.SStatusStandaloneResourceBase
+	BackuppolicyId string    `json:"backuppolicy_id"`
+	ResourceType   string    `json:"resource_type"`
+	ResourceId     string    `json:"resource_id"`
+	BackupId       string    `json:"backup_id"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	Reason         string    `json:"reason"`
+	// 备份已按保留规则清理
+	Pruned bool `json:"pruned"`
+}
+
 // SBackupStorage is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBackupStorage.
 type SBackupStorage struct {
 	apis.SEnabledStatusInfrasResourceBase
@@ -97,8 +138,12 @@ type SBackupStorage struct {
 
 // SBackupStorageAccessInfo is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBackupStorageAccessInfo.
 type SBackupStorageAccessInfo struct {
-	NfsHost      string `json:"nfs_host"`
-	NfsSharedDir string `json:"nfs_shared_dir"`
+	NfsHost         string `json:"nfs_host"`
+	NfsSharedDir    string `json:"nfs_shared_dir"`
+	ObjectBucketUrl string `json:"object_bucket_url"`
+	ObjectAccessKey string `json:"object_access_key"`
+	ObjectSecret    string `json:"object_secret"`
+	BackupFormat    string `json:"backup_format"`
 }
 
 // SBaremetalagent is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SBaremetalagent.
@@ -638,6 +683,8 @@ type SDisk struct {
 	Iops int `json:"iops"`
 	// 磁盘吞吐量
 	Throughput int `json:"throughput"`
+	// 磁盘性能等级, 为空则使用存储的默认性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
 }
 
 // SDiskBackup is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskBackup.
@@ -659,6 +706,26 @@ type SDiskBackup struct {
 	DiskConfig *SBackupDiskConfig `json:"disk_config"`
 }
 
+// SDiskPerformanceClass is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskPerformanceClass.
+type SDiskPerformanceClass struct {
+	apis.SEnabledStatusInfrasResourceBase
+	// 基础IOPS
+	Iops int `json:"iops"`
+	// 每GB容量的IOPS
+	IopsPerGb int `json:"iops_per_gb"`
+	// IOPS上限
+	MaxIops int `json:"max_iops"`
+	// 吞吐量限制, 单位MB/s
+	Bps          int `json:"bps"`
+	BurstIops    int `json:"burst_iops"`
+	BurstBps     int `json:"burst_bps"`
+	BurstSeconds int `json:"burst_seconds"`
+	// 同一主机上使用此性能等级的磁盘是否共享限额
+	SharedBudget bool `json:"shared_budget"`
+	// 介质类型, 调度时磁盘只会落在此介质的存储上
+	MediumType string `json:"medium_type"`
+}
+
 // SDiskResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDiskResourceBase.
 type SDiskResourceBase struct {
 	DiskId string `json:"disk_id"`
@@ -729,6 +796,44 @@ type SDnsZoneVpc struct {
 	VpcId string `json:"vpc_id"`
 }
 
+// SDrProtectionGroup is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDrProtectionGroup.
+type SDrProtectionGroup struct {
+	apis.SVirtualResourceBase
+	TargetZoneId    string `json:"target_zone_id"`
+	BackupStorageId string `json:"backup_storage_id"`
+	// 恢复点目标, 单位分钟
+	RpoMinutes int `json:"rpo_minutes"`
+	// 复制间隔, 单位分钟
+	ReplicateIntervalMinutes int `json:"replicate_interval_minutes"`
+	// 每台主机保留的副本个数
+	RetentionCount  int                 `json:"retention_count"`
+	Consistent      bool                `json:"consistent"`
+	NetworkMappings *SDrNetworkMappings `json:"network_mappings"`
+}
+
+// SDrProtectionGroupMember is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDrProtectionGroupMember.
+type SDrProtectionGroupMember struct {
+	apis.SStandaloneAnonResourceBase
+	DrprotectiongroupId string `json:"drprotectiongroup_id"`
+	GuestId             string `json:"guest_id"`
+	SourceZoneId        string `json:"source_zone_id"`
+	// nics of server when it is added, used to recreate server with the same network config
+	Nics *SDrServerNics `json:"nics"`
+	// RPO is accounted since the time server is protected before first replica
+	ProtectedAt time.Time `json:"protected_at"`
+	// latest ready instance backup replicated to backup storage
+	LastReplicaId    string    `json:"last_replica_id"`
+	LastReplicatedAt time.Time `json:"last_replicated_at"`
+	// replicas created by protection group, only these are pruned
+	ReplicaIds *SDrReplicaIds `json:"replica_ids,omitempty"`
+	// instance backup being replicated
+	ReplicatingId    string    `json:"replicating_id"`
+	ReplicateStartAt time.Time `json:"replicate_start_at"`
+	RpoCompliant     *bool     `json:"rpo_compliant,omitempty"`
+	TestGuestId      string    `json:"test_guest_id"`
+	FailoverGuestId  string    `json:"failover_guest_id"`
+}
+
 // SDynamicschedtag is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SDynamicschedtag.
 type SDynamicschedtag struct {
 	apis.SStandaloneResourceBase
@@ -2479,9 +2584,10 @@ type SScalingTimer struct {
 type SSchedpolicy struct {
 	apis.SStandaloneResourceBase
 	SSchedtagResourceBase
-	Condition string `json:"condition"`
-	Strategy  string `json:"strategy"`
-	Enabled   *bool  `json:"enabled,omitempty"`
+	Condition    string `json:"condition"`
+	Strategy     string `json:"strategy"`
+	LoadPriority string `json:"load_priority"`
+	Enabled      *bool  `json:"enabled,omitempty"`
 }
 
 // SSchedtag is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSchedtag.
@@ -2509,6 +2615,8 @@ type SSchedtagResourceBase struct {
 type SSecurityGroup struct {
 	apis.SSharableVirtualResourceBase
 	IsDirty bool `json:"is_dirty"`
+	// 流日志记录模式
+	FlowLog string `json:"flow_log"`
 }
 
 // SSecurityGroupCache is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SSecurityGroupCache.
@@ -2682,6 +2790,14 @@ type SStorage struct {
 	// 是否可以用作系统盘存储
 	// example: true
 	IsSysDiskStore *bool `json:"is_sys_disk_store,omitempty"`
+	// 存储上磁盘的默认性能等级
+	DiskPerformanceClassId string `json:"disk_performance_class_id"`
+	// 实际使用容量日增长量, 单位Mb, 由容量采样拟合得出
+	UsageGrowthRate float64 `json:"usage_growth_rate"`
+	// 分配容量日增长量, 单位Mb, 由容量采样拟合得出
+	CommitGrowthRate float64 `json:"commit_growth_rate"`
+	// 最近一次容量趋势拟合时间
+	ForecastAt time.Time `json:"forecast_at"`
 }
 
 // SStorageResourceBase is an autogenerated struct via yunion.io/x/onecloud/pkg/compute/models.SStorageResourceBase.
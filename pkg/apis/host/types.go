@@ -37,3 +37,19 @@ type GuestSetPasswordRequest struct {
 	Password string `json:"password"`
 	Crypted  bool   `json:"crypted"`
 }
+
+type GuestFsfreezeResponse struct {
+	// filesystems of a stopped guest are quiesced already
+	Running bool `json:"running"`
+	// zero if guest filesystems are not frozen
+	Epoch int `json:"epoch"`
+}
+
+type GuestFsthawRequest struct {
+	Epoch int `json:"epoch"`
+}
+
+type GuestFsthawResponse struct {
+	// filesystems stayed frozen since the freeze
+	Consistent bool `json:"consistent"`
+}
@@ -65,6 +65,8 @@ const (
 	IMAGE_INSTALLED_CLOUDINIT = "installed_cloud_init"
 	IMAGE_DISABLE_USB_KBD     = "disable_usb_kbd"
 	IMAGE_VDI_PROTOCOL        = "vdi_protocol"
+	IMAGE_REQUIRE_VTPM        = "require_vtpm"
+	IMAGE_SECURE_BOOT         = "secure_boot"
 
 	IMAGE_STATUS_UPDATING = "updating"
 )
@@ -48,6 +48,7 @@ const (
 	METRIC_UNIT_SEC     = "s"
 	METRIC_UNIT_BYTE    = "byte"
 	METRIC_UNIT_MB      = "Mb"
+	METRIC_UNIT_DAY     = "d"
 	METRIC_UNIT_NULL    = "NULL"
 
 	METRIC_DATABASE_TELE  = "telegraf"
@@ -58,7 +59,7 @@ var (
 	MetricResType = []string{METRIC_RES_TYPE_GUEST, METRIC_RES_TYPE_HOST, METRIC_RES_TYPE_REDIS, METRIC_RES_TYPE_OSS,
 		METRIC_RES_TYPE_RDS, METRIC_RES_TYPE_CLOUDACCOUNT}
 	MetricUnit = []string{METRIC_UNIT_PERCENT, METRIC_UNIT_BPS, METRIC_UNIT_MBPS, METRIC_UNIT_BYTEPS, "count/s",
-		METRIC_UNIT_COUNT, METRIC_UNIT_MS, METRIC_UNIT_BYTE, METRIC_UNIT_NULL}
+		METRIC_UNIT_COUNT, METRIC_UNIT_MS, METRIC_UNIT_BYTE, METRIC_UNIT_DAY, METRIC_UNIT_NULL}
 	ResTypeScoreMap = map[string]float64{
 		METRIC_RES_TYPE_GUEST:        1,
 		METRIC_RES_TYPE_AGENT:        1.1,
@@ -85,6 +85,15 @@ type ScheduleInput struct {
 	CpuMicrocode string `json:"cpu_microcode"`
 	CpuMode      string `json:"cpu_mode"`
 	OsArch       string `json:"os_arch"`
+	// SecureBoot requires host with secure boot capable OVMF
+	SecureBoot bool `json:"secure_boot"`
+	// FileShareStorageIds are storages of guest virtio-fs shares, host must attach all of them
+	FileShareStorageIds []string `json:"file_share_storage_ids"`
+	// HostLocalFileShare guest shares host local directory and can't be placed on other host
+	HostLocalFileShare bool `json:"host_local_file_share"`
+
+	// LoadPriority choose how host load is scored, commit or metrics
+	LoadPriority string `json:"load_priority"`
 
 	HostMemPageSizeKB int    `json:"host_mem_page_size"`
 	SkipKernelCheck   *bool  `json:"skip_kernel_check"`
@@ -183,6 +183,8 @@ func ParseDiskConfig(diskStr string, idx int) (*compute.DiskConfig, error) {
 			diskConfig.DiskId = str
 		case "storage", "storage_id":
 			diskConfig.Storage = str
+		case "performance_class", "disk_performance_class_id":
+			diskConfig.PerformanceClass = str
 		case "image", "image_id":
 			diskConfig.ImageId = str
 		case "existing_path":
@@ -74,6 +74,9 @@ const (
 	ACT_MIGRATE      = "migrate"
 	ACT_MIGRATE_FAIL = "migrate_fail"
 
+	ACT_REBALANCE      = "rebalance"
+	ACT_REBALANCE_FAIL = "rebalance_fail"
+
 	ACT_VM_CONVERT      = "vm_convert"
 	ACT_VM_CONVERTING   = "vm_converting"
 	ACT_VM_CONVERT_FAIL = "vm_convert_fail"
@@ -123,6 +126,12 @@ const (
 	ACT_DISK_AUTO_SNAPSHOT           = "disk_auto_snapshot"
 	ACT_DISK_AUTO_SNAPSHOT_FAIL      = "disk_auto_snapshot_fail"
 
+	ACT_BACKUP_POLICY_RUN_FAIL = "backup_policy_run_fail"
+
+	ACT_DR_REPLICATE_FAIL = "dr_replicate_fail"
+	ACT_DR_RPO_VIOLATED   = "dr_rpo_violated"
+	ACT_DR_RPO_RECOVERED  = "dr_rpo_recovered"
+
 	ACT_DISK_AUTO_SYNC_SNAPSHOT      = "disk_auto_sync_snapshot"
 	ACT_DISK_AUTO_SYNC_SNAPSHOT_FAIL = "disk_auto_sync_snapshot_fail"
 
@@ -30,6 +30,7 @@ import (
 
 	"yunion.io/x/onecloud/pkg/apis"
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	host_api "yunion.io/x/onecloud/pkg/apis/host"
 	"yunion.io/x/onecloud/pkg/cloudcommon/consts"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/quotas"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
@@ -487,6 +488,10 @@ func (drv *SBaseGuestDriver) RequestSwitchToTargetStorageDisk(ctx context.Contex
 	return cloudprovider.ErrNotImplemented
 }
 
+func (drv *SBaseGuestDriver) RequestCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, diskId string, task taskman.ITask) error {
+	return cloudprovider.ErrNotImplemented
+}
+
 func (drv *SBaseGuestDriver) RequestSyncIsolatedDevice(ctx context.Context, guest *models.SGuest, task taskman.ITask) error {
 	task.ScheduleRun(nil)
 	return nil
@@ -504,6 +509,14 @@ func (drv *SBaseGuestDriver) QgaRequestGuestPing(ctx context.Context, header htt
 	return httperrors.ErrNotImplemented
 }
 
+func (drv *SBaseGuestDriver) QgaRequestFsfreezeFreeze(ctx context.Context, header http.Header, host *models.SHost, guest *models.SGuest) (*host_api.GuestFsfreezeResponse, error) {
+	return nil, httperrors.ErrNotImplemented
+}
+
+func (drv *SBaseGuestDriver) QgaRequestFsfreezeThaw(ctx context.Context, header http.Header, host *models.SHost, guest *models.SGuest, epoch int) (bool, error) {
+	return false, httperrors.ErrNotImplemented
+}
+
 func (drv *SBaseGuestDriver) QgaRequestSetUserPassword(ctx context.Context, task taskman.ITask, host *models.SHost, guest *models.SGuest, input *api.ServerQgaSetPasswordInput) error {
 	return httperrors.ErrNotImplemented
 }
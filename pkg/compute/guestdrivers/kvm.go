@@ -92,12 +92,16 @@ func (self *SKVMGuestDriver) GetInstanceCapability() cloudprovider.SInstanceCapa
 				{StorageType: api.STORAGE_RBD, MinSizeGb: options.Options.LocalSysDiskMinSizeGB, MaxSizeGb: options.Options.LocalSysDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 				{StorageType: api.STORAGE_NFS, MinSizeGb: options.Options.LocalSysDiskMinSizeGB, MaxSizeGb: options.Options.LocalSysDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 				{StorageType: api.STORAGE_GPFS, MinSizeGb: options.Options.LocalSysDiskMinSizeGB, MaxSizeGb: options.Options.LocalSysDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
+				{StorageType: api.STORAGE_CIFS, MinSizeGb: options.Options.LocalSysDiskMinSizeGB, MaxSizeGb: options.Options.LocalSysDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
+				{StorageType: api.STORAGE_SLVM, MinSizeGb: options.Options.LocalSysDiskMinSizeGB, MaxSizeGb: options.Options.LocalSysDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 			},
 			DataDisk: []cloudprovider.StorageInfo{
 				{StorageType: api.STORAGE_LOCAL, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 				{StorageType: api.STORAGE_RBD, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 				{StorageType: api.STORAGE_NFS, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 				{StorageType: api.STORAGE_GPFS, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
+				{StorageType: api.STORAGE_CIFS, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
+				{StorageType: api.STORAGE_SLVM, MinSizeGb: options.Options.LocalDataDiskMinSizeGB, MaxSizeGb: options.Options.LocalDataDiskMaxSizeGB, StepSizeGb: 1, Resizable: true},
 			},
 		},
 	}
@@ -135,6 +139,7 @@ func (self *SKVMGuestDriver) RequestDiskSnapshot(ctx context.Context, guest *mod
 	body := jsonutils.NewDict()
 	body.Set("disk_id", jsonutils.NewString(diskId))
 	body.Set("snapshot_id", jsonutils.NewString(snapshotId))
+	body.Set("consistent", jsonutils.NewBool(jsonutils.QueryBoolean(task.GetParams(), "consistent", false)))
 	header := self.getTaskRequestHeader(task)
 	_, _, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
 	return err
@@ -811,6 +816,15 @@ func (self *SKVMGuestDriver) CheckMigrate(ctx context.Context, guest *models.SGu
 	if len(devices) > 0 {
 		return httperrors.NewBadRequestError("Cannot migrate with isolated devices")
 	}
+	shares, err := guest.GetFileShares()
+	if err != nil {
+		return errors.Wrapf(err, "GetFileShares")
+	}
+	for i := range shares {
+		if len(shares[i].StorageId) == 0 {
+			return httperrors.NewBadRequestError("Cannot migrate with host local file share %s", shares[i].Name)
+		}
+	}
 	if len(input.PreferHostId) > 0 {
 		err := checkAssignHost(ctx, userCred, input.PreferHostId)
 		if err != nil {
@@ -839,6 +853,14 @@ func (self *SKVMGuestDriver) CheckLiveMigrate(ctx context.Context, guest *models
 		if len(devices) > 0 {
 			return httperrors.NewBadRequestError("Cannot live migrate with isolated devices")
 		}
+		// vhost-user-fs device state is not migratable
+		shares, err := guest.GetFileShares()
+		if err != nil {
+			return errors.Wrapf(err, "GetFileShares")
+		}
+		if len(shares) > 0 {
+			return httperrors.NewBadRequestError("Cannot live migrate with file shares")
+		}
 		if !guest.CheckQemuVersion(guest.GetQemuVersion(userCred), "1.1.2") {
 			return httperrors.NewBadRequestError("Cannot do live migrate, too low qemu version")
 		}
@@ -923,6 +945,22 @@ func (self *SKVMGuestDriver) RequestSwitchToTargetStorageDisk(ctx context.Contex
 	return err
 }
 
+func (self *SKVMGuestDriver) RequestCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, guest *models.SGuest, diskId string, task taskman.ITask) error {
+	host, err := guest.GetHost()
+	if err != nil {
+		return err
+	}
+	body := jsonutils.NewDict()
+	body.Set("disk_id", jsonutils.NewString(diskId))
+	header := mcclient.GetTokenHeaders(userCred)
+	if task != nil {
+		header = self.getTaskRequestHeader(task)
+	}
+	url := fmt.Sprintf("%s/servers/%s/cancel-storage-clone-disk", host.ManagerUri, guest.GetId())
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
+	return err
+}
+
 func (self *SKVMGuestDriver) validateVdiProtocol(vdi string) error {
 	if !utils.IsInStringArray(vdi, []string{api.VM_VDI_PROTOCOL_VNC, api.VM_VDI_PROTOCOL_SPICE}) {
 		return httperrors.NewInputParameterError("unsupported vdi protocol %s", vdi)
@@ -1071,6 +1109,33 @@ func (self *SKVMGuestDriver) QgaRequestGuestPing(ctx context.Context, header htt
 	return nil
 }
 
+func (self *SKVMGuestDriver) QgaRequestFsfreezeFreeze(ctx context.Context, header http.Header, host *models.SHost, guest *models.SGuest) (*host_api.GuestFsfreezeResponse, error) {
+	url := fmt.Sprintf("%s/servers/%s/fsfreeze-freeze", host.ManagerUri, guest.Id)
+	_, res, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, jsonutils.NewDict(), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "host request")
+	}
+	resp := new(host_api.GuestFsfreezeResponse)
+	if err := res.Unmarshal(resp); err != nil {
+		return nil, errors.Wrap(err, "unmarshal response")
+	}
+	return resp, nil
+}
+
+func (self *SKVMGuestDriver) QgaRequestFsfreezeThaw(ctx context.Context, header http.Header, host *models.SHost, guest *models.SGuest, epoch int) (bool, error) {
+	url := fmt.Sprintf("%s/servers/%s/fsfreeze-thaw", host.ManagerUri, guest.Id)
+	body := jsonutils.Marshal(&host_api.GuestFsthawRequest{Epoch: epoch})
+	_, res, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
+	if err != nil {
+		return false, errors.Wrap(err, "host request")
+	}
+	resp := new(host_api.GuestFsthawResponse)
+	if err := res.Unmarshal(resp); err != nil {
+		return false, errors.Wrap(err, "unmarshal response")
+	}
+	return resp.Consistent, nil
+}
+
 func (self *SKVMGuestDriver) QgaRequestGuestInfoTask(ctx context.Context, userCred mcclient.TokenCredential, body jsonutils.JSONObject, host *models.SHost, guest *models.SGuest) (jsonutils.JSONObject, error) {
 	url := fmt.Sprintf("%s/servers/%s/qga-guest-info-task", host.ManagerUri, guest.Id)
 	httpClient := httputils.GetDefaultClient()
@@ -69,6 +69,12 @@ func (self *SKVMHostDriver) ValidateAttachStorage(ctx context.Context, userCred
 		}
 		pool, _ := storage.StorageConf.GetString("pool")
 		input.MountPoint = fmt.Sprintf("rbd:%s", pool)
+	} else if storage.StorageType == api.STORAGE_SLVM {
+		if host.HostStatus != api.HOST_ONLINE {
+			return input, httperrors.NewInvalidStatusError("Attach shared lvm storage require host status is online")
+		}
+		// vg name is used as mount point, same as local lvm storage
+		input.MountPoint, _ = storage.StorageConf.GetString("slvm_vg_name")
 	} else if utils.IsInStringArray(storage.StorageType, api.SHARED_FILE_STORAGE) {
 		if len(input.MountPoint) == 0 {
 			return input, httperrors.NewMissingParameterError("mount_point")
@@ -81,7 +87,7 @@ func (self *SKVMHostDriver) ValidateAttachStorage(ctx context.Context, userCred
 			return input, httperrors.NewBadRequestError("Host %s already have mount point %s with other storage", host.Name, input.MountPoint)
 		}
 		if host.HostStatus != api.HOST_ONLINE {
-			return input, httperrors.NewInvalidStatusError("Attach nfs storage require host status is online")
+			return input, httperrors.NewInvalidStatusError("Attach %s storage require host status is online", storage.StorageType)
 		}
 		if storage.StorageType == api.STORAGE_GPFS {
 			header := http.Header{}
@@ -270,6 +276,13 @@ func (self *SKVMHostDriver) RequestAllocateDiskOnStorage(ctx context.Context, us
 			input.SnapshotUrl = snapshot.Id
 			input.SrcDiskId = snapshot.DiskId
 			input.SrcPool, _ = snapshotStorage.StorageConf.GetString("pool")
+		} else if snapshotStorage.StorageType == api.STORAGE_LVM {
+			// lvm thin snapshot is cloned in the thin pool it belongs to
+			if snapshotStorage.Id != storage.Id {
+				return errors.Errorf("lvm snapshot %s could only create disk on storage %s", snapshot.Id, snapshotStorage.Name)
+			}
+			input.SnapshotUrl = snapshot.Id
+			input.SrcDiskId = snapshot.DiskId
 		} else {
 			input.SnapshotUrl = snapshot.Location
 		}
@@ -333,6 +346,12 @@ func (self *SKVMHostDriver) RequestResizeDiskOnHost(ctx context.Context, host *m
 	guest := disk.GetGuest()
 	if guest != nil {
 		content.Add(jsonutils.NewString(guest.Id), "server_id")
+		// limits scaled with size are re-applied once running guest is resized
+		if gd := guest.GetGuestDisk(disk.Id); gd != nil {
+			if throttle := gd.GetDiskPerformanceThrottle(disk, int(sizeMb)); throttle != nil {
+				content.Add(jsonutils.Marshal(throttle), "io_throttle")
+			}
+		}
 	}
 	body.Add(content, "disk")
 	_, err := host.Request(ctx, task.GetUserCred(), "POST", url, header, body)
@@ -16,11 +16,14 @@ package models
 
 import (
 	"context"
+	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/gotypes"
 	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
@@ -65,6 +68,12 @@ func init() {
 type SBackupStorageAccessInfo struct {
 	NfsHost      string `json:"nfs_host"`
 	NfsSharedDir string `json:"nfs_shared_dir"`
+
+	ObjectBucketUrl string `json:"object_bucket_url"`
+	ObjectAccessKey string `json:"object_access_key"`
+	ObjectSecret    string `json:"object_secret"`
+
+	BackupFormat string `json:"backup_format"`
 }
 
 func (ba *SBackupStorageAccessInfo) String() string {
@@ -81,9 +90,15 @@ func (bs *SBackupStorageManager) ValidateCreateData(ctx context.Context, userCre
 	if err != nil {
 		return input, err
 	}
-	if !utils.IsInStringArray(input.StorageType, []string{api.BACKUPSTORAGE_TYPE_NFS}) {
+	if !utils.IsInStringArray(input.StorageType, []string{api.BACKUPSTORAGE_TYPE_NFS, api.BACKUPSTORAGE_TYPE_OBJECT}) {
 		return input, httperrors.NewInputParameterError("Invalid storage type %s", input.StorageType)
 	}
+	if input.BackupFormat == "" {
+		input.BackupFormat = api.BACKUPSTORAGE_FORMAT_FILE
+	}
+	if !utils.IsInStringArray(input.BackupFormat, []string{api.BACKUPSTORAGE_FORMAT_FILE, api.BACKUPSTORAGE_FORMAT_CHUNK}) {
+		return input, httperrors.NewInputParameterError("Invalid backup format %s", input.BackupFormat)
+	}
 	switch input.StorageType {
 	case api.BACKUPSTORAGE_TYPE_NFS:
 		if input.NfsHost == "" {
@@ -92,18 +107,41 @@ func (bs *SBackupStorageManager) ValidateCreateData(ctx context.Context, userCre
 		if input.NfsSharedDir == "" {
 			return input, httperrors.NewInputParameterError("nfs_shared_dir is required when storage type is nfs")
 		}
+	case api.BACKUPSTORAGE_TYPE_OBJECT:
+		if input.ObjectBucketUrl == "" {
+			return input, httperrors.NewInputParameterError("object_bucket_url is required when storage type is object")
+		}
+		bucketUrl, err := url.Parse(input.ObjectBucketUrl)
+		if err != nil || !utils.IsInStringArray(bucketUrl.Scheme, []string{"http", "https"}) || len(bucketUrl.Host) == 0 {
+			return input, httperrors.NewInputParameterError("invalid object_bucket_url %s", input.ObjectBucketUrl)
+		}
+		if len(strings.Trim(bucketUrl.Path, "/")) == 0 {
+			return input, httperrors.NewInputParameterError("bucket name is missing in object_bucket_url %s", input.ObjectBucketUrl)
+		}
+		if input.ObjectAccessKey == "" {
+			return input, httperrors.NewInputParameterError("object_access_key is required when storage type is object")
+		}
+		if input.ObjectSecret == "" {
+			return input, httperrors.NewInputParameterError("object_secret is required when storage type is object")
+		}
 	}
 	return input, nil
 }
 
 func (bs *SBackupStorage) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
 	bs.SetEnabled(true)
-	nfsHost, _ := data.GetString("nfs_host")
-	nfsSharedDir, _ := data.GetString("nfs_shared_dir")
+	input := api.BackupStorageCreateInput{}
+	data.Unmarshal(&input)
 	bs.Status = api.BACKUPSTORAGE_STATUS_ONLINE
 	bs.AccessInfo = &SBackupStorageAccessInfo{
-		NfsHost:      nfsHost,
-		NfsSharedDir: nfsSharedDir,
+		NfsHost:      input.NfsHost,
+		NfsSharedDir: input.NfsSharedDir,
+
+		ObjectBucketUrl: input.ObjectBucketUrl,
+		ObjectAccessKey: input.ObjectAccessKey,
+		ObjectSecret:    input.ObjectSecret,
+
+		BackupFormat: input.BackupFormat,
 	}
 	return bs.SEnabledStatusInfrasResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
 }
@@ -120,11 +158,55 @@ func (bs *SBackupStorage) ValidateDeleteCondition(ctx context.Context, info json
 	if cnt > 0 {
 		return httperrors.NewNotEmptyError("storage has backup")
 	}
+	cnt, err = BackupPolicyManager.Query().Equals("backup_storage_id", bs.GetId()).CountWithError()
+	if err != nil {
+		return httperrors.NewInternalServerError("count backup policies fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("storage is target of %d backup policies", cnt)
+	}
 	return bs.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx, nil)
 }
 
+// saveObjectSecret encrypts object secret by id of backup storage, like
+// cloudaccount secret, it is decrypted only for requests to host
+func (bs *SBackupStorage) saveObjectSecret(secret string) error {
+	sec, err := utils.EncryptAESBase64(bs.Id, secret)
+	if err != nil {
+		return err
+	}
+	accessInfo := *bs.AccessInfo
+	accessInfo.ObjectSecret = sec
+	_, err = db.Update(bs, func() error {
+		bs.AccessInfo = &accessInfo
+		return nil
+	})
+	return err
+}
+
+// GetAccessInfo returns access info with decrypted object secret for host
+func (bs *SBackupStorage) GetAccessInfo() (*SBackupStorageAccessInfo, error) {
+	if bs.AccessInfo == nil {
+		return nil, errors.Errorf("backup storage %s has no access info", bs.Name)
+	}
+	accessInfo := *bs.AccessInfo
+	if len(accessInfo.ObjectSecret) > 0 {
+		secret, err := utils.DescryptAESBase64(bs.Id, accessInfo.ObjectSecret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decrypt object secret of backup storage %s", bs.Name)
+		}
+		accessInfo.ObjectSecret = secret
+	}
+	return &accessInfo, nil
+}
+
 func (bs *SBackupStorage) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	bs.SEnabledStatusInfrasResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	if bs.AccessInfo != nil && len(bs.AccessInfo.ObjectSecret) > 0 {
+		if err := bs.saveObjectSecret(bs.AccessInfo.ObjectSecret); err != nil {
+			log.Errorf("unable to save object secret of backup storage %s: %v", bs.Name, err)
+		}
+	}
 	err := StartResourceSyncStatusTask(ctx, userCred, bs, "BackupStorageSyncstatusTask", "")
 	if err != nil {
 		log.Errorf("unable to sync backup storage status")
@@ -135,6 +217,12 @@ func (bs *SBackupStorage) PostCreate(ctx context.Context, userCred mcclient.Toke
 func (bs *SBackupStorage) getMoreDetails(ctx context.Context, out api.BackupStorageDetails) api.BackupStorageDetails {
 	out.NfsHost = bs.AccessInfo.NfsHost
 	out.NfsSharedDir = bs.AccessInfo.NfsSharedDir
+	out.ObjectBucketUrl = bs.AccessInfo.ObjectBucketUrl
+	out.ObjectAccessKey = bs.AccessInfo.ObjectAccessKey
+	out.BackupFormat = bs.AccessInfo.BackupFormat
+	if out.BackupFormat == "" {
+		out.BackupFormat = api.BACKUPSTORAGE_FORMAT_FILE
+	}
 	return out
 }
 
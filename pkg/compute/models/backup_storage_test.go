@@ -0,0 +1,75 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"yunion.io/x/pkg/utils"
+)
+
+func TestBackupStorageGetAccessInfo(t *testing.T) {
+	const secret = "object-secret"
+	encrypted, err := utils.EncryptAESBase64("bs0", secret)
+	if err != nil {
+		t.Fatalf("EncryptAESBase64: %v", err)
+	}
+	cases := []struct {
+		name       string
+		id         string
+		accessInfo *SBackupStorageAccessInfo
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "nfs without secret",
+			id:         "bs0",
+			accessInfo: &SBackupStorageAccessInfo{NfsHost: "192.168.0.1", NfsSharedDir: "/backup"},
+		},
+		{
+			name:       "encrypted secret",
+			id:         "bs0",
+			accessInfo: &SBackupStorageAccessInfo{ObjectAccessKey: "ak", ObjectSecret: encrypted},
+			want:       secret,
+		},
+		{
+			name:    "no access info",
+			id:      "bs0",
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bs := &SBackupStorage{AccessInfo: c.accessInfo}
+			bs.Id = c.id
+			accessInfo, err := bs.GetAccessInfo()
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("want error, got secret %q", accessInfo.ObjectSecret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAccessInfo: %v", err)
+			}
+			if accessInfo.ObjectSecret != c.want {
+				t.Errorf("got secret %q, want %q", accessInfo.ObjectSecret, c.want)
+			}
+			if bs.AccessInfo.ObjectSecret != c.accessInfo.ObjectSecret || (len(c.want) > 0 && bs.AccessInfo.ObjectSecret == c.want) {
+				t.Errorf("stored secret should stay encrypted, got %q", bs.AccessInfo.ObjectSecret)
+			}
+		})
+	}
+}
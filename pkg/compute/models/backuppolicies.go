@@ -0,0 +1,669 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/tristate"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/bitmap"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SBackupPolicyManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+// SBackupPolicy creates disk backups or instance backups of bound resources
+// on schedule and prunes the ones out of retention
+type SBackupPolicy struct {
+	db.SVirtualResourceBase
+
+	// 1~7, 1 is Monday
+	RepeatWeekdays uint8 `nullable:"false" list:"user" get:"user" create:"required" update:"user"`
+	// 0~23
+	TimePoints uint32 `nullable:"false" list:"user" get:"user" create:"required" update:"user"`
+
+	// 保留最近的备份个数
+	RetentionCount int `nullable:"false" default:"0" list:"user" get:"user" create:"optional" update:"user"`
+	// 保留最近天数内的备份
+	RetentionDays int `nullable:"false" default:"0" list:"user" get:"user" create:"optional" update:"user"`
+	// 保留最近天数每天的最后一个备份
+	KeepDaily int `nullable:"false" default:"0" list:"user" get:"user" create:"optional" update:"user"`
+	// 保留最近周数每周的最后一个备份
+	KeepWeekly int `nullable:"false" default:"0" list:"user" get:"user" create:"optional" update:"user"`
+	// 保留最近月数每月的最后一个备份
+	KeepMonthly int `nullable:"false" default:"0" list:"user" get:"user" create:"optional" update:"user"`
+
+	BackupStorageId string `width:"36" charset:"ascii" nullable:"false" list:"user" get:"user" create:"required" update:"user" index:"true"`
+
+	Incremental bool              `nullable:"false" default:"false" list:"user" get:"user" create:"optional" update:"user"`
+	Consistent  bool              `nullable:"false" default:"false" list:"user" get:"user" create:"optional" update:"user"`
+	IsActivated tristate.TriState `list:"user" get:"user" create:"optional" update:"user" default:"true"`
+}
+
+var BackupPolicyManager *SBackupPolicyManager
+
+func init() {
+	BackupPolicyManager = &SBackupPolicyManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SBackupPolicy{},
+			"backuppolicies_tbl",
+			"backuppolicy",
+			"backuppolicies",
+		),
+	}
+	BackupPolicyManager.SetVirtualObject(BackupPolicyManager)
+}
+
+func validateBackupPolicyStorage(userCred mcclient.TokenCredential, backupStorageId string) (*SBackupStorage, error) {
+	ibs, err := BackupStorageManager.FetchByIdOrName(userCred, backupStorageId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError2(BackupStorageManager.Keyword(), backupStorageId)
+		}
+		if errors.Cause(err) == sqlchemy.ErrDuplicateEntry {
+			return nil, httperrors.NewDuplicateResourceError(BackupStorageManager.Keyword(), backupStorageId)
+		}
+		return nil, httperrors.NewGeneralError(err)
+	}
+	return ibs.(*SBackupStorage), nil
+}
+
+func validateBackupPolicyRetention(values ...int) error {
+	for _, v := range values {
+		if v < 0 {
+			return httperrors.NewInputParameterError("retention value should not be negative")
+		}
+	}
+	return nil
+}
+
+func (manager *SBackupPolicyManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.BackupPolicyCreateInput,
+) (*jsonutils.JSONDict, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return nil, err
+	}
+	repeatWeekdays, err := input.GetRepeatWeekdays()
+	if err != nil {
+		return nil, err
+	}
+	timePoints, err := input.GetTimePoints()
+	if err != nil {
+		return nil, err
+	}
+	err = validateBackupPolicyRetention(input.RetentionCount, input.RetentionDays, input.KeepDaily, input.KeepWeekly, input.KeepMonthly)
+	if err != nil {
+		return nil, err
+	}
+	if len(input.BackupStorageId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_storage_id")
+	}
+	bs, err := validateBackupPolicyStorage(userCred, input.BackupStorageId)
+	if err != nil {
+		return nil, err
+	}
+	input.BackupStorageId = bs.Id
+	input.Status = api.BACKUP_POLICY_STATUS_READY
+
+	ret := input.JSON(input)
+	ret.Set("repeat_weekdays", jsonutils.NewInt(int64(repeatWeekdays)))
+	ret.Set("time_points", jsonutils.NewInt(int64(timePoints)))
+	return ret, nil
+}
+
+func (self *SBackupPolicy) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.BackupPolicyUpdateInput,
+) (*jsonutils.JSONDict, error) {
+	var err error
+	input.VirtualResourceBaseUpdateInput, err = self.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBase.ValidateUpdateData")
+	}
+	for _, v := range []*int{input.RetentionCount, input.RetentionDays, input.KeepDaily, input.KeepWeekly, input.KeepMonthly} {
+		if v != nil {
+			if err := validateBackupPolicyRetention(*v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(input.BackupStorageId) > 0 {
+		bs, err := validateBackupPolicyStorage(userCred, input.BackupStorageId)
+		if err != nil {
+			return nil, err
+		}
+		input.BackupStorageId = bs.Id
+	}
+	schedule := api.BackupPolicyCreateInput{
+		RepeatWeekdays: input.RepeatWeekdays,
+		TimePoints:     input.TimePoints,
+	}
+	ret := input.JSON(input)
+	if len(input.RepeatWeekdays) > 0 {
+		repeatWeekdays, err := schedule.GetRepeatWeekdays()
+		if err != nil {
+			return nil, err
+		}
+		ret.Set("repeat_weekdays", jsonutils.NewInt(int64(repeatWeekdays)))
+	} else {
+		ret.Remove("repeat_weekdays")
+	}
+	if len(input.TimePoints) > 0 {
+		timePoints, err := schedule.GetTimePoints()
+		if err != nil {
+			return nil, err
+		}
+		ret.Set("time_points", jsonutils.NewInt(int64(timePoints)))
+	} else {
+		ret.Remove("time_points")
+	}
+	return ret, nil
+}
+
+func (self *SBackupPolicy) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SVirtualResourceBase.PostUpdate(ctx, userCred, query, data)
+	if data.Contains("repeat_weekdays") || data.Contains("time_points") {
+		// schedule changed, reschedule next runs of bound resources
+		err := BackupPolicyResourceManager.resetNextRunTime(self, time.Now())
+		if err != nil {
+			log.Errorf("reset next run time of backup policy %s: %s", self.Name, err)
+		}
+	}
+}
+
+func (manager *SBackupPolicyManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.BackupPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.BackupStorageId) > 0 {
+		bs, err := validateBackupPolicyStorage(userCred, query.BackupStorageId)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("backup_storage_id", bs.Id)
+	}
+	if query.IsActivated != nil {
+		if *query.IsActivated {
+			q = q.IsTrue("is_activated")
+		} else {
+			q = q.IsFalse("is_activated")
+		}
+	}
+	return q, nil
+}
+
+func (manager *SBackupPolicyManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.BackupPolicyListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+}
+
+func (manager *SBackupPolicyManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (manager *SBackupPolicyManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.BackupPolicyDetails {
+	rows := make([]api.BackupPolicyDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	bsIds := make([]string, len(objs))
+	for i := range rows {
+		bp := objs[i].(*SBackupPolicy)
+		rows[i] = api.BackupPolicyDetails{
+			VirtualResourceDetails: virtRows[i],
+			RepeatWeekdaysDisplay:  bitmap.Uint2IntArray(uint32(bp.RepeatWeekdays)),
+			TimePointsDisplay:      bitmap.Uint2IntArray(bp.TimePoints),
+		}
+		rows[i].BindingDiskCount, _ = BackupPolicyResourceManager.fetchBindingCount(bp.Id, api.BACKUP_POLICY_RESOURCE_DISK)
+		rows[i].BindingServerCount, _ = BackupPolicyResourceManager.fetchBindingCount(bp.Id, api.BACKUP_POLICY_RESOURCE_SERVER)
+		bsIds[i] = bp.BackupStorageId
+	}
+	bsNames, err := db.FetchIdNameMap2(BackupStorageManager, bsIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		rows[i].BackupStorage = bsNames[bsIds[i]]
+	}
+	return rows
+}
+
+func (self *SBackupPolicy) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := BackupPolicyResourceManager.Query().Equals("backuppolicy_id", self.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count binding resources")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("backup policy is bound to %d resources", cnt)
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SBackupPolicy) GetBackupStorage() (*SBackupStorage, error) {
+	bs, err := BackupStorageManager.FetchById(self.BackupStorageId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch backup storage %s", self.BackupStorageId)
+	}
+	return bs.(*SBackupStorage), nil
+}
+
+// ComputeNextRunTime returns the first scheduled time after base
+func (self *SBackupPolicy) ComputeNextRunTime(base time.Time) time.Time {
+	weekDays := bitmap.Uint2IntArray(uint32(self.RepeatWeekdays))
+	timePoints := bitmap.Uint2IntArray(self.TimePoints)
+	return computeNextSyncTime(weekDays, timePoints, base)
+}
+
+// PerformBind binds disks or servers to backup policy, a resource is bound to
+// one backup policy at most
+func (self *SBackupPolicy) PerformBind(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.BackupPolicyBindInput) (jsonutils.JSONObject, error) {
+	if len(input.Disks) == 0 && len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("disks or servers")
+	}
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	diskIds := make([]string, 0, len(input.Disks))
+	for i := range input.Disks {
+		diskObj, err := validators.ValidateModel(userCred, DiskManager, &input.Disks[i])
+		if err != nil {
+			return nil, err
+		}
+		disk := diskObj.(*SDisk)
+		storage, err := disk.GetStorage()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetStorage of disk %s", disk.Name)
+		}
+		if len(storage.ManagerId) > 0 {
+			return nil, httperrors.NewUnsupportOperationError("backup of managed disk %s is not supported", disk.Name)
+		}
+		diskIds = append(diskIds, disk.Id)
+	}
+	serverIds := make([]string, 0, len(input.Servers))
+	for i := range input.Servers {
+		guestObj, err := validators.ValidateModel(userCred, GuestManager, &input.Servers[i])
+		if err != nil {
+			return nil, err
+		}
+		guest := guestObj.(*SGuest)
+		if guest.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("instance backup of %s server %s is not supported", guest.Hypervisor, guest.Name)
+		}
+		serverIds = append(serverIds, guest.Id)
+	}
+
+	now := time.Now()
+	for _, id := range diskIds {
+		err := BackupPolicyResourceManager.bind(ctx, self, api.BACKUP_POLICY_RESOURCE_DISK, id, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range serverIds {
+		err := BackupPolicyResourceManager.bind(ctx, self, api.BACKUP_POLICY_RESOURCE_SERVER, id, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_BIND, input, userCred, true)
+	return nil, nil
+}
+
+func (self *SBackupPolicy) PerformUnbind(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.BackupPolicyUnbindInput) (jsonutils.JSONObject, error) {
+	if len(input.Disks) == 0 && len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("disks or servers")
+	}
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	for resType, ids := range map[string][]string{
+		api.BACKUP_POLICY_RESOURCE_DISK:   input.Disks,
+		api.BACKUP_POLICY_RESOURCE_SERVER: input.Servers,
+	} {
+		for _, id := range ids {
+			binding, err := BackupPolicyResourceManager.fetchBinding(self.Id, resType, id)
+			if err != nil {
+				return nil, err
+			}
+			err = binding.Delete(ctx, userCred)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unbind %s %s", resType, id)
+			}
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UNBIND, input, userCred, true)
+	return nil, nil
+}
+
+// AutoBackup starts backups of resources whose schedule is due and prunes
+// backups out of retention, it runs every hour as backup policy time points
+// are in hours
+func (manager *SBackupPolicyManager) AutoBackup(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	BackupPolicyRunManager.SyncRunningRuns(ctx, userCred, isStart)
+
+	now := time.Now()
+	bindings, err := BackupPolicyResourceManager.fetchDueBindings(now)
+	if err != nil {
+		log.Errorf("fetch due backup policy bindings: %s", err)
+		return
+	}
+	for i := range bindings {
+		policy, err := bindings[i].GetBackupPolicy()
+		if err != nil {
+			log.Errorf("GetBackupPolicy of binding %s: %s", bindings[i].Id, err)
+			continue
+		}
+		policy.runBackup(ctx, userCred, &bindings[i], now)
+	}
+
+	policies := make([]SBackupPolicy, 0)
+	err = db.FetchModelObjects(manager, manager.Query(), &policies)
+	if err != nil {
+		log.Errorf("fetch backup policies: %s", err)
+		return
+	}
+	for i := range policies {
+		policies[i].pruneBackups(ctx, userCred, now)
+	}
+}
+
+func (self *SBackupPolicy) runBackup(ctx context.Context, userCred mcclient.TokenCredential, binding *SBackupPolicyResource, now time.Time) {
+	err := binding.setNextRunTime(self.ComputeNextRunTime(now))
+	if err != nil {
+		log.Errorf("set next run time of backup policy binding %s: %s", binding.Id, err)
+		return
+	}
+	running, err := BackupPolicyRunManager.Query().Equals("backuppolicy_id", self.Id).
+		Equals("resource_id", binding.ResourceId).
+		Equals("status", api.BACKUP_POLICY_RUN_STATUS_RUNNING).CountWithError()
+	if err != nil {
+		log.Errorf("count running backups of %s %s: %s", binding.ResourceType, binding.ResourceId, err)
+		return
+	}
+	if running > 0 {
+		// backups pile up if one takes longer than schedule interval
+		log.Warningf("last backup of %s %s by policy %s is still running, skip", binding.ResourceType, binding.ResourceId, self.Name)
+		return
+	}
+
+	var backupId, backupName string
+	switch binding.ResourceType {
+	case api.BACKUP_POLICY_RESOURCE_DISK:
+		backupId, backupName, err = self.createDiskBackup(ctx, userCred, binding.ResourceId, now)
+	case api.BACKUP_POLICY_RESOURCE_SERVER:
+		backupId, backupName, err = self.createInstanceBackup(ctx, userCred, binding.ResourceId, now)
+	default:
+		err = errors.Wrapf(httperrors.ErrNotSupported, "resource type %s", binding.ResourceType)
+	}
+	if errors.Cause(err) == errors.ErrNotFound {
+		log.Infof("%s %s of backup policy %s is gone, unbind it", binding.ResourceType, binding.ResourceId, self.Name)
+		binding.Delete(ctx, userCred)
+		return
+	}
+	run, rerr := BackupPolicyRunManager.createRun(ctx, self, binding, backupId, backupName)
+	if rerr != nil {
+		log.Errorf("create backup policy run: %s", rerr)
+		return
+	}
+	if err != nil {
+		run.setResult(ctx, userCred, api.BACKUP_POLICY_RUN_STATUS_FAILED, err.Error())
+	}
+}
+
+func generateBackupPolicyBackupName(resName string, now time.Time) string {
+	return fmt.Sprintf("%s-auto-%s", resName, now.Format("20060102150405"))
+}
+
+func (self *SBackupPolicy) validateBackupStorage() error {
+	bs, err := self.GetBackupStorage()
+	if err != nil {
+		return err
+	}
+	if bs.Status != api.BACKUPSTORAGE_STATUS_ONLINE {
+		return fmt.Errorf("backup storage %s is %s", bs.Name, bs.Status)
+	}
+	return nil
+}
+
+func (self *SBackupPolicy) createDiskBackup(ctx context.Context, userCred mcclient.TokenCredential, diskId string, now time.Time) (string, string, error) {
+	disk := DiskManager.FetchDiskById(diskId)
+	if disk == nil {
+		return "", "", errors.Wrapf(errors.ErrNotFound, "disk %s", diskId)
+	}
+	if err := self.validateBackupStorage(); err != nil {
+		return "", "", err
+	}
+	if disk.Status != api.DISK_READY {
+		return "", "", fmt.Errorf("disk %s status is %s", disk.Name, disk.Status)
+	}
+	name, err := db.GenerateName(ctx, DiskBackupManager, disk.GetOwnerId(), generateBackupPolicyBackupName(disk.Name, now))
+	if err != nil {
+		return "", "", errors.Wrap(err, "GenerateName")
+	}
+	backup, err := DiskBackupManager.CreateBackup(ctx, disk.GetOwnerId(), disk.Id, self.BackupStorageId, name)
+	if err != nil {
+		return "", "", errors.Wrap(err, "CreateBackup")
+	}
+	err = disk.InheritTo(ctx, userCred, backup)
+	if err != nil {
+		log.Errorf("unable to inherit from disk %s to backup %s: %s", disk.Id, backup.Id, err)
+	}
+	params := jsonutils.NewDict()
+	// fallback to full backup silently if disk can't be backed up incrementally
+	if self.Incremental && disk.validateIncrementalBackup() == nil {
+		params.Set("incremental", jsonutils.JSONTrue)
+	}
+	err = backup.StartBackupCreateTask(ctx, userCred, params, "")
+	if err != nil {
+		return backup.Id, name, errors.Wrap(err, "StartBackupCreateTask")
+	}
+	return backup.Id, name, nil
+}
+
+func (self *SBackupPolicy) createInstanceBackup(ctx context.Context, userCred mcclient.TokenCredential, guestId string, now time.Time) (string, string, error) {
+	guest := GuestManager.FetchGuestById(guestId)
+	if guest == nil {
+		return "", "", errors.Wrapf(errors.ErrNotFound, "server %s", guestId)
+	}
+	if err := self.validateBackupStorage(); err != nil {
+		return "", "", err
+	}
+	lockman.LockClass(ctx, InstanceSnapshotManager, guest.ProjectId)
+	defer lockman.ReleaseClass(ctx, InstanceSnapshotManager, guest.ProjectId)
+
+	name, err := db.GenerateName(ctx, InstanceBackupManager, guest.GetOwnerId(), generateBackupPolicyBackupName(guest.Name, now))
+	if err != nil {
+		return "", "", errors.Wrap(err, "GenerateName")
+	}
+	data := jsonutils.NewDict()
+	data.Set("name", jsonutils.NewString(name))
+	err = guest.validateCreateInstanceBackup(ctx, userCred, jsonutils.NewDict(), data)
+	if err != nil {
+		return "", "", err
+	}
+	ib, err := InstanceBackupManager.CreateInstanceBackup(ctx, userCred, guest, name, self.BackupStorageId)
+	if err != nil {
+		return "", "", errors.Wrap(err, "CreateInstanceBackup")
+	}
+	err = guest.InheritTo(ctx, userCred, ib)
+	if err != nil {
+		log.Errorf("unable to inherit from guest %s to instance backup %s: %s", guest.Id, ib.Id, err)
+	}
+	err = guest.InstanceCreateBackup(ctx, userCred, ib, self.Consistent, self.Incremental)
+	if err != nil {
+		return ib.Id, name, errors.Wrap(err, "InstanceCreateBackup")
+	}
+	return ib.Id, name, nil
+}
+
+func (self *SBackupPolicy) hasRetention() bool {
+	return self.RetentionCount > 0 || self.RetentionDays > 0 || self.KeepDaily > 0 || self.KeepWeekly > 0 || self.KeepMonthly > 0
+}
+
+// pruneBackups deletes backups created by policy which are out of retention,
+// backups still referenced, e.g. base of incremental backups, are kept until
+// the references are pruned
+func (self *SBackupPolicy) pruneBackups(ctx context.Context, userCred mcclient.TokenCredential, now time.Time) {
+	if !self.hasRetention() {
+		return
+	}
+	runs, err := BackupPolicyRunManager.fetchRetainedRuns(self.Id)
+	if err != nil {
+		log.Errorf("fetch backups of backup policy %s: %s", self.Name, err)
+		return
+	}
+	runsByResource := map[string][]SBackupPolicyRun{}
+	for i := range runs {
+		runsByResource[runs[i].ResourceId] = append(runsByResource[runs[i].ResourceId], runs[i])
+	}
+	for _, resRuns := range runsByResource {
+		records := make([]sBackupRecord, len(resRuns))
+		for i := range resRuns {
+			records[i] = sBackupRecord{Id: resRuns[i].Id, CreatedAt: resRuns[i].StartTime}
+		}
+		expired := computeExpiredBackups(records, self.retention(), now)
+		for i := range resRuns {
+			if !expired[resRuns[i].Id] {
+				continue
+			}
+			err := resRuns[i].pruneBackup(ctx, userCred)
+			if err != nil {
+				log.Warningf("prune backup %s of backup policy %s: %s", resRuns[i].BackupId, self.Name, err)
+			}
+		}
+	}
+}
+
+type sBackupRetention struct {
+	Count   int
+	Days    int
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+func (self *SBackupPolicy) retention() sBackupRetention {
+	return sBackupRetention{
+		Count:   self.RetentionCount,
+		Days:    self.RetentionDays,
+		Daily:   self.KeepDaily,
+		Weekly:  self.KeepWeekly,
+		Monthly: self.KeepMonthly,
+	}
+}
+
+type sBackupRecord struct {
+	Id        string
+	CreatedAt time.Time
+}
+
+// computeExpiredBackups returns backups retained by none of the rules, rules
+// are combined as grandfather-father-son rotation: latest Count backups,
+// backups within Days, and the latest backup of each of the latest Daily
+// days, Weekly weeks and Monthly months which have backups
+func computeExpiredBackups(backups []sBackupRecord, r sBackupRetention, now time.Time) map[string]bool {
+	sorted := make([]sBackupRecord, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	keep := map[string]bool{}
+	for i := 0; i < len(sorted) && i < r.Count; i++ {
+		keep[sorted[i].Id] = true
+	}
+	if r.Days > 0 {
+		since := now.AddDate(0, 0, -r.Days)
+		for _, b := range sorted {
+			if b.CreatedAt.After(since) {
+				keep[b.Id] = true
+			}
+		}
+	}
+	keepLatestOfPeriods := func(limit int, period func(t time.Time) string) {
+		seen := map[string]bool{}
+		for _, b := range sorted {
+			if len(seen) >= limit {
+				return
+			}
+			p := period(b.CreatedAt.In(now.Location()))
+			if !seen[p] {
+				seen[p] = true
+				keep[b.Id] = true
+			}
+		}
+	}
+	keepLatestOfPeriods(r.Daily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepLatestOfPeriods(r.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+	keepLatestOfPeriods(r.Monthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	expired := map[string]bool{}
+	for _, b := range sorted {
+		if !keep[b.Id] {
+			expired[b.Id] = true
+		}
+	}
+	return expired
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestComputeExpiredBackups(t *testing.T) {
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+	// one backup at 02:00 every day of the last 90 days, id is days ago
+	backups := make([]sBackupRecord, 0)
+	for i := 0; i < 90; i++ {
+		day := now.AddDate(0, 0, -i)
+		backups = append(backups, sBackupRecord{
+			Id:        fmt.Sprintf("%d", i),
+			CreatedAt: time.Date(day.Year(), day.Month(), day.Day(), 2, 0, 0, 0, time.UTC),
+		})
+	}
+	kept := func(r sBackupRetention) []string {
+		expired := computeExpiredBackups(backups, r, now)
+		ret := []string{}
+		for _, b := range backups {
+			if !expired[b.Id] {
+				ret = append(ret, b.Id)
+			}
+		}
+		sort.Strings(ret)
+		return ret
+	}
+	cases := []struct {
+		name string
+		in   sBackupRetention
+		want []string
+	}{
+		{
+			name: "count",
+			in:   sBackupRetention{Count: 3},
+			want: []string{"0", "1", "2"},
+		},
+		{
+			name: "days",
+			in:   sBackupRetention{Days: 2},
+			want: []string{"0", "1"},
+		},
+		{
+			name: "daily and count",
+			in:   sBackupRetention{Count: 1, Daily: 2},
+			want: []string{"0", "1"},
+		},
+		{
+			// 2023-03-31 is Friday, latest backups of weeks are on Fridays and Sundays
+			name: "weekly",
+			in:   sBackupRetention{Weekly: 3},
+			want: []string{"0", "12", "5"},
+		},
+		{
+			name: "monthly",
+			in:   sBackupRetention{Monthly: 3},
+			want: []string{"0", "31", "59"},
+		},
+		{
+			name: "gfs",
+			in:   sBackupRetention{Daily: 2, Weekly: 2, Monthly: 2},
+			want: []string{"0", "1", "31", "5"},
+		},
+	}
+	for _, c := range cases {
+		got := kept(c.in)
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", c.want) {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
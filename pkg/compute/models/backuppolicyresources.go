@@ -0,0 +1,144 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SBackupPolicyResourceManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+// SBackupPolicyResource binds a disk or a server to backup policy
+type SBackupPolicyResource struct {
+	db.SStandaloneAnonResourceBase
+
+	BackuppolicyId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// disk or server
+	ResourceType string    `width:"16" charset:"ascii" nullable:"false" list:"user"`
+	ResourceId   string    `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	NextRunTime  time.Time `list:"user"`
+}
+
+var BackupPolicyResourceManager *SBackupPolicyResourceManager
+
+func init() {
+	BackupPolicyResourceManager = &SBackupPolicyResourceManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SBackupPolicyResource{},
+			"backuppolicyresources_tbl",
+			"backuppolicyresource",
+			"backuppolicyresources",
+		),
+	}
+	BackupPolicyResourceManager.SetVirtualObject(BackupPolicyResourceManager)
+}
+
+func (manager *SBackupPolicyResourceManager) bind(ctx context.Context, policy *SBackupPolicy, resType, resId string, now time.Time) error {
+	binding := &SBackupPolicyResource{}
+	binding.SetModelManager(manager, binding)
+	err := manager.Query().Equals("resource_type", resType).Equals("resource_id", resId).First(binding)
+	if err == nil {
+		if binding.BackuppolicyId == policy.Id {
+			return nil
+		}
+		return httperrors.NewConflictError("%s %s has been bound to backup policy %s", resType, resId, binding.BackuppolicyId)
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return errors.Wrap(err, "query binding")
+	}
+	binding = &SBackupPolicyResource{
+		BackuppolicyId: policy.Id,
+		ResourceType:   resType,
+		ResourceId:     resId,
+		NextRunTime:    policy.ComputeNextRunTime(now),
+	}
+	binding.SetModelManager(manager, binding)
+	return manager.TableSpec().Insert(ctx, binding)
+}
+
+func (manager *SBackupPolicyResourceManager) fetchBinding(policyId, resType, resId string) (*SBackupPolicyResource, error) {
+	binding := &SBackupPolicyResource{}
+	binding.SetModelManager(manager, binding)
+	err := manager.Query().Equals("backuppolicy_id", policyId).
+		Equals("resource_type", resType).Equals("resource_id", resId).First(binding)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError("%s %s is not bound to backup policy", resType, resId)
+		}
+		return nil, errors.Wrap(err, "query binding")
+	}
+	return binding, nil
+}
+
+func (manager *SBackupPolicyResourceManager) fetchBindingCount(policyId, resType string) (int, error) {
+	return manager.Query().Equals("backuppolicy_id", policyId).Equals("resource_type", resType).CountWithError()
+}
+
+func (manager *SBackupPolicyResourceManager) fetchDueBindings(now time.Time) ([]SBackupPolicyResource, error) {
+	policies := BackupPolicyManager.Query("id").IsTrue("is_activated").SubQuery()
+	q := manager.Query().LE("next_run_time", now).In("backuppolicy_id", policies)
+	bindings := make([]SBackupPolicyResource, 0)
+	err := db.FetchModelObjects(manager, q, &bindings)
+	if err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func (manager *SBackupPolicyResourceManager) resetNextRunTime(policy *SBackupPolicy, now time.Time) error {
+	bindings := make([]SBackupPolicyResource, 0)
+	err := db.FetchModelObjects(manager, manager.Query().Equals("backuppolicy_id", policy.Id), &bindings)
+	if err != nil {
+		return err
+	}
+	next := policy.ComputeNextRunTime(now)
+	for i := range bindings {
+		err := bindings[i].setNextRunTime(next)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *SBackupPolicyResource) GetBackupPolicy() (*SBackupPolicy, error) {
+	policy, err := BackupPolicyManager.FetchById(self.BackuppolicyId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch backup policy %s", self.BackuppolicyId)
+	}
+	return policy.(*SBackupPolicy), nil
+}
+
+func (self *SBackupPolicyResource) setNextRunTime(next time.Time) error {
+	_, err := db.Update(self, func() error {
+		self.NextRunTime = next
+		return nil
+	})
+	return err
+}
+
+func (self *SBackupPolicyResource) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	return db.DeleteModel(ctx, userCred, self)
+}
@@ -0,0 +1,314 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/rbacscope"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+const BACKUP_POLICY_RUN_TIMEOUT = 24 * time.Hour
+
+type SBackupPolicyRunManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+// SBackupPolicyRun records a backup started by backup policy
+type SBackupPolicyRun struct {
+	db.SStatusStandaloneResourceBase
+
+	BackuppolicyId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	ResourceType   string `width:"16" charset:"ascii" nullable:"false" list:"user"`
+	ResourceId     string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	// disk backup id or instance backup id
+	BackupId  string    `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	StartTime time.Time `list:"user"`
+	EndTime   time.Time `list:"user"`
+	Reason    string    `width:"1024" charset:"utf8" nullable:"true" list:"user"`
+	// 备份已按保留规则清理
+	Pruned bool `nullable:"false" default:"false" list:"user"`
+}
+
+var BackupPolicyRunManager *SBackupPolicyRunManager
+
+func init() {
+	BackupPolicyRunManager = &SBackupPolicyRunManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SBackupPolicyRun{},
+			"backuppolicyruns_tbl",
+			"backuppolicyrun",
+			"backuppolicyruns",
+		),
+	}
+	BackupPolicyRunManager.SetVirtualObject(BackupPolicyRunManager)
+}
+
+func (manager *SBackupPolicyRunManager) createRun(ctx context.Context, policy *SBackupPolicy, binding *SBackupPolicyResource, backupId, backupName string) (*SBackupPolicyRun, error) {
+	run := &SBackupPolicyRun{
+		BackuppolicyId: policy.Id,
+		ResourceType:   binding.ResourceType,
+		ResourceId:     binding.ResourceId,
+		BackupId:       backupId,
+		StartTime:      time.Now(),
+	}
+	run.Name = backupName
+	if len(run.Name) == 0 {
+		run.Name = fmt.Sprintf("%s-%s", binding.ResourceType, binding.ResourceId)
+	}
+	run.Status = api.BACKUP_POLICY_RUN_STATUS_RUNNING
+	run.SetModelManager(manager, run)
+	return run, manager.TableSpec().Insert(ctx, run)
+}
+
+func (manager *SBackupPolicyRunManager) fetchRetainedRuns(policyId string) ([]SBackupPolicyRun, error) {
+	q := manager.Query().Equals("backuppolicy_id", policyId).
+		Equals("status", api.BACKUP_POLICY_RUN_STATUS_SUCCEEDED).IsFalse("pruned")
+	runs := make([]SBackupPolicyRun, 0)
+	err := db.FetchModelObjects(manager, q, &runs)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// SyncRunningRuns updates result of running runs from status of their backups
+func (manager *SBackupPolicyRunManager) SyncRunningRuns(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	q := manager.Query().Equals("status", api.BACKUP_POLICY_RUN_STATUS_RUNNING)
+	runs := make([]SBackupPolicyRun, 0)
+	err := db.FetchModelObjects(manager, q, &runs)
+	if err != nil {
+		log.Errorf("fetch running backup policy runs: %s", err)
+		return
+	}
+	for i := range runs {
+		status, err := runs[i].getBackupStatus()
+		if err != nil {
+			log.Errorf("get backup status of backup policy run %s: %s", runs[i].Name, err)
+			continue
+		}
+		switch {
+		case status == api.BACKUP_STATUS_READY:
+			runs[i].setResult(ctx, userCred, api.BACKUP_POLICY_RUN_STATUS_SUCCEEDED, "")
+		case strings.HasSuffix(status, "_failed"):
+			runs[i].setResult(ctx, userCred, api.BACKUP_POLICY_RUN_STATUS_FAILED, fmt.Sprintf("backup %s is %s", runs[i].BackupId, status))
+		case time.Since(runs[i].StartTime) > BACKUP_POLICY_RUN_TIMEOUT:
+			// stuck backup blocks the following runs of the resource
+			runs[i].setResult(ctx, userCred, api.BACKUP_POLICY_RUN_STATUS_FAILED, fmt.Sprintf("backup %s is still %s after %s", runs[i].BackupId, status, BACKUP_POLICY_RUN_TIMEOUT))
+		}
+	}
+}
+
+// getBackupStatus returns status of backup, a backup removed before it got
+// ready is taken as failed
+func (self *SBackupPolicyRun) getBackupStatus() (string, error) {
+	var manager db.IModelManager = DiskBackupManager
+	if self.ResourceType == api.BACKUP_POLICY_RESOURCE_SERVER {
+		manager = InstanceBackupManager
+	}
+	obj, err := db.FetchById(manager, self.BackupId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return api.BACKUP_STATUS_CREATE_FAILED, nil
+		}
+		return "", err
+	}
+	return obj.(db.IStatusStandaloneModel).GetStatus(), nil
+}
+
+func (self *SBackupPolicyRun) setResult(ctx context.Context, userCred mcclient.TokenCredential, status, reason string) {
+	_, err := db.Update(self, func() error {
+		self.Status = status
+		self.Reason = reason
+		self.EndTime = time.Now()
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update backup policy run %s: %s", self.Name, err)
+		return
+	}
+	if status != api.BACKUP_POLICY_RUN_STATUS_FAILED {
+		return
+	}
+	policyName := self.BackuppolicyId
+	if policy, err := BackupPolicyManager.FetchById(self.BackuppolicyId); err == nil {
+		db.OpsLog.LogEvent(policy, db.ACT_BACKUP_POLICY_RUN_FAIL, reason, userCred)
+		policyName = policy.GetName()
+	}
+	msg := fmt.Sprintf("Backup of %s %s by backup policy failed: %s", self.ResourceType, self.ResourceId, reason)
+	notifyclient.NotifySystemErrorWithCtx(ctx, self.BackuppolicyId, policyName, db.ACT_BACKUP_POLICY_RUN_FAIL, msg)
+}
+
+func (self *SBackupPolicyRun) markPruned() error {
+	_, err := db.Update(self, func() error {
+		self.Pruned = true
+		return nil
+	})
+	return err
+}
+
+func (self *SBackupPolicyRun) pruneBackup(ctx context.Context, userCred mcclient.TokenCredential) error {
+	switch self.ResourceType {
+	case api.BACKUP_POLICY_RESOURCE_DISK:
+		obj, err := DiskBackupManager.FetchById(self.BackupId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return self.markPruned()
+			}
+			return err
+		}
+		backup := obj.(*SDiskBackup)
+		err = backup.ValidateDeleteCondition(ctx, nil)
+		if err != nil {
+			return err
+		}
+		err = backup.StartBackupDeleteTask(ctx, userCred, "", false)
+		if err != nil {
+			return err
+		}
+	case api.BACKUP_POLICY_RESOURCE_SERVER:
+		obj, err := InstanceBackupManager.FetchById(self.BackupId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return self.markPruned()
+			}
+			return err
+		}
+		backup := obj.(*SInstanceBackup)
+		err = backup.ValidateDeleteCondition(ctx, nil)
+		if err != nil {
+			return err
+		}
+		err = backup.StartInstanceBackupDeleteTask(ctx, userCred, "", false)
+		if err != nil {
+			return err
+		}
+	}
+	return self.markPruned()
+}
+
+func (manager *SBackupPolicyRunManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.BackupPolicyRunListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, err
+	}
+	if len(query.BackuppolicyId) > 0 {
+		policy, err := BackupPolicyManager.FetchByIdOrName(userCred, query.BackuppolicyId)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return nil, errors.Wrapf(errors.ErrNotFound, "%s %s", BackupPolicyManager.Keyword(), query.BackuppolicyId)
+			}
+			return nil, err
+		}
+		q = q.Equals("backuppolicy_id", policy.GetId())
+	}
+	if len(query.ResourceType) > 0 {
+		q = q.Equals("resource_type", query.ResourceType)
+	}
+	if len(query.ResourceId) > 0 {
+		q = q.Equals("resource_id", query.ResourceId)
+	}
+	q = q.Desc("start_time")
+	return q, nil
+}
+
+func (manager *SBackupPolicyRunManager) OrderByExtraFields(ctx context.Context, q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential, query api.BackupPolicyRunListInput) (*sqlchemy.SQuery, error) {
+	return manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+}
+
+func (manager *SBackupPolicyRunManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (manager *SBackupPolicyRunManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.BackupPolicyRunDetails {
+	rows := make([]api.BackupPolicyRunDetails, len(objs))
+	statusRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	policyIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i].StatusStandaloneResourceDetails = statusRows[i]
+		policyIds[i] = objs[i].(*SBackupPolicyRun).BackuppolicyId
+	}
+	policyNames, err := db.FetchIdNameMap2(BackupPolicyManager, policyIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		rows[i].Backuppolicy = policyNames[policyIds[i]]
+	}
+	return rows
+}
+
+func (manager *SBackupPolicyRunManager) NamespaceScope() rbacscope.TRbacScope {
+	return rbacscope.ScopeProject
+}
+
+func (manager *SBackupPolicyRunManager) ResourceScope() rbacscope.TRbacScope {
+	return rbacscope.ScopeProject
+}
+
+func (manager *SBackupPolicyRunManager) FilterByOwner(q *sqlchemy.SQuery, man db.FilterByOwnerProvider, userCred mcclient.TokenCredential, owner mcclient.IIdentityProvider, scope rbacscope.TRbacScope) *sqlchemy.SQuery {
+	if owner != nil {
+		policyQ := BackupPolicyManager.Query("id", "domain_id", "tenant_id").SubQuery()
+		switch scope {
+		case rbacscope.ScopeProject:
+			q = q.Join(policyQ, sqlchemy.Equals(q.Field("backuppolicy_id"), policyQ.Field("id")))
+			q = q.Filter(sqlchemy.Equals(policyQ.Field("tenant_id"), owner.GetProjectId()))
+		case rbacscope.ScopeDomain:
+			q = q.Join(policyQ, sqlchemy.Equals(q.Field("backuppolicy_id"), policyQ.Field("id")))
+			q = q.Filter(sqlchemy.Equals(policyQ.Field("domain_id"), owner.GetProjectDomainId()))
+		}
+	}
+	return q
+}
+
+func (manager *SBackupPolicyRunManager) FetchOwnerId(ctx context.Context, data jsonutils.JSONObject) (mcclient.IIdentityProvider, error) {
+	return db.FetchProjectInfo(ctx, data)
+}
+
+func (self *SBackupPolicyRun) GetOwnerId() mcclient.IIdentityProvider {
+	policy, err := BackupPolicyManager.FetchById(self.BackuppolicyId)
+	if err != nil {
+		return nil
+	}
+	return policy.GetOwnerId()
+}
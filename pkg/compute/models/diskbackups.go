@@ -24,12 +24,14 @@ import (
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/gotypes"
+	"yunion.io/x/pkg/utils"
 	"yunion.io/x/sqlchemy"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/compute/options"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
@@ -64,6 +66,15 @@ type SDiskBackup struct {
 	// 操作系统类型
 	OsType     string `width:"32" charset:"ascii" nullable:"true" list:"user" create:"optional"`
 	DiskConfig *SBackupDiskConfig
+	// 是否基于应用一致性快照
+	ApplicationConsistent bool `nullable:"false" default:"false" list:"user"`
+
+	// 备份模式, full: 全量备份, incremental: 增量备份
+	BackupMode string `width:"16" charset:"ascii" nullable:"true" list:"user"`
+	// 增量备份所基于的上一个备份
+	ParentBackupId string `width:"36" charset:"ascii" nullable:"true" list:"user" index:"true"`
+	// 备份在增量链中的位置, 全量备份为1, 0表示磁盘未记录此备份的dirty bitmap, 不能作为增量备份的基础
+	BitmapGeneration int `nullable:"false" default:"0" list:"user"`
 }
 
 var DiskBackupManager *SDiskBackupManager
@@ -120,6 +131,12 @@ func (dm *SDiskBackupManager) ListItemFilter(ctx context.Context, q *sqlchemy.SQ
 	if input.BackupStorageId != "" {
 		q = q.Equals("backup_storage_id", input.BackupStorageId)
 	}
+	if input.BackupMode != "" {
+		q = q.Equals("backup_mode", input.BackupMode)
+	}
+	if input.ParentBackupId != "" {
+		q = q.Equals("parent_backup_id", input.ParentBackupId)
+	}
 	if input.IsInstanceBackup != nil {
 		insjsq := InstanceBackupJointManager.Query().SubQuery()
 		if !*input.IsInstanceBackup {
@@ -143,6 +160,13 @@ func (self *SDiskBackup) ValidateDeleteCondition(ctx context.Context, info jsonu
 	if is {
 		return httperrors.NewBadRequestError("disk backup referenced by instance backup")
 	}
+	cnt, err := DiskBackupManager.Query().Equals("parent_backup_id", self.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count incremental backups")
+	}
+	if cnt > 0 {
+		return httperrors.NewBadRequestError("disk backup is the base of %d incremental backups", cnt)
+	}
 	return nil
 }
 
@@ -243,6 +267,11 @@ func (dm *SDiskBackupManager) ValidateCreateData(
 	if disk.Status != api.DISK_READY {
 		return input, httperrors.NewInvalidStatusError("disk %s status is not %s", disk.Name, api.DISK_READY)
 	}
+	if input.Incremental {
+		if err := disk.validateIncrementalBackup(); err != nil {
+			return input, err
+		}
+	}
 	if len(disk.EncryptKeyId) > 0 {
 		input.EncryptKeyId = &disk.EncryptKeyId
 		input.EncryptedResourceCreateInput, err = dm.SEncryptedResourceManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EncryptedResourceCreateInput)
@@ -315,7 +344,11 @@ func (db *SDiskBackup) PostCreate(ctx context.Context, userCred mcclient.TokenCr
 	if err != nil {
 		log.Errorf("unable to inherit from disk %s to backup %s: %s", disk.GetId(), db.GetId(), err.Error())
 	}
-	db.StartBackupCreateTask(ctx, userCred, nil, "")
+	params := jsonutils.NewDict()
+	if jsonutils.QueryBoolean(data, "incremental", false) {
+		params.Set("incremental", jsonutils.JSONTrue)
+	}
+	db.StartBackupCreateTask(ctx, userCred, params, "")
 }
 
 func (db *SDiskBackup) StartBackupCreateTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
@@ -327,6 +360,56 @@ func (db *SDiskBackup) StartBackupCreateTask(ctx context.Context, userCred mccli
 	return nil
 }
 
+func (self *SDisk) validateIncrementalBackup() error {
+	storage, err := self.GetStorage()
+	if err != nil {
+		return errors.Wrapf(err, "unable to get storage of disk %s", self.Id)
+	}
+	if !utils.IsInStringArray(storage.StorageType, api.FIEL_STORAGE) {
+		return httperrors.NewUnsupportOperationError("incremental backup of disk on storage %s is not supported", storage.StorageType)
+	}
+	if self.IsEncrypted() {
+		return httperrors.NewUnsupportOperationError("incremental backup of encrypted disk is not supported")
+	}
+	guest := self.GetGuest()
+	if guest == nil || guest.GetHypervisor() != api.HYPERVISOR_KVM {
+		return httperrors.NewUnsupportOperationError("incremental backup is only supported for disk of kvm guest")
+	}
+	return nil
+}
+
+// CanLiveBackup dirty bitmaps of disk are maintained by qemu, incremental
+// backup of disk attached to a stopped guest falls back to full backup
+func (self *SDiskBackup) CanLiveBackup() bool {
+	disk, err := self.GetDisk()
+	if err != nil || disk.validateIncrementalBackup() != nil {
+		return false
+	}
+	return disk.GetGuest().Status == api.VM_RUNNING
+}
+
+// GetIncrementalParent returns the latest backup of the same disk in backup storage
+// which next incremental backup could be based on, nil means full backup needed
+func (self *SDiskBackup) GetIncrementalParent() (*SDiskBackup, error) {
+	q := DiskBackupManager.Query().Equals("disk_id", self.DiskId).
+		Equals("backup_storage_id", self.BackupStorageId).
+		Equals("status", api.BACKUP_STATUS_READY).
+		GT("bitmap_generation", 0).NotEquals("id", self.Id).Desc("created_at")
+	parent := &SDiskBackup{}
+	parent.SetModelManager(DiskBackupManager, parent)
+	err := q.First(parent)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "query parent backup")
+	}
+	if parent.BitmapGeneration > options.Options.DiskBackupMaxIncrementalCount {
+		return nil, nil
+	}
+	return parent, nil
+}
+
 func (manager *SDiskBackupManager) ListItemExportKeys(ctx context.Context,
 	q *sqlchemy.SQuery,
 	userCred mcclient.TokenCredential,
@@ -453,6 +536,25 @@ func (self *SDiskBackup) StartRecoveryTask(ctx context.Context, userCred mcclien
 	return nil
 }
 
+// PerformVerify rechecks integrity of backup data in backup storage
+func (self *SDiskBackup) PerformVerify(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DiskBackupVerifyInput) (jsonutils.JSONObject, error) {
+	if !utils.IsInStringArray(self.Status, []string{api.BACKUP_STATUS_READY, api.BACKUP_STATUS_VERIFY_FAILED}) {
+		return nil, errors.Wrapf(httperrors.ErrInvalidStatus, "cannot verify backup in status %s", self.Status)
+	}
+	return nil, self.StartVerifyTask(ctx, userCred, "")
+}
+
+func (self *SDiskBackup) StartVerifyTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	self.SetStatus(userCred, api.BACKUP_STATUS_VERIFYING, "")
+	task, err := taskman.TaskManager.NewTask(ctx, "DiskBackupVerifyTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return err
+	} else {
+		task.ScheduleRun(nil)
+	}
+	return nil
+}
+
 func (manager *SDiskBackupManager) CreateBackup(ctx context.Context, owner mcclient.IIdentityProvider, diskId, backupStorageId, name string) (*SDiskBackup, error) {
 	iDisk, err := DiskManager.FetchById(diskId)
 	if err != nil {
@@ -0,0 +1,398 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SDiskPerformanceClassManager struct {
+	db.SEnabledStatusInfrasResourceBaseManager
+}
+
+// SDiskPerformanceClass is a reusable io limit profile of disks, it is
+// attached to disks directly or to storages as default of their disks, and
+// enforced by qemu block throttling on host
+type SDiskPerformanceClass struct {
+	db.SEnabledStatusInfrasResourceBase
+
+	// 基础IOPS
+	Iops int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+	// 每GB容量的IOPS
+	IopsPerGb int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+	// IOPS上限
+	MaxIops int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+	// 吞吐量限制, 单位MB/s
+	Bps int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+
+	BurstIops    int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+	BurstBps     int `nullable:"false" default:"0" list:"user" create:"domain_optional" update:"domain"`
+	BurstSeconds int `nullable:"false" default:"1" list:"user" create:"domain_optional" update:"domain"`
+
+	// 同一主机上使用此性能等级的磁盘是否共享限额
+	SharedBudget bool `nullable:"false" default:"false" list:"user" create:"domain_optional" update:"domain"`
+
+	// 介质类型, 调度时磁盘只会落在此介质的存储上
+	MediumType string `width:"32" charset:"ascii" nullable:"true" list:"user" create:"domain_optional"`
+}
+
+var DiskPerformanceClassManager *SDiskPerformanceClassManager
+
+func init() {
+	DiskPerformanceClassManager = &SDiskPerformanceClassManager{
+		SEnabledStatusInfrasResourceBaseManager: db.NewEnabledStatusInfrasResourceBaseManager(
+			SDiskPerformanceClass{},
+			"diskperformanceclasses_tbl",
+			"diskperformanceclass",
+			"diskperformanceclasses",
+		),
+	}
+	DiskPerformanceClassManager.SetVirtualObject(DiskPerformanceClassManager)
+}
+
+func validateDiskPerformanceLimits(iops, iopsPerGb, maxIops, bps, burstIops, burstBps, burstSeconds int) error {
+	for k, v := range map[string]int{
+		"iops":          iops,
+		"iops_per_gb":   iopsPerGb,
+		"max_iops":      maxIops,
+		"bps":           bps,
+		"burst_iops":    burstIops,
+		"burst_bps":     burstBps,
+		"burst_seconds": burstSeconds,
+	} {
+		if v < 0 {
+			return httperrors.NewInputParameterError("%s must not be negative", k)
+		}
+	}
+	if maxIops > 0 && iops > maxIops {
+		return httperrors.NewInputParameterError("iops %d exceeds max_iops %d", iops, maxIops)
+	}
+	if burstIops > 0 {
+		if iops == 0 && iopsPerGb == 0 {
+			return httperrors.NewInputParameterError("burst_iops requires iops or iops_per_gb")
+		}
+		if burstIops < iops || (maxIops > 0 && burstIops < maxIops) {
+			return httperrors.NewInputParameterError("burst_iops %d should not be less than iops limit", burstIops)
+		}
+	}
+	if burstBps > 0 {
+		if bps == 0 {
+			return httperrors.NewInputParameterError("burst_bps requires bps")
+		}
+		if burstBps < bps {
+			return httperrors.NewInputParameterError("burst_bps %d should not be less than bps %d", burstBps, bps)
+		}
+	}
+	if (burstIops > 0 || burstBps > 0) && burstSeconds == 0 {
+		return httperrors.NewInputParameterError("burst_seconds is required for burst limits")
+	}
+	return nil
+}
+
+func (manager *SDiskPerformanceClassManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.DiskPerformanceClassCreateInput,
+) (api.DiskPerformanceClassCreateInput, error) {
+	var err error
+	input.EnabledStatusInfrasResourceBaseCreateInput, err = manager.SEnabledStatusInfrasResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.EnabledStatusInfrasResourceBaseCreateInput)
+	if err != nil {
+		return input, err
+	}
+	if len(input.MediumType) > 0 && !utils.IsInStringArray(input.MediumType, api.DISK_TYPES) {
+		return input, httperrors.NewInputParameterError("Invalid medium type %s", input.MediumType)
+	}
+	if input.BurstSeconds == 0 {
+		input.BurstSeconds = 1
+	}
+	err = validateDiskPerformanceLimits(input.Iops, input.IopsPerGb, input.MaxIops, input.Bps, input.BurstIops, input.BurstBps, input.BurstSeconds)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SDiskPerformanceClass) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	self.SetEnabled(true)
+	self.Status = api.DISK_PERFORMANCE_CLASS_STATUS_READY
+	return self.SEnabledStatusInfrasResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (self *SDiskPerformanceClass) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.DiskPerformanceClassUpdateInput,
+) (api.DiskPerformanceClassUpdateInput, error) {
+	var err error
+	input.EnabledStatusInfrasResourceBaseUpdateInput, err = self.SEnabledStatusInfrasResourceBase.ValidateUpdateData(ctx, userCred, query, input.EnabledStatusInfrasResourceBaseUpdateInput)
+	if err != nil {
+		return input, err
+	}
+	pick := func(v *int, def int) int {
+		if v != nil {
+			return *v
+		}
+		return def
+	}
+	err = validateDiskPerformanceLimits(
+		pick(input.Iops, self.Iops),
+		pick(input.IopsPerGb, self.IopsPerGb),
+		pick(input.MaxIops, self.MaxIops),
+		pick(input.Bps, self.Bps),
+		pick(input.BurstIops, self.BurstIops),
+		pick(input.BurstBps, self.BurstBps),
+		pick(input.BurstSeconds, self.BurstSeconds),
+	)
+	if err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+func (self *SDiskPerformanceClass) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SEnabledStatusInfrasResourceBase.PostUpdate(ctx, userCred, query, data)
+
+	for _, k := range []string{"iops", "iops_per_gb", "max_iops", "bps", "burst_iops", "burst_bps", "burst_seconds", "shared_budget"} {
+		if data.Contains(k) {
+			storages := StorageManager.Query("id").Equals("disk_performance_class_id", self.Id).SubQuery()
+			q := DiskManager.Query("id")
+			q = q.Filter(sqlchemy.OR(
+				sqlchemy.Equals(q.Field("disk_performance_class_id"), self.Id),
+				sqlchemy.AND(
+					sqlchemy.IsNullOrEmpty(q.Field("disk_performance_class_id")),
+					sqlchemy.In(q.Field("storage_id"), storages),
+				),
+			))
+			syncDiskPerformanceOfGuests(ctx, userCred, q)
+			break
+		}
+	}
+}
+
+// syncDiskPerformanceOfGuests pushes io limits of disks in diskQuery to the
+// running kvm guests they are attached to
+func syncDiskPerformanceOfGuests(ctx context.Context, userCred mcclient.TokenCredential, diskQuery *sqlchemy.SQuery) {
+	guestdisks := GuestdiskManager.Query("guest_id").In("disk_id", diskQuery.SubQuery()).SubQuery()
+	q := GuestManager.Query().In("id", guestdisks).Equals("hypervisor", api.HYPERVISOR_KVM).Equals("status", api.VM_RUNNING)
+	guests := make([]SGuest, 0)
+	err := db.FetchModelObjects(GuestManager, q, &guests)
+	if err != nil {
+		log.Errorf("fetch guests to sync disk performance: %v", err)
+		return
+	}
+	for i := range guests {
+		err := guests[i].StartSyncTask(ctx, userCred, false, "")
+		if err != nil {
+			log.Errorf("sync disk performance of guest %s: %v", guests[i].Name, err)
+		}
+	}
+}
+
+func (self *SDiskPerformanceClass) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := DiskManager.Query().Equals("disk_performance_class_id", self.Id).CountWithError()
+	if err != nil {
+		return httperrors.NewInternalServerError("count disks fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("disk performance class is used by %d disks", cnt)
+	}
+	cnt, err = StorageManager.Query().Equals("disk_performance_class_id", self.Id).CountWithError()
+	if err != nil {
+		return httperrors.NewInternalServerError("count storages fail %s", err)
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("disk performance class is default of %d storages", cnt)
+	}
+	return self.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (manager *SDiskPerformanceClassManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DiskPerformanceClassListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SEnabledStatusInfrasResourceBaseManager.ListItemFilter(ctx, q, userCred, query.EnabledStatusInfrasResourceBaseListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SEnabledStatusInfrasResourceBaseManager.ListItemFilter")
+	}
+	if len(query.MediumType) > 0 {
+		q = q.In("medium_type", query.MediumType)
+	}
+	return q, nil
+}
+
+func (manager *SDiskPerformanceClassManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.DiskPerformanceClassDetails {
+	rows := make([]api.DiskPerformanceClassDetails, len(objs))
+	stdRows := manager.SEnabledStatusInfrasResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	ids := make([]string, len(objs))
+	for i := range rows {
+		rows[i].EnabledStatusInfrasResourceBaseDetails = stdRows[i]
+		ids[i] = objs[i].(*SDiskPerformanceClass).Id
+	}
+	diskCnt := fetchDiskPerformanceClassRefCount(DiskManager.Query(), ids)
+	storageCnt := fetchDiskPerformanceClassRefCount(StorageManager.Query(), ids)
+	for i := range rows {
+		rows[i].DiskCount = diskCnt[ids[i]]
+		rows[i].StorageCount = storageCnt[ids[i]]
+	}
+	return rows
+}
+
+func fetchDiskPerformanceClassRefCount(q *sqlchemy.SQuery, ids []string) map[string]int {
+	ret := map[string]int{}
+	q = q.AppendField(q.Field("disk_performance_class_id"), sqlchemy.COUNT("count"))
+	q = q.In("disk_performance_class_id", ids).GroupBy(q.Field("disk_performance_class_id"))
+	counts := []struct {
+		DiskPerformanceClassId string
+		Count                  int
+	}{}
+	err := q.All(&counts)
+	if err != nil {
+		log.Errorf("count references of disk performance classes: %v", err)
+		return ret
+	}
+	for _, c := range counts {
+		ret[c.DiskPerformanceClassId] = c.Count
+	}
+	return ret
+}
+
+func (manager *SDiskPerformanceClassManager) fetchDiskPerformanceClass(id string) (*SDiskPerformanceClass, error) {
+	obj, err := manager.FetchById(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "FetchById %s", id)
+	}
+	return obj.(*SDiskPerformanceClass), nil
+}
+
+// validateDiskPerformanceClass normalizes id or name of class and checks it
+// could be attached to new disks
+func validateDiskPerformanceClass(userCred mcclient.TokenCredential, classId *string) (*SDiskPerformanceClass, error) {
+	obj, err := validators.ValidateModel(userCred, DiskPerformanceClassManager, classId)
+	if err != nil {
+		return nil, err
+	}
+	class := obj.(*SDiskPerformanceClass)
+	if !class.GetEnabled() {
+		return nil, httperrors.NewInvalidStatusError("disk performance class %s is disabled", class.Name)
+	}
+	return class, nil
+}
+
+// GetIops returns iops limit of disk with sizeMb, 0 means unlimited
+func (self *SDiskPerformanceClass) GetIops(sizeMb int) int {
+	iops := self.IopsPerGb * sizeMb / 1024
+	if iops < self.Iops {
+		iops = self.Iops
+	}
+	if self.MaxIops > 0 && iops > self.MaxIops {
+		iops = self.MaxIops
+	}
+	return iops
+}
+
+// fillThrottle sets io limits of disk desc, sizeMb is total size of disks
+// sharing the budget if SharedBudget is set
+func (self *SDiskPerformanceClass) fillThrottle(desc *api.GuestdiskJsonDesc, sizeMb int) {
+	desc.Iops = self.GetIops(sizeMb)
+	// qemu takes bytes per second
+	desc.Bps = self.Bps * 1024 * 1024
+	if self.BurstIops > desc.Iops && desc.Iops > 0 {
+		desc.BurstIops = self.BurstIops
+	}
+	if self.BurstBps > self.Bps && self.Bps > 0 {
+		desc.BurstBps = self.BurstBps * 1024 * 1024
+	}
+	if desc.BurstIops > 0 || desc.BurstBps > 0 {
+		desc.BurstSeconds = self.BurstSeconds
+	}
+	if self.SharedBudget {
+		desc.ThrottleGroup = "dpc-" + self.Id
+	}
+}
+
+// fetchDiskPerformanceClassNames returns name of class in effect of each disk
+func fetchDiskPerformanceClassNames(disks []*SDisk) map[string]string {
+	ret := map[string]string{}
+	storageIds := []string{}
+	for _, disk := range disks {
+		if len(disk.DiskPerformanceClassId) == 0 {
+			storageIds = append(storageIds, disk.StorageId)
+		}
+	}
+	storageClasses := map[string]string{}
+	if len(storageIds) > 0 {
+		q := StorageManager.Query("id", "disk_performance_class_id").In("id", storageIds).IsNotEmpty("disk_performance_class_id")
+		rows := []struct {
+			Id                     string
+			DiskPerformanceClassId string
+		}{}
+		err := q.All(&rows)
+		if err != nil {
+			log.Errorf("query disk performance classes of storages: %v", err)
+		}
+		for _, row := range rows {
+			storageClasses[row.Id] = row.DiskPerformanceClassId
+		}
+	}
+	classIds := []string{}
+	for _, disk := range disks {
+		classId := disk.DiskPerformanceClassId
+		if len(classId) == 0 {
+			classId = storageClasses[disk.StorageId]
+		}
+		if len(classId) > 0 {
+			ret[disk.Id] = classId
+			classIds = append(classIds, classId)
+		}
+	}
+	if len(classIds) == 0 {
+		return ret
+	}
+	names, err := db.FetchIdNameMap2(DiskPerformanceClassManager, classIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 of disk performance classes: %v", err)
+		return map[string]string{}
+	}
+	for diskId, classId := range ret {
+		ret[diskId] = names[classId]
+	}
+	return ret
+}
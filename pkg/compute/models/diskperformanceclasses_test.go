@@ -0,0 +1,146 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestDiskPerformanceClassGetIops(t *testing.T) {
+	cases := []struct {
+		name   string
+		class  SDiskPerformanceClass
+		sizeMb int
+		want   int
+	}{
+		{
+			name:   "unlimited",
+			sizeMb: 100 * 1024,
+			want:   0,
+		},
+		{
+			name:   "fixed",
+			class:  SDiskPerformanceClass{Iops: 500},
+			sizeMb: 100 * 1024,
+			want:   500,
+		},
+		{
+			name:   "per gb",
+			class:  SDiskPerformanceClass{IopsPerGb: 30},
+			sizeMb: 100 * 1024,
+			want:   3000,
+		},
+		{
+			name:   "per gb grows with resize",
+			class:  SDiskPerformanceClass{IopsPerGb: 30},
+			sizeMb: 200 * 1024,
+			want:   6000,
+		},
+		{
+			name:   "base for small disk",
+			class:  SDiskPerformanceClass{Iops: 1000, IopsPerGb: 30},
+			sizeMb: 10 * 1024,
+			want:   1000,
+		},
+		{
+			name:   "capped",
+			class:  SDiskPerformanceClass{Iops: 1000, IopsPerGb: 30, MaxIops: 5000},
+			sizeMb: 1024 * 1024,
+			want:   5000,
+		},
+		{
+			name:   "partial gb",
+			class:  SDiskPerformanceClass{IopsPerGb: 30},
+			sizeMb: 1536,
+			want:   45,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.class.GetIops(c.sizeMb); got != c.want {
+				t.Errorf("want %d, got %d", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDiskPerformanceClassFillThrottle(t *testing.T) {
+	cases := []struct {
+		name   string
+		class  SDiskPerformanceClass
+		sizeMb int
+		want   api.GuestdiskJsonDesc
+	}{
+		{
+			name:   "limits in bytes",
+			class:  SDiskPerformanceClass{IopsPerGb: 30, Bps: 100, BurstSeconds: 1},
+			sizeMb: 100 * 1024,
+			want:   api.GuestdiskJsonDesc{Iops: 3000, Bps: 100 << 20},
+		},
+		{
+			name: "burst",
+			class: SDiskPerformanceClass{
+				IopsPerGb:    30,
+				Bps:          100,
+				BurstIops:    6000,
+				BurstBps:     200,
+				BurstSeconds: 60,
+			},
+			sizeMb: 100 * 1024,
+			want: api.GuestdiskJsonDesc{
+				Iops:         3000,
+				Bps:          100 << 20,
+				BurstIops:    6000,
+				BurstBps:     200 << 20,
+				BurstSeconds: 60,
+			},
+		},
+		{
+			name: "burst below limit of resized disk is dropped",
+			class: SDiskPerformanceClass{
+				IopsPerGb:    30,
+				BurstIops:    6000,
+				BurstSeconds: 60,
+			},
+			sizeMb: 300 * 1024,
+			want:   api.GuestdiskJsonDesc{Iops: 9000},
+		},
+		{
+			name: "shared budget",
+			class: SDiskPerformanceClass{
+				IopsPerGb:    30,
+				SharedBudget: true,
+			},
+			sizeMb: 100 * 1024,
+			want:   api.GuestdiskJsonDesc{Iops: 3000, ThrottleGroup: "dpc-class0"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.class.Id = "class0"
+			got := api.GuestdiskJsonDesc{}
+			c.class.fillThrottle(&got, c.sizeMb)
+			if got.Iops != c.want.Iops || got.Bps != c.want.Bps ||
+				got.BurstIops != c.want.BurstIops || got.BurstBps != c.want.BurstBps ||
+				got.BurstSeconds != c.want.BurstSeconds || got.ThrottleGroup != c.want.ThrottleGroup {
+				t.Errorf("want %d/%d burst %d/%d %ds group %q, got %d/%d burst %d/%d %ds group %q",
+					c.want.Iops, c.want.Bps, c.want.BurstIops, c.want.BurstBps, c.want.BurstSeconds, c.want.ThrottleGroup,
+					got.Iops, got.Bps, got.BurstIops, got.BurstBps, got.BurstSeconds, got.ThrottleGroup)
+			}
+		})
+	}
+}
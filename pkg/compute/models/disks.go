@@ -52,6 +52,7 @@ import (
 	"yunion.io/x/onecloud/pkg/mcclient"
 	"yunion.io/x/onecloud/pkg/mcclient/auth"
 	"yunion.io/x/onecloud/pkg/mcclient/modules/image"
+	"yunion.io/x/onecloud/pkg/util/logclient"
 	"yunion.io/x/onecloud/pkg/util/stringutils2"
 )
 
@@ -134,6 +135,9 @@ type SDisk struct {
 
 	// 磁盘吞吐量
 	Throughput int `nullable:"true" list:"user" create:"optional"`
+
+	// 磁盘性能等级, 为空则使用存储的默认性能等级
+	DiskPerformanceClassId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
 }
 
 func (manager *SDiskManager) GetContextManagers() [][]db.IModelManager {
@@ -190,6 +194,14 @@ func (manager *SDiskManager) ListItemFilter(
 		return nil, errors.Wrapf(err, "SAutoDeleteResourceBaseManager.ListItemFilter")
 	}
 
+	if len(query.DiskPerformanceClassId) > 0 {
+		_, err := validators.ValidateModel(userCred, DiskPerformanceClassManager, &query.DiskPerformanceClassId)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("disk_performance_class_id", query.DiskPerformanceClassId)
+	}
+
 	if query.Unused != nil {
 		guestdisks := GuestdiskManager.Query().SubQuery()
 		sq := guestdisks.Query(guestdisks.Field("disk_id"))
@@ -802,10 +814,14 @@ func (self *SDisk) getDiskAllocateFromBackupInput(ctx context.Context, backupId
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to get backupstorage of backup %s", backupId)
 	}
+	accessInfo, err := bs.GetAccessInfo()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAccessInfo")
+	}
 	return &api.DiskAllocateFromBackupInput{
 		BackupId:                backupId,
 		BackupStorageId:         bs.GetId(),
-		BackupStorageAccessInfo: jsonutils.Marshal(bs.AccessInfo).(*jsonutils.JSONDict),
+		BackupStorageAccessInfo: jsonutils.Marshal(accessInfo).(*jsonutils.JSONDict),
 	}, nil
 }
 
@@ -1929,6 +1945,11 @@ func parseDiskInfo(ctx context.Context, userCred mcclient.TokenCredential, info
 			}
 		}
 	}
+	if info.PerformanceClass != "" {
+		if err := fillDiskConfigByPerformanceClass(userCred, info); err != nil {
+			return nil, errors.Wrap(err, "fillDiskConfigByPerformanceClass")
+		}
+	}
 	if info.ExistingPath != "" {
 		info.ExistingPath = strings.TrimSpace(info.ExistingPath)
 		_, err := filepath.Rel("/", info.ExistingPath)
@@ -1947,6 +1968,28 @@ func parseDiskInfo(ctx context.Context, userCred mcclient.TokenCredential, info
 	return info, nil
 }
 
+// fillDiskConfigByPerformanceClass requires medium of the class so that the
+// disk is scheduled to storages matching the class
+func fillDiskConfigByPerformanceClass(userCred mcclient.TokenCredential, diskConfig *api.DiskConfig) error {
+	class, err := validateDiskPerformanceClass(userCred, &diskConfig.PerformanceClass)
+	if err != nil {
+		return err
+	}
+	if len(class.MediumType) > 0 {
+		if len(diskConfig.Storage) > 0 {
+			storage := StorageManager.FetchStorageById(diskConfig.Storage)
+			if storage != nil && storage.MediumType != class.MediumType {
+				return httperrors.NewInputParameterError("storage %s is not %s required by disk performance class %s", storage.Name, class.MediumType, class.Name)
+			}
+		}
+		if len(diskConfig.Medium) > 0 && diskConfig.Medium != class.MediumType {
+			return httperrors.NewInputParameterError("medium %s conflicts with %s of disk performance class %s", diskConfig.Medium, class.MediumType, class.Name)
+		}
+		diskConfig.Medium = class.MediumType
+	}
+	return nil
+}
+
 func fillDiskConfigBySnapshot(userCred mcclient.TokenCredential, diskConfig *api.DiskConfig, snapshotId string) error {
 	iSnapshot, err := SnapshotManager.FetchByIdOrName(userCred, snapshotId)
 	if err != nil {
@@ -2148,6 +2191,7 @@ func (self *SDisk) fetchDiskInfo(diskConfig *api.DiskConfig) {
 	self.DiskFormat = diskConfig.Format
 	self.DiskSize = diskConfig.SizeMb
 	self.OsArch = diskConfig.OsArch
+	self.DiskPerformanceClassId = diskConfig.PerformanceClass
 }
 
 type DiskInfo struct {
@@ -2214,10 +2258,12 @@ func (self *SDisk) RealDelete(ctx context.Context, userCred mcclient.TokenCreden
 	diskbackups := DiskBackupManager.Query("id").Equals("disk_id", self.Id)
 	guestdisks := GuestdiskManager.Query("row_id").Equals("disk_id", self.Id)
 	diskpolicies := SnapshotPolicyDiskManager.Query("row_id").Equals("disk_id", self.Id)
+	backuppolicies := BackupPolicyResourceManager.Query("id").Equals("resource_type", api.BACKUP_POLICY_RESOURCE_DISK).Equals("resource_id", self.Id)
 	pairs := []purgePair{
 		{manager: DiskBackupManager, key: "id", q: diskbackups},
 		{manager: GuestdiskManager, key: "row_id", q: guestdisks},
 		{manager: SnapshotPolicyDiskManager, key: "row_id", q: diskpolicies},
+		{manager: BackupPolicyResourceManager, key: "id", q: backuppolicies},
 	}
 	for i := range pairs {
 		err := pairs[i].purgeAll(ctx)
@@ -2317,6 +2363,15 @@ func (manager *SDiskManager) FetchCustomizeColumns(
 		diskIds[i] = disk.Id
 	}
 
+	disks := make([]*SDisk, len(objs))
+	for i := range objs {
+		disks[i] = objs[i].(*SDisk)
+	}
+	classNames := fetchDiskPerformanceClassNames(disks)
+	for i := range rows {
+		rows[i].DiskPerformanceClass = classNames[diskIds[i]]
+	}
+
 	guestSQ := GuestManager.Query().SubQuery()
 	gds := GuestdiskManager.Query().SubQuery()
 	q := guestSQ.Query(
@@ -2589,6 +2644,11 @@ func (self *SDisk) GetShortDesc(ctx context.Context) *jsonutils.JSONDict {
 		desc.Add(jsonutils.NewString(storage.StorageType), "storage_type")
 		desc.Add(jsonutils.NewString(storage.MediumType), "medium_type")
 	}
+	// performance class is a billing dimension of disk
+	if class := self.GetDiskPerformanceClass(); class != nil {
+		desc.Add(jsonutils.NewString(class.Id), "disk_performance_class_id")
+		desc.Add(jsonutils.NewString(class.Name), "disk_performance_class")
+	}
 
 	if hypervisor := self.GetMetadata(ctx, "hypervisor", nil); len(hypervisor) > 0 {
 		desc.Add(jsonutils.NewString(hypervisor), "hypervisor")
@@ -2805,7 +2865,11 @@ func (self *SDisk) CreateSnapshotAuto(
 	}
 
 	db.OpsLog.LogEvent(snap, db.ACT_CREATE, "disk create snapshot auto", userCred)
-	err = snap.StartSnapshotCreateTask(ctx, userCred, nil, "")
+	params := jsonutils.NewDict()
+	if snapshotPolicy.Consistent {
+		params.Set("consistent", jsonutils.JSONTrue)
+	}
+	err = snap.StartSnapshotCreateTask(ctx, userCred, params, "")
 	if err != nil {
 		return errors.Wrap(err, "disk auto snapshot start snapshot task")
 	}
@@ -3271,3 +3335,56 @@ func (manager *SDiskManager) ListItemExportKeys(ctx context.Context,
 	}
 	return q, nil
 }
+
+// GetDiskPerformanceClass returns performance class in effect, disk's own
+// class takes precedence over the default of its storage
+func (self *SDisk) GetDiskPerformanceClass() *SDiskPerformanceClass {
+	classId := self.DiskPerformanceClassId
+	if len(classId) == 0 {
+		storage, _ := self.GetStorage()
+		if storage == nil || len(storage.DiskPerformanceClassId) == 0 {
+			return nil
+		}
+		classId = storage.DiskPerformanceClassId
+	}
+	class, err := DiskPerformanceClassManager.fetchDiskPerformanceClass(classId)
+	if err != nil {
+		log.Errorf("disk %s: %v", self.Name, err)
+		return nil
+	}
+	return class
+}
+
+// 设置磁盘性能等级
+func (self *SDisk) PerformSetPerformanceClass(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DiskSetPerformanceClassInput) (jsonutils.JSONObject, error) {
+	storage, err := self.GetStorage()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetStorage")
+	}
+	if len(storage.ManagerId) > 0 {
+		return nil, httperrors.NewUnsupportOperationError("disk performance class is not supported by managed storage")
+	}
+	if len(input.DiskPerformanceClassId) > 0 {
+		class, err := validateDiskPerformanceClass(userCred, &input.DiskPerformanceClassId)
+		if err != nil {
+			return nil, err
+		}
+		if len(class.MediumType) > 0 && class.MediumType != storage.MediumType {
+			return nil, httperrors.NewInputParameterError("storage %s is not %s required by disk performance class %s", storage.Name, class.MediumType, class.Name)
+		}
+	}
+	if input.DiskPerformanceClassId == self.DiskPerformanceClassId {
+		return nil, nil
+	}
+	diff, err := db.Update(self, func() error {
+		self.DiskPerformanceClassId = input.DiskPerformanceClassId
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	db.OpsLog.LogEvent(self, db.ACT_UPDATE, diff, userCred)
+	logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, diff, userCred, true)
+	syncDiskPerformanceOfGuests(ctx, userCred, DiskManager.Query("id").Equals("id", self.Id))
+	return nil, nil
+}
@@ -0,0 +1,284 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SDrProtectionGroupMemberManager struct {
+	db.SStandaloneAnonResourceBaseManager
+}
+
+// SDrProtectionGroupMember is a server protected by DR protection group
+type SDrProtectionGroupMember struct {
+	db.SStandaloneAnonResourceBase
+
+	DrprotectiongroupId string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	GuestId             string `width:"36" charset:"ascii" nullable:"false" list:"user" index:"true"`
+	SourceZoneId        string `width:"36" charset:"ascii" nullable:"false" list:"user"`
+	// nics of server when it is added, used to recreate server with the same network config
+	Nics *api.SDrServerNics `list:"user"`
+	// RPO is accounted since the time server is protected before first replica
+	ProtectedAt time.Time `nullable:"true" list:"user"`
+
+	// latest ready instance backup replicated to backup storage
+	LastReplicaId    string    `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	LastReplicatedAt time.Time `nullable:"true" list:"user"`
+	// replicas created by protection group, only these are pruned
+	ReplicaIds *api.SDrReplicaIds `list:"user"`
+	// instance backup being replicated
+	ReplicatingId    string    `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	ReplicateStartAt time.Time `nullable:"true" list:"user"`
+	RpoCompliant     bool      `nullable:"false" default:"true" list:"user"`
+
+	TestGuestId     string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+	FailoverGuestId string `width:"36" charset:"ascii" nullable:"true" list:"user"`
+}
+
+var DrProtectionGroupMemberManager *SDrProtectionGroupMemberManager
+
+func init() {
+	DrProtectionGroupMemberManager = &SDrProtectionGroupMemberManager{
+		SStandaloneAnonResourceBaseManager: db.NewStandaloneAnonResourceBaseManager(
+			SDrProtectionGroupMember{},
+			"drprotectiongroupmembers_tbl",
+			"drprotectiongroupmember",
+			"drprotectiongroupmembers",
+		),
+	}
+	DrProtectionGroupMemberManager.SetVirtualObject(DrProtectionGroupMemberManager)
+}
+
+func (manager *SDrProtectionGroupMemberManager) addMember(ctx context.Context, group *SDrProtectionGroup, guest *SGuest, zoneId string, nics api.SDrServerNics) error {
+	member := &SDrProtectionGroupMember{}
+	member.SetModelManager(manager, member)
+	err := manager.Query().Equals("guest_id", guest.Id).First(member)
+	if err == nil {
+		if member.DrprotectiongroupId == group.Id {
+			return nil
+		}
+		return httperrors.NewConflictError("server %s has been protected by group %s", guest.Name, member.DrprotectiongroupId)
+	}
+	if errors.Cause(err) != sql.ErrNoRows {
+		return errors.Wrap(err, "query member")
+	}
+	member = &SDrProtectionGroupMember{
+		DrprotectiongroupId: group.Id,
+		GuestId:             guest.Id,
+		SourceZoneId:        zoneId,
+		Nics:                &nics,
+		ProtectedAt:         time.Now(),
+		RpoCompliant:        true,
+	}
+	member.SetModelManager(manager, member)
+	return manager.TableSpec().Insert(ctx, member)
+}
+
+func (manager *SDrProtectionGroupMemberManager) fetchMember(groupId, guestId string) (*SDrProtectionGroupMember, error) {
+	member := &SDrProtectionGroupMember{}
+	member.SetModelManager(manager, member)
+	err := manager.Query().Equals("drprotectiongroup_id", groupId).Equals("guest_id", guestId).First(member)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, httperrors.NewResourceNotFoundError("server %s is not protected by group", guestId)
+		}
+		return nil, errors.Wrap(err, "query member")
+	}
+	return member, nil
+}
+
+func (manager *SDrProtectionGroupMemberManager) fetchMembers(groupId string) ([]SDrProtectionGroupMember, error) {
+	members := make([]SDrProtectionGroupMember, 0)
+	err := db.FetchModelObjects(manager, manager.Query().Equals("drprotectiongroup_id", groupId).Asc("created_at"), &members)
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (self *SDrProtectionGroupMember) GetGuest() *SGuest {
+	return GuestManager.FetchGuestById(self.GuestId)
+}
+
+func (self *SDrProtectionGroupMember) getNics() api.SDrServerNics {
+	if self.Nics == nil {
+		return nil
+	}
+	nics := make(api.SDrServerNics, len(*self.Nics))
+	copy(nics, *self.Nics)
+	sort.Slice(nics, func(i, j int) bool {
+		return nics[i].Index < nics[j].Index
+	})
+	return nics
+}
+
+// GetSourceNetworks returns network config to recreate server on source side
+func (self *SDrProtectionGroupMember) GetSourceNetworks() []*api.NetworkConfig {
+	ret := make([]*api.NetworkConfig, 0)
+	for _, nic := range self.getNics() {
+		ret = append(ret, &api.NetworkConfig{
+			Index:    nic.Index,
+			Network:  nic.NetworkId,
+			Address:  nic.IpAddr,
+			Address6: nic.Ip6Addr,
+			Mac:      nic.MacAddr,
+			Driver:   nic.Driver,
+			BwLimit:  nic.BwLimit,
+		})
+	}
+	return ret
+}
+
+// GetTargetNetworks maps nics of server to networks of target zone, addresses
+// and macs are kept if target network covers the address, test copies are
+// attached to isolated test networks with new addresses
+func (self *SDrProtectionGroupMember) GetTargetNetworks(mappings api.SDrNetworkMappings, isTest bool) ([]*api.NetworkConfig, error) {
+	ret := make([]*api.NetworkConfig, 0)
+	for _, nic := range self.getNics() {
+		mapping := mappings.Find(nic.NetworkId)
+		if mapping == nil {
+			return nil, errors.Wrapf(errors.ErrNotFound, "no network mapping of network %s", nic.NetworkId)
+		}
+		conf := &api.NetworkConfig{
+			Index:   nic.Index,
+			Network: mapping.TargetNetworkId,
+			Driver:  nic.Driver,
+			BwLimit: nic.BwLimit,
+		}
+		if isTest {
+			if len(mapping.TestNetworkId) == 0 {
+				return nil, errors.Wrapf(errors.ErrNotFound, "no test network mapping of network %s", nic.NetworkId)
+			}
+			conf.Network = mapping.TestNetworkId
+		} else {
+			conf.Mac = nic.MacAddr
+			if netObj, err := NetworkManager.FetchById(mapping.TargetNetworkId); err == nil && len(nic.IpAddr) > 0 && netObj.(*SNetwork).Contains(nic.IpAddr) {
+				conf.Address = nic.IpAddr
+			}
+		}
+		ret = append(ret, conf)
+	}
+	return ret, nil
+}
+
+// recoveryPoint returns time of the latest recovery point of server
+func (self *SDrProtectionGroupMember) recoveryPoint() time.Time {
+	if self.LastReplicatedAt.IsZero() {
+		return self.ProtectedAt
+	}
+	return self.LastReplicatedAt
+}
+
+func (self *SDrProtectionGroupMember) startReplicate(replicaId string, now time.Time) error {
+	_, err := db.Update(self, func() error {
+		self.ReplicatingId = replicaId
+		self.ReplicateStartAt = now
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) finishReplicate(replica *SInstanceBackup) error {
+	_, err := db.Update(self, func() error {
+		if replica != nil {
+			self.LastReplicaId = replica.Id
+			self.LastReplicatedAt = replica.CreatedAt
+			ids := append(self.getReplicaIds(), replica.Id)
+			self.ReplicaIds = &ids
+		}
+		self.ReplicatingId = ""
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) getReplicaIds() api.SDrReplicaIds {
+	if self.ReplicaIds == nil {
+		return api.SDrReplicaIds{}
+	}
+	ids := make(api.SDrReplicaIds, len(*self.ReplicaIds))
+	copy(ids, *self.ReplicaIds)
+	return ids
+}
+
+// removeReplicaIds forgets replicas which are pruned or gone
+func (self *SDrProtectionGroupMember) removeReplicaIds(removed []string) error {
+	_, err := db.Update(self, func() error {
+		ids := api.SDrReplicaIds{}
+		for _, id := range self.getReplicaIds() {
+			if !utils.IsInStringArray(id, removed) {
+				ids = append(ids, id)
+			}
+		}
+		self.ReplicaIds = &ids
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) setRpoCompliant(compliant bool) error {
+	_, err := db.Update(self, func() error {
+		self.RpoCompliant = compliant
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) SetTestGuestId(guestId string) error {
+	_, err := db.Update(self, func() error {
+		self.TestGuestId = guestId
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) SetFailoverGuestId(guestId string) error {
+	_, err := db.Update(self, func() error {
+		self.FailoverGuestId = guestId
+		return nil
+	})
+	return err
+}
+
+// SwitchGuest makes server recreated by failback the protected server
+func (self *SDrProtectionGroupMember) SwitchGuest(guest *SGuest) error {
+	_, err := db.Update(self, func() error {
+		self.GuestId = guest.Id
+		self.FailoverGuestId = ""
+		self.LastReplicaId = ""
+		self.LastReplicatedAt = time.Time{}
+		self.ReplicatingId = ""
+		self.ProtectedAt = time.Now()
+		self.RpoCompliant = true
+		return nil
+	})
+	return err
+}
+
+func (self *SDrProtectionGroupMember) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
+	return db.DeleteModel(ctx, userCred, self)
+}
@@ -0,0 +1,913 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/notifyclient"
+	"yunion.io/x/onecloud/pkg/cloudcommon/validators"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SDrProtectionGroupManager struct {
+	db.SVirtualResourceBaseManager
+}
+
+// SDrProtectionGroup replicates servers to backup storage reachable from
+// target zone periodically, servers could be recreated in target zone from
+// the latest replicas for failover drill or real failover
+type SDrProtectionGroup struct {
+	db.SVirtualResourceBase
+
+	TargetZoneId    string `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required" index:"true"`
+	BackupStorageId string `width:"36" charset:"ascii" nullable:"false" list:"user" create:"required" index:"true"`
+
+	// 恢复点目标, 单位分钟
+	RpoMinutes int `nullable:"false" default:"60" list:"user" create:"optional" update:"user"`
+	// 复制间隔, 单位分钟
+	ReplicateIntervalMinutes int `nullable:"false" default:"30" list:"user" create:"optional" update:"user"`
+	// 每台主机保留的副本个数
+	RetentionCount int  `nullable:"false" default:"3" list:"user" create:"optional" update:"user"`
+	Consistent     bool `nullable:"false" default:"false" list:"user" create:"optional" update:"user"`
+
+	NetworkMappings *api.SDrNetworkMappings `list:"user" create:"optional" update:"user"`
+}
+
+var DrProtectionGroupManager *SDrProtectionGroupManager
+
+func init() {
+	DrProtectionGroupManager = &SDrProtectionGroupManager{
+		SVirtualResourceBaseManager: db.NewVirtualResourceBaseManager(
+			SDrProtectionGroup{},
+			"drprotectiongroups_tbl",
+			"drprotectiongroup",
+			"drprotectiongroups",
+		),
+	}
+	DrProtectionGroupManager.SetVirtualObject(DrProtectionGroupManager)
+}
+
+func validateDrReplication(rpo, interval, retention int) error {
+	if rpo < api.DR_PROTECTION_GROUP_MIN_RPO_MINUTES {
+		return httperrors.NewInputParameterError("rpo_minutes should be at least %d", api.DR_PROTECTION_GROUP_MIN_RPO_MINUTES)
+	}
+	if interval <= 0 || interval > rpo {
+		return httperrors.NewInputParameterError("replicate_interval_minutes should be in range (0, %d]", rpo)
+	}
+	if retention < 1 {
+		return httperrors.NewInputParameterError("retention_count should be at least 1")
+	}
+	return nil
+}
+
+// validateDrNetworkMappings normalizes networks of mappings to ids, target
+// networks and test networks should be in target zone
+func validateDrNetworkMappings(userCred mcclient.TokenCredential, targetZoneId string, mappings api.SDrNetworkMappings) (api.SDrNetworkMappings, error) {
+	ret := make(api.SDrNetworkMappings, len(mappings))
+	for i := range mappings {
+		mapping := mappings[i]
+		_, err := validators.ValidateModel(userCred, NetworkManager, &mapping.SourceNetworkId)
+		if err != nil {
+			return nil, err
+		}
+		if ret[:i].Find(mapping.SourceNetworkId) != nil {
+			return nil, httperrors.NewInputParameterError("duplicate mapping of network %s", mappings[i].SourceNetworkId)
+		}
+		for _, netId := range []*string{&mapping.TargetNetworkId, &mapping.TestNetworkId} {
+			if netId == &mapping.TestNetworkId && len(*netId) == 0 {
+				continue
+			}
+			netObj, err := validators.ValidateModel(userCred, NetworkManager, netId)
+			if err != nil {
+				return nil, err
+			}
+			wire, err := netObj.(*SNetwork).GetWire()
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetWire of network %s", netObj.GetName())
+			}
+			if wire.ZoneId != targetZoneId {
+				return nil, httperrors.NewInputParameterError("network %s is not in target zone", netObj.GetName())
+			}
+		}
+		if mapping.TestNetworkId == mapping.TargetNetworkId {
+			return nil, httperrors.NewInputParameterError("test network of %s should be isolated from target network", mappings[i].SourceNetworkId)
+		}
+		ret[i] = mapping
+	}
+	return ret, nil
+}
+
+func (manager *SDrProtectionGroupManager) ValidateCreateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	ownerId mcclient.IIdentityProvider,
+	query jsonutils.JSONObject,
+	input api.DrProtectionGroupCreateInput,
+) (api.DrProtectionGroupCreateInput, error) {
+	var err error
+	input.VirtualResourceCreateInput, err = manager.SVirtualResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.VirtualResourceCreateInput)
+	if err != nil {
+		return input, err
+	}
+	zoneObj, err := validators.ValidateModel(userCred, ZoneManager, &input.TargetZoneId)
+	if err != nil {
+		return input, err
+	}
+	region, err := zoneObj.(*SZone).GetRegion()
+	if err != nil {
+		return input, errors.Wrapf(err, "GetRegion")
+	}
+	if region.Provider != api.CLOUD_PROVIDER_ONECLOUD {
+		return input, httperrors.NewUnsupportOperationError("target zone %s is not an on-premise zone", zoneObj.GetName())
+	}
+	if len(input.BackupStorageId) == 0 {
+		return input, httperrors.NewMissingParameterError("backup_storage_id")
+	}
+	bs, err := validateBackupPolicyStorage(userCred, input.BackupStorageId)
+	if err != nil {
+		return input, err
+	}
+	input.BackupStorageId = bs.Id
+	if input.RpoMinutes == 0 {
+		input.RpoMinutes = 60
+	}
+	if input.ReplicateIntervalMinutes == 0 {
+		input.ReplicateIntervalMinutes = input.RpoMinutes / 2
+	}
+	if input.RetentionCount == 0 {
+		input.RetentionCount = 3
+	}
+	err = validateDrReplication(input.RpoMinutes, input.ReplicateIntervalMinutes, input.RetentionCount)
+	if err != nil {
+		return input, err
+	}
+	input.NetworkMappings, err = validateDrNetworkMappings(userCred, input.TargetZoneId, input.NetworkMappings)
+	if err != nil {
+		return input, err
+	}
+	input.Status = api.DR_PROTECTION_GROUP_STATUS_READY
+	return input, nil
+}
+
+func (self *SDrProtectionGroup) ValidateUpdateData(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	input api.DrProtectionGroupUpdateInput,
+) (api.DrProtectionGroupUpdateInput, error) {
+	var err error
+	input.VirtualResourceBaseUpdateInput, err = self.SVirtualResourceBase.ValidateUpdateData(ctx, userCred, query, input.VirtualResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SVirtualResourceBase.ValidateUpdateData")
+	}
+	rpo, interval, retention := self.RpoMinutes, self.ReplicateIntervalMinutes, self.RetentionCount
+	if input.RpoMinutes != nil {
+		rpo = *input.RpoMinutes
+	}
+	if input.ReplicateIntervalMinutes != nil {
+		interval = *input.ReplicateIntervalMinutes
+	}
+	if input.RetentionCount != nil {
+		retention = *input.RetentionCount
+	}
+	err = validateDrReplication(rpo, interval, retention)
+	if err != nil {
+		return input, err
+	}
+	if input.NetworkMappings != nil {
+		input.NetworkMappings, err = validateDrNetworkMappings(userCred, self.TargetZoneId, input.NetworkMappings)
+		if err != nil {
+			return input, err
+		}
+		members, err := DrProtectionGroupMemberManager.fetchMembers(self.Id)
+		if err != nil {
+			return input, errors.Wrap(err, "fetchMembers")
+		}
+		for i := range members {
+			for _, nic := range members[i].getNics() {
+				if input.NetworkMappings.Find(nic.NetworkId) == nil {
+					return input, httperrors.NewInputParameterError("mapping of network %s used by server %s is missing", nic.NetworkId, members[i].GuestId)
+				}
+			}
+		}
+	}
+	return input, nil
+}
+
+func (manager *SDrProtectionGroupManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DrProtectionGroupListInput,
+) (*sqlchemy.SQuery, error) {
+	var err error
+	q, err = manager.SVirtualResourceBaseManager.ListItemFilter(ctx, q, userCred, query.VirtualResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SVirtualResourceBaseManager.ListItemFilter")
+	}
+	if len(query.TargetZoneId) > 0 {
+		_, err := validators.ValidateModel(userCred, ZoneManager, &query.TargetZoneId)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("target_zone_id", query.TargetZoneId)
+	}
+	if len(query.BackupStorageId) > 0 {
+		bs, err := validateBackupPolicyStorage(userCred, query.BackupStorageId)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Equals("backup_storage_id", bs.Id)
+	}
+	if query.RpoViolated != nil {
+		sq := DrProtectionGroupMemberManager.Query("drprotectiongroup_id").IsFalse("rpo_compliant").SubQuery()
+		if *query.RpoViolated {
+			q = q.In("id", sq)
+		} else {
+			q = q.NotIn("id", sq)
+		}
+	}
+	return q, nil
+}
+
+func (manager *SDrProtectionGroupManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.DrProtectionGroupListInput,
+) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.VirtualResourceListInput)
+}
+
+func (manager *SDrProtectionGroupManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	return manager.SVirtualResourceBaseManager.QueryDistinctExtraField(q, field)
+}
+
+func (manager *SDrProtectionGroupManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.DrProtectionGroupDetails {
+	rows := make([]api.DrProtectionGroupDetails, len(objs))
+	virtRows := manager.SVirtualResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	zoneIds := make([]string, len(objs))
+	bsIds := make([]string, len(objs))
+	for i := range rows {
+		group := objs[i].(*SDrProtectionGroup)
+		rows[i].VirtualResourceDetails = virtRows[i]
+		zoneIds[i] = group.TargetZoneId
+		bsIds[i] = group.BackupStorageId
+		members, err := DrProtectionGroupMemberManager.fetchMembers(group.Id)
+		if err != nil {
+			log.Errorf("fetch members of dr protection group %s: %s", group.Name, err)
+			continue
+		}
+		rows[i].MemberCount = len(members)
+		guestIds := make([]string, len(members))
+		for j := range members {
+			if !members[j].RpoCompliant {
+				rows[i].RpoViolatedCount++
+			}
+			guestIds[j] = members[j].GuestId
+		}
+		if isList {
+			continue
+		}
+		guestNames, _ := db.FetchIdNameMap2(GuestManager, guestIds)
+		rows[i].Members = make([]api.DrProtectionGroupMemberDetails, len(members))
+		for j, m := range members {
+			rows[i].Members[j] = api.DrProtectionGroupMemberDetails{
+				GuestId:          m.GuestId,
+				Guest:            guestNames[m.GuestId],
+				SourceZoneId:     m.SourceZoneId,
+				LastReplicaId:    m.LastReplicaId,
+				LastReplicatedAt: m.LastReplicatedAt,
+				ReplicatingId:    m.ReplicatingId,
+				RpoCompliant:     m.RpoCompliant,
+				TestGuestId:      m.TestGuestId,
+				FailoverGuestId:  m.FailoverGuestId,
+			}
+		}
+	}
+	zoneNames, err := db.FetchIdNameMap2(ZoneManager, zoneIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 fail %s", err)
+		return rows
+	}
+	bsNames, err := db.FetchIdNameMap2(BackupStorageManager, bsIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 fail %s", err)
+		return rows
+	}
+	for i := range rows {
+		rows[i].TargetZone = zoneNames[zoneIds[i]]
+		rows[i].BackupStorage = bsNames[bsIds[i]]
+	}
+	return rows
+}
+
+func (self *SDrProtectionGroup) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	cnt, err := DrProtectionGroupMemberManager.Query().Equals("drprotectiongroup_id", self.Id).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count members")
+	}
+	if cnt > 0 {
+		return httperrors.NewNotEmptyError("dr protection group has %d servers", cnt)
+	}
+	return self.SVirtualResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (self *SDrProtectionGroup) GetBackupStorage() (*SBackupStorage, error) {
+	bs, err := BackupStorageManager.FetchById(self.BackupStorageId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch backup storage %s", self.BackupStorageId)
+	}
+	return bs.(*SBackupStorage), nil
+}
+
+func (self *SDrProtectionGroup) GetMembers() ([]SDrProtectionGroupMember, error) {
+	return DrProtectionGroupMemberManager.fetchMembers(self.Id)
+}
+
+func (self *SDrProtectionGroup) GetNetworkMappings() api.SDrNetworkMappings {
+	if self.NetworkMappings == nil {
+		return nil
+	}
+	return *self.NetworkMappings
+}
+
+func fetchGuestDrNics(guest *SGuest) (api.SDrServerNics, error) {
+	gns, err := guest.GetNetworks("")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNetworks")
+	}
+	nics := make(api.SDrServerNics, len(gns))
+	for i, gn := range gns {
+		nics[i] = api.SDrServerNic{
+			Index:     int(gn.Index),
+			NetworkId: gn.NetworkId,
+			IpAddr:    gn.IpAddr,
+			Ip6Addr:   gn.Ip6Addr,
+			MacAddr:   gn.MacAddr,
+			Driver:    gn.Driver,
+			BwLimit:   gn.BwLimit,
+		}
+	}
+	return nics, nil
+}
+
+// PerformAddServers protects servers by group, every network of the servers
+// should have mapping in target zone
+func (self *SDrProtectionGroup) PerformAddServers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DrProtectionGroupAddServersInput) (jsonutils.JSONObject, error) {
+	if len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("servers")
+	}
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_READY {
+		return nil, httperrors.NewInvalidStatusError("can't add servers to dr protection group in status %s", self.Status)
+	}
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	mappings := self.GetNetworkMappings()
+	type sMember struct {
+		guest  *SGuest
+		zoneId string
+		nics   api.SDrServerNics
+	}
+	members := make([]sMember, 0, len(input.Servers))
+	for i := range input.Servers {
+		guestObj, err := validators.ValidateModel(userCred, GuestManager, &input.Servers[i])
+		if err != nil {
+			return nil, err
+		}
+		guest := guestObj.(*SGuest)
+		if guest.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("dr protection of %s server %s is not supported", guest.Hypervisor, guest.Name)
+		}
+		if len(guest.BackupHostId) > 0 {
+			return nil, httperrors.NewUnsupportOperationError("server %s with backup guest is not supported", guest.Name)
+		}
+		zone, err := guest.getZone()
+		if err != nil {
+			return nil, errors.Wrapf(err, "get zone of server %s", guest.Name)
+		}
+		if zone.Id == self.TargetZoneId {
+			return nil, httperrors.NewInputParameterError("server %s is already in target zone", guest.Name)
+		}
+		nics, err := fetchGuestDrNics(guest)
+		if err != nil {
+			return nil, err
+		}
+		for _, nic := range nics {
+			if mappings.Find(nic.NetworkId) == nil {
+				return nil, httperrors.NewInputParameterError("network %s of server %s has no mapping in target zone", nic.NetworkId, guest.Name)
+			}
+		}
+		members = append(members, sMember{guest: guest, zoneId: zone.Id, nics: nics})
+	}
+	for _, m := range members {
+		err := DrProtectionGroupMemberManager.addMember(ctx, self, m.guest, m.zoneId, m.nics)
+		if err != nil {
+			return nil, err
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_BIND, input, userCred, true)
+	return nil, nil
+}
+
+func (self *SDrProtectionGroup) PerformRemoveServers(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DrProtectionGroupRemoveServersInput) (jsonutils.JSONObject, error) {
+	if len(input.Servers) == 0 {
+		return nil, httperrors.NewMissingParameterError("servers")
+	}
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_READY {
+		return nil, httperrors.NewInvalidStatusError("can't remove servers from dr protection group in status %s", self.Status)
+	}
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	for _, id := range input.Servers {
+		guestId := id
+		if guestObj, err := GuestManager.FetchByIdOrName(userCred, id); err == nil {
+			guestId = guestObj.GetId()
+		}
+		member, err := DrProtectionGroupMemberManager.fetchMember(self.Id, guestId)
+		if err != nil {
+			return nil, err
+		}
+		err = member.Delete(ctx, userCred)
+		if err != nil {
+			return nil, errors.Wrapf(err, "remove server %s", id)
+		}
+	}
+	logclient.AddActionLogWithContext(ctx, self, logclient.ACT_UNBIND, input, userCred, true)
+	return nil, nil
+}
+
+// PerformReplicate starts replication of servers not being replicated
+// immediately regardless of replicate interval
+func (self *SDrProtectionGroup) PerformReplicate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_READY && self.Status != api.DR_PROTECTION_GROUP_STATUS_TESTING {
+		return nil, httperrors.NewInvalidStatusError("can't replicate dr protection group in status %s", self.Status)
+	}
+	if err := self.validateBackupStorage(); err != nil {
+		return nil, httperrors.NewInvalidStatusError("%v", err)
+	}
+	members, err := self.GetMembers()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMembers")
+	}
+	now := time.Now()
+	for i := range members {
+		if len(members[i].ReplicatingId) > 0 {
+			continue
+		}
+		err := self.replicate(ctx, userCred, &members[i], now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "replicate server %s", members[i].GuestId)
+		}
+	}
+	return nil, nil
+}
+
+func (self *SDrProtectionGroup) validateMembersReplicated(members []SDrProtectionGroupMember) error {
+	if len(members) == 0 {
+		return httperrors.NewInputParameterError("no servers in dr protection group")
+	}
+	for i := range members {
+		if len(members[i].LastReplicaId) == 0 {
+			return httperrors.NewInputParameterError("server %s has no replica yet", members[i].GuestId)
+		}
+	}
+	return nil
+}
+
+// PerformTestFailover boots copies of servers from the latest replicas in
+// target zone, the copies are attached to isolated test networks so that
+// protected servers keep running
+func (self *SDrProtectionGroup) PerformTestFailover(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_READY && self.Status != api.DR_PROTECTION_GROUP_STATUS_TEST_FAILOVER_FAILED {
+		return nil, httperrors.NewInvalidStatusError("can't test failover dr protection group in status %s", self.Status)
+	}
+	members, err := self.GetMembers()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMembers")
+	}
+	err = self.validateMembersReplicated(members)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if len(members[i].TestGuestId) > 0 {
+			return nil, httperrors.NewInvalidStatusError("test copy of server %s exists, cleanup test first", members[i].GuestId)
+		}
+		_, err := members[i].GetTargetNetworks(self.GetNetworkMappings(), true)
+		if err != nil {
+			return nil, httperrors.NewInputParameterError("%v", err)
+		}
+	}
+	return nil, self.StartDrProtectionGroupTask(ctx, userCred, "DrProtectionGroupTestFailoverTask", nil, api.DR_PROTECTION_GROUP_STATUS_TEST_FAILING_OVER)
+}
+
+// PerformCleanupTest deletes copies booted by test failover
+func (self *SDrProtectionGroup) PerformCleanupTest(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_TESTING && self.Status != api.DR_PROTECTION_GROUP_STATUS_TEST_FAILOVER_FAILED {
+		return nil, httperrors.NewInvalidStatusError("can't cleanup test of dr protection group in status %s", self.Status)
+	}
+	members, err := self.GetMembers()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMembers")
+	}
+	for i := range members {
+		if len(members[i].TestGuestId) == 0 {
+			continue
+		}
+		if guest := GuestManager.FetchGuestById(members[i].TestGuestId); guest != nil {
+			err := guest.StartDeleteGuestTask(ctx, userCred, "", api.ServerDeleteInput{OverridePendingDelete: true})
+			if err != nil {
+				return nil, errors.Wrapf(err, "delete test server %s", guest.Name)
+			}
+		}
+		err := members[i].SetTestGuestId("")
+		if err != nil {
+			return nil, errors.Wrap(err, "SetTestGuestId")
+		}
+	}
+	self.SetStatus(userCred, api.DR_PROTECTION_GROUP_STATUS_READY, "cleanup test")
+	return nil, nil
+}
+
+// PerformFailover recreates servers from the latest replicas in target zone
+// with mapped networks, source servers are stopped before if reachable
+func (self *SDrProtectionGroup) PerformFailover(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.DrProtectionGroupFailoverInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_READY && self.Status != api.DR_PROTECTION_GROUP_STATUS_FAILOVER_FAILED {
+		return nil, httperrors.NewInvalidStatusError("can't failover dr protection group in status %s", self.Status)
+	}
+	members, err := self.GetMembers()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMembers")
+	}
+	err = self.validateMembersReplicated(members)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		_, err := members[i].GetTargetNetworks(self.GetNetworkMappings(), false)
+		if err != nil {
+			return nil, httperrors.NewInputParameterError("%v", err)
+		}
+	}
+	return nil, self.StartDrProtectionGroupTask(ctx, userCred, "DrProtectionGroupFailoverTask", jsonutils.Marshal(input).(*jsonutils.JSONDict), api.DR_PROTECTION_GROUP_STATUS_FAILING_OVER)
+}
+
+// PerformFailback replicates servers running in target zone back and
+// recreates them on source side with original network config, stale source
+// servers are deleted to release their addresses
+func (self *SDrProtectionGroup) PerformFailback(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if self.Status != api.DR_PROTECTION_GROUP_STATUS_FAILED_OVER && self.Status != api.DR_PROTECTION_GROUP_STATUS_FAILBACK_FAILED {
+		return nil, httperrors.NewInvalidStatusError("can't failback dr protection group in status %s", self.Status)
+	}
+	if err := self.validateBackupStorage(); err != nil {
+		return nil, httperrors.NewInvalidStatusError("%v", err)
+	}
+	members, err := self.GetMembers()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetMembers")
+	}
+	for i := range members {
+		if len(members[i].FailoverGuestId) == 0 {
+			continue
+		}
+		guest := GuestManager.FetchGuestById(members[i].FailoverGuestId)
+		if guest == nil {
+			return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), members[i].FailoverGuestId)
+		}
+		if !utils.IsInStringArray(guest.Status, []string{api.VM_RUNNING, api.VM_READY}) {
+			return nil, httperrors.NewInvalidStatusError("server %s in target zone is %s", guest.Name, guest.Status)
+		}
+		if source := members[i].GetGuest(); source != nil && source.Status == api.VM_RUNNING {
+			return nil, httperrors.NewInvalidStatusError("source server %s is running, stop it before failback", source.Name)
+		}
+	}
+	return nil, self.StartDrProtectionGroupTask(ctx, userCred, "DrProtectionGroupFailbackTask", nil, api.DR_PROTECTION_GROUP_STATUS_FAILING_BACK)
+}
+
+func (self *SDrProtectionGroup) StartDrProtectionGroupTask(ctx context.Context, userCred mcclient.TokenCredential, taskName string, params *jsonutils.JSONDict, status string) error {
+	self.SetStatus(userCred, status, "")
+	task, err := taskman.TaskManager.NewTask(ctx, taskName, self, userCred, params, "", "", nil)
+	if err != nil {
+		return errors.Wrapf(err, "NewTask %s", taskName)
+	}
+	return task.ScheduleRun(nil)
+}
+
+// CreateRecoveryGuest recreates server of member from instance backup in
+// zone with networks, the guest create task is a subtask of parentTaskId
+func (self *SDrProtectionGroup) CreateRecoveryGuest(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember, instanceBackupId, zoneId, name string, networks []*api.NetworkConfig, parentTaskId string) (*SGuest, error) {
+	ibObj, err := InstanceBackupManager.FetchById(instanceBackupId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch instance backup %s", instanceBackupId)
+	}
+	ib := ibObj.(*SInstanceBackup)
+	input := api.ServerCreateInput{
+		ServerConfigs: &api.ServerConfigs{
+			PreferZone: zoneId,
+			Networks:   networks,
+		},
+	}
+	input.GenerateName = name
+	input.Description = fmt.Sprintf("recovered from instance backup %s by dr protection group %s", ib.Name, self.Name)
+	input.InstanceBackupId = ib.Id
+	input.ProjectId = ib.ProjectId
+	input.ProjectDomainId = ib.DomainId
+	ownerId := ib.GetOwnerId()
+
+	params := input.JSON(input)
+	guestObj, err := db.DoCreate(GuestManager, ctx, userCred, nil, params, ownerId)
+	if err != nil {
+		return nil, errors.Wrap(err, "create server")
+	}
+	guest := guestObj.(*SGuest)
+	func() {
+		lockman.LockObject(ctx, guest)
+		defer lockman.ReleaseObject(ctx, guest)
+
+		guest.PostCreate(ctx, userCred, ownerId, nil, params)
+	}()
+	params.Set("parent_task_id", jsonutils.NewString(parentTaskId))
+	GuestManager.OnCreateComplete(ctx, []db.IModel{guest}, userCred, ownerId, nil, []jsonutils.JSONObject{params})
+	return guest, nil
+}
+
+// CreateReplica starts an instance backup of guest to backup storage of group,
+// the backup task is a subtask of parentTaskId if not empty
+func (self *SDrProtectionGroup) CreateReplica(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest, parentTaskId string) (*SInstanceBackup, error) {
+	lockman.LockClass(ctx, InstanceSnapshotManager, guest.ProjectId)
+	defer lockman.ReleaseClass(ctx, InstanceSnapshotManager, guest.ProjectId)
+
+	name, err := db.GenerateName(ctx, InstanceBackupManager, guest.GetOwnerId(), fmt.Sprintf("%s%s%s", guest.Name, api.DR_REPLICA_NAME_INFIX, time.Now().Format("20060102150405")))
+	if err != nil {
+		return nil, errors.Wrap(err, "GenerateName")
+	}
+	data := jsonutils.NewDict()
+	data.Set("name", jsonutils.NewString(name))
+	err = guest.validateCreateInstanceBackup(ctx, userCred, jsonutils.NewDict(), data)
+	if err != nil {
+		return nil, err
+	}
+	ib, err := InstanceBackupManager.CreateInstanceBackup(ctx, userCred, guest, name, self.BackupStorageId)
+	if err != nil {
+		return nil, errors.Wrap(err, "CreateInstanceBackup")
+	}
+	err = guest.InheritTo(ctx, userCred, ib)
+	if err != nil {
+		log.Errorf("unable to inherit from guest %s to instance backup %s: %s", guest.Id, ib.Id, err)
+	}
+	guest.SetStatus(userCred, api.VM_START_INSTANCE_BACKUP, "dr replicate")
+	err = ib.StartCreateInstanceBackupTask(ctx, userCred, self.replicaTaskParams(), parentTaskId)
+	if err != nil {
+		return ib, errors.Wrap(err, "StartCreateInstanceBackupTask")
+	}
+	return ib, nil
+}
+
+// replicaTaskParams chooses how replicas are taken.  Application consistent
+// replicas are full backups from fsfreezed snapshots, otherwise replicas are
+// incremental live backups based on dirty bitmaps, which fall back to full
+// backups when there is no base or server is not running
+func (self *SDrProtectionGroup) replicaTaskParams() *jsonutils.JSONDict {
+	params := jsonutils.NewDict()
+	if self.Consistent {
+		params.Set("consistent", jsonutils.JSONTrue)
+	} else {
+		params.Set("incremental", jsonutils.JSONTrue)
+	}
+	return params
+}
+
+func (self *SDrProtectionGroup) validateBackupStorage() error {
+	bs, err := self.GetBackupStorage()
+	if err != nil {
+		return err
+	}
+	if bs.Status != api.BACKUPSTORAGE_STATUS_ONLINE {
+		return fmt.Errorf("backup storage %s is %s", bs.Name, bs.Status)
+	}
+	return nil
+}
+
+func (self *SDrProtectionGroup) replicate(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember, now time.Time) error {
+	guest := member.GetGuest()
+	if guest == nil {
+		return errors.Wrapf(errors.ErrNotFound, "server %s", member.GuestId)
+	}
+	ib, err := self.CreateReplica(ctx, userCred, guest, "")
+	if ib != nil {
+		if err := member.startReplicate(ib.Id, now); err != nil {
+			return errors.Wrap(err, "startReplicate")
+		}
+	}
+	return err
+}
+
+// AutoReplicate syncs replicating results, checks RPO compliance of protected
+// servers, starts replication of servers due and prunes replicas out of
+// retention
+func (manager *SDrProtectionGroupManager) AutoReplicate(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	groups := make([]SDrProtectionGroup, 0)
+	err := db.FetchModelObjects(manager, manager.Query(), &groups)
+	if err != nil {
+		log.Errorf("fetch dr protection groups: %s", err)
+		return
+	}
+	now := time.Now()
+	for i := range groups {
+		groups[i].autoReplicate(ctx, userCred, now)
+	}
+}
+
+func (self *SDrProtectionGroup) autoReplicate(ctx context.Context, userCred mcclient.TokenCredential, now time.Time) {
+	members, err := self.GetMembers()
+	if err != nil {
+		log.Errorf("fetch members of dr protection group %s: %s", self.Name, err)
+		return
+	}
+	// servers run in target zone after failover, replication stops until failback
+	replicating := utils.IsInStringArray(self.Status, []string{
+		api.DR_PROTECTION_GROUP_STATUS_READY,
+		api.DR_PROTECTION_GROUP_STATUS_TESTING,
+		api.DR_PROTECTION_GROUP_STATUS_TEST_FAILOVER_FAILED,
+	})
+	bsErr := self.validateBackupStorage()
+	for i := range members {
+		member := &members[i]
+		if len(member.ReplicatingId) > 0 {
+			self.syncReplica(ctx, userCred, member, now)
+		}
+		if !replicating {
+			continue
+		}
+		self.checkRpo(ctx, userCred, member, now)
+		if len(member.ReplicatingId) > 0 || member.ReplicateStartAt.Add(time.Duration(self.ReplicateIntervalMinutes)*time.Minute).After(now) {
+			continue
+		}
+		if bsErr != nil {
+			log.Warningf("skip replication of dr protection group %s: %s", self.Name, bsErr)
+			continue
+		}
+		err := self.replicate(ctx, userCred, member, now)
+		if errors.Cause(err) == errors.ErrNotFound {
+			log.Infof("server %s of dr protection group %s is gone, remove it", member.GuestId, self.Name)
+			member.Delete(ctx, userCred)
+			continue
+		}
+		if err != nil {
+			self.notifyReplicateFail(ctx, userCred, member, err.Error())
+		}
+	}
+}
+
+func (self *SDrProtectionGroup) syncReplica(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember, now time.Time) {
+	ibObj, err := InstanceBackupManager.FetchById(member.ReplicatingId)
+	if err != nil && errors.Cause(err) != sql.ErrNoRows {
+		log.Errorf("fetch replica %s: %s", member.ReplicatingId, err)
+		return
+	}
+	switch {
+	case ibObj == nil:
+		member.finishReplicate(nil)
+		self.notifyReplicateFail(ctx, userCred, member, fmt.Sprintf("replica %s is removed", member.ReplicatingId))
+	case ibObj.(*SInstanceBackup).Status == api.INSTANCE_BACKUP_STATUS_READY:
+		ib := ibObj.(*SInstanceBackup)
+		member.finishReplicate(ib)
+		self.pruneReplicas(ctx, userCred, member)
+	case strings.HasSuffix(ibObj.(*SInstanceBackup).Status, "_failed"):
+		ib := ibObj.(*SInstanceBackup)
+		member.finishReplicate(nil)
+		self.notifyReplicateFail(ctx, userCred, member, fmt.Sprintf("replica %s is %s", ib.Name, ib.Status))
+	case member.ReplicateStartAt.Add(BACKUP_POLICY_RUN_TIMEOUT).Before(now):
+		// stuck replica blocks the following replications
+		member.finishReplicate(nil)
+		self.notifyReplicateFail(ctx, userCred, member, fmt.Sprintf("replica %s is still %s after %s", member.ReplicatingId, ibObj.(*SInstanceBackup).Status, BACKUP_POLICY_RUN_TIMEOUT))
+	}
+}
+
+func (self *SDrProtectionGroup) notifyReplicateFail(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember, reason string) {
+	msg := fmt.Sprintf("replication of server %s failed: %s", member.GuestId, reason)
+	db.OpsLog.LogEvent(self, db.ACT_DR_REPLICATE_FAIL, msg, userCred)
+	notifyclient.NotifySystemErrorWithCtx(ctx, self.Id, self.Name, db.ACT_DR_REPLICATE_FAIL, msg)
+}
+
+func (self *SDrProtectionGroup) isRpoCompliant(member *SDrProtectionGroupMember, now time.Time) bool {
+	return now.Sub(member.recoveryPoint()) <= time.Duration(self.RpoMinutes)*time.Minute
+}
+
+// checkRpo notifies once server violates RPO, and once it recovers
+func (self *SDrProtectionGroup) checkRpo(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember, now time.Time) {
+	compliant := self.isRpoCompliant(member, now)
+	if compliant == member.RpoCompliant {
+		return
+	}
+	err := member.setRpoCompliant(compliant)
+	if err != nil {
+		log.Errorf("update rpo compliance of server %s: %s", member.GuestId, err)
+		return
+	}
+	if compliant {
+		db.OpsLog.LogEvent(self, db.ACT_DR_RPO_RECOVERED, fmt.Sprintf("server %s recovered RPO", member.GuestId), userCred)
+		return
+	}
+	msg := fmt.Sprintf("latest recovery point of server %s is %s, exceeds RPO of %d minutes", member.GuestId, member.recoveryPoint().Format(time.RFC3339), self.RpoMinutes)
+	db.OpsLog.LogEvent(self, db.ACT_DR_RPO_VIOLATED, msg, userCred)
+	notifyclient.NotifySystemErrorWithCtx(ctx, self.Id, self.Name, db.ACT_DR_RPO_VIOLATED, msg)
+}
+
+// selectPruneReplicas returns replicas beyond retention count, the latest
+// replica is the recovery point and never pruned
+func selectPruneReplicas(replicaIds []string, retention int, lastReplicaId string) []string {
+	ret := []string{}
+	for i := len(replicaIds) - 1 - retention; i >= 0; i-- {
+		if replicaIds[i] != lastReplicaId {
+			ret = append(ret, replicaIds[i])
+		}
+	}
+	return ret
+}
+
+// pruneReplicas deletes replicas recorded on member beyond retention count,
+// other backups of server are never touched.  Replicas still referenced, e.g.
+// base of incremental backups, are kept and retried later
+func (self *SDrProtectionGroup) pruneReplicas(ctx context.Context, userCred mcclient.TokenCredential, member *SDrProtectionGroupMember) {
+	pruneIds := selectPruneReplicas(member.getReplicaIds(), self.RetentionCount, member.LastReplicaId)
+	if len(pruneIds) == 0 {
+		return
+	}
+	replicas := make([]SInstanceBackup, 0)
+	err := db.FetchModelObjects(InstanceBackupManager, InstanceBackupManager.Query().In("id", pruneIds), &replicas)
+	if err != nil {
+		log.Errorf("fetch replicas of server %s: %s", member.GuestId, err)
+		return
+	}
+	replicaMap := map[string]*SInstanceBackup{}
+	for i := range replicas {
+		replicaMap[replicas[i].Id] = &replicas[i]
+	}
+	removed := []string{}
+	for _, id := range pruneIds {
+		replica, ok := replicaMap[id]
+		if !ok {
+			removed = append(removed, id)
+			continue
+		}
+		if replica.Status != api.INSTANCE_BACKUP_STATUS_READY {
+			continue
+		}
+		err := replica.ValidateDeleteCondition(ctx, nil)
+		if err == nil {
+			err = replica.StartInstanceBackupDeleteTask(ctx, userCred, "", false)
+		}
+		if err != nil {
+			log.Warningf("prune replica %s of dr protection group %s: %s", replica.Name, self.Name, err)
+			continue
+		}
+		removed = append(removed, id)
+	}
+	if len(removed) > 0 {
+		err := member.removeReplicaIds(removed)
+		if err != nil {
+			log.Errorf("remove pruned replicas of server %s: %s", member.GuestId, err)
+		}
+	}
+}
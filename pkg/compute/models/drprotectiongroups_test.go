@@ -0,0 +1,133 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestSelectPruneReplicas(t *testing.T) {
+	cases := []struct {
+		name      string
+		ids       []string
+		retention int
+		last      string
+		want      []string
+	}{
+		{
+			name:      "within retention",
+			ids:       []string{"r0", "r1"},
+			retention: 2,
+			last:      "r1",
+			want:      []string{},
+		},
+		{
+			name:      "oldest beyond retention",
+			ids:       []string{"r0", "r1", "r2", "r3"},
+			retention: 2,
+			last:      "r3",
+			want:      []string{"r1", "r0"},
+		},
+		{
+			name:      "latest replica is kept",
+			ids:       []string{"r0", "r1", "r2"},
+			retention: 0,
+			last:      "r2",
+			want:      []string{"r1", "r0"},
+		},
+		{
+			name:      "no replicas",
+			retention: 1,
+			want:      []string{},
+		},
+	}
+	for _, c := range cases {
+		got := selectPruneReplicas(c.ids, c.retention, c.last)
+		if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", c.want) {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestDrProtectionGroupMemberReplicaIds(t *testing.T) {
+	member := &SDrProtectionGroupMember{}
+	if ids := member.getReplicaIds(); len(ids) != 0 {
+		t.Fatalf("want no replicas, got %v", ids)
+	}
+	member.ReplicaIds = &api.SDrReplicaIds{"r0", "r1"}
+	ids := member.getReplicaIds()
+	ids[0] = "changed"
+	if (*member.ReplicaIds)[0] != "r0" {
+		t.Errorf("getReplicaIds should return a copy")
+	}
+	// user backups named like replicas are not recorded and never pruned
+	got := selectPruneReplicas(member.getReplicaIds(), 1, "r1")
+	if fmt.Sprintf("%v", got) != "[r0]" {
+		t.Errorf("want [r0], got %v", got)
+	}
+}
+
+func TestDrProtectionGroupIsRpoCompliant(t *testing.T) {
+	t0 := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	group := &SDrProtectionGroup{RpoMinutes: 60}
+	member := &SDrProtectionGroupMember{ProtectedAt: t0, RpoCompliant: true}
+	steps := []struct {
+		name       string
+		replicated time.Time
+		now        time.Time
+		want       bool
+	}{
+		{name: "before first replica", now: t0.Add(30 * time.Minute), want: true},
+		{name: "at rpo", now: t0.Add(60 * time.Minute), want: true},
+		{name: "first replica late", now: t0.Add(61 * time.Minute), want: false},
+		{name: "replicated", replicated: t0.Add(62 * time.Minute), now: t0.Add(63 * time.Minute), want: true},
+		{name: "replica stale", now: t0.Add(123 * time.Minute), want: false},
+	}
+	for _, step := range steps {
+		if !step.replicated.IsZero() {
+			member.LastReplicatedAt = step.replicated
+		}
+		got := group.isRpoCompliant(member, step.now)
+		if got != step.want {
+			t.Errorf("%s: want compliant %v, got %v", step.name, step.want, got)
+		}
+	}
+}
+
+func TestDrProtectionGroupReplicaTaskParams(t *testing.T) {
+	cases := []struct {
+		consistent bool
+		want       string
+	}{
+		{consistent: false, want: `{"incremental":true}`},
+		{consistent: true, want: `{"consistent":true}`},
+	}
+	for _, c := range cases {
+		group := &SDrProtectionGroup{Consistent: c.consistent}
+		got := group.replicaTaskParams()
+		if got.String() != c.want {
+			t.Errorf("consistent %v: want %s, got %s", c.consistent, c.want, got)
+		}
+		if jsonutils.QueryBoolean(got, "consistent", false) && jsonutils.QueryBoolean(got, "incremental", false) {
+			t.Errorf("consistent replicas are full backups")
+		}
+	}
+}
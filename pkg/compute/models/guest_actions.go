@@ -493,6 +493,8 @@ func (self *SGuest) GetSchedMigrateParams(
 	input *api.ServerMigrateForecastInput,
 ) *schedapi.ScheduleInput {
 	schedDesc := self.ToSchedDesc()
+	// host local shares can't follow guest to other hosts
+	schedDesc.HostLocalFileShare = self.hasHostLocalFileShare()
 	if input.PreferHostId != "" {
 		schedDesc.ServerConfig.PreferHost = input.PreferHostId
 	}
@@ -3456,6 +3458,38 @@ func (self *SGuest) PerformSetQemuParams(ctx context.Context, userCred mcclient.
 			return nil, err
 		}
 	}
+	vtpm, err := data.GetString(api.VM_METADATA_ENABLE_VTPM)
+	if err == nil {
+		if self.Hypervisor != api.HYPERVISOR_KVM {
+			return nil, httperrors.NewUnsupportOperationError("vTPM is not supported by hypervisor %s", self.Hypervisor)
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_ENABLE_VTPM, vtpm, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
+	secureBoot, err := data.GetString(api.VM_METADATA_SECURE_BOOT)
+	if err == nil {
+		if secureBoot == "true" {
+			if self.Hypervisor != api.HYPERVISOR_KVM {
+				return nil, httperrors.NewUnsupportOperationError("Secure Boot is not supported by hypervisor %s", self.Hypervisor)
+			}
+			if self.Bios != "UEFI" {
+				return nil, httperrors.NewInputParameterError("Secure Boot requires UEFI boot mode")
+			}
+			if !apis.IsARM(self.OsArch) && self.Machine != api.VM_MACHINE_TYPE_Q35 {
+				return nil, httperrors.NewInputParameterError("Secure Boot requires machine type %s", api.VM_MACHINE_TYPE_Q35)
+			}
+			host, _ := self.GetHost()
+			if host != nil && !host.IsOvmfSecbootCapable(ctx) {
+				return nil, httperrors.NewInputParameterError("host %s has no Secure Boot capable OVMF", host.Name)
+			}
+		}
+		err = self.SetMetadata(ctx, api.VM_METADATA_SECURE_BOOT, secureBoot, userCred)
+		if err != nil {
+			return nil, err
+		}
+	}
 	usbContType, err := data.GetString("usb_controller_type")
 	if err == nil {
 		err = self.SetMetadata(ctx, "usb_controller_type", usbContType, userCred)
@@ -5077,7 +5111,7 @@ func (self *SGuest) PerformInstanceSnapshot(
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to inherit from guest %s to instance snapshot %s", self.GetId(), instanceSnapshot.GetId())
 	}
-	err = self.InstaceCreateSnapshot(ctx, userCred, instanceSnapshot, pendingUsage)
+	err = self.InstaceCreateSnapshot(ctx, userCred, instanceSnapshot, pendingUsage, input.Consistent)
 	if err != nil {
 		quotas.CancelPendingUsage(
 			ctx, userCred, pendingUsage, pendingUsage, false)
@@ -5128,7 +5162,7 @@ func (self *SGuest) PerformInstanceBackup(
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to inherit from guest %s to instance backup %s", self.GetId(), instanceBackup.GetId())
 	}
-	err = self.InstanceCreateBackup(ctx, userCred, instanceBackup)
+	err = self.InstanceCreateBackup(ctx, userCred, instanceBackup, jsonutils.QueryBoolean(data, "consistent", false), jsonutils.QueryBoolean(data, "incremental", false))
 	if err != nil {
 		return nil, httperrors.NewInternalServerError("start create backup task failed: %s", err)
 	}
@@ -5140,14 +5174,26 @@ func (self *SGuest) InstaceCreateSnapshot(
 	userCred mcclient.TokenCredential,
 	instanceSnapshot *SInstanceSnapshot,
 	pendingUsage *SRegionQuota,
+	consistent bool,
 ) error {
 	self.SetStatus(userCred, api.VM_START_INSTANCE_SNAPSHOT, "instance snapshot")
-	return instanceSnapshot.StartCreateInstanceSnapshotTask(ctx, userCred, pendingUsage, "")
+	params := jsonutils.NewDict()
+	if consistent {
+		params.Set("consistent", jsonutils.JSONTrue)
+	}
+	return instanceSnapshot.StartCreateInstanceSnapshotTask(ctx, userCred, pendingUsage, params, "")
 }
 
-func (self *SGuest) InstanceCreateBackup(ctx context.Context, userCred mcclient.TokenCredential, instanceBackup *SInstanceBackup) error {
+func (self *SGuest) InstanceCreateBackup(ctx context.Context, userCred mcclient.TokenCredential, instanceBackup *SInstanceBackup, consistent, incremental bool) error {
 	self.SetStatus(userCred, api.VM_START_INSTANCE_BACKUP, "instance backup")
-	return instanceBackup.StartCreateInstanceBackupTask(ctx, userCred, "")
+	params := jsonutils.NewDict()
+	if consistent {
+		params.Set("consistent", jsonutils.JSONTrue)
+	}
+	if incremental {
+		params.Set("incremental", jsonutils.JSONTrue)
+	}
+	return instanceBackup.StartCreateInstanceBackupTask(ctx, userCred, params, "")
 }
 
 func (self *SGuest) PerformInstanceSnapshotReset(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.ServerResetInput) (jsonutils.JSONObject, error) {
@@ -5694,6 +5740,9 @@ func (self *SGuest) PerformChangeStorage(ctx context.Context, userCred mcclient.
 	if input.TargetStorageId == "" {
 		return nil, httperrors.NewNotEmptyError("Storage id is empty")
 	}
+	if input.MaxBandwidthMb < 0 {
+		return nil, httperrors.NewInputParameterError("max_bandwidth_mb should not be negative")
+	}
 
 	// validate storage
 	storageObj, err := StorageManager.FetchByIdOrName(userCred, input.TargetStorageId)
@@ -5750,6 +5799,9 @@ func (self *SGuest) PerformChangeDiskStorage(ctx context.Context, userCred mccli
 	if input.TargetStorageId == "" {
 		return nil, httperrors.NewNotEmptyError("Storage id is empty")
 	}
+	if input.MaxBandwidthMb < 0 {
+		return nil, httperrors.NewInputParameterError("max_bandwidth_mb should not be negative")
+	}
 
 	// validate disk
 	disks, err := self.GetDisks()
@@ -5806,6 +5858,35 @@ func (self *SGuest) PerformChangeDiskStorage(ctx context.Context, userCred mccli
 	return nil, self.StartChangeDiskStorageTask(ctx, userCred, internalInput, "")
 }
 
+// PerformCancelChangeDiskStorage cancels copying disk data of running guest,
+// change storage task then fails and rolls back to source disk
+func (self *SGuest) PerformCancelChangeDiskStorage(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input *api.ServerCancelChangeDiskStorageInput) (jsonutils.JSONObject, error) {
+	if self.Status != api.VM_DISK_CHANGE_STORAGE {
+		return nil, httperrors.NewInvalidStatusError("Cannot cancel change disk storage in status %s", self.Status)
+	}
+	disks, err := self.GetDisks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get server %s disks", self.GetName())
+	}
+	diskIds := []string{}
+	for _, disk := range disks {
+		if len(input.DiskId) == 0 || input.DiskId == disk.Id || input.DiskId == disk.Name {
+			diskIds = append(diskIds, disk.Id)
+		}
+	}
+	if len(diskIds) == 0 {
+		return nil, httperrors.NewNotFoundError("Disk %s not found on server %s", input.DiskId, self.GetName())
+	}
+	for _, diskId := range diskIds {
+		err := self.GetDriver().RequestCancelChangeDiskStorage(ctx, userCred, self, diskId, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cancel change storage of disk %s", diskId)
+		}
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_CANCEL, input, userCred, true)
+	return nil, nil
+}
+
 func (self *SGuest) StartChangeDiskStorageTask(ctx context.Context, userCred mcclient.TokenCredential, input *api.ServerChangeDiskStorageInternalInput, parentTaskId string) error {
 	reason := fmt.Sprintf("Change disk %s to storage %s", input.DiskId, input.TargetStorageId)
 	self.SetStatus(userCred, api.VM_DISK_CHANGE_STORAGE, reason)
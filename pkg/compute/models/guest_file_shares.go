@@ -0,0 +1,313 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SGuestFileShareManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+var GuestFileShareManager *SGuestFileShareManager
+
+// shell metacharacters not allowed in path of file share
+const fileSharePathForbiddenChars = "`$;&|<>()\\'\"!*?\n"
+
+func init() {
+	GuestFileShareManager = &SGuestFileShareManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SGuestFileShare{},
+			"guest_file_shares_tbl",
+			"guest_file_share",
+			"guest_file_shares",
+		),
+	}
+	GuestFileShareManager.SetVirtualObject(GuestFileShareManager)
+}
+
+// guest file share exposes a host directory to kvm guest through virtio-fs,
+// the directory is either under a shared file storage or a host local path,
+// guest with host local share is bound to its host
+type SGuestFileShare struct {
+	db.SStatusStandaloneResourceBase
+
+	GuestId   string `width:"36" charset:"ascii" nullable:"false" index:"true" list:"admin" create:"admin_required"`
+	StorageId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+	Path      string `width:"256" charset:"utf8" nullable:"false" list:"admin" create:"admin_required"`
+	MountTag  string `width:"36" charset:"ascii" nullable:"false" list:"admin" create:"admin_optional"`
+	ReadOnly  bool   `nullable:"false" default:"false" list:"admin" create:"admin_optional"`
+}
+
+func (manager *SGuestFileShareManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.GuestFileShareCreateInput) (api.GuestFileShareCreateInput, error) {
+	var err error
+	input.StatusStandaloneResourceCreateInput, err = manager.SStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	if len(input.ServerId) == 0 {
+		return input, httperrors.NewMissingParameterError("server_id")
+	}
+	guestObj, err := GuestManager.FetchByIdOrName(userCred, input.ServerId)
+	if err != nil {
+		if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+			return input, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), input.ServerId)
+		}
+		return input, httperrors.NewGeneralError(err)
+	}
+	guest := guestObj.(*SGuest)
+	input.ServerId = guest.Id
+	if guest.Hypervisor != api.HYPERVISOR_KVM {
+		return input, httperrors.NewUnsupportOperationError("hypervisor %s not support file share", guest.Hypervisor)
+	}
+	if !utils.IsInStringArray(guest.Status, []string{api.VM_READY, api.VM_RUNNING}) {
+		return input, httperrors.NewInvalidStatusError("can't add file share to guest in status %s", guest.Status)
+	}
+	// virtiofsd maps guest memory, which must be shared since guest started
+	if guest.Status == api.VM_RUNNING && guest.GetMetadata(ctx, api.VM_METADATA_ENABLE_VIRTIOFS, nil) != "true" {
+		return input, httperrors.NewInvalidStatusError("guest memory is not shared, stop guest to add the first file share")
+	}
+
+	input.Path = strings.TrimSpace(input.Path)
+	if len(input.Path) == 0 {
+		return input, httperrors.NewMissingParameterError("path")
+	}
+	input.Path = path.Clean(input.Path)
+	// path is passed to virtiofsd through shell script on host
+	if strings.ContainsAny(input.Path, fileSharePathForbiddenChars) {
+		return input, httperrors.NewInputParameterError("path %s contains forbidden characters %s", input.Path, fileSharePathForbiddenChars)
+	}
+	if len(input.StorageId) > 0 {
+		storageObj, err := StorageManager.FetchByIdOrName(userCred, input.StorageId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return input, httperrors.NewResourceNotFoundError2(StorageManager.Keyword(), input.StorageId)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		storage := storageObj.(*SStorage)
+		if !utils.IsInStringArray(storage.StorageType, api.SHARED_FILE_STORAGE) {
+			return input, httperrors.NewInputParameterError("storage %s of type %s can't be shared to guest", storage.Name, storage.StorageType)
+		}
+		host, err := guest.GetHost()
+		if err != nil {
+			return input, httperrors.NewGeneralError(errors.Wrap(err, "GetHost"))
+		}
+		if host.GetHoststorageOfId(storage.Id) == nil {
+			return input, httperrors.NewInputParameterError("storage %s is not attached to host %s", storage.Name, host.Name)
+		}
+		input.StorageId = storage.Id
+		// path is relative to storage mount point
+		input.Path = strings.TrimPrefix(input.Path, "/")
+		if input.Path == ".." || strings.HasPrefix(input.Path, "../") {
+			return input, httperrors.NewInputParameterError("path %s is out of storage", input.Path)
+		}
+	} else if !path.IsAbs(input.Path) || input.Path == "/" {
+		return input, httperrors.NewInputParameterError("host local path %s must be an absolute directory", input.Path)
+	}
+
+	if len(input.MountTag) == 0 {
+		input.MountTag = input.Name
+	}
+	if len(input.MountTag) == 0 || len(input.MountTag) > api.GUEST_FILE_SHARE_MOUNT_TAG_MAX_LEN {
+		return input, httperrors.NewInputParameterError("mount_tag length must be between 1 and %d", api.GUEST_FILE_SHARE_MOUNT_TAG_MAX_LEN)
+	}
+	cnt, err := manager.Query().Equals("guest_id", guest.Id).Equals("mount_tag", input.MountTag).CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(errors.Wrap(err, "count mount tag"))
+	}
+	if cnt > 0 {
+		return input, httperrors.NewDuplicateResourceError("mount_tag %s already used by guest %s", input.MountTag, guest.Name)
+	}
+	return input, nil
+}
+
+func (self *SGuestFileShare) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	input := api.GuestFileShareCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal")
+	}
+	self.GuestId = input.ServerId
+	self.Status = api.GUEST_FILE_SHARE_STATUS_READY
+	return self.SStatusStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (self *SGuestFileShare) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
+	self.SStatusStandaloneResourceBase.PostCreate(ctx, userCred, ownerId, query, data)
+	guest := self.GetGuest()
+	if guest == nil {
+		return
+	}
+	err := guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_VIRTIOFS, "true", userCred)
+	if err != nil {
+		log.Errorf("guest %s enable virtiofs: %s", guest.Name, err)
+	}
+	self.syncGuest(ctx, userCred, guest)
+}
+
+func (self *SGuestFileShare) PostDelete(ctx context.Context, userCred mcclient.TokenCredential) {
+	self.SStatusStandaloneResourceBase.PostDelete(ctx, userCred)
+	if guest := self.GetGuest(); guest != nil {
+		self.syncGuest(ctx, userCred, guest)
+	}
+}
+
+// syncGuest hotplugs or unplugs the share of running guest, others take effect on next start
+func (self *SGuestFileShare) syncGuest(ctx context.Context, userCred mcclient.TokenCredential, guest *SGuest) {
+	if guest.Status != api.VM_RUNNING {
+		return
+	}
+	err := guest.StartSyncTask(ctx, userCred, false, "")
+	if err != nil {
+		log.Errorf("guest %s start sync task: %s", guest.Name, err)
+	}
+}
+
+func (self *SGuestFileShare) GetGuest() *SGuest {
+	return GuestManager.FetchGuestById(self.GuestId)
+}
+
+func (self *SGuestFileShare) GetJsonDesc() *api.GuestFileShareJsonDesc {
+	return &api.GuestFileShareJsonDesc{
+		Id:        self.Id,
+		MountTag:  self.MountTag,
+		StorageId: self.StorageId,
+		Path:      self.Path,
+		ReadOnly:  self.ReadOnly,
+	}
+}
+
+func (manager *SGuestFileShareManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestFileShareListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ServerId) > 0 {
+		guestObj, err := GuestManager.FetchByIdOrName(userCred, query.ServerId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return nil, httperrors.NewResourceNotFoundError2(GuestManager.Keyword(), query.ServerId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("guest_id", guestObj.GetId())
+	}
+	if len(query.StorageId) > 0 {
+		storageObj, err := StorageManager.FetchByIdOrName(userCred, query.StorageId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return nil, httperrors.NewResourceNotFoundError2(StorageManager.Keyword(), query.StorageId)
+			}
+			return nil, httperrors.NewGeneralError(err)
+		}
+		q = q.Equals("storage_id", storageObj.GetId())
+	}
+	return q, nil
+}
+
+func (manager *SGuestFileShareManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.GuestFileShareListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SGuestFileShareManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SGuestFileShareManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.GuestFileShareDetails {
+	rows := make([]api.GuestFileShareDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	guestIds := make([]string, len(objs))
+	storageIds := make([]string, len(objs))
+	for i := range rows {
+		rows[i] = api.GuestFileShareDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+		}
+		share := objs[i].(*SGuestFileShare)
+		guestIds[i] = share.GuestId
+		storageIds[i] = share.StorageId
+	}
+	guests := make(map[string]SGuest)
+	err := db.FetchStandaloneObjectsByIds(GuestManager, guestIds, &guests)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds guests: %s", err)
+		return rows
+	}
+	storages := make(map[string]SStorage)
+	err = db.FetchStandaloneObjectsByIds(StorageManager, storageIds, &storages)
+	if err != nil {
+		log.Errorf("FetchStandaloneObjectsByIds storages: %s", err)
+		return rows
+	}
+	for i := range rows {
+		if guest, ok := guests[guestIds[i]]; ok {
+			rows[i].Guest = guest.Name
+		}
+		if storage, ok := storages[storageIds[i]]; ok {
+			rows[i].Storage = storage.Name
+		}
+	}
+	return rows
+}
+
+func (self *SGuest) GetFileShares() ([]SGuestFileShare, error) {
+	q := GuestFileShareManager.Query().Equals("guest_id", self.Id).Asc("created_at")
+	shares := []SGuestFileShare{}
+	err := db.FetchModelObjects(GuestFileShareManager, q, &shares)
+	if err != nil {
+		return nil, errors.Wrapf(err, "db.FetchModelObjects")
+	}
+	return shares, nil
+}
@@ -235,9 +235,68 @@ func (self *SGuestdisk) GetDiskJsonDescAtHost(ctx context.Context, host *SHost,
 	desc.Mountpoint = self.Mountpoint
 	desc.Dev = disk.getDev()
 	desc.IsSSD = disk.IsSsd
+	if host.HostType == api.HOST_TYPE_HYPERVISOR && self.Iops == 0 && self.Bps == 0 {
+		// limits set by io-throttle take precedence over performance class
+		self.fillDiskPerformanceThrottle(desc, disk, disk.DiskSize)
+	}
 	return desc
 }
 
+// fillDiskPerformanceThrottle sets io limits of disk with sizeMb, which
+// differs from DiskSize when the disk is being resized
+func (self *SGuestdisk) fillDiskPerformanceThrottle(desc *api.GuestdiskJsonDesc, disk *SDisk, sizeMb int) bool {
+	class := disk.GetDiskPerformanceClass()
+	if class == nil {
+		return false
+	}
+	if class.SharedBudget {
+		// budget of the group is accounted by all disks of the guest in class
+		guest := self.getGuest()
+		if guest == nil {
+			return false
+		}
+		gds, err := guest.GetGuestDisks()
+		if err != nil {
+			log.Errorf("guest %s GetGuestDisks: %v", guest.Name, err)
+			return false
+		}
+		for i := range gds {
+			if gds[i].DiskId == disk.Id || gds[i].Iops > 0 || gds[i].Bps > 0 {
+				continue
+			}
+			d := gds[i].GetDisk()
+			if d == nil {
+				continue
+			}
+			if c := d.GetDiskPerformanceClass(); c != nil && c.Id == class.Id {
+				sizeMb += d.DiskSize
+			}
+		}
+	}
+	class.fillThrottle(desc, sizeMb)
+	return true
+}
+
+// GetDiskPerformanceThrottle returns io limits of disk resized to sizeMb,
+// nil if limits are not derived from performance class
+func (self *SGuestdisk) GetDiskPerformanceThrottle(disk *SDisk, sizeMb int) *api.DiskIoThrottle {
+	if self.Iops > 0 || self.Bps > 0 {
+		return nil
+	}
+	desc := &api.GuestdiskJsonDesc{}
+	if !self.fillDiskPerformanceThrottle(desc, disk, sizeMb) {
+		return nil
+	}
+	return &api.DiskIoThrottle{
+		Iops:          desc.Iops,
+		Bps:           desc.Bps,
+		BurstIops:     desc.BurstIops,
+		BurstBps:      desc.BurstBps,
+		BurstSeconds:  desc.BurstSeconds,
+		ThrottleGroup: desc.ThrottleGroup,
+	}
+}
+
 func (self *SGuestdisk) Delete(ctx context.Context, userCred mcclient.TokenCredential) error {
 	return db.DeleteModel(ctx, userCred, self)
 }
@@ -236,6 +236,7 @@ type newGuestNetworkArgs struct {
 	index int8
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -328,6 +329,17 @@ func (manager *SGuestnetworkManager) newGuestNetwork(
 			gn.IpAddr = ipAddr
 		}
 
+		if network.IsSupportIPv6() && provider == api.CLOUD_PROVIDER_ONECLOUD {
+			ip6Addr, err := network.GetFreeIP6(network.GetUsedIp6Addresses(), args.ip6Addr)
+			if err != nil {
+				return nil, errors.Wrap(err, "GetFreeIP6")
+			}
+			if len(args.ip6Addr) > 0 && ip6Addr != parseIPv6(args.ip6Addr).String() && requiredDesignatedIp {
+				return nil, fmt.Errorf("candidate ipv6 %s is occupied!", args.ip6Addr)
+			}
+			gn.Ip6Addr = ip6Addr
+		}
+
 		if vpc.Id != api.DEFAULT_VPC_ID && provider == api.CLOUD_PROVIDER_ONECLOUD {
 			var err error
 			GuestnetworkManager.lockAllocMappedAddr(ctx)
@@ -605,6 +617,13 @@ func (gn *SGuestnetwork) getJsonDesc() *api.GuestnetworkJsonDesc {
 	desc.ExternalId = net.ExternalId
 	desc.TeamWith = gn.TeamWith
 
+	if len(gn.Ip6Addr) > 0 && !gn.Virtual {
+		desc.Ip6 = gn.Ip6Addr
+		desc.Gateway6 = net.GuestGateway6
+		desc.Masklen6 = net.GuestIp6Mask
+		desc.Dns6 = net.GetDNS6()
+	}
+
 	guest := gn.getGuest()
 	if guest.GetHypervisor() != api.HYPERVISOR_KVM || gn.IsSriovWithoutOffload() {
 		manual := true
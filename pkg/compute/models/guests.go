@@ -1366,6 +1366,38 @@ func (manager *SGuestManager) ExpandBatchCreateData(
 	return input, nil
 }
 
+// validateSecureBootCreateData ensures UEFI boot mode and SMM capable machine,
+// and the prefer host has secure boot capable OVMF, other hosts are filtered by scheduler
+func (manager *SGuestManager) validateSecureBootCreateData(ctx context.Context, input *api.ServerCreateInput) error {
+	if input.Hypervisor != api.HYPERVISOR_KVM {
+		return httperrors.NewInputParameterError("Secure Boot is not supported by hypervisor %s", input.Hypervisor)
+	}
+	if len(input.Bios) == 0 {
+		input.Bios = "UEFI"
+	} else if input.Bios != "UEFI" {
+		return httperrors.NewInputParameterError("Secure Boot requires UEFI boot mode")
+	}
+	if !apis.IsARM(input.OsArch) {
+		// secure boot on x86 requires SMM which is only supported by q35
+		if len(input.Machine) == 0 {
+			input.Machine = api.VM_MACHINE_TYPE_Q35
+		} else if input.Machine != api.VM_MACHINE_TYPE_Q35 {
+			return httperrors.NewInputParameterError("Secure Boot requires machine type %s", api.VM_MACHINE_TYPE_Q35)
+		}
+	}
+	if len(input.PreferHost) > 0 {
+		hostObj, err := HostManager.FetchById(input.PreferHost)
+		if err != nil {
+			return errors.Wrapf(err, "fetch host %s", input.PreferHost)
+		}
+		host := hostObj.(*SHost)
+		if !host.IsOvmfSecbootCapable(ctx) {
+			return httperrors.NewInputParameterError("host %s has no Secure Boot capable OVMF", host.Name)
+		}
+	}
+	return nil
+}
+
 func (manager *SGuestManager) validateCreateData(
 	ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider,
 	query jsonutils.JSONObject, data *jsonutils.JSONDict) (*api.ServerCreateInput, error) {
@@ -1520,6 +1552,9 @@ func (manager *SGuestManager) validateCreateData(
 			support := desc == "true"
 			imgSupportUEFI = &support
 		}
+		if imgProperties[imageapi.IMAGE_SECURE_BOOT] == "true" {
+			input.SecureBoot = true
+		}
 		if input.OsArch == apis.OS_ARCH_AARCH64 {
 			// arm image supports UEFI by default
 			support := true
@@ -1545,6 +1580,9 @@ func (manager *SGuestManager) validateCreateData(
 			imgProperties = map[string]string{"os_type": "Linux"}
 		}
 		input.DisableUsbKbd = imgProperties[imageapi.IMAGE_DISABLE_USB_KBD] == "true"
+		if imgProperties[imageapi.IMAGE_REQUIRE_VTPM] == "true" {
+			input.EnableVtpm = true
+		}
 		imgIsWindows := imgProperties[imageapi.IMAGE_OS_TYPE] == "Windows"
 
 		hasGpuVga := func() bool {
@@ -1606,6 +1644,16 @@ func (manager *SGuestManager) validateCreateData(
 		return nil, httperrors.NewBadRequestError("Miss operating system???")
 	}
 
+	if input.EnableVtpm && input.Hypervisor != api.HYPERVISOR_KVM {
+		return nil, httperrors.NewInputParameterError("vTPM is not supported by hypervisor %s", input.Hypervisor)
+	}
+
+	if input.SecureBoot {
+		if err := manager.validateSecureBootCreateData(ctx, input); err != nil {
+			return nil, err
+		}
+	}
+
 	if input.Hypervisor == api.HYPERVISOR_KVM {
 		if input.IsDaemon == nil && options.Options.SetKVMServerAsDaemonOnCreate {
 			setDaemon := true
@@ -2259,6 +2307,12 @@ func (guest *SGuest) PostCreate(ctx context.Context, userCred mcclient.TokenCred
 	if jsonutils.QueryBoolean(data, imageapi.IMAGE_DISABLE_USB_KBD, false) {
 		guest.SetMetadata(ctx, imageapi.IMAGE_DISABLE_USB_KBD, "true", userCred)
 	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_ENABLE_VTPM, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_ENABLE_VTPM, "true", userCred)
+	}
+	if jsonutils.QueryBoolean(data, api.VM_METADATA_SECURE_BOOT, false) {
+		guest.SetMetadata(ctx, api.VM_METADATA_SECURE_BOOT, "true", userCred)
+	}
 
 	userData, _ := data.GetString("user_data")
 	if len(userData) > 0 {
@@ -2331,11 +2385,35 @@ func (manager *SGuestManager) SetPropertiesWithInstanceSnapshot(
 	}
 }
 
+// SetUefiNvramWithInstanceBackup restores uefi variables saved in instance backup
+func (manager *SGuestManager) SetUefiNvramWithInstanceBackup(
+	ctx context.Context, userCred mcclient.TokenCredential, backupId string, items []db.IModel,
+) {
+	ibObj, err := InstanceBackupManager.FetchById(backupId)
+	if err != nil {
+		return
+	}
+	ib := ibObj.(*SInstanceBackup)
+	if ib.ServerMetadata == nil {
+		return
+	}
+	nvram, _ := ib.ServerMetadata.GetString(api.VM_METADATA_UEFI_NVRAM)
+	if len(nvram) == 0 {
+		return
+	}
+	for i := 0; i < len(items); i++ {
+		guest := items[i].(*SGuest)
+		guest.SetMetadata(ctx, api.VM_METADATA_UEFI_NVRAM, nvram, userCred)
+	}
+}
+
 func (manager *SGuestManager) OnCreateComplete(ctx context.Context, items []db.IModel, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data []jsonutils.JSONObject) {
 	input := api.ServerCreateInput{}
 	data[0].Unmarshal(&input)
 	if len(input.InstanceSnapshotId) > 0 {
 		manager.SetPropertiesWithInstanceSnapshot(ctx, userCred, input.InstanceSnapshotId, items)
+	} else if len(input.InstanceBackupId) > 0 {
+		manager.SetUefiNvramWithInstanceBackup(ctx, userCred, input.InstanceBackupId, items)
 	}
 	pendingUsage, pendingRegionUsage := getGuestResourceRequirements(ctx, userCred, input, ownerId, len(items), input.Backup)
 	err := RunBatchCreateTask(ctx, items, userCred, data, pendingUsage, pendingRegionUsage, "GuestBatchCreateTask", input.ParentTaskId)
@@ -3300,6 +3378,7 @@ type Attach2NetworkArgs struct {
 	Network *SNetwork
 
 	IpAddr              string
+	Ip6Addr             string
 	AllocDir            api.IPAllocationDirection
 	TryReserved         bool
 	RequireDesignatedIP bool
@@ -3325,6 +3404,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 		network: args.Network,
 
 		ipAddr:              args.IpAddr,
+		ip6Addr:             args.Ip6Addr,
 		allocDir:            args.AllocDir,
 		tryReserved:         args.TryReserved,
 		requireDesignatedIP: args.RequireDesignatedIP,
@@ -3343,6 +3423,7 @@ func (args *Attach2NetworkArgs) onceArgs(i int) attach2NetworkOnceArgs {
 	}
 	if i > 0 {
 		r.ipAddr = ""
+		r.ip6Addr = ""
 		r.bwLimit = 0
 		r.virtual = true
 		r.tryReserved = false
@@ -3359,6 +3440,7 @@ type attach2NetworkOnceArgs struct {
 	network *SNetwork
 
 	ipAddr              string
+	ip6Addr             string
 	allocDir            api.IPAllocationDirection
 	tryReserved         bool
 	requireDesignatedIP bool
@@ -3430,6 +3512,7 @@ func (self *SGuest) attach2NetworkOnce(
 		index: index,
 
 		ipAddr:              args.ipAddr,
+		ip6Addr:             args.ip6Addr,
 		allocDir:            args.allocDir,
 		tryReserved:         args.tryReserved,
 		requireDesignatedIP: args.requireDesignatedIP,
@@ -4184,6 +4267,7 @@ func (self *SGuest) attach2NamedNetworkDesc(ctx context.Context, userCred mcclie
 			Network:             net,
 			PendingUsage:        pendingUsage,
 			IpAddr:              netConfig.Address,
+			Ip6Addr:             netConfig.Address6,
 			NicDriver:           netConfig.Driver,
 			NumQueues:           netConfig.NumQueues,
 			BwLimit:             netConfig.BwLimit,
@@ -4933,6 +5017,12 @@ func (self *SGuest) GetJsonDescAtHypervisor(ctx context.Context, host *SHost) *a
 		desc.IsolatedDevices = append(desc.IsolatedDevices, dev.getDesc())
 	}
 
+	// virtio-fs file shares
+	fileShares, _ := self.GetFileShares()
+	for i := range fileShares {
+		desc.FileShares = append(desc.FileShares, fileShares[i].GetJsonDesc())
+	}
+
 	// nics, domain
 	desc.Domain = options.Options.DNSDomain
 	nics, _ := self.GetNetworks("")
@@ -5902,9 +5992,28 @@ func (self *SGuest) ToSchedDesc() *schedapi.ScheduleInput {
 	config.Hypervisor = self.GetHypervisor()
 	desc.ServerConfig = *config
 	desc.OsArch = self.OsArch
+	desc.SecureBoot = self.GetMetadata(context.Background(), api.VM_METADATA_SECURE_BOOT, nil) == "true"
+	fileShares, _ := self.GetFileShares()
+	for i := range fileShares {
+		if len(fileShares[i].StorageId) > 0 && !utils.IsInStringArray(fileShares[i].StorageId, desc.FileShareStorageIds) {
+			desc.FileShareStorageIds = append(desc.FileShareStorageIds, fileShares[i].StorageId)
+		}
+	}
 	return desc
 }
 
+// hasHostLocalFileShare guest shares host local directory, which is only
+// available on the current host
+func (self *SGuest) hasHostLocalFileShare() bool {
+	fileShares, _ := self.GetFileShares()
+	for i := range fileShares {
+		if len(fileShares[i].StorageId) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (self *SGuest) FillGroupSchedDesc(desc *api.ServerConfigs) {
 	groups := make([]SGroupguest, 0)
 	err := GroupguestManager.Query().Equals("guest_id", self.Id).All(&groups)
@@ -6098,6 +6207,7 @@ func (self *SGuest) toCreateInput() *api.ServerCreateInput {
 	r.Vga = self.Vga
 	r.Vdi = self.Vdi
 	r.Bios = self.Bios
+	r.SecureBoot = self.GetMetadata(context.Background(), api.VM_METADATA_SECURE_BOOT, nil) == "true"
 	r.Description = self.Description
 	r.BootOrder = self.BootOrder
 	r.DisableDelete = new(bool)
@@ -698,6 +698,11 @@ func (hh *SHost) IsArmHost() bool {
 	return hh.CpuArchitecture == apis.OS_ARCH_AARCH64
 }
 
+// IsOvmfSecbootCapable is reported by host agent on register
+func (hh *SHost) IsOvmfSecbootCapable(ctx context.Context) bool {
+	return hh.GetMetadata(ctx, api.HOSTMETA_OVMF_SECBOOT, nil) == "true"
+}
+
 func (hh *SHost) GetZone() (*SZone, error) {
 	zone, err := ZoneManager.FetchById(hh.ZoneId)
 	if err != nil {
@@ -4179,6 +4184,10 @@ func (hh *SHost) PerformPing(ctx context.Context, userCred mcclient.TokenCredent
 		}
 		hh.SetMetadata(ctx, "root_partition_used_capacity_mb", input.RootPartitionUsedCapacityMb, userCred)
 		hh.SetMetadata(ctx, "memory_used_mb", input.MemoryUsedMb, userCred)
+		if len(input.NumaNodeStats) > 0 {
+			hh.SetMetadata(ctx, api.HOSTMETA_NUMA_NODE_STATS, input.NumaNodeStats, userCred)
+		}
+		hh.syncGuestMemoryStats(ctx, userCred, input.GuestMemoryStats)
 	}
 	if hh.HostStatus != api.HOST_ONLINE {
 		hh.PerformOnline(ctx, userCred, query, nil)
@@ -4207,6 +4216,57 @@ func (hh *SHost) PerformPing(ctx context.Context, userCred mcclient.TokenCredent
 	return result, nil
 }
 
+// syncGuestMemoryStats saves actual memory of guests after ballooning,
+// scheduler takes the reclaimed memory into account when rating host load.
+// Metadata is written only on change as hosts report on every ping, and is
+// removed from guests no longer reported, e.g. stopped or balloon disabled
+func (hh *SHost) syncGuestMemoryStats(ctx context.Context, userCred mcclient.TokenCredential, stats []api.SGuestMemoryStat) {
+	guests, err := hh.GetGuests()
+	if err != nil {
+		log.Errorf("host %s get guests: %s", hh.Name, err)
+		return
+	}
+	guestIds := make([]string, len(guests))
+	for i := range guests {
+		guestIds[i] = guests[i].Id
+	}
+	current := make(map[string]string)
+	if len(guestIds) > 0 {
+		records := make([]db.SMetadata, 0)
+		q := db.Metadata.Query().Equals("obj_type", GuestManager.Keyword()).
+			Equals("key", api.VM_METADATA_BALLOON_ACTUAL_MEM_MB).In("obj_id", guestIds)
+		err := db.FetchModelObjects(db.Metadata, q, &records)
+		if err != nil {
+			log.Errorf("host %s fetch guest balloon metadata: %s", hh.Name, err)
+			return
+		}
+		for i := range records {
+			current[records[i].ObjId] = records[i].Value
+		}
+	}
+	reported := make(map[string]int64, len(stats))
+	var balloonedMb int64
+	for _, stat := range stats {
+		reported[stat.GuestId] = stat.ActualMemSizeMb
+		if stat.MemSizeMb > stat.ActualMemSizeMb {
+			balloonedMb += stat.MemSizeMb - stat.ActualMemSizeMb
+		}
+	}
+	for i := range guests {
+		guest := &guests[i]
+		actualMb, ok := reported[guest.Id]
+		switch {
+		case ok && current[guest.Id] != fmt.Sprintf("%d", actualMb):
+			guest.SetMetadata(ctx, api.VM_METADATA_BALLOON_ACTUAL_MEM_MB, actualMb, userCred)
+		case !ok && len(current[guest.Id]) > 0:
+			guest.RemoveMetadata(ctx, api.VM_METADATA_BALLOON_ACTUAL_MEM_MB, userCred)
+		}
+	}
+	if hh.GetMetadata(ctx, api.HOSTMETA_GUEST_MEMORY_BALLOONED_MB, userCred) != fmt.Sprintf("%d", balloonedMb) {
+		hh.SetMetadata(ctx, api.HOSTMETA_GUEST_MEMORY_BALLOONED_MB, balloonedMb, userCred)
+	}
+}
+
 func (host *SHost) getHostLogicalCores() ([]int, error) {
 	cpuObj, err := host.SysInfo.Get("cpu_info")
 	if err != nil {
@@ -5738,13 +5798,6 @@ func (host *SHost) PerformHostExitMaintenance(ctx context.Context, userCred mccl
 }
 
 func (host *SHost) PerformHostMaintenance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
-	if host.HostType != api.HOST_TYPE_HYPERVISOR {
-		return nil, httperrors.NewBadRequestError("host type %s can't do host maintenance", host.HostType)
-	}
-	if host.HostStatus == api.BAREMETAL_START_MAINTAIN {
-		return nil, httperrors.NewBadRequestError("unsupport on host status %s", host.HostStatus)
-	}
-
 	var preferHostId string
 	preferHost, _ := data.GetString("prefer_host")
 	if len(preferHost) > 0 {
@@ -5759,6 +5812,17 @@ func (host *SHost) PerformHostMaintenance(ctx context.Context, userCred mcclient
 			return nil, errors.Wrap(err, "IsAssignable")
 		}
 	}
+	return nil, host.StartHostMaintenance(ctx, userCred, preferHostId, "")
+}
+
+// StartHostMaintenance migrates all kvm guests off the host and disables it
+func (host *SHost) StartHostMaintenance(ctx context.Context, userCred mcclient.TokenCredential, preferHostId string, parentTaskId string) error {
+	if host.HostType != api.HOST_TYPE_HYPERVISOR {
+		return httperrors.NewBadRequestError("host type %s can't do host maintenance", host.HostType)
+	}
+	if host.HostStatus == api.BAREMETAL_START_MAINTAIN {
+		return httperrors.NewBadRequestError("unsupport on host status %s", host.HostStatus)
+	}
 
 	guests := host.GetKvmGuests()
 	for i := 0; i < len(guests); i++ {
@@ -5766,11 +5830,11 @@ func (host *SHost) PerformHostMaintenance(ctx context.Context, userCred mcclient
 		defer lockman.ReleaseObject(ctx, &guests[i])
 		guest, err := guests[i].validateForBatchMigrate(ctx, false)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		guests[i] = *guest
 		if host.HostStatus == api.HOST_OFFLINE && guests[i].Status != api.VM_UNKNOWN {
-			return nil, httperrors.NewBadRequestError("Host %s can't migrate guests %s in status %s",
+			return httperrors.NewBadRequestError("Host %s can't migrate guests %s in status %s",
 				host.HostStatus, guests[i].Name, guests[i].Status)
 		}
 	}
@@ -5790,7 +5854,7 @@ func (host *SHost) PerformHostMaintenance(ctx context.Context, userCred mcclient
 	kwargs := jsonutils.NewDict()
 	kwargs.Set("guests", jsonutils.Marshal(hostGuests))
 	kwargs.Set("prefer_host_id", jsonutils.NewString(preferHostId))
-	return nil, host.StartMaintainTask(ctx, userCred, kwargs)
+	return host.StartMaintainTask(ctx, userCred, kwargs, parentTaskId)
 }
 
 func (host *SHost) autoMigrateOnHostShutdown(ctx context.Context) bool {
@@ -5946,9 +6010,9 @@ func (host *SHost) SetStatus(userCred mcclient.TokenCredential, status string, r
 	return nil
 }
 
-func (host *SHost) StartMaintainTask(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict) error {
+func (host *SHost) StartMaintainTask(ctx context.Context, userCred mcclient.TokenCredential, data *jsonutils.JSONDict, parentTaskId string) error {
 	host.SetStatus(userCred, api.BAREMETAL_START_MAINTAIN, "start maintenance")
-	if task, err := taskman.TaskManager.NewTask(ctx, "HostMaintainTask", host, userCred, data, "", "", nil); err != nil {
+	if task, err := taskman.TaskManager.NewTask(ctx, "HostMaintainTask", host, userCred, data, parentTaskId, "", nil); err != nil {
 		log.Errorln(err)
 		return err
 	} else {
@@ -75,6 +75,8 @@ type SInstanceBackup struct {
 	InstanceType string `width:"64" charset:"utf8" nullable:"true" list:"user" create:"optional"`
 	// 主机备份容量和
 	SizeMb int `nullable:"false" list:"user"`
+	// 是否为应用一致性备份, 仅单磁盘主机的备份可以保证
+	ApplicationConsistent bool `nullable:"false" default:"false" list:"user"`
 }
 
 type SInstanceBackupManager struct {
@@ -255,9 +257,9 @@ func (manager *SInstanceBackupManager) FetchCustomizeColumns(
 	return rows
 }
 
-func (self *SInstanceBackup) StartCreateInstanceBackupTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+func (self *SInstanceBackup) StartCreateInstanceBackupTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
 	self.SetStatus(userCred, api.INSTANCE_BACKUP_STATUS_CREATING, "")
-	if task, err := taskman.TaskManager.NewTask(ctx, "InstanceBackupCreateTask", self, userCred, nil, parentTaskId, "", nil); err != nil {
+	if task, err := taskman.TaskManager.NewTask(ctx, "InstanceBackupCreateTask", self, userCred, params, parentTaskId, "", nil); err != nil {
 		return err
 	} else {
 		task.ScheduleRun(nil)
@@ -317,6 +319,12 @@ func (manager *SInstanceBackupManager) fillInstanceBackup(ctx context.Context, u
 	if osVersion := guest.GetMetadata(ctx, "os_version", nil); len(osVersion) > 0 {
 		serverMetadata.Set("os_version", jsonutils.NewString(osVersion))
 	}
+	// keep uefi variables so that boot entries and enrolled keys survive clone and recovery
+	for _, key := range []string{api.VM_METADATA_SECURE_BOOT, api.VM_METADATA_UEFI_NVRAM} {
+		if val := guest.GetMetadata(ctx, key, nil); len(val) > 0 {
+			serverMetadata.Set(key, jsonutils.NewString(val))
+		}
+	}
 	secs, _ := guest.GetSecgroups()
 	if len(secs) > 0 {
 		secIds := make([]string, len(secs))
@@ -411,6 +419,9 @@ func (self *SInstanceBackup) ToInstanceCreateInput(sourceInput *api.ServerCreate
 	if sourceInput.Bios == "" {
 		sourceInput.Bios = createInput.Bios
 	}
+	if createInput.SecureBoot {
+		sourceInput.SecureBoot = true
+	}
 	if sourceInput.BootOrder == "" {
 		sourceInput.BootOrder = createInput.BootOrder
 	}
@@ -433,6 +444,14 @@ func (self *SInstanceBackup) ValidateDeleteCondition(ctx context.Context, info j
 	if self.Status == api.INSTANCE_SNAPSHOT_START_DELETE || self.Status == api.INSTANCE_SNAPSHOT_RESET {
 		return httperrors.NewForbiddenError("can't delete instance snapshot with wrong status")
 	}
+	backupIds := InstanceBackupJointManager.Query("disk_backup_id").Equals("instance_backup_id", self.Id).SubQuery()
+	cnt, err := DiskBackupManager.Query().In("parent_backup_id", backupIds).CountWithError()
+	if err != nil {
+		return errors.Wrap(err, "count incremental backups")
+	}
+	if cnt > 0 {
+		return httperrors.NewBadRequestError("instance backup is the base of %d incremental backups", cnt)
+	}
 	return nil
 }
 
@@ -520,6 +539,21 @@ func (self *SInstanceBackup) PerformPack(ctx context.Context, userCred mcclient.
 	return nil, nil
 }
 
+// PerformVerify rechecks integrity of all disk backups in backup storage
+func (self *SInstanceBackup) PerformVerify(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.InstanceBackupVerifyInput) (jsonutils.JSONObject, error) {
+	if !utils.IsInStringArray(self.Status, []string{api.INSTANCE_BACKUP_STATUS_READY, api.INSTANCE_BACKUP_STATUS_VERIFY_FAILED}) {
+		return nil, errors.Wrapf(httperrors.ErrInvalidStatus, "cannot verify instance backup in status %s", self.Status)
+	}
+	self.SetStatus(userCred, api.INSTANCE_BACKUP_STATUS_VERIFYING, "")
+	task, err := taskman.TaskManager.NewTask(ctx, "InstanceBackupVerifyTask", self, userCred, nil, "", "", nil)
+	if err != nil {
+		return nil, err
+	} else {
+		task.ScheduleRun(nil)
+	}
+	return nil, nil
+}
+
 func (manager *SInstanceBackupManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.InstanceBackupManagerCreateFromPackageInput) (api.InstanceBackupManagerCreateFromPackageInput, error) {
 	if input.PackageName == "" {
 		return input, httperrors.NewMissingParameterError("miss package_name")
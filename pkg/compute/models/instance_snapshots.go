@@ -92,6 +92,8 @@ type SInstanceSnapshot struct {
 	MemoryFilePath string `width:"512" charset:"utf8" nullable:"true" get:"user" list:"user"`
 	// 内存文件校验和
 	MemoryFileChecksum string `width:"32" charset:"ascii" nullable:"true" get:"user" list:"user"`
+	// 是否为应用一致性快照, 仅单磁盘主机的快照可以保证
+	ApplicationConsistent bool `nullable:"false" default:"false" get:"user" list:"user"`
 }
 
 type SInstanceSnapshotManager struct {
@@ -328,10 +330,11 @@ func (self *SInstanceSnapshot) StartCreateInstanceSnapshotTask(
 	ctx context.Context,
 	userCred mcclient.TokenCredential,
 	pendingUsage quotas.IQuota,
+	params *jsonutils.JSONDict,
 	parentTaskId string,
 ) error {
 	if task, err := taskman.TaskManager.NewTask(
-		ctx, "InstanceSnapshotCreateTask", self, userCred, nil, parentTaskId, "", pendingUsage); err != nil {
+		ctx, "InstanceSnapshotCreateTask", self, userCred, params, parentTaskId, "", pendingUsage); err != nil {
 		return err
 	} else {
 		task.ScheduleRun(nil)
@@ -402,6 +405,12 @@ func (manager *SInstanceSnapshotManager) fillInstanceSnapshot(ctx context.Contex
 	if osVersion := guest.GetMetadata(ctx, "os_version", nil); len(osVersion) > 0 {
 		serverMetadata.Set("os_version", jsonutils.NewString(osVersion))
 	}
+	// keep uefi variables so that boot entries and enrolled keys survive clone and recovery
+	for _, key := range []string{api.VM_METADATA_SECURE_BOOT, api.VM_METADATA_UEFI_NVRAM} {
+		if val := guest.GetMetadata(ctx, key, nil); len(val) > 0 {
+			serverMetadata.Set(key, jsonutils.NewString(val))
+		}
+	}
 	secs, _ := guest.GetSecgroups()
 	if len(secs) > 0 {
 		secIds := make([]string, len(secs))
@@ -477,6 +486,9 @@ func (self *SInstanceSnapshot) ToInstanceCreateInput(
 	}
 
 	sourceInput.Disks = serverConfig.Disks
+	if self.ServerMetadata != nil && jsonutils.QueryBoolean(self.ServerMetadata, api.VM_METADATA_SECURE_BOOT, false) {
+		sourceInput.SecureBoot = true
+	}
 	if sourceInput.VmemSize == 0 {
 		sourceInput.VmemSize = serverConfig.Memory
 	}
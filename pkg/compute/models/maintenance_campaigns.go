@@ -0,0 +1,633 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+	"yunion.io/x/onecloud/pkg/util/stringutils2"
+)
+
+type SMaintenanceCampaignManager struct {
+	db.SStatusStandaloneResourceBaseManager
+}
+
+var MaintenanceCampaignManager *SMaintenanceCampaignManager
+
+func init() {
+	MaintenanceCampaignManager = &SMaintenanceCampaignManager{
+		SStatusStandaloneResourceBaseManager: db.NewStatusStandaloneResourceBaseManager(
+			SMaintenanceCampaign{},
+			"maintenance_campaigns_tbl",
+			"maintenance_campaign",
+			"maintenance_campaigns",
+		),
+	}
+	MaintenanceCampaignManager.SetVirtualObject(MaintenanceCampaignManager)
+}
+
+// maintenance campaign drains a set of hosts batch by batch, a drained host is
+// re-enabled after health hook passed, campaign is paused on any failure and
+// could be resumed
+type SMaintenanceCampaign struct {
+	db.SStatusStandaloneResourceBase
+
+	ZoneId     string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+	SchedtagId string `width:"36" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional"`
+
+	MaxUnavailable int `nullable:"false" default:"1" list:"admin" create:"admin_optional" update:"admin"`
+
+	HostAgentVersion     string `width:"128" charset:"ascii" nullable:"true" list:"admin" create:"admin_optional" update:"admin"`
+	HealthTimeoutMinutes int    `nullable:"false" default:"60" list:"admin" create:"admin_optional" update:"admin"`
+
+	// current batch number
+	Batch int `nullable:"false" default:"0" list:"admin"`
+
+	Hosts *api.SMaintenanceCampaignHosts `length:"long" list:"admin"`
+}
+
+func (manager *SMaintenanceCampaignManager) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, input api.MaintenanceCampaignCreateInput) (api.MaintenanceCampaignCreateInput, error) {
+	var err error
+	input.StatusStandaloneResourceCreateInput, err = manager.SStatusStandaloneResourceBaseManager.ValidateCreateData(ctx, userCred, ownerId, query, input.StatusStandaloneResourceCreateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ValidateCreateData")
+	}
+	if input.MaxUnavailable == 0 {
+		input.MaxUnavailable = 1
+	}
+	if input.MaxUnavailable < 0 {
+		return input, httperrors.NewInputParameterError("invalid max_unavailable %d", input.MaxUnavailable)
+	}
+	if input.HealthTimeoutMinutes == 0 {
+		input.HealthTimeoutMinutes = 60
+	}
+	if input.HealthTimeoutMinutes < 0 {
+		return input, httperrors.NewInputParameterError("invalid health_timeout_minutes %d", input.HealthTimeoutMinutes)
+	}
+
+	hostIds := []string{}
+	for _, h := range input.Hosts {
+		hostObj, err := HostManager.FetchByIdOrName(userCred, h)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return input, httperrors.NewResourceNotFoundError2(HostManager.Keyword(), h)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		hostIds = append(hostIds, hostObj.GetId())
+	}
+	if len(input.ZoneId) > 0 {
+		zoneObj, err := ZoneManager.FetchByIdOrName(userCred, input.ZoneId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return input, httperrors.NewResourceNotFoundError2(ZoneManager.Keyword(), input.ZoneId)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		input.ZoneId = zoneObj.GetId()
+		q := HostManager.Query("id").Equals("zone_id", input.ZoneId)
+		ids, err := fetchMaintenanceHostIds(q)
+		if err != nil {
+			return input, err
+		}
+		hostIds = append(hostIds, ids...)
+	}
+	if len(input.SchedtagId) > 0 {
+		tagObj, err := SchedtagManager.FetchByIdOrName(userCred, input.SchedtagId)
+		if err != nil {
+			if errors.Cause(err) == sqlchemy.ErrEmptyQuery {
+				return input, httperrors.NewResourceNotFoundError2(SchedtagManager.Keyword(), input.SchedtagId)
+			}
+			return input, httperrors.NewGeneralError(err)
+		}
+		tag := tagObj.(*SSchedtag)
+		if tag.ResourceType != HostManager.KeywordPlural() {
+			return input, httperrors.NewInputParameterError("schedtag %s is not for hosts", tag.Name)
+		}
+		input.SchedtagId = tag.Id
+		hostIdQ := HostschedtagManager.Query("host_id").Equals("schedtag_id", tag.Id).SubQuery()
+		q := HostManager.Query("id").In("id", hostIdQ)
+		ids, err := fetchMaintenanceHostIds(q)
+		if err != nil {
+			return input, err
+		}
+		hostIds = append(hostIds, ids...)
+	}
+
+	hosts := []SHost{}
+	q := HostManager.Query().In("id", hostIds).Asc("name")
+	err = db.FetchModelObjects(HostManager, q, &hosts)
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if len(hosts) == 0 {
+		return input, httperrors.NewInputParameterError("no host to maintain")
+	}
+	input.Hosts = []string{}
+	for i := range hosts {
+		if hosts[i].HostType != api.HOST_TYPE_HYPERVISOR {
+			return input, httperrors.NewInputParameterError("host %s of type %s can't do host maintenance", hosts[i].Name, hosts[i].HostType)
+		}
+		input.Hosts = append(input.Hosts, hosts[i].Id)
+	}
+	return input, nil
+}
+
+func fetchMaintenanceHostIds(q *sqlchemy.SQuery) ([]string, error) {
+	q = q.Equals("host_type", api.HOST_TYPE_HYPERVISOR)
+	rows, err := q.Rows()
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "query host ids"))
+	}
+	defer rows.Close()
+	ret := []string{}
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, httperrors.NewGeneralError(errors.Wrap(err, "scan host id"))
+		}
+		ret = append(ret, id)
+	}
+	return ret, nil
+}
+
+func (self *SMaintenanceCampaign) CustomizeCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) error {
+	input := api.MaintenanceCampaignCreateInput{}
+	err := data.Unmarshal(&input)
+	if err != nil {
+		return errors.Wrap(err, "Unmarshal")
+	}
+	hosts := api.SMaintenanceCampaignHosts{}
+	for _, hostId := range input.Hosts {
+		host := HostManager.FetchHostById(hostId)
+		if host == nil {
+			return httperrors.NewResourceNotFoundError2(HostManager.Keyword(), hostId)
+		}
+		hosts = append(hosts, api.SMaintenanceCampaignHost{
+			HostId: host.Id,
+			Host:   host.Name,
+			Status: api.MAINTENANCE_CAMPAIGN_HOST_PENDING,
+		})
+	}
+	self.Hosts = &hosts
+	self.Status = api.MAINTENANCE_CAMPAIGN_STATUS_READY
+	return self.SStatusStandaloneResourceBase.CustomizeCreate(ctx, userCred, ownerId, query, data)
+}
+
+func (self *SMaintenanceCampaign) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.MaintenanceCampaignUpdateInput) (api.MaintenanceCampaignUpdateInput, error) {
+	if input.MaxUnavailable != nil && *input.MaxUnavailable <= 0 {
+		return input, httperrors.NewInputParameterError("invalid max_unavailable %d", *input.MaxUnavailable)
+	}
+	if input.HealthTimeoutMinutes != nil && *input.HealthTimeoutMinutes <= 0 {
+		return input, httperrors.NewInputParameterError("invalid health_timeout_minutes %d", *input.HealthTimeoutMinutes)
+	}
+	var err error
+	input.StatusStandaloneResourceBaseUpdateInput, err = self.SStatusStandaloneResourceBase.ValidateUpdateData(ctx, userCred, query, input.StatusStandaloneResourceBaseUpdateInput)
+	if err != nil {
+		return input, errors.Wrap(err, "SStatusStandaloneResourceBase.ValidateUpdateData")
+	}
+	return input, nil
+}
+
+func (self *SMaintenanceCampaign) ValidateDeleteCondition(ctx context.Context, info jsonutils.JSONObject) error {
+	if self.Status == api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING {
+		return httperrors.NewInvalidStatusError("can't delete campaign in status %s", self.Status)
+	}
+	return self.SStatusStandaloneResourceBase.ValidateDeleteCondition(ctx, nil)
+}
+
+func (manager *SMaintenanceCampaignManager) ListItemFilter(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.MaintenanceCampaignListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.ListItemFilter(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.ListItemFilter")
+	}
+	if len(query.ZoneId) > 0 {
+		q = q.Equals("zone_id", query.ZoneId)
+	}
+	if len(query.SchedtagId) > 0 {
+		q = q.Equals("schedtag_id", query.SchedtagId)
+	}
+	return q, nil
+}
+
+func (manager *SMaintenanceCampaignManager) OrderByExtraFields(
+	ctx context.Context,
+	q *sqlchemy.SQuery,
+	userCred mcclient.TokenCredential,
+	query api.MaintenanceCampaignListInput,
+) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.OrderByExtraFields(ctx, q, userCred, query.StatusStandaloneResourceListInput)
+	if err != nil {
+		return nil, errors.Wrap(err, "SStatusStandaloneResourceBaseManager.OrderByExtraFields")
+	}
+	return q, nil
+}
+
+func (manager *SMaintenanceCampaignManager) QueryDistinctExtraField(q *sqlchemy.SQuery, field string) (*sqlchemy.SQuery, error) {
+	q, err := manager.SStatusStandaloneResourceBaseManager.QueryDistinctExtraField(q, field)
+	if err == nil {
+		return q, nil
+	}
+	return q, httperrors.ErrNotFound
+}
+
+func (manager *SMaintenanceCampaignManager) FetchCustomizeColumns(
+	ctx context.Context,
+	userCred mcclient.TokenCredential,
+	query jsonutils.JSONObject,
+	objs []interface{},
+	fields stringutils2.SSortedStrings,
+	isList bool,
+) []api.MaintenanceCampaignDetails {
+	rows := make([]api.MaintenanceCampaignDetails, len(objs))
+	stdRows := manager.SStatusStandaloneResourceBaseManager.FetchCustomizeColumns(ctx, userCred, query, objs, fields, isList)
+	for i := range rows {
+		rows[i] = api.MaintenanceCampaignDetails{
+			StatusStandaloneResourceDetails: stdRows[i],
+		}
+		campaign := objs[i].(*SMaintenanceCampaign)
+		hosts := campaign.getHosts()
+		rows[i].HostCount = len(hosts)
+		for _, h := range hosts {
+			switch h.Status {
+			case api.MAINTENANCE_CAMPAIGN_HOST_DONE:
+				rows[i].DoneCount++
+			case api.MAINTENANCE_CAMPAIGN_HOST_FAILED:
+				rows[i].FailedCount++
+			}
+		}
+		if rows[i].HostCount > 0 {
+			rows[i].Progress = float64(rows[i].DoneCount) * 100 / float64(rows[i].HostCount)
+		}
+	}
+	return rows
+}
+
+func (self *SMaintenanceCampaign) getHosts() api.SMaintenanceCampaignHosts {
+	if self.Hosts == nil {
+		return api.SMaintenanceCampaignHosts{}
+	}
+	return *self.Hosts
+}
+
+func (self *SMaintenanceCampaign) countHosts(status ...string) int {
+	return countCampaignHosts(self.getHosts(), status...)
+}
+
+func countCampaignHosts(hosts api.SMaintenanceCampaignHosts, status ...string) int {
+	cnt := 0
+	for _, h := range hosts {
+		if utils.IsInStringArray(h.Status, status) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// campaign statuses each action is allowed in
+var maintenanceCampaignActionStatus = map[string][]string{
+	"start":  {api.MAINTENANCE_CAMPAIGN_STATUS_READY},
+	"pause":  {api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING},
+	"resume": {api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED},
+	"cancel": {api.MAINTENANCE_CAMPAIGN_STATUS_READY, api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING, api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED},
+}
+
+func checkMaintenanceCampaignAction(action, status string) error {
+	if !utils.IsInStringArray(status, maintenanceCampaignActionStatus[action]) {
+		return httperrors.NewInvalidStatusError("can't %s campaign in status %s", action, status)
+	}
+	return nil
+}
+
+// resumeFailedHosts puts failed hosts back to pending so they are drained again
+func resumeFailedHosts(hosts api.SMaintenanceCampaignHosts) {
+	for i := range hosts {
+		if hosts[i].Status == api.MAINTENANCE_CAMPAIGN_HOST_FAILED {
+			hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_PENDING
+			hosts[i].Reason = ""
+		}
+	}
+}
+
+// nextBatchHosts marks pending hosts as draining in the batch until max
+// unavailable hosts reached, hosts draining or waiting healthy are unavailable
+func nextBatchHosts(hosts api.SMaintenanceCampaignHosts, maxUnavailable int, batch int) []api.SMaintenanceCampaignHost {
+	unavailable := countCampaignHosts(hosts, api.MAINTENANCE_CAMPAIGN_HOST_DRAINING, api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY)
+	ret := []api.SMaintenanceCampaignHost{}
+	for i := range hosts {
+		if unavailable >= maxUnavailable {
+			break
+		}
+		if hosts[i].Status != api.MAINTENANCE_CAMPAIGN_HOST_PENDING {
+			continue
+		}
+		hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_DRAINING
+		hosts[i].Batch = batch
+		ret = append(ret, hosts[i])
+		unavailable++
+	}
+	return ret
+}
+
+// drainedHostStatus returns the campaign host status and failure reason after
+// host maintenance task finished
+func drainedHostStatus(host *SHost) (string, string) {
+	if host == nil {
+		return api.MAINTENANCE_CAMPAIGN_HOST_FAILED, "host not found"
+	}
+	if host.Status != api.BAREMETAL_MAINTAINING {
+		return api.MAINTENANCE_CAMPAIGN_HOST_FAILED, fmt.Sprintf("host status %s after maintenance", host.Status)
+	}
+	return api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY, ""
+}
+
+// shouldStartCampaignTask tells whether a campaign task is needed to start
+// next batch, or to complete the campaign when all hosts are maintained
+func shouldStartCampaignTask(hosts api.SMaintenanceCampaignHosts, maxUnavailable int) bool {
+	unavailable := countCampaignHosts(hosts, api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY)
+	pending := countCampaignHosts(hosts, api.MAINTENANCE_CAMPAIGN_HOST_PENDING)
+	return (pending > 0 && unavailable < maxUnavailable) || (pending == 0 && unavailable == 0)
+}
+
+// updateHosts saves per host status changed by fn, campaign status is set when
+// status is not empty
+func (self *SMaintenanceCampaign) updateHosts(fn func(hosts api.SMaintenanceCampaignHosts), status string) error {
+	_, err := db.Update(self, func() error {
+		hosts := self.getHosts()
+		fn(hosts)
+		self.Hosts = &hosts
+		if len(status) > 0 {
+			self.Status = status
+		}
+		return nil
+	})
+	return err
+}
+
+func (self *SMaintenanceCampaign) PerformStart(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := checkMaintenanceCampaignAction("start", self.Status); err != nil {
+		return nil, err
+	}
+	self.SetStatus(userCred, api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING, "start")
+	logclient.AddSimpleActionLog(self, logclient.ACT_START, nil, userCred, true)
+	return nil, self.StartMaintenanceCampaignTask(ctx, userCred, "")
+}
+
+func (self *SMaintenanceCampaign) PerformPause(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := checkMaintenanceCampaignAction("pause", self.Status); err != nil {
+		return nil, err
+	}
+	self.SetStatus(userCred, api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED, "pause")
+	logclient.AddSimpleActionLog(self, logclient.ACT_PAUSE, nil, userCred, true)
+	return nil, nil
+}
+
+// PerformResume continues a paused campaign, failed hosts are drained again
+func (self *SMaintenanceCampaign) PerformResume(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := checkMaintenanceCampaignAction("resume", self.Status); err != nil {
+		return nil, err
+	}
+	err := self.updateHosts(resumeFailedHosts, api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING)
+	if err != nil {
+		return nil, errors.Wrap(err, "update hosts")
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_RESUME, nil, userCred, true)
+	return nil, self.StartMaintenanceCampaignTask(ctx, userCred, "")
+}
+
+// PerformCancel stops scheduling new batches, drained hosts are left disabled
+func (self *SMaintenanceCampaign) PerformCancel(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) (jsonutils.JSONObject, error) {
+	if err := checkMaintenanceCampaignAction("cancel", self.Status); err != nil {
+		return nil, err
+	}
+	self.SetStatus(userCred, api.MAINTENANCE_CAMPAIGN_STATUS_CANCELLED, "cancel")
+	logclient.AddSimpleActionLog(self, logclient.ACT_CANCEL, nil, userCred, true)
+	return nil, nil
+}
+
+func (self *SMaintenanceCampaign) StartMaintenanceCampaignTask(ctx context.Context, userCred mcclient.TokenCredential, parentTaskId string) error {
+	task, err := taskman.TaskManager.NewTask(ctx, "MaintenanceCampaignTask", self, userCred, nil, parentTaskId, "", nil)
+	if err != nil {
+		return errors.Wrap(err, "NewTask")
+	}
+	task.ScheduleRun(nil)
+	return nil
+}
+
+// NextBatch marks pending hosts as draining until max unavailable hosts reached,
+// returns the hosts to drain
+func (self *SMaintenanceCampaign) NextBatch(ctx context.Context) ([]api.SMaintenanceCampaignHost, error) {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	var batch []api.SMaintenanceCampaignHost
+	_, err := db.Update(self, func() error {
+		hosts := self.getHosts()
+		batch = nextBatchHosts(hosts, self.MaxUnavailable, self.Batch+1)
+		if len(batch) > 0 {
+			self.Batch += 1
+		}
+		self.Hosts = &hosts
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "update hosts")
+	}
+	return batch, nil
+}
+
+// OnHostsDrained checks the host status after host maintenance tasks finished,
+// campaign is paused if any host failed to be drained
+func (self *SMaintenanceCampaign) OnHostsDrained(ctx context.Context, userCred mcclient.TokenCredential) error {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	failed := []string{}
+	err := self.updateHosts(func(hosts api.SMaintenanceCampaignHosts) {
+		for i := range hosts {
+			if hosts[i].Status != api.MAINTENANCE_CAMPAIGN_HOST_DRAINING {
+				continue
+			}
+			hosts[i].Status, hosts[i].Reason = drainedHostStatus(HostManager.FetchHostById(hosts[i].HostId))
+			if hosts[i].Status == api.MAINTENANCE_CAMPAIGN_HOST_FAILED {
+				failed = append(failed, hosts[i].Host)
+			} else {
+				hosts[i].DrainedAt = time.Now()
+			}
+		}
+	}, "")
+	if err != nil {
+		return errors.Wrap(err, "update hosts")
+	}
+	if len(failed) > 0 {
+		self.pause(ctx, userCred, fmt.Sprintf("drain hosts %v failed", failed))
+	}
+	return nil
+}
+
+// MarkHostFailed marks a host failed to be drained and pauses the campaign
+func (self *SMaintenanceCampaign) MarkHostFailed(ctx context.Context, userCred mcclient.TokenCredential, hostId string, reason string) error {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	err := self.updateHosts(func(hosts api.SMaintenanceCampaignHosts) {
+		for i := range hosts {
+			if hosts[i].HostId == hostId {
+				hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_FAILED
+				hosts[i].Reason = reason
+			}
+		}
+	}, "")
+	if err != nil {
+		return errors.Wrap(err, "update hosts")
+	}
+	self.pause(ctx, userCred, reason)
+	return nil
+}
+
+func (self *SMaintenanceCampaign) pause(ctx context.Context, userCred mcclient.TokenCredential, reason string) {
+	if self.Status == api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING {
+		self.SetStatus(userCred, api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED, reason)
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_PAUSE, reason, userCred, false)
+}
+
+// TryComplete marks the campaign completed when all hosts are done
+func (self *SMaintenanceCampaign) TryComplete(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	if self.countHosts(api.MAINTENANCE_CAMPAIGN_HOST_DONE) != len(self.getHosts()) {
+		return false
+	}
+	self.SetStatus(userCred, api.MAINTENANCE_CAMPAIGN_STATUS_COMPLETED, "all hosts maintained")
+	logclient.AddSimpleActionLog(self, logclient.ACT_DONE, "all hosts maintained", userCred, true)
+	return true
+}
+
+// isHostHealthy is the health hook of a drained host, host must be online and ping
+// after drained, and report the expected host agent version if required
+func (self *SMaintenanceCampaign) isHostHealthy(host *SHost, drainedAt time.Time) bool {
+	if host.HostStatus != api.HOST_ONLINE || !host.LastPingAt.After(drainedAt) {
+		return false
+	}
+	if len(self.HostAgentVersion) > 0 && host.Version != self.HostAgentVersion {
+		return false
+	}
+	return true
+}
+
+// hostHealthStatus returns done if the drained host passed the health hook,
+// failed if health hook is not passed before timeout
+func (self *SMaintenanceCampaign) hostHealthStatus(host *SHost, drainedAt time.Time, now time.Time) string {
+	if host != nil && self.isHostHealthy(host, drainedAt) {
+		return api.MAINTENANCE_CAMPAIGN_HOST_DONE
+	}
+	if now.Sub(drainedAt) > time.Duration(self.HealthTimeoutMinutes)*time.Minute {
+		return api.MAINTENANCE_CAMPAIGN_HOST_FAILED
+	}
+	return api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY
+}
+
+// checkHostsHealth re-enables drained hosts passed the health hook, returns
+// false if campaign is paused by health timeout
+func (self *SMaintenanceCampaign) checkHostsHealth(ctx context.Context, userCred mcclient.TokenCredential) bool {
+	lockman.LockObject(ctx, self)
+	defer lockman.ReleaseObject(ctx, self)
+
+	timeout := []string{}
+	err := self.updateHosts(func(hosts api.SMaintenanceCampaignHosts) {
+		for i := range hosts {
+			if hosts[i].Status != api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY {
+				continue
+			}
+			host := HostManager.FetchHostById(hosts[i].HostId)
+			switch self.hostHealthStatus(host, hosts[i].DrainedAt, time.Now()) {
+			case api.MAINTENANCE_CAMPAIGN_HOST_DONE:
+				_, err := host.PerformEnable(ctx, userCred, nil, apis.PerformEnableInput{})
+				if err != nil {
+					log.Errorf("enable host %s: %v", host.Name, err)
+					continue
+				}
+				host.SetStatus(userCred, api.BAREMETAL_RUNNING, "maintenance campaign health check passed")
+				hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_DONE
+			case api.MAINTENANCE_CAMPAIGN_HOST_FAILED:
+				hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_FAILED
+				hosts[i].Reason = "health check timeout"
+				timeout = append(timeout, hosts[i].Host)
+			}
+		}
+	}, "")
+	if err != nil {
+		log.Errorf("update campaign %s hosts: %v", self.Name, err)
+		return false
+	}
+	if len(timeout) > 0 {
+		self.pause(ctx, userCred, fmt.Sprintf("hosts %v health check timeout", timeout))
+		return false
+	}
+	return true
+}
+
+// CheckHostsHealth is the cron job driving running campaigns, re-enables the
+// healthy hosts and starts next batch
+func (manager *SMaintenanceCampaignManager) CheckHostsHealth(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	campaigns := []SMaintenanceCampaign{}
+	q := manager.Query().Equals("status", api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING)
+	err := db.FetchModelObjects(manager, q, &campaigns)
+	if err != nil {
+		log.Errorf("fetch running maintenance campaigns: %v", err)
+		return
+	}
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		if !campaign.checkHostsHealth(ctx, userCred) {
+			continue
+		}
+		if taskman.TaskManager.IsInTask(campaign) {
+			continue
+		}
+		if campaign.countHosts(api.MAINTENANCE_CAMPAIGN_HOST_DRAINING) > 0 {
+			// draining hosts without task, the campaign task is interrupted
+			campaign.OnHostsDrained(ctx, userCred)
+			continue
+		}
+		if shouldStartCampaignTask(campaign.getHosts(), campaign.MaxUnavailable) {
+			err := campaign.StartMaintenanceCampaignTask(ctx, userCred, "")
+			if err != nil {
+				log.Errorf("start maintenance campaign %s task: %v", campaign.Name, err)
+			}
+		}
+	}
+}
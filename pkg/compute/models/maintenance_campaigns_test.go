@@ -0,0 +1,332 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func newTestCampaignHosts(status ...string) api.SMaintenanceCampaignHosts {
+	hosts := api.SMaintenanceCampaignHosts{}
+	for i, s := range status {
+		hosts = append(hosts, api.SMaintenanceCampaignHost{
+			HostId: fmt.Sprintf("host%d", i),
+			Host:   fmt.Sprintf("host%d", i),
+			Status: s,
+		})
+	}
+	return hosts
+}
+
+func newTestStatusHost(status string) *SHost {
+	host := &SHost{}
+	host.Status = status
+	return host
+}
+
+func campaignHostStatus(hosts api.SMaintenanceCampaignHosts) []string {
+	ret := []string{}
+	for _, h := range hosts {
+		ret = append(ret, h.Status)
+	}
+	return ret
+}
+
+func TestCheckMaintenanceCampaignAction(t *testing.T) {
+	allowed := map[string][]string{
+		"start":  {api.MAINTENANCE_CAMPAIGN_STATUS_READY},
+		"pause":  {api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING},
+		"resume": {api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED},
+		"cancel": {api.MAINTENANCE_CAMPAIGN_STATUS_READY, api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING, api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED},
+	}
+	statuses := []string{
+		api.MAINTENANCE_CAMPAIGN_STATUS_READY,
+		api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING,
+		api.MAINTENANCE_CAMPAIGN_STATUS_PAUSED,
+		api.MAINTENANCE_CAMPAIGN_STATUS_COMPLETED,
+		api.MAINTENANCE_CAMPAIGN_STATUS_CANCELLED,
+	}
+	for action, allowedStatus := range allowed {
+		for _, status := range statuses {
+			want := false
+			for _, s := range allowedStatus {
+				if s == status {
+					want = true
+				}
+			}
+			err := checkMaintenanceCampaignAction(action, status)
+			if (err == nil) != want {
+				t.Errorf("%s in status %s: want allowed %v, got error %v", action, status, want, err)
+			}
+		}
+	}
+}
+
+func TestNextBatchHosts(t *testing.T) {
+	cases := []struct {
+		name           string
+		hosts          api.SMaintenanceCampaignHosts
+		maxUnavailable int
+		wantBatch      []string
+		wantStatus     []string
+	}{
+		{
+			name:           "first batch",
+			hosts:          newTestCampaignHosts("pending", "pending", "pending"),
+			maxUnavailable: 2,
+			wantBatch:      []string{"host0", "host1"},
+			wantStatus:     []string{"draining", "draining", "pending"},
+		},
+		{
+			name:           "draining hosts count as unavailable",
+			hosts:          newTestCampaignHosts("draining", "pending", "pending"),
+			maxUnavailable: 2,
+			wantBatch:      []string{"host1"},
+			wantStatus:     []string{"draining", "draining", "pending"},
+		},
+		{
+			name:           "hosts waiting healthy count as unavailable",
+			hosts:          newTestCampaignHosts("wait_healthy", "wait_healthy", "pending"),
+			maxUnavailable: 2,
+			wantBatch:      []string{},
+			wantStatus:     []string{"wait_healthy", "wait_healthy", "pending"},
+		},
+		{
+			name:           "done and failed hosts are skipped",
+			hosts:          newTestCampaignHosts("done", "failed", "pending", "pending"),
+			maxUnavailable: 1,
+			wantBatch:      []string{"host2"},
+			wantStatus:     []string{"done", "failed", "draining", "pending"},
+		},
+		{
+			name:           "no pending hosts",
+			hosts:          newTestCampaignHosts("done", "failed"),
+			maxUnavailable: 1,
+			wantBatch:      []string{},
+			wantStatus:     []string{"done", "failed"},
+		},
+		{
+			name:           "max unavailable larger than hosts",
+			hosts:          newTestCampaignHosts("pending", "done", "pending"),
+			maxUnavailable: 10,
+			wantBatch:      []string{"host0", "host2"},
+			wantStatus:     []string{"draining", "done", "draining"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			batch := nextBatchHosts(c.hosts, c.maxUnavailable, 3)
+			got := []string{}
+			for _, h := range batch {
+				got = append(got, h.HostId)
+				if h.Batch != 3 {
+					t.Errorf("host %s: want batch 3, got %d", h.HostId, h.Batch)
+				}
+			}
+			if !reflect.DeepEqual(got, c.wantBatch) {
+				t.Errorf("want batch %v, got %v", c.wantBatch, got)
+			}
+			if status := campaignHostStatus(c.hosts); !reflect.DeepEqual(status, c.wantStatus) {
+				t.Errorf("want host status %v, got %v", c.wantStatus, status)
+			}
+		})
+	}
+}
+
+func TestDrainedHostStatus(t *testing.T) {
+	cases := []struct {
+		name       string
+		host       *SHost
+		wantStatus string
+	}{
+		{
+			name:       "host not found",
+			wantStatus: api.MAINTENANCE_CAMPAIGN_HOST_FAILED,
+		},
+		{
+			name:       "host maintaining",
+			host:       newTestStatusHost(api.BAREMETAL_MAINTAINING),
+			wantStatus: api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY,
+		},
+		{
+			name:       "host maintain failed",
+			host:       newTestStatusHost(api.BAREMETAL_MAINTAIN_FAIL),
+			wantStatus: api.MAINTENANCE_CAMPAIGN_HOST_FAILED,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, reason := drainedHostStatus(c.host)
+			if status != c.wantStatus {
+				t.Errorf("want status %s, got %s", c.wantStatus, status)
+			}
+			if (status == api.MAINTENANCE_CAMPAIGN_HOST_FAILED) != (len(reason) > 0) {
+				t.Errorf("unexpected reason %q for status %s", reason, status)
+			}
+		})
+	}
+}
+
+func TestMaintenanceCampaignHostHealthStatus(t *testing.T) {
+	drainedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name         string
+		agentVersion string
+		host         *SHost
+		now          time.Time
+		want         string
+	}{
+		{
+			name: "online and pinged after drained",
+			host: &SHost{HostStatus: api.HOST_ONLINE, LastPingAt: drainedAt.Add(time.Minute)},
+			now:  drainedAt.Add(2 * time.Minute),
+			want: api.MAINTENANCE_CAMPAIGN_HOST_DONE,
+		},
+		{
+			name: "not pinged after drained",
+			host: &SHost{HostStatus: api.HOST_ONLINE, LastPingAt: drainedAt.Add(-time.Minute)},
+			now:  drainedAt.Add(2 * time.Minute),
+			want: api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY,
+		},
+		{
+			name: "offline",
+			host: &SHost{HostStatus: api.HOST_OFFLINE, LastPingAt: drainedAt.Add(time.Minute)},
+			now:  drainedAt.Add(2 * time.Minute),
+			want: api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY,
+		},
+		{
+			name:         "agent not upgraded",
+			agentVersion: "v3.10.1",
+			host:         &SHost{HostStatus: api.HOST_ONLINE, LastPingAt: drainedAt.Add(time.Minute), Version: "v3.10.0"},
+			now:          drainedAt.Add(2 * time.Minute),
+			want:         api.MAINTENANCE_CAMPAIGN_HOST_WAIT_HEALTHY,
+		},
+		{
+			name:         "agent upgraded",
+			agentVersion: "v3.10.1",
+			host:         &SHost{HostStatus: api.HOST_ONLINE, LastPingAt: drainedAt.Add(time.Minute), Version: "v3.10.1"},
+			now:          drainedAt.Add(2 * time.Minute),
+			want:         api.MAINTENANCE_CAMPAIGN_HOST_DONE,
+		},
+		{
+			name: "health check timeout",
+			host: &SHost{HostStatus: api.HOST_OFFLINE},
+			now:  drainedAt.Add(61 * time.Minute),
+			want: api.MAINTENANCE_CAMPAIGN_HOST_FAILED,
+		},
+		{
+			name: "host deleted",
+			now:  drainedAt.Add(61 * time.Minute),
+			want: api.MAINTENANCE_CAMPAIGN_HOST_FAILED,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			campaign := &SMaintenanceCampaign{HostAgentVersion: c.agentVersion, HealthTimeoutMinutes: 60}
+			if got := campaign.hostHealthStatus(c.host, drainedAt, c.now); got != c.want {
+				t.Errorf("want %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestShouldStartCampaignTask(t *testing.T) {
+	cases := []struct {
+		name           string
+		hosts          api.SMaintenanceCampaignHosts
+		maxUnavailable int
+		want           bool
+	}{
+		{
+			name:           "pending hosts under limit",
+			hosts:          newTestCampaignHosts("wait_healthy", "pending"),
+			maxUnavailable: 2,
+			want:           true,
+		},
+		{
+			name:           "pending hosts reach limit",
+			hosts:          newTestCampaignHosts("wait_healthy", "wait_healthy", "pending"),
+			maxUnavailable: 2,
+			want:           false,
+		},
+		{
+			name:           "all hosts done",
+			hosts:          newTestCampaignHosts("done", "done"),
+			maxUnavailable: 1,
+			want:           true,
+		},
+		{
+			name:           "last hosts waiting healthy",
+			hosts:          newTestCampaignHosts("done", "wait_healthy"),
+			maxUnavailable: 2,
+			want:           false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := shouldStartCampaignTask(c.hosts, c.maxUnavailable); got != c.want {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+// TestMaintenanceCampaignResumeAfterFailure walks a campaign through batches
+// with a failed host, which is drained again after resumed
+func TestMaintenanceCampaignResumeAfterFailure(t *testing.T) {
+	hosts := newTestCampaignHosts("pending", "pending", "pending")
+	maxUnavailable := 2
+
+	batch := nextBatchHosts(hosts, maxUnavailable, 1)
+	if len(batch) != 2 {
+		t.Fatalf("want 2 hosts in batch 1, got %d", len(batch))
+	}
+	// host0 drained, host1 failed to be drained
+	hosts[0].Status, _ = drainedHostStatus(newTestStatusHost(api.BAREMETAL_MAINTAINING))
+	hosts[1].Status, hosts[1].Reason = drainedHostStatus(newTestStatusHost(api.BAREMETAL_MAINTAIN_FAIL))
+	if want := []string{"wait_healthy", "failed", "pending"}; !reflect.DeepEqual(campaignHostStatus(hosts), want) {
+		t.Fatalf("want %v after batch 1 drained, got %v", want, campaignHostStatus(hosts))
+	}
+
+	// campaign is paused, host0 passes health check meanwhile
+	hosts[0].Status = api.MAINTENANCE_CAMPAIGN_HOST_DONE
+	resumeFailedHosts(hosts)
+	if len(hosts[1].Reason) > 0 {
+		t.Errorf("failure reason should be cleared on resuming, got %q", hosts[1].Reason)
+	}
+	if !shouldStartCampaignTask(hosts, maxUnavailable) {
+		t.Fatalf("expect campaign task started after resumed")
+	}
+
+	batch = nextBatchHosts(hosts, maxUnavailable, 2)
+	got := []string{}
+	for _, h := range batch {
+		got = append(got, h.HostId)
+	}
+	if want := []string{"host1", "host2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("want batch 2 %v, got %v", want, got)
+	}
+	for i := range hosts {
+		hosts[i].Status = api.MAINTENANCE_CAMPAIGN_HOST_DONE
+	}
+	if !shouldStartCampaignTask(hosts, maxUnavailable) {
+		t.Errorf("expect campaign task started to complete the campaign")
+	}
+}
@@ -57,6 +57,8 @@ type SNatDEntry struct {
 
 	ExternalIP   string `width:"17" charset:"ascii" list:"user" create:"required"`
 	ExternalPort int    `list:"user" create:"required"`
+	// 端口范围结束, 为0时只转发ExternalPort一个端口
+	ExternalPortEnd int `list:"user" create:"optional"`
 
 	InternalIP   string `width:"17" charset:"ascii" list:"user" create:"required"`
 	InternalPort int    `list:"user" create:"required"`
@@ -148,6 +150,25 @@ func (man *SNatDEntryManager) ValidateCreateData(ctx context.Context, userCred m
 	if input.InternalPort < 1 || input.InternalPort > 65535 {
 		return nil, httperrors.NewInputParameterError("Port value error")
 	}
+	if input.ExternalPortEnd > 0 {
+		if input.ExternalPortEnd < input.ExternalPort || input.ExternalPortEnd > 65535 {
+			return nil, httperrors.NewInputParameterError("invalid external port range %d-%d", input.ExternalPort, input.ExternalPortEnd)
+		}
+		if input.InternalPort+input.ExternalPortEnd-input.ExternalPort > 65535 {
+			return nil, httperrors.NewInputParameterError("internal port range exceeds 65535")
+		}
+		if input.ExternalPortEnd-input.ExternalPort+1 > api.NAT_DNAT_PORT_RANGE_MAX {
+			return nil, httperrors.NewInputParameterError("external port range %d-%d exceeds %d ports", input.ExternalPort, input.ExternalPortEnd, api.NAT_DNAT_PORT_RANGE_MAX)
+		}
+		_natgw, err := validators.ValidateModel(userCred, NatGatewayManager, &input.NatgatewayId)
+		if err != nil {
+			return nil, err
+		}
+		// only realized by ovn of on-premise vpcs
+		if !IsOneCloudVpcResource(_natgw.(*SNatGateway)) {
+			return nil, httperrors.NewNotSupportedError("external port range is not supported by nat gateway of managed vpc")
+		}
+	}
 	if !regutils.MatchIPAddr(input.InternalIp) {
 		return nil, httperrors.NewInputParameterError("invalid internal ip address: %s", input.InternalIp)
 	}
@@ -159,14 +180,25 @@ func (man *SNatDEntryManager) ValidateCreateData(ctx context.Context, userCred m
 	eip := _eip.(*SElasticip)
 	input.ExternalIp = eip.IpAddr
 
-	q := man.Query().Equals("external_ip", input.ExternalIp).Equals("external_port", input.ExternalPort)
+	portEnd := input.ExternalPort
+	if input.ExternalPortEnd > 0 {
+		portEnd = input.ExternalPortEnd
+	}
+	q := man.Query().Equals("external_ip", input.ExternalIp)
+	q = q.Filter(sqlchemy.AND(
+		sqlchemy.LE(q.Field("external_port"), portEnd),
+		sqlchemy.OR(
+			sqlchemy.GE(q.Field("external_port"), input.ExternalPort),
+			sqlchemy.GE(q.Field("external_port_end"), input.ExternalPort),
+		),
+	))
 	count, err := q.CountWithError()
 	if err != nil {
 		return nil, errors.Wrap(err, "q.CountWithError")
 	}
 
 	if count > 0 {
-		return nil, httperrors.NewInputParameterError("there are dnat rules with same external ip and external port")
+		return nil, httperrors.NewInputParameterError("there are dnat rules with same external ip and overlapped external port")
 	}
 
 	// check that eip is suitable
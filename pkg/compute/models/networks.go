@@ -108,13 +108,18 @@ type SNetwork struct {
 
 	GuestDomain string `width:"128" charset:"ascii" nullable:"true" get:"user" update:"user"`
 
-	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6End   string `width:"64" charset:"ascii" nullable:"true"`
-	GuestIp6Mask  int8   `nullable:"true"`
-	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true"`
-	GuestDns6     string `width:"64" charset:"ascii" nullable:"true"`
-
-	GuestDomain6 string `width:"128" charset:"ascii" nullable:"true"`
+	// IPv6起始地址
+	GuestIp6Start string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6结束地址
+	GuestIp6End string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6前缀长度
+	GuestIp6Mask int8 `nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6网关地址
+	GuestGateway6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+	// IPv6 DNS, allow multiple dns, seperated by ","
+	GuestDns6 string `width:"64" charset:"ascii" nullable:"true" list:"user" update:"user" create:"optional"`
+
+	GuestDomain6 string `width:"128" charset:"ascii" nullable:"true" get:"user" update:"user"`
 
 	VlanId int `nullable:"false" default:"1" list:"user" update:"user" create:"optional"`
 
@@ -1567,6 +1572,10 @@ func (manager *SNetworkManager) ValidateCreateData(ctx context.Context, userCred
 		}
 	}
 
+	if err := validateGuestIp6Config(input.GuestIp6Start, input.GuestIp6End, input.GuestIp6Mask, input.GuestGateway6, input.GuestDns6); err != nil {
+		return input, err
+	}
+
 	var (
 		wire   *SWire
 		vpc    *SVpc
@@ -1667,6 +1676,11 @@ func (manager *SNetworkManager) ValidateCreateData(ctx context.Context, userCred
 			return input, httperrors.NewInputParameterError("Conflict address space with existing networks in vpc %q", vpc.GetName())
 		}
 	}
+	if region.Provider == api.CLOUD_PROVIDER_ONECLOUD && vpc.Id != api.DEFAULT_VPC_ID {
+		if err := validatePeerVpcOverlap(vpc, ipStart, int8(input.GuestIpMask)); err != nil {
+			return input, err
+		}
+	}
 
 	input.GuestIpStart = ipStart.String()
 	input.GuestIpEnd = ipEnd.String()
@@ -1753,6 +1767,15 @@ func (self *SNetwork) validateUpdateData(ctx context.Context, userCred mcclient.
 		endIp, _ = netutils.NewIPV4Addr(self.GuestIpEnd)
 		netAddr = startIp.NetAddr(masklen)
 	}
+	if self.isOneCloudVpcNetwork() {
+		vpc, err := self.GetVpc()
+		if err != nil {
+			return input, httperrors.NewGeneralError(errors.Wrap(err, "GetVpc"))
+		}
+		if err := validatePeerVpcOverlap(vpc, startIp, masklen); err != nil {
+			return input, err
+		}
+	}
 
 	for key, ipStr := range map[string]string{
 		"guest_gateway": input.GuestGateway,
@@ -1791,6 +1814,40 @@ func (self *SNetwork) validateUpdateData(ctx context.Context, userCred mcclient.
 		}
 	}
 
+	{
+		ip6Start, ip6End, ip6Mask := self.GuestIp6Start, self.GuestIp6End, self.GuestIp6Mask
+		if len(input.GuestIp6Start) > 0 {
+			ip6Start = input.GuestIp6Start
+		}
+		if len(input.GuestIp6End) > 0 {
+			ip6End = input.GuestIp6End
+		}
+		if input.GuestIp6Mask != nil {
+			ip6Mask = *input.GuestIp6Mask
+		}
+		gateway6, dns6 := self.GuestGateway6, self.GuestDns6
+		if len(input.GuestGateway6) > 0 {
+			gateway6 = input.GuestGateway6
+		}
+		if len(input.GuestDns6) > 0 {
+			dns6 = input.GuestDns6
+		}
+		if err := validateGuestIp6Config(ip6Start, ip6End, ip6Mask, gateway6, dns6); err != nil {
+			return input, err
+		}
+		if len(ip6Start) > 0 {
+			for ip6 := range self.GetUsedIp6Addresses() {
+				if ip6 == parseIPv6(gateway6).String() {
+					continue
+				}
+				v := ipv6ToInt(parseIPv6(ip6))
+				if v.Cmp(ipv6ToInt(parseIPv6(ip6Start))) < 0 || v.Cmp(ipv6ToInt(parseIPv6(ip6End))) > 0 {
+					return input, httperrors.NewInputParameterError("Address %s been assigned out of new ipv6 range", ip6)
+				}
+			}
+		}
+	}
+
 	if input.IsAutoAlloc != nil && *input.IsAutoAlloc {
 		if self.ServerType != api.NETWORK_TYPE_GUEST {
 			return input, httperrors.NewInputParameterError("network server_type %s not support auto alloc", self.ServerType)
@@ -1822,6 +1879,11 @@ func (self *SNetwork) ValidateUpdateData(ctx context.Context, userCred mcclient.
 		input.GuestDomain = self.GuestDomain
 		input.GuestDhcp = self.GuestDhcp
 		input.GuestNtp = self.GuestNtp
+		input.GuestIp6Start = self.GuestIp6Start
+		input.GuestIp6End = self.GuestIp6End
+		input.GuestIp6Mask = &self.GuestIp6Mask
+		input.GuestGateway6 = self.GuestGateway6
+		input.GuestDns6 = self.GuestDns6
 	}
 	var err error
 	input, err = self.validateUpdateData(ctx, userCred, query, input)
@@ -1850,6 +1912,37 @@ func (manager *SNetworkManager) getAllNetworks(wireId, excludeId string) []SNetw
 	return nets
 }
 
+// getSubnetRange returns the whole subnet of the network, which is what
+// routed to the network from peer vpcs
+func (net *SNetwork) getSubnetRange() netutils.IPV4AddrRange {
+	start, _ := netutils.NewIPV4Addr(net.GuestIpStart)
+	masklen := int8(net.GuestIpMask)
+	return netutils.NewIPV4AddrRange(start.NetAddr(masklen), start.BroadcastAddr(masklen))
+}
+
+func isOverlapNetworkSubnets(nets []SNetwork, ipRange netutils.IPV4AddrRange) bool {
+	for i := range nets {
+		if nets[i].getSubnetRange().IsOverlap(ipRange) {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePeerVpcOverlap rejects subnet of ipStart when it overlaps with
+// networks of vpcs peered with vpc or with its peers
+func validatePeerVpcOverlap(vpc *SVpc, ipStart netutils.IPV4Addr, masklen int8) error {
+	subnet := netutils.NewIPV4AddrRange(ipStart.NetAddr(masklen), ipStart.BroadcastAddr(masklen))
+	peerVpc, err := vpc.getOverlapPeerVpc(subnet)
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if peerVpc != nil {
+		return httperrors.NewInputParameterError("Conflict address space with networks in vpc %q reachable by peering", peerVpc.GetName())
+	}
+	return nil
+}
+
 func isOverlapNetworks(nets []SNetwork, startIp netutils.IPV4Addr, endIp netutils.IPV4Addr) bool {
 	ipRange := netutils.NewIPV4AddrRange(startIp, endIp)
 	for i := 0; i < len(nets); i += 1 {
@@ -0,0 +1,174 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"math/big"
+	"net"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/regutils"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+func parseIPv6(addr string) net.IP {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() != nil {
+		return nil
+	}
+	return ip.To16()
+}
+
+func ipv6ToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+func intToIPv6(i *big.Int) net.IP {
+	b := i.Bytes()
+	ip := make(net.IP, net.IPv6len)
+	copy(ip[net.IPv6len-len(b):], b)
+	return ip
+}
+
+// validateGuestIp6Config checks the IPv6 range, prefix length, gateway and
+// dns servers of a network, all of which are optional as a whole.
+func validateGuestIp6Config(start, end string, masklen int8, gateway, dns string) error {
+	if len(start) == 0 && len(end) == 0 {
+		if len(gateway) > 0 || len(dns) > 0 {
+			return httperrors.NewInputParameterError("guest_ip6_start and guest_ip6_end required for ipv6 gateway and dns")
+		}
+		return nil
+	}
+	startIp := parseIPv6(start)
+	if startIp == nil {
+		return httperrors.NewInputParameterError("invalid guest_ip6_start %s", start)
+	}
+	endIp := parseIPv6(end)
+	if endIp == nil {
+		return httperrors.NewInputParameterError("invalid guest_ip6_end %s", end)
+	}
+	if masklen < 48 || masklen > 126 {
+		return httperrors.NewInputParameterError("guest_ip6_mask %d out of range [48, 126]", masklen)
+	}
+	if ipv6ToInt(startIp).Cmp(ipv6ToInt(endIp)) > 0 {
+		return httperrors.NewInputParameterError("guest_ip6_start %s is greater than guest_ip6_end %s", start, end)
+	}
+	mask := net.CIDRMask(int(masklen), 8*net.IPv6len)
+	prefix := startIp.Mask(mask)
+	if !endIp.Mask(mask).Equal(prefix) {
+		return httperrors.NewInputParameterError("ipv6 start and end ip not in the same subnet")
+	}
+	if len(gateway) > 0 {
+		gwIp := parseIPv6(gateway)
+		if gwIp == nil {
+			return httperrors.NewInputParameterError("invalid guest_gateway6 %s", gateway)
+		}
+		if !gwIp.Mask(mask).Equal(prefix) {
+			return httperrors.NewInputParameterError("ipv6 gateway must be in the same subnet as start, end ip")
+		}
+	}
+	if len(dns) > 0 {
+		for _, srv := range strings.Split(dns, ",") {
+			if !regutils.MatchIP6Addr(srv) {
+				return httperrors.NewInputParameterError("guest_dns6: invalid ipv6 address %s", srv)
+			}
+		}
+	}
+	return nil
+}
+
+// IsSupportIPv6 tells whether guests attached to the network get an IPv6
+// address besides the IPv4 one.
+func (net *SNetwork) IsSupportIPv6() bool {
+	return len(net.GuestIp6Start) > 0 && len(net.GuestIp6End) > 0 && net.GuestIp6Mask > 0
+}
+
+func (net *SNetwork) GetDNS6() string {
+	return net.GuestDns6
+}
+
+func (net *SNetwork) GetDomain6() string {
+	if len(net.GuestDomain6) > 0 {
+		return net.GuestDomain6
+	}
+	return net.GetDomain()
+}
+
+func (net *SNetwork) isIp6AddrInRange(ip net.IP) bool {
+	start := parseIPv6(net.GuestIp6Start)
+	end := parseIPv6(net.GuestIp6End)
+	if start == nil || end == nil {
+		return false
+	}
+	v := ipv6ToInt(ip)
+	return v.Cmp(ipv6ToInt(start)) >= 0 && v.Cmp(ipv6ToInt(end)) <= 0
+}
+
+func (net *SNetwork) GetUsedIp6Addresses() map[string]bool {
+	used := make(map[string]bool)
+	q := GuestnetworkManager.Query("ip6_addr").Equals("network_id", net.Id)
+	q = q.Filter(sqlchemy.AND(sqlchemy.IsNotNull(q.Field("ip6_addr")), sqlchemy.IsNotEmpty(q.Field("ip6_addr"))))
+	results, err := q.AllStringMap()
+	if err != nil {
+		log.Errorf("GetUsedIp6Addresses fail %s", err)
+		return used
+	}
+	for _, result := range results {
+		if ip := parseIPv6(result["ip6_addr"]); ip != nil {
+			used[ip.String()] = true
+		}
+	}
+	if gw := parseIPv6(net.GuestGateway6); gw != nil {
+		used[gw.String()] = true
+	}
+	return used
+}
+
+// GetFreeIP6 returns the candidate address if it is free, otherwise the
+// lowest unused address of the IPv6 range.
+func (net *SNetwork) GetFreeIP6(addrTable map[string]bool, candidate string) (string, error) {
+	if len(candidate) > 0 {
+		ip := parseIPv6(candidate)
+		if ip == nil {
+			return "", errors.Wrapf(httperrors.ErrInputParameter, "invalid ipv6 address %s", candidate)
+		}
+		if !net.isIp6AddrInRange(ip) {
+			return "", errors.Wrapf(httperrors.ErrOutOfRange, "%s not in network ipv6 address range", candidate)
+		}
+		if !addrTable[ip.String()] {
+			return ip.String(), nil
+		}
+	}
+	start := parseIPv6(net.GuestIp6Start)
+	end := parseIPv6(net.GuestIp6End)
+	if start == nil || end == nil {
+		return "", errors.Wrapf(httperrors.ErrInvalidStatus, "network %s has no ipv6 range", net.Name)
+	}
+	endInt := ipv6ToInt(end)
+	one := big.NewInt(1)
+	// the used table is finite, so the lowest free address is found within
+	// len(addrTable)+1 steps
+	for cur := ipv6ToInt(start); cur.Cmp(endInt) <= 0; cur.Add(cur, one) {
+		addr := intToIPv6(cur).String()
+		if !addrTable[addr] {
+			return addr, nil
+		}
+	}
+	return "", errors.Wrapf(httperrors.ErrOutOfResource, "no free ipv6 address in network %s", net.Name)
+}
@@ -789,8 +789,15 @@ func (self *SGuest) purge(ctx context.Context, userCred mcclient.TokenCredential
 	tapFlows := NetTapFlowManager.Query("id").In("tap_id", tapService.SubQuery())
 	tapNics := NetTapFlowManager.Query("id").Equals("type", api.TapFlowGuestNic).Equals("source_id", self.Id)
 	backends := LoadbalancerBackendManager.Query("id").Equals("backend_id", self.Id)
+	fileShares := GuestFileShareManager.Query("id").Equals("guest_id", self.Id)
+	backuppolicies := BackupPolicyResourceManager.Query("id").Equals("resource_type", api.BACKUP_POLICY_RESOURCE_SERVER).Equals("resource_id", self.Id)
+	// members failed over keep protecting the servers to be recreated on failback
+	drMembers := DrProtectionGroupMemberManager.Query("id").Equals("guest_id", self.Id).IsNullOrEmpty("failover_guest_id")
 
 	pairs := []purgePair{
+		{manager: GuestFileShareManager, key: "id", q: fileShares},
+		{manager: BackupPolicyResourceManager, key: "id", q: backuppolicies},
+		{manager: DrProtectionGroupMemberManager, key: "id", q: drMembers},
 		{manager: LoadbalancerBackendManager, key: "id", q: backends},
 		{manager: NetTapFlowManager, key: "id", q: tapNics},
 		{manager: NetTapFlowManager, key: "id", q: tapFlows},
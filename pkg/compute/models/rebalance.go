@@ -0,0 +1,318 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/compute/rebalancer"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/mcclient/modules/scheduler"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
+)
+
+const (
+	REBALANCE_METRICS_DATABASE = "telegraf"
+)
+
+var (
+	// scope id => running, only one rebalance of a scope is executed at a time
+	rebalancingScopes     = make(map[string]bool)
+	rebalancingScopesLock sync.Mutex
+)
+
+func (self *SZone) PerformRebalance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input *api.RebalanceInput) (jsonutils.JSONObject, error) {
+	q := HostManager.Query().Equals("zone_id", self.Id)
+	return rebalanceHosts(ctx, userCred, self, q, input)
+}
+
+func (s *SSchedtag) PerformRebalance(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input *api.RebalanceInput) (jsonutils.JSONObject, error) {
+	if s.ResourceType != HostManager.KeywordPlural() {
+		return nil, httperrors.NewNotSupportedError("schedtag of %s can't be rebalanced", s.ResourceType)
+	}
+	hostIds := HostschedtagManager.Query("host_id").Equals("schedtag_id", s.Id).SubQuery()
+	q := HostManager.Query().In("id", hostIds)
+	return rebalanceHosts(ctx, userCred, s, q, input)
+}
+
+// AutoRebalance rebalances hosts of every zone with options
+func (manager *SZoneManager) AutoRebalance(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	zones := make([]SZone, 0)
+	err := db.FetchModelObjects(manager, manager.Query(), &zones)
+	if err != nil {
+		log.Errorf("AutoRebalance fetch zones: %v", err)
+		return
+	}
+	for i := range zones {
+		_, err := zones[i].PerformRebalance(ctx, userCred, nil, &api.RebalanceInput{})
+		if err != nil {
+			log.Errorf("AutoRebalance zone %s: %v", zones[i].Name, err)
+		}
+	}
+}
+
+func rebalanceHosts(ctx context.Context, userCred mcclient.TokenCredential, scope db.IModel, q *sqlchemy.SQuery, input *api.RebalanceInput) (jsonutils.JSONObject, error) {
+	opts := rebalancer.SPlanOptions{
+		Threshold:     options.Options.RebalanceThreshold,
+		MaxMigrations: options.Options.RebalanceMaxMigrations,
+	}
+	if input.Threshold != nil {
+		opts.Threshold = *input.Threshold
+	}
+	if input.MaxMigrations > 0 {
+		opts.MaxMigrations = input.MaxMigrations
+	}
+	maxConcurrent := options.Options.RebalanceMaxConcurrentMigrations
+	if input.MaxConcurrentMigrations > 0 {
+		maxConcurrent = input.MaxConcurrentMigrations
+	}
+
+	// scope is released by the executing goroutine once migrations are started
+	executing := false
+	if !input.DryRun {
+		if !claimRebalanceScope(scope.GetId()) {
+			return nil, httperrors.NewConflictError("%s %s is rebalancing", scope.Keyword(), scope.GetName())
+		}
+		defer func() {
+			if !executing {
+				releaseRebalanceScope(scope.GetId())
+			}
+		}()
+	}
+
+	q = q.Equals("host_type", api.HOST_TYPE_HYPERVISOR).
+		Equals("host_status", api.HOST_ONLINE).
+		Equals("status", api.HOST_STATUS_RUNNING).
+		IsTrue("enabled")
+	hosts, err := fetchRebalanceHosts(q)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch rebalance hosts")
+	}
+	metrics := hostmetrics.NewHostMetricsManager(
+		hostmetrics.NewInfluxdbDataSourceGetter(options.Options.Region, REBALANCE_METRICS_DATABASE),
+		0, options.Options.RebalanceMetricsTimeRange)
+	if err := metrics.SyncOnce(ctx); err != nil {
+		log.Warningf("sync host metrics for rebalance, fallback to commit rate: %v", err)
+	}
+	staleTimeout := time.Duration(options.Options.RebalanceMetricsStaleMinutes) * time.Minute
+	for _, h := range hosts {
+		setRebalanceHostUsage(h, metrics.Get(h.Id), staleTimeout)
+	}
+	plan, err := rebalancer.Plan(ctx, hosts, &sRebalanceScheduler{userCred: userCred}, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "plan rebalance")
+	}
+	if input.DryRun || len(plan.Migrations) == 0 {
+		return jsonutils.Marshal(plan), nil
+	}
+
+	executing = true
+	go func() {
+		defer releaseRebalanceScope(scope.GetId())
+		migrator := &sRebalanceMigrator{userCred: userCred}
+		err := rebalancer.Execute(context.Background(), plan, migrator, maxConcurrent)
+		if err != nil {
+			db.OpsLog.LogEvent(scope, db.ACT_REBALANCE_FAIL, jsonutils.Marshal(plan), userCred)
+			return
+		}
+		db.OpsLog.LogEvent(scope, db.ACT_REBALANCE, jsonutils.Marshal(plan), userCred)
+	}()
+	return jsonutils.Marshal(plan), nil
+}
+
+// claimRebalanceScope marks scope rebalancing, the lock only guards the map
+// as planning calls scheduler remotely and could take long
+func claimRebalanceScope(scopeId string) bool {
+	rebalancingScopesLock.Lock()
+	defer rebalancingScopesLock.Unlock()
+	if rebalancingScopes[scopeId] {
+		return false
+	}
+	rebalancingScopes[scopeId] = true
+	return true
+}
+
+func releaseRebalanceScope(scopeId string) {
+	rebalancingScopesLock.Lock()
+	defer rebalancingScopesLock.Unlock()
+	delete(rebalancingScopes, scopeId)
+}
+
+func fetchRebalanceHosts(q *sqlchemy.SQuery) ([]*rebalancer.SHost, error) {
+	hosts := make([]SHost, 0)
+	err := db.FetchModelObjects(HostManager, q, &hosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch hosts")
+	}
+	ret := make([]*rebalancer.SHost, 0, len(hosts))
+	guestIds := make([]string, 0)
+	guestMap := make(map[string]*rebalancer.SGuest)
+	for i := range hosts {
+		rh := &rebalancer.SHost{
+			Id:                hosts[i].Id,
+			Name:              hosts[i].Name,
+			CpuCount:          int64(hosts[i].GetVirtualCPUCount()),
+			MemSizeMb:         int64(hosts[i].GetVirtualMemorySize()),
+			PhysicalCpuCount:  int64(hosts[i].CpuCount),
+			PhysicalMemSizeMb: int64(hosts[i].MemSize),
+		}
+		guests, err := hosts[i].GetGuests()
+		if err != nil {
+			return nil, errors.Wrapf(err, "get guests of host %s", hosts[i].Name)
+		}
+		for j := range guests {
+			g := &rebalancer.SGuest{
+				Id:         guests[j].Id,
+				Name:       guests[j].Name,
+				VcpuCount:  int64(guests[j].VcpuCount),
+				VmemSizeMb: int64(guests[j].VmemSize),
+				Movable:    guests[j].Status == api.VM_RUNNING && len(guests[j].BackupHostId) == 0,
+			}
+			rh.Guests = append(rh.Guests, g)
+			guestIds = append(guestIds, g.Id)
+			guestMap[g.Id] = g
+		}
+		ret = append(ret, rh)
+	}
+	if len(guestIds) == 0 {
+		return ret, nil
+	}
+
+	groupguests := make([]SGroupguest, 0)
+	err = GroupguestManager.Query().In("guest_id", guestIds).All(&groupguests)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch groupguests")
+	}
+	groupIds := make([]string, 0)
+	for i := range groupguests {
+		groupIds = append(groupIds, groupguests[i].GroupId)
+	}
+	groups := make(map[string]SGroup)
+	err = db.FetchStandaloneObjectsByIds(GroupManager, groupIds, &groups)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch groups")
+	}
+	for i := range groupguests {
+		group, ok := groups[groupguests[i].GroupId]
+		if !ok || !group.Enabled.Bool() {
+			continue
+		}
+		g := guestMap[groupguests[i].GuestId]
+		g.InstanceGroups = append(g.InstanceGroups, rebalancer.SInstanceGroup{
+			Id:              group.Id,
+			Granularity:     group.Granularity,
+			ForceDispersion: group.ForceDispersion.IsTrue(),
+		})
+	}
+	return ret, nil
+}
+
+// setRebalanceHostUsage loads host with actual usage of metrics, commit rate
+// is taken instead if metrics is missing or stale like scheduler does
+func setRebalanceHostUsage(h *rebalancer.SHost, metrics *hostmetrics.SHostMetrics, staleTimeout time.Duration) {
+	if metrics.IsStale(staleTimeout) {
+		log.Debugf("host %s metrics is missing or stale, use commit rate", h.Name)
+		h.SetCommitUsage()
+		return
+	}
+	h.SetUsage(metrics.CpuUsage, metrics.MemUsage)
+}
+
+// sRebalanceScheduler checks target host with scheduler live migrate forecast
+type sRebalanceScheduler struct {
+	userCred mcclient.TokenCredential
+}
+
+func (s *sRebalanceScheduler) CanMigrate(ctx context.Context, guestId string, targetHostId string) (bool, error) {
+	guest := GuestManager.FetchGuestById(guestId)
+	if guest == nil {
+		return false, errors.Wrapf(errors.ErrNotFound, "guest %s", guestId)
+	}
+	lmInput := &api.GuestLiveMigrateInput{PreferHostId: targetHostId}
+	if err := guest.validateMigrate(ctx, s.userCred, nil, lmInput); err != nil {
+		log.Debugf("guest %s can't be live migrated: %v", guest.Name, err)
+		return false, nil
+	}
+	schedParams := guest.GetSchedMigrateParams(s.userCred, &api.ServerMigrateForecastInput{
+		PreferHostId: targetHostId,
+		LiveMigrate:  true,
+	})
+	session := auth.GetAdminSession(ctx, options.Options.Region)
+	canCreate, res, err := scheduler.SchedManager.DoScheduleForecast(session, schedParams, 1)
+	if err != nil {
+		return false, errors.Wrap(err, "do schedule forecast")
+	}
+	if !canCreate {
+		log.Debugf("guest %s can't be live migrated to host %s: %s", guest.Name, targetHostId, res)
+	}
+	return canCreate, nil
+}
+
+// sRebalanceMigrator starts live migrate task of guest and waits for it finished
+type sRebalanceMigrator struct {
+	userCred mcclient.TokenCredential
+}
+
+func (m *sRebalanceMigrator) LiveMigrate(ctx context.Context, guestId string, targetHostId string) error {
+	guest := GuestManager.FetchGuestById(guestId)
+	if guest == nil {
+		return errors.Wrapf(errors.ErrNotFound, "guest %s", guestId)
+	}
+	if guest.HostId == targetHostId {
+		return nil
+	}
+	_, err := guest.PerformLiveMigrate(ctx, m.userCred, nil, &api.GuestLiveMigrateInput{PreferHostId: targetHostId})
+	if err != nil {
+		return errors.Wrap(err, "perform live migrate")
+	}
+
+	timeout := time.After(time.Duration(options.Options.RebalanceMigrateTimeoutMinutes) * time.Minute)
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-timeout:
+			return errors.Wrapf(errors.ErrTimeout, "wait guest %s live migrate", guest.Name)
+		case <-ticker.C:
+		}
+		guest = GuestManager.FetchGuestById(guestId)
+		if guest == nil {
+			return errors.Wrapf(errors.ErrNotFound, "guest %s", guestId)
+		}
+		if guest.Status == api.VM_MIGRATE_FAILED {
+			return fmt.Errorf("guest %s live migrate failed in status %s", guest.Name, guest.Status)
+		}
+		if guest.Status != api.VM_RUNNING {
+			continue
+		}
+		if guest.HostId != targetHostId {
+			return fmt.Errorf("guest %s is running on host %s instead of %s", guest.Name, guest.HostId, targetHostId)
+		}
+		return nil
+	}
+}
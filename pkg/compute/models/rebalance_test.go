@@ -0,0 +1,71 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/compute/rebalancer"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
+)
+
+func TestSetRebalanceHostUsage(t *testing.T) {
+	cases := []struct {
+		name    string
+		metrics *hostmetrics.SHostMetrics
+		// cpu cores and memory of the guest in use
+		wantCpu float64
+		wantMem float64
+	}{
+		{
+			name:    "no metrics",
+			wantCpu: 2,
+			wantMem: 8192,
+		},
+		{
+			name:    "stale metrics",
+			metrics: &hostmetrics.SHostMetrics{CpuUsage: 80, MemUsage: 80, UpdatedAt: time.Now().Add(-time.Hour)},
+			wantCpu: 2,
+			wantMem: 8192,
+		},
+		{
+			name:    "fresh metrics",
+			metrics: &hostmetrics.SHostMetrics{CpuUsage: 75, MemUsage: 25, UpdatedAt: time.Now()},
+			wantCpu: 3,
+			wantMem: 4096,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			guest := &rebalancer.SGuest{Id: "guest0", VcpuCount: 8, VmemSizeMb: 16384}
+			other := &rebalancer.SGuest{Id: "guest1", VcpuCount: 8, VmemSizeMb: 16384}
+			// overcommitted 4 times of cpu and 2 times of memory
+			host := &rebalancer.SHost{
+				Id:                "host0",
+				CpuCount:          32,
+				MemSizeMb:         65536,
+				PhysicalCpuCount:  8,
+				PhysicalMemSizeMb: 32768,
+				Guests:            []*rebalancer.SGuest{guest, other},
+			}
+			setRebalanceHostUsage(host, c.metrics, 5*time.Minute)
+			if math.Abs(guest.CpuUsage-c.wantCpu) > 0.001 || math.Abs(guest.MemUsageMb-c.wantMem) > 0.001 {
+				t.Errorf("want usage %v cores %vMb, got %v cores %vMb", c.wantCpu, c.wantMem, guest.CpuUsage, guest.MemUsageMb)
+			}
+		})
+	}
+}
@@ -61,6 +61,9 @@ type SSchedpolicy struct {
 	Condition string `width:"1024" charset:"ascii" nullable:"false" list:"user" create:"required" update:"user"`
 	Strategy  string `width:"32" charset:"ascii" nullable:"false" list:"user" create:"required" update:"user"`
 
+	// host load scoring used by scheduler when policy matched, commit or metrics
+	LoadPriority string `width:"16" charset:"ascii" nullable:"true" list:"user" create:"optional" update:"user"`
+
 	Enabled tristate.TriState `default:"true" create:"optional" list:"user" update:"user"`
 }
 
@@ -79,6 +82,11 @@ func validateSchedpolicyInputData(data *jsonutils.JSONDict, create bool) error {
 		return httperrors.NewInputParameterError("invalid strategy %s", strategyStr)
 	}
 
+	loadPriority := jsonutils.GetAnyString(data, []string{"load_priority"})
+	if len(loadPriority) > 0 && !utils.IsInStringArray(loadPriority, api.SCHED_LOAD_PRIORITY_LIST) {
+		return httperrors.NewInputParameterError("invalid load_priority %s", loadPriority)
+	}
+
 	return nil
 }
 
@@ -283,6 +291,22 @@ func applyServerSchedtags(policies []SSchedpolicy, input *schedapi.ScheduleInput
 	applyResourceSchedPolicy(policies, input.Schedtags, inputCond, setFunc)
 }
 
+func applyServerLoadPriority(policies []SSchedpolicy, input *schedapi.ScheduleInput) {
+	if len(input.LoadPriority) > 0 {
+		return
+	}
+	inputCond := GetDynamicConditionInput(GuestManager, input.ToConditionInput())
+	for i := range policies {
+		if len(policies[i].LoadPriority) == 0 {
+			continue
+		}
+		if matchResourceSchedPolicy(policies[i], inputCond) {
+			input.LoadPriority = policies[i].LoadPriority
+			return
+		}
+	}
+}
+
 func applyDiskSchedtags(policies []SSchedpolicy, input *api.DiskConfig) {
 	inputCond := GetDynamicConditionInput(DiskManager, jsonutils.Marshal(input).(*jsonutils.JSONDict))
 	setFunc := func(tags []*api.SchedtagConfig) {
@@ -308,6 +332,7 @@ func ApplySchedPolicies(input *schedapi.ScheduleInput) *schedapi.ScheduleInput {
 	config := input.ServerConfigs
 
 	applyServerSchedtags(hostPolicies, input)
+	applyServerLoadPriority(hostPolicies, input)
 	for _, disk := range config.Disks {
 		applyDiskSchedtags(storagePolicies, disk)
 	}
@@ -458,6 +458,15 @@ func (self *SSchedtag) getMoreColumns(out api.SchedtagDetails) api.SchedtagDetai
 		out.HostCount = cnt
 	case GuestManager.Keyword():
 		out.ServerCount = cnt
+	case StorageManager.Keyword():
+		out.OtherCount = cnt
+		out.JoinModelKeyword = keyword
+		forecast, err := self.getStorageCapacityForecast()
+		if err != nil {
+			log.Errorf("getStorageCapacityForecast of schedtag %s: %v", self.Name, err)
+		} else {
+			out.StorageForecast = forecast
+		}
 	default:
 		out.OtherCount = cnt
 		out.JoinModelKeyword = keyword
@@ -0,0 +1,300 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+const (
+	// flow logs are written by host through telegraf
+	SECGROUP_FLOW_LOG_DATABASE = "telegraf"
+
+	secgroupFlowLogDefaultLimit = 100
+	secgroupFlowLogMaxLimit     = 1000
+)
+
+// 设置安全组流日志记录模式
+func (self *SSecurityGroup) PerformSetFlowLog(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.SecgroupSetFlowLogInput) (jsonutils.JSONObject, error) {
+	if !utils.IsInStringArray(input.FlowLog, api.SECGROUP_FLOW_LOG_MODES) {
+		return nil, httperrors.NewInputParameterError("invalid flow_log %q, want one of %s", input.FlowLog, api.SECGROUP_FLOW_LOG_MODES)
+	}
+	if self.FlowLog == input.FlowLog {
+		return nil, nil
+	}
+	diff, err := db.Update(self, func() error {
+		self.FlowLog = input.FlowLog
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Update")
+	}
+	logclient.AddSimpleActionLog(self, logclient.ACT_UPDATE, diff, userCred, true)
+	return nil, nil
+}
+
+// 查询安全组规则命中, 以及安全组内虚拟机被默认拒绝的流日志
+func (self *SSecurityGroup) GetDetailsFlowLogs(ctx context.Context, userCred mcclient.TokenCredential, input api.SecgroupFlowLogInput) (*api.SecgroupFlowLogOutput, error) {
+	rules, err := self.getSecurityRules()
+	if err != nil {
+		return nil, errors.Wrap(err, "getSecurityRules")
+	}
+	macs, err := self.getGuestMacs()
+	if err != nil {
+		return nil, errors.Wrap(err, "getGuestMacs")
+	}
+	ret := &api.SecgroupFlowLogOutput{Records: []api.SecgroupFlowLogRecord{}}
+	ruleIds := make([]string, len(rules))
+	for i := range rules {
+		ruleIds[i] = rules[i].Id
+	}
+	conds := secgroupFlowLogConds(ruleIds, macs)
+	if len(conds) == 0 {
+		return ret, nil
+	}
+	ret.Records, err = fetchSecgroupFlowLogs(ctx, input, conds)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ret.Records {
+		ret.Records[i].SecgroupId = self.Id
+	}
+	return ret, nil
+}
+
+// 查询虚拟机网卡的安全组流日志
+func (self *SGuest) GetDetailsSecgroupFlowLogs(ctx context.Context, userCred mcclient.TokenCredential, input api.SecgroupFlowLogInput) (*api.SecgroupFlowLogOutput, error) {
+	gns, err := self.GetNetworks("")
+	if err != nil {
+		return nil, errors.Wrap(err, "GetNetworks")
+	}
+	ret := &api.SecgroupFlowLogOutput{Records: []api.SecgroupFlowLogRecord{}}
+	if len(gns) == 0 {
+		return ret, nil
+	}
+	conds := make([]string, 0, 2*len(gns))
+	for i := range gns {
+		conds = append(conds,
+			flowLogTagEquals("src_mac", gns[i].MacAddr),
+			flowLogTagEquals("dst_mac", gns[i].MacAddr),
+		)
+	}
+	ret.Records, err = fetchSecgroupFlowLogs(ctx, input, conds)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleIds := []string{}
+	for i := range ret.Records {
+		if ruleId := ret.Records[i].RuleId; ruleId != api.SECGROUP_FLOW_LOG_DEFAULT_DENY_RULE && !utils.IsInStringArray(ruleId, ruleIds) {
+			ruleIds = append(ruleIds, ruleId)
+		}
+	}
+	secgroupIds := map[string]string{}
+	if len(ruleIds) > 0 {
+		rules := []SSecurityGroupRule{}
+		q := SecurityGroupRuleManager.Query().In("id", ruleIds)
+		err := db.FetchModelObjects(SecurityGroupRuleManager, q, &rules)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch security group rules")
+		}
+		for i := range rules {
+			secgroupIds[rules[i].Id] = rules[i].SecgroupId
+		}
+	}
+	// traffic not allowed by any secgroup of guest is dropped by default
+	secgroups, err := self.GetSecgroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSecgroups")
+	}
+	defaultDenyIds := make([]string, len(secgroups))
+	for i := range secgroups {
+		defaultDenyIds[i] = secgroups[i].Id
+	}
+	secgroupIds[api.SECGROUP_FLOW_LOG_DEFAULT_DENY_RULE] = strings.Join(defaultDenyIds, ",")
+	for i := range ret.Records {
+		ret.Records[i].SecgroupId = secgroupIds[ret.Records[i].RuleId]
+	}
+	return ret, nil
+}
+
+// getGuestMacs returns mac of nics of guests in secgroup, which tag the
+// records dropped by default
+func (self *SSecurityGroup) getGuestMacs() ([]string, error) {
+	guests := self.GetGuestsQuery().SubQuery()
+	q := GuestnetworkManager.Query().In("guest_id", guests.Query(guests.Field("id")).SubQuery())
+	gns := []SGuestnetwork{}
+	err := db.FetchModelObjects(GuestnetworkManager, q, &gns)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch guestnetworks")
+	}
+	macs := make([]string, 0, len(gns))
+	for i := range gns {
+		macs = append(macs, gns[i].MacAddr)
+	}
+	return macs, nil
+}
+
+// secgroupFlowLogConds matches records hit by rules of secgroup, and those
+// dropped by default from or to guests in the secgroup
+func secgroupFlowLogConds(ruleIds []string, macs []string) []string {
+	conds := make([]string, 0, len(ruleIds)+1)
+	for i := range ruleIds {
+		conds = append(conds, flowLogTagEquals("rule_id", ruleIds[i]))
+	}
+	if len(macs) == 0 {
+		return conds
+	}
+	macConds := make([]string, 0, 2*len(macs))
+	for i := range macs {
+		macConds = append(macConds,
+			flowLogTagEquals("src_mac", macs[i]),
+			flowLogTagEquals("dst_mac", macs[i]),
+		)
+	}
+	conds = append(conds, fmt.Sprintf("(%s AND (%s))",
+		flowLogTagEquals("rule_id", api.SECGROUP_FLOW_LOG_DEFAULT_DENY_RULE), strings.Join(macConds, " OR ")))
+	return conds
+}
+
+func flowLogTagEquals(tag, val string) string {
+	return fmt.Sprintf(`"%s" = '%s'`, tag, strings.ReplaceAll(val, "'", `\'`))
+}
+
+// secgroupFlowLogSql builds the query for records matching any of conds
+func secgroupFlowLogSql(input api.SecgroupFlowLogInput, conds []string) (string, error) {
+	if input.EndTime.IsZero() {
+		input.EndTime = time.Now()
+	}
+	if input.StartTime.IsZero() {
+		input.StartTime = input.EndTime.Add(-time.Hour)
+	}
+	if !input.StartTime.Before(input.EndTime) {
+		return "", httperrors.NewInputParameterError("start_time should be before end_time")
+	}
+	if input.Limit <= 0 {
+		input.Limit = secgroupFlowLogDefaultLimit
+	} else if input.Limit > secgroupFlowLogMaxLimit {
+		input.Limit = secgroupFlowLogMaxLimit
+	}
+
+	wheres := []string{
+		fmt.Sprintf("time >= '%s'", input.StartTime.UTC().Format(time.RFC3339)),
+		fmt.Sprintf("time <= '%s'", input.EndTime.UTC().Format(time.RFC3339)),
+		"(" + strings.Join(conds, " OR ") + ")",
+	}
+	switch input.Verdict {
+	case "":
+	case "allow", "drop":
+		wheres = append(wheres, flowLogTagEquals("verdict", input.Verdict))
+	default:
+		return "", httperrors.NewInputParameterError("invalid verdict %q", input.Verdict)
+	}
+	sql := fmt.Sprintf(`SELECT * FROM "%s" WHERE %s ORDER BY time DESC LIMIT %d`,
+		api.SECGROUP_FLOW_LOG_MEASUREMENT, strings.Join(wheres, " AND "), input.Limit)
+	return sql, nil
+}
+
+func fetchSecgroupFlowLogs(ctx context.Context, input api.SecgroupFlowLogInput, conds []string) ([]api.SecgroupFlowLogRecord, error) {
+	sql, err := secgroupFlowLogSql(input, conds)
+	if err != nil {
+		return nil, err
+	}
+	s := auth.GetAdminSession(ctx, options.Options.Region)
+	urls, err := s.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, options.Options.MonitorEndpointType)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "GetServiceURLs"))
+	}
+	if len(urls) == 0 {
+		return nil, httperrors.NewNotFoundError("no influxdb endpoint")
+	}
+	dbinst := influxdb.NewInfluxdb(urls[0])
+	err = dbinst.SetDatabase(SECGROUP_FLOW_LOG_DATABASE)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "SetDatabase"))
+	}
+	queryRes, err := dbinst.Query(sql)
+	if err != nil {
+		return nil, httperrors.NewGeneralError(errors.Wrap(err, "query influxdb"))
+	}
+	ret := []api.SecgroupFlowLogRecord{}
+	if len(queryRes) == 0 {
+		return ret, nil
+	}
+	for _, series := range queryRes[0] {
+		for _, vals := range series.Values {
+			ret = append(ret, newSecgroupFlowLogRecord(series.Columns, vals))
+		}
+	}
+	return ret, nil
+}
+
+func newSecgroupFlowLogRecord(columns []string, vals []jsonutils.JSONObject) api.SecgroupFlowLogRecord {
+	record := api.SecgroupFlowLogRecord{}
+	for i, col := range columns {
+		if i >= len(vals) || vals[i] == nil || vals[i] == jsonutils.JSONNull {
+			continue
+		}
+		val := vals[i]
+		str, _ := val.GetString()
+		num, _ := val.Int()
+		switch col {
+		case "time":
+			record.Time = time.UnixMilli(num)
+		case "host_id":
+			record.HostId = str
+		case "rule_id":
+			record.RuleId = str
+		case "verdict":
+			record.Verdict = str
+		case "direction":
+			record.Direction = str
+		case "proto":
+			record.Protocol = str
+		case "src_mac":
+			record.SrcMac = str
+		case "dst_mac":
+			record.DstMac = str
+		case "src_ip":
+			record.SrcIp = str
+		case "dst_ip":
+			record.DstIp = str
+		case "src_port":
+			record.SrcPort = int(num)
+		case "dst_port":
+			record.DstPort = int(num)
+		case "packets":
+			record.Packets = num
+		}
+	}
+	return record
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecgroupFlowLogConds(t *testing.T) {
+	cases := []struct {
+		name    string
+		ruleIds []string
+		macs    []string
+		want    []string
+	}{
+		{
+			name: "empty secgroup",
+			want: []string{},
+		},
+		{
+			name:    "rules only",
+			ruleIds: []string{"rule0", "rule1"},
+			want:    []string{`"rule_id" = 'rule0'`, `"rule_id" = 'rule1'`},
+		},
+		{
+			name: "guests without rules",
+			macs: []string{"00:22:aa:00:00:01"},
+			want: []string{
+				`("rule_id" = 'default-deny' AND ("src_mac" = '00:22:aa:00:00:01' OR "dst_mac" = '00:22:aa:00:00:01'))`,
+			},
+		},
+		{
+			name:    "rules and guests",
+			ruleIds: []string{"rule0"},
+			macs:    []string{"00:22:aa:00:00:01", "00:22:aa:00:00:02"},
+			want: []string{
+				`"rule_id" = 'rule0'`,
+				`("rule_id" = 'default-deny' AND ("src_mac" = '00:22:aa:00:00:01' OR "dst_mac" = '00:22:aa:00:00:01' OR "src_mac" = '00:22:aa:00:00:02' OR "dst_mac" = '00:22:aa:00:00:02'))`,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := secgroupFlowLogConds(c.ruleIds, c.macs)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %#v, got %#v", c.want, got)
+			}
+		})
+	}
+}
@@ -75,6 +75,9 @@ const (
 type SSecurityGroup struct {
 	db.SSharableVirtualResourceBase
 	IsDirty bool `nullable:"false" default:"false"`
+
+	// 流日志记录模式
+	FlowLog string `width:"8" charset:"ascii" nullable:"false" default:"off" list:"user" create:"optional"`
 }
 
 // 安全组列表
@@ -581,6 +584,10 @@ func (manager *SSecurityGroupManager) ValidateCreateData(
 
 	input.Status = api.SECGROUP_STATUS_READY
 
+	if len(input.FlowLog) > 0 && !utils.IsInStringArray(input.FlowLog, api.SECGROUP_FLOW_LOG_MODES) {
+		return input, httperrors.NewInputParameterError("invalid flow_log %q, want one of %s", input.FlowLog, api.SECGROUP_FLOW_LOG_MODES)
+	}
+
 	for i := range input.Rules {
 		err = input.Rules[i].Check()
 		if err != nil {
@@ -56,6 +56,8 @@ type SSnapshotPolicy struct {
 	// 0~23
 	TimePoints  uint32            `charset:"utf8" create:"required" list:"user" get:"user"`
 	IsActivated tristate.TriState `list:"user" get:"user" create:"optional" default:"true"`
+	// 自动快照是否冻结文件系统保证应用一致性
+	Consistent bool `nullable:"false" default:"false" list:"user" get:"user" create:"optional" update:"user"`
 }
 
 var SnapshotPolicyManager *SSnapshotPolicyManager
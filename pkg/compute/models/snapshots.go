@@ -89,6 +89,9 @@ type SSnapshot struct {
 
 	BackingDiskId string    `width:"36" charset:"ascii" nullable:"true" default:""`
 	ExpiredAt     time.Time `nullable:"true" list:"user" create:"optional"`
+
+	// 是否为冻结文件系统后创建的应用一致性快照
+	ApplicationConsistent bool `nullable:"false" default:"false" list:"user"`
 }
 
 var SnapshotManager *SSnapshotManager
@@ -531,7 +534,11 @@ func (snapshot *SSnapshot) PostCreate(ctx context.Context, userCred mcclient.Tok
 
 func (manager *SSnapshotManager) OnCreateComplete(ctx context.Context, items []db.IModel, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data []jsonutils.JSONObject) {
 	snapshot := items[0].(*SSnapshot)
-	snapshot.StartSnapshotCreateTask(ctx, userCred, nil, "")
+	params := jsonutils.NewDict()
+	if jsonutils.QueryBoolean(data[0], "consistent", false) {
+		params.Set("consistent", jsonutils.JSONTrue)
+	}
+	snapshot.StartSnapshotCreateTask(ctx, userCred, params, "")
 }
 
 func (self *SSnapshot) StartSnapshotCreateTask(ctx context.Context, userCred mcclient.TokenCredential, params *jsonutils.JSONDict, parentTaskId string) error {
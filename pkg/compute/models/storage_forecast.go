@@ -0,0 +1,344 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/sqlchemy"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/mcclient"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/util/influxdb"
+)
+
+const (
+	STORAGE_FORECAST_DATABASE = "telegraf"
+)
+
+type sCapacitySample struct {
+	At         time.Time
+	ActualUsed float64
+	CommitUsed float64
+}
+
+// fitGrowthRate returns the least squares slope of the samples in Mb per day
+func fitGrowthRate(samples []sCapacitySample, value func(s sCapacitySample) float64) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	start := samples[0].At
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range samples {
+		x := samples[i].At.Sub(start).Hours() / 24
+		y := value(samples[i])
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(samples))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// daysUntilFull returns nil if usage is not growing
+func daysUntilFull(capacity, used int64, rate float64) *int {
+	if capacity <= 0 || rate <= 0 {
+		return nil
+	}
+	days := 0
+	if used < capacity {
+		days = int(math.Ceil(float64(capacity-used) / rate))
+	}
+	return &days
+}
+
+func (self *SStorage) getCapacityForecast(usage api.StorageUsage) api.StorageCapacityForecast {
+	capacity := self.GetCapacity()
+	ret := api.StorageCapacityForecast{
+		Capacity:         capacity,
+		ActualUsed:       self.ActualCapacityUsed,
+		VirtualCapacity:  int64(float32(capacity) * self.GetOvercommitBound()),
+		CommitUsed:       usage.Used + usage.Wasted,
+		UsageGrowthRate:  self.UsageGrowthRate,
+		CommitGrowthRate: self.CommitGrowthRate,
+	}
+	ret.DaysUntilFull = daysUntilFull(ret.Capacity, ret.ActualUsed, ret.UsageGrowthRate)
+	ret.CommitDaysUntilFull = daysUntilFull(ret.VirtualCapacity, ret.CommitUsed, ret.CommitGrowthRate)
+	return ret
+}
+
+func (self *SStorage) GetDetailsCapacityForecast(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject) (api.StorageCapacityForecast, error) {
+	usages, err := StorageManager.TotalResourceCount([]string{self.Id})
+	if err != nil {
+		return api.StorageCapacityForecast{}, errors.Wrap(err, "TotalResourceCount")
+	}
+	return self.getCapacityForecast(usages[self.Id]), nil
+}
+
+func addCapacityForecast(sum *api.StorageCapacityForecast, f api.StorageCapacityForecast) {
+	sum.Capacity += f.Capacity
+	sum.ActualUsed += f.ActualUsed
+	sum.VirtualCapacity += f.VirtualCapacity
+	sum.CommitUsed += f.CommitUsed
+	sum.UsageGrowthRate += f.UsageGrowthRate
+	sum.CommitGrowthRate += f.CommitGrowthRate
+}
+
+// getStorageCapacityForecast sums up the trends of storages attached to the schedtag,
+// the slope of summed samples equals to the sum of slopes
+func (self *SSchedtag) getStorageCapacityForecast() (*api.StorageCapacityForecast, error) {
+	sq := StorageschedtagManager.Query("storage_id").Equals("schedtag_id", self.Id).SubQuery()
+	storages := make([]SStorage, 0)
+	err := db.FetchModelObjects(StorageManager, StorageManager.Query().In("id", sq), &storages)
+	if err != nil {
+		return nil, errors.Wrap(err, "FetchModelObjects")
+	}
+	storageIds := make([]string, len(storages))
+	for i := range storages {
+		storageIds[i] = storages[i].Id
+	}
+	usages, err := StorageManager.TotalResourceCount(storageIds)
+	if err != nil {
+		return nil, errors.Wrap(err, "TotalResourceCount")
+	}
+	ret := &api.StorageCapacityForecast{}
+	for i := range storages {
+		addCapacityForecast(ret, storages[i].getCapacityForecast(usages[storages[i].Id]))
+	}
+	ret.DaysUntilFull = daysUntilFull(ret.Capacity, ret.ActualUsed, ret.UsageGrowthRate)
+	ret.CommitDaysUntilFull = daysUntilFull(ret.VirtualCapacity, ret.CommitUsed, ret.CommitGrowthRate)
+	return ret, nil
+}
+
+func newCapacityForecastMetric(name string, f api.StorageCapacityForecast, now time.Time) influxdb.SMetricData {
+	metric := influxdb.SMetricData{
+		Name:      name,
+		Timestamp: now,
+		Metrics: []influxdb.SKeyValue{
+			{Key: "capacity", Value: fmt.Sprintf("%d", f.Capacity)},
+			{Key: "actual_used", Value: fmt.Sprintf("%d", f.ActualUsed)},
+			{Key: "virtual_capacity", Value: fmt.Sprintf("%d", f.VirtualCapacity)},
+			{Key: "commit_used", Value: fmt.Sprintf("%d", f.CommitUsed)},
+			{Key: "usage_growth_rate", Value: fmt.Sprintf("%f", f.UsageGrowthRate)},
+			{Key: "commit_growth_rate", Value: fmt.Sprintf("%f", f.CommitGrowthRate)},
+		},
+	}
+	if f.DaysUntilFull != nil {
+		metric.Metrics = append(metric.Metrics, influxdb.SKeyValue{Key: "days_until_full", Value: fmt.Sprintf("%d", *f.DaysUntilFull)})
+	}
+	if f.CommitDaysUntilFull != nil {
+		metric.Metrics = append(metric.Metrics, influxdb.SKeyValue{Key: "commit_days_until_full", Value: fmt.Sprintf("%d", *f.CommitDaysUntilFull)})
+	}
+	return metric
+}
+
+// fetchCapacitySamples loads hourly capacity samples of storages from tsdb
+func (manager *SStorageManager) fetchCapacitySamples(url string) (map[string][]sCapacitySample, error) {
+	dbinst := influxdb.NewInfluxdb(url)
+	err := dbinst.SetDatabase(STORAGE_FORECAST_DATABASE)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetDatabase")
+	}
+	sql := fmt.Sprintf(`SELECT mean("actual_used"), mean("commit_used") FROM "%s" WHERE time > now() - %dd GROUP BY time(1h), "storage_id"`,
+		api.STORAGE_CAPACITY_MEASUREMENT, options.Options.StorageForecastHistoryDays)
+	queryRes, err := dbinst.Query(sql)
+	if err != nil {
+		return nil, errors.Wrap(err, "query influxdb")
+	}
+	ret := map[string][]sCapacitySample{}
+	if len(queryRes) == 0 {
+		return ret, nil
+	}
+	for _, series := range queryRes[0] {
+		if series.Tags == nil {
+			continue
+		}
+		storageId, _ := series.Tags.GetString("storage_id")
+		if len(storageId) == 0 {
+			continue
+		}
+		samples := make([]sCapacitySample, 0, len(series.Values))
+		for _, vals := range series.Values {
+			if len(vals) != 3 || vals[1] == nil || vals[2] == nil {
+				continue
+			}
+			ts, err := vals[0].Int()
+			if err != nil {
+				continue
+			}
+			actual, err := vals[1].Float()
+			if err != nil {
+				continue
+			}
+			commit, err := vals[2].Float()
+			if err != nil {
+				continue
+			}
+			samples = append(samples, sCapacitySample{
+				At:         time.UnixMilli(ts),
+				ActualUsed: actual,
+				CommitUsed: commit,
+			})
+		}
+		ret[storageId] = samples
+	}
+	return ret, nil
+}
+
+// ForecastStorageCapacity samples the capacity usage of storages into tsdb, fits the growth trend
+// of each storage and storage schedtag and writes the forecast back so that alerts can be set on it
+func (manager *SStorageManager) ForecastStorageCapacity(ctx context.Context, userCred mcclient.TokenCredential, isStart bool) {
+	err := manager.forecastStorageCapacity(ctx)
+	if err != nil {
+		log.Errorf("ForecastStorageCapacity: %v", err)
+	}
+}
+
+func (manager *SStorageManager) forecastStorageCapacity(ctx context.Context) error {
+	s := auth.GetAdminSession(ctx, options.Options.Region)
+	urls, err := s.GetServiceURLs(apis.SERVICE_TYPE_INFLUXDB, options.Options.MonitorEndpointType)
+	if err != nil {
+		return errors.Wrap(err, "GetServiceURLs")
+	}
+	if len(urls) == 0 {
+		return errors.Wrap(errors.ErrNotFound, "no influxdb endpoint")
+	}
+
+	storages := make([]SStorage, 0)
+	q := manager.Query().GT("capacity", 0)
+	err = db.FetchModelObjects(manager, q, &storages)
+	if err != nil {
+		return errors.Wrap(err, "FetchModelObjects")
+	}
+	storageIds := make([]string, len(storages))
+	for i := range storages {
+		storageIds[i] = storages[i].Id
+	}
+	usages, err := manager.TotalResourceCount(storageIds)
+	if err != nil {
+		return errors.Wrap(err, "TotalResourceCount")
+	}
+
+	history, err := manager.fetchCapacitySamples(urls[0])
+	if err != nil {
+		// keep sampling, trend will be fitted once history is available
+		log.Warningf("fetch storage capacity samples: %v", err)
+		history = map[string][]sCapacitySample{}
+	}
+
+	now := time.Now()
+	forecasts := map[string]api.StorageCapacityForecast{}
+	metrics := make([]influxdb.SMetricData, 0, len(storages))
+	for i := range storages {
+		storage := &storages[i]
+		usage := usages[storage.Id]
+		samples := append(history[storage.Id], sCapacitySample{
+			At:         now,
+			ActualUsed: float64(storage.ActualCapacityUsed),
+			CommitUsed: float64(usage.Used + usage.Wasted),
+		})
+		usageRate := fitGrowthRate(samples, func(s sCapacitySample) float64 { return s.ActualUsed })
+		commitRate := fitGrowthRate(samples, func(s sCapacitySample) float64 { return s.CommitUsed })
+		_, err := db.Update(storage, func() error {
+			storage.UsageGrowthRate = usageRate
+			storage.CommitGrowthRate = commitRate
+			storage.ForecastAt = now
+			return nil
+		})
+		if err != nil {
+			log.Errorf("update capacity forecast of storage %s: %v", storage.Name, err)
+			continue
+		}
+		forecast := storage.getCapacityForecast(usage)
+		forecasts[storage.Id] = forecast
+		metric := newCapacityForecastMetric(api.STORAGE_CAPACITY_MEASUREMENT, forecast, now)
+		metric.Tags = []influxdb.SKeyValue{
+			{Key: "storage_id", Value: storage.Id},
+			{Key: "storage_name", Value: storage.Name},
+			{Key: "storage_type", Value: storage.StorageType},
+			{Key: "medium_type", Value: storage.MediumType},
+			{Key: "zone_id", Value: storage.ZoneId},
+			{Key: "domain_id", Value: storage.DomainId},
+		}
+		metrics = append(metrics, metric)
+	}
+
+	tagMetrics, err := manager.getSchedtagCapacityForecastMetrics(forecasts, now)
+	if err != nil {
+		log.Errorf("getSchedtagCapacityForecastMetrics: %v", err)
+	}
+	metrics = append(metrics, tagMetrics...)
+
+	return influxdb.BatchSendMetrics(urls, STORAGE_FORECAST_DATABASE, metrics, false)
+}
+
+func (manager *SStorageManager) getSchedtagCapacityForecastMetrics(forecasts map[string]api.StorageCapacityForecast, now time.Time) ([]influxdb.SMetricData, error) {
+	tags := make([]sStorageSchedtag, 0)
+	schedtags := SchedtagManager.Query().SubQuery()
+	storagetags := StorageschedtagManager.Query().SubQuery()
+	q := schedtags.Query(
+		schedtags.Field("id"),
+		schedtags.Field("name"),
+		storagetags.Field("storage_id"),
+	).Join(storagetags, sqlchemy.Equals(storagetags.Field("schedtag_id"), schedtags.Field("id")))
+	err := q.All(&tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "query storage schedtags")
+	}
+
+	names := map[string]string{}
+	sums := map[string]*api.StorageCapacityForecast{}
+	for i := range tags {
+		f, ok := forecasts[tags[i].StorageId]
+		if !ok {
+			continue
+		}
+		sum, ok := sums[tags[i].Id]
+		if !ok {
+			sum = &api.StorageCapacityForecast{}
+			sums[tags[i].Id] = sum
+			names[tags[i].Id] = tags[i].Name
+		}
+		addCapacityForecast(sum, f)
+	}
+
+	metrics := make([]influxdb.SMetricData, 0, len(sums))
+	for id, sum := range sums {
+		sum.DaysUntilFull = daysUntilFull(sum.Capacity, sum.ActualUsed, sum.UsageGrowthRate)
+		sum.CommitDaysUntilFull = daysUntilFull(sum.VirtualCapacity, sum.CommitUsed, sum.CommitGrowthRate)
+		metric := newCapacityForecastMetric(api.STORAGE_SCHEDTAG_CAPACITY_MEASUREMENT, *sum, now)
+		metric.Tags = []influxdb.SKeyValue{
+			{Key: "schedtag_id", Value: id},
+			{Key: "schedtag_name", Value: names[id]},
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
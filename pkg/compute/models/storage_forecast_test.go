@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitGrowthRate(t *testing.T) {
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := func(values ...float64) []sCapacitySample {
+		ret := make([]sCapacitySample, len(values))
+		for i, v := range values {
+			ret[i] = sCapacitySample{
+				At:         start.Add(time.Duration(i) * 24 * time.Hour),
+				ActualUsed: v,
+			}
+		}
+		return ret
+	}
+	cases := []struct {
+		name    string
+		samples []sCapacitySample
+		want    float64
+	}{
+		{
+			name: "no samples",
+			want: 0,
+		},
+		{
+			name:    "single sample",
+			samples: samples(100),
+			want:    0,
+		},
+		{
+			name:    "flat",
+			samples: samples(100, 100, 100, 100),
+			want:    0,
+		},
+		{
+			name:    "linear growth",
+			samples: samples(100, 110, 120, 130),
+			want:    10,
+		},
+		{
+			name:    "shrinking",
+			samples: samples(130, 120, 110, 100),
+			want:    -10,
+		},
+		{
+			name:    "noisy growth",
+			samples: samples(100, 120, 110, 130),
+			want:    8,
+		},
+		{
+			name: "same time",
+			samples: []sCapacitySample{
+				{At: start, ActualUsed: 100},
+				{At: start, ActualUsed: 200},
+			},
+			want: 0,
+		},
+		{
+			name: "hourly samples",
+			samples: []sCapacitySample{
+				{At: start, ActualUsed: 100},
+				{At: start.Add(12 * time.Hour), ActualUsed: 110},
+				{At: start.Add(24 * time.Hour), ActualUsed: 120},
+			},
+			want: 20,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := fitGrowthRate(c.samples, func(s sCapacitySample) float64 { return s.ActualUsed })
+			if math.Abs(got-c.want) > 1e-9 {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDaysUntilFull(t *testing.T) {
+	days := func(d int) *int {
+		return &d
+	}
+	cases := []struct {
+		name     string
+		capacity int64
+		used     int64
+		rate     float64
+		want     *int
+	}{
+		{
+			name:     "flat",
+			capacity: 1000,
+			used:     100,
+			rate:     0,
+		},
+		{
+			name:     "shrinking",
+			capacity: 1000,
+			used:     100,
+			rate:     -10,
+		},
+		{
+			name:     "no capacity",
+			capacity: 0,
+			used:     100,
+			rate:     10,
+		},
+		{
+			name:     "growing",
+			capacity: 1000,
+			used:     100,
+			rate:     10,
+			want:     days(90),
+		},
+		{
+			name:     "round up",
+			capacity: 1000,
+			used:     100,
+			rate:     7,
+			want:     days(129),
+		},
+		{
+			name:     "already full",
+			capacity: 1000,
+			used:     1200,
+			rate:     10,
+			want:     days(0),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := daysUntilFull(c.capacity, c.used, c.rate)
+			if c.want == nil {
+				if got != nil {
+					t.Errorf("want nil, got %d", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("want %d, got nil", *c.want)
+			}
+			if *got != *c.want {
+				t.Errorf("want %d, got %d", *c.want, *got)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"path"
+	"time"
 
 	"yunion.io/x/cloudmux/pkg/cloudprovider"
 	"yunion.io/x/jsonutils"
@@ -100,6 +101,16 @@ type SStorage struct {
 	// 是否可以用作系统盘存储
 	// example: true
 	IsSysDiskStore tristate.TriState `default:"true" list:"user" create:"optional" update:"domain"`
+
+	// 存储上磁盘的默认性能等级
+	DiskPerformanceClassId string `width:"36" charset:"ascii" nullable:"true" list:"user" create:"domain_optional" update:"domain"`
+
+	// 实际使用容量日增长量, 单位Mb, 由容量采样拟合得出
+	UsageGrowthRate float64 `nullable:"false" default:"0" list:"domain"`
+	// 分配容量日增长量, 单位Mb, 由容量采样拟合得出
+	CommitGrowthRate float64 `nullable:"false" default:"0" list:"domain"`
+	// 最近一次容量趋势拟合时间
+	ForecastAt time.Time `nullable:"true" list:"domain"`
 }
 
 func (manager *SStorageManager) GetContextManagers() [][]db.IModelManager {
@@ -119,6 +130,12 @@ func (self *SStorage) ValidateUpdateData(ctx context.Context, userCred mcclient.
 	if self.StorageConf != nil {
 		input.StorageConf.Update(jsonutils.Marshal(self.StorageConf))
 	}
+	if len(input.DiskPerformanceClassId) > 0 {
+		err = validateStorageDiskPerformanceClass(userCred, self.ManagerId, &input.DiskPerformanceClassId, self.MediumType)
+		if err != nil {
+			return input, err
+		}
+	}
 
 	driver := GetStorageDriver(self.StorageType)
 	if driver != nil {
@@ -127,9 +144,28 @@ func (self *SStorage) ValidateUpdateData(ctx context.Context, userCred mcclient.
 	return input, nil
 }
 
+func validateStorageDiskPerformanceClass(userCred mcclient.TokenCredential, managerId string, classId *string, mediumType string) error {
+	if len(managerId) > 0 {
+		return httperrors.NewUnsupportOperationError("disk performance class is not supported by managed storage")
+	}
+	class, err := validateDiskPerformanceClass(userCred, classId)
+	if err != nil {
+		return err
+	}
+	if len(class.MediumType) > 0 && class.MediumType != mediumType {
+		return httperrors.NewInputParameterError("medium %s of storage conflicts with %s of disk performance class %s", mediumType, class.MediumType, class.Name)
+	}
+	return nil
+}
+
 func (self *SStorage) PostUpdate(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	self.SEnabledStatusInfrasResourceBase.PostUpdate(ctx, userCred, query, data)
 
+	if data.Contains("disk_performance_class_id") {
+		disks := DiskManager.Query("id").Equals("storage_id", self.Id).IsNullOrEmpty("disk_performance_class_id")
+		syncDiskPerformanceOfGuests(ctx, userCred, disks)
+	}
+
 	if data.Contains("cmtbound") || data.Contains("capacity") {
 		hosts, _ := self.GetAttachedHosts()
 		for _, host := range hosts {
@@ -237,6 +273,12 @@ func (manager *SStorageManager) ValidateCreateData(
 	if err != nil {
 		return input, err
 	}
+	if len(input.DiskPerformanceClassId) > 0 {
+		err = validateStorageDiskPerformanceClass(userCred, input.ManagerId, &input.DiskPerformanceClassId, input.MediumType)
+		if err != nil {
+			return input, err
+		}
+	}
 	storageDirver := GetStorageDriver(input.StorageType)
 	if storageDirver == nil {
 		return input, httperrors.NewUnsupportOperationError("Not support create %s storage", input.StorageType)
@@ -400,7 +442,7 @@ func (self *SStorage) IsLocal() bool {
 func (self *SStorage) GetStorageCachePath(mountPoint, imageCachePath string) string {
 	if utils.IsInStringArray(self.StorageType, api.SHARED_FILE_STORAGE) {
 		return path.Join(mountPoint, imageCachePath)
-	} else if self.StorageType == api.STORAGE_LVM {
+	} else if utils.IsInStringArray(self.StorageType, []string{api.STORAGE_LVM, api.STORAGE_SLVM}) {
 		return mountPoint
 	} else {
 		return imageCachePath
@@ -609,7 +651,19 @@ func (manager *SStorageManager) FetchCustomizeColumns(
 		})
 	}
 
+	classIds := make([]string, 0)
+	for i := range objs {
+		if classId := objs[i].(*SStorage).DiskPerformanceClassId; len(classId) > 0 {
+			classIds = append(classIds, classId)
+		}
+	}
+	classNames, err := db.FetchIdNameMap2(DiskPerformanceClassManager, classIds)
+	if err != nil {
+		log.Errorf("FetchIdNameMap2 of disk performance classes: %v", err)
+	}
+
 	for i := range rows {
+		rows[i].DiskPerformanceClass = classNames[objs[i].(*SStorage).DiskPerformanceClassId]
 		rows[i].Hosts, _ = hoststorages[storageIds[i]]
 		tags, ok := tagMap[storageIds[i]]
 		if ok {
@@ -627,6 +681,9 @@ func (manager *SStorageManager) FetchCustomizeColumns(
 			Wasted:     rows[i].Wasted,
 		}
 		rows[i].SStorageCapacityInfo = capa.toCapacityInfo()
+		forecast := objs[i].(*SStorage).getCapacityForecast(cnt)
+		rows[i].DaysUntilFull = forecast.DaysUntilFull
+		rows[i].CommitDaysUntilFull = forecast.CommitDaysUntilFull
 	}
 	return rows
 }
@@ -27,6 +27,7 @@ import (
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/httperrors"
 	"yunion.io/x/onecloud/pkg/mcclient"
@@ -63,6 +64,9 @@ type SVpcPeeringConnection struct {
 	PeerVpcId        string `width:"36" charset:"ascii" nullable:"true" list:"domain" create:"required" json:"peer_vpc_id"`
 	PeerAccountId    string `width:"36" charset:"ascii" nullable:"true" list:"domain"`
 	Bandwidth        int    `nullable:"false" default:"0" list:"user" create:"optional"`
+
+	// 本地VPC对等连接的中转子网序号
+	TransitIndex int `nullable:"false" default:"0" list:"domain"`
 }
 
 func (manager *SVpcPeeringConnectionManager) GetContextManagers() [][]db.IModelManager {
@@ -143,6 +147,9 @@ func (manager *SVpcPeeringConnectionManager) ValidateCreateData(
 	}
 	peerVpc := _peerVpc.(*SVpc)
 
+	if len(vpc.ManagerId) == 0 && len(peerVpc.ManagerId) == 0 {
+		return manager.validateOnPremiseCreateData(input, vpc, peerVpc)
+	}
 	if len(vpc.ManagerId) == 0 || len(peerVpc.ManagerId) == 0 {
 		return input, httperrors.NewInputParameterError("Only public cloud support vpcpeering")
 	}
@@ -218,6 +225,87 @@ func (manager *SVpcPeeringConnectionManager) ValidateCreateData(
 	return input, nil
 }
 
+// validateOnPremiseCreateData validates peering connection between on-premise
+// vpcs.  The connection is realized by vpcagent with routes between the two
+// vpc routers, so vpc networks must not overlap
+func (manager *SVpcPeeringConnectionManager) validateOnPremiseCreateData(
+	input api.VpcPeeringConnectionCreateInput,
+	vpc, peerVpc *SVpc,
+) (api.VpcPeeringConnectionCreateInput, error) {
+	if vpc.Id == peerVpc.Id {
+		return input, httperrors.NewInputParameterError("cannot peer vpc %s with itself", vpc.Name)
+	}
+	if vpc.Id == api.DEFAULT_VPC_ID || peerVpc.Id == api.DEFAULT_VPC_ID {
+		return input, httperrors.NewInputParameterError("default vpc cannot be peered")
+	}
+	if vpc.CloudregionId != peerVpc.CloudregionId {
+		return input, httperrors.NewNotSupportedError("on-premise vpc peering across regions is not supported")
+	}
+	if input.Bandwidth > 0 {
+		return input, httperrors.NewNotSupportedError("bandwidth of on-premise vpc peering is not supported")
+	}
+
+	q := manager.Query()
+	q = q.Filter(sqlchemy.OR(
+		sqlchemy.AND(
+			sqlchemy.Equals(q.Field("vpc_id"), vpc.Id),
+			sqlchemy.Equals(q.Field("peer_vpc_id"), peerVpc.Id),
+		),
+		sqlchemy.AND(
+			sqlchemy.Equals(q.Field("vpc_id"), peerVpc.Id),
+			sqlchemy.Equals(q.Field("peer_vpc_id"), vpc.Id),
+		),
+	))
+	cnt, err := q.CountWithError()
+	if err != nil {
+		return input, httperrors.NewGeneralError(err)
+	}
+	if cnt > 0 {
+		return input, httperrors.NewNotSupportedError("vpc %s and vpc %s have already connected", input.VpcId, input.PeerVpcId)
+	}
+
+	if err := validatePeerVpcNetworksOverlap(vpc, peerVpc); err != nil {
+		return input, err
+	}
+	if err := validatePeerVpcNetworksOverlap(peerVpc, vpc); err != nil {
+		return input, err
+	}
+	return input, nil
+}
+
+// validatePeerVpcNetworksOverlap checks networks of vpc against networks of
+// peerVpc and vpcs already peered with it, router of peerVpc routes to all
+// of them after peering
+func validatePeerVpcNetworksOverlap(vpc, peerVpc *SVpc) error {
+	nets, err := vpc.GetNetworks()
+	if err != nil {
+		return httperrors.NewGeneralError(errors.Wrapf(err, "GetNetworks of vpc %s", vpc.Id))
+	}
+	peerPeerVpcs, err := peerVpc.getPeerVpcs()
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	for _, target := range append([]*SVpc{peerVpc}, peerPeerVpcs...) {
+		if target.Id == vpc.Id {
+			continue
+		}
+		targetNets, err := target.GetNetworks()
+		if err != nil {
+			return httperrors.NewGeneralError(errors.Wrapf(err, "GetNetworks of vpc %s", target.Id))
+		}
+		for i := range nets {
+			if !isOverlapNetworkSubnets(targetNets, nets[i].getSubnetRange()) {
+				continue
+			}
+			if target.Id == peerVpc.Id {
+				return httperrors.NewNotSupportedError("network %s of vpc %s overlaps with peer vpc %s", nets[i].Name, vpc.Name, peerVpc.Name)
+			}
+			return httperrors.NewNotSupportedError("network %s of vpc %s overlaps with vpc %s peered with vpc %s", nets[i].Name, vpc.Name, target.Name, peerVpc.Name)
+		}
+	}
+	return nil
+}
+
 func (self *SVpcPeeringConnection) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, ownerId mcclient.IIdentityProvider, query jsonutils.JSONObject, data jsonutils.JSONObject) {
 	params := jsonutils.NewDict()
 	task, err := taskman.TaskManager.NewTask(ctx, "VpcPeeringConnectionCreateTask", self, userCred, params, "", "", nil)
@@ -401,3 +489,47 @@ func (self *SVpcPeeringConnection) GetPeerVpc() (*SVpc, error) {
 	}
 	return vpc.(*SVpc), nil
 }
+
+// GetPeerVpcIdOf returns id of the vpc at the other end of the connection
+func (self *SVpcPeeringConnection) GetPeerVpcIdOf(vpcId string) string {
+	if vpcId == self.VpcId {
+		return self.PeerVpcId
+	}
+	return self.VpcId
+}
+
+// AllocateTransitIndex allocates transit subnet for on-premise peering
+// connection
+func (self *SVpcPeeringConnection) AllocateTransitIndex(ctx context.Context, userCred mcclient.TokenCredential) error {
+	if self.TransitIndex != api.VpcPeerTransitIndexNone {
+		return nil
+	}
+	lockman.LockClass(ctx, VpcPeeringConnectionManager, "")
+	defer lockman.ReleaseClass(ctx, VpcPeeringConnectionManager, "")
+
+	q := VpcPeeringConnectionManager.Query("transit_index").GT("transit_index", api.VpcPeerTransitIndexNone)
+	rows, err := q.Rows()
+	if err != nil {
+		return errors.Wrap(err, "query transit_index")
+	}
+	defer rows.Close()
+	used := map[int]bool{}
+	for rows.Next() {
+		var index int
+		if err := rows.Scan(&index); err != nil {
+			return errors.Wrap(err, "scan transit_index")
+		}
+		used[index] = true
+	}
+	for index := 1; index <= api.VpcPeerTransitIndexMax; index++ {
+		if used[index] {
+			continue
+		}
+		_, err := db.Update(self, func() error {
+			self.TransitIndex = index
+			return nil
+		})
+		return err
+	}
+	return httperrors.NewOutOfResourceError("no transit subnet available for vpc peering")
+}
@@ -202,6 +202,65 @@ func (svpc *SVpc) GetVpcPeeringConnectionCount() (int, error) {
 	return requesterPeerCount + accepterPeerCount, nil
 }
 
+// getPeerVpcs returns vpcs peered with the vpc
+func (svpc *SVpc) getPeerVpcs() ([]*SVpc, error) {
+	requesters, err := svpc.GetRequesterVpcPeeringConnections()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRequesterVpcPeeringConnections")
+	}
+	accepters, err := svpc.GetAccepterVpcPeeringConnections()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAccepterVpcPeeringConnections")
+	}
+	var peerVpcs []*SVpc
+	for _, peering := range append(requesters, accepters...) {
+		peerVpcObj, err := VpcManager.FetchById(peering.GetPeerVpcIdOf(svpc.Id))
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetch peer vpc of %s", peering.Id)
+		}
+		peerVpcs = append(peerVpcs, peerVpcObj.(*SVpc))
+	}
+	return peerVpcs, nil
+}
+
+// getOverlapPeerVpc returns the on-premise vpc with networks overlapping with
+// the subnet, which is either peered with the vpc, or peered with a peer of
+// the vpc, whose router routes to networks of both
+func (svpc *SVpc) getOverlapPeerVpc(subnet netutils.IPV4AddrRange) (*SVpc, error) {
+	peerVpcs, err := svpc.getPeerVpcs()
+	if err != nil {
+		return nil, err
+	}
+	for _, peerVpc := range peerVpcs {
+		nets, err := peerVpc.GetNetworks()
+		if err != nil {
+			return nil, errors.Wrapf(err, "GetNetworks of vpc %s", peerVpc.Id)
+		}
+		if isOverlapNetworkSubnets(nets, subnet) {
+			return peerVpc, nil
+		}
+	}
+	for _, peerVpc := range peerVpcs {
+		peerPeerVpcs, err := peerVpc.getPeerVpcs()
+		if err != nil {
+			return nil, err
+		}
+		for _, peerPeerVpc := range peerPeerVpcs {
+			if peerPeerVpc.Id == svpc.Id {
+				continue
+			}
+			nets, err := peerPeerVpc.GetNetworks()
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetNetworks of vpc %s", peerPeerVpc.Id)
+			}
+			if isOverlapNetworkSubnets(nets, subnet) {
+				return peerPeerVpc, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
 func (svpc *SVpc) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, query jsonutils.JSONObject, input api.VpcUpdateInput) (api.VpcUpdateInput, error) {
 	if input.ExternalAccessMode != "" {
 		if !utils.IsInStringArray(input.ExternalAccessMode, api.VPC_EXTERNAL_ACCESS_MODES) {
@@ -238,6 +297,16 @@ func (svpc *SVpc) ValidateDeleteCondition(ctx context.Context, info *api.VpcDeta
 	if info.RequestVpcPeerCount > 0 {
 		return httperrors.NewNotEmptyError("VPC not empty, please delete vpc peering first")
 	}
+	if len(svpc.ManagerId) == 0 {
+		// on-premise peering connections are realized on both vpcs
+		cnt, err := svpc.getAccepterVpcPeeringConnectionQuery().CountWithError()
+		if err != nil {
+			return errors.Wrap(err, "count accepter vpc peering connections")
+		}
+		if cnt > 0 {
+			return httperrors.NewNotEmptyError("VPC not empty, please delete vpc peering first")
+		}
+	}
 
 	return svpc.SEnabledStatusInfrasResourceBase.ValidateDeleteCondition(ctx, nil)
 }
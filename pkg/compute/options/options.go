@@ -103,6 +103,9 @@ type ComputeOptions struct {
 	DefaultMaxSnapshotCount       int `default:"9" help:"Per Disk max snapshot count, default 9"`
 	DefaultMaxManualSnapshotCount int `default:"2" help:"Per Disk max manual snapshot count, default 2"`
 
+	// disk backup options
+	DiskBackupMaxIncrementalCount int `default:"30" help:"Max incremental backups based on a full disk backup, default 30"`
+
 	//snapshot policy options
 	RetentionDaysLimit  int `default:"49" help:"Days of snapshot retention, default 49 days"`
 	TimePointsLimit     int `default:"1" help:"time point of every days, default 1 point"`
@@ -160,6 +163,8 @@ type ComputeOptions struct {
 	EnableAutoRenameProject bool `help:"when it set true, auto create project will rename when cloud project name changed" default:"false"`
 
 	SyncStorageCapacityUsedIntervalMinutes int  `help:"interval sync storage capacity used" default:"20"`
+	StorageForecastIntervalMinutes         int  `help:"interval to sample storage capacity usage and forecast storage exhaustion" default:"60"`
+	StorageForecastHistoryDays             int  `help:"days of storage capacity samples used to fit the growth trend" default:"14"`
 	LockStorageFromCachedimage             bool `help:"must use storage in where selected cachedimage when creating vm"`
 
 	SyncExtDiskSnapshotIntervalMinutes int  `help:"sync snapshot for external disk" default:"20"`
@@ -198,6 +203,17 @@ type ComputeOptions struct {
 
 	EnableTlsMigration bool `help:"Enable TLS migration" default:"false"`
 
+	EnableAutoRebalance              bool    `help:"Periodically live migrate guests off overloaded hosts of each zone" default:"false"`
+	AutoRebalanceIntervalMinutes     int     `help:"Interval to rebalance hosts of each zone" default:"30"`
+	RebalanceThreshold               float64 `help:"Percent the most loaded host exceeds the average load that is tolerated" default:"20"`
+	RebalanceMaxMigrations           int     `help:"Max guests migrated in one rebalance" default:"10"`
+	RebalanceMaxConcurrentMigrations int     `help:"Max concurrent live migrations when rebalancing" default:"2"`
+	RebalanceMigrateTimeoutMinutes   int     `help:"Timeout to wait a rebalance live migration finished" default:"60"`
+	RebalanceMetricsTimeRange        string  `help:"Time range used to calculate p95 of host utilization when rebalancing" default:"15m"`
+	RebalanceMetricsStaleMinutes     int     `help:"Host utilization metrics older than this fallback to commit rate when rebalancing" default:"5"`
+
+	MaintenanceCampaignCheckIntervalSeconds int `help:"Interval to check drained hosts health and start next batch of running maintenance campaigns" default:"60"`
+
 	AliyunResourceGroups []string `help:"Only sync indicate resource group resource"`
 
 	KvmMonitorAgentUseMetadataService bool   `help:"Monitor agent report metrics to metadata service on host" default:"true"`
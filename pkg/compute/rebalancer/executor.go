@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalancer
+
+import (
+	"context"
+	"sync"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+// IMigrator live migrates guest to target host and waits until it finished
+type IMigrator interface {
+	LiveMigrate(ctx context.Context, guestId string, targetHostId string) error
+}
+
+// Execute runs migrations of plan in batches of maxConcurrent,
+// if any migration of a batch failed, the finished migrations are migrated back to source hosts
+func Execute(ctx context.Context, plan *api.RebalancePlan, migrator IMigrator, maxConcurrent int) error {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	done := make([]int, 0, len(plan.Migrations))
+	for start := 0; start < len(plan.Migrations); start += maxConcurrent {
+		end := start + maxConcurrent
+		if end > len(plan.Migrations) {
+			end = len(plan.Migrations)
+		}
+		batch := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, i)
+		}
+		failed := runBatch(ctx, plan, batch, func(m *api.RebalanceMigration) error {
+			return migrator.LiveMigrate(ctx, m.GuestId, m.TargetHostId)
+		}, api.REBALANCE_MIGRATION_MIGRATING, api.REBALANCE_MIGRATION_DONE, api.REBALANCE_MIGRATION_FAILED)
+		for _, i := range batch {
+			if plan.Migrations[i].Status == api.REBALANCE_MIGRATION_DONE {
+				done = append(done, i)
+			}
+		}
+		if failed > 0 {
+			rollback(ctx, plan, done, migrator, maxConcurrent)
+			return errors.Errorf("%d migrations failed, rolled back %d finished migrations", failed, len(done))
+		}
+	}
+	return nil
+}
+
+func rollback(ctx context.Context, plan *api.RebalancePlan, done []int, migrator IMigrator, maxConcurrent int) {
+	for start := 0; start < len(done); start += maxConcurrent {
+		end := start + maxConcurrent
+		if end > len(done) {
+			end = len(done)
+		}
+		runBatch(ctx, plan, done[start:end], func(m *api.RebalanceMigration) error {
+			return migrator.LiveMigrate(ctx, m.GuestId, m.SourceHostId)
+		}, api.REBALANCE_MIGRATION_DONE, api.REBALANCE_MIGRATION_ROLLED_BACK, api.REBALANCE_MIGRATION_ROLLBACK_FAILED)
+	}
+}
+
+// runBatch runs migrate of migrations concurrently and returns failed count
+func runBatch(ctx context.Context, plan *api.RebalancePlan, batch []int, migrate func(m *api.RebalanceMigration) error, runningStatus, okStatus, failStatus string) int {
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		failed int
+	)
+	for _, i := range batch {
+		m := &plan.Migrations[i]
+		m.Status = runningStatus
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := migrate(m)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				log.Errorf("rebalance migrate guest %s(%s -> %s) %s: %v", m.Guest, m.SourceHost, m.TargetHost, failStatus, err)
+				m.Status = failStatus
+				m.Reason = err.Error()
+				failed++
+				return
+			}
+			m.Status = okStatus
+		}()
+	}
+	wg.Wait()
+	return failed
+}
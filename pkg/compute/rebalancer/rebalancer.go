@@ -0,0 +1,335 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rebalancer
+
+import (
+	"context"
+	"sort"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+// SInstanceGroup is the anti-affinity constraint of guests
+type SInstanceGroup struct {
+	Id string
+	// the upper limit number of guests with this group in a host
+	Granularity     int
+	ForceDispersion bool
+}
+
+type SGuest struct {
+	Id         string
+	Name       string
+	VcpuCount  int64
+	VmemSizeMb int64
+	// only running guests without backup can be live migrated
+	Movable bool
+
+	// actual cpu cores and memory in use, estimated by host usage
+	CpuUsage   float64
+	MemUsageMb float64
+
+	InstanceGroups []SInstanceGroup
+}
+
+type SHost struct {
+	Id   string
+	Name string
+	// overcommitted cpu count and memory size, committed by guests
+	CpuCount  int64
+	MemSizeMb int64
+	// physical cpu count and memory size, used by guests actually
+	PhysicalCpuCount  int64
+	PhysicalMemSizeMb int64
+
+	// usage of host not taken by any guest
+	cpuUsage   float64
+	memUsageMb float64
+
+	Guests []*SGuest
+}
+
+func (h *SHost) committedCpu() int64 {
+	used := int64(0)
+	for _, g := range h.Guests {
+		used += g.VcpuCount
+	}
+	return used
+}
+
+func (h *SHost) committedMem() int64 {
+	used := int64(0)
+	for _, g := range h.Guests {
+		used += g.VmemSizeMb
+	}
+	return used
+}
+
+// SetUsage shares actual cpu and memory usage percent of host among guests
+// in proportion to their vcpu count and memory size, host keeps the usage
+// itself if there isn't any guest
+func (h *SHost) SetUsage(cpuPercent, memPercent float64) {
+	cpuUsage := cpuPercent / 100 * float64(h.PhysicalCpuCount)
+	memUsage := memPercent / 100 * float64(h.PhysicalMemSizeMb)
+	committedCpu, committedMem := h.committedCpu(), h.committedMem()
+	h.cpuUsage, h.memUsageMb = 0, 0
+	if committedCpu > 0 {
+		for _, g := range h.Guests {
+			g.CpuUsage = cpuUsage * float64(g.VcpuCount) / float64(committedCpu)
+		}
+	} else {
+		h.cpuUsage = cpuUsage
+	}
+	if committedMem > 0 {
+		for _, g := range h.Guests {
+			g.MemUsageMb = memUsage * float64(g.VmemSizeMb) / float64(committedMem)
+		}
+	} else {
+		h.memUsageMb = memUsage
+	}
+}
+
+// SetCommitUsage takes commit rate as actual usage when host metrics is
+// unavailable
+func (h *SHost) SetCommitUsage() {
+	var cpuPercent, memPercent float64
+	if h.CpuCount > 0 {
+		cpuPercent = float64(h.committedCpu()) / float64(h.CpuCount) * 100
+	}
+	if h.MemSizeMb > 0 {
+		memPercent = float64(h.committedMem()) / float64(h.MemSizeMb) * 100
+	}
+	h.SetUsage(cpuPercent, memPercent)
+}
+
+func (h *SHost) usedCpu() float64 {
+	used := h.cpuUsage
+	for _, g := range h.Guests {
+		used += g.CpuUsage
+	}
+	return used
+}
+
+func (h *SHost) usedMem() float64 {
+	used := h.memUsageMb
+	for _, g := range h.Guests {
+		used += g.MemUsageMb
+	}
+	return used
+}
+
+func (h *SHost) load() float64 {
+	return hostLoad(float64(h.PhysicalCpuCount), float64(h.PhysicalMemSizeMb), h.usedCpu(), h.usedMem())
+}
+
+func hostLoad(cpuCount, memSizeMb, usedCpu, usedMem float64) float64 {
+	var cpuRate, memRate float64
+	if cpuCount > 0 {
+		cpuRate = usedCpu / cpuCount
+	}
+	if memSizeMb > 0 {
+		memRate = usedMem / memSizeMb
+	}
+	if cpuRate > memRate {
+		return cpuRate
+	}
+	return memRate
+}
+
+func (h *SHost) loadWith(g *SGuest) float64 {
+	return hostLoad(float64(h.PhysicalCpuCount), float64(h.PhysicalMemSizeMb), h.usedCpu()+g.CpuUsage, h.usedMem()+g.MemUsageMb)
+}
+
+func (h *SHost) loadWithout(g *SGuest) float64 {
+	return hostLoad(float64(h.PhysicalCpuCount), float64(h.PhysicalMemSizeMb), h.usedCpu()-g.CpuUsage, h.usedMem()-g.MemUsageMb)
+}
+
+func (h *SHost) committedLoadWith(g *SGuest) float64 {
+	return hostLoad(float64(h.CpuCount), float64(h.MemSizeMb), float64(h.committedCpu()+g.VcpuCount), float64(h.committedMem()+g.VmemSizeMb))
+}
+
+func (h *SHost) removeGuest(g *SGuest) {
+	for i := range h.Guests {
+		if h.Guests[i] == g {
+			h.Guests = append(h.Guests[:i], h.Guests[i+1:]...)
+			return
+		}
+	}
+}
+
+func (h *SHost) groupGuestCount(groupId string) int {
+	count := 0
+	for _, other := range h.Guests {
+		for _, og := range other.InstanceGroups {
+			if og.Id == groupId {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// isGroupsFit checks groups of force dispersion, which can't be violated
+func (h *SHost) isGroupsFit(g *SGuest) bool {
+	for _, group := range g.InstanceGroups {
+		if group.ForceDispersion && h.groupGuestCount(group.Id) >= group.Granularity {
+			return false
+		}
+	}
+	return true
+}
+
+// groupsPenalty counts groups not of force dispersion violated by moving
+// guest to host, hosts with less penalty are preferred
+func (h *SHost) groupsPenalty(g *SGuest) int {
+	penalty := 0
+	for _, group := range g.InstanceGroups {
+		if !group.ForceDispersion && h.groupGuestCount(group.Id) >= group.Granularity {
+			penalty++
+		}
+	}
+	return penalty
+}
+
+type IScheduler interface {
+	CanMigrate(ctx context.Context, guestId string, targetHostId string) (bool, error)
+}
+
+type SPlanOptions struct {
+	// percent the most loaded host exceeds the average load that is tolerated
+	Threshold float64
+	// max guests to migrate in one plan, 0 means no limit
+	MaxMigrations int
+}
+
+// imbalance is how many percent the most loaded host exceeds the average load
+func imbalance(hosts []*SHost) float64 {
+	if len(hosts) == 0 {
+		return 0
+	}
+	var sum, max float64
+	for _, h := range hosts {
+		load := h.load()
+		sum += load
+		if load > max {
+			max = load
+		}
+	}
+	return (max - sum/float64(len(hosts))) * 100
+}
+
+// Plan greedily moves guests from the most loaded host to less loaded hosts
+// until the imbalance is under threshold or no move can lower it.
+// Hosts are modified to reflect the planned placement.
+func Plan(ctx context.Context, hosts []*SHost, scheduler IScheduler, opts SPlanOptions) (*api.RebalancePlan, error) {
+	plan := &api.RebalancePlan{
+		ImbalanceBefore: imbalance(hosts),
+		Migrations:      []api.RebalanceMigration{},
+	}
+	moved := make(map[string]bool)
+	for {
+		if opts.MaxMigrations > 0 && len(plan.Migrations) >= opts.MaxMigrations {
+			break
+		}
+		if imbalance(hosts) <= opts.Threshold {
+			break
+		}
+		src := hottestHost(hosts)
+		migration, err := findMigration(ctx, src, hosts, scheduler, moved)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find migration of host %s", src.Name)
+		}
+		if migration == nil {
+			log.Infof("no guest of host %s can be migrated to lower imbalance", src.Name)
+			break
+		}
+		plan.Migrations = append(plan.Migrations, *migration)
+	}
+	plan.ImbalanceAfter = imbalance(hosts)
+	return plan, nil
+}
+
+func hottestHost(hosts []*SHost) *SHost {
+	var hottest *SHost
+	for _, h := range hosts {
+		if hottest == nil || h.load() > hottest.load() {
+			hottest = h
+		}
+	}
+	return hottest
+}
+
+func findMigration(ctx context.Context, src *SHost, hosts []*SHost, scheduler IScheduler, moved map[string]bool) (*api.RebalanceMigration, error) {
+	guests := make([]*SGuest, 0, len(src.Guests))
+	for _, g := range src.Guests {
+		if g.Movable && !moved[g.Id] {
+			guests = append(guests, g)
+		}
+	}
+	// moving bigger guest first to get balanced with less migrations
+	sort.SliceStable(guests, func(i, j int) bool {
+		return src.loadWithout(guests[i]) < src.loadWithout(guests[j])
+	})
+	targets := make([]*SHost, 0, len(hosts))
+	for _, h := range hosts {
+		if h != src {
+			targets = append(targets, h)
+		}
+	}
+
+	srcLoad := src.load()
+	for _, g := range guests {
+		// hosts breaking less anti-affinity groups first, then less loaded
+		sort.SliceStable(targets, func(i, j int) bool {
+			pi, pj := targets[i].groupsPenalty(g), targets[j].groupsPenalty(g)
+			if pi != pj {
+				return pi < pj
+			}
+			return targets[i].load() < targets[j].load()
+		})
+		for _, target := range targets {
+			// the move must lower the load peak of the two hosts
+			if newLoad := target.loadWith(g); newLoad >= srcLoad || newLoad > 1 {
+				continue
+			}
+			if target.committedLoadWith(g) > 1 || !target.isGroupsFit(g) {
+				continue
+			}
+			ok, err := scheduler.CanMigrate(ctx, g.Id, target.Id)
+			if err != nil {
+				return nil, errors.Wrapf(err, "check migrate guest %s to host %s", g.Name, target.Name)
+			}
+			if !ok {
+				continue
+			}
+			src.removeGuest(g)
+			target.Guests = append(target.Guests, g)
+			moved[g.Id] = true
+			return &api.RebalanceMigration{
+				GuestId:      g.Id,
+				Guest:        g.Name,
+				SourceHostId: src.Id,
+				SourceHost:   src.Name,
+				TargetHostId: target.Id,
+				TargetHost:   target.Name,
+				Status:       api.REBALANCE_MIGRATION_PENDING,
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -458,6 +458,10 @@ func (self *SBaseRegionDriver) RequestDeleteBackup(ctx context.Context, backup *
 	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestDeleteBackup")
 }
 
+func (self *SBaseRegionDriver) RequestVerifyBackup(ctx context.Context, backup *models.SDiskBackup, task taskman.ITask) error {
+	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestVerifyBackup")
+}
+
 func (self *SBaseRegionDriver) RequestCreateInstanceBackup(ctx context.Context, guest *models.SGuest, ib *models.SInstanceBackup, task taskman.ITask, params *jsonutils.JSONDict) error {
 	return errors.Wrapf(cloudprovider.ErrNotImplemented, "RequestCreateInstanceBackup")
 }
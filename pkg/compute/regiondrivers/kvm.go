@@ -806,7 +806,11 @@ func (self *SKVMRegionDriver) RequestCreateInstanceSnapshot(ctx context.Context,
 	params.Set(strconv.Itoa(diskIndex), jsonutils.NewString(snapshot.Id))
 	task.SetStage("OnKvmDiskSnapshot", params)
 
-	if err := snapshot.StartSnapshotCreateTask(ctx, task.GetUserCred(), nil, task.GetTaskId()); err != nil {
+	snapshotParams := jsonutils.NewDict()
+	if jsonutils.QueryBoolean(task.GetParams(), "consistent", false) {
+		snapshotParams.Set("consistent", jsonutils.JSONTrue)
+	}
+	if err := snapshot.StartSnapshotCreateTask(ctx, task.GetUserCred(), snapshotParams, task.GetTaskId()); err != nil {
 		return err
 	}
 	return nil
@@ -961,7 +965,15 @@ func (self *SKVMRegionDriver) RequestCreateInstanceBackup(ctx context.Context, g
 			return err
 		}
 		taskParams := jsonutils.NewDict()
-		taskParams.Set("only_snapshot", jsonutils.JSONTrue)
+		if jsonutils.QueryBoolean(task.GetParams(), "consistent", false) {
+			taskParams.Set("consistent", jsonutils.JSONTrue)
+			taskParams.Set("only_snapshot", jsonutils.JSONTrue)
+		} else if jsonutils.QueryBoolean(task.GetParams(), "incremental", false) && backup.CanLiveBackup() {
+			// saved by live backup directly, no snapshot returned
+			taskParams.Set("incremental", jsonutils.JSONTrue)
+		} else {
+			taskParams.Set("only_snapshot", jsonutils.JSONTrue)
+		}
 		if err := backup.StartBackupCreateTask(ctx, task.GetUserCred(), taskParams, task.GetTaskId()); err != nil {
 			return err
 		}
@@ -1001,7 +1013,11 @@ func (self *SKVMRegionDriver) RequestPackInstanceBackup(ctx context.Context, ib
 	body := jsonutils.NewDict()
 	body.Set("package_name", jsonutils.NewString(packageName))
 	body.Set("backup_storage_id", jsonutils.NewString(backupStorage.GetId()))
-	body.Set("backup_storage_access_info", jsonutils.Marshal(backupStorage.AccessInfo))
+	accessInfo, err := backupStorage.GetAccessInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetAccessInfo")
+	}
+	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 	body.Set("backup_ids", jsonutils.Marshal(backupIds))
 	body.Set("metadata", jsonutils.Marshal(metadata))
 	header := task.GetTaskRequestHeader()
@@ -1027,7 +1043,11 @@ func (self *SKVMRegionDriver) RequestUnpackInstanceBackup(ctx context.Context, i
 	body := jsonutils.NewDict()
 	body.Set("package_name", jsonutils.NewString(packageName))
 	body.Set("backup_storage_id", jsonutils.NewString(backupStorage.GetId()))
-	body.Set("backup_storage_access_info", jsonutils.Marshal(backupStorage.AccessInfo))
+	accessInfo, err := backupStorage.GetAccessInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetAccessInfo")
+	}
+	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 	if metadataOnly {
 		body.Set("metadata_only", jsonutils.JSONTrue)
 	}
@@ -1048,7 +1068,11 @@ func (self *SKVMRegionDriver) RequestSyncBackupStorageStatus(ctx context.Context
 		url := fmt.Sprintf("%s/storages/sync-backup-storage", host.ManagerUri)
 		body := jsonutils.NewDict()
 		body.Set("backup_storage_id", jsonutils.NewString(bs.GetId()))
-		body.Set("backup_storage_access_info", jsonutils.Marshal(bs.AccessInfo))
+		accessInfo, err := bs.GetAccessInfo()
+		if err != nil {
+			return nil, errors.Wrap(err, "GetAccessInfo")
+		}
+		body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 		header := task.GetTaskRequestHeader()
 		_, res, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
 		if err != nil {
@@ -1125,7 +1149,11 @@ func (self *SKVMRegionDriver) RequestSyncDiskBackupStatus(ctx context.Context, u
 		body := jsonutils.NewDict()
 		body.Set("backup_id", jsonutils.NewString(backup.GetId()))
 		body.Set("backup_storage_id", jsonutils.NewString(backupStroage.GetId()))
-		body.Set("backup_storage_access_info", jsonutils.Marshal(backupStroage.AccessInfo))
+		accessInfo, err := backupStroage.GetAccessInfo()
+		if err != nil {
+			return nil, errors.Wrap(err, "GetAccessInfo")
+		}
+		body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 		header := task.GetTaskRequestHeader()
 		_, res, err := httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
 		if err != nil {
@@ -1347,7 +1375,11 @@ func (self *SKVMRegionDriver) RequestDeleteBackup(ctx context.Context, backup *m
 	body := jsonutils.NewDict()
 	body.Set("backup_id", jsonutils.NewString(backup.GetId()))
 	body.Set("backup_storage_id", jsonutils.NewString(backupStroage.GetId()))
-	body.Set("backup_storage_access_info", jsonutils.Marshal(backupStroage.AccessInfo))
+	accessInfo, err := backupStroage.GetAccessInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetAccessInfo")
+	}
+	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 	header := task.GetTaskRequestHeader()
 	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
 	if err != nil {
@@ -1356,6 +1388,39 @@ func (self *SKVMRegionDriver) RequestDeleteBackup(ctx context.Context, backup *m
 	return nil
 }
 
+func (self *SKVMRegionDriver) RequestVerifyBackup(ctx context.Context, backup *models.SDiskBackup, task taskman.ITask) error {
+	backupStroage, err := backup.GetBackupStorage()
+	if err != nil {
+		return errors.Wrap(err, "unable to get backupStorage")
+	}
+	storage, _ := backup.GetStorage()
+	var host *models.SHost
+	if storage != nil {
+		host, _ = storage.GetMasterHost()
+	}
+	if host == nil {
+		host, err = models.HostManager.GetEnabledKvmHost()
+		if err != nil {
+			return errors.Wrap(err, "unable to GetEnabledKvmHost")
+		}
+	}
+	url := fmt.Sprintf("%s/storages/verify-backup", host.ManagerUri)
+	body := jsonutils.NewDict()
+	body.Set("backup_id", jsonutils.NewString(backup.GetId()))
+	body.Set("backup_storage_id", jsonutils.NewString(backupStroage.GetId()))
+	accessInfo, err := backupStroage.GetAccessInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetAccessInfo")
+	}
+	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
+	header := task.GetTaskRequestHeader()
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, body, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to verify backup")
+	}
+	return nil
+}
+
 func (self *SKVMRegionDriver) RequestCreateBackup(ctx context.Context, backup *models.SDiskBackup, snapshotId string, task taskman.ITask) error {
 	backupStroage, err := backup.GetBackupStorage()
 	if err != nil {
@@ -1376,10 +1441,23 @@ func (self *SKVMRegionDriver) RequestCreateBackup(ctx context.Context, backup *m
 	host, _ := guest.GetHost()
 	url := fmt.Sprintf("%s/disks/%s/backup/%s", host.ManagerUri, storage.Id, disk.Id)
 	body := jsonutils.NewDict()
-	body.Set("snapshot_id", jsonutils.NewString(snapshotId))
+	if len(snapshotId) == 0 {
+		// backup disk of running guest by qemu, incremental since parent backup
+		url = fmt.Sprintf("%s/servers/%s/disk-backup", host.ManagerUri, guest.Id)
+		body.Set("disk_id", jsonutils.NewString(disk.Id))
+		if parentId, _ := task.GetParams().GetString("parent_backup_id"); len(parentId) > 0 {
+			body.Set("parent_backup_id", jsonutils.NewString(parentId))
+		}
+	} else {
+		body.Set("snapshot_id", jsonutils.NewString(snapshotId))
+	}
 	body.Set("backup_id", jsonutils.NewString(backup.GetId()))
 	body.Set("backup_storage_id", jsonutils.NewString(backupStroage.GetId()))
-	body.Set("backup_storage_access_info", jsonutils.Marshal(backupStroage.AccessInfo))
+	accessInfo, err := backupStroage.GetAccessInfo()
+	if err != nil {
+		return errors.Wrap(err, "GetAccessInfo")
+	}
+	body.Set("backup_storage_access_info", jsonutils.Marshal(accessInfo))
 	if len(backup.EncryptKeyId) > 0 {
 		body.Set("encrypt_key_id", jsonutils.NewString(backup.EncryptKeyId))
 	}
@@ -96,6 +96,9 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.WafRuleStatementManager,
 		models.BillingResourceCheckManager,
+
+		models.BackupPolicyResourceManager,
+		models.DrProtectionGroupMemberManager,
 	} {
 		db.RegisterModelManager(manager)
 	}
@@ -114,6 +117,7 @@ func InitHandlers(app *appsrv.Application) {
 		models.VpcManager,
 		models.WireManager,
 		models.StorageManager,
+		models.DiskPerformanceClassManager,
 		models.StoragecacheManager,
 		models.CachedimageManager,
 		models.HostManager,
@@ -158,6 +162,8 @@ func InitHandlers(app *appsrv.Application) {
 
 		models.SchedpolicyManager,
 		models.DynamicschedtagManager,
+		models.MaintenanceCampaignManager,
+		models.GuestFileShareManager,
 
 		models.ServerSkuManager,
 		models.ExternalProjectManager,
@@ -231,6 +237,9 @@ func InitHandlers(app *appsrv.Application) {
 		models.BackupStorageManager,
 		models.DiskBackupManager,
 		models.InstanceBackupManager,
+		models.BackupPolicyManager,
+		models.BackupPolicyRunManager,
+		models.DrProtectionGroupManager,
 
 		models.IPv6GatewayManager,
 		models.TablestoreManager,
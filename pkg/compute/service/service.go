@@ -159,6 +159,11 @@ func StartService() {
 			cron.AddJobAtIntervals("StartHostPingDetectionTask", time.Duration(opts.HostOfflineDetectionInterval)*time.Second, models.HostManager.PingDetectionTask)
 		}
 
+		if opts.EnableAutoRebalance {
+			cron.AddJobAtIntervals("AutoRebalanceZones", time.Duration(opts.AutoRebalanceIntervalMinutes)*time.Minute, models.ZoneManager.AutoRebalance)
+		}
+		cron.AddJobAtIntervals("CheckMaintenanceCampaigns", time.Duration(opts.MaintenanceCampaignCheckIntervalSeconds)*time.Second, models.MaintenanceCampaignManager.CheckHostsHealth)
+
 		cron.AddJobAtIntervalsWithStartRun("CalculateQuotaUsages", time.Duration(opts.CalculateQuotaUsageIntervalSeconds)*time.Second, models.QuotaManager.CalculateQuotaUsages, true)
 		cron.AddJobAtIntervalsWithStartRun("CalculateRegionQuotaUsages", time.Duration(opts.CalculateQuotaUsageIntervalSeconds)*time.Second, models.RegionQuotaManager.CalculateQuotaUsages, true)
 		cron.AddJobAtIntervalsWithStartRun("CalculateZoneQuotaUsages", time.Duration(opts.CalculateQuotaUsageIntervalSeconds)*time.Second, models.ZoneQuotaManager.CalculateQuotaUsages, true)
@@ -167,6 +172,7 @@ func StartService() {
 		cron.AddJobAtIntervalsWithStartRun("CalculateInfrasQuotaUsages", time.Duration(opts.CalculateQuotaUsageIntervalSeconds)*time.Second, models.InfrasQuotaManager.CalculateQuotaUsages, true)
 		cron.AddJobAtIntervalsWithStartRun("AutoSyncCloudaccountStatusTask", time.Duration(opts.CloudAutoSyncIntervalSeconds)*time.Second, models.CloudaccountManager.AutoSyncCloudaccountStatusTask, true)
 		cron.AddJobAtIntervalsWithStartRun("SyncCapacityUsedForEsxiStorage", time.Duration(opts.SyncStorageCapacityUsedIntervalMinutes)*time.Minute, models.StorageManager.SyncCapacityUsedForEsxiStorage, true)
+		cron.AddJobAtIntervalsWithStartRun("ForecastStorageCapacity", time.Duration(opts.StorageForecastIntervalMinutes)*time.Minute, models.StorageManager.ForecastStorageCapacity, true)
 
 		cron.AddJobAtIntervalsWithStartRun("AutoSyncExtDiskSnapshot", time.Duration(opts.SyncExtDiskSnapshotIntervalMinutes)*time.Minute, models.DiskManager.AutoSyncExtDiskSnapshot, true)
 
@@ -174,6 +180,9 @@ func StartService() {
 
 		cron.AddJobEveryFewHour("AutoDiskSnapshot", 1, 5, 0, models.DiskManager.AutoDiskSnapshot, false)
 		cron.AddJobEveryFewHour("SnapshotsCleanup", 1, 35, 0, models.SnapshotManager.CleanupSnapshots, false)
+		cron.AddJobEveryFewHour("AutoBackupPolicies", 1, 10, 0, models.BackupPolicyManager.AutoBackup, false)
+		cron.AddJobAtIntervals("SyncBackupPolicyRuns", 5*time.Minute, models.BackupPolicyRunManager.SyncRunningRuns)
+		cron.AddJobAtIntervals("AutoDrReplicate", 5*time.Minute, models.DrProtectionGroupManager.AutoReplicate)
 
 		cron.AddJobEveryFewHour("AutoCleanImageCache", 1, 5, 0, models.CachedimageManager.AutoCleanImageCaches, false)
 
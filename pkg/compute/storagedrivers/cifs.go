@@ -0,0 +1,163 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagedrivers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/compute/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+	"yunion.io/x/onecloud/pkg/mcclient"
+)
+
+type SCifsStorageDriver struct {
+	SBaseStorageDriver
+}
+
+func init() {
+	driver := SCifsStorageDriver{}
+	models.RegisterStorageDriver(&driver)
+}
+
+func (self *SCifsStorageDriver) GetStorageType() string {
+	return api.STORAGE_CIFS
+}
+
+// validateCifsCreateInput checks the share location and credentials, returns the normalized share name
+func validateCifsCreateInput(input *api.StorageCreateInput) (string, error) {
+	if len(input.CifsHost) == 0 {
+		return "", httperrors.NewMissingParameterError("cifs_host")
+	}
+	share := strings.Trim(input.CifsShare, "/\\ ")
+	if len(share) == 0 {
+		return "", httperrors.NewMissingParameterError("cifs_share")
+	}
+	if len(input.CifsUsername) == 0 {
+		return "", httperrors.NewMissingParameterError("cifs_username")
+	}
+	// credentials are written into mount.cifs credentials file line by line
+	for k, v := range map[string]string{
+		"cifs_username": input.CifsUsername,
+		"cifs_password": input.CifsPassword,
+		"cifs_domain":   input.CifsDomain,
+	} {
+		if strings.ContainsAny(v, "\r\n") {
+			return "", httperrors.NewInputParameterError("invalid %s, line break is not allowed", k)
+		}
+	}
+	if strings.ContainsAny(input.CifsUsername, "/\\@") {
+		return "", httperrors.NewInputParameterError("invalid cifs_username %s, specify domain by cifs_domain", input.CifsUsername)
+	}
+	return share, nil
+}
+
+// probeCifsShare mounts the share on an online host of the zone to verify the credentials
+func probeCifsShare(ctx context.Context, userCred mcclient.TokenCredential, zoneId string, conf jsonutils.JSONObject) error {
+	q := models.HostManager.Query().Equals("zone_id", zoneId).
+		Equals("host_type", api.HOST_TYPE_HYPERVISOR).
+		Equals("host_status", api.HOST_ONLINE).
+		IsTrue("enabled").Asc("id")
+	hosts := []models.SHost{}
+	err := db.FetchModelObjects(models.HostManager, q, &hosts)
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	if len(hosts) == 0 {
+		return httperrors.NewResourceNotReadyError("no online host in zone %s to probe cifs share", zoneId)
+	}
+	host := hosts[0]
+	url := fmt.Sprintf("%s/storages/probe-cifs", host.ManagerUri)
+	headers := mcclient.GetTokenHeaders(userCred)
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, headers, conf, false)
+	if err != nil {
+		return httperrors.NewInputParameterError("probe cifs share on host %s: %s", host.Name, err)
+	}
+	return nil
+}
+
+func (self *SCifsStorageDriver) ValidateCreateData(ctx context.Context, userCred mcclient.TokenCredential, input *api.StorageCreateInput) error {
+	input.StorageConf = jsonutils.NewDict()
+	share, err := validateCifsCreateInput(input)
+	if err != nil {
+		return err
+	}
+
+	storages := []models.SStorage{}
+	q := models.StorageManager.Query().Equals("storage_type", api.STORAGE_CIFS)
+	err = db.FetchModelObjects(models.StorageManager, q, &storages)
+	if err != nil {
+		return httperrors.NewGeneralError(err)
+	}
+	for i := 0; i < len(storages); i++ {
+		host, _ := storages[i].StorageConf.GetString("cifs_host")
+		s, _ := storages[i].StorageConf.GetString("cifs_share")
+		if input.CifsHost == host && strings.EqualFold(share, s) {
+			return httperrors.NewDuplicateResourceError("This CIFS Storage[%s//%s/%s] has already exist", storages[i].Name, host, s)
+		}
+	}
+
+	input.StorageConf.Update(jsonutils.Marshal(map[string]string{
+		"cifs_host":     input.CifsHost,
+		"cifs_share":    share,
+		"cifs_username": input.CifsUsername,
+		"cifs_password": input.CifsPassword,
+		"cifs_domain":   input.CifsDomain,
+	}))
+	return probeCifsShare(ctx, userCred, input.ZoneId, input.StorageConf)
+}
+
+func (self *SCifsStorageDriver) PostCreate(ctx context.Context, userCred mcclient.TokenCredential, storage *models.SStorage, data jsonutils.JSONObject) {
+	sc := &models.SStoragecache{}
+	sc.Path = options.Options.DefaultImageCacheDir
+	sc.ExternalId = storage.Id
+	sc.Name = "cifs-" + storage.Name + time.Now().Format("2006-01-02 15:04:05")
+	if err := models.StoragecacheManager.TableSpec().Insert(ctx, sc); err != nil {
+		log.Errorf("insert storagecache for storage %s error: %v", storage.Name, err)
+		return
+	}
+	// password is encrypted by storage id once the storage is created, like cloudaccount secret
+	conf := jsonutils.NewDict()
+	if storage.StorageConf != nil {
+		conf.Update(storage.StorageConf)
+	}
+	if password, _ := conf.GetString("cifs_password"); len(password) > 0 {
+		sec, err := utils.EncryptAESBase64(storage.Id, password)
+		if err != nil {
+			log.Errorf("encrypt cifs password for storage %s error: %v", storage.Name, err)
+			return
+		}
+		conf.Set("cifs_password", jsonutils.NewString(sec))
+	}
+	_, err := db.Update(storage, func() error {
+		storage.StoragecacheId = sc.Id
+		storage.StorageConf = conf
+		storage.Status = api.STORAGE_ONLINE
+		return nil
+	})
+	if err != nil {
+		log.Errorf("update storagecache info for storage %s error: %v", storage.Name, err)
+	}
+}
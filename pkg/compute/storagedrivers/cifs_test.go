@@ -0,0 +1,88 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagedrivers
+
+import (
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+func TestValidateCifsCreateInput(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     api.StorageCreateInput
+		wantShare string
+		wantErr   bool
+	}{
+		{
+			name: "normalize share",
+			input: api.StorageCreateInput{
+				CifsHost:     "192.168.222.2",
+				CifsShare:    "\\\\vmstore/",
+				CifsUsername: "administrator",
+				CifsPassword: "secret",
+			},
+			wantShare: "vmstore",
+		},
+		{
+			name:    "missing host",
+			input:   api.StorageCreateInput{CifsShare: "vmstore", CifsUsername: "administrator"},
+			wantErr: true,
+		},
+		{
+			name:    "empty share",
+			input:   api.StorageCreateInput{CifsHost: "192.168.222.2", CifsShare: "/ ", CifsUsername: "administrator"},
+			wantErr: true,
+		},
+		{
+			name:    "missing username",
+			input:   api.StorageCreateInput{CifsHost: "192.168.222.2", CifsShare: "vmstore"},
+			wantErr: true,
+		},
+		{
+			name: "line break in password",
+			input: api.StorageCreateInput{
+				CifsHost:     "192.168.222.2",
+				CifsShare:    "vmstore",
+				CifsUsername: "administrator",
+				CifsPassword: "secret\nusername=root",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "domain in username",
+			input:   api.StorageCreateInput{CifsHost: "192.168.222.2", CifsShare: "vmstore", CifsUsername: "WORKGROUP\\administrator"},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			share, err := validateCifsCreateInput(&c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expect error, got share %q", share)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateCifsCreateInput: %s", err)
+			}
+			if share != c.wantShare {
+				t.Errorf("want share %q, got %q", c.wantShare, share)
+			}
+		})
+	}
+}
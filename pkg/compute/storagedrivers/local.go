@@ -21,9 +21,11 @@ import (
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
 	"yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/mcclient"
 )
@@ -72,8 +74,52 @@ func (self *SLVMStorageDriver) PostCreate(ctx context.Context, userCred mcclient
 	}
 }
 
+func (self *SLVMStorageDriver) ValidateUpdateData(ctx context.Context, userCred mcclient.TokenCredential, input api.StorageUpdateInput) (api.StorageUpdateInput, error) {
+	if len(input.LvmThinPool) > 0 {
+		input.StorageConf.Set("thin_pool", jsonutils.NewString(input.LvmThinPool))
+	}
+	return input, nil
+}
+
+func isLVMThinStorage(storage *models.SStorage) bool {
+	if storage == nil || storage.StorageConf == nil {
+		return false
+	}
+	return storage.StorageConf.Contains("thin_pool")
+}
+
 func (self *SLVMStorageDriver) ValidateCreateSnapshotData(ctx context.Context, userCred mcclient.TokenCredential, disk *models.SDisk, input *api.SnapshotCreateInput) error {
-	return errors.Errorf("lvm storage unsupported create snapshot")
+	storage, _ := disk.GetStorage()
+	if !isLVMThinStorage(storage) {
+		return errors.Errorf("lvm storage without thin pool unsupported create snapshot")
+	}
+	return self.SBaseStorageDriver.ValidateCreateSnapshotData(ctx, userCred, disk, input)
+}
+
+// thin snapshots are independent thin lvs, deleting one doesn't affect the others
+func (self *SLVMStorageDriver) SnapshotIsOutOfChain(disk *models.SDisk) bool {
+	return true
+}
+
+func (self *SLVMStorageDriver) RequestDeleteSnapshot(ctx context.Context, snapshot *models.SSnapshot, task taskman.ITask) error {
+	storage := snapshot.GetStorage()
+	host, err := storage.GetMasterHost()
+	if err != nil {
+		return errors.Wrapf(err, "storage.GetMasterHost")
+	}
+	url := fmt.Sprintf("%s/disks/%s/delete-snapshot/%s", host.ManagerUri, storage.Id, snapshot.DiskId)
+	header := task.GetTaskRequestHeader()
+	params := jsonutils.NewDict()
+	params.Set("snapshot_id", jsonutils.NewString(snapshot.Id))
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(), ctx, "POST", url, header, params, false)
+	if err != nil {
+		return errors.Wrap(err, "request delete snapshot")
+	}
+	return nil
+}
+
+func (self *SLVMStorageDriver) OnDiskReset(ctx context.Context, userCred mcclient.TokenCredential, disk *models.SDisk, snapshot *models.SSnapshot, data jsonutils.JSONObject) error {
+	return nil
 }
 
 type SNVMEPassthroughStorageDriver struct {
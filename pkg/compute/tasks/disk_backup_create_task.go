@@ -75,6 +75,10 @@ func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandalone
 		self.OnSnapshot(ctx, backup, nil)
 		return
 	}
+	if jsonutils.QueryBoolean(self.Params, "incremental", false) && backup.CanLiveBackup() {
+		self.startLiveBackup(ctx, backup)
+		return
+	}
 	backup.SetStatus(self.UserCred, api.BACKUP_STATUS_SNAPSHOT, "")
 	snapshot, err := self.CreateSnapshot(ctx, backup)
 	if err != nil {
@@ -84,7 +88,11 @@ func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandalone
 	params := jsonutils.NewDict()
 	params.Set("snapshot_id", jsonutils.NewString(snapshot.GetId()))
 	self.SetStage("OnSnapshot", params)
-	err = snapshot.StartSnapshotCreateTask(ctx, self.UserCred, nil, self.GetId())
+	snapshotParams := jsonutils.NewDict()
+	if jsonutils.QueryBoolean(self.Params, "consistent", false) {
+		snapshotParams.Set("consistent", jsonutils.JSONTrue)
+	}
+	err = snapshot.StartSnapshotCreateTask(ctx, self.UserCred, snapshotParams, self.GetId())
 	if err != nil {
 		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_SNAPSHOT_FAILED)
 		return
@@ -93,6 +101,12 @@ func (self *DiskBackupCreateTask) OnInit(ctx context.Context, obj db.IStandalone
 
 func (self *DiskBackupCreateTask) OnSnapshot(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
 	snapshotId, _ := self.Params.GetString("snapshot_id")
+	if snapshot, err := models.SnapshotManager.FetchById(snapshotId); err == nil {
+		db.Update(backup, func() error {
+			backup.ApplicationConsistent = snapshot.(*models.SSnapshot).ApplicationConsistent
+			return nil
+		})
+	}
 	if self.Params.Contains("only_snapshot") {
 		p := jsonutils.NewDict()
 		p.Set("snapshot_id", jsonutils.NewString(snapshotId))
@@ -113,12 +127,43 @@ func (self *DiskBackupCreateTask) OnSnapshot(ctx context.Context, backup *models
 	}
 }
 
+// startLiveBackup backups disk of running guest without snapshot, dirty bitmap
+// kept by qemu since parent backup makes the backup incremental
+func (self *DiskBackupCreateTask) startLiveBackup(ctx context.Context, backup *models.SDiskBackup) {
+	params := jsonutils.NewDict()
+	params.Set("live_backup", jsonutils.JSONTrue)
+	parent, err := backup.GetIncrementalParent()
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_SAVE_FAILED)
+		return
+	}
+	if parent != nil {
+		params.Set("parent_backup_id", jsonutils.NewString(parent.GetId()))
+	}
+	backup.SetStatus(self.UserCred, api.BACKUP_STATUS_SAVING, "")
+	self.SetStage("OnSave", params)
+	rd, err := backup.GetRegionDriver()
+	if err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_SAVE_FAILED)
+		return
+	}
+	if err := rd.RequestCreateBackup(ctx, backup, "", self); err != nil {
+		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_SAVE_FAILED)
+		return
+	}
+}
+
 func (self *DiskBackupCreateTask) OnSnapshotFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
 	// remove snapshot
 	self.taskFailed(ctx, backup, data, api.BACKUP_STATUS_SNAPSHOT_FAILED)
 }
 
 func (self *DiskBackupCreateTask) OnSave(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	log.Infof("data from RequestCreateBackup: %s", data)
+	if jsonutils.QueryBoolean(self.Params, "live_backup", false) {
+		self.onLiveBackupSaved(ctx, backup, data)
+		return
+	}
 	// cleanup snapshot
 	snapshotId, _ := self.Params.GetString("snapshot_id")
 	self.SetStage("OnCleanupSnapshot", nil)
@@ -127,10 +172,10 @@ func (self *DiskBackupCreateTask) OnSave(ctx context.Context, backup *models.SDi
 		self.taskFailed(ctx, backup, jsonutils.NewString(err.Error()), api.BACKUP_STATUS_CLEANUP_SNAPSHOT_FAILED)
 		return
 	}
-	log.Infof("data from RequestCreateBackup: %s", data)
 	sizeMb, _ := data.Int("size_mb")
 	db.Update(backup, func() error {
 		backup.SizeMb = int(sizeMb)
+		backup.BackupMode = api.BACKUP_MODE_FULL
 		return nil
 	})
 	snapshot := snapshotModel.(*models.SSnapshot)
@@ -141,7 +186,36 @@ func (self *DiskBackupCreateTask) OnSave(ctx context.Context, backup *models.SDi
 	}
 }
 
+func (self *DiskBackupCreateTask) onLiveBackupSaved(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	sizeMb, _ := data.Int("size_mb")
+	backupMode, _ := data.GetString("backup_mode")
+	parentId, _ := data.GetString("parent_backup_id")
+	generation := 1
+	if backupMode == api.BACKUP_MODE_INCREMENTAL {
+		parent, err := models.DiskBackupManager.FetchById(parentId)
+		if err != nil {
+			self.taskFailed(ctx, backup, jsonutils.NewString(errors.Wrapf(err, "fetch parent backup %s", parentId).Error()), api.BACKUP_STATUS_SAVE_FAILED)
+			return
+		}
+		generation = parent.(*models.SDiskBackup).BitmapGeneration + 1
+	} else if expected, _ := self.Params.GetString("parent_backup_id"); len(expected) > 0 {
+		db.OpsLog.LogEvent(backup, db.ACT_CREATE_BACKUP, fmt.Sprintf("dirty bitmap of backup %s lost, fallback to full backup", expected), self.UserCred)
+	}
+	db.Update(backup, func() error {
+		backup.SizeMb = int(sizeMb)
+		backup.BackupMode = backupMode
+		backup.ParentBackupId = parentId
+		backup.BitmapGeneration = generation
+		return nil
+	})
+	self.taksSuccess(ctx, backup, nil)
+}
+
 func (self *DiskBackupCreateTask) OnSaveFailed(ctx context.Context, backup *models.SDiskBackup, data jsonutils.JSONObject) {
+	if jsonutils.QueryBoolean(self.Params, "live_backup", false) {
+		self.taskFailed(ctx, backup, data, api.BACKUP_STATUS_SAVE_FAILED)
+		return
+	}
 	snapshotId, _ := self.Params.GetString("snapshot_id")
 	snapshotModel, err := models.SnapshotManager.FetchById(snapshotId)
 	if err != nil {
@@ -0,0 +1,188 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+// DrProtectionGroupFailbackTask replicates servers running in target zone,
+// deletes stale source servers to release their addresses, recreates servers
+// on source side from the replicas and stops servers in target zone at last
+type DrProtectionGroupFailbackTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DrProtectionGroupFailbackTask{})
+}
+
+func (self *DrProtectionGroupFailbackTask) taskFailed(ctx context.Context, group *models.SDrProtectionGroup, reason jsonutils.JSONObject) {
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_FAILBACK_FAILED, reason.String())
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_FAILBACK, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DrProtectionGroupFailbackTask) getFailoverMembers(group *models.SDrProtectionGroup) ([]models.SDrProtectionGroupMember, error) {
+	members, err := group.GetMembers()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]models.SDrProtectionGroupMember, 0, len(members))
+	for i := range members {
+		if len(members[i].FailoverGuestId) > 0 {
+			ret = append(ret, members[i])
+		}
+	}
+	return ret, nil
+}
+
+func (self *DrProtectionGroupFailbackTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	group := obj.(*models.SDrProtectionGroup)
+	members, err := self.getFailoverMembers(group)
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnReplicated", nil)
+	replicas := jsonutils.NewDict()
+	for i := range members {
+		guest := models.GuestManager.FetchGuestById(members[i].FailoverGuestId)
+		if guest == nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("server %s in target zone is gone", members[i].FailoverGuestId)))
+			return
+		}
+		ib, err := group.CreateReplica(ctx, self.UserCred, guest, self.GetTaskId())
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("replicate server %s: %s", guest.Name, err)))
+			return
+		}
+		replicas.Set(members[i].Id, jsonutils.NewString(ib.Id))
+	}
+	params := jsonutils.NewDict()
+	params.Set("replicas", replicas)
+	self.SaveParams(params)
+	if len(members) == 0 {
+		self.OnReplicated(ctx, group, nil)
+	}
+}
+
+func (self *DrProtectionGroupFailbackTask) OnReplicated(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnReplicated"); reason != nil {
+		self.taskFailed(ctx, group, reason)
+		return
+	}
+	members, err := self.getFailoverMembers(group)
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnSourcesDeleted", nil)
+	deleting := 0
+	for i := range members {
+		source := members[i].GetGuest()
+		if source == nil {
+			continue
+		}
+		err := source.StartDeleteGuestTask(ctx, self.UserCred, self.GetTaskId(), api.ServerDeleteInput{OverridePendingDelete: true})
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("delete source server %s: %s", source.Name, err)))
+			return
+		}
+		deleting++
+	}
+	if deleting == 0 {
+		self.OnSourcesDeleted(ctx, group, nil)
+	}
+}
+
+func (self *DrProtectionGroupFailbackTask) OnSourcesDeleted(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnSourcesDeleted"); reason != nil {
+		self.taskFailed(ctx, group, reason)
+		return
+	}
+	members, err := self.getFailoverMembers(group)
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnGuestsCreated", nil)
+	guests := jsonutils.NewDict()
+	for i := range members {
+		member := &members[i]
+		replicaId, _ := self.Params.GetString("replicas", member.Id)
+		name := member.GuestId
+		if failover := models.GuestManager.FetchGuestById(member.FailoverGuestId); failover != nil {
+			name = failover.Name
+		}
+		guest, err := group.CreateRecoveryGuest(ctx, self.UserCred, member, replicaId, member.SourceZoneId, name, member.GetSourceNetworks(), self.GetTaskId())
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("recreate server %s: %s", name, err)))
+			return
+		}
+		guests.Set(member.Id, jsonutils.NewString(guest.Id))
+	}
+	params := jsonutils.NewDict()
+	params.Set("guests", guests)
+	self.SaveParams(params)
+	if len(members) == 0 {
+		self.OnGuestsCreated(ctx, group, nil)
+	}
+}
+
+func (self *DrProtectionGroupFailbackTask) OnGuestsCreated(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnGuestsCreated"); reason != nil {
+		self.taskFailed(ctx, group, reason)
+		return
+	}
+	members, err := self.getFailoverMembers(group)
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	for i := range members {
+		member := &members[i]
+		guestId, _ := self.Params.GetString("guests", member.Id)
+		guest := models.GuestManager.FetchGuestById(guestId)
+		if guest == nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("recreated server %s is gone", guestId)))
+			return
+		}
+		if failover := models.GuestManager.FetchGuestById(member.FailoverGuestId); failover != nil && failover.Status == api.VM_RUNNING {
+			err := failover.StartGuestStopTask(ctx, self.UserCred, false, false, "")
+			if err != nil {
+				log.Errorf("stop server %s in target zone: %s", failover.Name, err)
+			}
+		}
+		err := member.SwitchGuest(guest)
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+			return
+		}
+	}
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_READY, "")
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_FAILBACK, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type DrProtectionGroupFailoverTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DrProtectionGroupFailoverTask{})
+}
+
+// drFailedSubtask returns result of the first failed subtask of stage
+func drFailedSubtask(taskId, stage string) jsonutils.JSONObject {
+	subTasks := taskman.SubTaskManager.GetTotalSubtasks(taskId, stage, taskman.SUBTASK_FAIL)
+	if len(subTasks) == 0 {
+		return nil
+	}
+	result, err := jsonutils.ParseString(subTasks[0].Result)
+	if err != nil {
+		return jsonutils.NewString(subTasks[0].Result)
+	}
+	return result
+}
+
+func (self *DrProtectionGroupFailoverTask) taskFailed(ctx context.Context, group *models.SDrProtectionGroup, reason jsonutils.JSONObject) {
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_FAILOVER_FAILED, reason.String())
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_FAILOVER, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DrProtectionGroupFailoverTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	group := obj.(*models.SDrProtectionGroup)
+	members, err := group.GetMembers()
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnSourcesStopped", nil)
+	stopping := 0
+	if !jsonutils.QueryBoolean(self.Params, "skip_stop_source", false) {
+		for i := range members {
+			guest := members[i].GetGuest()
+			if guest == nil || guest.Status != api.VM_RUNNING {
+				continue
+			}
+			// source side may be unreachable in disaster
+			host, _ := guest.GetHost()
+			if host == nil || host.HostStatus != api.HOST_ONLINE {
+				continue
+			}
+			err := guest.StartGuestStopTask(ctx, self.UserCred, false, false, self.GetTaskId())
+			if err != nil {
+				log.Errorf("stop source server %s: %s", guest.Name, err)
+				continue
+			}
+			stopping++
+		}
+	}
+	if stopping == 0 {
+		self.OnSourcesStopped(ctx, group, nil)
+	}
+}
+
+func (self *DrProtectionGroupFailoverTask) OnSourcesStopped(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnSourcesStopped"); reason != nil {
+		// go on failover, source servers are out of service anyway
+		db.OpsLog.LogEvent(group, db.ACT_STOP_FAIL, reason, self.UserCred)
+	}
+	members, err := group.GetMembers()
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnGuestsCreated", nil)
+	created := 0
+	for i := range members {
+		member := &members[i]
+		if len(member.FailoverGuestId) > 0 && models.GuestManager.FetchGuestById(member.FailoverGuestId) != nil {
+			// recreated by last failover
+			continue
+		}
+		networks, err := member.GetTargetNetworks(group.GetNetworkMappings(), false)
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+			return
+		}
+		name := member.GuestId
+		if source := member.GetGuest(); source != nil {
+			name = source.Name
+		}
+		guest, err := group.CreateRecoveryGuest(ctx, self.UserCred, member, member.LastReplicaId, group.TargetZoneId, name, networks, self.GetTaskId())
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("recreate server %s: %s", name, err)))
+			return
+		}
+		err = member.SetFailoverGuestId(guest.Id)
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+			return
+		}
+		created++
+	}
+	if created == 0 {
+		self.OnGuestsCreated(ctx, group, nil)
+	}
+}
+
+func (self *DrProtectionGroupFailoverTask) OnGuestsCreated(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnGuestsCreated"); reason != nil {
+		self.taskFailed(ctx, group, reason)
+		return
+	}
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_FAILED_OVER, "")
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_FAILOVER, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
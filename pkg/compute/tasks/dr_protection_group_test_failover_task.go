@@ -0,0 +1,86 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type DrProtectionGroupTestFailoverTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(DrProtectionGroupTestFailoverTask{})
+}
+
+func (self *DrProtectionGroupTestFailoverTask) taskFailed(ctx context.Context, group *models.SDrProtectionGroup, reason jsonutils.JSONObject) {
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_TEST_FAILOVER_FAILED, reason.String())
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_TEST_FAILOVER, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *DrProtectionGroupTestFailoverTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	group := obj.(*models.SDrProtectionGroup)
+	members, err := group.GetMembers()
+	if err != nil {
+		self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnGuestsCreated", nil)
+	for i := range members {
+		member := &members[i]
+		networks, err := member.GetTargetNetworks(group.GetNetworkMappings(), true)
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+			return
+		}
+		name := member.GuestId
+		if source := member.GetGuest(); source != nil {
+			name = source.Name
+		}
+		name = fmt.Sprintf("%s-drtest", name)
+		guest, err := group.CreateRecoveryGuest(ctx, self.UserCred, member, member.LastReplicaId, group.TargetZoneId, name, networks, self.GetTaskId())
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(fmt.Sprintf("create test server %s: %s", name, err)))
+			return
+		}
+		// recorded at once so that cleanup-test could remove it whatever
+		err = member.SetTestGuestId(guest.Id)
+		if err != nil {
+			self.taskFailed(ctx, group, jsonutils.NewString(err.Error()))
+			return
+		}
+	}
+}
+
+func (self *DrProtectionGroupTestFailoverTask) OnGuestsCreated(ctx context.Context, group *models.SDrProtectionGroup, data jsonutils.JSONObject) {
+	if reason := drFailedSubtask(self.GetTaskId(), "OnGuestsCreated"); reason != nil {
+		self.taskFailed(ctx, group, reason)
+		return
+	}
+	group.SetStatus(self.UserCred, api.DR_PROTECTION_GROUP_STATUS_TESTING, "")
+	logclient.AddActionLogWithStartable(self, group, logclient.ACT_DR_TEST_FAILOVER, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
@@ -17,6 +17,7 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
@@ -171,6 +172,8 @@ func (t *GuestChangeDiskStorageTask) OnDiskLiveChangeStorageReadyFailed(
 }
 
 func (t *GuestChangeDiskStorageTask) OnDiskChangeStorageComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	// guest runs on target disk from now on, never roll back
+	t.Params.Set("switched", jsonutils.JSONTrue)
 	srcDisk, err := t.GetSourceDisk()
 	if err != nil {
 		t.TaskFailed(ctx, guest, jsonutils.NewString(fmt.Sprintf("GetSourceDisk: %v", err)))
@@ -304,11 +307,97 @@ func (t *GuestChangeDiskStorageTask) TaskComplete(ctx context.Context, guest *mo
 }
 
 func (t *GuestChangeDiskStorageTask) TaskFailed(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	if !jsonutils.QueryBoolean(t.Params, "switched", false) && !t.Params.Contains("rollback_reason") {
+		t.rollback(ctx, guest, reason)
+		return
+	}
 	guest.SetStatus(t.GetUserCred(), api.VM_DISK_CHANGE_STORAGE_FAIL, reason.String())
 	logclient.AddActionLogWithStartable(t, guest, logclient.ACT_DISK_CHANGE_STORAGE, reason, t.GetUserCred(), false)
 	t.SetStageFailed(ctx, reason)
 }
 
+// rollback stops copying to target disk and removes it, guest keeps using source disk
+func (t *GuestChangeDiskStorageTask) rollback(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	t.Params.Set("rollback_reason", reason)
+	input, err := t.GetInputParams()
+	if err == nil && input.GuestRunning {
+		t.SetStage("OnRollbackBlockJobCancelled", nil)
+		err = guest.GetDriver().RequestCancelChangeDiskStorage(ctx, t.GetUserCred(), guest, input.DiskId, t)
+		if err == nil {
+			return
+		}
+		log.Errorf("cancel block job of disk %s: %s", input.DiskId, err)
+	}
+	t.OnRollbackBlockJobCancelled(ctx, guest, nil)
+}
+
+const (
+	changeDiskRollbackDelete   = "delete"
+	changeDiskRollbackKeep     = "keep"
+	changeDiskRollbackSwitched = "switched"
+)
+
+// changeDiskRollbackAction decides what to do with target disk on rollback by
+// the file the guest drive opens as reported by host.  A running guest may have
+// pivoted to target disk before the job was cancelled, and target disk is
+// kept if the file is unknown, guest data would be lost otherwise
+func changeDiskRollbackAction(guestRunning bool, blockFile string, targetDiskId string) string {
+	if !guestRunning {
+		return changeDiskRollbackDelete
+	}
+	if len(blockFile) == 0 {
+		return changeDiskRollbackKeep
+	}
+	// path of disk is named after disk id on any kind of storage
+	if len(targetDiskId) > 0 && strings.Contains(blockFile, targetDiskId) {
+		return changeDiskRollbackSwitched
+	}
+	return changeDiskRollbackDelete
+}
+
+func (t *GuestChangeDiskStorageTask) OnRollbackBlockJobCancelled(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	reason, _ := t.Params.Get("rollback_reason")
+	if reason == nil {
+		reason = jsonutils.NewString("rollback")
+	}
+	input, err := t.GetInputParams()
+	guestRunning := err == nil && input.GuestRunning
+	targetDisk, err := t.GetTargetDisk()
+	if err == nil {
+		blockFile := ""
+		if data != nil {
+			blockFile, _ = data.GetString("block_file")
+		}
+		switch changeDiskRollbackAction(guestRunning, blockFile, targetDisk.Id) {
+		case changeDiskRollbackSwitched:
+			log.Infof("guest %s runs on target disk %s, finish changing disk storage", guest.Id, blockFile)
+			t.OnDiskChangeStorageComplete(ctx, guest, data)
+			return
+		case changeDiskRollbackKeep:
+			log.Errorf("unknown disk guest %s runs on, keep target disk %s", guest.Id, targetDisk.Id)
+			targetDisk.SetStatus(t.GetUserCred(), api.DISK_CLONE_FAIL, reason.String())
+		default:
+			targetDisk.SetStatus(t.GetUserCred(), api.DISK_CLONE_FAIL, reason.String())
+			err = targetDisk.StartDiskDeleteTask(ctx, t.GetUserCred(), "", false, true, false)
+			if err != nil {
+				log.Errorf("delete target disk %s: %s", targetDisk.Id, err)
+			}
+		}
+	}
+	if guestRunning {
+		guest.StartSyncstatus(ctx, t.GetUserCred(), "")
+	} else {
+		guest.SetStatus(t.GetUserCred(), api.VM_READY, "rollback change disk storage")
+	}
+	logclient.AddActionLogWithStartable(t, guest, logclient.ACT_DISK_CHANGE_STORAGE, reason, t.GetUserCred(), false)
+	t.SetStageFailed(ctx, reason)
+}
+
+func (t *GuestChangeDiskStorageTask) OnRollbackBlockJobCancelledFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
+	log.Errorf("cancel block job failed: %s", data)
+	t.OnRollbackBlockJobCancelled(ctx, guest, data)
+}
+
 // --------------------- GuestChangeDisksStorageTask ----------------------------
 
 type GuestChangeDisksStorageTask struct {
@@ -367,7 +456,9 @@ func (t *GuestChangeDisksStorageTask) ChangeDiskStorage(ctx context.Context, gue
 }
 
 func (t *GuestChangeDisksStorageTask) ChangeDiskStorageFailed(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
-	t.TaskFailed(ctx, guest, data)
+	// status of guest is maintained by subtask, which may have rolled back
+	logclient.AddActionLogWithStartable(t, guest, logclient.ACT_DISK_CHANGE_STORAGE, data, t.GetUserCred(), false)
+	t.SetStageFailed(ctx, data)
 }
 
 func (t *GuestChangeDisksStorageTask) CreateTargetDisk(ctx context.Context, guest *models.SGuest, input *api.ServerChangeStorageInternalInput) {
@@ -401,6 +492,7 @@ func (t *GuestChangeDisksStorageTask) CreateTargetDisk(ctx context.Context, gues
 			DiskId:          srcDisk.Id,
 			TargetStorageId: storage.Id,
 			KeepOriginDisk:  input.KeepOriginDisk,
+			MaxBandwidthMb:  input.MaxBandwidthMb,
 		},
 		StorageId:          srcDisk.StorageId,
 		TargetDiskId:       targetDisk.GetId(),
@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import "testing"
+
+func TestChangeDiskRollbackAction(t *testing.T) {
+	const targetDiskId = "3d5f2c1a-7b7e-4c0e-9b1a-2f7c5d9e8a10"
+	cases := []struct {
+		name         string
+		guestRunning bool
+		blockFile    string
+		want         string
+	}{
+		{
+			name: "guest not running",
+			want: changeDiskRollbackDelete,
+		},
+		{
+			name:         "guest on source disk",
+			guestRunning: true,
+			blockFile:    "/opt/cloud/workspace/disks/0b9a4f3e-5d6c-4e1f-8a2b-3c4d5e6f7a8b",
+			want:         changeDiskRollbackDelete,
+		},
+		{
+			name:         "guest pivoted to local target disk",
+			guestRunning: true,
+			blockFile:    "/opt/cloud/workspace/disks/" + targetDiskId,
+			want:         changeDiskRollbackSwitched,
+		},
+		{
+			name:         "guest pivoted to lvm target disk",
+			guestRunning: true,
+			blockFile:    "/dev/vg0/" + targetDiskId,
+			want:         changeDiskRollbackSwitched,
+		},
+		{
+			name:         "block file unknown",
+			guestRunning: true,
+			want:         changeDiskRollbackKeep,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := changeDiskRollbackAction(c.guestRunning, c.blockFile, targetDiskId)
+			if got != c.want {
+				t.Errorf("want %s, got %s", c.want, got)
+			}
+		})
+	}
+}
@@ -78,10 +78,15 @@ func (self *GuestDiskSnapshotTask) OnDiskSnapshotComplete(ctx context.Context, g
 	if snapshot.DiskType == compute.DISK_TYPE_SYS {
 		osType = guest.GetOS()
 	}
+	consistent := jsonutils.QueryBoolean(res, "application_consistent", false)
+	if jsonutils.QueryBoolean(self.Params, "consistent", false) && !consistent {
+		db.OpsLog.LogEvent(snapshot, db.ACT_SNAPSHOT_DONE, "guest filesystems not frozen, snapshot is crash-consistent", self.UserCred)
+	}
 	_, err = db.Update(snapshot, func() error {
 		snapshot.Location = location
 		snapshot.Status = api.SNAPSHOT_READY
 		snapshot.OsType = osType
+		snapshot.ApplicationConsistent = consistent
 		return nil
 	})
 	if err != nil {
@@ -0,0 +1,79 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+// freezeGuestFilesystems freezes filesystems of the guest once for snapshots
+// of all its disks, so that the disks are consistent with each other.  The
+// epoch of the freeze is kept in task params until thawGuestFilesystems
+func freezeGuestFilesystems(ctx context.Context, task *taskman.STask, guest *models.SGuest) {
+	params := jsonutils.NewDict()
+	running, epoch := true, 0
+	host, err := guest.GetHost()
+	if err == nil {
+		resp, e := guest.GetDriver().QgaRequestFsfreezeFreeze(ctx, task.GetTaskRequestHeader(), host, guest)
+		if e == nil {
+			running, epoch = resp.Running, resp.Epoch
+		}
+		err = e
+	}
+	if err != nil {
+		log.Warningf("freeze guest %s filesystems: %s", guest.Name, err)
+	}
+	if running {
+		params.Set("fsfreeze_epoch", jsonutils.NewInt(int64(epoch)))
+	}
+	task.SaveParams(params)
+}
+
+// thawGuestFilesystems thaws filesystems frozen by freezeGuestFilesystems, it
+// does nothing if called more than once
+func thawGuestFilesystems(ctx context.Context, task *taskman.STask, guest *models.SGuest) {
+	epoch, err := task.Params.Int("fsfreeze_epoch")
+	if err != nil || task.Params.Contains("fsfreeze_consistent") {
+		return
+	}
+	consistent := false
+	if epoch > 0 {
+		host, err := guest.GetHost()
+		if err == nil {
+			consistent, err = guest.GetDriver().QgaRequestFsfreezeThaw(ctx, task.GetTaskRequestHeader(), host, guest, int(epoch))
+		}
+		if err != nil {
+			log.Errorf("thaw guest %s filesystems: %s", guest.Name, err)
+		}
+	}
+	params := jsonutils.NewDict()
+	params.Set("fsfreeze_consistent", jsonutils.NewBool(consistent))
+	task.SaveParams(params)
+}
+
+// isGuestFsfreezeConsistent tells whether guest filesystems stayed frozen
+// while all disks were snapshotted, stopped guest needs no freeze
+func isGuestFsfreezeConsistent(task *taskman.STask) bool {
+	if !task.Params.Contains("fsfreeze_epoch") {
+		return true
+	}
+	return jsonutils.QueryBoolean(task.Params, "fsfreeze_consistent", false)
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+)
+
+func TestIsGuestFsfreezeConsistent(t *testing.T) {
+	cases := []struct {
+		name   string
+		params string
+		want   bool
+	}{
+		{
+			name:   "guest not running",
+			params: `{"consistent":true}`,
+			want:   true,
+		},
+		{
+			name:   "frozen and not thawed",
+			params: `{"consistent":true,"fsfreeze_epoch":1}`,
+			want:   false,
+		},
+		{
+			name:   "thawed",
+			params: `{"consistent":true,"fsfreeze_epoch":1,"fsfreeze_consistent":true}`,
+			want:   true,
+		},
+		{
+			name:   "forcibly thawed while snapshotting",
+			params: `{"consistent":true,"fsfreeze_epoch":1,"fsfreeze_consistent":false}`,
+			want:   false,
+		},
+		{
+			name:   "freeze failed",
+			params: `{"consistent":true,"fsfreeze_epoch":0,"fsfreeze_consistent":false}`,
+			want:   false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			params, err := jsonutils.ParseString(c.params)
+			if err != nil {
+				t.Fatalf("parse params: %v", err)
+			}
+			task := &taskman.STask{Params: params.(*jsonutils.JSONDict)}
+			if got := isGuestFsfreezeConsistent(task); got != c.want {
+				t.Errorf("want %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/httputils"
 	"yunion.io/x/pkg/utils"
@@ -393,6 +394,14 @@ func (task *GuestMigrateTask) setBodyMemorySnapshotParams(guest *models.SGuest,
 	return nil
 }
 
+// setBodyVtpmParams tells target host where to fetch the vTPM state on cold migration,
+// the state is transferred by qemu itself on live migration
+func (task *GuestMigrateTask) setBodyVtpmParams(ctx context.Context, guest *models.SGuest, srcHost *models.SHost, body *jsonutils.JSONDict) {
+	if guest.GetMetadata(ctx, api.VM_METADATA_ENABLE_VTPM, nil) == "true" {
+		body.Set("vtpm_uri", jsonutils.NewString(fmt.Sprintf("%s/download/vtpm/%s", srcHost.ManagerUri, guest.Id)))
+	}
+}
+
 func (task *GuestMigrateTask) sharedStorageMigrateConf(ctx context.Context, guest *models.SGuest, targetHost *models.SHost) (*jsonutils.JSONDict, error) {
 	body := jsonutils.NewDict()
 	body.Set("is_local_storage", jsonutils.JSONFalse)
@@ -404,6 +413,7 @@ func (task *GuestMigrateTask) sharedStorageMigrateConf(ctx context.Context, gues
 	if err := task.setBodyMemorySnapshotParams(guest, sourceHost, body); err != nil {
 		return nil, errors.Wrap(err, "setBodyMemorySnapshotParams")
 	}
+	task.setBodyVtpmParams(ctx, guest, sourceHost, body)
 	return body, nil
 }
 
@@ -447,6 +457,7 @@ func (task *GuestMigrateTask) localStorageMigrateConf(ctx context.Context,
 	if err := task.setBodyMemorySnapshotParams(guest, sourceHost, body); err != nil {
 		return nil, errors.Wrap(err, "setBodyMemorySnapshotParams")
 	}
+	task.setBodyVtpmParams(ctx, guest, sourceHost, body)
 
 	targetDesc := guest.GetJsonDescAtHypervisor(ctx, targetHost)
 	if len(targetDesc.Disks) == 0 {
@@ -641,6 +652,7 @@ func (task *GuestLiveMigrateTask) OnResumeDestGuestComplete(ctx context.Context,
 
 func (task *GuestLiveMigrateTask) OnUndeploySrcGuestComplete(ctx context.Context, guest *models.SGuest, data jsonutils.JSONObject) {
 	db.OpsLog.LogEvent(guest, db.ACT_MIGRATE, "OnUndeploySrcGuestComplete", task.UserCred)
+	task.activateSLVMDisksExclusive(ctx, guest)
 	status, _ := task.Params.GetString("guest_status")
 	if status != guest.Status {
 		task.SetStage("OnGuestSyncStatus", nil)
@@ -693,7 +705,40 @@ func (task *GuestMigrateTask) TaskComplete(ctx context.Context, guest *models.SG
 	logclient.AddActionLogWithContext(ctx, guest, logclient.ACT_MIGRATE, task.Params, task.UserCred, true)
 }
 
+// activateSLVMDisksExclusive converts lock of lvs of shared vg opened by guest
+// back to exclusive, they are activated shared on both source and target host
+// while live migrating, host retries until the other one released them
+func (task *GuestMigrateTask) activateSLVMDisksExclusive(ctx context.Context, guest *models.SGuest) {
+	disks, err := guest.GetDisks()
+	if err != nil {
+		log.Errorf("guest %s GetDisks: %s", guest.Name, err)
+		return
+	}
+	hasSLVM := false
+	for i := range disks {
+		if storage, _ := disks[i].GetStorage(); storage != nil && storage.StorageType == api.STORAGE_SLVM {
+			hasSLVM = true
+			break
+		}
+	}
+	host, _ := guest.GetHost()
+	if !hasSLVM || host == nil {
+		return
+	}
+	body := jsonutils.NewDict()
+	body.Set("exclusive", jsonutils.JSONTrue)
+	url := fmt.Sprintf("%s/servers/%s/activate-slvm-disks", host.ManagerUri, guest.Id)
+	_, _, err = httputils.JSONRequest(httputils.GetDefaultClient(),
+		ctx, "POST", url, task.GetTaskRequestHeader(), body, false)
+	if err != nil {
+		db.OpsLog.LogEvent(guest, db.ACT_MIGRATE_FAIL, fmt.Sprintf("activate slvm disks exclusive on host %s: %s", host.Name, err), task.UserCred)
+	}
+}
+
 func (task *GuestMigrateTask) TaskFailed(ctx context.Context, guest *models.SGuest, reason jsonutils.JSONObject) {
+	if task.isLiveMigrate() && !jsonutils.QueryBoolean(task.Params, "keep_dest_guest_on_failed", false) {
+		task.activateSLVMDisksExclusive(ctx, guest)
+	}
 	task.markFailed(ctx, guest, reason)
 	task.SetStageFailed(ctx, reason)
 }
@@ -37,7 +37,11 @@ func init() {
 }
 
 func (self *InstanceBackupCreateTask) taskFailed(ctx context.Context, ib *models.SInstanceBackup, guest *models.SGuest, reason jsonutils.JSONObject, status string) {
+	if guest == nil {
+		guest = models.GuestManager.FetchGuestById(ib.GuestId)
+	}
 	if guest != nil {
+		thawGuestFilesystems(ctx, &self.STask, guest)
 		guest.SetStatus(self.UserCred, compute.VM_INSTANCE_BACKUP_FAILED, reason.String())
 	}
 	reasonStr, _ := reason.GetString()
@@ -56,6 +60,9 @@ func (self *InstanceBackupCreateTask) OnInit(ctx context.Context, obj db.IStanda
 	ib := obj.(*models.SInstanceBackup)
 	self.SetStage("OnInstanceBackup", nil)
 	guest := models.GuestManager.FetchGuestById(ib.GuestId)
+	if jsonutils.QueryBoolean(self.Params, "consistent", false) {
+		freezeGuestFilesystems(ctx, &self.STask, guest)
+	}
 	params := jsonutils.NewDict()
 	ib.SetStatus(self.GetUserCred(), compute.INSTANCE_BACKUP_STATUS_SNAPSHOT, "")
 	if err := ib.GetRegionDriver().RequestCreateInstanceBackup(ctx, guest, ib, self, params); err != nil {
@@ -66,7 +73,10 @@ func (self *InstanceBackupCreateTask) OnInit(ctx context.Context, obj db.IStanda
 func (self *InstanceBackupCreateTask) OnKvmDisksSnapshot(ctx context.Context, ib *models.SInstanceBackup, data jsonutils.JSONObject) {
 	subTasks := taskman.SubTaskManager.GetTotalSubtasks(self.Id, "OnKvmDisksSnapshot", "")
 	guest := models.GuestManager.FetchGuestById(ib.GuestId)
+	// all disks snapshotted
+	thawGuestFilesystems(ctx, &self.STask, guest)
 	self.SetStage("OnInstanceBackup", nil)
+	saving := 0
 	for i := range subTasks {
 		log.Infof("subsTask %s result: %s", subTasks[i].SubtaskId, subTasks[i].Result)
 		result, err := jsonutils.ParseString(subTasks[i].Result)
@@ -79,6 +89,10 @@ func (self *InstanceBackupCreateTask) OnKvmDisksSnapshot(ctx context.Context, ib
 			return
 		}
 		snapshotId, _ := result.GetString("snapshot_id")
+		if len(snapshotId) == 0 {
+			// disk backup has been saved by incremental live backup
+			continue
+		}
 		diskBakcupId, _ := result.GetString("disk_backup_id")
 		ibackup, err := models.DiskBackupManager.FetchById(diskBakcupId)
 		if err != nil {
@@ -92,9 +106,13 @@ func (self *InstanceBackupCreateTask) OnKvmDisksSnapshot(ctx context.Context, ib
 			self.taskFailed(ctx, ib, guest, jsonutils.NewString(err.Error()), compute.INSTANCE_BACKUP_STATUS_SAVE_FAILED)
 			return
 		}
+		saving++
 	}
 	ib.SetStatus(self.GetUserCred(), compute.INSTANCE_BACKUP_STATUS_SAVING, "")
 	guest.StartSyncstatus(ctx, self.UserCred, "")
+	if saving == 0 {
+		self.OnInstanceBackup(ctx, ib, nil)
+	}
 }
 
 func (self *InstanceBackupCreateTask) OnKvmDisksSnapshotFailed(ctx context.Context, ib *models.SInstanceBackup, data jsonutils.JSONObject) {
@@ -125,8 +143,14 @@ func (self *InstanceBackupCreateTask) OnInstanceBackup(ctx context.Context, ib *
 	for i := range backups {
 		sizeMb += backups[i].SizeMb
 	}
+	// snapshots of disks share the freeze held across all of them
+	consistent := len(backups) > 0 && isGuestFsfreezeConsistent(&self.STask)
+	for i := range backups {
+		consistent = consistent && backups[i].ApplicationConsistent
+	}
 	db.Update(ib, func() error {
 		ib.SizeMb = sizeMb
+		ib.ApplicationConsistent = consistent
 		return nil
 	})
 	self.taskSuccess(ctx, ib)
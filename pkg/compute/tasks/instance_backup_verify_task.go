@@ -0,0 +1,85 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+	"yunion.io/x/onecloud/pkg/util/logclient"
+)
+
+type InstanceBackupVerifyTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(InstanceBackupVerifyTask{})
+}
+
+func (self *InstanceBackupVerifyTask) taskFailed(ctx context.Context, ib *models.SInstanceBackup, reason jsonutils.JSONObject) {
+	reasonStr, _ := reason.GetString()
+	ib.SetStatus(self.UserCred, api.INSTANCE_BACKUP_STATUS_VERIFY_FAILED, reasonStr)
+	logclient.AddActionLogWithStartable(self, ib, logclient.ACT_VERIFY, reason, self.UserCred, false)
+	self.SetStageFailed(ctx, reason)
+}
+
+func (self *InstanceBackupVerifyTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	ib := obj.(*models.SInstanceBackup)
+	backups, err := ib.GetBackups()
+	if err != nil {
+		self.taskFailed(ctx, ib, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStage("OnDiskBackupsVerify", nil)
+	if len(backups) == 0 {
+		self.OnDiskBackupsVerify(ctx, ib, nil)
+		return
+	}
+	for i := range backups {
+		if err := backups[i].StartVerifyTask(ctx, self.UserCred, self.GetTaskId()); err != nil {
+			self.taskFailed(ctx, ib, jsonutils.NewString(err.Error()))
+			return
+		}
+	}
+}
+
+func (self *InstanceBackupVerifyTask) OnDiskBackupsVerify(ctx context.Context, ib *models.SInstanceBackup, data jsonutils.JSONObject) {
+	subTasks := taskman.SubTaskManager.GetTotalSubtasks(self.Id, "OnDiskBackupsVerify", "")
+	for i := range subTasks {
+		if subTasks[i].Status != taskman.SUBTASK_FAIL {
+			continue
+		}
+		result, err := jsonutils.ParseString(subTasks[i].Result)
+		if err != nil {
+			result = jsonutils.NewString(fmt.Sprintf("unable to parse %s", subTasks[i].Result))
+		}
+		self.taskFailed(ctx, ib, result)
+		return
+	}
+	ib.SetStatus(self.UserCred, api.INSTANCE_BACKUP_STATUS_READY, "verified")
+	logclient.AddActionLogWithStartable(self, ib, logclient.ACT_VERIFY, nil, self.UserCred, true)
+	self.SetStageComplete(ctx, nil)
+}
+
+func (self *InstanceBackupVerifyTask) OnDiskBackupsVerifyFailed(ctx context.Context, ib *models.SInstanceBackup, data jsonutils.JSONObject) {
+	self.taskFailed(ctx, ib, data)
+}
@@ -56,6 +56,7 @@ func (self *InstanceSnapshotCreateTask) taskFail(
 	if guest == nil {
 		guest = models.GuestManager.FetchGuestById(isp.GuestId)
 	}
+	thawGuestFilesystems(ctx, &self.STask, guest)
 	isp.SetStatus(self.UserCred, compute.INSTANCE_SNAPSHOT_FAILED, reason.String())
 	guest.SetStatus(self.UserCred, compute.VM_INSTANCE_SNAPSHOT_FAILED, reason.String())
 
@@ -89,6 +90,9 @@ func (self *InstanceSnapshotCreateTask) OnInit(
 
 	isp := obj.(*models.SInstanceSnapshot)
 	guest := models.GuestManager.FetchGuestById(isp.GuestId)
+	if jsonutils.QueryBoolean(self.Params, "consistent", false) {
+		freezeGuestFilesystems(ctx, &self.STask, guest)
+	}
 	self.SetStage("OnInstanceSnapshot", nil)
 	params := jsonutils.NewDict()
 	params.Set("disk_index", jsonutils.NewInt(0))
@@ -109,6 +113,11 @@ func (self *InstanceSnapshotCreateTask) OnKvmDiskSnapshot(
 		return
 	}
 
+	// thaw once all disks snapshotted, before memory snapshot if any
+	if disks, _ := guest.GetGuestDisks(); int(diskIndex)+1 >= len(disks) {
+		thawGuestFilesystems(ctx, &self.STask, guest)
+	}
+
 	params := jsonutils.NewDict()
 	params.Set("disk_index", jsonutils.NewInt(diskIndex+1))
 	if err := isp.GetRegionDriver().RequestCreateInstanceSnapshot(ctx, guest, isp, self, params); err != nil {
@@ -124,6 +133,23 @@ func (self *InstanceSnapshotCreateTask) OnKvmDiskSnapshotFailed(
 
 func (self *InstanceSnapshotCreateTask) OnInstanceSnapshot(ctx context.Context, isp *models.SInstanceSnapshot, data jsonutils.JSONObject) {
 	guest, _ := isp.GetGuest()
+	if jsonutils.QueryBoolean(self.Params, "consistent", false) {
+		thawGuestFilesystems(ctx, &self.STask, guest)
+		snapshots, err := isp.GetSnapshots()
+		if err != nil {
+			self.taskFail(ctx, isp, guest, jsonutils.NewString(err.Error()))
+			return
+		}
+		// disk snapshots share the freeze held across all of them
+		consistent := len(snapshots) > 0 && isGuestFsfreezeConsistent(&self.STask)
+		for i := range snapshots {
+			consistent = consistent && snapshots[i].ApplicationConsistent
+		}
+		db.Update(isp, func() error {
+			isp.ApplicationConsistent = consistent
+			return nil
+		})
+	}
 	if isp.WithMemory {
 		resp := new(hostapi.GuestMemorySnapshotResponse)
 		if err := data.Unmarshal(resp); err != nil {
@@ -0,0 +1,92 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/taskman"
+	"yunion.io/x/onecloud/pkg/compute/models"
+)
+
+// MaintenanceCampaignTask drains one batch of hosts of the campaign, the
+// drained hosts are re-enabled and next batch is started by the health check cron
+type MaintenanceCampaignTask struct {
+	taskman.STask
+}
+
+func init() {
+	taskman.RegisterTask(MaintenanceCampaignTask{})
+}
+
+func (self *MaintenanceCampaignTask) OnInit(ctx context.Context, obj db.IStandaloneModel, data jsonutils.JSONObject) {
+	campaign := obj.(*models.SMaintenanceCampaign)
+	if campaign.Status != api.MAINTENANCE_CAMPAIGN_STATUS_RUNNING {
+		self.SetStageComplete(ctx, nil)
+		return
+	}
+	if campaign.TryComplete(ctx, self.UserCred) {
+		self.SetStageComplete(ctx, nil)
+		return
+	}
+
+	batch, err := campaign.NextBatch(ctx)
+	if err != nil {
+		self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+		return
+	}
+	if len(batch) == 0 {
+		self.SetStageComplete(ctx, nil)
+		return
+	}
+
+	self.SetStage("OnHostsDrained", nil)
+	started := 0
+	for i := range batch {
+		host := models.HostManager.FetchHostById(batch[i].HostId)
+		if host == nil {
+			campaign.MarkHostFailed(ctx, self.UserCred, batch[i].HostId, "host not found")
+			continue
+		}
+		err := host.StartHostMaintenance(ctx, self.UserCred, "", self.Id)
+		if err != nil {
+			campaign.MarkHostFailed(ctx, self.UserCred, host.Id, err.Error())
+			continue
+		}
+		started++
+	}
+	if started == 0 {
+		self.OnHostsDrained(ctx, campaign, nil)
+	}
+}
+
+func (self *MaintenanceCampaignTask) OnHostsDrained(ctx context.Context, campaign *models.SMaintenanceCampaign, data jsonutils.JSONObject) {
+	err := campaign.OnHostsDrained(ctx, self.UserCred)
+	if err != nil {
+		self.SetStageFailed(ctx, jsonutils.NewString(err.Error()))
+		return
+	}
+	self.SetStageComplete(ctx, nil)
+}
+
+// OnHostsDrainedFailed is called when any host maintain task failed, hosts are
+// checked one by one as OnHostsDrained
+func (self *MaintenanceCampaignTask) OnHostsDrainedFailed(ctx context.Context, campaign *models.SMaintenanceCampaign, data jsonutils.JSONObject) {
+	self.OnHostsDrained(ctx, campaign, data)
+}
@@ -52,6 +52,18 @@ func (self *VpcPeeringConnectionCreateTask) OnInit(ctx context.Context, obj db.I
 		return
 	}
 
+	if len(vpc.ManagerId) == 0 {
+		// on-premise peering connection is realized by vpcagent
+		err := peer.AllocateTransitIndex(ctx, self.GetUserCred())
+		if err != nil {
+			self.taskFailed(ctx, peer, errors.Wrapf(err, "AllocateTransitIndex"))
+			return
+		}
+		peer.SetStatus(self.GetUserCred(), api.VPC_PEERING_CONNECTION_STATUS_ACTIVE, "")
+		self.taskComplete(ctx, peer)
+		return
+	}
+
 	peerVpc, err := peer.GetPeerVpc()
 	if err != nil {
 		self.taskFailed(ctx, peer, errors.Wrapf(err, "GetPeerVpc"))
@@ -50,6 +50,16 @@ func (self *VpcPeeringConnectionSyncstatusTask) OnInit(ctx context.Context, obj
 		self.taskFail(ctx, peer, errors.Wrap(err, "peer.GetVpc()"))
 		return
 	}
+	if len(svpc.ManagerId) == 0 {
+		err := peer.AllocateTransitIndex(ctx, self.GetUserCred())
+		if err != nil {
+			self.taskFail(ctx, peer, errors.Wrap(err, "AllocateTransitIndex"))
+			return
+		}
+		peer.SetStatus(self.GetUserCred(), api.VPC_PEERING_CONNECTION_STATUS_ACTIVE, "")
+		self.SetStageComplete(ctx, nil)
+		return
+	}
 
 	extVpc, err := svpc.GetIVpc(ctx)
 	if err != nil {
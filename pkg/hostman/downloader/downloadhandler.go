@@ -129,6 +129,16 @@ func download(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 				hostutils.Response(ctx, w, err)
 			}
 		}
+	case "vtpm":
+		statePath, err := guestman.GetVtpmStatePath(id)
+		if err != nil || !fileutils2.Exists(statePath) {
+			httperrors.NotFoundError(ctx, w, "Guest %s vtpm state not found", id)
+		} else {
+			hand := NewSnapshotDownloadProvider(w, compress, sparse, rateLimit, statePath)
+			if err := hand.Start(); err != nil {
+				hostutils.Response(ctx, w, err)
+			}
+		}
 	default:
 		hostutils.Response(ctx, w, httperrors.NewNotFoundError("%s Not found", action))
 	}
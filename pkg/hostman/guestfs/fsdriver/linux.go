@@ -826,6 +826,42 @@ func getNicTeamingConfigCmds(slaves []*types.SServerNic) string {
 	return cmds.String()
 }
 
+// getIfupdownIPv6Cmds renders the inet6 stanza of a dual-stack nic, manual
+// nics get the address allocated by the region, others ask for it by DHCPv6
+func getIfupdownIPv6Cmds(nicDesc *types.SServerNic, isMainNic bool) string {
+	var cmds strings.Builder
+	if nicDesc.Manual {
+		cmds.WriteString(fmt.Sprintf("iface %s inet6 static\n", nicDesc.Name))
+		cmds.WriteString(fmt.Sprintf("    address %s\n", nicDesc.Ip6))
+		cmds.WriteString(fmt.Sprintf("    netmask %d\n", nicDesc.Masklen6))
+		if len(nicDesc.Gateway6) > 0 && isMainNic {
+			cmds.WriteString(fmt.Sprintf("    gateway %s\n", nicDesc.Gateway6))
+		}
+	} else {
+		cmds.WriteString(fmt.Sprintf("iface %s inet6 dhcp\n", nicDesc.Name))
+		if len(nicDesc.Gateway6) > 0 && isMainNic {
+			cmds.WriteString(fmt.Sprintf("    up ip -6 route replace default via %s dev %s || true\n", nicDesc.Gateway6, nicDesc.Name))
+		}
+	}
+	cmds.WriteString("\n")
+	return cmds.String()
+}
+
+func getIfcfgIPv6Cmds(nicDesc *types.SServerNic, isMainNic bool) string {
+	var cmds strings.Builder
+	cmds.WriteString("IPV6INIT=yes\n")
+	if nicDesc.Manual {
+		cmds.WriteString("IPV6_AUTOCONF=no\n")
+		cmds.WriteString(fmt.Sprintf("IPV6ADDR=%s/%d\n", nicDesc.Ip6, nicDesc.Masklen6))
+	} else {
+		cmds.WriteString("DHCPV6C=yes\n")
+	}
+	if len(nicDesc.Gateway6) > 0 && isMainNic {
+		cmds.WriteString(fmt.Sprintf("IPV6_DEFAULTGW=%s\n", nicDesc.Gateway6))
+	}
+	return cmds.String()
+}
+
 func (d *sDebianLikeRootFs) deployNetplanConfigFile(rootFs IDiskPartition, nics []*types.SServerNic) error {
 	netplanDir := "/etc/netplan/"
 	dirExists := rootFs.Exists(netplanDir, false)
@@ -908,6 +944,9 @@ func (d *sDebianLikeRootFs) DeployNetworkingScripts(rootFs IDiskPartition, nics
 				cmds.WriteString(fmt.Sprintf("    down route del -net %s gw %s || true\n", r[0], r[1]))
 			}
 			dnslist := netutils2.GetNicDns(nicDesc)
+			if len(nicDesc.Ip6) > 0 && len(nicDesc.Dns6) > 0 {
+				dnslist = append(dnslist, nicDesc.Dns6)
+			}
 			if len(dnslist) > 0 {
 				cmds.WriteString(fmt.Sprintf("    dns-nameservers %s\n", strings.Join(dnslist, " ")))
 				dnss = append(dnss, dnslist...)
@@ -920,12 +959,18 @@ func (d *sDebianLikeRootFs) DeployNetworkingScripts(rootFs IDiskPartition, nics
 				cmds.WriteString(getNicTeamingConfigCmds(nicDesc.TeamingSlaves))
 			}
 			cmds.WriteString("\n")
+			if len(nicDesc.Ip6) > 0 {
+				cmds.WriteString(getIfupdownIPv6Cmds(nicDesc, nicDesc.Ip == mainIp))
+			}
 		} else {
 			cmds.WriteString(fmt.Sprintf("iface %s inet dhcp\n", nicDesc.Name))
 			if len(nicDesc.TeamingSlaves) > 0 {
 				cmds.WriteString(getNicTeamingConfigCmds(nicDesc.TeamingSlaves))
 			}
 			cmds.WriteString("\n")
+			if len(nicDesc.Ip6) > 0 {
+				cmds.WriteString(getIfupdownIPv6Cmds(nicDesc, nicDesc.Ip == mainIp))
+			}
 		}
 	}
 
@@ -1373,6 +1418,9 @@ func (r *sRedhatLikeRootFs) deployNetworkingScripts(rootFs IDiskPartition, nics
 		} else {
 			cmds.WriteString("BOOTPROTO=dhcp\n")
 		}
+		if len(nicDesc.Ip6) > 0 && nicDesc.TeamingMaster == nil && !nicDesc.Virtual {
+			cmds.WriteString(getIfcfgIPv6Cmds(nicDesc, nicDesc.Ip == mainIp))
+		}
 		var fn = fmt.Sprintf("/etc/sysconfig/network-scripts/ifcfg-%s", nicDesc.Name)
 		log.Debugf("%s: %s", fn, cmds.String())
 		if err := rootFs.FilePutContents(fn, cmds.String(), false, false); err != nil {
@@ -121,5 +121,21 @@ func getNetplanEthernetConfig(nic *types.SServerNic, isBond bool) *netplan.Ether
 		nicConf = netplan.NewDHCP4EthernetConfig()
 	}
 
+	if len(nic.Ip6) > 0 && !nic.Virtual {
+		setNetplanIPv6Config(nicConf, nic)
+	}
+
 	return nicConf
 }
+
+func setNetplanIPv6Config(nicConf *netplan.EthernetConfig, nic *types.SServerNic) {
+	if nic.Manual {
+		nicConf.Addresses = append(nicConf.Addresses, fmt.Sprintf("%s/%d", nic.Ip6, nic.Masklen6))
+		if len(nic.Dns6) > 0 && nicConf.Nameservers != nil {
+			nicConf.Nameservers.Addresses = append(nicConf.Nameservers.Addresses, nic.Dns6)
+		}
+	} else {
+		nicConf.DHCP6 = true
+	}
+	nicConf.Gateway6 = nic.Gateway6
+}
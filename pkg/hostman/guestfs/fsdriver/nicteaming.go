@@ -72,6 +72,11 @@ func ToServerNics(nics []*deployapi.Nic) []*types.SServerNic {
 			LinkUp:    nics[i].LinkUp,
 			Mtu:       int16(nics[i].Mtu),
 			TeamWith:  nics[i].TeamWith,
+
+			Ip6:      nics[i].Ip6,
+			Gateway6: nics[i].Gateway6,
+			Masklen6: int(nics[i].Masklen6),
+			Dns6:     nics[i].Dns6,
 		}
 	}
 	return ret
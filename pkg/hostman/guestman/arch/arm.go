@@ -69,6 +69,11 @@ func (*ARM) GenerateMemDesc() *desc.SGuestMem {
 	}
 }
 
+// arm virt machine has no isa bus, use sysbus tpm-tis-device instead of tpm-crb
+func (*ARM) GenerateTpmDesc(socketPath string) *desc.SGuestTpm {
+	return newTpmDesc(socketPath, "tpm-tis-device")
+}
+
 func (*ARM) GenerateCpuDesc(cpus uint, cpuMax uint, s KVMGuestInstance) (*desc.SGuestCpu, error) {
 	var hostCPUPassthrough = options.HostOptions.HostCpuPassthrough
 	var accel, cpuType string
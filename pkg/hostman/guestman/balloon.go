@@ -0,0 +1,212 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"runtime/debug"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/shirou/gopsutil/mem"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+type balloonAction int
+
+const (
+	// shrink guests memory to give back to host
+	BALLOON_INFLATE balloonAction = iota
+	// grow guests memory back to max bound
+	BALLOON_DEFLATE
+	// only refresh actual memory, e.g. host memory pressure is between thresholds
+	BALLOON_HOLD
+)
+
+const BALLOON_QUERY_TIMEOUT = 10 * time.Second
+
+func (m *SGuestManager) StartMemoryBalloonPolicy() {
+	if !options.HostOptions.EnableMemoryBalloon {
+		return
+	}
+	if m.host.IsHugepagesEnabled() {
+		log.Infof("Hugepages enabled, skip memory balloon policy")
+		return
+	}
+	interval := options.HostOptions.MemoryBalloonIntervalSeconds
+	if interval <= 0 {
+		interval = 30
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				debug.PrintStack()
+				log.Errorf("Memory balloon policy failed %s", r)
+			}
+		}()
+		for {
+			time.Sleep(time.Duration(interval) * time.Second)
+			m.balloonPolicy()
+		}
+	}()
+}
+
+func (m *SGuestManager) balloonPolicy() {
+	info, err := mem.VirtualMemory()
+	if err != nil || info.Total == 0 {
+		log.Errorf("balloon policy get host memory: %v", err)
+		return
+	}
+	freePercent := int(info.Available * 100 / info.Total)
+	action := BALLOON_HOLD
+	if freePercent < options.HostOptions.MemoryBalloonInflateFreePercent {
+		action = BALLOON_INFLATE
+	} else if freePercent > options.HostOptions.MemoryBalloonDeflateFreePercent {
+		action = BALLOON_DEFLATE
+	}
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest := v.(*SKVMGuestInstance)
+		if err := guest.adjustBalloon(action); err != nil {
+			log.Errorf("guest %s adjust balloon: %s", guest.GetName(), err)
+		}
+		return true
+	})
+}
+
+// GetGuestMemoryStats returns actual memory of guests managed by balloon,
+// reported to region on host ping
+func (m *SGuestManager) GetGuestMemoryStats() []compute.SGuestMemoryStat {
+	stats := make([]compute.SGuestMemoryStat, 0)
+	m.Servers.Range(func(k, v interface{}) bool {
+		guest := v.(*SKVMGuestInstance)
+		actual := atomic.LoadInt64(&guest.balloonActualMb)
+		if actual <= 0 || !guest.isBalloonAdjustable() {
+			return true
+		}
+		stats = append(stats, compute.SGuestMemoryStat{
+			GuestId:         guest.Id,
+			MemSizeMb:       guest.Desc.Mem,
+			ActualMemSizeMb: actual,
+		})
+		return true
+	})
+	return stats
+}
+
+func (s *SKVMGuestInstance) isBalloonAdjustable() bool {
+	if s.Desc == nil || s.Desc.Balloon == nil {
+		return false
+	}
+	// memory of passthrough devices guest is pinned by vfio
+	if len(s.Desc.IsolatedDevices) > 0 {
+		return false
+	}
+	return s.IsRunning() && s.IsMonitorAlive() && !s.IsStopping() && s.MigrateTask == nil
+}
+
+// getBalloonBounds returns memory range in MB guest balloon is adjusted within
+func (s *SKVMGuestInstance) getBalloonBounds() (int64, int64) {
+	maxMb := s.Desc.Mem
+	if v, err := strconv.ParseInt(s.Desc.Metadata[compute.VM_METADATA_BALLOON_MAX_MEM_MB], 10, 64); err == nil && v > 0 && v < maxMb {
+		maxMb = v
+	}
+	minMb := s.Desc.Mem * int64(options.HostOptions.MemoryBalloonMinPercent) / 100
+	if v, err := strconv.ParseInt(s.Desc.Metadata[compute.VM_METADATA_BALLOON_MIN_MEM_MB], 10, 64); err == nil && v > 0 {
+		minMb = v
+	}
+	if minMb > maxMb {
+		minMb = maxMb
+	}
+	return minMb, maxMb
+}
+
+func (s *SKVMGuestInstance) adjustBalloon(action balloonAction) error {
+	if !s.isBalloonAdjustable() {
+		atomic.StoreInt64(&s.balloonActualMb, 0)
+		return nil
+	}
+	actual, err := s.getBalloonActualMb()
+	if err != nil {
+		return errors.Wrap(err, "query balloon")
+	}
+	atomic.StoreInt64(&s.balloonActualMb, actual)
+
+	minMb, maxMb := s.getBalloonBounds()
+	step := s.Desc.Mem * int64(options.HostOptions.MemoryBalloonStepPercent) / 100
+	target := actual
+	switch action {
+	case BALLOON_INFLATE:
+		target = actual - step
+	case BALLOON_DEFLATE:
+		target = actual + step
+	}
+	if target < minMb {
+		target = minMb
+	}
+	if target > maxMb {
+		target = maxMb
+	}
+	if target == actual {
+		return nil
+	}
+	log.Infof("guest %s balloon %dM -> %dM", s.GetName(), actual, target)
+	return s.setBalloon(target)
+}
+
+func (s *SKVMGuestInstance) getBalloonActualMb() (int64, error) {
+	errChan := make(chan error, 1)
+	var actual int64
+	s.Monitor.GetBalloonInfo(func(info *monitor.BalloonInfo, err string) {
+		if len(err) > 0 {
+			errChan <- errors.Error(err)
+			return
+		}
+		actual = info.Actual / 1024 / 1024
+		errChan <- nil
+	})
+	select {
+	case err := <-errChan:
+		return actual, err
+	case <-time.After(BALLOON_QUERY_TIMEOUT):
+		return 0, errors.ErrTimeout
+	}
+}
+
+func (s *SKVMGuestInstance) setBalloon(sizeMb int64) error {
+	errChan := make(chan error, 1)
+	s.Monitor.Balloon(sizeMb, func(res string) {
+		if len(res) > 0 {
+			errChan <- errors.Error(res)
+			return
+		}
+		errChan <- nil
+	})
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return err
+		}
+		atomic.StoreInt64(&s.balloonActualMb, sizeMb)
+		return nil
+	case <-time.After(BALLOON_QUERY_TIMEOUT):
+		return errors.ErrTimeout
+	}
+}
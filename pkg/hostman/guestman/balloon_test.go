@@ -0,0 +1,80 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+func TestSKVMGuestInstance_getBalloonBounds(t *testing.T) {
+	options.HostOptions.MemoryBalloonMinPercent = 50
+	cases := []struct {
+		name     string
+		metadata map[string]string
+		wantMin  int64
+		wantMax  int64
+	}{
+		{
+			name:     "default bounds",
+			metadata: map[string]string{},
+			wantMin:  2048,
+			wantMax:  4096,
+		},
+		{
+			name: "custom bounds",
+			metadata: map[string]string{
+				compute.VM_METADATA_BALLOON_MIN_MEM_MB: "1024",
+				compute.VM_METADATA_BALLOON_MAX_MEM_MB: "3072",
+			},
+			wantMin: 1024,
+			wantMax: 3072,
+		},
+		{
+			name: "max exceeds guest memory",
+			metadata: map[string]string{
+				compute.VM_METADATA_BALLOON_MAX_MEM_MB: "8192",
+			},
+			wantMin: 2048,
+			wantMax: 4096,
+		},
+		{
+			name: "min exceeds max",
+			metadata: map[string]string{
+				compute.VM_METADATA_BALLOON_MIN_MEM_MB: "3072",
+				compute.VM_METADATA_BALLOON_MAX_MEM_MB: "2048",
+			},
+			wantMin: 2048,
+			wantMax: 2048,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &SKVMGuestInstance{
+				Desc: &desc.SGuestDesc{
+					SGuestHardwareDesc: desc.SGuestHardwareDesc{Mem: 4096},
+					SGuestMetaDesc:     desc.SGuestMetaDesc{Metadata: c.metadata},
+				},
+			}
+			minMb, maxMb := s.getBalloonBounds()
+			if minMb != c.wantMin || maxMb != c.wantMax {
+				t.Errorf("got bounds [%d, %d], want [%d, %d]", minMb, maxMb, c.wantMin, c.wantMax)
+			}
+		})
+	}
+}
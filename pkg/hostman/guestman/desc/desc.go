@@ -103,12 +103,15 @@ type SGuestHardwareDesc struct {
 	Disks           []*SGuestDisk           `json:",omitempty"`
 	Nics            []*SGuestNetwork        `json:",omitempty"`
 	IsolatedDevices []*SGuestIsolatedDevice `json:",omitempty"`
+	FileShares      []*SGuestFileShare      `json:",omitempty"`
 
 	// Random Number Generator Device
 	Rng       *SGuestRng       `json:",omitempty"`
 	Qga       *SGuestQga       `json:",omitempty"`
 	Pvpanic   *SGuestPvpanic   `json:",omitempty"`
 	IsaSerial *SGuestIsaSerial `json:",omitempty"`
+	Tpm       *SGuestTpm       `json:",omitempty"`
+	Balloon   *SGuestBalloon   `json:",omitempty"`
 
 	Usb            *UsbController   `json:",omitempty"`
 	PCIControllers []*PCIController `json:",omitempty"`
@@ -133,6 +136,13 @@ type VirtSerialPort struct {
 	Options map[string]string `json:",omitempty"`
 }
 
+// SGuestTpm is the tpm device backed by swtpm emulator
+type SGuestTpm struct {
+	Socket  *CharDev
+	Id      string
+	DevType string
+}
+
 type SGuestPvpanic struct {
 	Ioport uint // default ioport 1285(0x505)
 	Id     string
@@ -152,6 +162,8 @@ type SGuestMachine struct {
 
 	// arm only
 	GicVersion *string `json:",omitempty"`
+	// x86 only, required by secure boot
+	Smm bool `json:",omitempty"`
 }
 
 type SGuestDisk struct {
@@ -196,6 +208,14 @@ type SGuestNetwork struct {
 	Pci *PCIDevice `json:",omitempty"`
 }
 
+// SGuestFileShare is a vhost-user-fs device connected to virtiofsd
+type SGuestFileShare struct {
+	api.GuestFileShareJsonDesc
+
+	Socket *CharDev   `json:",omitempty"`
+	Pci    *PCIDevice `json:",omitempty"`
+}
+
 type VFIODevice struct {
 	*PCIDevice
 
@@ -219,6 +239,10 @@ type SGuestRng struct {
 	RngRandom *Object
 }
 
+type SGuestBalloon struct {
+	*PCIDevice `json:",omitempty"`
+}
+
 type SoundCard struct {
 	*PCIDevice `json:",omitempty"`
 	Codec      *Codec
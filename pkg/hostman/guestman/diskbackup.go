@@ -0,0 +1,264 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+// persistent dirty bitmap named after a backup tracks guest writes since the
+// backup started, next incremental backup copies only clusters it marked
+const DISK_BACKUP_BITMAP_PREFIX = "backup-"
+
+func diskBackupBitmapName(backupId string) string {
+	return DISK_BACKUP_BITMAP_PREFIX + backupId
+}
+
+func diskBackupJobId(backupId string) string {
+	return "diskbackup-" + backupId
+}
+
+// selectDiskBackupBitmap returns bitmap of parent backup if incremental backup
+// could be based on it, and backup bitmaps not needed any more. Bitmap is lost
+// after migration and inconsistent after qemu crashed, backup falls back to full
+func selectDiskBackupBitmap(bitmaps []monitor.BlockDirtyBitmap, parentBackupId string) (string, []string) {
+	var (
+		parent = ""
+		stale  = []string{}
+	)
+	for _, bitmap := range bitmaps {
+		if !strings.HasPrefix(bitmap.Name, DISK_BACKUP_BITMAP_PREFIX) {
+			continue
+		}
+		if len(parentBackupId) > 0 && bitmap.Name == diskBackupBitmapName(parentBackupId) &&
+			!bitmap.Inconsistent && !bitmap.Busy && bitmap.Recording {
+			parent = bitmap.Name
+			continue
+		}
+		stale = append(stale, bitmap.Name)
+	}
+	return parent, stale
+}
+
+func (s *SKVMGuestInstance) ExecDiskLiveBackupTask(ctx context.Context, params *SDiskLiveBackup) (jsonutils.JSONObject, error) {
+	if !s.IsRunning() {
+		return nil, errors.Errorf("guest %s is not running", s.GetName())
+	}
+	var disk storageman.IDisk
+	for _, d := range s.Desc.Disks {
+		if d.DiskId == params.DiskId {
+			var err error
+			disk, err = storageman.GetManager().GetDiskByPath(d.Path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "GetDiskByPath(%s)", d.Path)
+			}
+			break
+		}
+	}
+	if disk == nil {
+		return nil, errors.Wrapf(errors.ErrNotFound, "disk %s", params.DiskId)
+	}
+	NewGuestDiskBackupTask(ctx, s, disk, params).Start()
+	return nil, nil
+}
+
+func (s *SKVMGuestInstance) eventBackupJobFinished(event *monitor.Event) {
+	if itype, _ := event.Data["type"].(string); itype != "backup" {
+		return
+	}
+	jobId, _ := event.Data["device"].(string)
+	cb, ok := s.diskBackupJobs.LoadAndDelete(jobId)
+	if !ok {
+		return
+	}
+	reason, _ := event.Data["error"].(string)
+	if event.Event == `"BLOCK_JOB_CANCELLED"` {
+		reason = "backup job cancelled"
+	}
+	go cb.(func(string))(reason)
+}
+
+/**
+ *  GuestDiskBackupTask
+**/
+
+type SGuestDiskBackupTask struct {
+	*SGuestReloadDiskTask
+
+	params *SDiskLiveBackup
+	device string
+	target string
+	// bitmap of parent backup, full backup if empty
+	parentBitmap string
+}
+
+func NewGuestDiskBackupTask(
+	ctx context.Context, s *SKVMGuestInstance, disk storageman.IDisk, params *SDiskLiveBackup,
+) *SGuestDiskBackupTask {
+	return &SGuestDiskBackupTask{
+		SGuestReloadDiskTask: NewGuestReloadDiskTask(ctx, s, disk),
+		params:               params,
+		target:               path.Join(disk.GetStorage().GetBackupDir(), params.BackupId),
+	}
+}
+
+func (s *SGuestDiskBackupTask) Start() {
+	s.Monitor.GetBlocks(func(blocks []monitor.QemuBlock) {
+		for i := range blocks {
+			if device := s.getDiskOfDrive(blocks[i]); len(device) > 0 {
+				s.device = device
+				parent, stale := selectDiskBackupBitmap(blocks[i].GetDirtyBitmaps(), s.params.ParentBackupId)
+				if len(s.params.ParentBackupId) > 0 && len(parent) == 0 {
+					log.Warningf("bitmap of backup %s of disk %s unusable, fallback to full backup", s.params.ParentBackupId, s.disk.GetId())
+				}
+				s.parentBitmap = parent
+				s.removeBitmaps(stale, s.startBackup)
+				return
+			}
+		}
+		s.taskFailed("Device not found")
+	})
+}
+
+func (s *SGuestDiskBackupTask) removeBitmaps(bitmaps []string, callback func()) {
+	if len(bitmaps) == 0 {
+		callback()
+		return
+	}
+	s.Monitor.BlockDirtyBitmapRemove(s.device, bitmaps[0], func(res string) {
+		if len(res) > 0 {
+			log.Errorf("remove bitmap %s of %s: %s", bitmaps[0], s.device, res)
+		}
+		s.removeBitmaps(bitmaps[1:], callback)
+	})
+}
+
+func (s *SGuestDiskBackupTask) startBackup() {
+	backupDir := path.Dir(s.target)
+	if !fileutils2.Exists(backupDir) {
+		output, err := procutils.NewCommand("mkdir", "-p", backupDir).Output()
+		if err != nil {
+			s.taskFailed(fmt.Sprintf("mkdir %s failed: %s", backupDir, output))
+			return
+		}
+	}
+	backupArgs := map[string]interface{}{
+		"device":   s.device,
+		"job-id":   diskBackupJobId(s.params.BackupId),
+		"target":   s.target,
+		"format":   "qcow2",
+		"mode":     "absolute-paths",
+		"sync":     "full",
+		"compress": true,
+	}
+	if len(s.parentBitmap) > 0 {
+		backupArgs["sync"] = "incremental"
+		backupArgs["bitmap"] = s.parentBitmap
+	}
+	// bitmap of this backup starts tracking at the same point backup copying
+	actions := []monitor.TransactionAction{
+		{
+			Type: "block-dirty-bitmap-add",
+			Data: map[string]interface{}{
+				"node":       s.device,
+				"name":       diskBackupBitmapName(s.params.BackupId),
+				"persistent": true,
+			},
+		},
+		{
+			Type: "drive-backup",
+			Data: backupArgs,
+		},
+	}
+	s.diskBackupJobs.Store(diskBackupJobId(s.params.BackupId), s.onBackupJobFinished)
+	s.Monitor.Transaction(actions, func(res string) {
+		if len(res) > 0 {
+			s.diskBackupJobs.Delete(diskBackupJobId(s.params.BackupId))
+			s.taskFailed(fmt.Sprintf("start backup job: %s", res))
+		}
+	})
+}
+
+func (s *SGuestDiskBackupTask) onBackupJobFinished(reason string) {
+	if len(reason) > 0 {
+		// dirty bits of parent bitmap are merged back on failure
+		s.Monitor.BlockDirtyBitmapRemove(s.device, diskBackupBitmapName(s.params.BackupId), func(res string) {
+			if len(res) > 0 {
+				log.Errorf("remove bitmap of failed backup %s: %s", s.params.BackupId, res)
+			}
+		})
+		if output, err := procutils.NewCommand("rm", "-f", s.target).Output(); err != nil {
+			log.Errorf("rm %s failed: %s", s.target, output)
+		}
+		s.taskFailed(fmt.Sprintf("backup job failed: %s", reason))
+		return
+	}
+	if len(s.parentBitmap) > 0 {
+		s.removeBitmaps([]string{s.parentBitmap}, s.saveBackup)
+	} else {
+		s.saveBackup()
+	}
+}
+
+func (s *SGuestDiskBackupTask) saveBackup() {
+	res, err := s.doSaveBackup()
+	if err != nil {
+		s.taskFailed(err.Error())
+		return
+	}
+	hostutils.TaskComplete(s.ctx, res)
+}
+
+func (s *SGuestDiskBackupTask) doSaveBackup() (jsonutils.JSONObject, error) {
+	img, err := qemuimg.NewQemuImage(s.target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewQemuImage %s", s.target)
+	}
+	res := jsonutils.NewDict()
+	res.Set("backup_mode", jsonutils.NewString(api.BACKUP_MODE_FULL))
+	if len(s.parentBitmap) > 0 {
+		// backups of a chain are stored side by side in backup storage
+		if err := img.Rebase(s.params.ParentBackupId, true); err != nil {
+			return nil, errors.Wrapf(err, "rebase %s to parent backup", s.target)
+		}
+		res.Set("backup_mode", jsonutils.NewString(api.BACKUP_MODE_INCREMENTAL))
+		res.Set("parent_backup_id", jsonutils.NewString(s.params.ParentBackupId))
+	}
+	res.Set("size_mb", jsonutils.NewInt(int64(img.GetActualSizeMB())))
+	_, err = s.disk.GetStorage().StorageBackup(s.ctx, &storageman.SStorageBackup{
+		BackupId:                s.params.BackupId,
+		BackupStorageId:         s.params.BackupStorageId,
+		BackupStorageAccessInfo: s.params.BackupStorageAccessInfo,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to SStorageBackup")
+	}
+	return res, nil
+}
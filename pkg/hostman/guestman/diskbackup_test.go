@@ -0,0 +1,73 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"reflect"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+)
+
+func TestSelectDiskBackupBitmap(t *testing.T) {
+	cases := []struct {
+		name    string
+		bitmaps []monitor.BlockDirtyBitmap
+		parent  string
+		want    string
+		stale   []string
+	}{
+		{
+			name: "incremental",
+			bitmaps: []monitor.BlockDirtyBitmap{
+				{Name: "backup-b1", Recording: true, Persistent: true},
+				{Name: "backup-b0", Recording: true, Persistent: true},
+				{Name: "other", Recording: true},
+			},
+			parent: "b1",
+			want:   "backup-b1",
+			stale:  []string{"backup-b0"},
+		},
+		{
+			name: "inconsistent after crash",
+			bitmaps: []monitor.BlockDirtyBitmap{
+				{Name: "backup-b1", Persistent: true, Inconsistent: true},
+			},
+			parent: "b1",
+			want:   "",
+			stale:  []string{"backup-b1"},
+		},
+		{
+			name:   "lost after migration",
+			parent: "b1",
+			want:   "",
+			stale:  []string{},
+		},
+		{
+			name: "full",
+			bitmaps: []monitor.BlockDirtyBitmap{
+				{Name: "backup-b1", Recording: true, Persistent: true},
+			},
+			want:  "",
+			stale: []string{"backup-b1"},
+		},
+	}
+	for _, c := range cases {
+		got, stale := selectDiskBackupBitmap(c.bitmaps, c.parent)
+		if got != c.want || !reflect.DeepEqual(stale, c.stale) {
+			t.Errorf("%s: got %q %v, want %q %v", c.name, got, stale, c.want, c.stale)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/monitor/qga"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/httperrors"
+)
+
+// qga may not be installed in guest, ping it before freezing instead of
+// waiting for the whole freeze timeout
+const FSFREEZE_QGA_PING_TIMEOUT_MS = 3000
+
+// sGuestFsfreeze shares one fsfreeze among concurrent snapshots of a guest,
+// filesystems are thawed once the last snapshot finished, or forcibly
+// after they stayed frozen longer than timeout
+type sGuestFsfreeze struct {
+	lock sync.Mutex
+
+	count int
+	// epoch changes on every freeze and forced thaw, snapshot holding
+	// a stale epoch was not taken while filesystems frozen
+	epoch int
+	timer *time.Timer
+
+	timeout  time.Duration
+	doFreeze func() error
+	doThaw   func() error
+}
+
+func newGuestFsfreeze(timeout time.Duration, doFreeze, doThaw func() error) *sGuestFsfreeze {
+	return &sGuestFsfreeze{
+		timeout:  timeout,
+		doFreeze: doFreeze,
+		doThaw:   doThaw,
+	}
+}
+
+// freeze returns epoch of the freeze, zero means filesystems are not frozen
+// and snapshot falls back to crash-consistent
+func (f *sGuestFsfreeze) freeze() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.count > 0 {
+		f.count++
+		return f.epoch
+	}
+	if err := f.doFreeze(); err != nil {
+		log.Warningf("fsfreeze failed, fallback to crash-consistent snapshot: %s", err)
+		return 0
+	}
+	f.epoch++
+	f.count = 1
+	epoch := f.epoch
+	f.timer = time.AfterFunc(f.timeout, func() { f.expire(epoch) })
+	return epoch
+}
+
+// thaw returns whether filesystems stayed frozen since the freeze of epoch
+func (f *sGuestFsfreeze) thaw(epoch int) bool {
+	if epoch == 0 {
+		return false
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if epoch != f.epoch || f.count == 0 {
+		return false
+	}
+	f.count--
+	if f.count > 0 {
+		return true
+	}
+	f.timer.Stop()
+	if err := f.doThaw(); err != nil {
+		log.Errorf("fsfreeze thaw: %s", err)
+	}
+	return true
+}
+
+func (f *sGuestFsfreeze) expire(epoch int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if epoch != f.epoch || f.count == 0 {
+		return
+	}
+	log.Errorf("guest filesystems frozen longer than %s, force thaw", f.timeout)
+	f.count = 0
+	f.epoch++
+	if err := f.doThaw(); err != nil {
+		log.Errorf("fsfreeze force thaw: %s", err)
+	}
+}
+
+func (s *SKVMGuestInstance) initFsfreeze() {
+	s.fsfreeze = newGuestFsfreeze(
+		time.Duration(options.HostOptions.FsfreezeTimeoutSeconds)*time.Second,
+		s.qgaFsfreezeFreeze, s.qgaFsfreezeThaw,
+	)
+}
+
+// execGuestAgent waits qga finishing last command rather than failing at once,
+// thaw must not be skipped because of a concurrent qga command
+func (s *SKVMGuestInstance) execGuestAgent(timeout time.Duration, f func(agent *qga.QemuGuestAgent) error) error {
+	if s.guestAgent == nil {
+		if err := s.InitQga(); err != nil {
+			return errors.Wrap(err, "init qga")
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	for !s.guestAgent.TryLock() {
+		if time.Now().After(deadline) {
+			return errors.Wrap(errors.ErrTimeout, "qga unfinished last cmd")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	defer s.guestAgent.Unlock()
+	return f(s.guestAgent)
+}
+
+func (s *SKVMGuestInstance) qgaFsfreezeFreeze() error {
+	timeout := time.Duration(options.HostOptions.FsfreezeTimeoutSeconds) * time.Second
+	return s.execGuestAgent(timeout, func(agent *qga.QemuGuestAgent) error {
+		if err := agent.GuestPing(FSFREEZE_QGA_PING_TIMEOUT_MS); err != nil {
+			return errors.Wrap(err, "qga guest ping")
+		}
+		cnt, err := agent.GuestFsfreezeFreeze(int(timeout / time.Millisecond))
+		if err != nil {
+			// freeze timed out may still complete inside guest
+			if _, e := agent.GuestFsfreezeThaw(-1); e != nil {
+				log.Errorf("qga fsfreeze thaw after failed freeze: %s", e)
+			}
+			return errors.Wrap(err, "qga fsfreeze freeze")
+		}
+		if cnt == 0 {
+			return errors.Errorf("no filesystem frozen")
+		}
+		log.Infof("guest %s frozen %d filesystems", s.GetName(), cnt)
+		return nil
+	})
+}
+
+func (s *SKVMGuestInstance) qgaFsfreezeThaw() error {
+	timeout := time.Duration(options.HostOptions.FsfreezeTimeoutSeconds) * time.Second
+	return s.execGuestAgent(timeout, func(agent *qga.QemuGuestAgent) error {
+		cnt, err := agent.GuestFsfreezeThaw(int(timeout / time.Millisecond))
+		if err != nil {
+			return errors.Wrap(err, "qga fsfreeze thaw")
+		}
+		log.Infof("guest %s thawed %d filesystems", s.GetName(), cnt)
+		return nil
+	})
+}
+
+// FsfreezeFreeze freezes filesystems of the guest across snapshots of all its
+// disks, epoch returned is passed to FsfreezeThaw once all disks snapshotted
+func (m *SGuestManager) FsfreezeFreeze(sid string) (*hostapi.GuestFsfreezeResponse, error) {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return nil, httperrors.NewNotFoundError("Not found")
+	}
+	resp := &hostapi.GuestFsfreezeResponse{}
+	if guest.IsRunning() {
+		resp.Running = true
+		resp.Epoch = guest.fsfreeze.freeze()
+	}
+	return resp, nil
+}
+
+// FsfreezeThaw returns whether filesystems stayed frozen since the freeze of epoch
+func (m *SGuestManager) FsfreezeThaw(sid string, epoch int) (bool, error) {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return false, httperrors.NewNotFoundError("Not found")
+	}
+	return guest.fsfreeze.thaw(epoch), nil
+}
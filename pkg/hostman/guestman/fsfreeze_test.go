@@ -0,0 +1,102 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"testing"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+)
+
+func TestSGuestFsfreeze(t *testing.T) {
+	var frozen, thawed int
+	var freezeErr error
+	f := newGuestFsfreeze(time.Hour,
+		func() error {
+			if freezeErr != nil {
+				return freezeErr
+			}
+			frozen++
+			return nil
+		},
+		func() error {
+			thawed++
+			return nil
+		},
+	)
+
+	// concurrent snapshots share one freeze
+	e1 := f.freeze()
+	e2 := f.freeze()
+	if e1 == 0 || e1 != e2 || frozen != 1 {
+		t.Fatalf("freeze epoch %d %d, frozen %d", e1, e2, frozen)
+	}
+	if !f.thaw(e1) || thawed != 0 {
+		t.Fatalf("thawed %d before last snapshot finished", thawed)
+	}
+	if !f.thaw(e2) || thawed != 1 {
+		t.Fatalf("thawed %d after last snapshot finished", thawed)
+	}
+
+	// forced thaw makes snapshot crash-consistent
+	e3 := f.freeze()
+	f.expire(e3)
+	if thawed != 2 {
+		t.Fatalf("thawed %d after expire", thawed)
+	}
+	if f.thaw(e3) || thawed != 2 {
+		t.Fatalf("snapshot of expired freeze is consistent")
+	}
+
+	// fallback when agent absent
+	freezeErr = errors.ErrTimeout
+	e4 := f.freeze()
+	if e4 != 0 || f.thaw(e4) || thawed != 2 {
+		t.Fatalf("failed freeze epoch %d thawed %d", e4, thawed)
+	}
+}
+
+func TestSGuestFsfreezeAcrossDisks(t *testing.T) {
+	var frozen, thawed int
+	f := newGuestFsfreeze(time.Hour,
+		func() error {
+			frozen++
+			return nil
+		},
+		func() error {
+			thawed++
+			return nil
+		},
+	)
+
+	// instance snapshot holds the freeze while disks are snapshotted one by one
+	epoch := f.freeze()
+	for i := 0; i < 3; i++ {
+		diskEpoch := f.freeze()
+		if diskEpoch != epoch {
+			t.Fatalf("disk %d epoch %d, want %d", i, diskEpoch, epoch)
+		}
+		if !f.thaw(diskEpoch) {
+			t.Fatalf("disk %d snapshot not consistent", i)
+		}
+	}
+	if frozen != 1 || thawed != 0 {
+		t.Fatalf("frozen %d thawed %d before all disks snapshotted", frozen, thawed)
+	}
+	if !f.thaw(epoch) || thawed != 1 {
+		t.Fatalf("instance snapshot not consistent, thawed %d", thawed)
+	}
+}
@@ -62,47 +62,52 @@ func AddGuestTaskHandler(prefix string, app *appsrv.Application) {
 			auth.Authenticate(deleteGuest))
 
 		for action, f := range map[string]actionFunc{
-			"create":                   guestCreate,
-			"deploy":                   guestDeploy,
-			"rebuild":                  guestRebuild,
-			"start":                    guestStart,
-			"stop":                     guestStop,
-			"monitor":                  guestMonitor,
-			"sync":                     guestSync,
-			"suspend":                  guestSuspend,
-			"io-throttle":              guestIoThrottle,
-			"snapshot":                 guestSnapshot,
-			"delete-snapshot":          guestDeleteSnapshot,
-			"reload-disk-snapshot":     guestReloadDiskSnapshot,
-			"src-prepare-migrate":      guestSrcPrepareMigrate,
-			"dest-prepare-migrate":     guestDestPrepareMigrate,
-			"live-migrate":             guestLiveMigrate,
-			"cancel-live-migrate":      guestCancelLiveMigrate,
-			"resume":                   guestResume,
-			"block-replication":        guestBlockReplication,
-			"slave-block-stream-disks": slaveGuestBlockStreamDisks,
-			"hotplug-cpu-mem":          guestHotplugCpuMem,
-			"cancel-block-jobs":        guestCancelBlockJobs,
-			"cancel-block-replication": guestCancelBlockReplication,
-			"create-from-libvirt":      guestCreateFromLibvirt,
-			"create-form-esxi":         guestCreateFromEsxi,
-			"open-forward":             guestOpenForward,
-			"list-forward":             guestListForward,
-			"close-forward":            guestCloseForward,
-			"storage-clone-disk":       guestStorageCloneDisk,
-			"live-change-disk":         guestLiveChangeDisk,
-			"cpuset":                   guestCPUSet,
-			"cpuset-remove":            guestCPUSetRemove,
-			"memory-snapshot":          guestMemorySnapshot,
-			"memory-snapshot-reset":    guestMemorySnapshotReset,
-			"qga-set-password":         qgaGuestSetPassword,
-			"qga-guest-ping":           qgaGuestPing,
-			"qga-command":              qgaCommand,
-			"reset-nic-traffic-limit":  guestResetNicTrafficLimit,
-			"set-nic-traffic-limit":    guestSetNicTrafficLimit,
-			"qga-guest-info-task":      qgaGuestInfoTask,
-			"qga-get-network":          qgaGetNetwork,
-			"qga-set-network":          qgaSetNetwork,
+			"create":                    guestCreate,
+			"deploy":                    guestDeploy,
+			"rebuild":                   guestRebuild,
+			"start":                     guestStart,
+			"stop":                      guestStop,
+			"monitor":                   guestMonitor,
+			"sync":                      guestSync,
+			"suspend":                   guestSuspend,
+			"io-throttle":               guestIoThrottle,
+			"snapshot":                  guestSnapshot,
+			"disk-backup":               guestDiskBackup,
+			"delete-snapshot":           guestDeleteSnapshot,
+			"reload-disk-snapshot":      guestReloadDiskSnapshot,
+			"src-prepare-migrate":       guestSrcPrepareMigrate,
+			"dest-prepare-migrate":      guestDestPrepareMigrate,
+			"live-migrate":              guestLiveMigrate,
+			"cancel-live-migrate":       guestCancelLiveMigrate,
+			"resume":                    guestResume,
+			"block-replication":         guestBlockReplication,
+			"slave-block-stream-disks":  slaveGuestBlockStreamDisks,
+			"hotplug-cpu-mem":           guestHotplugCpuMem,
+			"cancel-block-jobs":         guestCancelBlockJobs,
+			"cancel-block-replication":  guestCancelBlockReplication,
+			"create-from-libvirt":       guestCreateFromLibvirt,
+			"create-form-esxi":          guestCreateFromEsxi,
+			"open-forward":              guestOpenForward,
+			"list-forward":              guestListForward,
+			"close-forward":             guestCloseForward,
+			"storage-clone-disk":        guestStorageCloneDisk,
+			"live-change-disk":          guestLiveChangeDisk,
+			"cancel-storage-clone-disk": guestCancelStorageCloneDisk,
+			"cpuset":                    guestCPUSet,
+			"cpuset-remove":             guestCPUSetRemove,
+			"memory-snapshot":           guestMemorySnapshot,
+			"memory-snapshot-reset":     guestMemorySnapshotReset,
+			"qga-set-password":          qgaGuestSetPassword,
+			"qga-guest-ping":            qgaGuestPing,
+			"qga-command":               qgaCommand,
+			"reset-nic-traffic-limit":   guestResetNicTrafficLimit,
+			"set-nic-traffic-limit":     guestSetNicTrafficLimit,
+			"qga-guest-info-task":       qgaGuestInfoTask,
+			"qga-get-network":           qgaGetNetwork,
+			"qga-set-network":           qgaSetNetwork,
+			"fsfreeze-freeze":           guestFsfreezeFreeze,
+			"fsfreeze-thaw":             guestFsfreezeThaw,
+			"activate-slvm-disks":       guestActivateSLVMDisks,
 		} {
 			app.AddHandler("POST",
 				fmt.Sprintf("%s/%s/<sid>/%s", prefix, keyWord, action),
@@ -432,6 +437,8 @@ func guestDestPrepareMigrateInternal(ctx context.Context, userCred mcclient.Toke
 	msIds, _ := jsonutils.GetStringArray(body, "src_memory_snapshots")
 	params.SrcMemorySnapshots = msIds
 
+	params.VtpmUri, _ = body.GetString("vtpm_uri")
+
 	params.UserCred = userCred
 
 	hostutils.DelayTask(ctx, guestman.GetGuestManager().DestPrepareMigrate, params)
@@ -507,6 +514,14 @@ func guestResume(ctx context.Context, userCred mcclient.TokenCredential, sid str
 	return nil, nil
 }
 
+func guestActivateSLVMDisks(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	exclusive := jsonutils.QueryBoolean(body, "exclusive", false)
+	if err := guestman.GetGuestManager().ActivateSLVMDisks(sid, exclusive); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 // func guestStartNbdServer(ctx context.Context, sid string, body jsonutils.JSONObject) (interface{}, error) {
 // 	if !guestManger.IsGuestExist(sid) {
 // 		return nil, httperrors.NewNotFoundError("Guest %s not found", sid)
@@ -647,10 +662,34 @@ func guestSnapshot(ctx context.Context, userCred mcclient.TokenCredential, sid s
 		Sid:        sid,
 		SnapshotId: snapshotId,
 		Disk:       disk,
+		Consistent: jsonutils.QueryBoolean(body, "consistent", false),
 	})
 	return nil, nil
 }
 
+func guestDiskBackup(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	params := new(guestman.SDiskLiveBackup)
+	if err := body.Unmarshal(params); err != nil {
+		return nil, httperrors.NewInputParameterError("unmarshal params: %s", err)
+	}
+	if len(params.DiskId) == 0 {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	if len(params.BackupId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_id")
+	}
+	if len(params.BackupStorageId) == 0 {
+		return nil, httperrors.NewMissingParameterError("backup_storage_id")
+	}
+	if _, ok := guestman.GetGuestManager().GetServer(sid); !ok {
+		return nil, httperrors.NewNotFoundError("guest %s not found", sid)
+	}
+	params.Sid = sid
+	params.UserCred = userCred
+	hostutils.DelayTask(ctx, guestman.GetGuestManager().DoDiskLiveBackup, params)
+	return nil, nil
+}
+
 func guestDeleteSnapshot(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	deleteSnapshot, err := body.GetString("delete_snapshot")
 	if err != nil {
@@ -731,6 +770,7 @@ func formatCloneDiskParams(sid string, body jsonutils.JSONObject) (*guestman.SSt
 		TargetDiskId:       input.TargetDiskId,
 		DiskFormat:         input.DiskFormat,
 		TargetDiskDesc:     input.TargetDiskDesc,
+		MaxBandwidthMb:     input.MaxBandwidthMb,
 		CompletedDiskCount: input.CompletedDiskCount,
 		CloneDiskCount:     input.CloneDiskCount,
 	}
@@ -757,6 +797,21 @@ func guestLiveChangeDisk(ctx context.Context, userCred mcclient.TokenCredential,
 	return nil, nil
 }
 
+func guestCancelStorageCloneDisk(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	diskId, err := body.GetString("disk_id")
+	if err != nil {
+		return nil, httperrors.NewMissingParameterError("disk_id")
+	}
+	if !guestman.GetGuestManager().IsGuestExist(sid) {
+		return nil, httperrors.NewNotFoundError("Guest %s not found", sid)
+	}
+	hostutils.DelayTaskWithoutReqctx(ctx, guestman.GetGuestManager().CancelStorageCloneDisk, &guestman.SCancelStorageCloneDisk{
+		ServerId: sid,
+		DiskId:   diskId,
+	})
+	return nil, nil
+}
+
 func guestCPUSet(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	input := new(computeapi.ServerCPUSetInput)
 	if err := body.Unmarshal(input); err != nil {
@@ -787,6 +842,24 @@ func guestMemorySnapshot(ctx context.Context, userCred mcclient.TokenCredential,
 	return nil, nil
 }
 
+func guestFsfreezeFreeze(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	gm := guestman.GetGuestManager()
+	return gm.FsfreezeFreeze(sid)
+}
+
+func guestFsfreezeThaw(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
+	input := new(hostapi.GuestFsthawRequest)
+	if err := body.Unmarshal(input); err != nil {
+		return nil, err
+	}
+	gm := guestman.GetGuestManager()
+	consistent, err := gm.FsfreezeThaw(sid, input.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &hostapi.GuestFsthawResponse{Consistent: consistent}, nil
+}
+
 func guestMemorySnapshotReset(ctx context.Context, userCred mcclient.TokenCredential, sid string, body jsonutils.JSONObject) (interface{}, error) {
 	input := new(hostapi.GuestMemorySnapshotResetRequest)
 	if err := body.Unmarshal(input); err != nil {
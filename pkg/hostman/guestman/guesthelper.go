@@ -16,6 +16,7 @@ package guestman
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
 
 	"yunion.io/x/cloudmux/pkg/multicloud/esxi/vcenter"
@@ -72,6 +73,8 @@ type SDestPrepareMigrate struct {
 	MemorySnapshotsUri string
 	SrcMemorySnapshots []string
 
+	VtpmUri string
+
 	UserCred mcclient.TokenCredential
 }
 
@@ -108,6 +111,16 @@ type SDiskSnapshot struct {
 	Sid        string
 	SnapshotId string
 	Disk       storageman.IDisk
+	// freeze guest filesystems via qga while taking snapshot
+	Consistent bool
+}
+
+type SDiskLiveBackup struct {
+	storageman.SDiskBakcup
+	Sid    string `json:"-"`
+	DiskId string `json:"disk_id"`
+	// backup incrementally since parent backup, full backup if empty
+	ParentBackupId string `json:"parent_backup_id"`
 }
 
 type SMemorySnapshot struct {
@@ -279,6 +292,21 @@ func (pq *CpuSetCounter) ReleaseCpus(cpus []int, vcpuCount int) {
 	}
 }
 
+func (pq *CpuSetCounter) GetNumaNodeStats() []compute.SHostNumaNodeStat {
+	pq.Lock.Lock()
+	defer pq.Lock.Unlock()
+	stats := make([]compute.SHostNumaNodeStat, len(pq.Nodes))
+	for i := range pq.Nodes {
+		stats[i] = compute.SHostNumaNodeStat{
+			NodeId:    pq.Nodes[i].NodeId,
+			CpuCount:  pq.Nodes[i].CpuCount,
+			VcpuCount: pq.Nodes[i].VcpuCount,
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].NodeId < stats[j].NodeId })
+	return stats
+}
+
 func (pq *CpuSetCounter) LoadCpus(cpus []int, vcpuCpunt int) {
 	pq.Lock.Lock()
 	defer pq.Lock.Unlock()
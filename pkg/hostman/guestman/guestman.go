@@ -127,6 +127,10 @@ func NewGuestManager(host hostutils.IHost, serversPath string) *SGuestManager {
 	return manager
 }
 
+func (m *SGuestManager) GetNumaNodeStats() []compute.SHostNumaNodeStat {
+	return m.cpuSet.GetNumaNodeStats()
+}
+
 func (m *SGuestManager) InitQemuMaxCpus(machineCaps []monitor.MachineInfo, kvmMaxCpus uint) {
 	m.qemuMachineCpuMax[compute.VM_MACHINE_TYPE_PC] = arch.X86_MAX_CPUS
 	m.qemuMachineCpuMax[compute.VM_MACHINE_TYPE_Q35] = arch.X86_MAX_CPUS
@@ -315,6 +319,7 @@ func (m *SGuestManager) OnLoadExistingGuestsComplete() {
 	}
 
 	go m.verifyDirtyServers()
+	m.StartMemoryBalloonPolicy()
 
 	if !options.HostOptions.EnableCpuBinding {
 		m.ClenaupCpuset()
@@ -1047,7 +1052,17 @@ func (m *SGuestManager) DestPrepareMigrate(ctx context.Context, params interface
 		body.Add(jsonutils.Marshal(preparedMs), "dest_prepared_memory_snapshots")
 	}
 
+	if !migParams.LiveMigrate {
+		if err := guest.prepareMigrateVtpmState(ctx, migParams.VtpmUri); err != nil {
+			return nil, errors.Wrap(err, "prepare migrate vtpm state")
+		}
+	}
+
 	if migParams.LiveMigrate {
+		// source guest keeps lvs of shared vg opened until migration completed
+		if err := guest.activateSLVMDisks(false); err != nil {
+			return nil, errors.Wrap(err, "activate slvm disks shared")
+		}
 		startParams := jsonutils.NewDict()
 		startParams.Set("qemu_version", jsonutils.NewString(migParams.QemuVersion))
 		startParams.Set("need_migrate", jsonutils.JSONTrue)
@@ -1205,7 +1220,16 @@ func (m *SGuestManager) DoSnapshot(ctx context.Context, params interface{}) (jso
 		return nil, hostutils.ParamsError
 	}
 	guest, _ := m.GetServer(snapshotParams.Sid)
-	return guest.ExecDiskSnapshotTask(ctx, snapshotParams.UserCred, snapshotParams.Disk, snapshotParams.SnapshotId)
+	return guest.ExecDiskSnapshotTask(ctx, snapshotParams.UserCred, snapshotParams.Disk, snapshotParams.SnapshotId, snapshotParams.Consistent)
+}
+
+func (m *SGuestManager) DoDiskLiveBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	backupParams, ok := params.(*SDiskLiveBackup)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	guest, _ := m.GetServer(backupParams.Sid)
+	return guest.ExecDiskLiveBackupTask(ctx, backupParams)
 }
 
 func (m *SGuestManager) DeleteSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
@@ -1260,6 +1284,24 @@ func (m *SGuestManager) DoDeleteMemorySnapshot(ctx context.Context, params inter
 	return nil, nil
 }
 
+// ActivateSLVMDisks converts lock of lvs of shared vg used by guest, region
+// converts them back to exclusive after live migration, exclusive lock is
+// refused until the other host released them, e.g. undeploying guest there
+func (m *SGuestManager) ActivateSLVMDisks(sid string, exclusive bool) error {
+	guest, ok := m.GetServer(sid)
+	if !ok {
+		return httperrors.NewNotFoundError("guest %s not found", sid)
+	}
+	var err error
+	for i := 0; i < 30; i++ {
+		if err = guest.activateSLVMDisks(exclusive); err == nil || !exclusive {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
+
 func (m *SGuestManager) Resume(ctx context.Context, sid string, isLiveMigrate bool, cleanTLS bool) (jsonutils.JSONObject, error) {
 	guest, _ := m.GetServer(sid)
 	if guest.IsStopping() || guest.IsStopped() {
@@ -1286,13 +1328,13 @@ func (m *SGuestManager) Resume(ctx context.Context, sid string, isLiveMigrate bo
 	return nil, nil
 }
 
-func (m *SGuestManager) OnlineResizeDisk(ctx context.Context, sid string, diskId string, sizeMb int64) (jsonutils.JSONObject, error) {
+func (m *SGuestManager) OnlineResizeDisk(ctx context.Context, sid string, diskId string, sizeMb int64, ioThrottle *compute.DiskIoThrottle) (jsonutils.JSONObject, error) {
 	guest, ok := m.GetServer(sid)
 	if !ok {
 		return nil, httperrors.NewNotFoundError("guest %s not found", sid)
 	}
 	if guest.IsRunning() {
-		guest.onlineResizeDisk(ctx, diskId, sizeMb)
+		guest.onlineResizeDisk(ctx, diskId, sizeMb, ioThrottle)
 		return nil, nil
 	} else {
 		return nil, httperrors.NewInvalidStatusError("guest is not runnign")
@@ -1407,6 +1449,8 @@ type SStorageCloneDisk struct {
 	TargetDiskId   string
 	DiskFormat     string
 	TargetDiskDesc *compute.GuestdiskJsonDesc
+	// bandwidth of drive mirror in MB/s
+	MaxBandwidthMb int64
 
 	// clone progress
 	CompletedDiskCount int
@@ -1441,6 +1485,52 @@ func (m *SGuestManager) LiveChangeDisk(ctx context.Context, params interface{})
 	return nil, nil
 }
 
+type SCancelStorageCloneDisk struct {
+	ServerId string
+	DiskId   string
+}
+
+// CancelStorageCloneDisk cancels drive mirror of disk and reports the file
+// the drive opens, which is the source disk unless the job has pivoted
+func (m *SGuestManager) CancelStorageCloneDisk(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	input := params.(*SCancelStorageCloneDisk)
+	guest, _ := m.GetServer(input.ServerId)
+	if guest == nil {
+		return nil, httperrors.NewNotFoundError("Not found guest by id %s", input.ServerId)
+	}
+	if !(guest.IsRunning() || guest.IsSuspend()) {
+		hostutils.TaskComplete(ctx, nil)
+		return nil, nil
+	}
+	var diskIndex = -1
+	for i := range guest.Desc.Disks {
+		if guest.Desc.Disks[i].DiskId == input.DiskId {
+			diskIndex = int(guest.Desc.Disks[i].Index)
+			break
+		}
+	}
+	if diskIndex < 0 {
+		return nil, httperrors.NewNotFoundError("Not found disk %s on guest %s", input.DiskId, input.ServerId)
+	}
+	driveName := fmt.Sprintf("drive_%d", diskIndex)
+	guest.Monitor.CancelBlockJob(driveName, false, func(res string) {
+		if len(res) > 0 {
+			// no job of the drive is also fine
+			log.Warningf("cancel block job of disk %s: %s", input.DiskId, res)
+		}
+		// report the file guest runs on, job may have pivoted before cancelling
+		guest.Monitor.GetBlocks(func(blocks []monitor.QemuBlock) {
+			file, ok := getBlockFile(blocks, driveName)
+			if !ok {
+				hostutils.TaskFailed(ctx, fmt.Sprintf("drive %s not found", driveName))
+				return
+			}
+			hostutils.TaskComplete(ctx, jsonutils.Marshal(map[string]string{"block_file": file}))
+		})
+	})
+	return nil, nil
+}
+
 func (m *SGuestManager) GetHost() hostutils.IHost {
 	return m.host
 }
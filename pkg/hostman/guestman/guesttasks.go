@@ -989,6 +989,132 @@ func (t *SGuestIsolatedDeviceSyncTask) delDeviceCallBack(opts []*isolated_device
 	})
 }
 
+/**
+ *  GuestFileShareSyncTask
+**/
+
+type SGuestFileShareSyncTask struct {
+	guest     *SKVMGuestInstance
+	delShares []*desc.SGuestFileShare
+	addShares []*desc.SGuestFileShare
+	errors    []error
+
+	callback func(...error)
+}
+
+func NewGuestFileShareSyncTask(guest *SKVMGuestInstance, delShares, addShares []*desc.SGuestFileShare) *SGuestFileShareSyncTask {
+	return &SGuestFileShareSyncTask{guest, delShares, addShares, make([]error, 0), nil}
+}
+
+func (t *SGuestFileShareSyncTask) Start(cb func(...error)) {
+	t.callback = cb
+	t.syncShare()
+}
+
+func (t *SGuestFileShareSyncTask) syncShare() {
+	if len(t.delShares) > 0 {
+		share := t.delShares[len(t.delShares)-1]
+		t.delShares = t.delShares[:len(t.delShares)-1]
+		t.removeShare(share)
+	} else if len(t.addShares) > 0 {
+		share := t.addShares[len(t.addShares)-1]
+		t.addShares = t.addShares[:len(t.addShares)-1]
+		t.addShare(share)
+	} else {
+		t.callback(t.errors...)
+	}
+}
+
+func (t *SGuestFileShareSyncTask) onFail(err error) {
+	log.Errorln(err)
+	t.errors = append(t.errors, err)
+	t.syncShare()
+}
+
+func (t *SGuestFileShareSyncTask) removeShare(share *desc.SGuestFileShare) {
+	if share.Pci == nil {
+		t.onFail(errors.Errorf("file share %s has no device", share.MountTag))
+		return
+	}
+	t.guest.Monitor.DeviceDel(share.Pci.Id, func(res string) {
+		if len(res) > 0 {
+			t.onFail(errors.Errorf("file share %s device del failed: %s", share.MountTag, res))
+			return
+		}
+		if share.Pci.PCIAddr != nil {
+			if err := t.guest.pciAddrs.ReleasePCIAddress(share.Pci.PCIAddr); err != nil {
+				log.Errorf("failed release file share pci addr %s", share.Pci.PCIAddr)
+			}
+		}
+		for i := 0; i < len(t.guest.Desc.FileShares); i++ {
+			if t.guest.Desc.FileShares[i].Id == share.Id {
+				t.guest.Desc.FileShares = append(t.guest.Desc.FileShares[:i], t.guest.Desc.FileShares[i+1:]...)
+				break
+			}
+		}
+		// virtiofsd exits once qemu closed the chardev
+		t.guest.Monitor.ChardevRemove(share.Socket.Id, func(res string) {
+			if len(res) > 0 {
+				log.Errorf("file share %s chardev remove: %s", share.MountTag, res)
+			}
+			t.syncShare()
+		})
+	})
+}
+
+func (t *SGuestFileShareSyncTask) addShare(share *desc.SGuestFileShare) {
+	mem := t.guest.Desc.MemDesc
+	if mem == nil || mem.Mem == nil || mem.Mem.Options["share"] != "on" {
+		t.onFail(errors.Errorf("guest memory is not shared, restart guest to attach file share %s", share.MountTag))
+		return
+	}
+	cType := t.guest.getVfioDeviceHotPlugPciControllerType()
+	if cType == nil {
+		t.onFail(errors.Errorf("no hotplugable pci controller found"))
+		return
+	}
+	t.guest.initFileShareDevice(share, *cType)
+	if err := t.guest.startVirtiofsd(share); err != nil {
+		t.onFail(errors.Wrapf(err, "file share %s", share.MountTag))
+		return
+	}
+	if err := t.guest.ensureDevicePciAddress(share.Pci, -1, nil); err != nil {
+		t.onFail(errors.Wrapf(err, "ensure file share %s pci address", share.MountTag))
+		return
+	}
+	onDeviceAddFail := func(err error) {
+		if e := t.guest.pciAddrs.ReleasePCIAddress(share.Pci.PCIAddr); e != nil {
+			log.Errorf("failed release file share pci addr %s", share.Pci.PCIAddr)
+		}
+		t.guest.Monitor.ChardevRemove(share.Socket.Id, func(res string) {
+			log.Infof("chardev %s remove %s", share.Socket.Id, res)
+		})
+		t.onFail(err)
+	}
+	t.guest.Monitor.ChardevAdd(share.Socket.Backend, share.Socket.Id, share.Socket.Options, func(res string) {
+		if len(res) > 0 {
+			onDeviceAddFail(errors.Errorf("file share %s chardev add failed: %s", share.MountTag, res))
+			return
+		}
+		params := map[string]string{
+			"id":   share.Pci.Id,
+			"bus":  share.Pci.BusStr(),
+			"addr": share.Pci.SlotFunc(),
+		}
+		for k, v := range share.Pci.Options {
+			params[k] = v
+		}
+		t.guest.Monitor.DeviceAdd(share.Pci.DevType, params, func(res string) {
+			if len(res) > 0 {
+				onDeviceAddFail(errors.Errorf("file share %s device add failed: %s", share.MountTag, res))
+				return
+			}
+			t.guest.Desc.FileShares = append(t.guest.Desc.FileShares, share)
+			t.syncShare()
+		})
+	})
+}
+
 /**
  *  GuestLiveMigrateTask
 **/
@@ -1351,6 +1477,8 @@ func (s *SGuestLiveMigrateTask) migrateComplete(stats jsonutils.JSONObject) {
 	}
 	s.Monitor.Disconnect()
 	s.Monitor = nil
+	// source guest is deleted after migrated
+	s.syncUefiNvram()
 	res := jsonutils.NewDict()
 	if stats != nil {
 		res.Set("migration_info", stats)
@@ -1419,6 +1547,7 @@ type SGuestResumeTask struct {
 	cleanTLS            bool
 	resumed             bool
 	isResumeFromMigrate bool
+	isIncomingMigrate   bool
 
 	getTaskData func() (jsonutils.JSONObject, error)
 }
@@ -1488,6 +1617,7 @@ func (s *SGuestResumeTask) onConfirmRunning(status string) {
 		s.resumeGuest()
 	case "paused (inmigrate)":
 		// guest is paused waiting for an incoming migration
+		s.isIncomingMigrate = true
 		time.Sleep(time.Second * 1)
 		s.confirmRunning()
 	default:
@@ -1579,6 +1709,10 @@ func (s *SGuestResumeTask) onStartRunning() {
 
 	s.setCgroupPid()
 	s.removeStatefile()
+	if s.isIncomingMigrate {
+		// io throttle is not migrated with guest, apply it on destination
+		s.doBlockIoThrottle()
+	}
 	if s.ctx != nil && len(appctx.AppContextTaskId(s.ctx)) > 0 {
 		var (
 			data jsonutils.JSONObject
@@ -1954,19 +2088,36 @@ type SGuestDiskSnapshotTask struct {
 	*SGuestReloadDiskTask
 
 	snapshotId string
+	// non zero if guest filesystems frozen before disk snapshot created
+	fsfreezeEpoch int
 }
 
 func NewGuestDiskSnapshotTask(
-	ctx context.Context, s *SKVMGuestInstance, disk storageman.IDisk, snapshotId string,
+	ctx context.Context, s *SKVMGuestInstance, disk storageman.IDisk, snapshotId string, fsfreezeEpoch int,
 ) *SGuestDiskSnapshotTask {
 	return &SGuestDiskSnapshotTask{
 		SGuestReloadDiskTask: NewGuestReloadDiskTask(ctx, s, disk),
 		snapshotId:           snapshotId,
+		fsfreezeEpoch:        fsfreezeEpoch,
 	}
 }
 
 func (s *SGuestDiskSnapshotTask) Start() {
-	s.fetchDisksInfo(s.startSnapshot)
+	if !s.disk.IsFile() {
+		// snapshot of block device disk (e.g. lvm thin snapshot) is taken
+		// aside, path of disk opened by qemu stays unchanged
+		s.onResumeSucc("")
+		return
+	}
+	s.Monitor.GetBlocks(func(blocks []monitor.QemuBlock) {
+		for i := range blocks {
+			if device := s.getDiskOfDrive(blocks[i]); len(device) > 0 {
+				s.startSnapshot(device)
+				return
+			}
+		}
+		s.onSnapshotBlkdevFail("Device not found")
+	})
 }
 
 func (s *SGuestDiskSnapshotTask) startSnapshot(device string) {
@@ -1990,14 +2141,17 @@ func (s *SGuestDiskSnapshotTask) onSnapshotBlkdevFail(reason string) {
 	if err != nil {
 		log.Errorf("mv %s to %s failed: %s, %s", snapshotPath, s.disk.GetPath(), err, output)
 	}
+	s.fsfreeze.thaw(s.fsfreezeEpoch)
 	hostutils.TaskFailed(s.ctx, fmt.Sprintf("Reload blkdev error: %s", reason))
 }
 
 func (s *SGuestDiskSnapshotTask) onResumeSucc(res string) {
 	log.Infof("guest disk snapshot task resume succ %s", res)
+	consistent := s.fsfreeze.thaw(s.fsfreezeEpoch)
 	snapshotLocation := path.Join(s.disk.GetSnapshotLocation(), s.snapshotId)
 	body := jsonutils.NewDict()
 	body.Set("location", jsonutils.NewString(snapshotLocation))
+	body.Set("application_consistent", jsonutils.NewBool(consistent))
 	hostutils.TaskComplete(s.ctx, body)
 }
 
@@ -2309,16 +2463,19 @@ type SGuestOnlineResizeDiskTask struct {
 	ctx    context.Context
 	diskId string
 	sizeMB int64
+	// io limits of performance class scaled with the new size
+	ioThrottle *api.DiskIoThrottle
 }
 
 func NewGuestOnlineResizeDiskTask(
-	ctx context.Context, s *SKVMGuestInstance, diskId string, sizeMB int64,
+	ctx context.Context, s *SKVMGuestInstance, diskId string, sizeMB int64, ioThrottle *api.DiskIoThrottle,
 ) *SGuestOnlineResizeDiskTask {
 	return &SGuestOnlineResizeDiskTask{
 		SKVMGuestInstance: s,
 		ctx:               ctx,
 		diskId:            diskId,
 		sizeMB:            sizeMB,
+		ioThrottle:        ioThrottle,
 	}
 }
 
@@ -2349,14 +2506,48 @@ func (task *SGuestOnlineResizeDiskTask) OnGetBlocksSucc(blocks []monitor.QemuBlo
 
 func (task *SGuestOnlineResizeDiskTask) OnResizeSucc(err string) {
 	if len(err) == 0 {
-		params := jsonutils.NewDict()
-		params.Add(jsonutils.NewInt(task.sizeMB), "disk_size")
-		hostutils.TaskComplete(task.ctx, params)
+		task.reapplyIoThrottle(task.taskComplete)
 		return
 	}
 	hostutils.TaskFailed(task.ctx, fmt.Sprintf("resize disk %s %dMb error: %v", task.diskId, task.sizeMB, err))
 }
 
+func (task *SGuestOnlineResizeDiskTask) taskComplete() {
+	params := jsonutils.NewDict()
+	params.Add(jsonutils.NewInt(task.sizeMB), "disk_size")
+	hostutils.TaskComplete(task.ctx, params)
+}
+
+// reapplyIoThrottle updates io limits of the resized disk, failure is only
+// logged as the disk has been resized and limits are synced with guest later
+func (task *SGuestOnlineResizeDiskTask) reapplyIoThrottle(callback func()) {
+	if task.ioThrottle == nil {
+		callback()
+		return
+	}
+	var disk *desc.SGuestDisk
+	for i := range task.Desc.Disks {
+		if task.Desc.Disks[i].DiskId == task.diskId {
+			disk = task.Desc.Disks[i]
+			break
+		}
+	}
+	if disk == nil {
+		callback()
+		return
+	}
+	disk.Iops, disk.Bps = task.ioThrottle.Iops, task.ioThrottle.Bps
+	disk.BurstIops, disk.BurstBps = task.ioThrottle.BurstIops, task.ioThrottle.BurstBps
+	disk.BurstSeconds, disk.ThrottleGroup = task.ioThrottle.BurstSeconds, task.ioThrottle.ThrottleGroup
+	task.SaveLiveDesc(task.Desc)
+	task.Monitor.BlockIoThrottle(fmt.Sprintf("drive_%d", disk.Index), newBlockIoThrottle(disk), func(res string) {
+		if len(res) > 0 {
+			log.Errorf("re-apply io throttle of disk %s after resize: %s", task.diskId, res)
+		}
+		callback()
+	})
+}
+
 /**
  *  GuestHotplugCpuMem
 **/
@@ -2470,6 +2661,13 @@ func (task *SGuestHotplugCpuMemTask) onGetSlotIndex(index int) {
 			"share":    "on",
 			"prealloc": "on",
 		}
+	} else if task.isVirtiofsEnabled() {
+		// hotplugged memory is also mapped by virtiofsd
+		objType = "memory-backend-memfd"
+		options = map[string]string{
+			"size":  fmt.Sprintf("%dM", task.addMemSize),
+			"share": "on",
+		}
 	} else {
 		objType = "memory-backend-ram"
 		options = map[string]string{
@@ -2592,14 +2790,24 @@ func (task *SGuestBlockIoThrottleTask) startDoIoThrottle(idx int) {
 				task.startDoIoThrottle(idx + 1)
 			}
 		}
-		task.Monitor.BlockIoThrottle(
-			fmt.Sprintf("drive_%d", task.Desc.Disks[idx].Index),
-			int64(task.Desc.Disks[idx].Bps), int64(task.Desc.Disks[idx].Iops), _cb)
+		disk := task.Desc.Disks[idx]
+		task.Monitor.BlockIoThrottle(fmt.Sprintf("drive_%d", disk.Index), newBlockIoThrottle(disk), _cb)
 	} else {
 		task.taskComplete(nil)
 	}
 }
 
+func newBlockIoThrottle(disk *desc.SGuestDisk) *monitor.BlockIoThrottle {
+	return &monitor.BlockIoThrottle{
+		Bps:          int64(disk.Bps),
+		Iops:         int64(disk.Iops),
+		BpsMax:       int64(disk.BurstBps),
+		IopsMax:      int64(disk.BurstIops),
+		BurstSeconds: int64(disk.BurstSeconds),
+		Group:        disk.ThrottleGroup,
+	}
+}
+
 func (task *SGuestBlockIoThrottleTask) taskFail(reason string) {
 	if taskId := task.ctx.Value(appctx.APP_CONTEXT_KEY_TASK_ID); taskId != nil {
 		hostutils.TaskFailed(task.ctx, reason)
@@ -2764,7 +2972,15 @@ func (t *SGuestStorageCloneDiskTask) Start(guestRunning bool) {
 		t.diskIndex = diskIndex
 	}
 
-	t.Monitor.DriveMirror(t.onDriveMirror, fmt.Sprintf("drive_%d", diskIndex), targetDisk.GetPath(), "full", targetDiskFormat, true, false, 0)
+	t.driveMirror(targetDisk.GetPath(), targetDiskFormat)
+}
+
+func (t *SGuestStorageCloneDiskTask) driveMirror(targetPath, targetFormat string) {
+	var speed int64 = 0
+	if t.params.MaxBandwidthMb > 0 {
+		speed = t.params.MaxBandwidthMb * 1024 * 1024
+	}
+	t.Monitor.DriveMirror(t.onDriveMirror, fmt.Sprintf("drive_%d", t.diskIndex), targetPath, "full", targetFormat, true, false, speed)
 }
 
 func (t *SGuestStorageCloneDiskTask) onDriveMirror(res string) {
@@ -2784,7 +3000,15 @@ func (t *SGuestStorageCloneDiskTask) onDriveMirror(res string) {
 }
 
 func (t *SGuestStorageCloneDiskTask) OnGetBlockJobs(jobs []monitor.BlockJob) {
-	if len(jobs) == 0 {
+	drive := fmt.Sprintf("drive_%d", t.diskIndex)
+	var job *monitor.BlockJob
+	for i := range jobs {
+		if jobs[i].Device == drive {
+			job = &jobs[i]
+			break
+		}
+	}
+	if job == nil {
 		if !t.started {
 			targetDisk, err := t.params.TargetStorage.GetDiskById(t.params.TargetDiskId)
 			if err != nil {
@@ -2800,22 +3024,20 @@ func (t *SGuestStorageCloneDiskTask) OnGetBlockJobs(jobs []monitor.BlockJob) {
 				)
 				return
 			}
-			t.Monitor.DriveMirror(t.onDriveMirror, fmt.Sprintf("drive_%d", t.diskIndex), targetDisk.GetPath(), "full", targetDiskFormat, true, false, 0)
+			t.driveMirror(targetDisk.GetPath(), targetDiskFormat)
 		} else {
-			hostutils.TaskFailed(t.ctx, fmt.Sprintf("Disk %s Block job not found", t.params.SourceDisk.GetId()))
+			// job of other drives keeps the wait loop running
+			t.cancelWaitBlockJobs()
+			hostutils.TaskFailed(t.ctx, fmt.Sprintf("Disk %s Block job not found, cancelled or failed", t.params.SourceDisk.GetId()))
 		}
 		return
 	}
-	for i := range jobs {
-		if jobs[i].Status == "ready" && jobs[i].Device == fmt.Sprintf("drive_%d", t.diskIndex) {
-			t.cancelWaitBlockJobs()
-			params := jsonutils.NewDict()
-			params.Set("block_jobs_ready", jsonutils.JSONTrue)
-			hostutils.TaskComplete(t.ctx, params)
-			break
-		}
+	if job.Status == "ready" {
+		t.cancelWaitBlockJobs()
+		params := jsonutils.NewDict()
+		params.Set("block_jobs_ready", jsonutils.JSONTrue)
+		hostutils.TaskComplete(t.ctx, params)
 	}
-
 }
 
 func (t *SGuestStorageCloneDiskTask) StreamingDiskCompletedCount() int {
@@ -2826,6 +3048,10 @@ func (t *SGuestStorageCloneDiskTask) StreamingDiskCount() int {
 	return t.params.CloneDiskCount
 }
 
+const LIVE_CHANGE_DISK_PIVOT_RETRY = 30
+
+var liveChangeDiskPivotInterval = time.Second
+
 type SGuestLiveChangeDisk struct {
 	*SKVMGuestInstance
 
@@ -2886,17 +3112,77 @@ func (t *SGuestLiveChangeDisk) onGuestPaused(res string) {
 		return
 	}
 
-	t.Monitor.BlockJobComplete(fmt.Sprintf("drive_%d", t.diskIndex), t.onReopenImageSuccess)
+	t.Monitor.BlockJobComplete(t.getDriveName(), t.onBlockJobComplete)
 }
 
-func (t *SGuestLiveChangeDisk) onReopenImageSuccess(res string) {
-	// resume guest first
+func (t *SGuestLiveChangeDisk) getDriveName() string {
+	return fmt.Sprintf("drive_%d", t.diskIndex)
+}
+
+func (t *SGuestLiveChangeDisk) onBlockJobComplete(res string) {
+	if len(res) > 0 {
+		t.rollback(fmt.Sprintf("complete block job failed: %s", res))
+		return
+	}
+	t.waitPivot(0)
+}
+
+// waitPivot waits block job to finish, block-job-complete only starts pivoting
+func (t *SGuestLiveChangeDisk) waitPivot(retry int) {
+	t.Monitor.GetBlockJobs(func(jobs []monitor.BlockJob) {
+		for i := range jobs {
+			if jobs[i].Device != t.getDriveName() {
+				continue
+			}
+			if retry >= LIVE_CHANGE_DISK_PIVOT_RETRY {
+				// cancel job so that guest keeps writing to source disk
+				t.Monitor.CancelBlockJob(t.getDriveName(), true, func(string) {
+					t.rollback("wait block job pivot timeout")
+				})
+				return
+			}
+			timeutils2.AddTimeout(liveChangeDiskPivotInterval, func() { t.waitPivot(retry + 1) })
+			return
+		}
+		t.Monitor.GetBlocks(t.onGetBlocks)
+	})
+}
+
+func (t *SGuestLiveChangeDisk) onGetBlocks(blocks []monitor.QemuBlock) {
+	file, ok := getBlockFile(blocks, t.getDriveName())
+	if !ok {
+		t.rollback(fmt.Sprintf("drive %s not found", t.getDriveName()))
+		return
+	}
+	// path of disk is named after disk id on any kind of storage
+	if !strings.Contains(file, t.params.TargetDiskId) {
+		t.rollback(fmt.Sprintf("drive %s still opens %s after pivot", t.getDriveName(), file))
+		return
+	}
+	t.onPivotSucc()
+}
+
+// getBlockFile returns the file opened by drive
+func getBlockFile(blocks []monitor.QemuBlock, driveName string) (string, bool) {
+	for i := range blocks {
+		if blocks[i].Device == driveName {
+			return blocks[i].Inserted.File, true
+		}
+	}
+	return "", false
+}
+
+// rollback resumes guest which is still running on source disk
+func (t *SGuestLiveChangeDisk) rollback(reason string) {
 	if t.guestNeedResume {
 		t.Monitor.SimpleCommand("cont", nil)
 	}
-	if len(res) > 0 {
-		hostutils.TaskFailed(t.ctx, fmt.Sprintf("reopen image failed: %s", res))
-		return
+	hostutils.TaskFailed(t.ctx, reason)
+}
+
+func (t *SGuestLiveChangeDisk) onPivotSucc() {
+	if t.guestNeedResume {
+		t.Monitor.SimpleCommand("cont", nil)
 	}
 	if t.params.TargetDiskDesc != nil {
 		for i := 0; i < len(t.Desc.Disks); i++ {
@@ -0,0 +1,160 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/hostman/monitor"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+)
+
+const testTargetDiskId = "3d5f2c1a-7b7e-4c0e-9b1a-2f7c5d9e8a10"
+
+// fakePivotMonitor reports the block job of drive_0 for jobRounds queries,
+// then the drive opens file
+type fakePivotMonitor struct {
+	monitor.Monitor
+
+	lock      sync.Mutex
+	jobRounds int
+	file      string
+	cmds      []string
+}
+
+func (m *fakePivotMonitor) record(cmd string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cmds = append(m.cmds, cmd)
+}
+
+func (m *fakePivotMonitor) SimpleCommand(cmd string, callback monitor.StringCallback) {
+	m.record(cmd)
+}
+
+func (m *fakePivotMonitor) getCmds() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]string{}, m.cmds...)
+}
+
+func (m *fakePivotMonitor) GetBlockJobs(callback func([]monitor.BlockJob)) {
+	m.lock.Lock()
+	jobs := []monitor.BlockJob{}
+	if m.jobRounds > 0 {
+		m.jobRounds -= 1
+		jobs = append(jobs, monitor.BlockJob{Device: "drive_0", Type: "mirror"})
+	}
+	m.lock.Unlock()
+	callback(jobs)
+}
+
+func (m *fakePivotMonitor) CancelBlockJob(driveName string, force bool, callback monitor.StringCallback) {
+	m.record("cancel " + driveName)
+	callback("")
+}
+
+func (m *fakePivotMonitor) GetBlocks(callback func([]monitor.QemuBlock)) {
+	m.record("blocks")
+	block := monitor.QemuBlock{Device: "drive_0"}
+	block.Inserted.File = m.file
+	callback([]monitor.QemuBlock{block})
+}
+
+type fakeTargetDisk struct {
+	storageman.IDisk
+
+	mon *fakePivotMonitor
+}
+
+// GetPath is only called after pivot succeeded
+func (d *fakeTargetDisk) GetPath() string {
+	d.mon.record("path")
+	return "/dev/vg0/" + testTargetDiskId
+}
+
+func TestSGuestLiveChangeDiskWaitPivot(t *testing.T) {
+	interval := liveChangeDiskPivotInterval
+	liveChangeDiskPivotInterval = time.Millisecond
+	defer func() { liveChangeDiskPivotInterval = interval }()
+
+	cases := []struct {
+		name      string
+		jobRounds int
+		file      string
+		want      []string
+	}{
+		{
+			name:      "pivoted",
+			jobRounds: 3,
+			file:      "/dev/vg0/" + testTargetDiskId,
+			want:      []string{"blocks", "cont", "path"},
+		},
+		{
+			name:      "still on source disk",
+			jobRounds: 1,
+			file:      "/opt/cloud/workspace/disks/0b9a4f3e-5d6c-4e1f-8a2b-3c4d5e6f7a8b",
+			want:      []string{"blocks", "cont"},
+		},
+		{
+			name:      "pivot timeout",
+			jobRounds: LIVE_CHANGE_DISK_PIVOT_RETRY + 1,
+			want:      []string{"cancel drive_0", "cont"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mon := &fakePivotMonitor{
+				jobRounds: c.jobRounds,
+				file:      c.file,
+			}
+			task := &SGuestLiveChangeDisk{
+				SKVMGuestInstance: &SKVMGuestInstance{Monitor: mon},
+				ctx:               context.Background(),
+				params:            &SStorageCloneDisk{TargetDiskId: testTargetDiskId},
+				guestNeedResume:   true,
+				targetDisk:        &fakeTargetDisk{mon: mon},
+			}
+			task.waitPivot(0)
+			deadline := time.Now().Add(10 * time.Second)
+			for len(mon.getCmds()) < len(c.want) && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if cmds := mon.getCmds(); !reflect.DeepEqual(cmds, c.want) {
+				t.Errorf("want commands %v, got %v", c.want, cmds)
+			}
+			mon.lock.Lock()
+			defer mon.lock.Unlock()
+			if mon.jobRounds != 0 {
+				t.Errorf("%d rounds of block job left", mon.jobRounds)
+			}
+		})
+	}
+}
+
+func TestGetBlockFile(t *testing.T) {
+	blocks := []monitor.QemuBlock{{Device: "drive_0"}, {Device: "drive_1"}}
+	blocks[1].Inserted.File = "/dev/vg0/" + testTargetDiskId
+	if file, ok := getBlockFile(blocks, "drive_1"); !ok || file != blocks[1].Inserted.File {
+		t.Errorf("drive_1 file %q %v", file, ok)
+	}
+	if _, ok := getBlockFile(blocks, "drive_2"); ok {
+		t.Errorf("drive_2 found")
+	}
+}
@@ -91,11 +91,14 @@ func (s *SKVMGuestInstance) initGuestDevicesDesc(pciRoot, pciBridge *desc.PCICon
 	}
 
 	s.initIsolatedDevices(pciRoot, pciBridge)
+	s.initFileShares(pciRoot, pciBridge)
 	s.initUsbController(pciRoot)
 	s.initRandomDevice(pciRoot, options.HostOptions.EnableVirtioRngDevice)
+	s.initBalloonDevice(pciRoot, options.HostOptions.EnableMemoryBalloon)
 	s.initQgaDesc()
 	s.initPvpanicDesc()
 	s.initIsaSerialDesc()
+	s.initTpmDesc()
 	return nil
 }
 
@@ -271,6 +274,21 @@ func (s *SKVMGuestInstance) initRandomDevice(pciRoot *desc.PCIController, enable
 	}
 }
 
+func (s *SKVMGuestInstance) initBalloonDevice(pciRoot *desc.PCIController, enableMemoryBalloon bool) {
+	if !enableMemoryBalloon {
+		return
+	}
+	s.Desc.Balloon = &desc.SGuestBalloon{
+		PCIDevice: desc.NewPCIDevice(pciRoot.CType, "virtio-balloon-pci", "balloon0"),
+	}
+	if options.HostOptions.EnableBalloonFreePageReporting {
+		// guest reports freed pages to host, so that they are discarded at once
+		s.Desc.Balloon.Options = map[string]string{
+			"free-page-reporting": "on",
+		}
+	}
+}
+
 func (s *SKVMGuestInstance) initUsbController(pciRoot *desc.PCIController) {
 	contType := s.getUsbControllerType()
 	s.Desc.Usb = &desc.UsbController{
@@ -693,6 +711,15 @@ func (s *SKVMGuestInstance) ensurePciAddresses() error {
 		}
 	}
 
+	for i := 0; i < len(s.Desc.FileShares); i++ {
+		if s.Desc.FileShares[i].Pci != nil {
+			err = s.ensureDevicePciAddress(s.Desc.FileShares[i].Pci, -1, nil)
+			if err != nil {
+				return errors.Wrapf(err, "ensure file share %s pci address", s.Desc.FileShares[i].MountTag)
+			}
+		}
+	}
+
 	if s.Desc.Usb != nil {
 		err = s.ensureDevicePciAddress(s.Desc.Usb.PCIDevice, -1, nil)
 		if err != nil {
@@ -707,6 +734,13 @@ func (s *SKVMGuestInstance) ensurePciAddresses() error {
 		}
 	}
 
+	if s.Desc.Balloon != nil {
+		err = s.ensureDevicePciAddress(s.Desc.Balloon.PCIDevice, -1, nil)
+		if err != nil {
+			return errors.Wrap(err, "ensure balloon device pci address")
+		}
+	}
+
 	for i := 0; i < len(s.Desc.AnonymousPCIDevs); i++ {
 		err = s.ensureDevicePciAddress(s.Desc.AnonymousPCIDevs[i], -1, nil)
 		if err != nil {
@@ -752,6 +786,7 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 	s.initQgaDesc()
 	s.initPvpanicDesc()
 	s.initIsaSerialDesc()
+	s.initTpmDesc()
 	s.Desc.VdiDevice = new(desc.SGuestVdi)
 
 	for i := 0; i < len(pciInfoList[0].Devices); i++ {
@@ -810,6 +845,15 @@ func (s *SKVMGuestInstance) initGuestDescFromExistingGuest(
 			if err != nil {
 				return errors.Wrap(err, "ensure random device pci address")
 			}
+		case "balloon0":
+			if s.Desc.Balloon == nil {
+				s.initBalloonDevice(pciRoot, true)
+			}
+			s.Desc.Balloon.PCIAddr = pciAddr
+			err = s.ensureDevicePciAddress(s.Desc.Balloon.PCIDevice, -1, nil)
+			if err != nil {
+				return errors.Wrap(err, "ensure balloon device pci address")
+			}
 		case "usb":
 			if s.Desc.Usb == nil {
 				s.initUsbController(pciRoot)
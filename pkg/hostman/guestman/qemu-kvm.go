@@ -24,6 +24,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -103,6 +104,14 @@ type SKVMInstanceRuntime struct {
 
 	pciUninitialized bool
 	pciAddrs         *desc.SGuestPCIAddresses
+
+	// actual memory in MB after ballooning, accessed atomically
+	balloonActualMb int64
+
+	fsfreeze *sGuestFsfreeze
+
+	// backup job id -> callback on job finished
+	diskBackupJobs *sync.Map
 }
 
 type SKVMGuestInstance struct {
@@ -126,14 +135,17 @@ func NewKVMGuestInstance(id string, manager *SGuestManager) *SKVMGuestInstance {
 	if manager.host.IsAarch64() {
 		qemuArch = arch.Arch_aarch64
 	}
-	return &SKVMGuestInstance{
+	s := &SKVMGuestInstance{
 		SKVMInstanceRuntime: SKVMInstanceRuntime{
 			blockJobTigger: make(map[string]chan struct{}),
+			diskBackupJobs: new(sync.Map),
 		},
 		Id:      id,
 		manager: manager,
 		archMan: arch.NewArch(qemuArch),
 	}
+	s.initFsfreeze()
+	return s
 }
 
 // update guest runtime desc from source desc
@@ -796,8 +808,13 @@ func (s *SKVMGuestInstance) StartMonitor(ctx context.Context, cb func()) error {
 
 func (s *SKVMGuestInstance) onReceiveQMPEvent(event *monitor.Event) {
 	switch event.Event {
-	case `"BLOCK_JOB_READY"`, `"BLOCK_JOB_COMPLETED"`:
+	case `"BLOCK_JOB_READY"`:
+		s.eventBlockJobReady(event)
+	case `"BLOCK_JOB_COMPLETED"`:
 		s.eventBlockJobReady(event)
+		s.eventBackupJobFinished(event)
+	case `"BLOCK_JOB_CANCELLED"`:
+		s.eventBackupJobFinished(event)
 	case `"BLOCK_JOB_ERROR"`:
 		s.eventBlockJobError(event)
 	case `"GUEST_PANICKED"`:
@@ -1229,6 +1246,8 @@ func (s *SKVMGuestInstance) onMonitorDisConnect(err error) {
 	}
 	s.clearCgroup(0)
 	s.Monitor = nil
+	// qemu exited, e.g. guest shutdown itself
+	s.syncUefiNvram()
 }
 
 func (s *SKVMGuestInstance) startDiskBackupMirror(ctx context.Context) {
@@ -1681,6 +1700,15 @@ func (s *SKVMGuestInstance) delTmpDisks(ctx context.Context, migrated bool) erro
 					return err
 				}
 			}
+			if d != nil && d.GetType() == api.STORAGE_SLVM && migrated {
+				// target host has opened lv, release lock of it held by this host
+				if slvmDisk, ok := d.(*storageman.SSLVMDisk); ok {
+					if err := slvmDisk.Deactivate(); err != nil {
+						log.Errorf("deactivate migrated disk %s: %s", diskPath, err)
+						return err
+					}
+				}
+			}
 			if migrated {
 				// remove memory snapshot files
 				dir := GetMemorySnapshotPath(s.GetId(), "")
@@ -1714,6 +1742,7 @@ func (s *SKVMGuestInstance) Delete(ctx context.Context, migrated bool) error {
 	if err := s.delTmpDisks(ctx, migrated); err != nil {
 		return errors.Wrap(err, "delTmpDisks")
 	}
+	s.cleanVtpmState(migrated)
 	output, err := procutils.NewCommand("rm", "-rf", s.HomeDir()).Output()
 	if err != nil {
 		return errors.Wrapf(err, "rm %s failed: %s", s.HomeDir(), output)
@@ -1724,6 +1753,7 @@ func (s *SKVMGuestInstance) Delete(ctx context.Context, migrated bool) error {
 func (s *SKVMGuestInstance) Stop() bool {
 	s.ExitCleanup(true)
 	if s.scriptStop() {
+		s.syncUefiNvram()
 		return true
 	} else {
 		return false
@@ -1876,6 +1906,29 @@ func (s *SKVMGuestInstance) compareDescDisks(newDesc *desc.SGuestDesc) ([]*desc.
 	return delDisks, addDisks
 }
 
+// syncDescDiskIoThrottle copies io throttle of existing disks from new desc,
+// returns true if any of them changed
+func (s *SKVMGuestInstance) syncDescDiskIoThrottle(newDesc *desc.SGuestDesc) bool {
+	changed := false
+	for _, disk := range s.Desc.Disks {
+		for _, ndisk := range newDesc.Disks {
+			if disk.Index != ndisk.Index || !pathEqual(disk, ndisk) {
+				continue
+			}
+			if disk.Iops != ndisk.Iops || disk.Bps != ndisk.Bps ||
+				disk.BurstIops != ndisk.BurstIops || disk.BurstBps != ndisk.BurstBps ||
+				disk.BurstSeconds != ndisk.BurstSeconds || disk.ThrottleGroup != ndisk.ThrottleGroup {
+				disk.Iops, disk.Bps = ndisk.Iops, ndisk.Bps
+				disk.BurstIops, disk.BurstBps = ndisk.BurstIops, ndisk.BurstBps
+				disk.BurstSeconds, disk.ThrottleGroup = ndisk.BurstSeconds, ndisk.ThrottleGroup
+				changed = true
+			}
+			break
+		}
+	}
+	return changed
+}
+
 func (s *SKVMGuestInstance) compareDescIsolatedDevices(newDesc *desc.SGuestDesc,
 ) ([]*desc.SGuestIsolatedDevice, []*desc.SGuestIsolatedDevice) {
 	var delDevs, addDevs = []*desc.SGuestIsolatedDevice{}, []*desc.SGuestIsolatedDevice{}
@@ -1905,6 +1958,29 @@ func (s *SKVMGuestInstance) compareDescIsolatedDevices(newDesc *desc.SGuestDesc,
 	return delDevs, addDevs
 }
 
+func (s *SKVMGuestInstance) compareDescFileShares(newDesc *desc.SGuestDesc,
+) ([]*desc.SGuestFileShare, []*desc.SGuestFileShare) {
+	var delShares, addShares = []*desc.SGuestFileShare{}, []*desc.SGuestFileShare{}
+	for _, share := range newDesc.FileShares {
+		newShare := *share
+		addShares = append(addShares, &newShare)
+	}
+	for _, oldShare := range s.Desc.FileShares {
+		var find = false
+		for idx, addShare := range addShares {
+			if oldShare.Id == addShare.Id {
+				addShares = append(addShares[:idx], addShares[idx+1:]...)
+				find = true
+				break
+			}
+		}
+		if !find {
+			delShares = append(delShares, oldShare)
+		}
+	}
+	return delShares, addShares
+}
+
 func (s *SKVMGuestInstance) compareDescCdroms(newDesc *desc.SGuestDesc) []*desc.SGuestCdrom {
 	var changeCdroms []*desc.SGuestCdrom
 	newCdroms := newDesc.Cdroms
@@ -2085,6 +2161,7 @@ func (s *SKVMGuestInstance) SyncConfig(
 	var delNetworks, addNetworks []*desc.SGuestNetwork
 	var changedNetworks [][2]*desc.SGuestNetwork
 	var delDevs, addDevs []*desc.SGuestIsolatedDevice
+	var delShares, addShares []*desc.SGuestFileShare
 	var cdroms []*desc.SGuestCdrom
 	var floppys []*desc.SGuestFloppy
 
@@ -2098,6 +2175,7 @@ func (s *SKVMGuestInstance) SyncConfig(
 		floppys = s.compareDescFloppys(guestDesc)
 		delNetworks, addNetworks, changedNetworks = s.compareDescNetworks(guestDesc)
 		delDevs, addDevs = s.compareDescIsolatedDevices(guestDesc)
+		delShares, addShares = s.compareDescFileShares(guestDesc)
 	}
 
 	if len(changedNetworks) > 0 && s.IsRunning() {
@@ -2121,6 +2199,11 @@ func (s *SKVMGuestInstance) SyncConfig(
 	s.Desc.SGuestRegionDesc = guestDesc.SGuestRegionDesc
 	s.Desc.SGuestMetaDesc = guestDesc.SGuestMetaDesc
 
+	var throttleChanged bool
+	if !fwOnly {
+		throttleChanged = s.syncDescDiskIoThrottle(guestDesc)
+	}
+
 	s.SaveLiveDesc(s.Desc)
 
 	if fwOnly {
@@ -2143,6 +2226,12 @@ func (s *SKVMGuestInstance) SyncConfig(
 		tasks = append(tasks, task)
 	}
 
+	if len(delShares)+len(addShares) > 0 {
+		task := NewGuestFileShareSyncTask(s, delShares, addShares)
+		runTaskNames = append(runTaskNames, jsonutils.NewString("file_share_sync"))
+		tasks = append(tasks, task)
+	}
+
 	// make sure network sync before isolated device
 	if len(delNetworks)+len(addNetworks) > 0 {
 		task := NewGuestNetworkSyncTask(s, delNetworks, addNetworks)
@@ -2153,6 +2242,9 @@ func (s *SKVMGuestInstance) SyncConfig(
 	lenTasks := len(tasks)
 	var callBack = func(errs []error) {
 		s.SaveLiveDesc(s.Desc)
+		if throttleChanged || len(addDisks) > 0 {
+			s.doBlockIoThrottle()
+		}
 		if lenTasks > 0 { // devices updated, regenerate start script
 			vncPort := s.GetVncPort()
 			data := jsonutils.NewDict()
@@ -2551,7 +2643,7 @@ func (s *SKVMGuestInstance) ExecReloadDiskTask(ctx context.Context, disk storage
 }
 
 func (s *SKVMGuestInstance) ExecDiskSnapshotTask(
-	ctx context.Context, userCred mcclient.TokenCredential, disk storageman.IDisk, snapshotId string,
+	ctx context.Context, userCred mcclient.TokenCredential, disk storageman.IDisk, snapshotId string, consistent bool,
 ) (jsonutils.JSONObject, error) {
 	var (
 		encryptKey = ""
@@ -2571,15 +2663,26 @@ func (s *SKVMGuestInstance) ExecDiskSnapshotTask(
 		if !s.isLiveSnapshotEnabled() {
 			return nil, fmt.Errorf("Guest dosen't support live snapshot")
 		}
+		var fsfreezeEpoch int
+		if consistent {
+			fsfreezeEpoch = s.fsfreeze.freeze()
+		}
 		err := disk.CreateSnapshot(snapshotId, encryptKey, encFormat, encAlg)
 		if err != nil {
+			s.fsfreeze.thaw(fsfreezeEpoch)
 			return nil, errors.Wrap(err, "disk.CreateSnapshot")
 		}
-		task := NewGuestDiskSnapshotTask(ctx, s, disk, snapshotId)
+		task := NewGuestDiskSnapshotTask(ctx, s, disk, snapshotId, fsfreezeEpoch)
 		task.Start()
 		return nil, nil
 	} else {
-		return s.StaticSaveSnapshot(ctx, disk, snapshotId, encryptKey, encFormat, encAlg)
+		res, err := s.StaticSaveSnapshot(ctx, disk, snapshotId, encryptKey, encFormat, encAlg)
+		if err != nil {
+			return nil, err
+		}
+		// filesystems of a stopped guest are quiesced already
+		res.(*jsonutils.JSONDict).Set("application_consistent", jsonutils.NewBool(consistent))
+		return res, nil
 	}
 }
 
@@ -2734,9 +2837,33 @@ func (s *SKVMGuestInstance) PrepareDisksMigrate(liveMigrage bool) (*jsonutils.JS
 			}
 		}
 	}
+	if liveMigrage {
+		// target host opens lvs of shared vg while guest is still running here
+		if err := s.activateSLVMDisks(false); err != nil {
+			return nil, nil, false, errors.Wrap(err, "activate slvm disks shared")
+		}
+	}
 	return disksBackFile, diskSnapsChain, sysDiskHasTemplate, nil
 }
 
+// activateSLVMDisks sets lock mode of lvs of shared vg used by guest,
+// they are activated shared only in the window of live migration
+func (s *SKVMGuestInstance) activateSLVMDisks(exclusive bool) error {
+	for _, disk := range s.Desc.Disks {
+		if disk.StorageType != api.STORAGE_SLVM {
+			continue
+		}
+		storage, ok := storageman.GetManager().GetStorage(disk.StorageId).(*storageman.SSLVMStorage)
+		if !ok {
+			return errors.Errorf("storage %s of disk %s is not slvm", disk.StorageId, disk.DiskId)
+		}
+		if err := storage.ActivateDisk(disk.DiskId, exclusive); err != nil {
+			return errors.Wrapf(err, "disk %s", disk.DiskId)
+		}
+	}
+	return nil
+}
+
 func (s *SKVMGuestInstance) prepareNicsForVolatileGuestResume() error {
 	for _, nic := range s.Desc.Nics {
 		bridge := nic.Bridge
@@ -2751,8 +2878,8 @@ func (s *SKVMGuestInstance) prepareNicsForVolatileGuestResume() error {
 	return nil
 }
 
-func (s *SKVMGuestInstance) onlineResizeDisk(ctx context.Context, diskId string, sizeMB int64) {
-	task := NewGuestOnlineResizeDiskTask(ctx, s, diskId, sizeMB)
+func (s *SKVMGuestInstance) onlineResizeDisk(ctx context.Context, diskId string, sizeMB int64, ioThrottle *api.DiskIoThrottle) {
+	task := NewGuestOnlineResizeDiskTask(ctx, s, diskId, sizeMB, ioThrottle)
 	task.Start()
 }
 
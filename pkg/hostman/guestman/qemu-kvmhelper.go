@@ -465,6 +465,24 @@ function nic_mtu() {
 }
 `
 
+	// metadata may be changed after desc created
+	s.initTpmDesc()
+	if s.Desc.Tpm != nil {
+		swtpmScript, err := s.generateSwtpmScript(jsonutils.QueryBoolean(data, "need_migrate", false))
+		if err != nil {
+			return "", errors.Wrap(err, "generateSwtpmScript")
+		}
+		cmd += swtpmScript
+	}
+
+	if len(s.Desc.FileShares) > 0 {
+		virtiofsdScript, err := s.generateVirtiofsdScript()
+		if err != nil {
+			return "", errors.Wrap(err, "generateVirtiofsdScript")
+		}
+		cmd += virtiofsdScript
+	}
+
 	// Generate Start VM script
 	cmd += `CMD="$QEMU_CMD $QEMU_CMD_KVM_ARG`
 
@@ -486,9 +504,16 @@ function nic_mtu() {
 
 	input.EnableUUID = options.HostOptions.EnableVmUuid
 	if s.Desc.Bios == qemu.BIOS_UEFI {
-		if len(input.OVMFPath) == 0 {
-			input.OVMFPath = options.HostOptions.OvmfPath
+		ovmfPath, ovmfVarsPath, err := s.prepareOvmf()
+		if err != nil {
+			return "", errors.Wrap(err, "prepareOvmf")
 		}
+		input.OVMFPath = ovmfPath
+		input.OVMFVarsPath = ovmfVarsPath
+		input.SecureBoot = s.isSecureBoot()
+	}
+	if s.Desc.MachineDesc != nil {
+		s.Desc.MachineDesc.Smm = s.Desc.Bios == qemu.BIOS_UEFI && s.isSecureBoot() && input.QemuArch != qemu.Arch_aarch64
 	}
 
 	// inject usb devices
@@ -871,7 +896,7 @@ func (s *SKVMGuestInstance) initMemDesc(memSizeMB int64) {
 func (s *SKVMGuestInstance) memObjectType() string {
 	if s.manager.host.IsHugepagesEnabled() {
 		return "memory-backend-file"
-	} else if s.isMemcleanEnabled() {
+	} else if s.isMemcleanEnabled() || s.isVirtiofsEnabled() {
 		return "memory-backend-memfd"
 	} else {
 		return "memory-backend-ram"
@@ -891,6 +916,12 @@ func (s *SKVMGuestInstance) initDefaultMemObject(memSizeMB int64) {
 			"size":  fmt.Sprintf("%dM", memSizeMB),
 			"share": "on", "prealloc": "on",
 		}
+	} else if s.isVirtiofsEnabled() {
+		// virtiofsd maps guest memory through vhost-user
+		s.Desc.MemDesc.Mem.Options = map[string]string{
+			"size":  fmt.Sprintf("%dM", memSizeMB),
+			"share": "on",
+		}
 	} else {
 		s.Desc.MemDesc.Mem.Options = map[string]string{
 			"size": fmt.Sprintf("%dM", memSizeMB),
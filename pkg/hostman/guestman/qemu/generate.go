@@ -164,6 +164,9 @@ func generateMachineOption(machine string, machineDesc *desc.SGuestMachine) stri
 	if machineDesc.GicVersion != nil {
 		cmd += fmt.Sprintf(",gic-version=%s", *machineDesc.GicVersion)
 	}
+	if machineDesc.Smm {
+		cmd += ",smm=on"
+	}
 
 	return cmd
 }
@@ -581,6 +584,39 @@ func generatePvpanicDeviceOption(pvpanic *desc.SGuestPvpanic) string {
 	return fmt.Sprintf("-device pvpanic,id=%s,ioport=0x%x", pvpanic.Id, pvpanic.Ioport)
 }
 
+func generateTpmOptions(tpm *desc.SGuestTpm) []string {
+	opts := make([]string, 0)
+	opts = append(opts, chardevOption(tpm.Socket))
+	opts = append(opts, fmt.Sprintf("-tpmdev emulator,id=%s,chardev=%s", tpm.Id, tpm.Socket.Id))
+	opts = append(opts, fmt.Sprintf("-device %s,tpmdev=%s", tpm.DevType, tpm.Id))
+	return opts
+}
+
+func generateBIOSOptions(drvOpt QemuOptions, input *GenerateStartOptionsInput) ([]string, error) {
+	if input.GuestDesc.Bios != BIOS_UEFI {
+		return nil, nil
+	}
+	if input.OVMFPath == "" {
+		return nil, errors.Errorf("input OVMF path is empty")
+	}
+	if input.OVMFVarsPath == "" {
+		return nil, errors.Errorf("input OVMF vars path is empty")
+	}
+	opts := []string{drvOpt.BIOS(input.OVMFPath, input.OVMFVarsPath)}
+	if input.SecureBoot && input.QemuArch != Arch_aarch64 {
+		// only code running in SMM could write the secure boot variables
+		opts = append(opts, "-global driver=cfi.pflash01,property=secure,value=on")
+	}
+	return opts, nil
+}
+
+func generateFileShareOptions(share *desc.SGuestFileShare) []string {
+	return []string{
+		chardevOption(share.Socket),
+		generatePCIDeviceOption(share.Pci),
+	}
+}
+
 func getMigrateOptions(drvOpt QemuOptions, input *GenerateStartOptionsInput) []string {
 	opts := make([]string, 0)
 	if input.NeedMigrate {
@@ -611,6 +647,8 @@ type GenerateStartOptionsInput struct {
 	OVNIntegrationBridge string
 	Devices              []string
 	OVMFPath             string
+	OVMFVarsPath         string
+	SecureBoot           bool
 	VNCPort              uint
 	VNCPassword          bool
 	EnableLog            bool
@@ -705,16 +743,11 @@ func GenerateStartOptions(
 	opts = append(opts, drvOpt.Boot(bootOrder, enableMenu))
 
 	// bios
-	if input.GuestDesc.Bios == BIOS_UEFI {
-		if input.OVMFPath == "" {
-			return "", errors.Errorf("input OVMF path is empty")
-		}
-		fmOpt, err := drvOpt.BIOS(input.OVMFPath, input.HomeDir)
-		if err != nil {
-			return "", errors.Wrap(err, "bios option")
-		}
-		opts = append(opts, fmOpt)
+	biosOpts, err := generateBIOSOptions(drvOpt, input)
+	if err != nil {
+		return "", err
 	}
+	opts = append(opts, biosOpts...)
 
 	if input.OsName == OS_NAME_MACOS {
 		opts = append(opts, drvOpt.Device("isa-applesmc,osk=ourhardworkbythesewordsguardedpleasedontsteal(c)AppleComputerInc"))
@@ -790,6 +823,11 @@ func GenerateStartOptions(
 		opts = append(opts, generateIsolatedDeviceOptions(input.GuestDesc)...)
 	}
 
+	// virtio-fs file shares
+	for _, share := range input.GuestDesc.FileShares {
+		opts = append(opts, generateFileShareOptions(share)...)
+	}
+
 	// pidfile
 	opts = append(opts, drvOpt.Pidfile(input.PidFilePath))
 
@@ -809,11 +847,21 @@ func GenerateStartOptions(
 		opts = append(opts, getRNGRandomOptions(input.GuestDesc.Rng)...)
 	}
 
+	// balloon device
+	if input.GuestDesc.Balloon != nil {
+		opts = append(opts, generatePCIDeviceOption(input.GuestDesc.Balloon.PCIDevice))
+	}
+
 	// serial device
 	if input.GuestDesc.IsaSerial != nil {
 		opts = append(opts, generateISASerialOptions(input.GuestDesc.IsaSerial)...)
 	}
 
+	// tpm device
+	if input.GuestDesc.Tpm != nil {
+		opts = append(opts, generateTpmOptions(input.GuestDesc.Tpm)...)
+	}
+
 	// migrate options
 	opts = append(opts, getMigrateOptions(drvOpt, input)...)
 
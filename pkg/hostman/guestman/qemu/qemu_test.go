@@ -15,9 +15,12 @@
 package qemu
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
 )
 
 func Test_baseOptions(t *testing.T) {
@@ -44,3 +47,125 @@ func Test_baseOptions(t *testing.T) {
 	assert.Equal("-vnc :5900,password", opt.VNC(5900, true))
 	assert.Equal("-vnc :5900", opt.VNC(5900, false))
 }
+
+func Test_generateTpmOptions(t *testing.T) {
+	newTpm := func(devType string) *desc.SGuestTpm {
+		socket := desc.NewCharDev("socket", "chrtpm", "")
+		socket.Options = map[string]string{"path": "/opt/cloud/workspace/servers/guest/swtpm.sock"}
+		return &desc.SGuestTpm{Socket: socket, Id: "tpm0", DevType: devType}
+	}
+	cases := []struct {
+		name string
+		tpm  *desc.SGuestTpm
+		want []string
+	}{
+		{
+			name: "x86_64 crb",
+			tpm:  newTpm("tpm-crb"),
+			want: []string{
+				"-chardev socket,id=chrtpm,path=/opt/cloud/workspace/servers/guest/swtpm.sock",
+				"-tpmdev emulator,id=tpm0,chardev=chrtpm",
+				"-device tpm-crb,tpmdev=tpm0",
+			},
+		},
+		{
+			name: "aarch64 tis",
+			tpm:  newTpm("tpm-tis-device"),
+			want: []string{
+				"-chardev socket,id=chrtpm,path=/opt/cloud/workspace/servers/guest/swtpm.sock",
+				"-tpmdev emulator,id=tpm0,chardev=chrtpm",
+				"-device tpm-tis-device,tpmdev=tpm0",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, generateTpmOptions(c.tpm))
+		})
+	}
+}
+
+func Test_generateBIOSOptions(t *testing.T) {
+	const (
+		ovmf        = "/opt/cloud/contrib/OVMF.fd"
+		secbootOvmf = "/opt/cloud/contrib/OVMF_CODE.secboot.fd"
+		vars        = "/opt/cloud/workspace/servers/guest/OVMF_VARS.fd"
+		pflash      = "-drive if=pflash,format=raw,unit=0,file=%s,readonly=on -drive if=pflash,format=raw,unit=1,file=%s"
+		secure      = "-global driver=cfi.pflash01,property=secure,value=on"
+	)
+	cases := []struct {
+		name    string
+		input   *GenerateStartOptionsInput
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "legacy bios",
+			input: &GenerateStartOptionsInput{
+				QemuArch:  Arch_x86_64,
+				GuestDesc: &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: "BIOS"}},
+			},
+		},
+		{
+			name: "uefi",
+			input: &GenerateStartOptionsInput{
+				QemuArch:     Arch_x86_64,
+				OVMFPath:     ovmf,
+				OVMFVarsPath: vars,
+				GuestDesc:    &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: BIOS_UEFI}},
+			},
+			want: []string{fmt.Sprintf(pflash, ovmf, vars)},
+		},
+		{
+			name: "uefi secure boot",
+			input: &GenerateStartOptionsInput{
+				QemuArch:     Arch_x86_64,
+				OVMFPath:     secbootOvmf,
+				OVMFVarsPath: vars,
+				SecureBoot:   true,
+				GuestDesc:    &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: BIOS_UEFI}},
+			},
+			want: []string{fmt.Sprintf(pflash, secbootOvmf, vars), secure},
+		},
+		{
+			name: "aarch64 secure boot without smm",
+			input: &GenerateStartOptionsInput{
+				QemuArch:     Arch_aarch64,
+				OVMFPath:     secbootOvmf,
+				OVMFVarsPath: vars,
+				SecureBoot:   true,
+				GuestDesc:    &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: BIOS_UEFI}},
+			},
+			want: []string{fmt.Sprintf(pflash, secbootOvmf, vars)},
+		},
+		{
+			name: "uefi without ovmf",
+			input: &GenerateStartOptionsInput{
+				QemuArch:     Arch_x86_64,
+				OVMFVarsPath: vars,
+				GuestDesc:    &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: BIOS_UEFI}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "uefi without vars",
+			input: &GenerateStartOptionsInput{
+				QemuArch:  Arch_x86_64,
+				OVMFPath:  ovmf,
+				GuestDesc: &desc.SGuestDesc{SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: BIOS_UEFI}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opts, err := generateBIOSOptions(newBaseOptions(c.input.QemuArch), c.input)
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, opts)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"os"
+	"path"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/userdata"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/qemu"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+const (
+	OVMF_VARS_FILE         = "OVMF_VARS.fd"
+	OVMF_SECBOOT_VARS_FILE = "OVMF_VARS.secboot.fd"
+
+	// metadata value is stored as text, skip syncing oversize vars
+	UEFI_NVRAM_MAX_SYNC_SIZE = 60 * 1024
+)
+
+func (s *SKVMGuestInstance) isSecureBoot() bool {
+	return s.Desc.Metadata[api.VM_METADATA_SECURE_BOOT] == "true"
+}
+
+func (s *SKVMGuestInstance) getOvmfVarsPath() string {
+	if s.isSecureBoot() {
+		return path.Join(s.HomeDir(), OVMF_SECBOOT_VARS_FILE)
+	}
+	return path.Join(s.HomeDir(), OVMF_VARS_FILE)
+}
+
+// prepareOvmf returns OVMF code and per guest vars path, vars is restored from
+// the copy synced to region if any, e.g. guest is cloned or migrated, otherwise
+// created from template
+func (s *SKVMGuestInstance) prepareOvmf() (string, string, error) {
+	ovmfPath, varsTemplate := options.HostOptions.OvmfPath, options.HostOptions.OvmfPath
+	if s.isSecureBoot() {
+		ovmfPath, varsTemplate = options.HostOptions.OvmfSecbootPath, options.HostOptions.OvmfSecbootVarsPath
+		if !fileutils2.Exists(ovmfPath) || !fileutils2.Exists(varsTemplate) {
+			return "", "", errors.Wrapf(errors.ErrNotFound, "secure boot OVMF %s or vars template %s", ovmfPath, varsTemplate)
+		}
+	}
+	varsPath := s.getOvmfVarsPath()
+	if fileutils2.Exists(varsPath) {
+		return ovmfPath, varsPath, nil
+	}
+	if nvram := s.Desc.Metadata[api.VM_METADATA_UEFI_NVRAM]; len(nvram) > 0 {
+		err := restoreUefiNvram(nvram, varsTemplate, varsPath)
+		if err == nil {
+			return ovmfPath, varsPath, nil
+		}
+		log.Warningf("guest %s restore uefi nvram: %s, recreate from template", s.Id, err)
+	}
+	if err := procutils.NewRemoteCommandAsFarAsPossible("cp", "-f", varsTemplate, varsPath).Run(); err != nil {
+		return "", "", errors.Wrap(err, "failed copy ovmf vars")
+	}
+	return ovmfPath, varsPath, nil
+}
+
+func restoreUefiNvram(nvram, varsTemplate, varsPath string) error {
+	data, err := userdata.Decode(nvram)
+	if err != nil {
+		return errors.Wrap(err, "decode")
+	}
+	// saved vars may be created from other template before secure boot toggled
+	stat, err := os.Stat(varsTemplate)
+	if err != nil {
+		return errors.Wrap(err, "stat vars template")
+	}
+	if stat.Size() != int64(len(data)) {
+		return errors.Errorf("size %d mismatch with template %d", len(data), stat.Size())
+	}
+	return fileutils2.FilePutContents(varsPath, data, false)
+}
+
+// syncUefiNvramMetadata is replaceable in tests
+var syncUefiNvramMetadata = func(s *SKVMGuestInstance, meta *jsonutils.JSONDict) error {
+	return s.SyncMetadata(meta)
+}
+
+// getUefiNvramToSync returns the encoded guest vars if changed since last synced
+func (s *SKVMGuestInstance) getUefiNvramToSync() (string, bool) {
+	if s.Desc == nil || s.Desc.Bios != qemu.BIOS_UEFI {
+		return "", false
+	}
+	varsPath := s.getOvmfVarsPath()
+	if !fileutils2.Exists(varsPath) {
+		return "", false
+	}
+	data, err := fileutils2.FileGetContents(varsPath)
+	if err != nil {
+		log.Errorf("guest %s read uefi vars: %s", s.Id, err)
+		return "", false
+	}
+	nvram, err := userdata.Encode(data)
+	if err != nil {
+		log.Errorf("guest %s encode uefi vars: %s", s.Id, err)
+		return "", false
+	}
+	if len(nvram) > UEFI_NVRAM_MAX_SYNC_SIZE {
+		log.Warningf("guest %s uefi vars too large to sync: %d", s.Id, len(nvram))
+		return "", false
+	}
+	if s.Desc.Metadata[api.VM_METADATA_UEFI_NVRAM] == nvram {
+		return "", false
+	}
+	return nvram, true
+}
+
+// syncUefiNvram saves guest vars to region, so that boot entries and enrolled
+// keys are kept across migrate, clone, snapshot and backup. It is called once
+// qemu exited or migrated, vars are not changed afterwards
+func (s *SKVMGuestInstance) syncUefiNvram() {
+	nvram, ok := s.getUefiNvramToSync()
+	if !ok {
+		return
+	}
+	meta := jsonutils.NewDict()
+	meta.Set(api.VM_METADATA_UEFI_NVRAM, jsonutils.NewString(nvram))
+	if err := syncUefiNvramMetadata(s, meta); err != nil {
+		return
+	}
+	s.Desc.Metadata[api.VM_METADATA_UEFI_NVRAM] = nvram
+	if err := s.SaveLiveDesc(s.Desc); err != nil {
+		log.Errorf("guest %s save desc: %s", s.Id, err)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"yunion.io/x/jsonutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/userdata"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/qemu"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+func newTestUefiGuest(t *testing.T, bios string, vars []byte, metadata map[string]string) *SKVMGuestInstance {
+	s := &SKVMGuestInstance{
+		Id:      "6a1c3e0e-9a44-4a41-8d2b-0f4f3d6c2b1a",
+		manager: &SGuestManager{ServersPath: t.TempDir()},
+		Desc: &desc.SGuestDesc{
+			SGuestHardwareDesc: desc.SGuestHardwareDesc{Bios: bios},
+			SGuestMetaDesc:     desc.SGuestMetaDesc{Metadata: metadata},
+		},
+	}
+	if err := os.MkdirAll(s.HomeDir(), 0755); err != nil {
+		t.Fatalf("mkdir: %s", err)
+	}
+	if vars != nil {
+		if err := os.WriteFile(s.getOvmfVarsPath(), vars, 0644); err != nil {
+			t.Fatalf("write vars: %s", err)
+		}
+	}
+	return s
+}
+
+func encodeTestNvram(t *testing.T, data []byte) string {
+	nvram, err := userdata.Encode(string(data))
+	if err != nil {
+		t.Fatalf("encode: %s", err)
+	}
+	return nvram
+}
+
+func TestSKVMGuestInstanceGetUefiNvramToSync(t *testing.T) {
+	vars := bytes.Repeat([]byte{0xff}, 4096)
+	cases := []struct {
+		name     string
+		bios     string
+		vars     []byte
+		synced   []byte
+		wantSync bool
+	}{
+		{
+			name: "bios guest",
+			bios: "BIOS",
+			vars: vars,
+		},
+		{
+			name: "no vars file",
+			bios: qemu.BIOS_UEFI,
+		},
+		{
+			name:     "vars changed",
+			bios:     qemu.BIOS_UEFI,
+			vars:     vars,
+			synced:   bytes.Repeat([]byte{0x00}, 4096),
+			wantSync: true,
+		},
+		{
+			name:     "never synced",
+			bios:     qemu.BIOS_UEFI,
+			vars:     vars,
+			wantSync: true,
+		},
+		{
+			name:   "vars unchanged",
+			bios:   qemu.BIOS_UEFI,
+			vars:   vars,
+			synced: vars,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			metadata := map[string]string{}
+			if c.synced != nil {
+				metadata[api.VM_METADATA_UEFI_NVRAM] = encodeTestNvram(t, c.synced)
+			}
+			s := newTestUefiGuest(t, c.bios, c.vars, metadata)
+			nvram, ok := s.getUefiNvramToSync()
+			if ok != c.wantSync {
+				t.Fatalf("want sync %v, got %v", c.wantSync, ok)
+			}
+			if ok && nvram != encodeTestNvram(t, c.vars) {
+				t.Errorf("nvram to sync mismatch with vars file")
+			}
+		})
+	}
+}
+
+func TestSKVMGuestInstanceGetUefiNvramToSyncOversize(t *testing.T) {
+	// random content is not compressible
+	vars := make([]byte, UEFI_NVRAM_MAX_SYNC_SIZE)
+	seed := uint32(1)
+	for i := range vars {
+		seed = seed*1664525 + 1013904223
+		vars[i] = byte(seed >> 24)
+	}
+	s := newTestUefiGuest(t, qemu.BIOS_UEFI, vars, map[string]string{})
+	if _, ok := s.getUefiNvramToSync(); ok {
+		t.Errorf("oversize vars should not be synced")
+	}
+}
+
+func TestSKVMGuestInstanceStopSyncUefiNvram(t *testing.T) {
+	vars := bytes.Repeat([]byte{0x5a}, 4096)
+	s := newTestUefiGuest(t, qemu.BIOS_UEFI, vars, map[string]string{})
+	if err := os.WriteFile(s.GetStopScriptPath(), []byte("exit 0\n"), 0755); err != nil {
+		t.Fatalf("write stop script: %s", err)
+	}
+
+	synced := []jsonutils.JSONObject{}
+	origSync := syncUefiNvramMetadata
+	syncUefiNvramMetadata = func(s *SKVMGuestInstance, meta *jsonutils.JSONDict) error {
+		synced = append(synced, meta)
+		return nil
+	}
+	defer func() { syncUefiNvramMetadata = origSync }()
+
+	if !s.Stop() {
+		t.Fatalf("Stop failed")
+	}
+	if len(synced) != 1 {
+		t.Fatalf("want nvram synced once on stop, got %d", len(synced))
+	}
+	nvram, _ := synced[0].GetString(api.VM_METADATA_UEFI_NVRAM)
+	if nvram != encodeTestNvram(t, vars) {
+		t.Errorf("synced nvram mismatch with vars file")
+	}
+	if s.Desc.Metadata[api.VM_METADATA_UEFI_NVRAM] != nvram {
+		t.Errorf("desc metadata not updated")
+	}
+	if _, err := os.Stat(s.GetDescFilePath()); err != nil {
+		t.Errorf("desc not saved: %s", err)
+	}
+
+	// nothing changed on the next stop
+	s.Stop()
+	if len(synced) != 1 {
+		t.Errorf("unchanged nvram should not be synced again, got %d syncs", len(synced))
+	}
+}
+
+func TestSKVMGuestInstancePrepareOvmf(t *testing.T) {
+	contrib := t.TempDir()
+	ovmf := path.Join(contrib, "OVMF.fd")
+	secbootOvmf := path.Join(contrib, "OVMF_CODE.secboot.fd")
+	secbootVars := path.Join(contrib, "OVMF_VARS.secboot.fd")
+	for fp, data := range map[string][]byte{
+		ovmf:        bytes.Repeat([]byte{0x01}, 4096),
+		secbootOvmf: bytes.Repeat([]byte{0x02}, 4096),
+		secbootVars: bytes.Repeat([]byte{0x03}, 4096),
+	} {
+		if err := os.WriteFile(fp, data, 0644); err != nil {
+			t.Fatalf("write %s: %s", fp, err)
+		}
+	}
+	origOptions := options.HostOptions
+	defer func() { options.HostOptions = origOptions }()
+
+	cases := []struct {
+		name         string
+		secureBoot   bool
+		secbootOvmf  string
+		vars         []byte
+		wantOvmf     string
+		wantVarsFile string
+		wantVars     []byte
+		wantErr      bool
+	}{
+		{
+			name:         "uefi",
+			secbootOvmf:  secbootOvmf,
+			wantOvmf:     ovmf,
+			wantVarsFile: OVMF_VARS_FILE,
+			wantVars:     bytes.Repeat([]byte{0x01}, 4096),
+		},
+		{
+			name:         "secure boot",
+			secureBoot:   true,
+			secbootOvmf:  secbootOvmf,
+			wantOvmf:     secbootOvmf,
+			wantVarsFile: OVMF_SECBOOT_VARS_FILE,
+			wantVars:     bytes.Repeat([]byte{0x03}, 4096),
+		},
+		{
+			name:         "secure boot keeps existing vars",
+			secureBoot:   true,
+			secbootOvmf:  secbootOvmf,
+			vars:         bytes.Repeat([]byte{0x5a}, 4096),
+			wantOvmf:     secbootOvmf,
+			wantVarsFile: OVMF_SECBOOT_VARS_FILE,
+			wantVars:     bytes.Repeat([]byte{0x5a}, 4096),
+		},
+		{
+			name:        "secure boot ovmf missing",
+			secureBoot:  true,
+			secbootOvmf: path.Join(contrib, "missing.fd"),
+			wantErr:     true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			options.HostOptions.OvmfPath = ovmf
+			options.HostOptions.OvmfSecbootPath = c.secbootOvmf
+			options.HostOptions.OvmfSecbootVarsPath = secbootVars
+			metadata := map[string]string{}
+			if c.secureBoot {
+				metadata[api.VM_METADATA_SECURE_BOOT] = "true"
+			}
+			s := newTestUefiGuest(t, qemu.BIOS_UEFI, c.vars, metadata)
+			ovmfPath, varsPath, err := s.prepareOvmf()
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got ovmf %s", ovmfPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareOvmf: %s", err)
+			}
+			if ovmfPath != c.wantOvmf {
+				t.Errorf("want ovmf %s, got %s", c.wantOvmf, ovmfPath)
+			}
+			if want := path.Join(s.HomeDir(), c.wantVarsFile); varsPath != want {
+				t.Errorf("want vars %s, got %s", want, varsPath)
+			}
+			data, err := os.ReadFile(varsPath)
+			if err != nil {
+				t.Fatalf("read vars: %s", err)
+			}
+			if !bytes.Equal(data, c.wantVars) {
+				t.Errorf("vars content mismatch")
+			}
+		})
+	}
+}
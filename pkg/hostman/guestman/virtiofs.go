@@ -0,0 +1,157 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// virtiofsd creates the socket shortly after started, wait for it before qemu connects
+const VIRTIOFSD_SOCKET_WAIT_TIMEOUT = 10 * time.Second
+
+// isVirtiofsEnabled guest memory must be shared with virtiofsd, keep it shared
+// once any share is added so that later shares could be hotplugged
+func (s *SKVMGuestInstance) isVirtiofsEnabled() bool {
+	return len(s.Desc.FileShares) > 0 || s.Desc.Metadata[api.VM_METADATA_ENABLE_VIRTIOFS] == "true"
+}
+
+func (s *SKVMGuestInstance) getVirtiofsSocketPath(share *desc.SGuestFileShare) string {
+	return path.Join(s.HomeDir(), fmt.Sprintf("virtiofs-%s.sock", share.Id))
+}
+
+func (s *SKVMGuestInstance) getVirtiofsLogPath(share *desc.SGuestFileShare) string {
+	return path.Join(s.HomeDir(), fmt.Sprintf("virtiofs-%s.log", share.Id))
+}
+
+func (s *SKVMGuestInstance) initFileShares(pciRoot, pciBridge *desc.PCIController) {
+	cont := pciRoot
+	if pciBridge != nil {
+		cont = pciBridge
+	}
+	for i := range s.Desc.FileShares {
+		s.initFileShareDevice(s.Desc.FileShares[i], cont.CType)
+	}
+}
+
+func (s *SKVMGuestInstance) initFileShareDevice(share *desc.SGuestFileShare, cType desc.PCI_CONTROLLER_TYPE) {
+	charId := fmt.Sprintf("vfschr-%s", share.Id)
+	share.Socket = &desc.CharDev{
+		Backend: "socket",
+		Id:      charId,
+		Options: map[string]string{
+			"path": s.getVirtiofsSocketPath(share),
+		},
+	}
+	share.Pci = desc.NewPCIDevice(cType, "vhost-user-fs-pci", fmt.Sprintf("vfs-%s", share.Id))
+	share.Pci.Options = map[string]string{
+		"chardev": charId,
+		"tag":     share.MountTag,
+	}
+}
+
+// getFileShareSourceDir returns host directory of the share, storage backed
+// share is relative to storage mount point
+func (s *SKVMGuestInstance) getFileShareSourceDir(share *desc.SGuestFileShare) (string, error) {
+	if len(share.StorageId) == 0 {
+		return share.Path, nil
+	}
+	storage := storageman.GetManager().GetStorage(share.StorageId)
+	if storage == nil {
+		return "", errors.Wrapf(errors.ErrNotFound, "storage %s", share.StorageId)
+	}
+	return path.Join(storage.GetPath(), share.Path), nil
+}
+
+// shellQuote quotes str as a single word of sh
+func shellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
+
+func (s *SKVMGuestInstance) generateVirtiofsdCmd(share *desc.SGuestFileShare) (string, error) {
+	virtiofsd := options.HostOptions.VirtiofsdPath
+	if !fileutils2.Exists(virtiofsd) {
+		return "", errors.Wrapf(errors.ErrNotFound, "virtiofsd %s", virtiofsd)
+	}
+	sourceDir, err := s.getFileShareSourceDir(share)
+	if err != nil {
+		return "", err
+	}
+	cmd := fmt.Sprintf("%s --socket-path=%s --shared-dir=%s --cache=auto",
+		shellQuote(virtiofsd), shellQuote(s.getVirtiofsSocketPath(share)), shellQuote(sourceDir))
+	if share.ReadOnly {
+		cmd += " --readonly"
+	}
+	return cmd, nil
+}
+
+// virtiofsdScript starts virtiofsd of the share and waits for its socket,
+// virtiofsd serves a single connection and exits along with qemu
+func (s *SKVMGuestInstance) virtiofsdScript(share *desc.SGuestFileShare) (string, error) {
+	virtiofsdCmd, err := s.generateVirtiofsdCmd(share)
+	if err != nil {
+		return "", errors.Wrapf(err, "file share %s", share.MountTag)
+	}
+	cmd := ""
+	if len(share.StorageId) > 0 {
+		sourceDir, _ := s.getFileShareSourceDir(share)
+		cmd += fmt.Sprintf("mkdir -p %s\n", shellQuote(sourceDir))
+	}
+	socket := shellQuote(s.getVirtiofsSocketPath(share))
+	cmd += fmt.Sprintf("rm -f %s\n", socket)
+	cmd += fmt.Sprintf("nohup %s > %s 2>&1 &\n", virtiofsdCmd, shellQuote(s.getVirtiofsLogPath(share)))
+	cmd += fmt.Sprintf("for i in $(seq 1 %d); do [ -S %s ] && break; sleep 0.1; done\n",
+		int(VIRTIOFSD_SOCKET_WAIT_TIMEOUT/(100*time.Millisecond)), socket)
+	return cmd, nil
+}
+
+func (s *SKVMGuestInstance) generateVirtiofsdScript() (string, error) {
+	cmd := ""
+	for _, share := range s.Desc.FileShares {
+		script, err := s.virtiofsdScript(share)
+		if err != nil {
+			return "", err
+		}
+		cmd += script
+	}
+	return cmd, nil
+}
+
+// startVirtiofsd starts virtiofsd of hotplugged share
+func (s *SKVMGuestInstance) startVirtiofsd(share *desc.SGuestFileShare) error {
+	script, err := s.virtiofsdScript(share)
+	if err != nil {
+		return err
+	}
+	output, err := procutils.NewRemoteCommandAsFarAsPossible("sh", "-c", script).Output()
+	if err != nil {
+		return errors.Wrapf(err, "start virtiofsd %s", output)
+	}
+	if socket := s.getVirtiofsSocketPath(share); !fileutils2.Exists(socket) {
+		return errors.Wrapf(errors.ErrTimeout, "wait virtiofsd socket %s", socket)
+	}
+	return nil
+}
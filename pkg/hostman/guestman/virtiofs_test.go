@@ -0,0 +1,106 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+func newFileShare(id string) *desc.SGuestFileShare {
+	return &desc.SGuestFileShare{
+		GuestFileShareJsonDesc: compute.GuestFileShareJsonDesc{Id: id, MountTag: id},
+	}
+}
+
+func TestSKVMGuestInstance_compareDescFileShares(t *testing.T) {
+	s := &SKVMGuestInstance{
+		Desc: &desc.SGuestDesc{
+			SGuestHardwareDesc: desc.SGuestHardwareDesc{
+				FileShares: []*desc.SGuestFileShare{newFileShare("a"), newFileShare("b")},
+			},
+		},
+	}
+	newDesc := &desc.SGuestDesc{
+		SGuestHardwareDesc: desc.SGuestHardwareDesc{
+			FileShares: []*desc.SGuestFileShare{newFileShare("b"), newFileShare("c")},
+		},
+	}
+	delShares, addShares := s.compareDescFileShares(newDesc)
+	if len(delShares) != 1 || delShares[0].Id != "a" {
+		t.Errorf("want delete share a, got %v", delShares)
+	}
+	if len(addShares) != 1 || addShares[0].Id != "c" {
+		t.Errorf("want add share c, got %v", addShares)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, str := range []string{
+		"/data/share",
+		"/data/a b",
+		"/data/a;touch pwned",
+		"/data/$(id)",
+		"/data/`id`",
+		"/data/it's",
+		"/data/'",
+	} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(str)).Output()
+		if err != nil {
+			t.Fatalf("%s: %v", str, err)
+		}
+		if string(out) != str {
+			t.Errorf("want %q, got %q", str, out)
+		}
+	}
+}
+
+func TestSKVMGuestInstanceVirtiofsdScript(t *testing.T) {
+	virtiofsd := path.Join(t.TempDir(), "virtiofsd")
+	if err := os.WriteFile(virtiofsd, nil, 0755); err != nil {
+		t.Fatalf("write virtiofsd: %v", err)
+	}
+	saved := options.HostOptions.VirtiofsdPath
+	options.HostOptions.VirtiofsdPath = virtiofsd
+	defer func() { options.HostOptions.VirtiofsdPath = saved }()
+
+	s := &SKVMGuestInstance{
+		Id:      "guest0",
+		manager: &SGuestManager{ServersPath: "/opt/cloud/workspace/servers"},
+	}
+	share := newFileShare("share0")
+	share.Path = "/data/a b;touch /pwned"
+	share.ReadOnly = true
+	script, err := s.virtiofsdScript(share)
+	if err != nil {
+		t.Fatalf("virtiofsdScript: %v", err)
+	}
+	for _, want := range []string{
+		"rm -f '/opt/cloud/workspace/servers/guest0/virtiofs-share0.sock'\n",
+		"nohup '" + virtiofsd + "' --socket-path='/opt/cloud/workspace/servers/guest0/virtiofs-share0.sock' --shared-dir='/data/a b;touch /pwned' --cache=auto --readonly > '/opt/cloud/workspace/servers/guest0/virtiofs-share0.log' 2>&1 &\n",
+		"[ -S '/opt/cloud/workspace/servers/guest0/virtiofs-share0.sock' ]",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("missing %q in\n%s", want, script)
+		}
+	}
+}
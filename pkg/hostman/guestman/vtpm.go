@@ -0,0 +1,150 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/remotefile"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// swtpm keeps whole tpm2 state in this file under the state dir
+const VTPM_STATE_FILE = "tpm2-00.permall"
+
+func (s *SKVMGuestInstance) isVtpmEnabled() bool {
+	return s.Desc.Metadata[api.VM_METADATA_ENABLE_VTPM] == "true"
+}
+
+func (s *SKVMGuestInstance) initTpmDesc() {
+	if s.isVtpmEnabled() {
+		s.Desc.Tpm = s.archMan.GenerateTpmDesc(s.getVtpmSocketPath())
+	} else {
+		s.Desc.Tpm = nil
+	}
+}
+
+func (s *SKVMGuestInstance) getVtpmSocketPath() string {
+	return path.Join(s.HomeDir(), "swtpm.sock")
+}
+
+// getGuestStorage is replaceable in tests
+var getGuestStorage = func(storageId string) storageman.IStorage {
+	return storageman.GetManager().GetStorage(storageId)
+}
+
+// getVtpmSharedStorage returns the file based shared storage of system disk,
+// vtpm state is stored alongside the disk there so that any host could reach it
+func (s *SKVMGuestInstance) getVtpmSharedStorage() storageman.IStorage {
+	if len(s.Desc.Disks) == 0 {
+		return nil
+	}
+	storage := getGuestStorage(s.Desc.Disks[0].StorageId)
+	if storage == nil || storage.IsLocal() {
+		return nil
+	}
+	if !utils.IsInStringArray(storage.StorageType(), api.SHARED_FILE_STORAGE) {
+		return nil
+	}
+	return storage
+}
+
+func (s *SKVMGuestInstance) isVtpmStateShared() bool {
+	return s.getVtpmSharedStorage() != nil
+}
+
+func (s *SKVMGuestInstance) GetVtpmStateDir() string {
+	if storage := s.getVtpmSharedStorage(); storage != nil {
+		return path.Join(storage.GetPath(), "vtpm", s.Id)
+	}
+	return path.Join(s.HomeDir(), "vtpm")
+}
+
+func (s *SKVMGuestInstance) GetVtpmStatePath() string {
+	return path.Join(s.GetVtpmStateDir(), VTPM_STATE_FILE)
+}
+
+func (s *SKVMGuestInstance) generateSwtpmScript(isMigrateDest bool) (string, error) {
+	swtpm := options.HostOptions.SwtpmPath
+	if !fileutils2.Exists(swtpm) {
+		return "", errors.Wrapf(errors.ErrNotFound, "swtpm %s", swtpm)
+	}
+	stateDir := s.GetVtpmStateDir()
+	socket := s.getVtpmSocketPath()
+
+	cmd := fmt.Sprintf("mkdir -p %s\n", stateDir)
+	cmd += fmt.Sprintf("rm -f %s\n", socket)
+	cmd += fmt.Sprintf("%s socket --tpm2 --tpmstate dir=%s --ctrl type=unixio,path=%s", swtpm, stateDir, socket)
+	cmd += fmt.Sprintf(" --pid file=%s --log file=%s --terminate --daemon",
+		path.Join(s.HomeDir(), "swtpm.pid"), path.Join(s.HomeDir(), "swtpm.log"))
+	if s.isVtpmStateShared() {
+		// state file is shared with migration peer, let source release the lock
+		// and dest not to touch the state until migration finished
+		migration := "release-lock-outgoing"
+		if isMigrateDest {
+			migration += ",incoming"
+		}
+		cmd += fmt.Sprintf(" --migration %s", migration)
+	}
+	cmd += "\n"
+	return cmd, nil
+}
+
+// prepareMigrateVtpmState fetches vtpm state from source host on cold migration,
+// qemu carries the state in migration stream on live migration
+func (s *SKVMGuestInstance) prepareMigrateVtpmState(ctx context.Context, vtpmUri string) error {
+	if len(vtpmUri) == 0 || !s.isVtpmEnabled() || s.isVtpmStateShared() {
+		return nil
+	}
+	stateDir := s.GetVtpmStateDir()
+	if err := procutils.NewRemoteCommandAsFarAsPossible("mkdir", "-p", stateDir).Run(); err != nil {
+		return errors.Wrapf(err, "mkdir -p %q", stateDir)
+	}
+	remoteFile := remotefile.NewRemoteFile(ctx, vtpmUri, s.GetVtpmStatePath(), false, "", -1, nil, "", "")
+	if err := remoteFile.Fetch(nil); err != nil {
+		return errors.Wrapf(err, "fetch vtpm state %s", vtpmUri)
+	}
+	return nil
+}
+
+// cleanVtpmState removes vtpm state on shared storage, state under home dir
+// is removed along with home dir
+func (s *SKVMGuestInstance) cleanVtpmState(migrated bool) {
+	if migrated || s.Desc == nil || !s.isVtpmEnabled() || !s.isVtpmStateShared() {
+		return
+	}
+	stateDir := s.GetVtpmStateDir()
+	if output, err := procutils.NewRemoteCommandAsFarAsPossible("rm", "-rf", stateDir).Output(); err != nil {
+		log.Errorf("rm vtpm state dir %s failed: %s %s", stateDir, err, output)
+	}
+}
+
+func GetVtpmStatePath(sid string) (string, error) {
+	guest, ok := guestManager.GetServer(sid)
+	if !ok {
+		return "", errors.Wrapf(errors.ErrNotFound, "guest %s", sid)
+	}
+	return guest.GetVtpmStatePath(), nil
+}
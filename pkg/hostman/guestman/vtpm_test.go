@@ -0,0 +1,150 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guestman
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/hostman/storageman"
+)
+
+type fakeVtpmStorage struct {
+	storageman.IStorage
+
+	storageType string
+	path        string
+}
+
+func (s *fakeVtpmStorage) IsLocal() bool {
+	return s.storageType == api.STORAGE_LOCAL
+}
+
+func (s *fakeVtpmStorage) StorageType() string {
+	return s.storageType
+}
+
+func (s *fakeVtpmStorage) GetPath() string {
+	return s.path
+}
+
+func writeTestFile(t *testing.T, fp string) {
+	if err := os.WriteFile(fp, []byte{}, 0644); err != nil {
+		t.Fatalf("write %s: %s", fp, err)
+	}
+}
+
+func TestSKVMGuestInstanceGenerateSwtpmScript(t *testing.T) {
+	swtpm := path.Join(t.TempDir(), "swtpm")
+	writeTestFile(t, swtpm)
+	origSwtpm := options.HostOptions.SwtpmPath
+	options.HostOptions.SwtpmPath = swtpm
+	defer func() { options.HostOptions.SwtpmPath = origSwtpm }()
+
+	storagePath := t.TempDir()
+	storages := map[string]storageman.IStorage{
+		"local": &fakeVtpmStorage{storageType: api.STORAGE_LOCAL, path: storagePath},
+		"nfs":   &fakeVtpmStorage{storageType: api.STORAGE_NFS, path: storagePath},
+		"rbd":   &fakeVtpmStorage{storageType: api.STORAGE_RBD, path: storagePath},
+	}
+	origGetStorage := getGuestStorage
+	getGuestStorage = func(storageId string) storageman.IStorage {
+		return storages[storageId]
+	}
+	defer func() { getGuestStorage = origGetStorage }()
+
+	cases := []struct {
+		name          string
+		storageId     string
+		migrateDest   bool
+		wantShared    bool
+		wantMigration string
+	}{
+		{
+			name: "no disk",
+		},
+		{
+			name:      "local storage",
+			storageId: "local",
+		},
+		{
+			name:        "local storage migrate dest",
+			storageId:   "local",
+			migrateDest: true,
+		},
+		{
+			name:      "block shared storage",
+			storageId: "rbd",
+		},
+		{
+			name:          "file shared storage",
+			storageId:     "nfs",
+			wantShared:    true,
+			wantMigration: " --migration release-lock-outgoing",
+		},
+		{
+			name:          "file shared storage migrate dest",
+			storageId:     "nfs",
+			migrateDest:   true,
+			wantShared:    true,
+			wantMigration: " --migration release-lock-outgoing,incoming",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestUefiGuest(t, "BIOS", nil, map[string]string{api.VM_METADATA_ENABLE_VTPM: "true"})
+			if len(c.storageId) > 0 {
+				disk := &desc.SGuestDisk{}
+				disk.StorageId = c.storageId
+				s.Desc.Disks = []*desc.SGuestDisk{disk}
+			}
+			stateDir := path.Join(s.HomeDir(), "vtpm")
+			if c.wantShared {
+				stateDir = path.Join(storagePath, "vtpm", s.Id)
+			}
+			if got := s.GetVtpmStateDir(); got != stateDir {
+				t.Fatalf("want state dir %s, got %s", stateDir, got)
+			}
+			socket := path.Join(s.HomeDir(), "swtpm.sock")
+			want := fmt.Sprintf("mkdir -p %s\nrm -f %s\n", stateDir, socket)
+			want += fmt.Sprintf("%s socket --tpm2 --tpmstate dir=%s --ctrl type=unixio,path=%s", swtpm, stateDir, socket)
+			want += fmt.Sprintf(" --pid file=%s/swtpm.pid --log file=%s/swtpm.log --terminate --daemon", s.HomeDir(), s.HomeDir())
+			want += c.wantMigration + "\n"
+			got, err := s.generateSwtpmScript(c.migrateDest)
+			if err != nil {
+				t.Fatalf("generateSwtpmScript: %s", err)
+			}
+			if got != want {
+				t.Errorf("want script:\n%s\ngot:\n%s", want, got)
+			}
+		})
+	}
+}
+
+func TestSKVMGuestInstanceGenerateSwtpmScriptNoSwtpm(t *testing.T) {
+	origSwtpm := options.HostOptions.SwtpmPath
+	options.HostOptions.SwtpmPath = path.Join(t.TempDir(), "swtpm")
+	defer func() { options.HostOptions.SwtpmPath = origSwtpm }()
+
+	s := newTestUefiGuest(t, "BIOS", nil, map[string]string{api.VM_METADATA_ENABLE_VTPM: "true"})
+	if _, err := s.generateSwtpmScript(false); err == nil {
+		t.Errorf("want error without swtpm binary")
+	}
+}
@@ -91,6 +91,8 @@ func (host *SHostService) RunService() {
 
 	var guestChan chan struct{}
 	guestman.Init(hostInstance, options.HostOptions.ServersPath)
+	hostInstance.SetNumaCpuStatsGetter(guestman.GetGuestManager().GetNumaNodeStats)
+	hostInstance.SetGuestMemoryStatsGetter(guestman.GetGuestManager().GetGuestMemoryStats)
 	guestman.GetGuestManager().InitQemuMaxCpus(
 		hostInstance.GetQemuMachineInfoList(), hostInstance.GetKVMMaxCpus(),
 	)
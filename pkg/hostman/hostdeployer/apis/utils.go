@@ -148,6 +148,10 @@ func GuestnetworksDescToDeployDesc(guestnetworks []*desc.SGuestNetwork) []*Nic {
 		nics[i].LinkUp = nic.LinkUp
 		nics[i].Mtu = int64(nic.Mtu)
 		//nics[i].Name = nic.Name
+		nics[i].Ip6 = nic.Ip6
+		nics[i].Gateway6 = nic.Gateway6
+		nics[i].Masklen6 = int32(nic.Masklen6)
+		nics[i].Dns6 = nic.Dns6
 	}
 
 	return nics
@@ -0,0 +1,139 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostdhcp
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"yunion.io/x/log"
+
+	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	guestman "yunion.io/x/onecloud/pkg/hostman/guestman/types"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/dhcp"
+)
+
+// SGuestDHCP6Server serves stateful DHCPv6 and router advertisements to
+// guests of classic networks on a host bridge, the guest of ovn vpcs are
+// served by ovn itself.
+type SGuestDHCP6Server struct {
+	server *dhcp.DHCP6Server
+
+	iface string
+}
+
+func NewGuestDHCP6Server(iface string) (*SGuestDHCP6Server, error) {
+	server, err := dhcp.NewDHCP6Server(iface)
+	if err != nil {
+		return nil, err
+	}
+	return &SGuestDHCP6Server{
+		server: server,
+		iface:  iface,
+	}, nil
+}
+
+func (s *SGuestDHCP6Server) Start(blocking bool) {
+	log.Infof("SGuestDHCP6Server starting ...")
+	serve := func() {
+		err := s.server.ListenAndServe(s)
+		if err != nil {
+			log.Errorf("DHCPv6 serve error: %s", err)
+		}
+	}
+	if blocking {
+		serve()
+	} else {
+		go serve()
+	}
+}
+
+func (s *SGuestDHCP6Server) getGuestNic(mac net.HardwareAddr) (*desc.SGuestDesc, *desc.SGuestNetwork) {
+	if guestman.GuestDescGetter == nil {
+		return nil, nil
+	}
+	var (
+		ip, port    = "", ""
+		isCandidate = false
+	)
+	guestDesc, guestNic := guestman.GuestDescGetter.GetGuestNicDesc(mac.String(), ip, port, s.iface, isCandidate)
+	if guestNic == nil {
+		guestDesc, guestNic = guestman.GuestDescGetter.GetGuestNicDesc(mac.String(), ip, port, s.iface, !isCandidate)
+	}
+	if guestNic == nil || guestNic.Virtual || len(guestNic.Ip6) == 0 {
+		return nil, nil
+	}
+	return guestDesc, guestNic
+}
+
+func (s *SGuestDHCP6Server) getGuestConfig(guestNic *desc.SGuestNetwork, srvMac net.HardwareAddr) *dhcp.ResponseConfig6 {
+	conf := &dhcp.ResponseConfig6{
+		ServerMac:         srvMac,
+		ClientIP:          net.ParseIP(guestNic.Ip6),
+		PreferredLifetime: time.Duration(options.HostOptions.DhcpRenewalTime) * time.Second,
+		ValidLifetime:     time.Duration(options.HostOptions.DhcpLeaseTime) * time.Second,
+	}
+	if len(guestNic.Dns6) > 0 {
+		for _, dns := range strings.Split(guestNic.Dns6, ",") {
+			if ip := net.ParseIP(dns); ip != nil {
+				conf.DNSServers = append(conf.DNSServers, ip)
+			}
+		}
+	}
+	if len(guestNic.Domain) > 0 {
+		conf.DomainSearch = []string{guestNic.Domain}
+	}
+	return conf
+}
+
+func (s *SGuestDHCP6Server) ServeDHCPv6(pkt *layers.DHCPv6, cliMac net.HardwareAddr, srvMac net.HardwareAddr) (*layers.DHCPv6, error) {
+	if !dhcp.IsDHCPv6ClientPacket(pkt) {
+		return nil, nil
+	}
+	_, guestNic := s.getGuestNic(cliMac)
+	if guestNic == nil {
+		return nil, nil
+	}
+	conf := s.getGuestConfig(guestNic, srvMac)
+	log.Infof("Make DHCPv6 %s Reply %s TO %s", pkt.MsgType, conf.ClientIP, cliMac)
+	return dhcp.MakeDHCPv6ReplyPacket(pkt, conf)
+}
+
+// ServeRouterSolicitation tells guests to fetch their address and dns by
+// stateful DHCPv6, the host is not the gateway of classic networks, so the
+// router lifetime is always 0 and the default route comes from gateway6.
+func (s *SGuestDHCP6Server) ServeRouterSolicitation(cliMac net.HardwareAddr, srvMac net.HardwareAddr) (*layers.ICMPv6RouterAdvertisement, error) {
+	_, guestNic := s.getGuestNic(cliMac)
+	if guestNic == nil {
+		return nil, nil
+	}
+	conf := &dhcp.RouterAdvertisementConfig{
+		SourceMac:         srvMac,
+		Prefix:            net.ParseIP(guestNic.Ip6),
+		PrefixLen:         uint8(guestNic.Masklen6),
+		Managed:           true,
+		Other:             true,
+		ValidLifetime:     time.Duration(options.HostOptions.DhcpLeaseTime) * time.Second,
+		PreferredLifetime: time.Duration(options.HostOptions.DhcpRenewalTime) * time.Second,
+	}
+	if guestNic.Mtu > 0 {
+		conf.MTU = uint32(guestNic.Mtu)
+	}
+	return dhcp.MakeRouterAdvertisement(conf), nil
+}
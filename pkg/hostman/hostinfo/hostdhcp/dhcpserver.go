@@ -121,6 +121,10 @@ func gusetnetworkJsonDescToServerNic(nicdesc *types.SServerNic, guestNic *desc.S
 	nicdesc.NicType = guestNic.NicType
 	nicdesc.LinkUp = guestNic.LinkUp
 	nicdesc.TeamWith = guestNic.TeamWith
+	nicdesc.Ip6 = guestNic.Ip6
+	nicdesc.Gateway6 = guestNic.Gateway6
+	nicdesc.Masklen6 = int(guestNic.Masklen6)
+	nicdesc.Dns6 = guestNic.Dns6
 	return nil
 }
 
@@ -94,6 +94,9 @@ type SHostInfo struct {
 	onHostDown       string
 	reservedCpusInfo *api.HostReserveCpusInput
 
+	numaCpuStatsGetter     NumaCpuStatsGetter
+	guestMemoryStatsGetter GuestMemoryStatsGetter
+
 	IsolatedDeviceMan isolated_device.IsolatedDeviceManager
 
 	MasterNic *netutils2.SNetInterface
@@ -145,6 +148,9 @@ func (h *SHostInfo) GetBridgeDev(bridge string) hostbridge.IBridgeDriver {
 func (h *SHostInfo) StartDHCPServer() {
 	for _, nic := range h.Nics {
 		nic.dhcpServer.Start(false)
+		if nic.dhcp6Server != nil {
+			nic.dhcp6Server.Start(false)
+		}
 	}
 }
 
@@ -1494,6 +1500,8 @@ func (h *SHostInfo) updateHostMetadata(hostname string) (*api.HostDetails, error
 	}
 	meta.RootPartitionTotalCapacityMB = int64(storageman.GetRootPartTotalCapacity())
 	meta.RootPartitionUsedCapacityMB = int64(storageman.GetRootPartUsedCapacity())
+	meta.OvmfSecboot = fileutils2.Exists(options.HostOptions.OvmfSecbootPath) &&
+		fileutils2.Exists(options.HostOptions.OvmfSecbootVarsPath)
 	data := meta.JSON(meta)
 	res, err := modules.Hosts.SetMetadata(h.GetSession(), h.HostId, data)
 	if err != nil {
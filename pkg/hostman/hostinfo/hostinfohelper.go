@@ -175,9 +175,10 @@ type SNIC struct {
 	WireId string
 	Mask   int
 
-	Bandwidth  int
-	BridgeDev  hostbridge.IBridgeDriver
-	dhcpServer *hostdhcp.SGuestDHCPServer
+	Bandwidth   int
+	BridgeDev   hostbridge.IBridgeDriver
+	dhcpServer  *hostdhcp.SGuestDHCPServer
+	dhcp6Server *hostdhcp.SGuestDHCP6Server
 }
 
 func (n *SNIC) EnableDHCPRelay() bool {
@@ -311,6 +312,12 @@ func NewNIC(desc string) (*SNIC, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "NewGuestDHCPServer(%s, %d, %#v)", nic.Bridge, options.HostOptions.DhcpServerPort, dhcpRelay)
 	}
+	if options.HostOptions.EnableDhcp6 {
+		nic.dhcp6Server, err = hostdhcp.NewGuestDHCP6Server(nic.Bridge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewGuestDHCP6Server(%s)", nic.Bridge)
+		}
+	}
 	// dhcp server start after guest manager init
 	return nic, nil
 }
@@ -0,0 +1,104 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+)
+
+const sysNodePath = "/sys/devices/system/node"
+
+// NumaCpuStatsGetter returns cpu count and allocated vcpu count of each numa node,
+// registered by guest manager which owns the cpuset counter
+type NumaCpuStatsGetter func() []api.SHostNumaNodeStat
+
+func (h *SHostInfo) SetNumaCpuStatsGetter(getter NumaCpuStatsGetter) {
+	h.numaCpuStatsGetter = getter
+}
+
+func (h *SHostInfo) GetNumaNodeStats() []api.SHostNumaNodeStat {
+	if h.numaCpuStatsGetter == nil {
+		return nil
+	}
+	stats := h.numaCpuStatsGetter()
+	for i := range stats {
+		if err := h.fillNumaNodeMemStat(&stats[i]); err != nil {
+			log.Errorf("fill numa node %d memory stat: %v", stats[i].NodeId, err)
+			return nil
+		}
+	}
+	return stats
+}
+
+func (h *SHostInfo) fillNumaNodeMemStat(stat *api.SHostNumaNodeStat) error {
+	nodePath := fmt.Sprintf("%s/node%d", sysNodePath, stat.NodeId)
+	content, err := fileutils2.FileGetContents(nodePath + "/meminfo")
+	if err != nil {
+		return errors.Wrap(err, "read meminfo")
+	}
+	memInfo := parseNodeMeminfo(content)
+	stat.MemSizeMb = memInfo["MemTotal"] / 1024
+	stat.FreeMemSizeMb = memInfo["MemFree"] / 1024
+
+	if !h.IsHugepagesEnabled() || h.HugepageSizeKb() <= 0 {
+		return nil
+	}
+	hugepagePath := fmt.Sprintf("%s/hugepages/hugepages-%dkB", nodePath, h.HugepageSizeKb())
+	nr, err := readIntFile(hugepagePath + "/nr_hugepages")
+	if err != nil {
+		return errors.Wrap(err, "read nr_hugepages")
+	}
+	free, err := readIntFile(hugepagePath + "/free_hugepages")
+	if err != nil {
+		return errors.Wrap(err, "read free_hugepages")
+	}
+	stat.HugepageSizeKb = h.HugepageSizeKb()
+	stat.HugepageNr = nr
+	stat.FreeHugepageNr = free
+	return nil
+}
+
+func readIntFile(path string) (int, error) {
+	content, err := fileutils2.FileGetContents(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(content))
+}
+
+// parseNodeMeminfo parses lines like "Node 0 MemTotal:  32801296 kB" into field => kB
+func parseNodeMeminfo(content string) map[string]int {
+	ret := make(map[string]int)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "Node" {
+			continue
+		}
+		val, err := strconv.Atoi(fields[3])
+		if err != nil {
+			continue
+		}
+		ret[strings.TrimSuffix(fields[2], ":")] = val
+	}
+	return ret
+}
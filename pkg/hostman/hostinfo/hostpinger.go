@@ -62,6 +62,21 @@ func NewCatalog() *SCatalog {
 	}
 }
 
+// GuestMemoryStatsGetter returns actual memory of guests after ballooning,
+// registered by guest manager which runs the balloon policy
+type GuestMemoryStatsGetter func() []api.SGuestMemoryStat
+
+func (h *SHostInfo) SetGuestMemoryStatsGetter(getter GuestMemoryStatsGetter) {
+	h.guestMemoryStatsGetter = getter
+}
+
+func (h *SHostInfo) GetGuestMemoryStats() []api.SGuestMemoryStat {
+	if h.guestMemoryStatsGetter == nil {
+		return nil
+	}
+	return h.guestMemoryStatsGetter()
+}
+
 func NewHostPingTask(interval int) *SHostPingTask {
 	if interval <= 0 {
 		return nil
@@ -105,6 +120,8 @@ func (p *SHostPingTask) payload() api.SHostPingInput {
 	p.lastStatAt = now
 	data = storageman.GatherHostStorageStats()
 	data.WithData = true
+	data.NumaNodeStats = Instance().GetNumaNodeStats()
+	data.GuestMemoryStats = Instance().GetGuestMemoryStats()
 	info, err := mem.VirtualMemory()
 	if err != nil {
 		return data
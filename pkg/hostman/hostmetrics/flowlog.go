@@ -0,0 +1,281 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+)
+
+const (
+	// aclLogNamePrefix is the prefix of acl names set by vpcagent
+	aclLogNamePrefix = "sgr/"
+
+	// flowLogMaxReadBytes limits bytes read from the log file in one round
+	flowLogMaxReadBytes = 4 * 1024 * 1024
+)
+
+type sAclLogRecord struct {
+	Time time.Time
+
+	RuleId    string
+	Verdict   string
+	Direction string
+	Proto     string
+	SrcMac    string
+	DstMac    string
+	SrcIp     string
+	DstIp     string
+	SrcPort   int
+	DstPort   int
+}
+
+// parseAclLogLine parses acl log lines of ovn-controller like
+//
+//	2023-05-10T08:11:24.568Z|00004|acl_log(ovn_pinctrl0)|INFO|name="sgr/<rule-id>", verdict=drop, severity=warning, direction=to-lport: tcp,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=192.168.0.3,nw_dst=192.168.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=43210,tp_dst=22,tcp_flags=syn
+//
+// direction is missing with older ovn.  Acls not named by vpcagent are ignored
+func parseAclLogLine(line string) (*sAclLogRecord, bool) {
+	parts := strings.SplitN(line, "|", 5)
+	if len(parts) != 5 || !strings.HasPrefix(parts[2], "acl_log") {
+		return nil, false
+	}
+	i := strings.Index(parts[4], ": ")
+	if i < 0 {
+		return nil, false
+	}
+	header, flow := parts[4][:i], strings.TrimSpace(parts[4][i+2:])
+
+	record := &sAclLogRecord{}
+	if t, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+		record.Time = t
+	}
+	for _, kv := range strings.Split(header, ", ") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "name":
+			name, err := strconv.Unquote(v)
+			if err != nil || !strings.HasPrefix(name, aclLogNamePrefix) {
+				return nil, false
+			}
+			record.RuleId = name[len(aclLogNamePrefix):]
+		case "verdict":
+			record.Verdict = v
+		case "direction":
+			record.Direction = v
+		}
+	}
+	if record.RuleId == "" || record.Verdict == "" {
+		return nil, false
+	}
+
+	for i, kv := range strings.Split(flow, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			if i == 0 {
+				record.Proto = kv
+			}
+			continue
+		}
+		switch k {
+		case "dl_src":
+			record.SrcMac = v
+		case "dl_dst":
+			record.DstMac = v
+		case "nw_src", "ipv6_src":
+			record.SrcIp = v
+		case "nw_dst", "ipv6_dst":
+			record.DstIp = v
+		case "tp_src":
+			record.SrcPort, _ = strconv.Atoi(v)
+		case "tp_dst":
+			record.DstPort, _ = strconv.Atoi(v)
+		}
+	}
+	return record, true
+}
+
+// SSecgroupFlowLogCollector tails the log file of ovn-controller and reports
+// acl logs as security group flow records
+type SSecgroupFlowLogCollector struct {
+	logFile string
+	hostId  func() string
+
+	inode   uint64
+	offset  int64
+	started bool
+	partial []byte
+}
+
+func NewSecgroupFlowLogCollector(logFile string, hostId func() string) *SSecgroupFlowLogCollector {
+	return &SSecgroupFlowLogCollector{
+		logFile: logFile,
+		hostId:  hostId,
+	}
+}
+
+// readLines returns complete lines appended since last read.  Logs written
+// before the collector starts are skipped.  Rotation is detected by inode
+// change or file truncation
+func (c *SSecgroupFlowLogCollector) readLines() ([]string, error) {
+	fi, err := os.Stat(c.logFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat")
+	}
+	var inode uint64
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		inode = st.Ino
+	}
+	if !c.started {
+		c.started = true
+		c.inode, c.offset = inode, fi.Size()
+		return nil, nil
+	}
+	if inode != c.inode || fi.Size() < c.offset {
+		c.inode, c.offset, c.partial = inode, 0, nil
+	}
+	if fi.Size() == c.offset {
+		return nil, nil
+	}
+
+	f, err := os.Open(c.logFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "open")
+	}
+	defer f.Close()
+	n := fi.Size() - c.offset
+	if n > flowLogMaxReadBytes {
+		n = flowLogMaxReadBytes
+	}
+	buf := make([]byte, n)
+	n0, err := f.ReadAt(buf, c.offset)
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "read")
+	}
+	c.offset += int64(n0)
+	data := append(c.partial, buf[:n0]...)
+	i := bytes.LastIndexByte(data, '\n')
+	if i < 0 {
+		c.partial = data
+		return nil, nil
+	}
+	c.partial = append([]byte(nil), data[i+1:]...)
+	return strings.Split(string(data[:i]), "\n"), nil
+}
+
+type sFlowLogAgg struct {
+	first   time.Time
+	packets int64
+}
+
+// toTelegrafData aggregates records of the same flow into one line with
+// packet count.  Timestamps are offset by index to not overwrite each other
+// as they may share tags
+func (c *SSecgroupFlowLogCollector) toTelegrafData(records []*sAclLogRecord, now time.Time) []string {
+	aggs := map[sAclLogRecord]*sFlowLogAgg{}
+	flows := []sAclLogRecord{}
+	for _, record := range records {
+		flow := *record
+		flow.Time = time.Time{}
+		agg, ok := aggs[flow]
+		if !ok {
+			agg = &sFlowLogAgg{first: record.Time}
+			if agg.first.IsZero() {
+				agg.first = now
+			}
+			aggs[flow] = agg
+			flows = append(flows, flow)
+		}
+		agg.packets += 1
+	}
+
+	hostId := c.hostId()
+	ret := make([]string, 0, len(flows))
+	for i, flow := range flows {
+		agg := aggs[flow]
+		tags := []string{compute.SECGROUP_FLOW_LOG_MEASUREMENT}
+		for _, kv := range [][2]string{
+			{"direction", flow.Direction},
+			{"dst_mac", flow.DstMac},
+			{"host_id", hostId},
+			{"rule_id", flow.RuleId},
+			{"src_mac", flow.SrcMac},
+			{"verdict", flow.Verdict},
+		} {
+			if kv[1] != "" {
+				tags = append(tags, kv[0]+"="+kv[1])
+			}
+		}
+		fields := []string{
+			fmt.Sprintf("proto=%q", flow.Proto),
+			fmt.Sprintf("src_ip=%q", flow.SrcIp),
+			fmt.Sprintf("dst_ip=%q", flow.DstIp),
+			fmt.Sprintf("src_port=%di", flow.SrcPort),
+			fmt.Sprintf("dst_port=%di", flow.DstPort),
+			fmt.Sprintf("packets=%di", agg.packets),
+		}
+		ret = append(ret, fmt.Sprintf("%s %s %d",
+			strings.Join(tags, ","), strings.Join(fields, ","), agg.first.UnixNano()+int64(i)))
+	}
+	return ret
+}
+
+func (c *SSecgroupFlowLogCollector) CollectReportData() string {
+	lines, err := c.readLines()
+	if err != nil {
+		log.Debugf("read acl logs from %s: %v", c.logFile, err)
+		return ""
+	}
+	records := []*sAclLogRecord{}
+	for _, line := range lines {
+		if record, ok := parseAclLogLine(line); ok {
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		return ""
+	}
+	return strings.Join(c.toTelegrafData(records, time.Now()), "\n")
+}
+
+// reportFlowLogToTelegraf sends flow records without retry, acl logs are
+// rate limited best-effort records after all
+func reportFlowLogToTelegraf(data string) {
+	res, err := httputils.Request(httputils.GetDefaultClient(), context.Background(), "POST", TelegrafServer, nil, strings.NewReader(data), false)
+	if err != nil {
+		log.Errorf("Upload secgroup flow log failed: %s", err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 204 {
+		log.Errorf("upload secgroup flow log failed %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testAclLogTcp  = `2023-05-10T08:11:24.568Z|00004|acl_log(ovn_pinctrl0)|INFO|name="sgr/rule0", verdict=drop, severity=warning, direction=to-lport: tcp,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=192.168.0.3,nw_dst=192.168.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=43210,tp_dst=22,tcp_flags=syn`
+	testAclLogIcmp = `2023-05-10T08:11:25.001Z|00005|acl_log(ovn_pinctrl0)|INFO|name="sgr/default-deny", verdict=drop, severity=warning: icmp,vlan_tci=0x0000,dl_src=00:22:00:00:00:01,dl_dst=00:22:00:00:00:02,nw_src=192.168.0.3,nw_dst=192.168.0.2,nw_tos=0,nw_ecn=0,nw_ttl=64,icmp_type=8,icmp_code=0`
+)
+
+func TestParseAclLogLine(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want *sAclLogRecord
+	}{
+		{
+			name: "tcp",
+			line: testAclLogTcp,
+			want: &sAclLogRecord{
+				Time:      time.Date(2023, 5, 10, 8, 11, 24, 568000000, time.UTC),
+				RuleId:    "rule0",
+				Verdict:   "drop",
+				Direction: "to-lport",
+				Proto:     "tcp",
+				SrcMac:    "00:22:00:00:00:01",
+				DstMac:    "00:22:00:00:00:02",
+				SrcIp:     "192.168.0.3",
+				DstIp:     "192.168.0.2",
+				SrcPort:   43210,
+				DstPort:   22,
+			},
+		},
+		{
+			name: "icmp without direction",
+			line: testAclLogIcmp,
+			want: &sAclLogRecord{
+				Time:    time.Date(2023, 5, 10, 8, 11, 25, 1000000, time.UTC),
+				RuleId:  "default-deny",
+				Verdict: "drop",
+				Proto:   "icmp",
+				SrcMac:  "00:22:00:00:00:01",
+				DstMac:  "00:22:00:00:00:02",
+				SrcIp:   "192.168.0.3",
+				DstIp:   "192.168.0.2",
+			},
+		},
+		{
+			name: "unnamed acl",
+			line: `2023-05-10T08:11:24.568Z|00004|acl_log(ovn_pinctrl0)|INFO|name=<unnamed>, verdict=allow, severity=info: udp,dl_src=00:22:00:00:00:01`,
+		},
+		{
+			name: "acl not from vpcagent",
+			line: `2023-05-10T08:11:24.568Z|00004|acl_log(ovn_pinctrl0)|INFO|name="other", verdict=allow, severity=info: udp,dl_src=00:22:00:00:00:01`,
+		},
+		{
+			name: "other module",
+			line: `2023-05-10T08:11:24.568Z|00004|binding|INFO|Claiming lport vm0 for this chassis.`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := parseAclLogLine(c.line)
+			if ok != (c.want != nil) {
+				t.Fatalf("want ok %v, got %v", c.want != nil, ok)
+			}
+			if c.want != nil && !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %#v\ngot  %#v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestSecgroupFlowLogCollector(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "ovn-controller.log")
+	appendLog := func(s string) {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	c := NewSecgroupFlowLogCollector(logFile, func() string { return "host0" })
+
+	// logs before start are skipped
+	appendLog(testAclLogTcp + "\n")
+	if data := c.CollectReportData(); data != "" {
+		t.Fatalf("want no data on start, got %q", data)
+	}
+
+	// the same flow is aggregated, incomplete line is left for next round
+	appendLog(testAclLogTcp + "\n" + testAclLogTcp + "\n" + testAclLogIcmp[:20])
+	want := `secgroup_flow_log,direction=to-lport,dst_mac=00:22:00:00:00:02,host_id=host0,rule_id=rule0,src_mac=00:22:00:00:00:01,verdict=drop ` +
+		`proto="tcp",src_ip="192.168.0.3",dst_ip="192.168.0.2",src_port=43210i,dst_port=22i,packets=2i 1683706284568000000`
+	if data := c.CollectReportData(); data != want {
+		t.Fatalf("want %s\ngot  %s", want, data)
+	}
+	appendLog(testAclLogIcmp[20:] + "\n")
+	want = `secgroup_flow_log,dst_mac=00:22:00:00:00:02,host_id=host0,rule_id=default-deny,src_mac=00:22:00:00:00:01,verdict=drop ` +
+		`proto="icmp",src_ip="192.168.0.3",dst_ip="192.168.0.2",src_port=0i,dst_port=0i,packets=1i 1683706285001000000`
+	if data := c.CollectReportData(); data != want {
+		t.Fatalf("want %s\ngot  %s", want, data)
+	}
+
+	// rotated
+	if err := os.Rename(logFile, logFile+".1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendLog(testAclLogIcmp + "\n")
+	if data := c.CollectReportData(); data != want {
+		t.Fatalf("want %s after rotation\ngot  %s", want, data)
+	}
+}
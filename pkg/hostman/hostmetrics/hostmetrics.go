@@ -34,6 +34,7 @@ import (
 	"yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/hostman/guestman"
 	"yunion.io/x/onecloud/pkg/hostman/guestman/desc"
+	"yunion.io/x/onecloud/pkg/hostman/hostinfo"
 	"yunion.io/x/onecloud/pkg/hostman/hostinfo/hostconsts"
 	"yunion.io/x/onecloud/pkg/hostman/options"
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
@@ -49,6 +50,7 @@ type SHostMetricsCollector struct {
 	LastCollectTime   time.Time
 	waitingReportData []string
 	guestMonitor      *SGuestMonitorCollector
+	flowLog           *SSecgroupFlowLogCollector
 }
 
 var hostMetricsCollector *SHostMetricsCollector
@@ -99,6 +101,11 @@ func (m *SHostMetricsCollector) runMonitor() {
 	if options.HostOptions.EnableTelegraf && len(reportData) > 0 {
 		m.reportUsageToTelegraf(reportData)
 	}
+	if m.flowLog != nil {
+		if flowLogData := m.flowLog.CollectReportData(); len(flowLogData) > 0 {
+			reportFlowLogToTelegraf(flowLogData)
+		}
+	}
 }
 
 func (m *SHostMetricsCollector) reportUsageToTelegraf(data string) {
@@ -141,11 +148,17 @@ func (m *SHostMetricsCollector) collectReportData() string {
 }
 
 func NewHostMetricsCollector() *SHostMetricsCollector {
-	return &SHostMetricsCollector{
+	m := &SHostMetricsCollector{
 		ReportInterval:    options.HostOptions.ReportInterval,
 		waitingReportData: make([]string, 0),
 		guestMonitor:      NewGuestMonitorCollector(),
 	}
+	if options.HostOptions.EnableTelegraf && options.HostOptions.EnableSecgroupFlowLog {
+		m.flowLog = NewSecgroupFlowLogCollector(options.HostOptions.OvnControllerLogFile, func() string {
+			return hostinfo.Instance().HostId
+		})
+	}
+	return m
 }
 
 type SGuestMonitorCollector struct {
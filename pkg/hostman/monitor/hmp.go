@@ -30,6 +30,8 @@ import (
 	"yunion.io/x/onecloud/pkg/util/regutils2"
 )
 
+var hmpBalloonActualRegexp = regexp.MustCompile(`actual=(\d+)`)
+
 type HmpMonitor struct {
 	SBaseMonitor
 
@@ -282,6 +284,18 @@ func (m *HmpMonitor) XBlockdevChange(parent, node, child string, callback String
 	go callback("hmp not support command x-blockdev-change")
 }
 
+func (m *HmpMonitor) BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback) {
+	go callback("hmp not support command block-dirty-bitmap-add")
+}
+
+func (m *HmpMonitor) BlockDirtyBitmapRemove(node, name string, callback StringCallback) {
+	go callback("hmp not support command block-dirty-bitmap-remove")
+}
+
+func (m *HmpMonitor) Transaction(actions []TransactionAction, callback StringCallback) {
+	go callback("hmp not support command transaction")
+}
+
 func (m *HmpMonitor) DriveAdd(bus, node string, params map[string]string, callback StringCallback) {
 	var paramsKvs = []string{}
 	for k, v := range params {
@@ -519,6 +533,32 @@ func (m *HmpMonitor) GetMemdevList(callback MemdevListCallback) {
 	go callback(nil, "hmp unsupport get memdev list")
 }
 
+func (m *HmpMonitor) Balloon(sizeMB int64, callback StringCallback) {
+	var cb = func(output string) {
+		// nothing is printed on success
+		callback(strings.TrimSpace(output))
+	}
+	m.Query(fmt.Sprintf("balloon %d", sizeMB), cb)
+}
+
+func (m *HmpMonitor) GetBalloonInfo(callback QueryBalloonCallback) {
+	var cb = func(output string) {
+		// balloon: actual=1024
+		m := hmpBalloonActualRegexp.FindStringSubmatch(output)
+		if len(m) < 2 {
+			callback(nil, strings.TrimSpace(output))
+			return
+		}
+		actual, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			callback(nil, err.Error())
+			return
+		}
+		callback(&BalloonInfo{Actual: actual * 1024 * 1024}, "")
+	}
+	m.Query("info balloon", cb)
+}
+
 func (m *HmpMonitor) ObjectAdd(objectType string, params map[string]string, callback StringCallback) {
 	var paramsKvs = []string{}
 	for k, v := range params {
@@ -528,8 +568,21 @@ func (m *HmpMonitor) ObjectAdd(objectType string, params map[string]string, call
 	m.Query(cmd, callback)
 }
 
-func (m *HmpMonitor) BlockIoThrottle(driveName string, bps, iops int64, callback StringCallback) {
-	cmd := fmt.Sprintf("block_set_io_throttle %s %d 0 0 %d 0 0", driveName, bps, iops)
+func (m *HmpMonitor) ChardevAdd(backend, id string, params map[string]string, callback StringCallback) {
+	var opts = []string{backend, "id=" + id}
+	for k, v := range params {
+		opts = append(opts, fmt.Sprintf("%s=%s", k, v))
+	}
+	m.Query(fmt.Sprintf("chardev-add %s", strings.Join(opts, ",")), callback)
+}
+
+func (m *HmpMonitor) ChardevRemove(id string, callback StringCallback) {
+	m.Query(fmt.Sprintf("chardev-remove %s", id), callback)
+}
+
+func (m *HmpMonitor) BlockIoThrottle(driveName string, throttle *BlockIoThrottle, callback StringCallback) {
+	// hmp command could not set burst limits and throttle group
+	cmd := fmt.Sprintf("block_set_io_throttle %s %d 0 0 %d 0 0", driveName, throttle.Bps, throttle.Iops)
 	m.Query(cmd, callback)
 }
 
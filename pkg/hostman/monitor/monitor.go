@@ -50,6 +50,24 @@ type BlockJob struct {
 	SpeedMbps float64
 }
 
+// BlockDirtyBitmap tracks guest writes since it was added,
+// inconsistent bitmap is not saved properly, e.g. qemu crashed
+type BlockDirtyBitmap struct {
+	Name         string
+	Count        int64
+	Granularity  int64
+	Recording    bool
+	Busy         bool
+	Persistent   bool
+	Inconsistent bool
+}
+
+// TransactionAction is an action of qmp transaction, e.g. block-dirty-bitmap-add, drive-backup
+type TransactionAction struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
 type QemuBlock struct {
 	IoStatus  string `json:"io-status"`
 	Device    string
@@ -58,7 +76,9 @@ type QemuBlock struct {
 	Qdev      string
 	TrayOpen  bool
 	Type      string
-	Inserted  struct {
+	// dirty bitmaps of qemu before 4.2
+	DirtyBitmaps []BlockDirtyBitmap `json:"dirty-bitmaps"`
+	Inserted     struct {
 		Ro               bool
 		Drv              string
 		Encrypted        bool
@@ -80,6 +100,7 @@ type QemuBlock struct {
 		IopsSize         int64
 		DetectZeroes     string
 		WriteThreshold   int
+		DirtyBitmaps     []BlockDirtyBitmap `json:"dirty-bitmaps"`
 		Image            struct {
 			Filename              string
 			Format                string
@@ -105,6 +126,27 @@ type QemuBlock struct {
 	}
 }
 
+func (b *QemuBlock) GetDirtyBitmaps() []BlockDirtyBitmap {
+	if len(b.Inserted.DirtyBitmaps) > 0 {
+		return b.Inserted.DirtyBitmaps
+	}
+	return b.DirtyBitmaps
+}
+
+// BlockIoThrottle holds total limits of a drive, burst limits and
+// throttle group are only honored by qmp monitor
+type BlockIoThrottle struct {
+	Bps  int64
+	Iops int64
+
+	BpsMax       int64
+	IopsMax      int64
+	BurstSeconds int64
+
+	// drives in the same group share the limits
+	Group string
+}
+
 type MigrationInfo struct {
 	Status                *MigrationStatus  `json:"status,omitempty"`
 	RAM                   *MigrationStats   `json:"ram,omitempty"`
@@ -208,6 +250,8 @@ type Monitor interface {
 	GeMemtSlotIndex(func(index int))
 	GetMemoryDevicesInfo(QueryMemoryDevicesCallback)
 	GetMemdevList(MemdevListCallback)
+	Balloon(sizeMB int64, callback StringCallback)
+	GetBalloonInfo(QueryBalloonCallback)
 
 	GetBlocks(callback func([]QemuBlock))
 	EjectCdrom(dev string, callback StringCallback)
@@ -218,6 +262,8 @@ type Monitor interface {
 	ObjectDel(idstr string, callback StringCallback)
 
 	ObjectAdd(objectType string, params map[string]string, callback StringCallback)
+	ChardevAdd(backend, id string, params map[string]string, callback StringCallback)
+	ChardevRemove(id string, callback StringCallback)
 	DriveAdd(bus, node string, params map[string]string, callback StringCallback)
 	DeviceAdd(dev string, params map[string]string, callback StringCallback)
 
@@ -228,6 +274,9 @@ type Monitor interface {
 	BlockJobComplete(drive string, cb StringCallback)
 	BlockReopenImage(drive, newImagePath, format string, cb StringCallback)
 	SnapshotBlkdev(drive, newImagePath, format string, reuse bool, cb StringCallback)
+	BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback)
+	BlockDirtyBitmapRemove(node, name string, callback StringCallback)
+	Transaction(actions []TransactionAction, callback StringCallback)
 
 	MigrateSetDowntime(dtSec float64, callback StringCallback)
 	MigrateSetCapability(capability, state string, callback StringCallback)
@@ -246,7 +295,7 @@ type Monitor interface {
 	StopNbdServer(callback StringCallback)
 
 	ResizeDisk(driveName string, sizeMB int64, callback StringCallback)
-	BlockIoThrottle(driveName string, bps, iops int64, callback StringCallback)
+	BlockIoThrottle(driveName string, throttle *BlockIoThrottle, callback StringCallback)
 	CancelBlockJob(driveName string, force bool, callback StringCallback)
 
 	NetdevAdd(id, netType string, params map[string]string, callback StringCallback)
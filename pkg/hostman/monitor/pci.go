@@ -139,6 +139,14 @@ type Memdev struct {
 
 type MemdevListCallback func(res []Memdev, err string)
 
+// BalloonInfo implements the "BalloonInfo" QMP API type.
+type BalloonInfo struct {
+	// logical size of the VM in bytes
+	Actual int64 `json:"actual"`
+}
+
+type QueryBalloonCallback func(info *BalloonInfo, err string)
+
 // CpuInstanceProperties -> CPUInstanceProperties (struct)
 
 // CPUInstanceProperties implements the "CpuInstanceProperties" QMP API type.
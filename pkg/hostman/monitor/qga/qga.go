@@ -638,3 +638,102 @@ func (qga *QemuGuestAgent) GuestExecStatusCommand(pid int) (*GuestExecStatus, er
 	}
 	return res, nil
 }
+
+const (
+	QGA_FSFREEZE_STATUS_THAWED = "thawed"
+	QGA_FSFREEZE_STATUS_FROZEN = "frozen"
+)
+
+/*
+##
+# @guest-fsfreeze-freeze:
+#
+# Sync and freeze all freezable, local guest filesystems. If this
+# command succeeded, you may call @guest-fsfreeze-thaw later to
+# unfreeze.
+#
+# Returns: Number of file systems currently frozen. On error, all filesystems
+#          will be thawed. If no filesystems are frozen as a result of this call,
+#          then @guest-fsfreeze-status will remain "thawed" and calling
+#          @guest-fsfreeze-thaw is not necessary.
+#
+# Since: 0.15.0
+##
+{ 'command': 'guest-fsfreeze-freeze',
+  'returns': 'int' }
+*/
+
+func (qga *QemuGuestAgent) GuestFsfreezeFreeze(timeout int) (int, error) {
+	cmd := &monitor.Command{
+		Execute: "guest-fsfreeze-freeze",
+	}
+	return qga.execIntCmd(cmd, timeout)
+}
+
+/*
+##
+# @guest-fsfreeze-thaw:
+#
+# Unfreeze all frozen guest filesystems
+#
+# Returns: Number of file systems thawed by this call
+#
+# Since: 0.15.0
+##
+{ 'command': 'guest-fsfreeze-thaw',
+  'returns': 'int' }
+*/
+
+func (qga *QemuGuestAgent) GuestFsfreezeThaw(timeout int) (int, error) {
+	cmd := &monitor.Command{
+		Execute: "guest-fsfreeze-thaw",
+	}
+	return qga.execIntCmd(cmd, timeout)
+}
+
+/*
+##
+# @guest-fsfreeze-status:
+#
+# Get guest fsfreeze state.
+#
+# Returns: GuestFsfreezeStatus ("thawed", "frozen", etc., as defined below)
+#
+# Since: 0.15.0
+##
+{ 'command': 'guest-fsfreeze-status',
+  'returns': 'GuestFsfreezeStatus' }
+*/
+
+func (qga *QemuGuestAgent) GuestFsfreezeStatus() (string, error) {
+	cmd := &monitor.Command{
+		Execute: "guest-fsfreeze-status",
+	}
+	rawRes, err := qga.execCmd(cmd, true, -1)
+	if err != nil {
+		return "", err
+	}
+	if rawRes == nil {
+		return "", errors.Errorf("qga no response")
+	}
+	var status string
+	if err := json.Unmarshal(*rawRes, &status); err != nil {
+		return "", errors.Wrap(err, "unmarshal raw response")
+	}
+	return status, nil
+}
+
+func (qga *QemuGuestAgent) execIntCmd(cmd *monitor.Command, timeout int) (int, error) {
+	rawRes, err := qga.execCmd(cmd, true, timeout)
+	if err != nil {
+		return 0, err
+	}
+	if rawRes == nil {
+		return 0, errors.Errorf("qga no response")
+	}
+	var res int
+	if err := json.Unmarshal(*rawRes, &res); err != nil {
+		return 0, errors.Wrap(err, "unmarshal raw response")
+	}
+	return res, nil
+}
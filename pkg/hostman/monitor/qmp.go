@@ -820,6 +820,55 @@ func (m *QmpMonitor) DriveBackup(callback StringCallback, drive, target, syncMod
 	m.Query(cmd, cb)
 }
 
+func (m *QmpMonitor) BlockDirtyBitmapAdd(node, name string, persistent bool, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-dirty-bitmap-add",
+			Args: map[string]interface{}{
+				"node":       node,
+				"name":       name,
+				"persistent": persistent,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) BlockDirtyBitmapRemove(node, name string, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "block-dirty-bitmap-remove",
+			Args: map[string]interface{}{
+				"node": node,
+				"name": name,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+// Transaction runs actions atomically, none of them takes effect if any failed
+func (m *QmpMonitor) Transaction(actions []TransactionAction, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "transaction",
+			Args: map[string]interface{}{
+				"actions": actions,
+			},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
 func (m *QmpMonitor) BlockStream(drive string, callback StringCallback) {
 	var (
 		speed = 5 * 100 * 1024 * 1024 // limit 500 MB/s
@@ -913,6 +962,19 @@ func (m *QmpMonitor) ObjectAdd(objectType string, params map[string]string, call
 	m.HumanMonitorCommand(cmd, callback)
 }
 
+func (m *QmpMonitor) ChardevAdd(backend, id string, params map[string]string, callback StringCallback) {
+	var opts = []string{backend, "id=" + id}
+	for k, v := range params {
+		opts = append(opts, fmt.Sprintf("%s=%s", k, v))
+	}
+	cmd := fmt.Sprintf("chardev-add %s", strings.Join(opts, ","))
+	m.HumanMonitorCommand(cmd, callback)
+}
+
+func (m *QmpMonitor) ChardevRemove(id string, callback StringCallback) {
+	m.HumanMonitorCommand(fmt.Sprintf("chardev-remove %s", id), callback)
+}
+
 func (m *QmpMonitor) GeMemtSlotIndex(callback func(index int)) {
 	var cb = func(res string) {
 		memInfos := strings.Split(res, "\\n")
@@ -972,9 +1034,84 @@ func (m *QmpMonitor) GetMemdevList(callback MemdevListCallback) {
 	m.Query(cmd, cb)
 }
 
-func (m *QmpMonitor) BlockIoThrottle(driveName string, bps, iops int64, callback StringCallback) {
-	cmd := fmt.Sprintf("block_set_io_throttle %s %d 0 0 %d 0 0", driveName, bps, iops)
-	m.HumanMonitorCommand(cmd, callback)
+func (m *QmpMonitor) Balloon(sizeMB int64, callback StringCallback) {
+	var (
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+		cmd = &Command{
+			Execute: "balloon",
+			Args:    map[string]interface{}{"value": sizeMB * 1024 * 1024},
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) GetBalloonInfo(callback QueryBalloonCallback) {
+	var (
+		cb = func(res *Response) {
+			if res.ErrorVal != nil {
+				callback(nil, res.ErrorVal.Error())
+			} else {
+				info := new(BalloonInfo)
+				err := json.Unmarshal(res.Return, info)
+				if err != nil {
+					callback(nil, err.Error())
+				} else {
+					callback(info, "")
+				}
+			}
+		}
+		cmd = &Command{
+			Execute: "query-balloon",
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func (m *QmpMonitor) BlockIoThrottle(driveName string, throttle *BlockIoThrottle, callback StringCallback) {
+	var (
+		cmd = &Command{
+			Execute: "block_set_io_throttle",
+			Args:    blockIoThrottleArgs(driveName, throttle),
+		}
+		cb = func(res *Response) {
+			callback(m.actionResult(res))
+		}
+	)
+	m.Query(cmd, cb)
+}
+
+func blockIoThrottleArgs(driveName string, throttle *BlockIoThrottle) map[string]interface{} {
+	args := map[string]interface{}{
+		"device":  driveName,
+		"bps":     throttle.Bps,
+		"bps_rd":  0,
+		"bps_wr":  0,
+		"iops":    throttle.Iops,
+		"iops_rd": 0,
+		"iops_wr": 0,
+	}
+	if throttle.BpsMax > 0 {
+		args["bps_max"] = throttle.BpsMax
+		if throttle.BurstSeconds > 0 {
+			args["bps_max_length"] = throttle.BurstSeconds
+		}
+	}
+	if throttle.IopsMax > 0 {
+		args["iops_max"] = throttle.IopsMax
+		if throttle.BurstSeconds > 0 {
+			args["iops_max_length"] = throttle.BurstSeconds
+		}
+	}
+	// qemu keeps the drive in its current group if group is omitted,
+	// name the group after the drive to leave a shared group
+	if len(throttle.Group) > 0 {
+		args["group"] = throttle.Group
+	} else {
+		args["group"] = driveName
+	}
+	return args
 }
 
 func (m *QmpMonitor) CancelBlockJob(driveName string, force bool, callback StringCallback) {
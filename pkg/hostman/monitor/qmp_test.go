@@ -15,6 +15,7 @@
 package monitor
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -43,3 +44,45 @@ func TestQmpMonitor_Connect(t *testing.T) {
 	m.Disconnect()
 	time.Sleep(3 * time.Second)
 }
+
+func TestBlockIoThrottleArgs(t *testing.T) {
+	cases := []struct {
+		name     string
+		throttle *BlockIoThrottle
+		want     string
+	}{
+		{
+			name:     "limits only leave shared group",
+			throttle: &BlockIoThrottle{Bps: 100 << 20, Iops: 3000},
+			want:     `{"bps":104857600,"bps_rd":0,"bps_wr":0,"device":"drive_0","group":"drive_0","iops":3000,"iops_rd":0,"iops_wr":0}`,
+		},
+		{
+			name: "burst in throttle group",
+			throttle: &BlockIoThrottle{
+				Bps:          100 << 20,
+				Iops:         3000,
+				BpsMax:       200 << 20,
+				IopsMax:      6000,
+				BurstSeconds: 60,
+				Group:        "dpc-class0",
+			},
+			want: `{"bps":104857600,"bps_max":209715200,"bps_max_length":60,"bps_rd":0,"bps_wr":0,"device":"drive_0","group":"dpc-class0","iops":3000,"iops_max":6000,"iops_max_length":60,"iops_rd":0,"iops_wr":0}`,
+		},
+		{
+			name:     "unlimited",
+			throttle: &BlockIoThrottle{},
+			want:     `{"bps":0,"bps_rd":0,"bps_wr":0,"device":"drive_0","group":"drive_0","iops":0,"iops_rd":0,"iops_wr":0}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := json.Marshal(blockIoThrottleArgs("drive_0", c.throttle))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(got) != c.want {
+				t.Errorf("want %s\ngot  %s", c.want, got)
+			}
+		})
+	}
+}
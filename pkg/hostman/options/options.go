@@ -84,6 +84,12 @@ type SHostOptions struct {
 
 	ChntpwPath string `help:"path to chntpw tool" default:"/usr/local/bin/chntpw.static"`
 	OvmfPath   string `help:"Path to OVMF.fd" default:"/opt/cloud/contrib/OVMF.fd"`
+	SwtpmPath  string `help:"Path to swtpm used as vTPM backend" default:"/usr/bin/swtpm"`
+
+	VirtiofsdPath string `help:"Path to virtiofsd serving guest file shares" default:"/usr/libexec/virtiofsd"`
+
+	OvmfSecbootPath     string `help:"Path to OVMF code built with secure boot and SMM" default:"/opt/cloud/contrib/OVMF_CODE.secboot.fd"`
+	OvmfSecbootVarsPath string `help:"Path to OVMF vars template with secure boot keys enrolled" default:"/opt/cloud/contrib/OVMF_VARS.secboot.fd"`
 
 	LinuxDefaultRootUser    bool `help:"Default account for linux system is root"`
 	WindowsDefaultAdminUser bool `default:"true" help:"Default account for Windows system is Administrator"`
@@ -97,6 +103,7 @@ type SHostOptions struct {
 	LocalImagePath  []string `help:"Local image storage paths"`
 	SharedStorages  []string `help:"Path of shared storages"`
 	LVMVolumeGroups []string `help:"LVM Volume Groups(vgs)"`
+	LVMThinPools    []string `help:"LVM thin pools backing lvm storages, format <vg>/<thinpool>"`
 
 	DhcpRelay       []string `help:"DHCP relay upstream"`
 	DhcpLeaseTime   int      `default:"100663296" help:"DHCP lease time in seconds"`
@@ -107,6 +114,7 @@ type SHostOptions struct {
 	CheckSystemServices bool `help:"Check system services (ntpd, telegraf) on startup" default:"true"`
 
 	DhcpServerPort     int    `help:"Host dhcp server bind port" default:"67"`
+	EnableDhcp6        bool   `help:"Serve DHCPv6 and router advertisement to guests of ipv6 networks" default:"true"`
 	FetcherfsPath      string `default:"/opt/yunion/fetchclient/bin/fetcherfs" help:"Fuse fetcherfs path"`
 	FetcherfsBlockSize int    `default:"16" help:"Fuse fetcherfs fetch chunk_size MB"`
 
@@ -133,6 +141,8 @@ type SHostOptions struct {
 	SnapshotDirSuffix  string `help:"Snapshot dir name equal diskId concat snapshot dir suffix" default:"_snap"`
 	SnapshotRecycleDay int    `default:"1" help:"Snapshot Recycle delete Duration day"`
 
+	FsfreezeTimeoutSeconds int `default:"30" help:"Max seconds guest filesystems stay frozen for an application-consistent snapshot, default 30 seconds"`
+
 	EnableTelegraf bool `default:"true" help:"enable send monitoring data to telegraf"`
 
 	DisableSetCgroup bool `default:"false" help:"disable cgroup for guests"`
@@ -157,6 +167,9 @@ type SHostOptions struct {
 
 	ovnutils.SOvnOptions
 
+	EnableSecgroupFlowLog bool   `help:"collect security group flow logs from acl logs of ovn-controller" default:"true"`
+	OvnControllerLogFile  string `help:"log file of ovn-controller where acl logs are collected from" default:"$HOST_OVN_CONTROLLER_LOG_FILE|/var/log/openvswitch/ovn-controller.log"`
+
 	// EnableRemoteExecutor bool `help:"Enable remote executor" default:"false"`
 	HostHealthTimeout int `help:"host health timeout" default:"30"`
 	HostLeaseTimeout  int `help:"lease timeout" default:"10"`
@@ -186,6 +199,14 @@ type SHostOptions struct {
 
 	EnableVirtioRngDevice bool `help:"enable qemu virtio-rng device" default:"true"`
 
+	EnableMemoryBalloon             bool `help:"enable qemu virtio-balloon device and adjust guests balloon on host memory pressure" default:"false"`
+	EnableBalloonFreePageReporting  bool `help:"enable free page reporting of virtio-balloon device, requires qemu 5.1 or later" default:"false"`
+	MemoryBalloonIntervalSeconds    int  `help:"interval in seconds of checking host memory pressure and adjusting guests balloon" default:"30"`
+	MemoryBalloonInflateFreePercent int  `help:"inflate guests balloon when host available memory is below this percent" default:"10"`
+	MemoryBalloonDeflateFreePercent int  `help:"deflate guests balloon when host available memory is above this percent" default:"20"`
+	MemoryBalloonStepPercent        int  `help:"percent of guest memory the balloon is adjusted by each round" default:"10"`
+	MemoryBalloonMinPercent         int  `help:"default lower bound in percent of guest memory the balloon could shrink guest to" default:"50"`
+
 	RestrictQemuImgConvertWorker bool `help:"restrict qemu-img convert worker" default:"false"`
 
 	DefaultLiveMigrateDowntime float32 `help:"allow downtime in seconds for live migrate" default:"5.0"`
@@ -17,9 +17,12 @@ package backupstorage
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"sync"
 
 	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/qemuimgfmt"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
@@ -35,11 +38,29 @@ type IBackupStorage interface {
 	InstancePack(ctx context.Context, packageName string, backupIds []string, metadata *api.InstanceBackupPackMetadata) (string, error)
 	InstanceUnpack(ctx context.Context, packageName string, metadataOnly bool) ([]string, *api.InstanceBackupPackMetadata, error)
 	IsOnline() (bool, string, error)
+	// VerifyBackup checks integrity of backup and its parent backups
+	VerifyBackup(ctx context.Context, backupId string) error
 }
 
 var backupStoragePool *sync.Map = &sync.Map{}
 
 func NewBackupStorage(backupStroageId string, backupStorageAccessInfo *jsonutils.JSONDict) (IBackupStorage, error) {
+	store, err := newBackupBlobStore(backupStroageId, backupStorageAccessInfo)
+	if err != nil {
+		return nil, err
+	}
+	if format, _ := backupStorageAccessInfo.GetString("backup_format"); format == api.BACKUPSTORAGE_FORMAT_CHUNK {
+		return NewChunkedBackupStorage(backupStroageId, store), nil
+	}
+	return store.(IBackupStorage), nil
+}
+
+func newBackupBlobStore(backupStroageId string, backupStorageAccessInfo *jsonutils.JSONDict) (iBackupBlobStore, error) {
+	if bucketUrl, _ := backupStorageAccessInfo.GetString("object_bucket_url"); len(bucketUrl) > 0 {
+		accessKey, _ := backupStorageAccessInfo.GetString("object_access_key")
+		secret, _ := backupStorageAccessInfo.GetString("object_secret")
+		return NewObjectBackupStorage(backupStroageId, bucketUrl, accessKey, secret)
+	}
 	nfsHost, err := backupStorageAccessInfo.GetString("nfs_host")
 	if err != nil {
 		return nil, fmt.Errorf("need nfs_host in backup_storage_access_info")
@@ -59,3 +80,21 @@ func GetBackupStorage(backupStroageId string, backupStorageAccessInfo *jsonutils
 	ibs, _ := backupStoragePool.LoadOrStore(backupStroageId, bs)
 	return ibs.(IBackupStorage), nil
 }
+
+func loadPackageMetadata(packagePath string) (*api.InstanceBackupPackMetadata, error) {
+	packageMetadataPath := path.Join(packagePath, PackageMetadataFilename)
+	metadataBytes, err := ioutil.ReadFile(packageMetadataPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read metadata file")
+	}
+	metadataJson, err := jsonutils.Parse(metadataBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse string to json")
+	}
+	metadata := &api.InstanceBackupPackMetadata{}
+	err = metadataJson.Unmarshal(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal backup metadata")
+	}
+	return metadata, nil
+}
@@ -0,0 +1,950 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pierrec/lz4/v4"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/qemuimgfmt"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+const (
+	BACKUP_REPO_CHUNK_DIR    = "repo/chunks"
+	BACKUP_REPO_MANIFEST_DIR = "repo/manifests"
+	BACKUP_REPO_PACK_DIR     = "repo/packs"
+	// leases of writers and garbage collectors on any host
+	BACKUP_REPO_LEASE_DIR   = "repo/leases"
+	BACKUP_REPO_GC_LOCK_DIR = "repo/gclocks"
+	// hashes of chunks to collect once garbage collection is not blocked by writers
+	BACKUP_REPO_GARBAGE_DIR = "repo/garbage"
+
+	// chunks appearing more than once in a backup, e.g. zeroes of sparse
+	// image, are cached on reading
+	BACKUP_CHUNK_CACHE_SIZE = 16
+
+	chunkEncodingRaw byte = 'R'
+	chunkEncodingLz4 byte = 'L'
+)
+
+var (
+	// leases not renewed within ttl are stale, e.g. holder host crashed
+	backupRepoLeaseTTL = 10 * time.Minute
+	// writers wait for garbage collection running on other hosts
+	backupRepoLeaseRetryInterval = 5 * time.Second
+	backupRepoLeaseWaitTimeout   = 30 * time.Minute
+
+	errBackupRepoBusy = errors.Error("backup repository has writers")
+)
+
+// iBackupBlobStore is implemented by backup storages able to hold
+// the blobs of chunked backup repository
+type iBackupBlobStore interface {
+	IsOnline() (bool, string, error)
+
+	attachBlobStore() error
+	detachBlobStore()
+	putBlob(ctx context.Context, key string, data []byte) error
+	// getBlob returns errors.ErrNotFound if blob not exists
+	getBlob(ctx context.Context, key string) ([]byte, error)
+	hasBlob(key string) (bool, error)
+	removeBlob(key string) error
+	listBlobs(prefix string) ([]string, error)
+}
+
+type SBackupChunk struct {
+	// sha256 of uncompressed content
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+// SBackupManifest describes a backup file as chunks in order
+type SBackupManifest struct {
+	BackupId string `json:"backup_id"`
+	// parent of incremental backup, which is the backing file of restored image
+	BackingBackupId string         `json:"backing_backup_id"`
+	SizeBytes       int64          `json:"size_bytes"`
+	Sha256          string         `json:"sha256"`
+	Chunks          []SBackupChunk `json:"chunks"`
+}
+
+// SBackupPackManifest is an instance backup package, disks of which
+// are self-contained manifests sharing chunks with backups
+type SBackupPackManifest struct {
+	Metadata *api.InstanceBackupPackMetadata `json:"metadata"`
+	Disks    []SBackupManifest               `json:"disks"`
+}
+
+// SChunkedBackupStorage keeps backups in a content addressed repository on
+// top of another backup storage. Backup files are split into content defined
+// chunks, each of which is compressed and stored once however many backups
+// contain it. A backup is a manifest listing its chunks, chunks are garbage
+// collected once no manifest references them.
+//
+// The repository is shared by hosts, so writers and garbage collection
+// exclude each other by leases kept in the repository as well. A writer
+// holds a lease under BACKUP_REPO_LEASE_DIR and garbage collection a lock
+// under BACKUP_REPO_GC_LOCK_DIR, each puts its own blob first and then checks
+// the other's, so that at least one of them backs off. Garbage collection
+// never waits for writers, hashes are saved under BACKUP_REPO_GARBAGE_DIR
+// and collected by the next run instead.
+type SChunkedBackupStorage struct {
+	BackupStorageId string
+
+	store iBackupBlobStore
+	// backups are written holding read lock, garbage collection holds
+	// write lock so that writers of this host are not turned away
+	lock *sync.RWMutex
+}
+
+func NewChunkedBackupStorage(backupStorageId string, store iBackupBlobStore) *SChunkedBackupStorage {
+	return &SChunkedBackupStorage{
+		BackupStorageId: backupStorageId,
+		store:           store,
+		lock:            &sync.RWMutex{},
+	}
+}
+
+func getChunkKey(hash string) string {
+	return path.Join(BACKUP_REPO_CHUNK_DIR, hash[:2], hash)
+}
+
+func getManifestKey(backupId string) string {
+	return path.Join(BACKUP_REPO_MANIFEST_DIR, backupId)
+}
+
+func getPackManifestKey(packageName string) string {
+	return path.Join(BACKUP_REPO_PACK_DIR, packageName)
+}
+
+// encodeChunk compresses chunk by lz4, chunk is kept raw if incompressible
+func encodeChunk(data []byte) []byte {
+	buf := make([]byte, 1+lz4.CompressBlockBound(len(data)))
+	n, err := lz4.CompressBlock(data, buf[1:], nil)
+	if err != nil || n == 0 || n >= len(data) {
+		buf = make([]byte, 1+len(data))
+		buf[0] = chunkEncodingRaw
+		copy(buf[1:], data)
+		return buf
+	}
+	buf[0] = chunkEncodingLz4
+	return buf[:1+n]
+}
+
+func decodeChunk(blob []byte, size int64) ([]byte, error) {
+	if len(blob) == 0 {
+		return nil, errors.Error("empty chunk")
+	}
+	switch blob[0] {
+	case chunkEncodingRaw:
+		return blob[1:], nil
+	case chunkEncodingLz4:
+		data := make([]byte, size)
+		n, err := lz4.UncompressBlock(blob[1:], data)
+		if err != nil {
+			return nil, errors.Wrap(err, "lz4 uncompress")
+		}
+		return data[:n], nil
+	default:
+		return nil, errors.Errorf("unknown chunk encoding %q", blob[0])
+	}
+}
+
+func (s *SChunkedBackupStorage) saveJson(ctx context.Context, key string, obj interface{}) error {
+	return s.store.putBlob(ctx, key, []byte(jsonutils.Marshal(obj).String()))
+}
+
+func (s *SChunkedBackupStorage) loadJson(ctx context.Context, key string, obj interface{}) error {
+	data, err := s.store.getBlob(ctx, key)
+	if err != nil {
+		return err
+	}
+	json, err := jsonutils.Parse(data)
+	if err != nil {
+		return errors.Wrapf(err, "parse %s", key)
+	}
+	return json.Unmarshal(obj)
+}
+
+func (s *SChunkedBackupStorage) loadManifest(ctx context.Context, backupId string) (*SBackupManifest, error) {
+	manifest := &SBackupManifest{}
+	if err := s.loadJson(ctx, getManifestKey(backupId), manifest); err != nil {
+		return nil, errors.Wrapf(err, "load manifest of backup %s", backupId)
+	}
+	return manifest, nil
+}
+
+// storeFile writes chunks of file absent in repository, hashes of new chunks
+// and of reused chunks are returned for cleanup and consistency check
+func (s *SChunkedBackupStorage) storeFile(ctx context.Context, filename string) (*SBackupManifest, []string, []string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "open %s", filename)
+	}
+	defer f.Close()
+
+	manifest := &SBackupManifest{Chunks: []SBackupChunk{}}
+	created := []string{}
+	reused := []string{}
+	known := map[string]bool{}
+	fileHash := sha256.New()
+	chunker := newChunker(f, BACKUP_CHUNK_MIN_SIZE, BACKUP_CHUNK_AVG_SIZE, BACKUP_CHUNK_MAX_SIZE)
+	for {
+		data, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, created, reused, errors.Wrapf(err, "read %s", filename)
+		}
+		fileHash.Write(data)
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		if !known[hash] {
+			known[hash] = true
+			key := getChunkKey(hash)
+			exists, err := s.store.hasBlob(key)
+			if err != nil {
+				return nil, created, reused, errors.Wrapf(err, "check chunk %s", hash)
+			}
+			if exists {
+				reused = append(reused, hash)
+			} else {
+				if err := s.store.putBlob(ctx, key, encodeChunk(data)); err != nil {
+					return nil, created, reused, errors.Wrapf(err, "put chunk %s", hash)
+				}
+				created = append(created, hash)
+			}
+		}
+		manifest.Chunks = append(manifest.Chunks, SBackupChunk{Hash: hash, Size: int64(len(data))})
+		manifest.SizeBytes += int64(len(data))
+	}
+	manifest.Sha256 = hex.EncodeToString(fileHash.Sum(nil))
+	return manifest, created, reused, nil
+}
+
+// checkChunksExist detects reused chunks removed by garbage collection
+// running on another host meanwhile, e.g. lease of writer is not renewed
+func (s *SChunkedBackupStorage) checkChunksExist(hashes []string) error {
+	for _, hash := range hashes {
+		exists, err := s.store.hasBlob(getChunkKey(hash))
+		if err != nil {
+			return errors.Wrapf(err, "check chunk %s", hash)
+		}
+		if !exists {
+			return errors.Errorf("chunk %s is removed during backup, please retry", hash)
+		}
+	}
+	return nil
+}
+
+// storeBackup saves file as backup, the caller must have attached the blob store
+func (s *SChunkedBackupStorage) storeBackup(ctx context.Context, filename, backupId, backingBackupId string) error {
+	var created []string
+	err := func() error {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+
+		release, err := s.acquireWriterLease(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		manifest, newChunks, reused, err := s.storeFile(ctx, filename)
+		created = newChunks
+		if err != nil {
+			return err
+		}
+		manifest.BackupId = backupId
+		manifest.BackingBackupId = backingBackupId
+		key := getManifestKey(backupId)
+		if err := s.saveJson(ctx, key, manifest); err != nil {
+			return errors.Wrapf(err, "save manifest of backup %s", backupId)
+		}
+		if err := s.checkChunksExist(reused); err != nil {
+			s.store.removeBlob(key)
+			return err
+		}
+		return nil
+	}()
+	if err != nil {
+		if gcErr := s.collectGarbageExclusively(ctx, created); gcErr != nil {
+			log.Errorf("backup storage %s collect garbage: %s", s.BackupStorageId, gcErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// sChunkReader reads and verifies chunks of a manifest
+type sChunkReader struct {
+	s        *SChunkedBackupStorage
+	repeated map[string]int
+	cache    map[string][]byte
+}
+
+func newChunkReader(s *SChunkedBackupStorage, manifest *SBackupManifest) *sChunkReader {
+	r := &sChunkReader{
+		s:        s,
+		repeated: map[string]int{},
+		cache:    map[string][]byte{},
+	}
+	for _, chunk := range manifest.Chunks {
+		r.repeated[chunk.Hash]++
+	}
+	return r
+}
+
+func (r *sChunkReader) read(ctx context.Context, chunk SBackupChunk) ([]byte, error) {
+	if data, ok := r.cache[chunk.Hash]; ok {
+		return data, nil
+	}
+	blob, err := r.s.store.getBlob(ctx, getChunkKey(chunk.Hash))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get chunk %s", chunk.Hash)
+	}
+	data, err := decodeChunk(blob, chunk.Size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode chunk %s", chunk.Hash)
+	}
+	if int64(len(data)) != chunk.Size {
+		return nil, errors.Errorf("size %d of chunk %s mismatch %d", len(data), chunk.Hash, chunk.Size)
+	}
+	sum := sha256.Sum256(data)
+	if hash := hex.EncodeToString(sum[:]); hash != chunk.Hash {
+		return nil, errors.Errorf("chunk %s is corrupted, sha256 is %s", chunk.Hash, hash)
+	}
+	if r.repeated[chunk.Hash] > 1 && len(r.cache) < BACKUP_CHUNK_CACHE_SIZE {
+		r.cache[chunk.Hash] = data
+	}
+	return data, nil
+}
+
+func (s *SChunkedBackupStorage) restoreFile(ctx context.Context, manifest *SBackupManifest, filename string) error {
+	err := func() error {
+		f, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "open %s", filename)
+		}
+		defer f.Close()
+		reader := newChunkReader(s, manifest)
+		fileHash := sha256.New()
+		for _, chunk := range manifest.Chunks {
+			data, err := reader.read(ctx, chunk)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(data); err != nil {
+				return errors.Wrapf(err, "write %s", filename)
+			}
+			fileHash.Write(data)
+		}
+		if sum := hex.EncodeToString(fileHash.Sum(nil)); sum != manifest.Sha256 {
+			return errors.Errorf("sha256 %s of backup %s mismatch %s", sum, manifest.BackupId, manifest.Sha256)
+		}
+		return nil
+	}()
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+	return nil
+}
+
+// fetchBackup restores backup and its parent backups of incremental chain
+// into dir, named by backup ids as they are referred by backing files
+func (s *SChunkedBackupStorage) fetchBackup(ctx context.Context, dir, backupId string) (string, bool, error) {
+	head := path.Join(dir, backupId)
+	chained := false
+	for id := backupId; len(id) > 0; {
+		manifest, err := s.loadManifest(ctx, id)
+		if err != nil {
+			return "", false, err
+		}
+		if err := s.restoreFile(ctx, manifest, path.Join(dir, id)); err != nil {
+			return "", false, errors.Wrapf(err, "restore backup %s", id)
+		}
+		if id == backupId {
+			chained = len(manifest.BackingBackupId) > 0
+		}
+		id = manifest.BackingBackupId
+	}
+	return head, chained, nil
+}
+
+func (s *SChunkedBackupStorage) listManifests(ctx context.Context) ([]SBackupManifest, error) {
+	manifests := []SBackupManifest{}
+	keys, err := s.store.listBlobs(BACKUP_REPO_MANIFEST_DIR)
+	if err != nil {
+		return nil, errors.Wrap(err, "list manifests")
+	}
+	for _, key := range keys {
+		manifest := SBackupManifest{}
+		if err := s.loadJson(ctx, key, &manifest); err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				continue
+			}
+			return nil, errors.Wrapf(err, "load %s", key)
+		}
+		manifests = append(manifests, manifest)
+	}
+	keys, err = s.store.listBlobs(BACKUP_REPO_PACK_DIR)
+	if err != nil {
+		return nil, errors.Wrap(err, "list packs")
+	}
+	for _, key := range keys {
+		pack := SBackupPackManifest{}
+		if err := s.loadJson(ctx, key, &pack); err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				continue
+			}
+			return nil, errors.Wrapf(err, "load %s", key)
+		}
+		manifests = append(manifests, pack.Disks...)
+	}
+	return manifests, nil
+}
+
+type sBackupRepoLease struct {
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// holdLease puts lease blob key and renews it until released
+func (s *SChunkedBackupStorage) holdLease(ctx context.Context, key string) (func(), error) {
+	put := func() error {
+		return s.saveJson(ctx, key, &sBackupRepoLease{ExpireAt: time.Now().Add(backupRepoLeaseTTL)})
+	}
+	if err := put(); err != nil {
+		return nil, errors.Wrapf(err, "put lease %s", key)
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(backupRepoLeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := put(); err != nil {
+					log.Errorf("backup storage %s renew lease %s: %s", s.BackupStorageId, key, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		if err := s.store.removeBlob(key); err != nil {
+			log.Errorf("backup storage %s remove lease %s: %s", s.BackupStorageId, key, err)
+		}
+	}, nil
+}
+
+// hasLiveLease checks leases under dir, stale leases are removed
+func (s *SChunkedBackupStorage) hasLiveLease(ctx context.Context, dir string) (bool, error) {
+	keys, err := s.store.listBlobs(dir)
+	if err != nil {
+		return false, errors.Wrapf(err, "list %s", dir)
+	}
+	now := time.Now()
+	for _, key := range keys {
+		lease := sBackupRepoLease{}
+		if err := s.loadJson(ctx, key, &lease); err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				continue
+			}
+			return false, errors.Wrapf(err, "load lease %s", key)
+		}
+		if lease.ExpireAt.After(now) {
+			return true, nil
+		}
+		log.Warningf("backup storage %s: remove stale lease %s expired at %s", s.BackupStorageId, key, lease.ExpireAt)
+		s.store.removeBlob(key)
+	}
+	return false, nil
+}
+
+// acquireWriterLease keeps chunks reused by writer from garbage collection
+// of any host, waiting for garbage collection in progress
+func (s *SChunkedBackupStorage) acquireWriterLease(ctx context.Context) (func(), error) {
+	key := path.Join(BACKUP_REPO_LEASE_DIR, db.DefaultUUIDGenerator())
+	deadline := time.Now().Add(backupRepoLeaseWaitTimeout)
+	for {
+		release, err := s.holdLease(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		collecting, err := s.hasLiveLease(ctx, BACKUP_REPO_GC_LOCK_DIR)
+		if err == nil && !collecting {
+			return release, nil
+		}
+		release()
+		if err != nil {
+			return nil, errors.Wrap(err, "check garbage collection")
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("garbage collection of backup storage %s lasts over %s", s.BackupStorageId, backupRepoLeaseWaitTimeout)
+		}
+		time.Sleep(backupRepoLeaseRetryInterval)
+	}
+}
+
+// acquireGcLock fails with errBackupRepoBusy if any writer holds lease
+func (s *SChunkedBackupStorage) acquireGcLock(ctx context.Context) (func(), error) {
+	release, err := s.holdLease(ctx, path.Join(BACKUP_REPO_GC_LOCK_DIR, db.DefaultUUIDGenerator()))
+	if err != nil {
+		return nil, err
+	}
+	writing, err := s.hasLiveLease(ctx, BACKUP_REPO_LEASE_DIR)
+	if err == nil && !writing {
+		return release, nil
+	}
+	release()
+	if err != nil {
+		return nil, errors.Wrap(err, "check writers")
+	}
+	return nil, errBackupRepoBusy
+}
+
+func (s *SChunkedBackupStorage) deferGarbage(ctx context.Context, hashes []string) error {
+	key := path.Join(BACKUP_REPO_GARBAGE_DIR, db.DefaultUUIDGenerator())
+	return s.saveJson(ctx, key, hashes)
+}
+
+// collectGarbage removes chunks among hashes and deferred garbage no longer
+// referenced by any backup or package, the caller must hold gc lock
+func (s *SChunkedBackupStorage) collectGarbage(ctx context.Context, hashes []string) error {
+	unreferenced := map[string]bool{}
+	for _, hash := range hashes {
+		unreferenced[hash] = true
+	}
+	garbageKeys, err := s.store.listBlobs(BACKUP_REPO_GARBAGE_DIR)
+	if err != nil {
+		return errors.Wrap(err, "list deferred garbage")
+	}
+	for _, key := range garbageKeys {
+		deferred := []string{}
+		if err := s.loadJson(ctx, key, &deferred); err != nil {
+			if errors.Cause(err) == errors.ErrNotFound {
+				continue
+			}
+			return errors.Wrapf(err, "load %s", key)
+		}
+		for _, hash := range deferred {
+			unreferenced[hash] = true
+		}
+	}
+	if len(unreferenced) == 0 {
+		return nil
+	}
+	manifests, err := s.listManifests(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range manifests {
+		for _, chunk := range manifests[i].Chunks {
+			delete(unreferenced, chunk.Hash)
+		}
+	}
+	for hash := range unreferenced {
+		if err := s.store.removeBlob(getChunkKey(hash)); err != nil {
+			return errors.Wrapf(err, "remove chunk %s", hash)
+		}
+	}
+	for _, key := range garbageKeys {
+		if err := s.store.removeBlob(key); err != nil {
+			return errors.Wrapf(err, "remove %s", key)
+		}
+	}
+	log.Infof("backup storage %s: %d unreferenced chunks removed", s.BackupStorageId, len(unreferenced))
+	return nil
+}
+
+// collectGarbageExclusively collects garbage holding gc lock, hashes are
+// deferred if writers of other hosts are running
+func (s *SChunkedBackupStorage) collectGarbageExclusively(ctx context.Context, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	release, err := s.acquireGcLock(ctx)
+	if err != nil {
+		if errors.Cause(err) != errBackupRepoBusy {
+			return err
+		}
+		log.Infof("backup storage %s: writers are running, defer collecting %d chunks", s.BackupStorageId, len(hashes))
+		return s.deferGarbage(ctx, hashes)
+	}
+	defer release()
+	return s.collectGarbage(ctx, hashes)
+}
+
+func (s *SChunkedBackupStorage) CopyBackupFrom(srcFilename string, backupId string) error {
+	img, err := qemuimg.NewQemuImage(srcFilename)
+	if err != nil {
+		return errors.Wrapf(err, "NewQemuImage %s", srcFilename)
+	}
+	backingBackupId := ""
+	if img.IsChained() {
+		backingBackupId = path.Base(img.BackFilePath)
+	}
+	if err := s.store.attachBlobStore(); err != nil {
+		return errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+	return s.storeBackup(context.Background(), srcFilename, backupId, backingBackupId)
+}
+
+func (s *SChunkedBackupStorage) CopyBackupTo(targetFilename string, backupId string) error {
+	if err := s.store.attachBlobStore(); err != nil {
+		return errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "restore")
+	if err != nil {
+		return errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	head, chained, err := s.fetchBackup(context.Background(), tmpFileDir, backupId)
+	if err != nil {
+		return err
+	}
+	if chained {
+		return copyBackupFile(head, targetFilename)
+	}
+	if output, err := procutils.NewCommand("mv", head, targetFilename).Output(); err != nil {
+		return errors.Wrapf(err, "mv %s to %s failed and output is %q", head, targetFilename, output)
+	}
+	return nil
+}
+
+func (s *SChunkedBackupStorage) InstancePack(ctx context.Context, packageName string, backupIds []string, metadata *api.InstanceBackupPackMetadata) (string, error) {
+	if err := s.store.attachBlobStore(); err != nil {
+		return "", errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "pack")
+	if err != nil {
+		return "", errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	created := []string{}
+	packageFilename := packageName
+	err = func() error {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+
+		release, err := s.acquireWriterLease(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		pack := &SBackupPackManifest{
+			Metadata: metadata,
+			Disks:    []SBackupManifest{},
+		}
+		for i, backupId := range backupIds {
+			manifest, err := s.loadManifest(ctx, backupId)
+			if err != nil {
+				return err
+			}
+			if len(manifest.BackingBackupId) > 0 {
+				// package is self-contained, incremental backup is flattened
+				backupDir := path.Join(tmpFileDir, fmt.Sprintf("backups_%d", i))
+				if err := os.MkdirAll(backupDir, 0755); err != nil {
+					return errors.Wrapf(err, "mkdir %s", backupDir)
+				}
+				head, _, err := s.fetchBackup(ctx, backupDir, backupId)
+				if err != nil {
+					return errors.Wrap(err, "fetch disk backup")
+				}
+				packageDiskPath := path.Join(tmpFileDir, fmt.Sprintf("%s_%d", PackageDiskFilename, i))
+				if err := copyBackupFile(head, packageDiskPath); err != nil {
+					return errors.Wrap(err, "flatten disk backup")
+				}
+				removeTempDir(backupDir)
+				var newChunks, reused []string
+				manifest, newChunks, reused, err = s.storeFile(ctx, packageDiskPath)
+				created = append(created, newChunks...)
+				if err != nil {
+					return errors.Wrap(err, "store flattened disk backup")
+				}
+				if err := s.checkChunksExist(reused); err != nil {
+					return err
+				}
+				os.Remove(packageDiskPath)
+				manifest.BackupId = backupId
+			}
+			pack.Disks = append(pack.Disks, *manifest)
+		}
+
+		lockman.LockRawObject(ctx, "package", packageName)
+		defer lockman.ReleaseRawObject(ctx, "package", packageName)
+
+		// find the package name
+		tried := 0
+		for {
+			exists, err := s.store.hasBlob(getPackManifestKey(packageFilename))
+			if err != nil {
+				return errors.Wrap(err, "check package exists")
+			}
+			if !exists {
+				break
+			}
+			tried++
+			packageFilename = fmt.Sprintf("%s-%d", packageName, tried)
+		}
+		if err := s.saveJson(ctx, getPackManifestKey(packageFilename), pack); err != nil {
+			return errors.Wrapf(err, "save package %s", packageFilename)
+		}
+		return nil
+	}()
+	if err != nil {
+		if gcErr := s.collectGarbageExclusively(ctx, created); gcErr != nil {
+			log.Errorf("backup storage %s collect garbage: %s", s.BackupStorageId, gcErr)
+		}
+		return "", err
+	}
+	return packageFilename, nil
+}
+
+func (s *SChunkedBackupStorage) InstanceUnpack(ctx context.Context, packageName string, metadataOnly bool) ([]string, *api.InstanceBackupPackMetadata, error) {
+	if err := s.store.attachBlobStore(); err != nil {
+		return nil, nil, errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	release, err := s.acquireWriterLease(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	packageName = strings.TrimSuffix(packageName, ".tar")
+	pack := &SBackupPackManifest{}
+	if err := s.loadJson(ctx, getPackManifestKey(packageName), pack); err != nil {
+		return nil, nil, errors.Wrapf(err, "load package %s", packageName)
+	}
+	if pack.Metadata == nil {
+		return nil, nil, errors.Errorf("package %s has no metadata", packageName)
+	}
+	if len(pack.Disks) != len(pack.Metadata.DiskMetadatas) {
+		return nil, nil, errors.Errorf("package %s has %d disks, expect %d", packageName, len(pack.Disks), len(pack.Metadata.DiskMetadatas))
+	}
+	backupIds := make([]string, len(pack.Disks))
+	if !metadataOnly {
+		// unpacked backups share chunks of package, only manifests are copied
+		for i := range pack.Disks {
+			backupId := db.DefaultUUIDGenerator()
+			manifest := pack.Disks[i]
+			manifest.BackupId = backupId
+			if err := s.saveJson(ctx, getManifestKey(backupId), &manifest); err != nil {
+				return nil, nil, errors.Wrapf(err, "save manifest of backup %s", backupId)
+			}
+			backupIds[i] = backupId
+		}
+	}
+	return backupIds, pack.Metadata, nil
+}
+
+func (s *SChunkedBackupStorage) ConvertFrom(srcPath string, format qemuimgfmt.TImageFormat, backupId string) (int, error) {
+	if err := s.store.attachBlobStore(); err != nil {
+		return 0, errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "convert")
+	if err != nil {
+		return 0, errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	destPath := path.Join(tmpFileDir, backupId)
+	srcInfo := qemuimg.SImageInfo{
+		Path:     srcPath,
+		Format:   format,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	destInfo := qemuimg.SImageInfo{
+		Path:     destPath,
+		Format:   qemuimgfmt.QCOW2,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	err = qemuimg.Convert(srcInfo, destInfo, true, nil)
+	if err != nil {
+		return 0, err
+	}
+	newImage, err := qemuimg.NewQemuImage(destPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.storeBackup(context.Background(), destPath, backupId, ""); err != nil {
+		return 0, errors.Wrap(err, "store backup")
+	}
+	return newImage.GetActualSizeMB(), nil
+}
+
+func (s *SChunkedBackupStorage) ConvertTo(destPath string, format qemuimgfmt.TImageFormat, backupId string) error {
+	if err := s.store.attachBlobStore(); err != nil {
+		return errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "convert")
+	if err != nil {
+		return errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	head, _, err := s.fetchBackup(context.Background(), tmpFileDir, backupId)
+	if err != nil {
+		return err
+	}
+	srcInfo := qemuimg.SImageInfo{
+		Path:     head,
+		Format:   qemuimgfmt.QCOW2,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	destInfo := qemuimg.SImageInfo{
+		Path:     destPath,
+		Format:   format,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	var workerOpts []string
+	if options.HostOptions.RestrictQemuImgConvertWorker {
+		workerOpts = nil
+	} else {
+		workerOpts = []string{"-W", "-m", "16"}
+	}
+	return qemuimg.Convert(srcInfo, destInfo, false, workerOpts)
+}
+
+func (s *SChunkedBackupStorage) RemoveBackup(backupId string) error {
+	if err := s.store.attachBlobStore(); err != nil {
+		return errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	ctx := context.Background()
+	manifest, err := s.loadManifest(ctx, backupId)
+	if err != nil {
+		if errors.Cause(err) == errors.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if err := s.store.removeBlob(getManifestKey(backupId)); err != nil {
+		return errors.Wrapf(err, "remove manifest of backup %s", backupId)
+	}
+	hashes := make([]string, 0, len(manifest.Chunks))
+	for _, chunk := range manifest.Chunks {
+		hashes = append(hashes, chunk.Hash)
+	}
+	return s.collectGarbageExclusively(ctx, hashes)
+}
+
+func (s *SChunkedBackupStorage) IsExists(backupId string) (bool, error) {
+	if err := s.store.attachBlobStore(); err != nil {
+		return false, errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+	return s.store.hasBlob(getManifestKey(backupId))
+}
+
+func (s *SChunkedBackupStorage) IsOnline() (bool, string, error) {
+	return s.store.IsOnline()
+}
+
+// VerifyBackup rechecks every chunk of backup and its parent backups
+func (s *SChunkedBackupStorage) VerifyBackup(ctx context.Context, backupId string) error {
+	if err := s.store.attachBlobStore(); err != nil {
+		return errors.Wrap(err, "attach backup storage")
+	}
+	defer s.store.detachBlobStore()
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for id := backupId; len(id) > 0; {
+		manifest, err := s.loadManifest(ctx, id)
+		if err != nil {
+			return err
+		}
+		reader := newChunkReader(s, manifest)
+		fileHash := sha256.New()
+		broken := 0
+		var sizeBytes int64
+		for _, chunk := range manifest.Chunks {
+			data, err := reader.read(ctx, chunk)
+			if err != nil {
+				log.Errorf("verify backup %s: %s", id, err)
+				broken++
+				continue
+			}
+			fileHash.Write(data)
+			sizeBytes += chunk.Size
+		}
+		if broken > 0 {
+			return errors.Errorf("%d of %d chunks of backup %s are missing or corrupted", broken, len(manifest.Chunks), id)
+		}
+		if sizeBytes != manifest.SizeBytes {
+			return errors.Errorf("size %d of backup %s mismatch %d", sizeBytes, id, manifest.SizeBytes)
+		}
+		if sum := hex.EncodeToString(fileHash.Sum(nil)); sum != manifest.Sha256 {
+			return errors.Errorf("sha256 %s of backup %s mismatch %s", sum, id, manifest.Sha256)
+		}
+		id = manifest.BackingBackupId
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+)
+
+func setupChunkedBackupStorage(t *testing.T) (*SChunkedBackupStorage, *fakeS3Server, string) {
+	store, bucket, tmpDir := setupObjectBackupStorage(t)
+	return NewChunkedBackupStorage(store.BackupStorageId, store), bucket, tmpDir
+}
+
+func writeRandomFile(t *testing.T, filename string, data []byte) {
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+}
+
+func countChunks(bucket *fakeS3Server) int {
+	return len(bucket.listKeys(BACKUP_REPO_CHUNK_DIR + "/"))
+}
+
+func restoreAndCompare(t *testing.T, s *SChunkedBackupStorage, tmpDir, backupId string, expect []byte) {
+	filename := path.Join(tmpDir, "restore-"+backupId)
+	manifest, err := s.loadManifest(context.Background(), backupId)
+	if err != nil {
+		t.Fatalf("loadManifest %s: %s", backupId, err)
+	}
+	if err := s.restoreFile(context.Background(), manifest, filename); err != nil {
+		t.Fatalf("restoreFile %s: %s", backupId, err)
+	}
+	got, _ := ioutil.ReadFile(filename)
+	if !bytes.Equal(got, expect) {
+		t.Errorf("restored content of %s mismatch", backupId)
+	}
+}
+
+func TestChunkedBackupStorageDedup(t *testing.T) {
+	s, bucket, tmpDir := setupChunkedBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+	ctx := context.Background()
+
+	data1 := make([]byte, 8*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data1)
+	// zeroes are compressed
+	for i := 2 * 1024 * 1024; i < 4*1024*1024; i++ {
+		data1[i] = 0
+	}
+	data2 := append([]byte{}, data1...)
+	copy(data2[6*1024*1024:], []byte("changed"))
+
+	src1 := path.Join(tmpDir, "src1")
+	src2 := path.Join(tmpDir, "src2")
+	writeRandomFile(t, src1, data1)
+	writeRandomFile(t, src2, data2)
+
+	if err := s.storeBackup(ctx, src1, "backup1", ""); err != nil {
+		t.Fatalf("store backup1: %s", err)
+	}
+	count1 := countChunks(bucket)
+	if err := s.storeBackup(ctx, src2, "backup2", ""); err != nil {
+		t.Fatalf("store backup2: %s", err)
+	}
+	count2 := countChunks(bucket)
+	if count2-count1 > 2 {
+		t.Errorf("expect at most 2 new chunks for backup2, got %d", count2-count1)
+	}
+	var stored int
+	for _, key := range bucket.listKeys(BACKUP_REPO_CHUNK_DIR + "/") {
+		stored += len(bucket.getObject(key))
+	}
+	if stored >= 2*len(data1) {
+		t.Errorf("expect chunks deduplicated, stored %d bytes", stored)
+	}
+
+	restoreAndCompare(t, s, tmpDir, "backup1", data1)
+	restoreAndCompare(t, s, tmpDir, "backup2", data2)
+
+	if err := s.RemoveBackup("backup1"); err != nil {
+		t.Fatalf("RemoveBackup backup1: %s", err)
+	}
+	if exists, _ := s.IsExists("backup1"); exists {
+		t.Errorf("backup1 should be removed")
+	}
+	if err := s.VerifyBackup(ctx, "backup2"); err != nil {
+		t.Errorf("backup2 should be intact after backup1 removed: %s", err)
+	}
+	restoreAndCompare(t, s, tmpDir, "backup2", data2)
+
+	if err := s.RemoveBackup("backup2"); err != nil {
+		t.Fatalf("RemoveBackup backup2: %s", err)
+	}
+	if count := countChunks(bucket); count != 0 {
+		t.Errorf("expect all chunks collected, %d left", count)
+	}
+}
+
+func TestChunkedBackupStorageVerify(t *testing.T) {
+	s, bucket, tmpDir := setupChunkedBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+	ctx := context.Background()
+
+	data := make([]byte, 3*1024*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+	src := path.Join(tmpDir, "src")
+	writeRandomFile(t, src, data)
+	if err := s.storeBackup(ctx, src, "backup1", ""); err != nil {
+		t.Fatalf("store backup1: %s", err)
+	}
+	if err := s.VerifyBackup(ctx, "backup1"); err != nil {
+		t.Fatalf("VerifyBackup: %s", err)
+	}
+
+	keys := bucket.listKeys(BACKUP_REPO_CHUNK_DIR + "/")
+	blob := bucket.getObject(keys[0])
+	blob[len(blob)-1] ^= 0xff
+	bucket.setObject(keys[0], blob)
+	if err := s.VerifyBackup(ctx, "backup1"); err == nil {
+		t.Errorf("expect corrupted chunk detected")
+	}
+	bucket.removeObject(keys[0])
+	if err := s.VerifyBackup(ctx, "backup1"); err == nil {
+		t.Errorf("expect missing chunk detected")
+	}
+}
+
+func TestChunkedBackupStoragePack(t *testing.T) {
+	lockman.Init(lockman.NewInMemoryLockManager())
+	s, bucket, tmpDir := setupChunkedBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+	ctx := context.Background()
+
+	data := make([]byte, 2*1024*1024)
+	rand.New(rand.NewSource(3)).Read(data)
+	src := path.Join(tmpDir, "src")
+	writeRandomFile(t, src, data)
+	if err := s.storeBackup(ctx, src, "backup1", ""); err != nil {
+		t.Fatalf("store backup1: %s", err)
+	}
+	count := countChunks(bucket)
+
+	metadata := &api.InstanceBackupPackMetadata{
+		DiskMetadatas: []api.DiskBackupPackMetadata{{}},
+	}
+	name, err := s.InstancePack(ctx, "pack", []string{"backup1"}, metadata)
+	if err != nil {
+		t.Fatalf("InstancePack: %s", err)
+	}
+	if name2, err := s.InstancePack(ctx, "pack", []string{"backup1"}, metadata); err != nil || name2 == name {
+		t.Errorf("expect another package name, got %s %v", name2, err)
+	}
+	if countChunks(bucket) != count {
+		t.Errorf("package should reuse chunks of backup")
+	}
+	if err := s.RemoveBackup("backup1"); err != nil {
+		t.Fatalf("RemoveBackup: %s", err)
+	}
+
+	backupIds, _, err := s.InstanceUnpack(ctx, name+".tar", false)
+	if err != nil {
+		t.Fatalf("InstanceUnpack: %s", err)
+	}
+	if len(backupIds) != 1 {
+		t.Fatalf("expect 1 backup unpacked, got %d", len(backupIds))
+	}
+	if err := s.VerifyBackup(ctx, backupIds[0]); err != nil {
+		t.Errorf("unpacked backup broken: %s", err)
+	}
+	restoreAndCompare(t, s, tmpDir, backupIds[0], data)
+}
+
+// pausedBlobStore pauses the first writer checking chunk existence, as if
+// it is in the middle of a backup
+type pausedBlobStore struct {
+	iBackupBlobStore
+
+	once    sync.Once
+	paused  chan struct{}
+	release chan struct{}
+}
+
+func (p *pausedBlobStore) hasBlob(key string) (bool, error) {
+	if strings.HasPrefix(key, BACKUP_REPO_CHUNK_DIR) {
+		p.once.Do(func() {
+			close(p.paused)
+			<-p.release
+		})
+	}
+	return p.iBackupBlobStore.hasBlob(key)
+}
+
+func TestChunkedBackupStorageConcurrentGc(t *testing.T) {
+	// hosts share the repository but not in-process locks
+	hostA, bucket, tmpDir := setupChunkedBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+	hostB := NewChunkedBackupStorage(hostA.BackupStorageId, hostA.store)
+	ctx := context.Background()
+
+	data1 := make([]byte, 4*1024*1024)
+	rand.New(rand.NewSource(4)).Read(data1)
+	data2 := append([]byte{}, data1[:3*1024*1024]...)
+	src1 := path.Join(tmpDir, "src1")
+	src2 := path.Join(tmpDir, "src2")
+	writeRandomFile(t, src1, data1)
+	writeRandomFile(t, src2, data2)
+	if err := hostA.storeBackup(ctx, src1, "backup1", ""); err != nil {
+		t.Fatalf("store backup1: %s", err)
+	}
+
+	paused := &pausedBlobStore{
+		iBackupBlobStore: hostA.store,
+		paused:           make(chan struct{}),
+		release:          make(chan struct{}),
+	}
+	writer := NewChunkedBackupStorage(hostA.BackupStorageId, paused)
+	done := make(chan error)
+	go func() {
+		done <- writer.storeBackup(ctx, src2, "backup2", "")
+	}()
+	<-paused.paused
+
+	// backup2 reuses chunks of backup1, which are kept while it is written
+	if err := hostB.RemoveBackup("backup1"); err != nil {
+		t.Fatalf("RemoveBackup backup1: %s", err)
+	}
+	if exists, _ := hostB.IsExists("backup1"); exists {
+		t.Errorf("backup1 should be removed")
+	}
+	garbage := bucket.listKeys(BACKUP_REPO_GARBAGE_DIR + "/")
+	if len(garbage) != 1 {
+		t.Errorf("expect garbage collection deferred, got %v", garbage)
+	}
+	close(paused.release)
+	if err := <-done; err != nil {
+		t.Fatalf("store backup2: %s", err)
+	}
+	if err := hostB.VerifyBackup(ctx, "backup2"); err != nil {
+		t.Fatalf("backup2 taken during garbage collection is broken: %s", err)
+	}
+	restoreAndCompare(t, hostB, tmpDir, "backup2", data2)
+	if leases := bucket.listKeys(BACKUP_REPO_LEASE_DIR + "/"); len(leases) != 0 {
+		t.Errorf("expect writer lease released, got %v", leases)
+	}
+
+	// chunks only of backup1 are collected with deferred garbage
+	if err := hostB.RemoveBackup("backup2"); err != nil {
+		t.Fatalf("RemoveBackup backup2: %s", err)
+	}
+	if count := countChunks(bucket); count != 0 {
+		t.Errorf("expect all chunks collected, %d left", count)
+	}
+	if garbage := bucket.listKeys(BACKUP_REPO_GARBAGE_DIR + "/"); len(garbage) != 0 {
+		t.Errorf("expect deferred garbage collected, got %v", garbage)
+	}
+}
+
+func TestChunkedBackupStorageLeases(t *testing.T) {
+	s, _, tmpDir := setupChunkedBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+	ctx := context.Background()
+
+	// stale lease of crashed host doesn't block garbage collection
+	stale := path.Join(BACKUP_REPO_LEASE_DIR, "crashed")
+	if err := s.saveJson(ctx, stale, &sBackupRepoLease{ExpireAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("save stale lease: %s", err)
+	}
+	release, err := s.acquireGcLock(ctx)
+	if err != nil {
+		t.Fatalf("acquireGcLock with stale writer lease: %s", err)
+	}
+	if exists, _ := s.store.hasBlob(stale); exists {
+		t.Errorf("stale lease should be removed")
+	}
+
+	// writers wait for garbage collection
+	oldInterval := backupRepoLeaseRetryInterval
+	backupRepoLeaseRetryInterval = 10 * time.Millisecond
+	defer func() { backupRepoLeaseRetryInterval = oldInterval }()
+	acquired := make(chan func())
+	go func() {
+		releaseWriter, err := s.acquireWriterLease(ctx)
+		if err != nil {
+			t.Errorf("acquireWriterLease: %s", err)
+		}
+		acquired <- releaseWriter
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("writer should wait for garbage collection")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	releaseWriter := <-acquired
+
+	// garbage collection never waits for writers
+	if _, err := s.acquireGcLock(ctx); err != errBackupRepoBusy {
+		t.Errorf("expect errBackupRepoBusy with writer running, got %v", err)
+	}
+	releaseWriter()
+	release, err = s.acquireGcLock(ctx)
+	if err != nil {
+		t.Fatalf("acquireGcLock: %s", err)
+	}
+	release()
+}
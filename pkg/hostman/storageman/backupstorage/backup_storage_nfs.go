@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -144,6 +146,32 @@ func (s *SNFSBackupStorage) CopyBackupTo(targetFilename string, backupId string)
 	defer s.unMount()
 	backupDir := s.getBackupDir()
 	srcFilename := path.Join(backupDir, backupId)
+	return copyBackupFile(srcFilename, targetFilename)
+}
+
+// copyBackupFile rebuilds a full image from an incremental backup, whose
+// parent backups are chained as relative backing files in backup dir
+func copyBackupFile(srcFilename, targetFilename string) error {
+	img, err := qemuimg.NewQemuImage(srcFilename)
+	if err != nil {
+		return errors.Wrapf(err, "NewQemuImage %s", srcFilename)
+	}
+	if img.IsChained() {
+		srcInfo := qemuimg.SImageInfo{
+			Path:    srcFilename,
+			Format:  qemuimgfmt.QCOW2,
+			IoLevel: qemuimg.IONiceNone,
+		}
+		destInfo := qemuimg.SImageInfo{
+			Path:    targetFilename,
+			Format:  qemuimgfmt.QCOW2,
+			IoLevel: qemuimg.IONiceNone,
+		}
+		if err := qemuimg.Convert(srcInfo, destInfo, true, nil); err != nil {
+			return errors.Wrapf(err, "flatten backup %s to %s", srcFilename, targetFilename)
+		}
+		return nil
+	}
 	if output, err := procutils.NewCommand("cp", srcFilename, targetFilename).Output(); err != nil {
 		log.Errorf("unable to cp %s to %s: %s", srcFilename, targetFilename, output)
 		return errors.Wrapf(err, "cp %s to %s failed and output is %q", srcFilename, targetFilename, output)
@@ -304,9 +332,8 @@ func (s *SNFSBackupStorage) InstancePack(ctx context.Context, packageName string
 	for i, backupId := range backupIds {
 		packageDiskPath := path.Join(packagePath, fmt.Sprintf("%s_%d", PackageDiskFilename, i))
 		backupPath := path.Join(backupDir, backupId)
-		if output, err := procutils.NewCommand("cp", backupPath, packageDiskPath).Output(); err != nil {
-			log.Errorf("unable to cp %s to %s: %s", backupPath, packageDiskPath, output)
-			return "", errors.Wrapf(err, "cp %s to %s failed and output is %q", backupPath, packageDiskPath, output)
+		if err := copyBackupFile(backupPath, packageDiskPath); err != nil {
+			return "", errors.Wrap(err, "copy disk backup")
 		}
 	}
 	// save snapshot metadata
@@ -390,19 +417,9 @@ func (s *SNFSBackupStorage) InstanceUnpack(ctx context.Context, packageName stri
 		return nil, nil, errors.Wrap(err, "unable to untar")
 	}
 	// unpack metadata
-	packageMetadataPath := path.Join(packagePath, PackageMetadataFilename)
-	metadataBytes, err := ioutil.ReadFile(packageMetadataPath)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to read metadata file")
-	}
-	metadataJson, err := jsonutils.Parse(metadataBytes)
+	metadata, err := loadPackageMetadata(packagePath)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "unable to parse string to json")
-	}
-	metadata := &api.InstanceBackupPackMetadata{}
-	err = metadataJson.Unmarshal(metadata)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "unmarshal backup metadata")
+		return nil, nil, err
 	}
 	// copy disk files only if !metadataOnly
 	backupIds := make([]string, len(metadata.DiskMetadatas))
@@ -525,3 +542,117 @@ func (s *SNFSBackupStorage) IsOnline() (bool, string, error) {
 	s.unMount()
 	return true, "", nil
 }
+
+func (s *SNFSBackupStorage) VerifyBackup(ctx context.Context, backupId string) error {
+	err := s.checkAndMount()
+	if err != nil {
+		return errors.Wrap(err, "unable to checkAndMount")
+	}
+	defer s.unMount()
+	filename := path.Join(s.getBackupDir(), backupId)
+	for len(filename) > 0 {
+		img, err := qemuimg.NewQemuImage(filename)
+		if err != nil {
+			return errors.Wrapf(err, "NewQemuImage %s", filename)
+		}
+		if !img.IsValid() {
+			return errors.Errorf("backup %s is missing or invalid", path.Base(filename))
+		}
+		if err := img.Check(); err != nil {
+			return errors.Wrapf(err, "check backup %s", path.Base(filename))
+		}
+		filename = ""
+		if img.IsChained() {
+			filename = path.Join(s.getBackupDir(), path.Base(img.BackFilePath))
+		}
+	}
+	return nil
+}
+
+func (s *SNFSBackupStorage) attachBlobStore() error {
+	return s.checkAndMount()
+}
+
+func (s *SNFSBackupStorage) detachBlobStore() {
+	s.unMount()
+}
+
+// putBlob writes blob to a temporary file renamed at last,
+// so that a partially written blob is never seen by readers
+func (s *SNFSBackupStorage) putBlob(ctx context.Context, key string, data []byte) error {
+	filename := path.Join(s.Path, key)
+	dir := path.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", dir)
+	}
+	f, err := ioutil.TempFile(dir, ".blob-")
+	if err != nil {
+		return errors.Wrapf(err, "create temp file in %s", dir)
+	}
+	tmpName := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, filename)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return errors.Wrapf(err, "write %s", filename)
+	}
+	return nil
+}
+
+func (s *SNFSBackupStorage) getBlob(ctx context.Context, key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path.Join(s.Path, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrap(errors.ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *SNFSBackupStorage) hasBlob(key string) (bool, error) {
+	return fileutils2.Exists(path.Join(s.Path, key)), nil
+}
+
+func (s *SNFSBackupStorage) removeBlob(key string) error {
+	err := os.Remove(path.Join(s.Path, key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *SNFSBackupStorage) listBlobs(prefix string) ([]string, error) {
+	root := path.Join(s.Path, prefix)
+	keys := []string{}
+	err := filepath.Walk(root, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		// skip blobs being written
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		key, err := filepath.Rel(s.Path, filename)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walk %s", root)
+	}
+	return keys, nil
+}
@@ -0,0 +1,757 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/util/qemuimgfmt"
+	"yunion.io/x/s3cli"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db"
+	"yunion.io/x/onecloud/pkg/cloudcommon/db/lockman"
+	"yunion.io/x/onecloud/pkg/hostman/options"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+)
+
+const (
+	OBJECT_BACKUP_PART_SIZE  = 64 * 1024 * 1024
+	OBJECT_BACKUP_MAX_PARTS  = 10000
+	OBJECT_BACKUP_PART_RETRY = 3
+
+	// user metadata holding sha256 of whole object, verified on downloading
+	OBJECT_BACKUP_META_SHA256 = "Sha256"
+)
+
+type sObjectStat struct {
+	SizeBytes int64
+	Sha256    string
+}
+
+// iObjectBucket is the subset of s3 api used by object backup storage
+type iObjectBucket interface {
+	Exists() (bool, error)
+	// Stat returns errors.ErrNotFound if object not exists
+	Stat(key string) (*sObjectStat, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, input io.Reader, size int64) error
+	Remove(key string) error
+	// List returns keys of all objects with prefix
+	List(prefix string) ([]string, error)
+
+	NewMultipartUpload(ctx context.Context, key string, meta map[string]string) (string, error)
+	UploadPart(ctx context.Context, key, uploadId string, partNumber int, input io.Reader, size int64, md5Base64 string) (string, error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadId string, etags []string) error
+	AbortMultipartUpload(ctx context.Context, key, uploadId string) error
+}
+
+type sS3Bucket struct {
+	client *s3cli.Client
+	bucket string
+}
+
+func newS3Bucket(endpoint *url.URL, bucket, accessKey, secret string) (*sS3Bucket, error) {
+	cli, err := s3cli.New(endpoint.Host, accessKey, secret, endpoint.Scheme == "https", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "s3cli.New")
+	}
+	cli.SetCustomTransport(httputils.GetTransport(true))
+	return &sS3Bucket{client: cli, bucket: bucket}, nil
+}
+
+func (b *sS3Bucket) Exists() (bool, error) {
+	exists, _, err := b.client.BucketExists(b.bucket)
+	return exists, err
+}
+
+func (b *sS3Bucket) Stat(key string) (*sObjectStat, error) {
+	info, err := b.client.StatObject(b.bucket, key, s3cli.StatObjectOptions{})
+	if err != nil {
+		if s3cli.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errors.Wrap(errors.ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return &sObjectStat{
+		SizeBytes: info.Size,
+		Sha256:    info.Metadata.Get("X-Amz-Meta-" + OBJECT_BACKUP_META_SHA256),
+	}, nil
+}
+
+func (b *sS3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.client.GetObject(b.bucket, key, s3cli.GetObjectOptions{})
+}
+
+func (b *sS3Bucket) Put(ctx context.Context, key string, input io.Reader, size int64) error {
+	_, err := b.client.PutObjectWithContext(ctx, b.bucket, key, input, size, s3cli.PutObjectOptions{})
+	return err
+}
+
+func (b *sS3Bucket) Remove(key string) error {
+	return b.client.RemoveObject(b.bucket, key)
+}
+
+func (b *sS3Bucket) List(prefix string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	keys := []string{}
+	for obj := range b.client.ListObjects(b.bucket, prefix, true, doneCh) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+func (b *sS3Bucket) NewMultipartUpload(ctx context.Context, key string, meta map[string]string) (string, error) {
+	result, err := b.client.InitiateMultipartUpload(ctx, b.bucket, key, s3cli.PutObjectOptions{UserMetadata: meta})
+	if err != nil {
+		return "", err
+	}
+	return result.UploadID, nil
+}
+
+func (b *sS3Bucket) UploadPart(ctx context.Context, key, uploadId string, partNumber int, input io.Reader, size int64, md5Base64 string) (string, error) {
+	part, err := b.client.UploadPart(ctx, b.bucket, key, uploadId, input, partNumber, md5Base64, "", size, nil)
+	if err != nil {
+		return "", err
+	}
+	return part.ETag, nil
+}
+
+func (b *sS3Bucket) CompleteMultipartUpload(ctx context.Context, key, uploadId string, etags []string) error {
+	complete := s3cli.CompleteMultipartUpload{}
+	complete.Parts = make([]s3cli.CompletePart, len(etags))
+	for i := range etags {
+		complete.Parts[i] = s3cli.CompletePart{
+			PartNumber: i + 1,
+			ETag:       etags[i],
+		}
+	}
+	_, err := b.client.CompleteMultipartUpload(ctx, b.bucket, key, uploadId, complete)
+	return err
+}
+
+func (b *sS3Bucket) AbortMultipartUpload(ctx context.Context, key, uploadId string) error {
+	return b.client.AbortMultipartUpload(ctx, b.bucket, key, uploadId)
+}
+
+// sObjectUploadState is persisted after each uploaded part,
+// an interrupted upload of the same file continues from the next part
+type sObjectUploadState struct {
+	Key       string
+	UploadId  string
+	SizeBytes int64
+	ModTime   int64
+	PartSize  int64
+	Sha256    string
+	Etags     []string
+}
+
+// SObjectBackupStorage stores backups in bucket of s3 compatible object storage,
+// e.g. minio or ceph rgw. Objects are transferred by streaming to or from local
+// temporary files, backups are never mounted.
+type SObjectBackupStorage struct {
+	BackupStorageId string
+	BucketUrl       string
+
+	bucket   iObjectBucket
+	prefix   string
+	partSize int64
+}
+
+func NewObjectBackupStorage(backupStorageId, bucketUrl, accessKey, secret string) (*SObjectBackupStorage, error) {
+	endpoint, err := url.Parse(bucketUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse bucket url %s", bucketUrl)
+	}
+	// bucket url is in path style, e.g. https://minio:9000/bucket/prefix
+	segs := strings.SplitN(strings.Trim(endpoint.Path, "/"), "/", 2)
+	if len(segs[0]) == 0 {
+		return nil, fmt.Errorf("need bucket name in object_bucket_url %s", bucketUrl)
+	}
+	bucket, err := newS3Bucket(endpoint, segs[0], accessKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	s := newObjectBackupStorage(backupStorageId, bucket)
+	s.BucketUrl = bucketUrl
+	if len(segs) > 1 {
+		s.prefix = strings.Trim(segs[1], "/")
+	}
+	return s, nil
+}
+
+func newObjectBackupStorage(backupStorageId string, bucket iObjectBucket) *SObjectBackupStorage {
+	return &SObjectBackupStorage{
+		BackupStorageId: backupStorageId,
+		bucket:          bucket,
+		partSize:        OBJECT_BACKUP_PART_SIZE,
+	}
+}
+
+func (s *SObjectBackupStorage) getBackupKey(backupId string) string {
+	return path.Join(s.prefix, "backups", backupId)
+}
+
+func (s *SObjectBackupStorage) getPackageKey(filename string) string {
+	return path.Join(s.prefix, "backuppacks", filename)
+}
+
+func (s *SObjectBackupStorage) getUploadStateFile(key string) string {
+	return path.Join(options.HostOptions.LocalBackupTempPath, "uploads", s.BackupStorageId, strings.ReplaceAll(key, "/", "_"))
+}
+
+func (s *SObjectBackupStorage) loadUploadState(key string, fi os.FileInfo) *sObjectUploadState {
+	content, err := ioutil.ReadFile(s.getUploadStateFile(key))
+	if err != nil {
+		return nil
+	}
+	state := &sObjectUploadState{}
+	if obj, err := jsonutils.Parse(content); err != nil {
+		return nil
+	} else if err := obj.Unmarshal(state); err != nil {
+		return nil
+	}
+	// file is rewritten since last upload, start over
+	if state.Key != key || state.SizeBytes != fi.Size() || state.ModTime != fi.ModTime().UnixNano() {
+		return nil
+	}
+	return state
+}
+
+func (s *SObjectBackupStorage) saveUploadState(state *sObjectUploadState) error {
+	filename := s.getUploadStateFile(state.Key)
+	if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", path.Dir(filename))
+	}
+	return fileutils2.FilePutContents(filename, jsonutils.Marshal(state).String(), false)
+}
+
+func (s *SObjectBackupStorage) removeUploadState(key string) {
+	if err := os.Remove(s.getUploadStateFile(key)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("remove upload state of %s: %s", key, err)
+	}
+}
+
+func (s *SObjectBackupStorage) getPartSize(sizeBytes int64) int64 {
+	partSize := s.partSize
+	if sizeBytes > partSize*OBJECT_BACKUP_MAX_PARTS {
+		partSize = (sizeBytes + OBJECT_BACKUP_MAX_PARTS - 1) / OBJECT_BACKUP_MAX_PARTS
+	}
+	return partSize
+}
+
+func fileSha256(f io.ReaderAt, sizeBytes int64) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(f, 0, sizeBytes)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isNoSuchUpload(err error) bool {
+	return s3cli.ToErrorResponse(errors.Cause(err)).Code == "NoSuchUpload"
+}
+
+// uploadFile uploads file by multipart upload, which is resumed if a previous
+// upload of the same file to the same key is interrupted
+func (s *SObjectBackupStorage) uploadFile(ctx context.Context, filename, key string) error {
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s", filename)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrapf(err, "open %s", filename)
+	}
+	defer f.Close()
+
+	state := s.loadUploadState(key, fi)
+	if state != nil {
+		log.Infof("resume uploading %s to %s from part %d", filename, key, len(state.Etags)+1)
+		err = s.uploadParts(ctx, f, state)
+		if err == nil || !isNoSuchUpload(err) {
+			return err
+		}
+		// upload is aborted or expired by server
+		log.Warningf("upload %s of %s not found, start over", state.UploadId, key)
+		s.removeUploadState(key)
+	}
+
+	sum, err := fileSha256(f, fi.Size())
+	if err != nil {
+		return errors.Wrapf(err, "sha256 of %s", filename)
+	}
+	uploadId, err := s.bucket.NewMultipartUpload(ctx, key, map[string]string{OBJECT_BACKUP_META_SHA256: sum})
+	if err != nil {
+		return errors.Wrapf(err, "new multipart upload %s", key)
+	}
+	state = &sObjectUploadState{
+		Key:       key,
+		UploadId:  uploadId,
+		SizeBytes: fi.Size(),
+		ModTime:   fi.ModTime().UnixNano(),
+		PartSize:  s.getPartSize(fi.Size()),
+		Sha256:    sum,
+		Etags:     []string{},
+	}
+	if err := s.saveUploadState(state); err != nil {
+		return errors.Wrap(err, "save upload state")
+	}
+	return s.uploadParts(ctx, f, state)
+}
+
+func (s *SObjectBackupStorage) uploadParts(ctx context.Context, f *os.File, state *sObjectUploadState) error {
+	partCount := int((state.SizeBytes + state.PartSize - 1) / state.PartSize)
+	if partCount == 0 {
+		partCount = 1
+	}
+	for i := len(state.Etags); i < partCount; i++ {
+		offset := int64(i) * state.PartSize
+		size := state.PartSize
+		if offset+size > state.SizeBytes {
+			size = state.SizeBytes - offset
+		}
+		etag, err := s.uploadPart(ctx, f, state, i+1, offset, size)
+		if err != nil {
+			// upload state is kept for resuming
+			return errors.Wrapf(err, "upload part %d of %s", i+1, state.Key)
+		}
+		state.Etags = append(state.Etags, etag)
+		if err := s.saveUploadState(state); err != nil {
+			return errors.Wrap(err, "save upload state")
+		}
+	}
+	err := s.bucket.CompleteMultipartUpload(ctx, state.Key, state.UploadId, state.Etags)
+	if err != nil {
+		return errors.Wrapf(err, "complete multipart upload %s", state.Key)
+	}
+	s.removeUploadState(state.Key)
+	return nil
+}
+
+// uploadPart sends md5 of part as Content-MD5, server rejects the part if
+// it is corrupted on the way
+func (s *SObjectBackupStorage) uploadPart(ctx context.Context, f *os.File, state *sObjectUploadState, partNumber int, offset, size int64) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(f, offset, size)); err != nil {
+		return "", errors.Wrap(err, "md5 of part")
+	}
+	sum := hash.Sum(nil)
+	var err error
+	for tried := 0; tried < OBJECT_BACKUP_PART_RETRY; tried++ {
+		var etag string
+		etag, err = s.bucket.UploadPart(ctx, state.Key, state.UploadId, partNumber, io.NewSectionReader(f, offset, size), size, base64.StdEncoding.EncodeToString(sum))
+		if err == nil {
+			// etag of part is md5 of its content unless it is encrypted by server
+			if len(etag) != 2*md5.Size || strings.EqualFold(etag, hex.EncodeToString(sum)) {
+				return etag, nil
+			}
+			err = errors.Errorf("etag %s of part mismatch md5 %x", etag, sum)
+		}
+		if isNoSuchUpload(err) {
+			return "", err
+		}
+		log.Warningf("upload part %d of %s failed %d times: %s", partNumber, state.Key, tried+1, err)
+	}
+	return "", err
+}
+
+// downloadFile streams object to file, sha256 recorded on uploading is verified
+func (s *SObjectBackupStorage) downloadFile(ctx context.Context, key, filename string) error {
+	stat, err := s.bucket.Stat(key)
+	if err != nil {
+		return errors.Wrapf(err, "stat %s", key)
+	}
+	rc, err := s.bucket.Get(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "get %s", key)
+	}
+	defer rc.Close()
+	err = func() error {
+		f, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+		if err != nil {
+			return errors.Wrapf(err, "open %s", filename)
+		}
+		defer f.Close()
+		hash := sha256.New()
+		n, err := io.Copy(io.MultiWriter(f, hash), rc)
+		if err != nil {
+			return errors.Wrapf(err, "download %s", key)
+		}
+		if n != stat.SizeBytes {
+			return errors.Errorf("downloaded %d bytes of %s, expect %d", n, key, stat.SizeBytes)
+		}
+		if sum := hex.EncodeToString(hash.Sum(nil)); len(stat.Sha256) > 0 && sum != stat.Sha256 {
+			return errors.Errorf("sha256 %s of %s mismatch %s", sum, key, stat.Sha256)
+		}
+		return nil
+	}()
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+	return nil
+}
+
+// fetchBackup downloads backup and its parent backups of incremental chain
+// into dir, backing files of them are kept relative
+func (s *SObjectBackupStorage) fetchBackup(ctx context.Context, dir, backupId string) (*qemuimg.SQemuImage, error) {
+	var head *qemuimg.SQemuImage
+	for id := backupId; len(id) > 0; {
+		filename := path.Join(dir, id)
+		if err := s.downloadFile(ctx, s.getBackupKey(id), filename); err != nil {
+			return nil, errors.Wrapf(err, "download backup %s", id)
+		}
+		img, err := qemuimg.NewQemuImage(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "NewQemuImage %s", filename)
+		}
+		if head == nil {
+			head = img
+		}
+		id = ""
+		if img.IsChained() {
+			id = path.Base(img.BackFilePath)
+		}
+	}
+	return head, nil
+}
+
+func removeTempDir(dir string) {
+	if output, err := procutils.NewCommand("rm", "-rf", dir).Output(); err != nil {
+		log.Errorf("unable to rm %s: %s", dir, output)
+	}
+}
+
+func (s *SObjectBackupStorage) CopyBackupFrom(srcFilename string, backupId string) error {
+	return s.uploadFile(context.Background(), srcFilename, s.getBackupKey(backupId))
+}
+
+func (s *SObjectBackupStorage) CopyBackupTo(targetFilename string, backupId string) error {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "download")
+	if err != nil {
+		return errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	img, err := s.fetchBackup(context.Background(), tmpFileDir, backupId)
+	if err != nil {
+		return err
+	}
+	if img.IsChained() {
+		return copyBackupFile(img.Path, targetFilename)
+	}
+	if output, err := procutils.NewCommand("mv", img.Path, targetFilename).Output(); err != nil {
+		return errors.Wrapf(err, "mv %s to %s failed and output is %q", img.Path, targetFilename, output)
+	}
+	return nil
+}
+
+func (s *SObjectBackupStorage) InstancePack(ctx context.Context, packageName string, backupIds []string, metadata *api.InstanceBackupPackMetadata) (string, error) {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "pack")
+	if err != nil {
+		return "", errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	packagePath := path.Join(tmpFileDir, packageName)
+	tmpPkgFilename := path.Join(tmpFileDir, packageName+".tar")
+	if err := os.MkdirAll(packagePath, 0755); err != nil {
+		return "", errors.Wrapf(err, "mkdir %s", packagePath)
+	}
+	// download disk files
+	for i, backupId := range backupIds {
+		backupDir := path.Join(tmpFileDir, fmt.Sprintf("backups_%d", i))
+		if err := os.MkdirAll(backupDir, 0755); err != nil {
+			return "", errors.Wrapf(err, "mkdir %s", backupDir)
+		}
+		img, err := s.fetchBackup(ctx, backupDir, backupId)
+		if err != nil {
+			return "", errors.Wrap(err, "fetch disk backup")
+		}
+		packageDiskPath := path.Join(packagePath, fmt.Sprintf("%s_%d", PackageDiskFilename, i))
+		if err := copyBackupFile(img.Path, packageDiskPath); err != nil {
+			return "", errors.Wrap(err, "copy disk backup")
+		}
+		removeTempDir(backupDir)
+	}
+	// save snapshot metadata
+	packageMetadataPath := path.Join(packagePath, PackageMetadataFilename)
+	err = ioutil.WriteFile(packageMetadataPath, []byte(jsonutils.Marshal(metadata).PrettyString()), 0644)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to write to %s", packageMetadataPath)
+	}
+	// tar
+	if output, err := procutils.NewCommand("tar", "-cf", tmpPkgFilename, "-C", tmpFileDir, packageName).Output(); err != nil {
+		log.Errorf("unable to 'tar -cf %s -C %s %s': %s", tmpPkgFilename, tmpFileDir, packageName, output)
+		return "", errors.Wrap(err, "unable to tar")
+	}
+	// upload to pack dir
+	lockman.LockRawObject(ctx, "package", packageName)
+	defer lockman.ReleaseRawObject(ctx, "package", packageName)
+
+	// find the filename
+	tried := 0
+	packageFilename := packageName + ".tar"
+	for {
+		exists, err := s.isObjectExists(s.getPackageKey(packageFilename))
+		if err != nil {
+			return "", errors.Wrap(err, "check package exists")
+		}
+		if !exists {
+			break
+		}
+		tried++
+		packageFilename = fmt.Sprintf("%s-%d.tar", packageName, tried)
+	}
+	if err := s.uploadFile(ctx, tmpPkgFilename, s.getPackageKey(packageFilename)); err != nil {
+		return "", errors.Wrap(err, "upload package")
+	}
+	return packageFilename, nil
+}
+
+func (s *SObjectBackupStorage) InstanceUnpack(ctx context.Context, packageName string, metadataOnly bool) ([]string, *api.InstanceBackupPackMetadata, error) {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "unpack")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	packageName = strings.TrimSuffix(packageName, ".tar")
+	packageFilename := path.Join(tmpFileDir, packageName+".tar")
+	if err := s.downloadFile(ctx, s.getPackageKey(packageName+".tar"), packageFilename); err != nil {
+		return nil, nil, errors.Wrapf(err, "download package %s", packageName)
+	}
+
+	// untar to temp dir
+	packagePath := path.Join(tmpFileDir, packageName)
+	untarArgs := []string{
+		"-xf", packageFilename, "-C", tmpFileDir,
+	}
+	if metadataOnly {
+		untarArgs = append(untarArgs, fmt.Sprintf("%s/metadata", packageName))
+	} else {
+		untarArgs = append(untarArgs, packageName)
+	}
+	if output, err := procutils.NewCommand("tar", untarArgs...).Output(); err != nil {
+		log.Errorf("unable to 'tar -xf %s -C %s %s': %s", packageFilename, tmpFileDir, packageName, output)
+		return nil, nil, errors.Wrap(err, "unable to untar")
+	}
+	os.Remove(packageFilename)
+
+	metadata, err := loadPackageMetadata(packagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	// upload disk files only if !metadataOnly
+	backupIds := make([]string, len(metadata.DiskMetadatas))
+	if !metadataOnly {
+		for i := 0; i < len(metadata.DiskMetadatas); i++ {
+			backupId := db.DefaultUUIDGenerator()
+			backupIds[i] = backupId
+			packageDiskPath := path.Join(packagePath, fmt.Sprintf("%s_%d", PackageDiskFilename, i))
+			if err := s.uploadFile(ctx, packageDiskPath, s.getBackupKey(backupId)); err != nil {
+				return nil, nil, errors.Wrapf(err, "upload %s", packageDiskPath)
+			}
+		}
+	}
+	return backupIds, metadata, nil
+}
+
+func (s *SObjectBackupStorage) ConvertFrom(srcPath string, format qemuimgfmt.TImageFormat, backupId string) (int, error) {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "convert")
+	if err != nil {
+		return 0, errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	destPath := path.Join(tmpFileDir, backupId)
+	srcInfo := qemuimg.SImageInfo{
+		Path:     srcPath,
+		Format:   format,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	destInfo := qemuimg.SImageInfo{
+		Path:     destPath,
+		Format:   qemuimgfmt.QCOW2,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	err = qemuimg.Convert(srcInfo, destInfo, true, nil)
+	if err != nil {
+		return 0, err
+	}
+	newImage, err := qemuimg.NewQemuImage(destPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.uploadFile(context.Background(), destPath, s.getBackupKey(backupId)); err != nil {
+		return 0, errors.Wrap(err, "upload backup")
+	}
+	return newImage.GetActualSizeMB(), nil
+}
+
+func (s *SObjectBackupStorage) ConvertTo(destPath string, format qemuimgfmt.TImageFormat, backupId string) error {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "convert")
+	if err != nil {
+		return errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	img, err := s.fetchBackup(context.Background(), tmpFileDir, backupId)
+	if err != nil {
+		return err
+	}
+	srcInfo := qemuimg.SImageInfo{
+		Path:     img.Path,
+		Format:   qemuimgfmt.QCOW2,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	destInfo := qemuimg.SImageInfo{
+		Path:     destPath,
+		Format:   format,
+		IoLevel:  qemuimg.IONiceNone,
+		Password: "",
+	}
+	var workerOpts []string
+	if options.HostOptions.RestrictQemuImgConvertWorker {
+		workerOpts = nil
+	} else {
+		workerOpts = []string{"-W", "-m", "16"}
+	}
+	return qemuimg.Convert(srcInfo, destInfo, false, workerOpts)
+}
+
+func (s *SObjectBackupStorage) RemoveBackup(backupId string) error {
+	key := s.getBackupKey(backupId)
+	s.removeUploadState(key)
+	if err := s.bucket.Remove(key); err != nil {
+		return errors.Wrapf(err, "remove %s", key)
+	}
+	return nil
+}
+
+func (s *SObjectBackupStorage) isObjectExists(key string) (bool, error) {
+	_, err := s.bucket.Stat(key)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Cause(err) == errors.ErrNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *SObjectBackupStorage) IsExists(backupId string) (bool, error) {
+	return s.isObjectExists(s.getBackupKey(backupId))
+}
+
+func (s *SObjectBackupStorage) IsOnline() (bool, string, error) {
+	exists, err := s.bucket.Exists()
+	if err != nil {
+		return false, errors.Wrap(ErrorBackupStorageOffline, err.Error()).Error(), nil
+	}
+	if !exists {
+		return false, fmt.Sprintf("bucket of %s not exists", s.BucketUrl), nil
+	}
+	return true, "", nil
+}
+
+// VerifyBackup downloads backup chain, sha256 of each object is checked on downloading
+func (s *SObjectBackupStorage) VerifyBackup(ctx context.Context, backupId string) error {
+	tmpFileDir, err := ioutil.TempDir(options.HostOptions.LocalBackupTempPath, "verify")
+	if err != nil {
+		return errors.Wrap(err, "create tempdir")
+	}
+	defer removeTempDir(tmpFileDir)
+
+	img, err := s.fetchBackup(ctx, tmpFileDir, backupId)
+	if err != nil {
+		return err
+	}
+	return img.Check()
+}
+
+func (s *SObjectBackupStorage) attachBlobStore() error {
+	return nil
+}
+
+func (s *SObjectBackupStorage) detachBlobStore() {}
+
+func (s *SObjectBackupStorage) putBlob(ctx context.Context, key string, data []byte) error {
+	return s.bucket.Put(ctx, path.Join(s.prefix, key), bytes.NewReader(data), int64(len(data)))
+}
+
+func (s *SObjectBackupStorage) getBlob(ctx context.Context, key string) ([]byte, error) {
+	rc, err := s.bucket.Get(ctx, path.Join(s.prefix, key))
+	if err != nil {
+		if s3cli.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errors.Wrap(errors.ErrNotFound, key)
+		}
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		if s3cli.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errors.Wrap(errors.ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *SObjectBackupStorage) hasBlob(key string) (bool, error) {
+	return s.isObjectExists(path.Join(s.prefix, key))
+}
+
+func (s *SObjectBackupStorage) removeBlob(key string) error {
+	return s.bucket.Remove(path.Join(s.prefix, key))
+}
+
+func (s *SObjectBackupStorage) listBlobs(prefix string) ([]string, error) {
+	keys, err := s.bucket.List(path.Join(s.prefix, prefix) + "/")
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i] = strings.TrimPrefix(strings.TrimPrefix(keys[i], s.prefix), "/")
+	}
+	return keys, nil
+}
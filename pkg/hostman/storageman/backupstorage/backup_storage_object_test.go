@@ -0,0 +1,500 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/hostman/options"
+)
+
+const (
+	testS3Bucket       = "test-bucket"
+	testS3LastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+)
+
+// fakeS3Server is an in-memory s3 service serving the subset of api used
+// by sS3Bucket.  Requests are not authenticated
+type fakeS3Server struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	objects map[string][]byte
+	metas   map[string]http.Header
+	uploads map[string]map[int][]byte
+	upMetas map[string]http.Header
+
+	// corrupt object content after uploaded
+	corrupt bool
+
+	uploadedParts int
+}
+
+func newFakeS3Server() *fakeS3Server {
+	srv := &fakeS3Server{
+		objects: map[string][]byte{},
+		metas:   map[string]http.Header{},
+		uploads: map[string]map[int][]byte{},
+		upMetas: map[string]http.Header{},
+	}
+	srv.Server = httptest.NewServer(srv)
+	return srv
+}
+
+func (srv *fakeS3Server) getObject(key string) []byte {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return append([]byte{}, srv.objects[key]...)
+}
+
+func (srv *fakeS3Server) setObject(key string, data []byte) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.objects[key] = data
+}
+
+func (srv *fakeS3Server) removeObject(key string) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	delete(srv.objects, key)
+	delete(srv.metas, key)
+}
+
+func (srv *fakeS3Server) listKeys(prefix string) []string {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	keys := []string{}
+	for key := range srv.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// expireUploads drops incomplete multipart uploads like lifecycle rules of s3
+func (srv *fakeS3Server) expireUploads() {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.uploads = map[string]map[int][]byte{}
+}
+
+func (srv *fakeS3Server) getUploadedParts() int {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.uploadedParts
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message><Resource>%s</Resource></Error>", code, code, r.URL.Path)
+	}
+}
+
+func writeS3Xml(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
+
+// readS3Body decodes aws-chunked payload signed by streaming signature v4,
+// which is used by s3cli to put objects over http
+func readS3Body(r *http.Request) ([]byte, error) {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return ioutil.ReadAll(r.Body)
+	}
+	reader := bufio.NewReader(r.Body)
+	buf := &bytes.Buffer{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrap(err, "read chunk header")
+		}
+		sizeHex := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse chunk size %q", sizeHex)
+		}
+		if size == 0 {
+			return buf.Bytes(), nil
+		}
+		if _, err := io.CopyN(buf, reader, size); err != nil {
+			return nil, errors.Wrap(err, "read chunk")
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, errors.Wrap(err, "read chunk trailer")
+		}
+	}
+}
+
+func userMetas(header http.Header) http.Header {
+	metas := http.Header{}
+	for k, v := range header {
+		if strings.HasPrefix(k, "X-Amz-Meta-") {
+			metas[k] = v
+		}
+	}
+	return metas
+}
+
+func md5Etag(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (srv *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segs := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if segs[0] != testS3Bucket {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	key := ""
+	if len(segs) > 1 {
+		key = segs[1]
+	}
+	query := r.URL.Query()
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if len(key) == 0 {
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet && query.Has("location"):
+			writeS3Xml(w, struct {
+				XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+			}{})
+		case r.Method == http.MethodGet:
+			srv.listObjects(w, query.Get("prefix"))
+		default:
+			writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+		}
+		return
+	}
+
+	uploadId := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := srv.objects[key]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		for k, v := range srv.metas[key] {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Last-Modified", testS3LastModified)
+		w.Header().Set("ETag", `"`+md5Etag(data)+`"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodPut && len(uploadId) > 0:
+		parts, ok := srv.uploads[uploadId]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		sum := md5.Sum(data)
+		if base64.StdEncoding.EncodeToString(sum[:]) != r.Header.Get("Content-Md5") {
+			writeS3Error(w, r, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[partNumber] = data
+		srv.uploadedParts++
+		w.Header().Set("ETag", `"`+md5Etag(data)+`"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		srv.objects[key] = data
+		srv.metas[key] = userMetas(r.Header)
+		w.Header().Set("ETag", `"`+md5Etag(data)+`"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadId := fmt.Sprintf("upload-%d", len(srv.upMetas))
+		srv.uploads[uploadId] = map[int][]byte{}
+		srv.upMetas[uploadId] = userMetas(r.Header)
+		writeS3Xml(w, struct {
+			XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: testS3Bucket, Key: key, UploadId: uploadId})
+	case r.Method == http.MethodPost && len(uploadId) > 0:
+		srv.completeMultipartUpload(w, r, key, uploadId)
+	case r.Method == http.MethodDelete && len(uploadId) > 0:
+		delete(srv.uploads, uploadId)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(srv.objects, key)
+		delete(srv.metas, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (srv *fakeS3Server) listObjects(w http.ResponseWriter, prefix string) {
+	type sContent struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name        string
+		Prefix      string
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []sContent
+	}{Name: testS3Bucket, Prefix: prefix, MaxKeys: 1000}
+	keys := []string{}
+	for key := range srv.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result.Contents = append(result.Contents, sContent{
+			Key:          key,
+			LastModified: "2006-01-02T15:04:05.000Z",
+			ETag:         `"` + md5Etag(srv.objects[key]) + `"`,
+			Size:         len(srv.objects[key]),
+		})
+	}
+	writeS3Xml(w, result)
+}
+
+func (srv *fakeS3Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, key, uploadId string) {
+	parts, ok := srv.uploads[uploadId]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	complete := struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}{}
+	if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	buf := &bytes.Buffer{}
+	for i, part := range complete.Parts {
+		data, ok := parts[part.PartNumber]
+		if !ok || part.PartNumber != i+1 || strings.Trim(part.ETag, `"`) != md5Etag(data) {
+			writeS3Error(w, r, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		buf.Write(data)
+	}
+	data := buf.Bytes()
+	if srv.corrupt && len(data) > 0 {
+		data[0] ^= 0xff
+	}
+	srv.objects[key] = data
+	srv.metas[key] = srv.upMetas[uploadId]
+	delete(srv.uploads, uploadId)
+	writeS3Xml(w, struct {
+		XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: testS3Bucket, Key: key, ETag: `"` + md5Etag(data) + `-1"`})
+}
+
+func setupObjectBackupStorage(t *testing.T) (*SObjectBackupStorage, *fakeS3Server, string) {
+	tmpDir, err := ioutil.TempDir("", "objectbackup")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	options.HostOptions.LocalBackupTempPath = tmpDir
+	srv := newFakeS3Server()
+	t.Cleanup(srv.Close)
+	s, err := NewObjectBackupStorage("test-backup-storage", srv.URL+"/"+testS3Bucket, "access", "secret")
+	if err != nil {
+		t.Fatalf("NewObjectBackupStorage: %s", err)
+	}
+	s.partSize = 1024
+	return s, srv, tmpDir
+}
+
+func writeTestFile(t *testing.T, filename string, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return data
+}
+
+func TestObjectBackupStorageUploadDownload(t *testing.T) {
+	s, bucket, tmpDir := setupObjectBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+
+	src := path.Join(tmpDir, "src")
+	data := writeTestFile(t, src, 3*1024+100)
+	if err := s.CopyBackupFrom(src, "backup1"); err != nil {
+		t.Fatalf("CopyBackupFrom: %s", err)
+	}
+	if parts := bucket.getUploadedParts(); parts != 4 {
+		t.Errorf("expect 4 parts uploaded, got %d", parts)
+	}
+	if exists, err := s.IsExists("backup1"); err != nil || !exists {
+		t.Errorf("expect backup1 exists, got %v %v", exists, err)
+	}
+
+	dest := path.Join(tmpDir, "dest")
+	if err := s.downloadFile(context.Background(), s.getBackupKey("backup1"), dest); err != nil {
+		t.Fatalf("downloadFile: %s", err)
+	}
+	got, _ := ioutil.ReadFile(dest)
+	if !bytes.Equal(got, data) {
+		t.Errorf("downloaded content mismatch")
+	}
+
+	if err := s.RemoveBackup("backup1"); err != nil {
+		t.Fatalf("RemoveBackup: %s", err)
+	}
+	if exists, err := s.IsExists("backup1"); err != nil || exists {
+		t.Errorf("expect backup1 removed, got %v %v", exists, err)
+	}
+}
+
+func TestObjectBackupStorageResumeUpload(t *testing.T) {
+	s, bucket, tmpDir := setupObjectBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+
+	src := path.Join(tmpDir, "src")
+	data := writeTestFile(t, src, 5*1024)
+	s3Bucket := s.bucket
+	s.bucket = &failingBucket{iObjectBucket: s3Bucket, failPart: 3}
+	if err := s.CopyBackupFrom(src, "backup1"); err == nil {
+		t.Fatalf("expect upload failure")
+	}
+	if parts := bucket.getUploadedParts(); parts != 2 {
+		t.Fatalf("expect 2 parts uploaded before failure, got %d", parts)
+	}
+
+	s.bucket = s3Bucket
+	if err := s.CopyBackupFrom(src, "backup1"); err != nil {
+		t.Fatalf("resume CopyBackupFrom: %s", err)
+	}
+	if parts := bucket.getUploadedParts(); parts != 5 {
+		t.Errorf("expect parts 3-5 uploaded on resuming, got %d parts in total", parts)
+	}
+	if !bytes.Equal(bucket.getObject(s.getBackupKey("backup1")), data) {
+		t.Errorf("resumed object content mismatch")
+	}
+	if _, err := os.Stat(s.getUploadStateFile(s.getBackupKey("backup1"))); !os.IsNotExist(err) {
+		t.Errorf("upload state should be removed after completed")
+	}
+}
+
+func TestObjectBackupStorageRestartExpiredUpload(t *testing.T) {
+	s, bucket, tmpDir := setupObjectBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+
+	src := path.Join(tmpDir, "src")
+	data := writeTestFile(t, src, 2*1024)
+	s3Bucket := s.bucket
+	s.bucket = &failingBucket{iObjectBucket: s3Bucket, failPart: 2}
+	if err := s.CopyBackupFrom(src, "backup1"); err == nil {
+		t.Fatalf("expect upload failure")
+	}
+	// server expires the incomplete upload
+	bucket.expireUploads()
+
+	s.bucket = s3Bucket
+	if err := s.CopyBackupFrom(src, "backup1"); err != nil {
+		t.Fatalf("CopyBackupFrom after upload expired: %s", err)
+	}
+	if !bytes.Equal(bucket.getObject(s.getBackupKey("backup1")), data) {
+		t.Errorf("object content mismatch")
+	}
+}
+
+func TestObjectBackupStorageChecksumMismatch(t *testing.T) {
+	s, bucket, tmpDir := setupObjectBackupStorage(t)
+	defer os.RemoveAll(tmpDir)
+
+	bucket.corrupt = true
+	src := path.Join(tmpDir, "src")
+	writeTestFile(t, src, 1500)
+	if err := s.CopyBackupFrom(src, "backup1"); err != nil {
+		t.Fatalf("CopyBackupFrom: %s", err)
+	}
+	dest := path.Join(tmpDir, "dest")
+	if err := s.downloadFile(context.Background(), s.getBackupKey("backup1"), dest); err == nil {
+		t.Errorf("expect sha256 mismatch of corrupted object")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("corrupted download should be removed")
+	}
+}
+
+// failingBucket fails the part every time
+type failingBucket struct {
+	iObjectBucket
+	failPart int
+}
+
+func (b *failingBucket) UploadPart(ctx context.Context, key, uploadId string, partNumber int, input io.Reader, size int64, md5Base64 string) (string, error) {
+	if partNumber == b.failPart {
+		return "", errors.Error("connection reset")
+	}
+	return b.iObjectBucket.UploadPart(ctx, key, uploadId, partNumber, input, size, md5Base64)
+}
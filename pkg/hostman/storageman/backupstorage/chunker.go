@@ -0,0 +1,142 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"io"
+)
+
+const (
+	BACKUP_CHUNK_MIN_SIZE = 256 * 1024
+	BACKUP_CHUNK_AVG_SIZE = 1024 * 1024
+	BACKUP_CHUNK_MAX_SIZE = 4 * 1024 * 1024
+)
+
+// gearTable must never change, otherwise chunks of new backups
+// are no longer deduplicated against existing ones
+var gearTable [256]uint64
+
+func init() {
+	// splitmix64 with fixed seed
+	seed := uint64(0x6f6e65636c6f7564)
+	for i := range gearTable {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		gearTable[i] = z ^ (z >> 31)
+	}
+}
+
+// sChunker splits stream into content defined chunks by gear rolling hash
+// with normalized chunking as FastCDC, so that an insertion or deletion
+// only changes chunks around it
+type sChunker struct {
+	rd  io.Reader
+	buf []byte
+	eof bool
+
+	start int
+	end   int
+
+	minSize int
+	avgSize int
+	maxSize int
+	// mask with more bits before average size makes small chunks rare,
+	// mask with less bits after makes large chunks rare
+	maskS uint64
+	maskL uint64
+}
+
+func newChunker(rd io.Reader, minSize, avgSize, maxSize int) *sChunker {
+	bits := uint(0)
+	for (1 << bits) < avgSize {
+		bits++
+	}
+	return &sChunker{
+		rd:      rd,
+		buf:     make([]byte, maxSize),
+		minSize: minSize,
+		avgSize: avgSize,
+		maxSize: maxSize,
+		// rolling hash shifts left, so highest bits cover the widest window
+		maskS: ^uint64(0) << (64 - (bits + 2)),
+		maskL: ^uint64(0) << (64 - (bits - 2)),
+	}
+}
+
+func (c *sChunker) fill() error {
+	if c.start > 0 {
+		copy(c.buf, c.buf[c.start:c.end])
+		c.end -= c.start
+		c.start = 0
+	}
+	for !c.eof && c.end < len(c.buf) {
+		n, err := c.rd.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Next returns next chunk, which is only valid until the next call.
+// io.EOF is returned after all data is consumed
+func (c *sChunker) Next() ([]byte, error) {
+	if c.end-c.start < c.maxSize && !c.eof {
+		if err := c.fill(); err != nil {
+			return nil, err
+		}
+	}
+	data := c.buf[c.start:c.end]
+	if len(data) == 0 {
+		return nil, io.EOF
+	}
+	n := c.cutPoint(data)
+	c.start += n
+	return data[:n], nil
+}
+
+func (c *sChunker) cutPoint(data []byte) int {
+	n := len(data)
+	if n <= c.minSize {
+		return n
+	}
+	if n > c.maxSize {
+		n = c.maxSize
+	}
+	normal := c.avgSize
+	if normal > n {
+		normal = n
+	}
+	var fp uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gearTable[data[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backupstorage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func splitChunks(t *testing.T, data []byte) [][]byte {
+	chunks := [][]byte{}
+	chunker := newChunker(bytes.NewReader(data), BACKUP_CHUNK_MIN_SIZE, BACKUP_CHUNK_AVG_SIZE, BACKUP_CHUNK_MAX_SIZE)
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %s", err)
+		}
+		chunks = append(chunks, append([]byte{}, chunk...))
+	}
+	return chunks
+}
+
+func TestChunker(t *testing.T) {
+	data := make([]byte, 16*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks := splitChunks(t, data)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatalf("chunks do not compose the data")
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > BACKUP_CHUNK_MAX_SIZE || (len(chunk) < BACKUP_CHUNK_MIN_SIZE && i != len(chunks)-1) {
+			t.Errorf("chunk %d size %d out of range", i, len(chunk))
+		}
+	}
+
+	// insertion only changes chunks around it
+	shifted := append(append(append([]byte{}, data[:5*1024*1024]...), []byte("inserted")...), data[5*1024*1024:]...)
+	known := map[[32]byte]bool{}
+	for _, chunk := range chunks {
+		known[sha256.Sum256(chunk)] = true
+	}
+	changed := 0
+	for _, chunk := range splitChunks(t, shifted) {
+		if !known[sha256.Sum256(chunk)] {
+			changed++
+		}
+	}
+	if changed > 2 {
+		t.Errorf("expect at most 2 chunks changed by insertion, got %d of %d", changed, len(chunks))
+	}
+}
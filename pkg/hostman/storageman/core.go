@@ -91,8 +91,17 @@ func NewStorageManager(host hostutils.IHost) (*SStorageManager, error) {
 		}
 	}
 
+	thinPools := map[string]string{}
+	for _, conf := range options.HostOptions.LVMThinPools {
+		vgPool := strings.Split(conf, "/")
+		if len(vgPool) != 2 {
+			return nil, fmt.Errorf("bad lvm thin pool config %s", conf)
+		}
+		thinPools[vgPool[0]] = vgPool[1]
+	}
 	for i, d := range options.HostOptions.LVMVolumeGroups {
 		s := NewLVMStorage(ret, d, i)
+		s.ThinPool = thinPools[d]
 		if err := s.Accessible(); err == nil {
 			ret.Storages = append(ret.Storages, s)
 			if allFull && s.GetFreeSizeMb() > MINIMAL_FREE_SPACE {
@@ -132,7 +141,7 @@ func (s *SStorageManager) Remove(storage IStorage) {
 		delete(s.SharedFileStorageImagecacheManagers, storage.GetStoragecacheId())
 	} else if storage.StorageType() == api.STORAGE_RBD {
 		delete(s.RbdStorageImagecacheManagers, storage.GetStoragecacheId())
-	} else if storage.StorageType() == api.STORAGE_LVM {
+	} else if utils.IsInStringArray(storage.StorageType(), []string{api.STORAGE_LVM, api.STORAGE_SLVM}) {
 		delete(s.LVMStorageImagecacheManagers, storage.GetStoragecacheId())
 	}
 	for index, iS := range s.Storages {
@@ -312,10 +321,16 @@ func (s *SStorageManager) InitSharedStorageImageCache(storageType, storagecacheI
 		if rbdStorage := s.GetStoragecacheById(storagecacheId); rbdStorage == nil {
 			s.AddRbdStorageImagecache(imagecachePath, storage, storagecacheId)
 		}
+	} else if storageType == api.STORAGE_SLVM {
+		s.initLVMStorageImageCache(storagecacheId, imagecachePath, true)
 	}
 }
 
 func (s *SStorageManager) InitLVMStorageImageCache(storagecacheId, vg string) {
+	s.initLVMStorageImageCache(storagecacheId, vg, false)
+}
+
+func (s *SStorageManager) initLVMStorageImageCache(storagecacheId, vg string, lvmlockd bool) {
 	if len(storagecacheId) == 0 {
 		return
 	}
@@ -323,8 +338,19 @@ func (s *SStorageManager) InitLVMStorageImageCache(storagecacheId, vg string) {
 		s.LVMStorageImagecacheManagers = map[string]IImageCacheManger{}
 	}
 	if _, ok := s.LVMStorageImagecacheManagers[storagecacheId]; !ok {
-		s.LVMStorageImagecacheManagers[storagecacheId] = NewLVMImageCacheManager(s, vg, storagecacheId)
+		manager := NewLVMImageCacheManager(s, vg, storagecacheId, lvmlockd)
+		manager.thinPool = s.getLVMThinPool(vg)
+		s.LVMStorageImagecacheManagers[storagecacheId] = manager
+	}
+}
+
+func (s *SStorageManager) getLVMThinPool(vg string) string {
+	for i := range s.Storages {
+		if storage, ok := s.Storages[i].(*SLVMStorage); ok && storage.GetPath() == vg {
+			return storage.ThinPool
+		}
 	}
+	return ""
 }
 
 func (s *SStorageManager) InitSharedFileStorageImagecache(storagecacheId, path string) {
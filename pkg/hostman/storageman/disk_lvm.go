@@ -22,6 +22,8 @@ import (
 	"yunion.io/x/onecloud/pkg/util/fileutils2"
 	"yunion.io/x/onecloud/pkg/util/procutils"
 	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+	"yunion.io/x/onecloud/pkg/util/seclib2"
 )
 
 type SLVMDisk struct {
@@ -53,6 +55,21 @@ func (d *SLVMDisk) GetSysDiskExtendPath() string {
 	return path.Join("/dev", d.Storage.GetPath(), d.GetExtendDiskId())
 }
 
+// thin pool of lvm storage, empty if lvs are fully allocated
+func (d *SLVMDisk) getThinPool() string {
+	if storage, ok := d.Storage.(*SLVMStorage); ok {
+		return storage.ThinPool
+	}
+	return ""
+}
+
+func (d *SLVMDisk) lvCreate(lv string, size int64) error {
+	if thinPool := d.getThinPool(); len(thinPool) > 0 {
+		return lvmutils.LvCreateThin(d.Storage.GetPath(), thinPool, lv, size)
+	}
+	return lvmutils.LvCreate(d.Storage.GetPath(), lv, size)
+}
+
 func (d *SLVMDisk) GetPath() string {
 	var diskPath = d.GetLvPath()
 	if fileutils2.Exists(d.GetDevMapperPath()) {
@@ -111,7 +128,7 @@ func (d *SLVMDisk) CreateRaw(
 			return nil, errors.Wrap(err, "failed remove exists lvm")
 		}
 	}
-	if err := lvmutils.LvCreate(d.Storage.GetPath(), d.Id, int64(sizeMb)*1024*1024); err != nil {
+	if err := d.lvCreate(d.Id, int64(sizeMb)*1024*1024); err != nil {
 		return nil, errors.Wrap(err, "CreateRaw")
 	}
 
@@ -180,6 +197,14 @@ func (d *SLVMDisk) resize(newSize int64) error {
 	}
 
 	resizePath := d.GetLvPath()
+	if len(d.getThinPool()) > 0 {
+		// thin snapshot is an independent thin lv, could be resized directly
+		err = lvmutils.LvResize(d.Storage.GetPath(), resizePath, newSize)
+		if err != nil {
+			return errors.Wrap(err, "lv resize")
+		}
+		return nil
+	}
 	origin, err := lvmutils.GetLvOrigin(resizePath)
 	if err != nil {
 		return errors.Wrap(err, "get lv origin")
@@ -311,13 +336,136 @@ func (d *SLVMDisk) createFromTemplate(
 		return nil, errors.Wrapf(err, "NewQemuImage(%s)", cacheImagePath)
 	}
 
-	if err = lvmutils.LvCreateFromSnapshot(d.GetLvPath(), cacheImagePath, cacheImage.SizeBytes); err != nil {
-		return nil, errors.Wrap(err, "lv create from snapshot")
+	if err := d.cloneImageCache(cacheImagePath, cacheImage.SizeBytes); err != nil {
+		return nil, err
 	}
-
 	return d.GetDiskDesc(), nil
 }
 
+// cloneImageCache creates disk lv from image cache lv, thin snapshot is only
+// possible if image cache is thin lv of the same pool, otherwise image is copied
+func (d *SLVMDisk) cloneImageCache(cacheImagePath string, size int64) error {
+	thinPool := d.getThinPool()
+	if len(thinPool) == 0 {
+		if err := lvmutils.LvCreateFromSnapshot(d.GetLvPath(), cacheImagePath, size); err != nil {
+			return errors.Wrap(err, "lv create from snapshot")
+		}
+		return nil
+	}
+	originPool, err := lvmutils.GetLvThinPool(cacheImagePath)
+	if err != nil {
+		return errors.Wrapf(err, "get thin pool of image cache %s", cacheImagePath)
+	}
+	if originPool == thinPool {
+		if err := lvmutils.LvCreateThinSnapshot(cacheImagePath, d.Id, true); err != nil {
+			return errors.Wrap(err, "lv create from thin snapshot")
+		}
+		return nil
+	}
+	log.Infof("image cache %s is not thin lv of pool %s, copy it to disk %s", cacheImagePath, thinPool, d.Id)
+	if err := d.lvCreate(d.Id, size); err != nil {
+		return errors.Wrap(err, "lv create")
+	}
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(),
+		"convert", "-W", "-m", "16", "-n", "-O", "raw", cacheImagePath, d.GetLvPath()).Output()
+	if err != nil {
+		if err := lvmutils.LvRemove(d.GetLvPath()); err != nil {
+			log.Errorf("remove lv %s: %s", d.GetLvPath(), err)
+		}
+		return errors.Wrapf(err, "convert image cache %s to lv %s: %s", cacheImagePath, d.GetLvPath(), out)
+	}
+	return nil
+}
+
+func (d *SLVMDisk) createFromThinSnapshot(snapshotPath string) error {
+	if err := lvmutils.LvCreateThinSnapshot(snapshotPath, d.Id, true); err != nil {
+		return errors.Wrap(err, "lv create from thin snapshot")
+	}
+	return nil
+}
+
+func (d *SLVMDisk) getSnapshotPath(snapshotId string) string {
+	return path.Join("/dev", d.Storage.GetPath(), getLvmSnapshotName(d.Id, snapshotId))
+}
+
+func (d *SLVMDisk) CreateSnapshot(snapshotId string, encryptKey string, encFormat qemuimg.TEncryptFormat, encAlg seclib2.TSymEncAlg) error {
+	if len(d.getThinPool()) == 0 {
+		return errors.Errorf("lvm storage without thin pool unsupported snapshot")
+	}
+	snapshotName := getLvmSnapshotName(d.Id, snapshotId)
+	if err := lvmutils.LvCreateThinSnapshot(d.GetLvPath(), snapshotName, false); err != nil {
+		return errors.Wrapf(err, "create snapshot %s", snapshotName)
+	}
+	return nil
+}
+
+func (d *SLVMDisk) DeleteSnapshot(snapshotId, convertSnapshot string, pendingDelete bool) error {
+	if len(d.getThinPool()) == 0 {
+		return errors.Errorf("lvm storage without thin pool unsupported snapshot")
+	}
+	snapshotPath := d.getSnapshotPath(snapshotId)
+	if !lvmutils.LvExists(snapshotPath) {
+		return nil
+	}
+	return lvmutils.LvRemove(snapshotPath)
+}
+
+func (d *SLVMDisk) DeleteAllSnapshot(skipRecycle bool) error {
+	storage, ok := d.Storage.(*SLVMStorage)
+	if !ok || !storage.IsThin() {
+		return nil
+	}
+	return storage.deleteDiskSnapshots(d.Id)
+}
+
+func (d *SLVMDisk) DiskSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	snapshotId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	return nil, d.CreateSnapshot(snapshotId, "", "", "")
+}
+
+func (d *SLVMDisk) DiskDeleteSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	snapshotId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	if err := d.DeleteSnapshot(snapshotId, "", false); err != nil {
+		return nil, err
+	}
+	res := jsonutils.NewDict()
+	res.Set("deleted", jsonutils.JSONTrue)
+	return res, nil
+}
+
+// ResetFromSnapshot replaces disk lv with a thin snapshot of the snapshot lv,
+// snapshot itself is kept so that disk could be reset to it again
+func (d *SLVMDisk) ResetFromSnapshot(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	resetParams, ok := params.(*SDiskReset)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	if len(d.getThinPool()) == 0 {
+		return nil, errors.Errorf("lvm storage without thin pool unsupported snapshot")
+	}
+	diskId := resetParams.BackingDiskId
+	if len(diskId) == 0 {
+		diskId = d.GetId()
+	}
+	snapshotPath := path.Join("/dev", d.Storage.GetPath(), getLvmSnapshotName(diskId, resetParams.SnapshotId))
+	if !lvmutils.LvExists(snapshotPath) {
+		return nil, errors.Wrapf(errors.ErrNotFound, "snapshot %s", snapshotPath)
+	}
+	if err := d.CleanUpDisk(); err != nil {
+		return nil, errors.Wrap(err, "clean up disk")
+	}
+	if err := d.createFromThinSnapshot(snapshotPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 func NewLVMDisk(storage IStorage, id string) *SLVMDisk {
 	return &SLVMDisk{
 		SBaseDisk: *NewBaseDisk(storage, id),
@@ -0,0 +1,100 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils/lvmtest"
+)
+
+func TestSLVMDiskCloneImageCache(t *testing.T) {
+	if _, err := exec.LookPath("qemu-img"); err != nil {
+		t.Skip("qemu-img not found")
+	}
+	vg := lvmtest.SetupLoopVg(t, 512, false)
+	if out, err := exec.Command("lvm", "lvcreate", "--type", "thin-pool", "-L", "128M", "-n", "pool", vg, "-y").CombinedOutput(); err != nil {
+		t.Skipf("thin pool unavailable: %s %s", err, out)
+	}
+	const size = 8 * 1024 * 1024
+	image := bytes.Repeat([]byte("image-cache"), size/len("image-cache"))
+	writeImage := func(lvPath string) {
+		if err := os.WriteFile(lvPath, image, 0644); err != nil {
+			t.Fatalf("write image to %s: %s", lvPath, err)
+		}
+	}
+	if err := lvmutils.LvCreate(vg, "thick_cache", size); err != nil {
+		t.Fatalf("LvCreate: %s", err)
+	}
+	writeImage(path.Join("/dev", vg, "thick_cache"))
+	if err := lvmutils.LvCreateThin(vg, "pool", "thin_cache", size); err != nil {
+		t.Fatalf("LvCreateThin: %s", err)
+	}
+	writeImage(path.Join("/dev", vg, "thin_cache"))
+
+	storage := NewLVMStorage(nil, vg, 0)
+	storage.ThinPool = "pool"
+	cases := []struct {
+		name       string
+		cache      string
+		wantOrigin string
+	}{
+		{
+			name:       "thin image cache is snapshotted",
+			cache:      "thin_cache",
+			wantOrigin: "thin_cache",
+		},
+		{
+			name:  "thick image cache is copied",
+			cache: "thick_cache",
+		},
+	}
+	for i, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			disk := NewLVMDisk(storage, fmt.Sprintf("disk%d", i))
+			if err := disk.cloneImageCache(path.Join("/dev", vg, c.cache), size); err != nil {
+				t.Fatalf("cloneImageCache: %s", err)
+			}
+			defer lvmutils.LvRemove(disk.GetLvPath())
+			pool, err := lvmutils.GetLvThinPool(disk.GetLvPath())
+			if err != nil {
+				t.Fatalf("GetLvThinPool: %s", err)
+			}
+			if pool != "pool" {
+				t.Errorf("disk should be thin lv of pool, got pool %q", pool)
+			}
+			origin, err := lvmutils.GetLvOrigin(disk.GetLvPath())
+			if err != nil {
+				t.Fatalf("GetLvOrigin: %s", err)
+			}
+			if origin != c.wantOrigin {
+				t.Errorf("want origin %q, got %q", c.wantOrigin, origin)
+			}
+			data, err := os.ReadFile(disk.GetLvPath())
+			if err != nil {
+				t.Fatalf("read disk: %s", err)
+			}
+			if !bytes.Equal(data[:len(image)], image) {
+				t.Errorf("disk content mismatch with image cache")
+			}
+		})
+	}
+}
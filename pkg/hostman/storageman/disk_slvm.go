@@ -0,0 +1,178 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+
+	"yunion.io/x/cloudmux/pkg/cloudprovider"
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
+	"yunion.io/x/onecloud/pkg/util/qemutils"
+)
+
+// SSLVMDisk is lv of shared vg, it is activated with exclusive lock so that
+// only one host could open it, the lock is converted to shared only while
+// live migrating, when both source and target host have to open it
+type SSLVMDisk struct {
+	SLVMDisk
+}
+
+func NewSLVMDisk(storage IStorage, id string) *SSLVMDisk {
+	return &SSLVMDisk{
+		SLVMDisk: *NewLVMDisk(storage, id),
+	}
+}
+
+// GetType tells guest migration lv is shared, rather than copied to
+// target host and removed from source like lv of local vg
+func (d *SSLVMDisk) GetType() string {
+	return api.STORAGE_SLVM
+}
+
+// Activate activates lv with given lock mode, lvmlockd converts lock of
+// activated lv in place, so that it works for lv opened by running guest
+func (d *SSLVMDisk) Activate(exclusive bool) error {
+	if err := lvmutils.LvActivate(d.GetLvPath(), exclusive); err != nil {
+		return errors.Wrapf(err, "activate lv exclusive %v", exclusive)
+	}
+	return nil
+}
+
+func (d *SSLVMDisk) Probe() error {
+	active, err := lvmutils.GetLvActive(d.GetLvPath())
+	if err != nil {
+		return errors.Wrapf(cloudprovider.ErrNotFound, "%s: %s", d.GetLvPath(), err)
+	}
+	if !active {
+		if err := lvmutils.LvActivate(d.GetLvPath(), true); err != nil {
+			return errors.Wrap(err, "activate lv")
+		}
+	}
+	return nil
+}
+
+func (d *SSLVMDisk) CreateRaw(
+	ctx context.Context, sizeMb int, diskFormat string, fsFormat string,
+	encryptInfo *apis.SEncryptInfo, diskId string, back string,
+) (jsonutils.JSONObject, error) {
+	if err := d.removeExists(); err != nil {
+		return nil, err
+	}
+	return d.SLVMDisk.CreateRaw(ctx, sizeMb, diskFormat, fsFormat, encryptInfo, diskId, back)
+}
+
+// Deactivate releases lock of exists lv, lvremove on any host requires
+// exclusive lock, e.g. source host of live migrated guest should release it
+func (d *SSLVMDisk) Deactivate() error {
+	if active, err := lvmutils.GetLvActive(d.GetLvPath()); err == nil && active {
+		if err := lvmutils.LvDeactivate(d.GetLvPath()); err != nil {
+			return errors.Wrap(err, "deactivate lv")
+		}
+	}
+	return nil
+}
+
+// removeExists removes lv left by previous failed creation, device node of
+// it may be absent on this host, so query lvm rather than checking path
+func (d *SSLVMDisk) removeExists() error {
+	if _, err := lvmutils.GetLvActive(d.GetLvPath()); err != nil {
+		return nil
+	}
+	if err := d.Deactivate(); err != nil {
+		return err
+	}
+	if err := lvmutils.LvRemove(d.GetLvPath()); err != nil {
+		return errors.Wrap(err, "failed remove exists lvm")
+	}
+	return nil
+}
+
+func (d *SSLVMDisk) Delete(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	if err := d.Deactivate(); err != nil {
+		return nil, err
+	}
+	if err := lvmutils.LvRemove(d.GetLvPath()); err != nil {
+		return nil, errors.Wrap(err, "Delete")
+	}
+	d.Storage.RemoveDisk(d)
+	return nil, nil
+}
+
+func (d *SSLVMDisk) OnRebuildRoot(ctx context.Context, params api.DiskAllocateInput) error {
+	_, err := d.Delete(ctx, api.DiskDeleteInput{})
+	return err
+}
+
+func (d *SSLVMDisk) CreateFromTemplate(
+	ctx context.Context, imageId, format string, size int64, encryptInfo *apis.SEncryptInfo,
+) (jsonutils.JSONObject, error) {
+	if err := d.removeExists(); err != nil {
+		return nil, err
+	}
+
+	var imageCacheManager = storageManager.GetStoragecacheById(d.Storage.GetStoragecacheId())
+	ret, err := d.createFromTemplate(ctx, imageId, imageCacheManager)
+	if err != nil {
+		return nil, err
+	}
+	retSize, _ := ret.Int("disk_size")
+	log.Infof("REQSIZE: %d, RETSIZE: %d", size, retSize)
+	if size > retSize {
+		params := jsonutils.NewDict()
+		params.Set("size", jsonutils.NewInt(size))
+		if encryptInfo != nil {
+			params.Set("encrypt_info", jsonutils.Marshal(encryptInfo))
+		}
+		return d.Resize(ctx, params)
+	}
+	return ret, nil
+}
+
+// lvm cow snapshot requires origin lv activated exclusively,
+// so image cache lv of shared vg is fully copied instead
+func (d *SSLVMDisk) createFromTemplate(
+	ctx context.Context, imageId string, imageCacheManager IImageCacheManger,
+) (jsonutils.JSONObject, error) {
+	input := api.CacheImageInput{ImageId: imageId, Zone: d.GetZoneId()}
+	imageCache, err := imageCacheManager.AcquireImage(ctx, input, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "AcquireImage")
+	}
+
+	defer imageCacheManager.ReleaseImage(ctx, imageId)
+	cacheImagePath := imageCache.GetPath()
+	cacheImage, err := qemuimg.NewQemuImage(cacheImagePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "NewQemuImage(%s)", cacheImagePath)
+	}
+
+	if err = lvmutils.LvCreate(d.Storage.GetPath(), d.Id, cacheImage.SizeBytes); err != nil {
+		return nil, errors.Wrap(err, "lv create")
+	}
+	err = procutils.NewRemoteCommandAsFarAsPossible(qemutils.GetQemuImg(),
+		"convert", "-W", "-m", "16", "-n", "-O", "raw", cacheImagePath, d.GetLvPath()).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "convert image cache %s to lv %s", cacheImagePath, d.GetLvPath())
+	}
+	return d.GetDiskDesc(), nil
+}
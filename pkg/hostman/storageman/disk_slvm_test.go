@@ -0,0 +1,84 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"testing"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils/lvmtest"
+)
+
+func TestSLVMDiskActivation(t *testing.T) {
+	vg := lvmtest.SetupLoopVg(t, 512, false)
+	storage := NewSLVMStorage(nil, vg)
+	disk := NewSLVMDisk(storage, "disk0")
+	if disk.GetType() != api.STORAGE_SLVM {
+		t.Fatalf("lv of shared vg should not be migrated as local disk, got type %s", disk.GetType())
+	}
+	if err := disk.removeExists(); err != nil {
+		t.Fatalf("removeExists without lv: %s", err)
+	}
+	if err := disk.Probe(); err == nil {
+		t.Fatalf("Probe should fail without lv")
+	}
+
+	if err := lvmutils.LvCreate(vg, "disk0", 8*1024*1024); err != nil {
+		t.Fatalf("LvCreate: %s", err)
+	}
+	isActive := func() bool {
+		active, err := lvmutils.GetLvActive(disk.GetLvPath())
+		if err != nil {
+			t.Fatalf("GetLvActive: %s", err)
+		}
+		return active
+	}
+	// source host of migrated guest releases lv
+	if err := disk.Deactivate(); err != nil {
+		t.Fatalf("Deactivate: %s", err)
+	}
+	if isActive() {
+		t.Errorf("lv should be inactive after Deactivate")
+	}
+	if err := disk.Deactivate(); err != nil {
+		t.Errorf("Deactivate inactive lv: %s", err)
+	}
+	// target host activates lv on probing
+	if err := disk.Probe(); err != nil {
+		t.Fatalf("Probe: %s", err)
+	}
+	if !isActive() {
+		t.Errorf("lv should be activated by Probe")
+	}
+	// lock of lv is converted for the window of live migration
+	if err := disk.Activate(false); err != nil {
+		t.Fatalf("Activate shared: %s", err)
+	}
+	if err := disk.Activate(true); err != nil {
+		t.Fatalf("Activate exclusive: %s", err)
+	}
+	if !isActive() {
+		t.Errorf("lv should stay active after converting lock")
+	}
+
+	if _, err := disk.Delete(context.Background(), api.DiskDeleteInput{}); err != nil {
+		t.Fatalf("Delete active lv: %s", err)
+	}
+	if lvmutils.LvExists(disk.GetLvPath()) {
+		t.Errorf("lv should be removed")
+	}
+}
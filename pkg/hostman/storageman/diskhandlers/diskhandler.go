@@ -297,7 +297,14 @@ func diskResize(ctx context.Context, userCred mcclient.TokenCredential, storage
 	serverId, _ := diskInfo.GetString("server_id")
 	if len(serverId) > 0 && guestman.GetGuestManager().Status(serverId) == "running" {
 		sizeMb, _ := diskInfo.Int("size")
-		return guestman.GetGuestManager().OnlineResizeDisk(ctx, serverId, diskId, sizeMb)
+		var ioThrottle *compute.DiskIoThrottle
+		if diskInfo.Contains("io_throttle") {
+			ioThrottle = new(compute.DiskIoThrottle)
+			if err := diskInfo.Unmarshal(ioThrottle, "io_throttle"); err != nil {
+				return nil, httperrors.NewInputParameterError("unmarshal io_throttle: %v", err)
+			}
+		}
+		return guestman.GetGuestManager().OnlineResizeDisk(ctx, serverId, diskId, sizeMb, ioThrottle)
 	} else {
 		hostutils.DelayTask(ctx, disk.Resize, diskInfo)
 		return nil, nil
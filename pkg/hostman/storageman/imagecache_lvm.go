@@ -24,11 +24,14 @@ type SLVMImageCache struct {
 	imageId string
 	cond    *sync.Cond
 	Manager IImageCacheManger
+
+	lvmlockd bool
 }
 
-func NewLVMImageCache(imageId string, imagecacheManager IImageCacheManger) *SLVMImageCache {
+func NewLVMImageCache(imageId string, imagecacheManager IImageCacheManger, lvmlockd bool) *SLVMImageCache {
 	imageCache := new(SLVMImageCache)
 	imageCache.imageId = imageId
+	imageCache.lvmlockd = lvmlockd
 	imageCache.Manager = imagecacheManager
 	imageCache.cond = sync.NewCond(new(sync.Mutex))
 	return imageCache
@@ -59,6 +62,12 @@ func (c *SLVMImageCache) GetDesc() *remotefile.SImageDesc {
 
 func (c *SLVMImageCache) Load() error {
 	log.Debugf("loading lvm imagecache %s", c.GetPath())
+	if c.lvmlockd {
+		// fails while other host holding exclusive lock is still converting image
+		if err := lvmutils.LvActivate(c.GetPath(), false); err != nil {
+			return errors.Wrap(err, "activate image cache lv")
+		}
+	}
 	origin, err := qemuimg.NewQemuImage(c.GetPath())
 	if err != nil {
 		return errors.Wrap(err, "NewQemuImage")
@@ -87,7 +96,11 @@ func (c *SLVMImageCache) Acquire(
 		if err != nil {
 			return errors.Wrapf(err, "NewQemuImage for local image path %s", localImageCache.GetPath())
 		}
-		err = lvmutils.LvCreate(c.Manager.GetPath(), c.GetName(), localImg.SizeBytes)
+		if manager, ok := c.Manager.(*SLVMImageCacheManager); ok {
+			err = manager.lvCreate(c.GetName(), localImg.SizeBytes)
+		} else {
+			err = lvmutils.LvCreate(c.Manager.GetPath(), c.GetName(), localImg.SizeBytes)
+		}
 		if err != nil {
 			return errors.Wrap(err, "lvm image cache acquire")
 		}
@@ -98,6 +111,12 @@ func (c *SLVMImageCache) Acquire(
 		if err != nil {
 			return errors.Wrapf(err, "convert local image %s to lvm %s", c.imageId, c.GetPath())
 		}
+		if c.lvmlockd {
+			// lv is created with exclusive lock, release it so that other hosts could share image
+			if err := lvmutils.LvDeactivate(c.GetPath()); err != nil {
+				return errors.Wrap(err, "deactivate image cache lv")
+			}
+		}
 		if len(input.ServerId) > 0 {
 			modules.Servers.Update(hostutils.GetComputeSession(context.Background()), input.ServerId, jsonutils.Marshal(map[string]float32{"progress": 100.0}))
 		}
@@ -114,6 +133,11 @@ func (r *SLVMImageCache) Release() {
 }
 
 func (c *SLVMImageCache) Remove(ctx context.Context) error {
+	if c.lvmlockd {
+		if err := lvmutils.LvDeactivate(c.GetPath()); err != nil {
+			return errors.Wrap(err, "lvmImageCache Deactivate")
+		}
+	}
 	if err := lvmutils.LvRemove(c.GetPath()); err != nil {
 		return errors.Wrap(err, "lvmImageCache Remove")
 	}
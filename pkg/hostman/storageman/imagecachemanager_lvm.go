@@ -22,10 +22,15 @@ type SLVMImageCacheManager struct {
 	SBaseImageCacheManager
 
 	lock lockman.ILockManager
+	// vg shared by hosts, image cache lv is activated with lvmlockd shared lock
+	lvmlockd bool
+	// image cache lv is created in thin pool, so disks could be thin snapshots of it
+	thinPool string
 }
 
-func NewLVMImageCacheManager(manager IStorageManager, cachePath string, storagecacheId string) *SLVMImageCacheManager {
+func NewLVMImageCacheManager(manager IStorageManager, cachePath string, storagecacheId string, lvmlockd bool) *SLVMImageCacheManager {
 	imageCacheManager := new(SLVMImageCacheManager)
+	imageCacheManager.lvmlockd = lvmlockd
 	imageCacheManager.lock = lockman.NewInMemoryLockManager()
 	imageCacheManager.storageManager = manager
 	imageCacheManager.storagecacaheId = storagecacheId
@@ -35,6 +40,13 @@ func NewLVMImageCacheManager(manager IStorageManager, cachePath string, storagec
 	return imageCacheManager
 }
 
+func (c *SLVMImageCacheManager) lvCreate(lv string, size int64) error {
+	if len(c.thinPool) > 0 {
+		return lvmutils.LvCreateThin(c.cachePath, c.thinPool, lv, size)
+	}
+	return lvmutils.LvCreate(c.cachePath, lv, size)
+}
+
 func (c *SLVMImageCacheManager) loadLvNames() []string {
 	lvNames, err := lvmutils.GetLvNames(c.cachePath)
 	if err != nil {
@@ -75,7 +87,7 @@ func (c *SLVMImageCacheManager) AcquireImage(
 
 	img, ok := c.cachedImages[input.ImageId]
 	if !ok {
-		img = NewLVMImageCache(input.ImageId, c)
+		img = NewLVMImageCache(input.ImageId, c, c.lvmlockd)
 		c.cachedImages[input.ImageId] = img
 	}
 	if callback == nil && len(input.ServerId) > 0 {
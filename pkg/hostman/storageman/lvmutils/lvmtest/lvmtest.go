@@ -0,0 +1,153 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lvmtest provides vg on loop device for tests of lvm backed storages,
+// tests using it are skipped unless running as root with lvm installed
+package lvmtest // import "yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils/lvmtest"
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// peerHostId is sanlock host id of the simulated peer host, the largest one
+// sanlock allows so that it won't collide with host_id of this host
+const peerHostId = 2000
+
+func run(t testing.TB, name string, args ...string) string {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s %s: %s %s", name, strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func lookPath(t testing.TB, bins ...string) {
+	for _, bin := range bins {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s not found", bin)
+		}
+	}
+}
+
+// SetupLoopVg creates vg on a loop device backed by sparse file of sizeMb,
+// shared vg is created with lvmlockd sanlock and its lockspace is started
+func SetupLoopVg(t testing.TB, sizeMb int64, shared bool) string {
+	if os.Geteuid() != 0 {
+		t.Skip("loop device requires root")
+	}
+	lookPath(t, "lvm", "losetup")
+	if shared {
+		lookPath(t, "lvmlockctl", "sanlock")
+		if out, err := exec.Command("lvmlockctl", "--info").CombinedOutput(); err != nil {
+			t.Skipf("lvmlockd is not running: %s %s", err, out)
+		}
+	}
+	backing := filepath.Join(t.TempDir(), "pv.img")
+	if err := os.WriteFile(backing, nil, 0644); err != nil {
+		t.Fatalf("create backing file: %s", err)
+	}
+	if err := os.Truncate(backing, sizeMb*1024*1024); err != nil {
+		t.Fatalf("truncate backing file: %s", err)
+	}
+	out, err := exec.Command("losetup", "-f", "--show", backing).CombinedOutput()
+	if err != nil {
+		t.Skipf("loop device unavailable: %s %s", err, out)
+	}
+	loop := strings.TrimSpace(string(out))
+	vg := fmt.Sprintf("test_vg_%d", os.Getpid())
+	t.Cleanup(func() {
+		if shared {
+			exec.Command("lvm", "vgchange", "-an", vg).Run()
+		}
+		exec.Command("lvm", "vgremove", "-f", vg).Run()
+		if shared {
+			exec.Command("lvm", "vgchange", "--lock-stop", vg).Run()
+		}
+		exec.Command("lvm", "pvremove", "-f", loop).Run()
+		exec.Command("losetup", "-d", loop).Run()
+	})
+	run(t, "lvm", "pvcreate", "-y", loop)
+	if shared {
+		run(t, "lvm", "vgcreate", "-y", "--shared", "--locktype", "sanlock", vg, loop)
+		run(t, "lvm", "vgchange", "--lock-start", vg)
+	} else {
+		run(t, "lvm", "vgcreate", "-y", vg, loop)
+	}
+	return vg
+}
+
+// PeerHost runs lvm commands as another host of shared vg, it is simulated by
+// a pair of sanlock and lvmlockd daemons running in their own mount namespace,
+// so that lv locks held by it conflict with ones held by this host on disk
+type PeerHost struct {
+	runDir string
+}
+
+func (p *PeerHost) command(args ...string) *exec.Cmd {
+	script := fmt.Sprintf(
+		"mount --bind %s /run/lvm && mount --bind %s /run/sanlock && exec \"$@\"",
+		filepath.Join(p.runDir, "lvm"), filepath.Join(p.runDir, "sanlock"),
+	)
+	return exec.Command("unshare", append([]string{"-m", "sh", "-c", script, "peer"}, args...)...)
+}
+
+// Lvm runs lvm command on peer host, device mapper of kernel is shared with
+// this host, so peer only takes or releases locks without touching it
+func (p *PeerHost) Lvm(args ...string) error {
+	args = append([]string{args[0], "--driverloaded", "n"}, args[1:]...)
+	out, err := p.command(append([]string{"lvm"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("peer lvm %s: %s %s", strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// StartPeerHost starts lockspace of shared vg on simulated peer host
+func StartPeerHost(t testing.TB, vg string) *PeerHost {
+	lookPath(t, "unshare", "lvmlockd")
+	p := &PeerHost{runDir: t.TempDir()}
+	for _, dir := range []string{"lvm", "sanlock"} {
+		if err := os.MkdirAll(filepath.Join(p.runDir, dir), 0755); err != nil {
+			t.Fatalf("mkdir %s: %s", dir, err)
+		}
+	}
+	daemons := []*exec.Cmd{
+		p.command("sanlock", "daemon", "-D", "-w", "0"),
+		p.command("lvmlockd", "-f", "-g", "sanlock", "-p", filepath.Join(p.runDir, "lvmlockd.pid")),
+	}
+	for _, daemon := range daemons {
+		if err := daemon.Start(); err != nil {
+			t.Skipf("start peer %s: %s", daemon.Args, err)
+		}
+		cmd := daemon
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+	}
+	t.Cleanup(func() {
+		p.Lvm("vgchange", "-an", vg)
+		p.Lvm("vgchange", "--lock-stop", vg)
+	})
+	config := fmt.Sprintf("local/host_id=%d", peerHostId)
+	if err := p.Lvm("vgchange", "--config", config, "--lock-start", vg); err != nil {
+		t.Skipf("peer host lock start: %s", err)
+	}
+	return p
+}
@@ -198,3 +198,191 @@ $size1 $size2 linear $2 0" | dmsetup create $3
 	}
 	return nil
 }
+
+type LvActive struct {
+	Report []struct {
+		LV []struct {
+			LvActiveLocally string `json:"lv_active_locally"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+// GetLvActive returns whether lv is activated on this host,
+// device node of lv exists only if it is activated
+func GetLvActive(lvPath string) (bool, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(
+		"lvm", "lvs", "--reportformat", "json", "-o", "lv_active_locally", lvPath,
+	).Output()
+	if err != nil {
+		return false, errors.Wrapf(err, "lvm lvs %s", out)
+	}
+	var res LvActive
+	err = json.Unmarshal(out, &res)
+	if err != nil {
+		return false, errors.Wrap(err, "unmarshal lvs")
+	}
+	if len(res.Report) == 1 && len(res.Report[0].LV) == 1 {
+		return strings.Contains(res.Report[0].LV[0].LvActiveLocally, "active"), nil
+	}
+	return false, errors.Errorf("unexpect res %v", res)
+}
+
+// LvActivate activates lv of lvmlockd managed vg, shared lock allows
+// other hosts activating lv concurrently while exclusive lock doesn't
+func LvActivate(lvPath string, exclusive bool) error {
+	mode := "-asy"
+	if exclusive {
+		mode = "-aey"
+	}
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("lvm", "lvchange", mode, lvPath).Output()
+	if err != nil {
+		return errors.Wrapf(err, "LvActivate %s failed %s", mode, out)
+	}
+	return nil
+}
+
+// LvDeactivate deactivates lv and releases its lock
+func LvDeactivate(lvPath string) error {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("lvm", "lvchange", "-an", lvPath).Output()
+	if err != nil {
+		return errors.Wrapf(err, "LvDeactivate failed %s", out)
+	}
+	return nil
+}
+
+// VgLockStart joins lockspace of shared vg, lvs could not be activated before it
+func VgLockStart(vg string) error {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("lvm", "vgchange", "--lock-start", vg).Output()
+	if err != nil {
+		return errors.Wrapf(err, "VgLockStart failed %s", out)
+	}
+	return nil
+}
+
+func VgLockStop(vg string) error {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("lvm", "vgchange", "--lock-stop", vg).Output()
+	if err != nil {
+		return errors.Wrapf(err, "VgLockStop failed %s", out)
+	}
+	return nil
+}
+
+// LvCreateThin creates thin lv in thin pool, the virtual size may exceed free space of pool
+func LvCreateThin(vg, thinPool, lv string, size int64) error {
+	size, err := ExtendLvSize(vg, size)
+	if err != nil {
+		return err
+	}
+
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(
+		"lvm", "lvcreate", "--virtualsize", fmt.Sprintf("%dB", size),
+		"--thinpool", fmt.Sprintf("%s/%s", vg, thinPool), "-n", lv, "-y",
+	).Output()
+	if err != nil {
+		return errors.Wrapf(err, "LvCreateThin failed %s", out)
+	}
+	return nil
+}
+
+// LvCreateThinSnapshot creates thin snapshot of thin lv originPath, which shares blocks
+// with origin. Thin snapshots are skipped on activation by default,
+// set activate true if the snapshot is going to be opened as a disk.
+// @param: originPath string: should like /dev/<vg>/<lv>
+func LvCreateThinSnapshot(originPath, lv string, activate bool) error {
+	args := []string{"lvcreate", "-s", originPath, "-n", lv, "-y"}
+	if activate {
+		args = append(args, "--setactivationskip", "n")
+	}
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("lvm", args...).Output()
+	if err != nil {
+		return errors.Wrapf(err, "LvCreateThinSnapshot %s failed %s", originPath, out)
+	}
+	return nil
+}
+
+type LvThinPoolReports struct {
+	Report []struct {
+		LV []struct {
+			PoolLv string `json:"pool_lv"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+// GetLvThinPool returns thin pool of the lv, empty if lv is fully allocated
+// @param: lvPath string: should like /dev/<vg>/<lv>
+func GetLvThinPool(lvPath string) (string, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(
+		"lvm", "lvs", "--reportformat", "json", "-o", "pool_lv", lvPath,
+	).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "exec lvm command: %s", out)
+	}
+	return parseLvThinPool(out)
+}
+
+func parseLvThinPool(out []byte) (string, error) {
+	var reports LvThinPoolReports
+	err := json.Unmarshal(out, &reports)
+	if err != nil {
+		return "", errors.Wrapf(err, "unmarshal lv thin pool %s", out)
+	}
+	if len(reports.Report) != 1 || len(reports.Report[0].LV) != 1 {
+		return "", errors.Errorf("invalid lv report %v", reports)
+	}
+	// hidden lv is reported in brackets
+	return strings.Trim(reports.Report[0].LV[0].PoolLv, "[]"), nil
+}
+
+// @param: lvPath string: should like /dev/<vg>/<lv>
+func LvExists(lvPath string) bool {
+	return procutils.NewRemoteCommandAsFarAsPossible("lvm", "lvs", lvPath).Run() == nil
+}
+
+type ThinPoolProps struct {
+	LvSize int64
+	// bytes of data allocated in pool
+	DataUsed int64
+}
+
+type ThinPoolReports struct {
+	Report []struct {
+		LV []struct {
+			LvSize      string `json:"lv_size"`
+			DataPercent string `json:"data_percent"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+func GetThinPoolProps(vg, thinPool string) (*ThinPoolProps, error) {
+	out, err := procutils.NewRemoteCommandAsFarAsPossible(
+		"lvm", "lvs", "--reportformat", "json", "-o", "lv_size,data_percent", "--units=B",
+		fmt.Sprintf("%s/%s", vg, thinPool),
+	).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "exec lvm command: %s", out)
+	}
+	return parseThinPoolProps(out)
+}
+
+func parseThinPoolProps(out []byte) (*ThinPoolProps, error) {
+	var reports ThinPoolReports
+	err := json.Unmarshal(out, &reports)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal thin pool props %s", out)
+	}
+	if len(reports.Report) != 1 || len(reports.Report[0].LV) != 1 {
+		return nil, errors.Errorf("invalid thin pool report %v", reports)
+	}
+	lv := reports.Report[0].LV[0]
+	var props ThinPoolProps
+	props.LvSize, err = strconv.ParseInt(strings.TrimSuffix(lv.LvSize, "B"), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse size %s", lv.LvSize)
+	}
+	percent, err := strconv.ParseFloat(lv.DataPercent, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse data percent %s", lv.DataPercent)
+	}
+	props.DataUsed = int64(float64(props.LvSize) * percent / 100)
+	return &props, nil
+}
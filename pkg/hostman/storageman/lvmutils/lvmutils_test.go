@@ -0,0 +1,194 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lvmutils
+
+import (
+	"path"
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils/lvmtest"
+)
+
+func TestLvActivation(t *testing.T) {
+	vg := lvmtest.SetupLoopVg(t, 256, false)
+	lvPath := path.Join("/dev", vg, "lv0")
+	if err := LvCreate(vg, "lv0", 10*1024*1024); err != nil {
+		t.Fatalf("LvCreate: %s", err)
+	}
+	if !LvExists(lvPath) {
+		t.Fatalf("lv %s should exist", lvPath)
+	}
+	steps := []struct {
+		name   string
+		action func() error
+		active bool
+	}{
+		{name: "created", active: true},
+		{name: "deactivate", action: func() error { return LvDeactivate(lvPath) }, active: false},
+		{name: "activate shared", action: func() error { return LvActivate(lvPath, false) }, active: true},
+		{name: "deactivate again", action: func() error { return LvDeactivate(lvPath) }, active: false},
+		{name: "activate exclusive", action: func() error { return LvActivate(lvPath, true) }, active: true},
+	}
+	for _, step := range steps {
+		if step.action != nil {
+			if err := step.action(); err != nil {
+				t.Fatalf("%s: %s", step.name, err)
+			}
+		}
+		active, err := GetLvActive(lvPath)
+		if err != nil {
+			t.Fatalf("%s: GetLvActive: %s", step.name, err)
+		}
+		if active != step.active {
+			t.Errorf("%s: want active %v, got %v", step.name, step.active, active)
+		}
+	}
+	if err := LvRemove(lvPath); err != nil {
+		t.Fatalf("LvRemove: %s", err)
+	}
+	if LvExists(lvPath) {
+		t.Errorf("lv %s should be removed", lvPath)
+	}
+}
+
+func TestLvActivationLock(t *testing.T) {
+	vg := lvmtest.SetupLoopVg(t, 256, true)
+	lvPath := path.Join("/dev", vg, "lv0")
+	if err := LvCreate(vg, "lv0", 10*1024*1024); err != nil {
+		t.Fatalf("LvCreate: %s", err)
+	}
+	peer := lvmtest.StartPeerHost(t, vg)
+	steps := []struct {
+		name    string
+		action  func() error
+		wantErr bool
+	}{
+		// lvcreate leaves lv activated exclusively
+		{name: "peer activate shared while exclusive", action: func() error { return peer.Lvm("lvchange", "-asy", lvPath) }, wantErr: true},
+		{name: "peer activate exclusive while exclusive", action: func() error { return peer.Lvm("lvchange", "-aey", lvPath) }, wantErr: true},
+		// migration window
+		{name: "convert to shared", action: func() error { return LvActivate(lvPath, false) }},
+		{name: "peer activate shared", action: func() error { return peer.Lvm("lvchange", "-asy", lvPath) }},
+		{name: "convert to exclusive while peer holds", action: func() error { return LvActivate(lvPath, true) }, wantErr: true},
+		{name: "peer activate exclusive while shared", action: func() error { return peer.Lvm("lvchange", "-aey", lvPath) }, wantErr: true},
+		// source releases lv and target converts back
+		{name: "peer deactivate", action: func() error { return peer.Lvm("lvchange", "-an", lvPath) }},
+		{name: "convert to exclusive", action: func() error { return LvActivate(lvPath, true) }},
+		{name: "peer activate shared after convert", action: func() error { return peer.Lvm("lvchange", "-asy", lvPath) }, wantErr: true},
+	}
+	for _, step := range steps {
+		err := step.action()
+		if step.wantErr && err == nil {
+			t.Errorf("%s: should be refused", step.name)
+		} else if !step.wantErr && err != nil {
+			t.Fatalf("%s: %s", step.name, err)
+		}
+	}
+	if err := LvDeactivate(lvPath); err != nil {
+		t.Fatalf("LvDeactivate: %s", err)
+	}
+	if err := LvRemove(lvPath); err != nil {
+		t.Fatalf("LvRemove: %s", err)
+	}
+}
+
+func TestParseThinPoolProps(t *testing.T) {
+	cases := []struct {
+		out      string
+		size     int64
+		dataUsed int64
+		wantErr  bool
+	}{
+		{
+			out:      `{"report":[{"lv":[{"lv_size":"107374182400B", "data_percent":"25.00"}]}]}`,
+			size:     107374182400,
+			dataUsed: 26843545600,
+		},
+		{
+			out:      `{"report":[{"lv":[{"lv_size":"1073741824B", "data_percent":"0.00"}]}]}`,
+			size:     1073741824,
+			dataUsed: 0,
+		},
+		{
+			out:     `{"report":[{"lv":[]}]}`,
+			wantErr: true,
+		},
+		{
+			out:     `{"report":[{"lv":[{"lv_size":"1073741824B", "data_percent":""}]}]}`,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		props, err := parseThinPoolProps([]byte(c.out))
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expect error", c.out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.out, err)
+			continue
+		}
+		if props.LvSize != c.size || props.DataUsed != c.dataUsed {
+			t.Errorf("%s: got %d/%d, want %d/%d", c.out, props.LvSize, props.DataUsed, c.size, c.dataUsed)
+		}
+	}
+}
+
+func TestParseLvThinPool(t *testing.T) {
+	cases := []struct {
+		out     string
+		pool    string
+		wantErr bool
+	}{
+		{
+			out:  `{"report":[{"lv":[{"pool_lv":"thinpool"}]}]}`,
+			pool: "thinpool",
+		},
+		{
+			out:  `{"report":[{"lv":[{"pool_lv":"[thinpool]"}]}]}`,
+			pool: "thinpool",
+		},
+		{
+			out:  `{"report":[{"lv":[{"pool_lv":""}]}]}`,
+			pool: "",
+		},
+		{
+			out:     `{"report":[{"lv":[]}]}`,
+			wantErr: true,
+		},
+		{
+			out:     `lvs failed`,
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		pool, err := parseLvThinPool([]byte(c.out))
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expect error", c.out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.out, err)
+			continue
+		}
+		if pool != c.pool {
+			t.Errorf("%s: got pool %q, want %q", c.out, pool, c.pool)
+		}
+	}
+}
@@ -79,7 +79,13 @@ func registerStorageFactory(factory IStorageFactory) {
 
 func NewStorage(manager *SStorageManager, mountPoint, storageType string) IStorage {
 	for i := range storagesFactories {
-		if storageType == storagesFactories[i].StorageType() || strings.HasPrefix(mountPoint, storagesFactories[i].StorageType()) {
+		if storageType == storagesFactories[i].StorageType() {
+			return storagesFactories[i].NewStorage(manager, mountPoint)
+		}
+	}
+	// mount point of shared lvm storage is vg name, which may start with name of other storage type
+	for i := range storagesFactories {
+		if strings.HasPrefix(mountPoint, storagesFactories[i].StorageType()) {
 			return storagesFactories[i].NewStorage(manager, mountPoint)
 		}
 	}
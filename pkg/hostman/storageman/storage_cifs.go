@@ -0,0 +1,250 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/utils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+	"yunion.io/x/onecloud/pkg/util/fileutils2"
+	"yunion.io/x/onecloud/pkg/util/procutils"
+)
+
+// cifs credentials files are placed in the dir shared with host,
+// mount.cifs may be executed in host namespace
+const CIFS_CREDENTIALS_DIR = "/opt/cloud/workspace/cifs"
+
+func init() {
+	registerStorageFactory(&SCIFSStorageFactory{})
+}
+
+type SCIFSStorageFactory struct {
+}
+
+func (factory *SCIFSStorageFactory) NewStorage(manager *SStorageManager, mountPoint string) IStorage {
+	return NewCIFSStorage(manager, mountPoint)
+}
+
+func (factory *SCIFSStorageFactory) StorageType() string {
+	return api.STORAGE_CIFS
+}
+
+type SCIFSStorage struct {
+	SNasStorage
+}
+
+func NewCIFSStorage(manager *SStorageManager, path string) *SCIFSStorage {
+	ret := &SCIFSStorage{}
+	ret.SNasStorage = *NewNasStorage(manager, path, ret)
+	if !fileutils2.Exists(path) {
+		procutils.NewCommand("mkdir", "-p", path).Run()
+	}
+	return ret
+}
+
+func (s *SCIFSStorage) newDisk(diskId string) IDisk {
+	return NewCIFSDisk(s, diskId)
+}
+
+func (s *SCIFSStorage) StorageType() string {
+	return api.STORAGE_CIFS
+}
+
+func (s *SCIFSStorage) IsLocal() bool {
+	return false
+}
+
+func (s *SCIFSStorage) SyncStorageInfo() (jsonutils.JSONObject, error) {
+	if len(s.StorageId) == 0 {
+		return nil, fmt.Errorf("Sync cifs storage without storage id")
+	}
+	content := jsonutils.NewDict()
+	content.Set("capacity", jsonutils.NewInt(int64(s.GetAvailSizeMb())))
+	content.Set("storage_type", jsonutils.NewString(s.StorageType()))
+	content.Set("status", jsonutils.NewString(api.STORAGE_ONLINE))
+	content.Set("zone", jsonutils.NewString(s.GetZoneId()))
+	log.Infof("Sync storage info %s", s.StorageId)
+	res, err := modules.Storages.Put(
+		hostutils.GetComputeSession(context.Background()),
+		s.StorageId, content)
+	if err != nil {
+		log.Errorf("SyncStorageInfo Failed: %s: %s", content, err)
+	}
+	return res, err
+}
+
+func (s *SCIFSStorage) SetStorageInfo(storageId, storageName string, conf jsonutils.JSONObject) error {
+	s.StorageId = storageId
+	s.StorageName = storageName
+	if dconf, ok := conf.(*jsonutils.JSONDict); ok {
+		s.StorageConf = dconf
+	}
+	if err := s.checkAndMount(); err != nil {
+		return errors.Errorf("Fail to mount storage to mountpoint: %s, %s", s.Path, err)
+	}
+	return s.BindMountStoragePath(s.Path)
+}
+
+func (s *SCIFSStorage) getCredentialsFile() string {
+	return path.Join(CIFS_CREDENTIALS_DIR, s.StorageId)
+}
+
+// cifsCredentials formats mount.cifs credentials file content
+func cifsCredentials(username, password, domain string) []byte {
+	lines := []string{
+		fmt.Sprintf("username=%s", username),
+		fmt.Sprintf("password=%s", password),
+	}
+	if len(domain) > 0 {
+		lines = append(lines, fmt.Sprintf("domain=%s", domain))
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// getCredentials decrypts password of storage conf, which is encrypted by storage id
+func (s *SCIFSStorage) getCredentials() ([]byte, error) {
+	username, err := s.StorageConf.GetString("cifs_username")
+	if err != nil {
+		return nil, fmt.Errorf("Storage conf missing cifs_username")
+	}
+	password, _ := s.StorageConf.GetString("cifs_password")
+	if len(password) > 0 {
+		password, err = utils.DescryptAESBase64(s.StorageId, password)
+		if err != nil {
+			return nil, errors.Wrap(err, "decrypt cifs password")
+		}
+	}
+	domain, _ := s.StorageConf.GetString("cifs_domain")
+	return cifsCredentials(username, password, domain), nil
+}
+
+// writeCredentialsFile keeps password out of mount command line
+func writeCredentialsFile(credFile string, content []byte) error {
+	if err := os.MkdirAll(path.Dir(credFile), 0700); err != nil {
+		return errors.Wrapf(err, "mkdir %s", path.Dir(credFile))
+	}
+	if err := os.WriteFile(credFile, content, 0600); err != nil {
+		return errors.Wrapf(err, "write credentials file %s", credFile)
+	}
+	return nil
+}
+
+func mountCifs(host, share, mountPoint, credFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := procutils.NewRemoteCommandContextAsFarAsPossible(ctx,
+		"mount", "-t", "cifs", fmt.Sprintf("//%s/%s", host, share), mountPoint,
+		"-o", fmt.Sprintf("credentials=%s,file_mode=0644,dir_mode=0755,cache=none", credFile)).Output()
+	if err != nil {
+		return errors.Wrapf(err, "mount cifs %s", out)
+	}
+	return nil
+}
+
+// ProbeCifsShare mounts the share to a temporary dir to verify it is accessible with the credentials
+func ProbeCifsShare(host, share, username, password, domain string) error {
+	if err := os.MkdirAll(CIFS_CREDENTIALS_DIR, 0700); err != nil {
+		return errors.Wrapf(err, "mkdir %s", CIFS_CREDENTIALS_DIR)
+	}
+	probeDir, err := os.MkdirTemp(CIFS_CREDENTIALS_DIR, "probe-")
+	if err != nil {
+		return errors.Wrap(err, "create probe dir")
+	}
+	defer os.RemoveAll(probeDir)
+	credFile := path.Join(probeDir, "credentials")
+	if err := writeCredentialsFile(credFile, cifsCredentials(username, password, domain)); err != nil {
+		return err
+	}
+	mountPoint := path.Join(probeDir, "mnt")
+	if err := os.Mkdir(mountPoint, 0755); err != nil {
+		return errors.Wrapf(err, "mkdir %s", mountPoint)
+	}
+	if err := mountCifs(host, share, mountPoint, credFile); err != nil {
+		return err
+	}
+	if out, err := procutils.NewRemoteCommandAsFarAsPossible("umount", mountPoint).Output(); err != nil {
+		return errors.Wrapf(err, "umount probe mountpoint %s: %s", mountPoint, out)
+	}
+	return nil
+}
+
+func (s *SCIFSStorage) checkAndMount() error {
+	if err := procutils.NewRemoteCommandAsFarAsPossible("mountpoint", s.Path).Run(); err == nil {
+		return nil
+	}
+	if s.StorageConf == nil {
+		return fmt.Errorf("Storage conf is nil")
+	}
+	host, err := s.StorageConf.GetString("cifs_host")
+	if err != nil {
+		return fmt.Errorf("Storage conf missing cifs_host")
+	}
+	share, err := s.StorageConf.GetString("cifs_share")
+	if err != nil {
+		return fmt.Errorf("Storage conf missing cifs_share")
+	}
+	credentials, err := s.getCredentials()
+	if err != nil {
+		return err
+	}
+	credFile := s.getCredentialsFile()
+	if err := writeCredentialsFile(credFile, credentials); err != nil {
+		return err
+	}
+	return mountCifs(host, share, s.Path, credFile)
+}
+
+// Accessible checks the share is still mounted before probing it is writable,
+// otherwise disks would be written into the local mountpoint dir silently
+func (s *SCIFSStorage) Accessible() error {
+	if err := procutils.NewRemoteCommandAsFarAsPossible("mountpoint", s.Path).Run(); err != nil {
+		return errors.Errorf("cifs share is not mounted at %s", s.Path)
+	}
+	return s.SLocalStorage.Accessible()
+}
+
+func (s *SCIFSStorage) Detach() error {
+	if !strings.HasPrefix(s.Path, "/opt/cloud") {
+		tmpPath := path.Join(TempBindMountPath, s.Path)
+		out, err := procutils.NewCommand("umount", s.Path).Output()
+		if err != nil {
+			return errors.Wrapf(err, "1. umount %s failed %s", s.Path, out)
+		}
+		out, err = procutils.NewRemoteCommandAsFarAsPossible("umount", tmpPath).Output()
+		if err != nil {
+			return errors.Wrapf(err, "2. umount %s failed %s", tmpPath, out)
+		}
+	}
+	out, err := procutils.NewRemoteCommandAsFarAsPossible("umount", s.Path).Output()
+	if err != nil {
+		return errors.Wrapf(err, "3. umount %s failed %s", s.Path, out)
+	}
+	if err := os.Remove(s.getCredentialsFile()); err != nil && !os.IsNotExist(err) {
+		log.Warningf("remove cifs credentials file %s: %s", s.getCredentialsFile(), err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"testing"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/utils"
+)
+
+func TestCIFSStorageGetCredentials(t *testing.T) {
+	storageId := "4b6e2c5a-2f3d-4c1e-8a8b-0d6f1e2a3b4c"
+	encrypted, err := utils.EncryptAESBase64(storageId, "pass=word")
+	if err != nil {
+		t.Fatalf("EncryptAESBase64: %s", err)
+	}
+	cases := []struct {
+		name    string
+		conf    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "encrypted password with domain",
+			conf: map[string]string{
+				"cifs_username": "admin",
+				"cifs_password": encrypted,
+				"cifs_domain":   "WORKGROUP",
+			},
+			want: "username=admin\npassword=pass=word\ndomain=WORKGROUP\n",
+		},
+		{
+			name: "empty password",
+			conf: map[string]string{"cifs_username": "guest"},
+			want: "username=guest\npassword=\n",
+		},
+		{
+			name:    "plaintext password",
+			conf:    map[string]string{"cifs_username": "admin", "cifs_password": "pass=word"},
+			wantErr: true,
+		},
+		{
+			name:    "missing username",
+			conf:    map[string]string{"cifs_password": encrypted},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &SCIFSStorage{}
+			s.StorageId = storageId
+			s.StorageConf = jsonutils.Marshal(c.conf).(*jsonutils.JSONDict)
+			got, err := s.getCredentials()
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expect error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getCredentials: %s", err)
+			}
+			if string(got) != c.want {
+				t.Errorf("want %q, got %q", c.want, got)
+			}
+		})
+	}
+}
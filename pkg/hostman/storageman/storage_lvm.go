@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/qemuimgfmt"
 
 	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
 	deployapi "yunion.io/x/onecloud/pkg/hostman/hostdeployer/apis"
 	"yunion.io/x/onecloud/pkg/hostman/hostdeployer/deployclient"
 	"yunion.io/x/onecloud/pkg/hostman/hostutils"
@@ -19,12 +22,15 @@ import (
 	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
 	"yunion.io/x/onecloud/pkg/mcclient/modules/image"
 	"yunion.io/x/onecloud/pkg/util/procutils"
+	"yunion.io/x/onecloud/pkg/util/qemuimg"
 )
 
 type SLVMStorage struct {
 	SBaseStorage
 
 	Index int
+	// thin pool in vg, disks are thin lvs of it if not empty
+	ThinPool string
 }
 
 func NewLVMStorage(manager *SStorageManager, vgName string, index int) *SLVMStorage {
@@ -50,7 +56,19 @@ func (s *SLVMStorage) GetMediumType() (string, error) {
 	return api.DISK_TYPE_ROTATE, nil
 }
 
+func (s *SLVMStorage) IsThin() bool {
+	return len(s.ThinPool) > 0
+}
+
 func (s *SLVMStorage) GetFreeSizeMb() int {
+	if s.IsThin() {
+		poolProps, err := lvmutils.GetThinPoolProps(s.GetPath(), s.ThinPool)
+		if err != nil {
+			log.Errorf("failed get thin pool props %s", err)
+			return -1
+		}
+		return int((poolProps.LvSize - poolProps.DataUsed) / 1024 / 1024)
+	}
 	vgProps, err := lvmutils.GetVgProps(s.GetPath())
 	if err != nil {
 		log.Errorf("failed get vg_free %s", err)
@@ -67,6 +85,13 @@ func (s *SLVMStorage) GetAvailSizeMb() int {
 }
 
 func (s *SLVMStorage) getAvailSizeMb() (int64, error) {
+	if s.IsThin() {
+		poolProps, err := lvmutils.GetThinPoolProps(s.GetPath(), s.ThinPool)
+		if err != nil {
+			return -1, err
+		}
+		return poolProps.LvSize / 1024 / 1024, nil
+	}
 	vgProps, err := lvmutils.GetVgProps(s.GetPath())
 	if err != nil {
 		return -1, err
@@ -77,6 +102,13 @@ func (s *SLVMStorage) getAvailSizeMb() (int64, error) {
 }
 
 func (s *SLVMStorage) GetUsedSizeMb() (int64, error) {
+	if s.IsThin() {
+		poolProps, err := lvmutils.GetThinPoolProps(s.GetPath(), s.ThinPool)
+		if err != nil {
+			return -1, err
+		}
+		return poolProps.DataUsed / 1024 / 1024, nil
+	}
 	vgProps, err := lvmutils.GetVgProps(s.GetPath())
 	if err != nil {
 		return -1, err
@@ -94,6 +126,14 @@ func (s *SLVMStorage) SyncStorageSize() (api.SHostStorageStat, error) {
 	}
 	stat.CapacityMb = sizeMb
 	stat.ActualCapacityUsedMb = 0
+	if s.IsThin() {
+		// thin lvs are allocated on write, so that storage could be overcommitted
+		usedSizeMb, err := s.GetUsedSizeMb()
+		if err != nil {
+			return stat, err
+		}
+		stat.ActualCapacityUsedMb = usedSizeMb
+	}
 	return stat, nil
 }
 
@@ -113,6 +153,9 @@ func (s *SLVMStorage) SyncStorageInfo() (jsonutils.JSONObject, error) {
 	content.Set("actual_capacity_used", jsonutils.NewInt(usedSizeMb))
 	content.Set("storage_type", jsonutils.NewString(s.StorageType()))
 	content.Set("zone", jsonutils.NewString(s.GetZoneId()))
+	if s.IsThin() {
+		content.Set("lvm_thin_pool", jsonutils.NewString(s.ThinPool))
+	}
 
 	var (
 		res jsonutils.JSONObject
@@ -159,16 +202,47 @@ func (s *SLVMStorage) GetSnapshotDir() string {
 	return ""
 }
 
+// /dev/<vg>/snap_<disk_id>_<snapshot_id>
 func (s *SLVMStorage) GetSnapshotPathByIds(diskId, snapshotId string) string {
-	return ""
+	if !s.IsThin() {
+		return ""
+	}
+	return path.Join("/dev", s.GetPath(), getLvmSnapshotName(diskId, snapshotId))
 }
 
 func (s *SLVMStorage) DeleteSnapshots(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
-	return nil, errors.Errorf("unsupported operation")
+	diskId, ok := params.(string)
+	if !ok {
+		return nil, hostutils.ParamsError
+	}
+	if !s.IsThin() {
+		return nil, errors.Errorf("unsupported operation")
+	}
+	return nil, s.deleteDiskSnapshots(diskId)
+}
+
+func (s *SLVMStorage) deleteDiskSnapshots(diskId string) error {
+	lvNames, err := lvmutils.GetLvNames(s.GetPath())
+	if err != nil {
+		return errors.Wrap(err, "GetLvNames")
+	}
+	prefix := getLvmSnapshotName(diskId, "")
+	for _, lvName := range lvNames {
+		if !strings.HasPrefix(lvName, prefix) {
+			continue
+		}
+		if err := lvmutils.LvRemove(path.Join("/dev", s.GetPath(), lvName)); err != nil {
+			return errors.Wrapf(err, "remove snapshot %s", lvName)
+		}
+	}
+	return nil
 }
 
 func (s *SLVMStorage) IsSnapshotExist(diskId, snapshotId string) (bool, error) {
-	return false, errors.Errorf("unsupported operation")
+	if !s.IsThin() {
+		return false, errors.Errorf("unsupported operation")
+	}
+	return lvmutils.LvExists(s.GetSnapshotPathByIds(diskId, snapshotId)), nil
 }
 
 func (s *SLVMStorage) GetDiskById(diskId string) (IDisk, error) {
@@ -284,8 +358,20 @@ func (s *SLVMStorage) saveToGlance(ctx context.Context, imageId, imagePath strin
 	return nil
 }
 
-func (s *SLVMStorage) CreateDiskFromSnapshot(context.Context, IDisk, *SDiskCreateByDiskinfo) error {
-	return errors.Errorf("unsupported operation")
+func (s *SLVMStorage) CreateDiskFromSnapshot(ctx context.Context, disk IDisk, input *SDiskCreateByDiskinfo) error {
+	if !s.IsThin() {
+		return errors.Errorf("unsupported operation")
+	}
+	lvmDisk, ok := disk.(*SLVMDisk)
+	if !ok {
+		return errors.Errorf("unsupported disk type %T", disk)
+	}
+	info := input.DiskInfo
+	snapshotPath := s.GetSnapshotPathByIds(info.SrcDiskId, info.SnapshotId)
+	if !lvmutils.LvExists(snapshotPath) {
+		return errors.Wrapf(errors.ErrNotFound, "snapshot %s", snapshotPath)
+	}
+	return lvmDisk.createFromThinSnapshot(snapshotPath)
 }
 
 func (s *SLVMStorage) CreateDiskFromExistingPath(context.Context, IDisk, *SDiskCreateByDiskinfo) error {
@@ -315,3 +401,44 @@ func (s *SLVMStorage) Accessible() error {
 func (s *SLVMStorage) Detach() error {
 	return nil
 }
+
+func (s *SLVMStorage) GetCloneTargetDiskPath(ctx context.Context, targetDiskId string) string {
+	return path.Join("/dev", s.GetPath(), targetDiskId)
+}
+
+func (s *SLVMStorage) CloneDiskFromStorage(
+	ctx context.Context, srcStorage IStorage, srcDisk IDisk, targetDiskId string, fullCopy bool,
+) (*hostapi.ServerCloneDiskFromStorageResponse, error) {
+	return cloneDiskToLvmStorage(ctx, s, srcDisk, targetDiskId, fullCopy)
+}
+
+// cloneDiskToLvmStorage creates a raw lv as large as source disk, lvm
+// storages pass themselves in to create disks of their own kind
+func cloneDiskToLvmStorage(
+	ctx context.Context, storage IStorage, srcDisk IDisk, targetDiskId string, fullCopy bool,
+) (*hostapi.ServerCloneDiskFromStorageResponse, error) {
+	srcDiskPath := srcDisk.GetPath()
+	srcImg, err := qemuimg.NewQemuImage(srcDiskPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Get source image %q info", srcDiskPath)
+	}
+	disk := storage.CreateDisk(targetDiskId)
+	_, err = disk.CreateRaw(ctx, srcImg.GetSizeMB(), qemuimgfmt.RAW.String(), "", nil, targetDiskId, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "Create target lv")
+	}
+	if fullCopy {
+		_, err = srcImg.Clone(disk.GetPath(), qemuimgfmt.RAW, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "Clone source disk to target lvm storage")
+		}
+	}
+	return &hostapi.ServerCloneDiskFromStorageResponse{
+		TargetAccessPath: disk.GetPath(),
+		TargetFormat:     qemuimgfmt.RAW.String(),
+	}, nil
+}
+
+func getLvmSnapshotName(diskId, snapshotId string) string {
+	return fmt.Sprintf("snap_%s_%s", diskId, snapshotId)
+}
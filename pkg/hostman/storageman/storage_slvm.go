@@ -0,0 +1,158 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageman
+
+import (
+	"context"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	hostapi "yunion.io/x/onecloud/pkg/apis/host"
+	"yunion.io/x/onecloud/pkg/hostman/hostutils"
+	"yunion.io/x/onecloud/pkg/hostman/storageman/lvmutils"
+	modules "yunion.io/x/onecloud/pkg/mcclient/modules/compute"
+)
+
+func init() {
+	registerStorageFactory(&SSLVMStorageFactory{})
+}
+
+type SSLVMStorageFactory struct {
+}
+
+func (factory *SSLVMStorageFactory) NewStorage(manager *SStorageManager, mountPoint string) IStorage {
+	return NewSLVMStorage(manager, mountPoint)
+}
+
+func (factory *SSLVMStorageFactory) StorageType() string {
+	return api.STORAGE_SLVM
+}
+
+// SSLVMStorage is a volume group on shared block device (multipath LUN of san,
+// or loop device for testing) attached by many hosts, lvs activation
+// is coordinated by lvmlockd
+type SSLVMStorage struct {
+	SLVMStorage
+}
+
+func NewSLVMStorage(manager *SStorageManager, vgName string) *SSLVMStorage {
+	var ret = new(SSLVMStorage)
+	ret.SLVMStorage = *NewLVMStorage(manager, vgName, 0)
+	return ret
+}
+
+func (s *SSLVMStorage) StorageType() string {
+	return api.STORAGE_SLVM
+}
+
+func (s *SSLVMStorage) IsLocal() bool {
+	return false
+}
+
+func (s *SSLVMStorage) SyncStorageInfo() (jsonutils.JSONObject, error) {
+	if len(s.StorageId) == 0 {
+		return nil, errors.Errorf("Sync shared lvm storage without storage id")
+	}
+	content := jsonutils.NewDict()
+	sizeMb, err := s.getAvailSizeMb()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAvailSizeMb")
+	}
+	usedSizeMb, err := s.GetUsedSizeMb()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUsedSizeMb")
+	}
+	content.Set("capacity", jsonutils.NewInt(sizeMb))
+	content.Set("actual_capacity_used", jsonutils.NewInt(usedSizeMb))
+	content.Set("storage_type", jsonutils.NewString(s.StorageType()))
+	content.Set("status", jsonutils.NewString(api.STORAGE_ONLINE))
+	content.Set("zone", jsonutils.NewString(s.GetZoneId()))
+	log.Infof("Sync storage info %s", s.StorageId)
+	res, err := modules.Storages.Put(
+		hostutils.GetComputeSession(context.Background()),
+		s.StorageId, content)
+	if err != nil {
+		log.Errorf("SyncStorageInfo Failed: %s: %s", content, err)
+	}
+	return res, err
+}
+
+func (s *SSLVMStorage) GetDiskById(diskId string) (IDisk, error) {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	for i := 0; i < len(s.Disks); i++ {
+		if s.Disks[i].GetId() == diskId {
+			err := s.Disks[i].Probe()
+			if err != nil {
+				return nil, errors.Wrapf(err, "disk.Prob")
+			}
+			return s.Disks[i], nil
+		}
+	}
+	var disk = NewSLVMDisk(s, diskId)
+	if disk.Probe() == nil {
+		s.Disks = append(s.Disks, disk)
+		return disk, nil
+	}
+	return nil, errors.ErrNotFound
+}
+
+// ActivateDisk activates lv of disk or converts lock of activated one, it
+// doesn't probe disk, which activates lv exclusively, e.g. target host of
+// live migrating guest has to activate lv shared while source holds it
+func (s *SSLVMStorage) ActivateDisk(diskId string, exclusive bool) error {
+	return NewSLVMDisk(s, diskId).Activate(exclusive)
+}
+
+func (s *SSLVMStorage) CreateDisk(diskId string) IDisk {
+	s.DiskLock.Lock()
+	defer s.DiskLock.Unlock()
+	disk := NewSLVMDisk(s, diskId)
+	s.Disks = append(s.Disks, disk)
+	return disk
+}
+
+// SetStorageInfo joins lockspace of vg, lvs in it couldn't be activated otherwise
+func (s *SSLVMStorage) SetStorageInfo(storageId, storageName string, conf jsonutils.JSONObject) error {
+	s.StorageId = storageId
+	s.StorageName = storageName
+	if dconf, ok := conf.(*jsonutils.JSONDict); ok {
+		s.StorageConf = dconf
+	}
+	if err := lvmutils.VgLockStart(s.GetPath()); err != nil {
+		return errors.Wrapf(err, "lock start vg %s", s.GetPath())
+	}
+	return nil
+}
+
+func (s *SSLVMStorage) CloneDiskFromStorage(
+	ctx context.Context, srcStorage IStorage, srcDisk IDisk, targetDiskId string, fullCopy bool,
+) (*hostapi.ServerCloneDiskFromStorageResponse, error) {
+	return cloneDiskToLvmStorage(ctx, s, srcDisk, targetDiskId, fullCopy)
+}
+
+func (s *SSLVMStorage) Accessible() error {
+	if _, err := lvmutils.GetVgProps(s.GetPath()); err != nil {
+		return errors.Wrapf(err, "get vg %s props", s.GetPath())
+	}
+	return nil
+}
+
+func (s *SSLVMStorage) Detach() error {
+	return lvmutils.VgLockStop(s.GetPath())
+}
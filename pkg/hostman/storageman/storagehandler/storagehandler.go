@@ -42,6 +42,8 @@ var (
 		"attach": storageAttach,
 		"detach": storageDetach,
 		"update": storageUpdate,
+
+		"probe-cifs": storageProbeCifs,
 	}
 )
 
@@ -67,6 +69,9 @@ func AddStorageHandler(prefix string, app *appsrv.Application) {
 		app.AddHandler("POST",
 			fmt.Sprintf("%s/%s/delete-backup", prefix, keyWords),
 			auth.Authenticate(storageDeleteBackup))
+		app.AddHandler("POST",
+			fmt.Sprintf("%s/%s/verify-backup", prefix, keyWords),
+			auth.Authenticate(storageVerifyBackup))
 		app.AddHandler("POST",
 			fmt.Sprintf("%s/%s/sync-backup", prefix, keyWords),
 			auth.Authenticate(storageSyncBackup))
@@ -178,6 +183,30 @@ func storageAttach(ctx context.Context, body jsonutils.JSONObject) (interface{},
 	return resp, nil
 }
 
+func storageProbeCifs(ctx context.Context, body jsonutils.JSONObject) (interface{}, error) {
+	conf := struct {
+		CifsHost     string
+		CifsShare    string
+		CifsUsername string
+		CifsPassword string
+		CifsDomain   string
+	}{}
+	if err := body.Unmarshal(&conf); err != nil {
+		return nil, errors.Wrapf(err, "body.Unmarshal")
+	}
+	if len(conf.CifsHost) == 0 {
+		return nil, httperrors.NewMissingParameterError("cifs_host")
+	}
+	if len(conf.CifsShare) == 0 {
+		return nil, httperrors.NewMissingParameterError("cifs_share")
+	}
+	err := storageman.ProbeCifsShare(conf.CifsHost, conf.CifsShare, conf.CifsUsername, conf.CifsPassword, conf.CifsDomain)
+	if err != nil {
+		return nil, httperrors.NewBadRequestError("probe cifs share //%s/%s: %s", conf.CifsHost, conf.CifsShare, err)
+	}
+	return nil, nil
+}
+
 func storageDetach(ctx context.Context, body jsonutils.JSONObject) (interface{}, error) {
 	info := struct {
 		MountPoint string
@@ -425,6 +454,35 @@ func deleteBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject
 	return nil, nil
 }
 
+func storageVerifyBackup(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	_, _, body := appsrv.FetchEnv(ctx, w, r)
+	if !checkOptions(ctx, w, body, "backup_id", "backup_storage_id", "backup_storage_access_info") {
+		return
+	}
+	backupId, _ := body.GetString("backup_id")
+	backupStorageId, _ := body.GetString("backup_storage_id")
+	backupStorageAccessInfo, _ := body.Get("backup_storage_access_info")
+	hostutils.DelayTask(ctx, verifyBackup, &storageman.SStorageBackup{
+		BackupId:                backupId,
+		BackupStorageId:         backupStorageId,
+		BackupStorageAccessInfo: backupStorageAccessInfo.(*jsonutils.JSONDict),
+	})
+	hostutils.ResponseOk(ctx, w)
+}
+
+func verifyBackup(ctx context.Context, params interface{}) (jsonutils.JSONObject, error) {
+	sbParams := params.(*storageman.SStorageBackup)
+	backupStorage, err := backupstorage.GetBackupStorage(sbParams.BackupStorageId, sbParams.BackupStorageAccessInfo)
+	if err != nil {
+		return nil, err
+	}
+	err = backupStorage.VerifyBackup(ctx, sbParams.BackupId)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 func storageDeleteSnapshots(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	params, _, body := appsrv.FetchEnv(ctx, w, r)
 	var storageId = params["<storageId>"]
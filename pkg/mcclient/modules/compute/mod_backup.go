@@ -31,10 +31,27 @@ type InstanceBackupManager struct {
 	modulebase.ResourceManager
 }
 
+type BackupPolicyManager struct {
+	modulebase.ResourceManager
+}
+
+type BackupPolicyRunManager struct {
+	modulebase.ResourceManager
+}
+
+type DrProtectionGroupManager struct {
+	modulebase.ResourceManager
+}
+
 var (
 	DiskBackups     DiskBackupManager
 	BackupStorages  BackupStorageManager
 	InstanceBackups InstanceBackupManager
+
+	BackupPolicies   BackupPolicyManager
+	BackupPolicyRuns BackupPolicyRunManager
+
+	DrProtectionGroups DrProtectionGroupManager
 )
 
 func init() {
@@ -61,4 +78,28 @@ func init() {
 		[]string{},
 	)}
 	modules.RegisterCompute(&InstanceBackups)
+
+	BackupPolicies = BackupPolicyManager{modules.NewComputeManager(
+		"backuppolicy",
+		"backuppolicies",
+		[]string{"Id", "Name", "Status", "Backup_Storage", "Repeat_Weekdays_Display", "Time_Points_Display", "Is_Activated"},
+		[]string{},
+	)}
+	modules.RegisterCompute(&BackupPolicies)
+
+	BackupPolicyRuns = BackupPolicyRunManager{modules.NewComputeManager(
+		"backuppolicyrun",
+		"backuppolicyruns",
+		[]string{"Id", "Name", "Status", "Backuppolicy", "Resource_Type", "Resource_Id", "Backup_Id", "Start_Time", "End_Time", "Reason"},
+		[]string{},
+	)}
+	modules.RegisterCompute(&BackupPolicyRuns)
+
+	DrProtectionGroups = DrProtectionGroupManager{modules.NewComputeManager(
+		"drprotectiongroup",
+		"drprotectiongroups",
+		[]string{"Id", "Name", "Status", "Target_Zone", "Backup_Storage", "Rpo_Minutes", "Member_Count", "Rpo_Violated_Count"},
+		[]string{},
+	)}
+	modules.RegisterCompute(&DrProtectionGroups)
 }
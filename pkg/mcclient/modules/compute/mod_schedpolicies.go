@@ -27,7 +27,7 @@ func init() {
 	Schedpolicies = modules.NewComputeManager("schedpolicy", "schedpolicies",
 		[]string{
 			"ID", "Name", "Description", "Condition", "Schedtag",
-			"Resource_Type", "Schedtag_Id", "Strategy", "Enabled",
+			"Resource_Type", "Schedtag_Id", "Strategy", "Load_Priority", "Enabled",
 		},
 		[]string{})
 
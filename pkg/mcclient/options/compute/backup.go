@@ -26,6 +26,8 @@ type DiskBackupListOptions struct {
 	BackupStorageId  string `help:"backup storage id" json:"backup_storage_id"`
 	IsInstanceBackup *bool  `help:"if part of instance backup" json:"is_instance_backup"`
 	OrderByDiskName  string
+	BackupMode       string `help:"backup mode" choices:"full|incremental" json:"backup_mode"`
+	ParentBackupId   string `help:"parent backup id of incremental backup" json:"parent_backup_id"`
 }
 
 func (opts *DiskBackupListOptions) Params() (jsonutils.JSONObject, error) {
@@ -57,6 +59,7 @@ type DiskBackupCreateOptions struct {
 	options.BaseCreateOptions
 	DISKID          string `help:"disk id" json:"disk_id"`
 	BACKUPSTORAGEID string `help:"back storage id" json:"backup_storage_id"`
+	Incremental     bool   `help:"backup changed data since last backup of the disk, fallback to full backup if not possible" json:"incremental"`
 }
 
 func (opts *DiskBackupCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -112,10 +115,14 @@ func (opts *BackupStorageIdOptions) Params() (jsonutils.JSONObject, error) {
 
 type BackupStorageCreateOptions struct {
 	options.BaseCreateOptions
-	StorageType  string `help:"storage type" choices:"nfs"`
-	NfsHost      string `help:"nfs host, required when storage_type is nfs"`
-	NfsSharedDir string `help:"nfs shared dir, required when storage_type is nfs" `
-	CapacityMb   int    `help:"capacity, unit mb"`
+	StorageType     string `help:"storage type" choices:"nfs|object"`
+	NfsHost         string `help:"nfs host, required when storage_type is nfs"`
+	NfsSharedDir    string `help:"nfs shared dir, required when storage_type is nfs" `
+	ObjectBucketUrl string `help:"bucket url of s3 compatible object storage, e.g. https://minio:9000/backups, required when storage_type is object"`
+	ObjectAccessKey string `help:"object storage access key, required when storage_type is object"`
+	ObjectSecret    string `help:"object storage secret, required when storage_type is object"`
+	BackupFormat    string `help:"format of backups, chunk for deduplicated and compressed chunks" choices:"file|chunk"`
+	CapacityMb      int    `help:"capacity, unit mb"`
 }
 
 func (opts *BackupStorageCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -185,3 +192,83 @@ type InstanceBackupManagerCreateFromPackageOptions struct {
 func (opts *InstanceBackupManagerCreateFromPackageOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(opts), nil
 }
+
+type BackupPolicyListOptions struct {
+	options.BaseListOptions
+	BackupStorageId string `help:"backup storage id" json:"backup_storage_id"`
+	IsActivated     *bool  `help:"if backup policy is activated" json:"is_activated"`
+}
+
+func (opts *BackupPolicyListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type BackupPolicyIdOptions struct {
+	ID string `help:"backup policy id or name" json:"-"`
+}
+
+func (opts *BackupPolicyIdOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *BackupPolicyIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type BackupPolicyCreateOptions struct {
+	options.BaseCreateOptions
+	RepeatWeekdays  []int  `help:"days of week to run backup, 1 is Monday" json:"repeat_weekdays"`
+	TimePoints      []int  `help:"hours of day to run backup, 0~23" json:"time_points"`
+	RetentionCount  int    `help:"keep latest count of backups" json:"retention_count"`
+	RetentionDays   int    `help:"keep backups within days" json:"retention_days"`
+	KeepDaily       int    `help:"keep latest backup of each of latest days" json:"keep_daily"`
+	KeepWeekly      int    `help:"keep latest backup of each of latest weeks" json:"keep_weekly"`
+	KeepMonthly     int    `help:"keep latest backup of each of latest months" json:"keep_monthly"`
+	BACKUPSTORAGEID string `help:"backup storage id" json:"backup_storage_id"`
+	Incremental     bool   `help:"backup disk incrementally if possible" json:"incremental"`
+	Consistent      bool   `help:"freeze guest filesystems via guest agent when backing up server" json:"consistent"`
+}
+
+func (opts *BackupPolicyCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type BackupPolicyUpdateOptions struct {
+	options.BaseUpdateOptions
+	RepeatWeekdays  []int  `help:"days of week to run backup, 1 is Monday" json:"repeat_weekdays"`
+	TimePoints      []int  `help:"hours of day to run backup, 0~23" json:"time_points"`
+	RetentionCount  *int   `help:"keep latest count of backups" json:"retention_count"`
+	RetentionDays   *int   `help:"keep backups within days" json:"retention_days"`
+	KeepDaily       *int   `help:"keep latest backup of each of latest days" json:"keep_daily"`
+	KeepWeekly      *int   `help:"keep latest backup of each of latest weeks" json:"keep_weekly"`
+	KeepMonthly     *int   `help:"keep latest backup of each of latest months" json:"keep_monthly"`
+	BackupStorageId string `help:"backup storage id" json:"backup_storage_id"`
+	Incremental     *bool  `help:"backup disk incrementally if possible" json:"incremental"`
+	Consistent      *bool  `help:"freeze guest filesystems via guest agent when backing up server" json:"consistent"`
+	IsActivated     *bool  `help:"activate or deactivate backup policy" json:"is_activated"`
+}
+
+func (opts *BackupPolicyUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
+
+type BackupPolicyBindOptions struct {
+	BackupPolicyIdOptions
+	Disks   []string `help:"ids of disks" json:"disks"`
+	Servers []string `help:"ids of servers" json:"servers"`
+}
+
+func (opts *BackupPolicyBindOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type BackupPolicyRunListOptions struct {
+	options.BaseListOptions
+	BackuppolicyId string `help:"backup policy id" json:"backuppolicy_id"`
+	ResourceType   string `help:"resource type" choices:"disk|server" json:"resource_type"`
+	ResourceId     string `help:"resource id" json:"resource_id"`
+}
+
+func (opts *BackupPolicyRunListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type DiskPerformanceClassListOptions struct {
+	options.BaseListOptions
+	MediumType []string `help:"filter by medium type" choices:"rotate|ssd|hybrid" json:"medium_type"`
+}
+
+func (opts *DiskPerformanceClassListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type DiskPerformanceClassIdOptions struct {
+	ID string `help:"disk performance class id or name" json:"-"`
+}
+
+func (opts *DiskPerformanceClassIdOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *DiskPerformanceClassIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type DiskPerformanceClassCreateOptions struct {
+	options.BaseCreateOptions
+	Iops         int    `help:"base iops limit, 0 means no limit" json:"iops"`
+	IopsPerGb    int    `help:"iops per GB of disk size, e.g. 30" json:"iops_per_gb"`
+	MaxIops      int    `help:"upper limit of iops, 0 means no limit" json:"max_iops"`
+	Bps          int    `help:"throughput limit in MB/s, 0 means no limit" json:"bps"`
+	BurstIops    int    `help:"burst iops limit" json:"burst_iops"`
+	BurstBps     int    `help:"burst throughput limit in MB/s" json:"burst_bps"`
+	BurstSeconds int    `help:"burst length in seconds" json:"burst_seconds"`
+	SharedBudget bool   `help:"disks of a server in this class share one budget" json:"shared_budget"`
+	MediumType   string `help:"medium type of storages this class could be placed" choices:"rotate|ssd|hybrid" json:"medium_type"`
+}
+
+func (opts *DiskPerformanceClassCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type DiskPerformanceClassUpdateOptions struct {
+	options.BaseUpdateOptions
+	Iops         *int  `help:"base iops limit, 0 means no limit" json:"iops"`
+	IopsPerGb    *int  `help:"iops per GB of disk size" json:"iops_per_gb"`
+	MaxIops      *int  `help:"upper limit of iops, 0 means no limit" json:"max_iops"`
+	Bps          *int  `help:"throughput limit in MB/s, 0 means no limit" json:"bps"`
+	BurstIops    *int  `help:"burst iops limit" json:"burst_iops"`
+	BurstBps     *int  `help:"burst throughput limit in MB/s" json:"burst_bps"`
+	BurstSeconds *int  `help:"burst length in seconds" json:"burst_seconds"`
+	SharedBudget *bool `help:"disks of a server in this class share one budget" json:"shared_budget"`
+}
+
+func (opts *DiskPerformanceClassUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	return options.StructToParams(opts)
+}
@@ -15,8 +15,11 @@
 package compute
 
 import (
+	"yunion.io/x/jsonutils"
+
 	api "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/cloudcommon/cmdline"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
 )
 
 type DiskCreateOptions struct {
@@ -38,6 +41,8 @@ type DiskCreateOptions struct {
 	SnapshotId string   `help:"snapshot id"`
 	BackupId   string   `help:"Backup id"`
 
+	PerformanceClass string `help:"ID or name of disk performance class"`
+
 	Project string `help:"Owner project"`
 }
 
@@ -49,6 +54,9 @@ func (o DiskCreateOptions) Params() (*api.DiskCreateInput, error) {
 	if len(o.Backend) > 0 {
 		config.Backend = o.Backend
 	}
+	if len(o.PerformanceClass) > 0 {
+		config.PerformanceClass = o.PerformanceClass
+	}
 	for _, desc := range o.Schedtag {
 		tag, err := cmdline.ParseSchedtagConfig(desc)
 		if err != nil {
@@ -74,3 +82,12 @@ func (o DiskCreateOptions) Params() (*api.DiskCreateInput, error) {
 	params.BackupId = o.BackupId
 	return params, nil
 }
+
+type DiskSetPerformanceClassOptions struct {
+	options.BaseIdOptions
+	DiskPerformanceClass string `help:"ID or name of disk performance class, empty to use the default of storage" json:"disk_performance_class_id"`
+}
+
+func (o *DiskSetPerformanceClassOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]string{"disk_performance_class_id": o.DiskPerformanceClass}), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"strings"
+
+	"yunion.io/x/jsonutils"
+	"yunion.io/x/pkg/errors"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+func parseDrNetworkMappings(mappings []string) (api.SDrNetworkMappings, error) {
+	ret := api.SDrNetworkMappings{}
+	for _, m := range mappings {
+		segs := strings.Split(m, ":")
+		if len(segs) < 2 || len(segs) > 3 {
+			return nil, errors.Errorf("invalid network mapping %q, should be source:target[:test]", m)
+		}
+		mapping := api.SDrNetworkMapping{
+			SourceNetworkId: segs[0],
+			TargetNetworkId: segs[1],
+		}
+		if len(segs) == 3 {
+			mapping.TestNetworkId = segs[2]
+		}
+		ret = append(ret, mapping)
+	}
+	return ret, nil
+}
+
+type DrProtectionGroupListOptions struct {
+	options.BaseListOptions
+	TargetZoneId    string `help:"target zone id" json:"target_zone_id"`
+	BackupStorageId string `help:"backup storage id" json:"backup_storage_id"`
+	RpoViolated     *bool  `help:"if any member violates rpo" json:"rpo_violated"`
+}
+
+func (opts *DrProtectionGroupListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type DrProtectionGroupIdOptions struct {
+	ID string `help:"dr protection group id or name" json:"-"`
+}
+
+func (opts *DrProtectionGroupIdOptions) GetId() string {
+	return opts.ID
+}
+
+func (opts *DrProtectionGroupIdOptions) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type DrProtectionGroupCreateOptions struct {
+	options.BaseCreateOptions
+	TARGETZONEID             string   `help:"zone to fail over to" json:"target_zone_id"`
+	BACKUPSTORAGEID          string   `help:"backup storage to store replicas" json:"backup_storage_id"`
+	RpoMinutes               int      `help:"recovery point objective in minutes" json:"rpo_minutes"`
+	ReplicateIntervalMinutes int      `help:"interval of replication in minutes" json:"replicate_interval_minutes"`
+	RetentionCount           int      `help:"count of replicas to keep for each server" json:"retention_count"`
+	Consistent               bool     `help:"freeze guest filesystems via guest agent when replicating" json:"consistent"`
+	NetworkMapping           []string `help:"network mapping, source_network:target_network[:test_network]" json:"-"`
+}
+
+func (opts *DrProtectionGroupCreateOptions) Params() (jsonutils.JSONObject, error) {
+	mappings, err := parseDrNetworkMappings(opts.NetworkMapping)
+	if err != nil {
+		return nil, err
+	}
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	if len(mappings) > 0 {
+		params.Set("network_mappings", jsonutils.Marshal(mappings))
+	}
+	return params, nil
+}
+
+type DrProtectionGroupUpdateOptions struct {
+	options.BaseUpdateOptions
+	RpoMinutes               *int     `help:"recovery point objective in minutes" json:"rpo_minutes"`
+	ReplicateIntervalMinutes *int     `help:"interval of replication in minutes" json:"replicate_interval_minutes"`
+	RetentionCount           *int     `help:"count of replicas to keep for each server" json:"retention_count"`
+	Consistent               *bool    `help:"freeze guest filesystems via guest agent when replicating" json:"consistent"`
+	NetworkMapping           []string `help:"network mapping, source_network:target_network[:test_network]" json:"-"`
+}
+
+func (opts *DrProtectionGroupUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	mappings, err := parseDrNetworkMappings(opts.NetworkMapping)
+	if err != nil {
+		return nil, err
+	}
+	params, err := options.StructToParams(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) > 0 {
+		params.Set("network_mappings", jsonutils.Marshal(mappings))
+	}
+	return params, nil
+}
+
+type DrProtectionGroupServersOptions struct {
+	DrProtectionGroupIdOptions
+	Servers []string `help:"ids of servers" json:"servers"`
+}
+
+func (opts *DrProtectionGroupServersOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type DrProtectionGroupFailoverOptions struct {
+	DrProtectionGroupIdOptions
+	SkipStopSource bool `help:"do not try to stop source servers" json:"skip_stop_source"`
+}
+
+func (opts *DrProtectionGroupFailoverOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type GuestFileShareListOptions struct {
+	options.BaseListOptions
+	Server  string `help:"Filter by server" json:"server_id"`
+	Storage string `help:"Filter by storage" json:"storage_id"`
+}
+
+func (opts *GuestFileShareListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type GuestFileShareIdOption struct {
+	ID string `help:"Guest file share Id or name"`
+}
+
+func (opts *GuestFileShareIdOption) GetId() string {
+	return opts.ID
+}
+
+func (opts *GuestFileShareIdOption) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type GuestFileShareCreateOptions struct {
+	options.BaseCreateOptions
+	SERVER   string `help:"Server the directory is shared to" json:"server_id"`
+	PATH     string `help:"Path relative to storage mount point, or absolute host local path" json:"path"`
+	Storage  string `help:"Shared file storage, e.g. nfs or gpfs, host local directory if not set" json:"storage_id"`
+	MountTag string `help:"Tag to mount in guest, default is name"`
+	ReadOnly bool   `help:"Share directory read only"`
+}
+
+func (opts *GuestFileShareCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compute
+
+import (
+	"yunion.io/x/jsonutils"
+
+	"yunion.io/x/onecloud/pkg/mcclient/options"
+)
+
+type MaintenanceCampaignListOptions struct {
+	options.BaseListOptions
+	Zone     string `help:"Filter by zone" json:"zone_id"`
+	Schedtag string `help:"Filter by schedtag" json:"schedtag_id"`
+}
+
+func (opts *MaintenanceCampaignListOptions) Params() (jsonutils.JSONObject, error) {
+	return options.ListStructToParams(opts)
+}
+
+type MaintenanceCampaignIdOption struct {
+	ID string `help:"Maintenance campaign Id or name"`
+}
+
+func (opts *MaintenanceCampaignIdOption) GetId() string {
+	return opts.ID
+}
+
+func (opts *MaintenanceCampaignIdOption) Params() (jsonutils.JSONObject, error) {
+	return nil, nil
+}
+
+type MaintenanceCampaignCreateOptions struct {
+	options.BaseCreateOptions
+	Host                 []string `help:"Host to maintain" json:"hosts"`
+	Zone                 string   `help:"Maintain all hosts of the zone" json:"zone_id"`
+	Schedtag             string   `help:"Maintain all hosts bound to the schedtag" json:"schedtag_id"`
+	MaxUnavailable       int      `help:"Max hosts drained at the same time, default 1"`
+	HostAgentVersion     string   `help:"Re-enable drained host after it reports this host agent version"`
+	HealthTimeoutMinutes int      `help:"Pause campaign if drained host is not healthy in time, default 60"`
+}
+
+func (opts *MaintenanceCampaignCreateOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(opts), nil
+}
+
+type MaintenanceCampaignUpdateOptions struct {
+	MaintenanceCampaignIdOption
+	Name                 string
+	Description          string
+	MaxUnavailable       *int
+	HostAgentVersion     *string
+	HealthTimeoutMinutes *int
+}
+
+func (opts *MaintenanceCampaignUpdateOptions) Params() (jsonutils.JSONObject, error) {
+	params := jsonutils.Marshal(opts).(*jsonutils.JSONDict)
+	params.Remove("id")
+	return params, nil
+}
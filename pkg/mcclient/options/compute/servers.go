@@ -270,6 +270,7 @@ type ServerConfigs struct {
 	snapshot_id: use snapshot-list get snapshot id
 	disk_id: use disk-list get disk id
 	storage_id: use storage-list get storage id
+	performance_class: use diskperformanceclass-list get disk performance class id
 	image_id: use image-list get image id
 	for example:
 		--disk 'image_id=c2be02a4-7ff2-43e6-8a00-a489e04d2d6f,size=10G,driver=ide,storage_type=rbd'
@@ -421,6 +422,8 @@ type ServerCreateOptionalOptions struct {
 	Vdi              string   `help:"VDI protocool" choices:"vnc|spice"`
 	Bios             string   `help:"BIOS" choices:"BIOS|UEFI"`
 	Machine          string   `help:"Machine type" choices:"pc|q35"`
+	EnableVtpm       bool     `help:"Attach a virtual TPM device backed by swtpm"`
+	SecureBoot       bool     `help:"Enable UEFI Secure Boot, implies UEFI BIOS"`
 	Desc             string   `help:"Description" metavar:"<DESCRIPTION>" json:"description"`
 	Boot             string   `help:"Boot device" metavar:"<BOOT_DEVICE>" choices:"disk|cdrom" json:"-"`
 	EnableCloudInit  bool     `help:"Enable cloud-init service"`
@@ -530,6 +533,8 @@ func (opts *ServerCreateOptionalOptions) OptionalParams() (*computeapi.ServerCre
 		Vdi:                opts.Vdi,
 		Bios:               opts.Bios,
 		Machine:            opts.Machine,
+		EnableVtpm:         opts.EnableVtpm,
+		SecureBoot:         opts.SecureBoot,
 		ShutdownBehavior:   opts.ShutdownBehavior,
 		AutoStart:          opts.AutoStart,
 		Duration:           opts.Duration,
@@ -1350,6 +1355,7 @@ type ServerChangeDiskStorageOptions struct {
 	DISKID         string `json:"disk_id" help:"Disk id or name"`
 	TARGETSTORAGE  string `json:"target_storage_id" help:"Target storage id or name"`
 	KeepOriginDisk bool   `json:"keep_origin_disk" help:"Keep origin disk when changed"`
+	MaxBandwidthMb int64  `json:"max_bandwidth_mb" help:"Bandwidth limit of copying disk of running server, MB/s"`
 }
 
 func (o *ServerChangeDiskStorageOptions) Params() (jsonutils.JSONObject, error) {
@@ -1360,12 +1366,22 @@ type ServerChangeStorageOptions struct {
 	options.BaseIdOptions
 	TARGETSTORAGE  string `json:"target_storage_id" help:"Target storage id or name"`
 	KeepOriginDisk bool   `json:"keep_origin_disk" help:"Keep origin disk when changed"`
+	MaxBandwidthMb int64  `json:"max_bandwidth_mb" help:"Bandwidth limit of copying disks of running server, MB/s"`
 }
 
 func (o *ServerChangeStorageOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(o), nil
 }
 
+type ServerCancelChangeDiskStorageOptions struct {
+	options.BaseIdOptions
+	Disk string `json:"disk_id" help:"Disk id or name, cancel all disks if not specified"`
+}
+
+func (o *ServerCancelChangeDiskStorageOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(o), nil
+}
+
 type ServerCPUSetOptions struct {
 	options.BaseIdOptions
 	SETS string `help:"Cgroup cpusets CPUs spec string, e.g. '0-2,16'"`
@@ -1399,6 +1415,15 @@ func (o *ServerVncOptions) Params() (jsonutils.JSONObject, error) {
 	return jsonutils.Marshal(o), nil
 }
 
+type ServerSecgroupFlowLogOptions struct {
+	ServerIdOptions
+	options.SecgroupFlowLogQueryOptions
+}
+
+func (o *ServerSecgroupFlowLogOptions) Params() (jsonutils.JSONObject, error) {
+	return o.SecgroupFlowLogQueryOptions.Params()
+}
+
 type ServerIsoOptions struct {
 	ServerIdOptions
 	Ordinal int `help:"server iso ordinal, default 0"`
@@ -56,6 +56,7 @@ type StorageUpdateOptions struct {
 	RbdKey                string  `help:"ceph rbd key"`
 	Reserved              string  `help:"Reserved storage space"`
 	Capacity              int     `help:"Capacity for storage"`
+	DiskPerformanceClass  string  `help:"Default disk performance class of the storage" json:"disk_performance_class_id"`
 }
 
 func (opts *StorageUpdateOptions) Params() (jsonutils.JSONObject, error) {
@@ -67,7 +68,7 @@ type StorageCreateOptions struct {
 	ZONE                  string `help:"Zone id of storage"`
 	Capacity              int64  `help:"Capacity of the Storage"`
 	MediumType            string `help:"Medium type" choices:"ssd|rotate" default:"ssd"`
-	StorageType           string `help:"Storage type" choices:"local|nas|vsan|rbd|nfs|gpfs|baremetal"`
+	StorageType           string `help:"Storage type" choices:"local|nas|vsan|rbd|nfs|gpfs|baremetal|slvm|cifs"`
 	RbdMonHost            string `help:"Ceph mon_host config"`
 	RbdRadosMonOpTimeout  int64  `help:"ceph rados_mon_op_timeout"`
 	RbdRadosOsdOpTimeout  int64  `help:"ceph rados_osd_op_timeout"`
@@ -76,6 +77,13 @@ type StorageCreateOptions struct {
 	RbdPool               string `help:"Ceph Pool Name"`
 	NfsHost               string `help:"NFS host"`
 	NfsSharedDir          string `help:"NFS shared dir"`
+	SlvmVgName            string `help:"Shared lvm volume group name"`
+	CifsHost              string `help:"CIFS server host"`
+	CifsShare             string `help:"CIFS share name"`
+	CifsUsername          string `help:"CIFS username"`
+	CifsPassword          string `help:"CIFS password"`
+	CifsDomain            string `help:"CIFS domain"`
+	DiskPerformanceClass  string `help:"Default disk performance class of the storage" json:"disk_performance_class_id"`
 }
 
 func (opts *StorageCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -87,6 +95,14 @@ func (opts *StorageCreateOptions) Params() (jsonutils.JSONObject, error) {
 		if len(opts.NfsHost) == 0 || len(opts.NfsSharedDir) == 0 {
 			return nil, fmt.Errorf("Storage type nfs missing conf host or shared dir")
 		}
+	} else if opts.StorageType == "slvm" {
+		if len(opts.SlvmVgName) == 0 {
+			return nil, fmt.Errorf("Storage type slvm missing conf vg name")
+		}
+	} else if opts.StorageType == "cifs" {
+		if len(opts.CifsHost) == 0 || len(opts.CifsShare) == 0 || len(opts.CifsUsername) == 0 {
+			return nil, fmt.Errorf("Storage type cifs missing conf host, share or username")
+		}
 	}
 	return options.StructToParams(opts)
 }
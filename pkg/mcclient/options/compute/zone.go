@@ -137,3 +137,12 @@ func (opts *ZonePurgeOptions) Params() (jsonutils.JSONObject, error) {
 	params.Set("manager_id", jsonutils.NewString(opts.MANAGER_ID))
 	return params, nil
 }
+
+type ZoneRebalanceOptions struct {
+	ZoneIdOptions
+	options.RebalanceOptions
+}
+
+func (opts *ZoneRebalanceOptions) Params() (jsonutils.JSONObject, error) {
+	return opts.RebalanceOptions.Params()
+}
@@ -194,3 +194,23 @@ func (o SchedtagSetResource) Params() (jsonutils.JSONObject, error) {
 
 	return jsonutils.Marshal(input), nil
 }
+
+type RebalanceOptions struct {
+	DryRun                  bool     `help:"Only show migration plan, do not migrate"`
+	Threshold               *float64 `help:"Percent the most loaded host exceeds the average load that is tolerated"`
+	MaxMigrations           int      `help:"Max guests migrated in this rebalance"`
+	MaxConcurrentMigrations int      `help:"Max concurrent live migrations"`
+}
+
+func (o RebalanceOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(o)
+}
+
+type SchedtagRebalanceOptions struct {
+	ID string `help:"ID or Name of schedtag"`
+	RebalanceOptions
+}
+
+func (o SchedtagRebalanceOptions) GetId() string {
+	return o.ID
+}
@@ -46,7 +46,8 @@ func (opts *SecgroupListOptions) Params() (jsonutils.JSONObject, error) {
 
 type SecgroupCreateOptions struct {
 	BaseCreateOptions
-	Rules []string `help:"security rule to create"`
+	Rules   []string `help:"security rule to create"`
+	FlowLog string   `help:"flow log mode, only for kvm guests in ovn vpcs" choices:"off|deny|all"`
 }
 
 func (opts *SecgroupCreateOptions) Params() (jsonutils.JSONObject, error) {
@@ -164,3 +165,32 @@ func (opts *SecgroupImportRulesOptions) Params() (jsonutils.JSONObject, error) {
 	}
 	return jsonutils.Marshal(map[string]*jsonutils.JSONArray{"rules": rules}), nil
 }
+
+type SecgroupSetFlowLogOptions struct {
+	SecgroupIdOptions
+	FLOW_LOG string `help:"flow log mode" choices:"off|deny|all"`
+}
+
+func (opts *SecgroupSetFlowLogOptions) Params() (jsonutils.JSONObject, error) {
+	return jsonutils.Marshal(map[string]string{"flow_log": opts.FLOW_LOG}), nil
+}
+
+type SecgroupFlowLogQueryOptions struct {
+	StartTime string `help:"start time of flow logs, default to an hour ago, e.g. 2023-05-10T08:00:00Z"`
+	EndTime   string `help:"end time of flow logs, default to now"`
+	Verdict   string `help:"filter by verdict" choices:"allow|drop"`
+	Limit     int    `help:"max number of records, default 100"`
+}
+
+func (opts *SecgroupFlowLogQueryOptions) Params() (jsonutils.JSONObject, error) {
+	return StructToParams(opts)
+}
+
+type SecgroupFlowLogOptions struct {
+	SecgroupIdOptions
+	SecgroupFlowLogQueryOptions
+}
+
+func (opts *SecgroupFlowLogOptions) Params() (jsonutils.JSONObject, error) {
+	return opts.SecgroupFlowLogQueryOptions.Params()
+}
@@ -394,6 +394,22 @@ func init() {
 			newMetricFieldCreateInput("usage_active", "Storage utilization rate", monitor.METRIC_UNIT_PERCENT, 1),
 			newMetricFieldCreateInput("free", "Free storage", monitor.METRIC_UNIT_MB, 2),
 		})
+	RegistryMetricCreateInput("storage_capacity", "Storage capacity forecast",
+		monitor.METRIC_RES_TYPE_STORAGE, monitor.METRIC_DATABASE_TELE, 2, []monitor.MetricFieldCreateInput{
+			newMetricFieldCreateInput("days_until_full", "Days until storage is full", monitor.METRIC_UNIT_DAY, 1),
+			newMetricFieldCreateInput("commit_days_until_full", "Days until storage is fully committed", monitor.METRIC_UNIT_DAY, 2),
+			newMetricFieldCreateInput("usage_growth_rate", "Daily growth of used storage", monitor.METRIC_UNIT_MB, 3),
+			newMetricFieldCreateInput("commit_growth_rate", "Daily growth of committed storage", monitor.METRIC_UNIT_MB, 4),
+			newMetricFieldCreateInput("actual_used", "Used storage", monitor.METRIC_UNIT_MB, 5),
+			newMetricFieldCreateInput("commit_used", "Committed storage", monitor.METRIC_UNIT_MB, 6),
+		})
+	RegistryMetricCreateInput("storage_schedtag_capacity", "Storage schedtag capacity forecast",
+		monitor.METRIC_RES_TYPE_STORAGE, monitor.METRIC_DATABASE_TELE, 3, []monitor.MetricFieldCreateInput{
+			newMetricFieldCreateInput("days_until_full", "Days until storages of schedtag are full", monitor.METRIC_UNIT_DAY, 1),
+			newMetricFieldCreateInput("commit_days_until_full", "Days until storages of schedtag are fully committed", monitor.METRIC_UNIT_DAY, 2),
+			newMetricFieldCreateInput("usage_growth_rate", "Daily growth of used storage", monitor.METRIC_UNIT_MB, 3),
+			newMetricFieldCreateInput("commit_growth_rate", "Daily growth of committed storage", monitor.METRIC_UNIT_MB, 4),
+		})
 
 	//jenkins
 	RegistryMetricCreateInput("jenkins_node", "jenkins node",
@@ -129,6 +129,15 @@ func (p *DiskSchedtagPredicate) IsResourceFitInput(ctx context.Context, u *core.
 			}
 		}
 	}
+	// storage with default performance class is reserved for disks of that class
+	if len(d.PerformanceClass) != 0 && len(storage.DiskPerformanceClassId) != 0 {
+		if storage.DiskPerformanceClassId != d.PerformanceClass {
+			return &FailReason{
+				fmt.Sprintf("Storage %s disk performance class %s != %s", storage.Name, storage.DiskPerformanceClassId, d.PerformanceClass),
+				StoragePerformanceClass,
+			}
+		}
+	}
 	storageTypes := p.GetHypervisorDriver().GetStorageTypes()
 	if len(storageTypes) != 0 && !utils.IsInStringArray(storage.StorageType, storageTypes) {
 		return &FailReason{
@@ -44,6 +44,10 @@ const (
 	ErrBaremetalHasAlreadyBeenOccupied        = `baremetal has already been occupied`
 	ErrPrepaidHostOccupied                    = `prepaid host occupied`
 	ErrHostCpuArchitectureNotMatch            = `host cpu architecture not match`
+	ErrNoNumaNodeCanHoldGuest                 = `no numa node can hold the guest`
+	ErrHostNoSecureBootOvmf                   = `host has no secure boot capable OVMF`
+	ErrHostLocalFileShare                     = `guest has host local file share`
+	ErrFileShareStorageNotAttached            = `file share storage not attached`
 
 	ErrUnknown = `unknown error`
 )
@@ -130,4 +134,6 @@ const (
 	StorageOwnership = "storage_ownership"
 	StorageMedium    = "storage_medium"
 	StorageCapacity  = "storage_capacity"
+
+	StoragePerformanceClass = "storage_performance_class"
 )
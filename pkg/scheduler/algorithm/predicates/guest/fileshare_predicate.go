@@ -0,0 +1,62 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"context"
+	"fmt"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// FileSharePredicate filter hosts not attached to storages of guest virtio-fs
+// shares, guest with host local share can't be placed on any other host.
+type FileSharePredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *FileSharePredicate) Name() string {
+	return "host_file_share"
+}
+
+func (p *FileSharePredicate) Clone() core.FitPredicate {
+	return &FileSharePredicate{}
+}
+
+func (p *FileSharePredicate) PreExecute(ctx context.Context, u *core.Unit, cs []core.Candidater) (bool, error) {
+	data := u.SchedData()
+	return data.HostLocalFileShare || len(data.FileShareStorageIds) > 0, nil
+}
+
+func (p *FileSharePredicate) Execute(ctx context.Context, u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+	data := u.SchedData()
+	if data.HostLocalFileShare {
+		h.Exclude(predicates.ErrHostLocalFileShare)
+		return h.GetResult()
+	}
+	storageIds := make(map[string]bool)
+	for _, s := range c.Getter().Storages() {
+		storageIds[s.Id] = true
+	}
+	for _, id := range data.FileShareStorageIds {
+		if !storageIds[id] {
+			h.Exclude(fmt.Sprintf("%s: %s", predicates.ErrFileShareStorageNotAttached, id))
+			break
+		}
+	}
+	return h.GetResult()
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// NumaPredicate filter hosts that no single numa node has enough free cpu and memory,
+// guest larger than any numa node of the host is let through because split is unavoidable.
+type NumaPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *NumaPredicate) Name() string {
+	return "host_numa"
+}
+
+func (p *NumaPredicate) Clone() core.FitPredicate {
+	return &NumaPredicate{}
+}
+
+func (p *NumaPredicate) PreExecute(ctx context.Context, u *core.Unit, cs []core.Candidater) (bool, error) {
+	if o.Options.NumaFitPolicy != o.NUMA_FIT_POLICY_REQUIRE {
+		return false, nil
+	}
+	if !u.GetHypervisorDriver().DoScheduleCPUFilter() || !u.GetHypervisorDriver().DoScheduleMemoryFilter() {
+		return false, nil
+	}
+	data := u.SchedData()
+	if data.Ncpu <= 0 && data.Memory <= 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *NumaPredicate) Execute(ctx context.Context, u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+	d := u.SchedData()
+
+	nodes := c.Getter().NumaNodes()
+	if len(nodes) == 0 {
+		return h.GetResult()
+	}
+
+	reqCpu, reqMem := int64(d.Ncpu), int64(d.Memory)
+	var (
+		capacity  int64
+		canHold   bool
+		nodesFree = make([]string, 0, len(nodes))
+	)
+	for _, node := range nodes {
+		nodesFree = append(nodesFree, fmt.Sprintf("node%d cpu %d memory %dM", node.NodeId, node.FreeCPUCount, node.FreeMemSize))
+		if !node.CanHold(reqCpu, reqMem) {
+			continue
+		}
+		canHold = true
+		capacity += node.Capacity(reqCpu, reqMem)
+	}
+	if !canHold {
+		return h.GetResult()
+	}
+	if capacity == 0 {
+		h.AppendPredicateFailMsg(fmt.Sprintf("%s, requested cpu %d memory %dM, free: %s",
+			predicates.ErrNoNumaNodeCanHoldGuest, reqCpu, reqMem, strings.Join(nodesFree, ", ")))
+	}
+	h.SetCapacity(capacity)
+	return h.GetResult()
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"context"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/predicates"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// SecureBootPredicate filter hosts without secure boot capable OVMF build
+// when guest requires UEFI Secure Boot.
+type SecureBootPredicate struct {
+	predicates.BasePredicate
+}
+
+func (p *SecureBootPredicate) Name() string {
+	return "host_secure_boot"
+}
+
+func (p *SecureBootPredicate) Clone() core.FitPredicate {
+	return &SecureBootPredicate{}
+}
+
+func (p *SecureBootPredicate) PreExecute(ctx context.Context, u *core.Unit, cs []core.Candidater) (bool, error) {
+	return u.SchedData().SecureBoot, nil
+}
+
+func (p *SecureBootPredicate) Execute(ctx context.Context, u *core.Unit, c core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	h := predicates.NewPredicateHelper(p, u, c)
+	if !c.Getter().OvmfSecbootCapable() {
+		h.Exclude(predicates.ErrHostNoSecureBootOvmf)
+	}
+	return h.GetResult()
+}
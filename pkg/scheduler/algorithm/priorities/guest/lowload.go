@@ -15,9 +15,11 @@
 package guest
 
 import (
+	computeapi "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
 	"yunion.io/x/onecloud/pkg/scheduler/core"
 	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
 )
 
 type LowLoadPriority struct {
@@ -32,17 +34,38 @@ func (p *LowLoadPriority) Clone() core.Priority {
 	return &LowLoadPriority{}
 }
 
+func (p *LowLoadPriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	// host_metrics_load priority takes over and fallbacks to commit rate itself
+	return !isMetricsLoadPriority(u), nil, nil
+}
+
 func (p *LowLoadPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
 	h := priorities.NewPriorityHelper(p, u, c)
 
-	getter := c.Getter()
+	if score, ok := commitRateScore(c.Getter()); ok {
+		h.SetScore(score)
+	}
+	return h.GetResult()
+}
+
+func commitRateScore(getter core.CandidatePropertyGetter) (int, bool) {
 	cpuCommitRate := float64(getter.RunningCPUCount()) / float64(getter.TotalCPUCount(false))
-	memCommitRate := float64(getter.RunningMemorySize()) / float64(getter.TotalMemorySize(false))
+	// memory given back by guest balloons is free to use
+	runningMem := getter.RunningMemorySize() - getter.BalloonedMemorySize()
+	memCommitRate := float64(runningMem) / float64(getter.TotalMemorySize(false))
 	if cpuCommitRate < 0.5 && memCommitRate < 0.5 {
 		score := 10 * (1 - cpuCommitRate - memCommitRate)
-		h.SetScore(int(score))
+		return int(score), true
 	}
-	return h.GetResult()
+	return 0, false
+}
+
+func isMetricsLoadPriority(u *core.Unit) bool {
+	loadPriority := u.SchedData().LoadPriority
+	if len(loadPriority) == 0 {
+		loadPriority = o.Options.DefaultLoadPriority
+	}
+	return loadPriority == computeapi.SCHED_LOAD_PRIORITY_METRICS
 }
 
 func (p *LowLoadPriority) ScoreIntervals() score.Intervals {
@@ -0,0 +1,111 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"time"
+
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+const (
+	metricsCpuWeight  = 0.4
+	metricsMemWeight  = 0.3
+	metricsDiskWeight = 0.15
+	metricsNetWeight  = 0.15
+)
+
+// MetricsLoadPriority scores hosts by real p95 utilization reported to tsdb,
+// hosts without fresh metrics fallback to commit rate like LowLoadPriority
+type MetricsLoadPriority struct {
+	priorities.BasePriority
+
+	staleTimeout time.Duration
+	// disk and nic throughput are normalized by the busiest candidate
+	maxDiskIOBps float64
+	maxNetBps    float64
+}
+
+func (p *MetricsLoadPriority) Name() string {
+	return "host_metrics_load"
+}
+
+func (p *MetricsLoadPriority) Clone() core.Priority {
+	return &MetricsLoadPriority{}
+}
+
+func (p *MetricsLoadPriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	if !isMetricsLoadPriority(u) {
+		return false, nil, nil
+	}
+	p.staleTimeout = utils.ToDuration(o.Options.HostMetricsStaleTimeout)
+	for _, c := range cs {
+		metrics := c.Getter().HostMetrics()
+		if metrics.IsStale(p.staleTimeout) {
+			continue
+		}
+		if metrics.DiskIOBps > p.maxDiskIOBps {
+			p.maxDiskIOBps = metrics.DiskIOBps
+		}
+		if metrics.NetBps > p.maxNetBps {
+			p.maxNetBps = metrics.NetBps
+		}
+	}
+	return true, nil, nil
+}
+
+func (p *MetricsLoadPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+
+	getter := c.Getter()
+	metrics := getter.HostMetrics()
+	if metrics.IsStale(p.staleTimeout) {
+		if score, ok := commitRateScore(getter); ok {
+			h.SetScore(score)
+		}
+		return h.GetResult()
+	}
+	h.SetScore(metricsLoadScore(metrics, p.maxDiskIOBps, p.maxNetBps))
+	return h.GetResult()
+}
+
+func (p *MetricsLoadPriority) ScoreIntervals() score.Intervals {
+	return score.NewIntervals(0, 1, 5)
+}
+
+func metricsLoadScore(metrics *hostmetrics.SHostMetrics, maxDiskIOBps, maxNetBps float64) int {
+	ratio := func(val, max float64) float64 {
+		if max <= 0 {
+			return 0
+		}
+		return val / max
+	}
+	load := metricsCpuWeight*metrics.CpuUsage/100 +
+		metricsMemWeight*metrics.MemUsage/100 +
+		metricsDiskWeight*ratio(metrics.DiskIOBps, maxDiskIOBps) +
+		metricsNetWeight*ratio(metrics.NetBps, maxNetBps)
+	if load > 1 {
+		load = 1
+	} else if load < 0 {
+		load = 0
+	}
+	return int(10 * (1 - load))
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
+)
+
+func TestMetricsLoadScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		metrics hostmetrics.SHostMetrics
+		maxDisk float64
+		maxNet  float64
+		want    int
+	}{
+		{
+			name: "idle host",
+			want: 10,
+		},
+		{
+			name:    "busy cpu and memory",
+			metrics: hostmetrics.SHostMetrics{CpuUsage: 100, MemUsage: 100},
+			want:    3,
+		},
+		{
+			name:    "busiest io host",
+			metrics: hostmetrics.SHostMetrics{CpuUsage: 50, MemUsage: 50, DiskIOBps: 200, NetBps: 100},
+			maxDisk: 200,
+			maxNet:  100,
+			want:    3,
+		},
+		{
+			name:    "fully loaded",
+			metrics: hostmetrics.SHostMetrics{CpuUsage: 100, MemUsage: 100, DiskIOBps: 10, NetBps: 10},
+			maxDisk: 10,
+			maxNet:  10,
+			want:    0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := metricsLoadScore(&c.metrics, c.maxDisk, c.maxNet)
+			if got != c.want {
+				t.Errorf("got score %d, want %d", got, c.want)
+			}
+		})
+	}
+}
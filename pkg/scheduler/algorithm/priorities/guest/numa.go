@@ -0,0 +1,72 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"yunion.io/x/onecloud/pkg/scheduler/algorithm/priorities"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+	o "yunion.io/x/onecloud/pkg/scheduler/options"
+)
+
+// NumaPriority prefers hosts that can hold the guest in a single numa node,
+// hosts that would split the guest across nodes are penalized
+type NumaPriority struct {
+	priorities.BasePriority
+}
+
+func (p *NumaPriority) Name() string {
+	return "host_numa_fit"
+}
+
+func (p *NumaPriority) Clone() core.Priority {
+	return &NumaPriority{}
+}
+
+func (p *NumaPriority) PreExecute(u *core.Unit, cs []core.Candidater) (bool, []core.PredicateFailureReason, error) {
+	return o.Options.NumaFitPolicy != o.NUMA_FIT_POLICY_NONE, nil, nil
+}
+
+func (p *NumaPriority) Map(u *core.Unit, c core.Candidater) (core.HostPriority, error) {
+	h := priorities.NewPriorityHelper(p, u, c)
+
+	d := u.SchedData()
+	if score, ok := numaFitScore(c.Getter().NumaNodes(), int64(d.Ncpu), int64(d.Memory)); ok {
+		h.SetScore(score)
+	}
+	return h.GetResult()
+}
+
+// numaFitScore returns how many guests can be put in a single numa node,
+// or -1 if the guest has to be split although some node is large enough
+func numaFitScore(nodes []*core.NumaNodeResource, cpu, mem int64) (int, bool) {
+	var (
+		canHold  bool
+		capacity int64
+	)
+	for _, node := range nodes {
+		if !node.CanHold(cpu, mem) {
+			continue
+		}
+		canHold = true
+		capacity += node.Capacity(cpu, mem)
+	}
+	if !canHold {
+		return 0, false
+	}
+	if capacity == 0 {
+		return -1, true
+	}
+	return int(capacity), true
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package guest
+
+import (
+	"testing"
+
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+func TestNumaFitScore(t *testing.T) {
+	nodes := []*core.NumaNodeResource{
+		{NodeId: 0, TotalCPUCount: 16, FreeCPUCount: 2, TotalMemSize: 32768, FreeMemSize: 30000},
+		{NodeId: 1, TotalCPUCount: 16, FreeCPUCount: 10, TotalMemSize: 32768, FreeMemSize: 4096},
+	}
+	cases := []struct {
+		name      string
+		nodes     []*core.NumaNodeResource
+		cpu       int64
+		mem       int64
+		wantScore int
+		wantOk    bool
+	}{
+		{name: "no numa data", nodes: nil, cpu: 4, mem: 4096, wantOk: false},
+		{name: "larger than any node", nodes: nodes, cpu: 32, mem: 4096, wantOk: false},
+		{name: "fit node1", nodes: nodes, cpu: 4, mem: 4096, wantScore: 1, wantOk: true},
+		{name: "fit both nodes", nodes: nodes, cpu: 2, mem: 2048, wantScore: 3, wantOk: true},
+		{name: "split", nodes: nodes, cpu: 4, mem: 8192, wantScore: -1, wantOk: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			score, ok := numaFitScore(c.nodes, c.cpu, c.mem)
+			if ok != c.wantOk || score != c.wantScore {
+				t.Errorf("got (%d, %v), want (%d, %v)", score, ok, c.wantScore, c.wantOk)
+			}
+		})
+	}
+}
@@ -35,11 +35,14 @@ func defaultPredicates() sets.String {
 		factory.RegisterFitPredicate("d-GuestMigrateFilter", &predicateguest.MigratePredicate{}),
 		factory.RegisterFitPredicate("e-GuestDomainFilter", &predicates.DomainPredicate{}),
 		factory.RegisterFitPredicate("e-GuestImageFilter", &predicateguest.ImagePredicate{}),
+		factory.RegisterFitPredicate("e-GuestSecureBootFilter", &predicateguest.SecureBootPredicate{}),
 		factory.RegisterFitPredicate("f-ClassMetadataFilter", &predicates.ClassMetadataPredicate{}),
 		//factory.RegisterFitPredicate("f-GuestGroupFilter", &predicateguest.GroupPredicate{}),
 		factory.RegisterFitPredicate("g-GuestCPUFilter", &predicateguest.CPUPredicate{}),
 		factory.RegisterFitPredicate("h-GuestMemoryFilter", &predicateguest.MemoryPredicate{}),
+		factory.RegisterFitPredicate("h-GuestNumaFilter", &predicateguest.NumaPredicate{}),
 		factory.RegisterFitPredicate("i-GuestStorageFilter", &predicateguest.StoragePredicate{}),
+		factory.RegisterFitPredicate("i-GuestFileShareFilter", &predicateguest.FileSharePredicate{}),
 		factory.RegisterFitPredicate("j-GuestNetworkFilter", predicates.NewNetworkPredicateWithNicCounter()),
 		factory.RegisterFitPredicate("k-GuestIsolatedDeviceFilter", &predicates.IsolatedDevicePredicate{}),
 		factory.RegisterFitPredicate("l-GuestResourceTypeFilter", &predicates.ResourceTypePredicate{}),
@@ -57,7 +60,9 @@ func defaultPriorities() sets.String {
 	return sets.NewString(
 		factory.RegisterPriority("guest-avoid-same-host", &priorityguest.AvoidSameHostPriority{}, 1),
 		factory.RegisterPriority("guest-lowload", &priorityguest.LowLoadPriority{}, 1),
+		factory.RegisterPriority("guest-metrics-load", &priorityguest.MetricsLoadPriority{}, 1),
 		factory.RegisterPriority("guest-creating", &priorityguest.CreatingPriority{}, 1),
 		factory.RegisterPriority("guest-capacity", &priorityguest.CapacityPriority{}, 1),
+		factory.RegisterPriority("guest-numa-fit", &priorityguest.NumaPriority{}, 1),
 	)
 }
@@ -32,6 +32,7 @@ import (
 	"yunion.io/x/onecloud/pkg/scheduler/api"
 	"yunion.io/x/onecloud/pkg/scheduler/core"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudregion"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/netinterface"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/network"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/sku"
@@ -179,6 +180,10 @@ func (b baseHostGetter) OvnCapable() bool {
 	return false
 }
 
+func (b baseHostGetter) OvmfSecbootCapable() bool {
+	return false
+}
+
 func (b baseHostGetter) ResourceType() string {
 	return reviseResourceType(b.h.ResourceType)
 }
@@ -219,10 +224,22 @@ func (b baseHostGetter) RunningMemorySize() int64 {
 	return 0
 }
 
+func (b baseHostGetter) BalloonedMemorySize() int64 {
+	return 0
+}
+
 func (b baseHostGetter) TotalMemorySize(_ bool) int64 {
 	return int64(b.h.MemSize)
 }
 
+func (b baseHostGetter) HostMetrics() *hostmetrics.SHostMetrics {
+	return nil
+}
+
+func (b baseHostGetter) NumaNodes() []*core.NumaNodeResource {
+	return nil
+}
+
 func checkStorageSize(s *api.CandidateStorage, reqMaxSize int64, useRsvd bool) error {
 	storageSize := s.FreeCapacity
 	if useRsvd {
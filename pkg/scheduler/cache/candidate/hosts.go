@@ -16,9 +16,11 @@ package candidate
 
 import (
 	"encoding/json"
+	"strconv"
 	gosync "sync"
 	"time"
 
+	"yunion.io/x/jsonutils"
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/sets"
@@ -30,6 +32,7 @@ import (
 	"yunion.io/x/onecloud/pkg/compute/baremetal"
 	computemodels "yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/scheduler/core"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
 	o "yunion.io/x/onecloud/pkg/scheduler/options"
 )
 
@@ -69,10 +72,23 @@ func (h *hostGetter) RunningMemorySize() int64 {
 	return h.h.RunningMemSize
 }
 
+func (h *hostGetter) BalloonedMemorySize() int64 {
+	size, _ := strconv.ParseInt(h.h.Metadata[computeapi.HOSTMETA_GUEST_MEMORY_BALLOONED_MB], 10, 64)
+	return size
+}
+
 func (h *hostGetter) TotalMemorySize(useRsvd bool) int64 {
 	return h.h.GetTotalMemSize(useRsvd)
 }
 
+func (h *hostGetter) HostMetrics() *hostmetrics.SHostMetrics {
+	return h.h.Metrics
+}
+
+func (h *hostGetter) NumaNodes() []*core.NumaNodeResource {
+	return h.h.NumaNodes
+}
+
 func (h *hostGetter) IsEmpty() bool {
 	return h.h.GuestCount == 0
 }
@@ -93,6 +109,10 @@ func (h *hostGetter) OvnCapable() bool {
 	return len(h.h.OvnVersion) > 0
 }
 
+func (h *hostGetter) OvmfSecbootCapable() bool {
+	return h.h.Metadata[computeapi.HOSTMETA_OVMF_SECBOOT] == "true"
+}
+
 type HostDesc struct {
 	*BaseHostDesc
 
@@ -123,6 +143,12 @@ type HostDesc struct {
 	IOBoundCount int64    `json:"io_bound_count"`
 	IOLoad       *float64 `json:"io_load"`
 
+	// real utilization from tsdb
+	Metrics *hostmetrics.SHostMetrics `json:"metrics"`
+
+	// numa nodes reported by host ping
+	NumaNodes []*core.NumaNodeResource `json:"numa_nodes"`
+
 	// server
 	GuestCount         int64 `json:"guest_count"`
 	CreatingGuestCount int64 `json:"creating_guest_count"`
@@ -710,6 +736,8 @@ func (b *HostBuilder) BuildOne(host *computemodels.SHost, getter *networkGetter,
 		//b.fillResidentGroups,
 		b.fillMetadata,
 		b.fillCPUIOLoads,
+		b.fillHostMetrics,
+		b.fillNumaNodes,
 	}
 
 	for _, f := range fillFuncs {
@@ -945,6 +973,50 @@ func (b *HostBuilder) fillCPUIOLoads(desc *HostDesc, host *computemodels.SHost)
 	return nil
 }
 
+func (b *HostBuilder) fillHostMetrics(desc *HostDesc, host *computemodels.SHost) error {
+	desc.Metrics = hostmetrics.GetHostMetrics(host.Id)
+	return nil
+}
+
+func (b *HostBuilder) fillNumaNodes(desc *HostDesc, host *computemodels.SHost) error {
+	statsStr, ok := desc.Metadata[computeapi.HOSTMETA_NUMA_NODE_STATS]
+	if !ok || len(statsStr) == 0 {
+		return nil
+	}
+	obj, err := jsonutils.ParseString(statsStr)
+	if err != nil {
+		log.Errorf("Parse host %s numa node stats %q: %v", desc.GetId(), statsStr, err)
+		return nil
+	}
+	stats := make([]computeapi.SHostNumaNodeStat, 0)
+	if err := obj.Unmarshal(&stats); err != nil {
+		log.Errorf("Unmarshal host %s numa node stats: %v", desc.GetId(), err)
+		return nil
+	}
+	desc.NumaNodes = newNumaNodeResources(stats, desc.CPUCmtbound)
+	return nil
+}
+
+func newNumaNodeResources(stats []computeapi.SHostNumaNodeStat, cpuCmtbound float32) []*core.NumaNodeResource {
+	nodes := make([]*core.NumaNodeResource, 0, len(stats))
+	for _, stat := range stats {
+		node := &core.NumaNodeResource{
+			NodeId:        stat.NodeId,
+			TotalCPUCount: int64(float32(stat.CpuCount) * cpuCmtbound),
+			TotalMemSize:  int64(stat.MemSizeMb),
+			FreeMemSize:   int64(stat.FreeMemSizeMb),
+		}
+		node.FreeCPUCount = node.TotalCPUCount - int64(stat.VcpuCount)
+		if stat.HugepageSizeKb > 0 {
+			// guests memory is backed by hugepages only
+			node.TotalMemSize = int64(stat.HugepageNr) * int64(stat.HugepageSizeKb) / 1024
+			node.FreeMemSize = int64(stat.FreeHugepageNr) * int64(stat.HugepageSizeKb) / 1024
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
 func (b *HostBuilder) loadByName(hostID, name string) *float64 {
 	if b.cpuIOLoads == nil {
 		return nil
@@ -29,6 +29,7 @@ import (
 	computemodels "yunion.io/x/onecloud/pkg/compute/models"
 	"yunion.io/x/onecloud/pkg/scheduler/api"
 	"yunion.io/x/onecloud/pkg/scheduler/core/score"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/sku"
 	schedmodels "yunion.io/x/onecloud/pkg/scheduler/models"
 )
@@ -79,6 +80,8 @@ type CandidatePropertyGetter interface {
 	Storages() []*api.CandidateStorage
 	Networks() []*api.CandidateNetwork
 	OvnCapable() bool
+	// OvmfSecbootCapable reports whether host could boot guest with UEFI Secure Boot
+	OvmfSecbootCapable() bool
 	Status() string
 	HostStatus() string
 	Enabled() bool
@@ -95,9 +98,16 @@ type CandidatePropertyGetter interface {
 	FreeCPUCount(useRsvd bool) int64
 
 	RunningMemorySize() int64
+	// BalloonedMemorySize is the memory reclaimed from running guests by balloon
+	BalloonedMemorySize() int64
 	TotalMemorySize(useRsvd bool) int64
 	FreeMemorySize(useRsvd bool) int64
 
+	// HostMetrics is the real utilization of host collected from tsdb
+	HostMetrics() *hostmetrics.SHostMetrics
+	// NumaNodes is the schedulable resource of each host numa node
+	NumaNodes() []*NumaNodeResource
+
 	StorageInfo() []*baremetal.BaremetalStorage
 	GetFreeStorageSizeOfType(storageType string, mediumType string, useRsvd bool, reqMaxSize int64) (int64, int64, error)
 
@@ -222,6 +232,39 @@ type Priority interface {
 	ScoreIntervals() score.Intervals
 }
 
+// NumaNodeResource is cpu and memory of a host numa node,
+// cpu count is overcommitted while memory is not because remote memory access is what we try to avoid
+type NumaNodeResource struct {
+	NodeId        int   `json:"node_id"`
+	TotalCPUCount int64 `json:"total_cpu_count"`
+	FreeCPUCount  int64 `json:"free_cpu_count"`
+	TotalMemSize  int64 `json:"total_mem_size"`
+	FreeMemSize   int64 `json:"free_mem_size"`
+}
+
+// CanHold reports whether the node is large enough to hold the request when it is empty
+func (n *NumaNodeResource) CanHold(cpu, mem int64) bool {
+	return cpu <= n.TotalCPUCount && mem <= n.TotalMemSize
+}
+
+// Capacity is how many guests of the request can be put in this node
+func (n *NumaNodeResource) Capacity(cpu, mem int64) int64 {
+	capacity := int64(-1)
+	if cpu > 0 {
+		capacity = n.FreeCPUCount / cpu
+	}
+	if mem > 0 {
+		memCapacity := n.FreeMemSize / mem
+		if capacity < 0 || memCapacity < capacity {
+			capacity = memCapacity
+		}
+	}
+	if capacity < 0 {
+		return 0
+	}
+	return capacity
+}
+
 type AllocatedResource struct {
 	Disks []*schedapi.CandidateDiskV2 `json:"disks"`
 	Nets  []*schedapi.CandidateNet    `json:"nets"`
@@ -0,0 +1,321 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/wait"
+
+	"yunion.io/x/onecloud/pkg/apis"
+	identity_api "yunion.io/x/onecloud/pkg/apis/identity"
+	monitor_api "yunion.io/x/onecloud/pkg/apis/monitor"
+	"yunion.io/x/onecloud/pkg/mcclient/auth"
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+const (
+	HOST_ID_TAG = "host_id"
+
+	// values of series are averaged in buckets of the interval, values of
+	// all devices of a host are then summed in each bucket
+	METRICS_INTERVAL   = "1m"
+	METRICS_PERCENTILE = 95
+)
+
+// SHostMetrics is the p95 utilization of a host over the query time range,
+// UpdatedAt is the time of the latest data point reported by the host
+type SHostMetrics struct {
+	HostId string `json:"host_id"`
+
+	// cpu usage_active percent
+	CpuUsage float64 `json:"cpu_usage"`
+	// memory used_percent
+	MemUsage float64 `json:"mem_usage"`
+	// disk read and write bytes per second
+	DiskIOBps float64 `json:"disk_io_bps"`
+	// nic received and sent bits per second
+	NetBps float64 `json:"net_bps"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (m *SHostMetrics) IsStale(timeout time.Duration) bool {
+	if m == nil {
+		return true
+	}
+	return time.Since(m.UpdatedAt) > timeout
+}
+
+type sMetricField struct {
+	measurement string
+	field       string
+	tags        []monitor_api.MetricQueryTag
+	// tag of device, series of devices are summed as the host total
+	deviceTag string
+	apply     func(m *SHostMetrics, val float64)
+}
+
+var metricFields = []sMetricField{
+	{
+		measurement: "cpu",
+		field:       "usage_active",
+		tags: []monitor_api.MetricQueryTag{
+			{Key: "cpu", Operator: "=", Value: "cpu-total"},
+		},
+		apply: func(m *SHostMetrics, val float64) { m.CpuUsage = val },
+	},
+	{
+		measurement: "mem",
+		field:       "used_percent",
+		apply:       func(m *SHostMetrics, val float64) { m.MemUsage = val },
+	},
+	{
+		measurement: "diskio",
+		field:       "read_bps",
+		deviceTag:   "name",
+		apply:       func(m *SHostMetrics, val float64) { m.DiskIOBps += val },
+	},
+	{
+		measurement: "diskio",
+		field:       "write_bps",
+		deviceTag:   "name",
+		apply:       func(m *SHostMetrics, val float64) { m.DiskIOBps += val },
+	},
+	{
+		measurement: "net",
+		field:       "bps_recv",
+		deviceTag:   "interface",
+		apply:       func(m *SHostMetrics, val float64) { m.NetBps += val },
+	},
+	{
+		measurement: "net",
+		field:       "bps_sent",
+		deviceTag:   "interface",
+		apply:       func(m *SHostMetrics, val float64) { m.NetBps += val },
+	},
+}
+
+func (f sMetricField) refId() string {
+	return fmt.Sprintf("%s.%s", f.measurement, f.field)
+}
+
+func (f sMetricField) toQuery(ds *tsdb.DataSource) *tsdb.Query {
+	groupBy := []monitor_api.MetricQueryPart{
+		{Type: "time", Params: []string{METRICS_INTERVAL}},
+		{Type: "tag", Params: []string{HOST_ID_TAG}},
+	}
+	if len(f.deviceTag) > 0 {
+		groupBy = append(groupBy, monitor_api.MetricQueryPart{Type: "tag", Params: []string{f.deviceTag}})
+	}
+	groupBy = append(groupBy, monitor_api.MetricQueryPart{Type: "fill", Params: []string{"none"}})
+	return &tsdb.Query{
+		RefId:      f.refId(),
+		DataSource: *ds,
+		MetricQuery: monitor_api.MetricQuery{
+			Database:    ds.Database,
+			Measurement: f.measurement,
+			Tags:        f.tags,
+			Selects: []monitor_api.MetricQuerySelect{
+				monitor_api.NewMetricQuerySelect(
+					monitor_api.MetricQueryPart{Type: "field", Params: []string{f.field}},
+					monitor_api.MetricQueryPart{Type: "mean"},
+				),
+			},
+			GroupBy: groupBy,
+		},
+	}
+}
+
+// sHostPoints is the sum of values of all series of a host in each bucket
+type sHostPoints struct {
+	sums map[float64]float64
+	// timestamp in milliseconds of the latest valid point
+	lastTs float64
+}
+
+// sumHostPoints sums points of series by host and timestamp, series of one
+// host are of its devices, e.g. disks or nics
+func sumHostPoints(series tsdb.TimeSeriesSlice) map[string]*sHostPoints {
+	hosts := make(map[string]*sHostPoints)
+	for _, s := range series {
+		hostId := s.Tags[HOST_ID_TAG]
+		if len(hostId) == 0 {
+			continue
+		}
+		for _, point := range s.Points {
+			if !point.IsValid() {
+				continue
+			}
+			hp, ok := hosts[hostId]
+			if !ok {
+				hp = &sHostPoints{sums: make(map[float64]float64)}
+				hosts[hostId] = hp
+			}
+			ts := point.Timestamp()
+			hp.sums[ts] += point.Value()
+			if ts > hp.lastTs {
+				hp.lastTs = ts
+			}
+		}
+	}
+	return hosts
+}
+
+// percentile returns the nearest rank percentile of values, as influxdb does
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (hp *sHostPoints) percentile(p float64) float64 {
+	values := make([]float64, 0, len(hp.sums))
+	for _, val := range hp.sums {
+		values = append(values, val)
+	}
+	return percentile(values, p)
+}
+
+type DataSourceGetter func() (*tsdb.DataSource, error)
+
+// SHostMetricsManager caches host utilization metrics queried from tsdb
+type SHostMetricsManager struct {
+	lock    sync.RWMutex
+	metrics map[string]*SHostMetrics
+
+	refreshInterval time.Duration
+	timeRange       string
+
+	getDataSource DataSourceGetter
+	handleRequest tsdb.HandleRequestFunc
+}
+
+var Manager *SHostMetricsManager
+
+func NewHostMetricsManager(getDataSource DataSourceGetter, refreshInterval time.Duration, timeRange string) *SHostMetricsManager {
+	return &SHostMetricsManager{
+		metrics:         make(map[string]*SHostMetrics),
+		refreshInterval: refreshInterval,
+		timeRange:       timeRange,
+		getDataSource:   getDataSource,
+		handleRequest:   tsdb.HandleRequest,
+	}
+}
+
+// NewInfluxdbDataSourceGetter returns a getter that looks up the influxdb endpoint from service catalog
+func NewInfluxdbDataSourceGetter(region string, database string) DataSourceGetter {
+	return func() (*tsdb.DataSource, error) {
+		url, err := auth.GetServiceURL(apis.SERVICE_TYPE_INFLUXDB, region, "", identity_api.EndpointInterfaceInternal)
+		if err != nil {
+			return nil, errors.Wrap(err, "get influxdb service url")
+		}
+		return &tsdb.DataSource{
+			Id:       apis.SERVICE_TYPE_INFLUXDB,
+			Name:     apis.SERVICE_TYPE_INFLUXDB,
+			Type:     monitor_api.DataSourceTypeInfluxdb,
+			Url:      url,
+			Database: database,
+		}, nil
+	}
+}
+
+func Start(ctx context.Context, getDataSource DataSourceGetter, refreshInterval time.Duration, timeRange string) {
+	Manager = NewHostMetricsManager(getDataSource, refreshInterval, timeRange)
+	go Manager.sync(ctx)
+}
+
+// GetHostMetrics returns cached metrics of host, nil if not found or manager not started
+func GetHostMetrics(hostId string) *SHostMetrics {
+	if Manager == nil {
+		return nil
+	}
+	return Manager.Get(hostId)
+}
+
+func (m *SHostMetricsManager) sync(ctx context.Context) {
+	wait.Forever(func() {
+		if err := m.SyncOnce(ctx); err != nil {
+			log.Warningf("HostMetricsManager sync: %v", err)
+		}
+	}, m.refreshInterval)
+}
+
+func (m *SHostMetricsManager) Get(hostId string) *SHostMetrics {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.metrics[hostId]
+}
+
+func (m *SHostMetricsManager) SyncOnce(ctx context.Context) error {
+	ds, err := m.getDataSource()
+	if err != nil {
+		return errors.Wrap(err, "get data source")
+	}
+	startTime := time.Now()
+	metrics := make(map[string]*SHostMetrics)
+	for _, field := range metricFields {
+		req := &tsdb.TsdbQuery{
+			TimeRange: tsdb.NewTimeRange(fmt.Sprintf("now-%s", m.timeRange), "now"),
+			Queries:   []*tsdb.Query{field.toQuery(ds)},
+		}
+		resp, err := m.handleRequest(ctx, ds, req)
+		if err != nil {
+			return errors.Wrapf(err, "query %s", field.refId())
+		}
+		result, ok := resp.Results[field.refId()]
+		if !ok {
+			continue
+		}
+		if result.Error != nil {
+			return errors.Wrapf(result.Error, "result of %s", field.refId())
+		}
+		for hostId, hp := range sumHostPoints(result.Series) {
+			hm, ok := metrics[hostId]
+			if !ok {
+				hm = &SHostMetrics{HostId: hostId}
+				metrics[hostId] = hm
+			}
+			field.apply(hm, hp.percentile(METRICS_PERCENTILE))
+			if updatedAt := time.UnixMilli(int64(hp.lastTs)); updatedAt.After(hm.UpdatedAt) {
+				hm.UpdatedAt = updatedAt
+			}
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.metrics = metrics
+
+	log.Debugf("HostMetricsManager synced %d hosts, consume %s", len(metrics), time.Since(startTime))
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yunion.io/x/onecloud/pkg/monitor/tsdb"
+)
+
+const fakeDataSourceType = "fake_hostmetrics"
+
+type fakeSeries struct {
+	hostId string
+	device string
+	// value, timestamp in milliseconds pairs
+	points []float64
+}
+
+type fakeEndpoint struct {
+	// refId => series
+	series map[string][]fakeSeries
+}
+
+func (e *fakeEndpoint) Query(ctx context.Context, ds *tsdb.DataSource, query *tsdb.TsdbQuery) (*tsdb.Response, error) {
+	resp := &tsdb.Response{
+		Results: make(map[string]*tsdb.QueryResult),
+	}
+	for _, q := range query.Queries {
+		ret := tsdb.NewQueryResult()
+		ret.RefId = q.RefId
+		for _, fs := range e.series[q.RefId] {
+			series := tsdb.NewTimeSeries(q.Measurement, tsdb.NewTimeSeriesPointsFromArgs(fs.points...))
+			series.Tags = map[string]string{HOST_ID_TAG: fs.hostId, "name": fs.device}
+			ret.Series = append(ret.Series, series)
+		}
+		resp.Results[q.RefId] = ret
+	}
+	return resp, nil
+}
+
+func TestHostMetricsManagerSyncOnce(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	ts := func(minutesAgo int) float64 {
+		return float64(now.Add(-time.Duration(minutesAgo) * time.Minute).UnixMilli())
+	}
+	endpoint := &fakeEndpoint{
+		series: map[string][]fakeSeries{
+			"cpu.usage_active": {
+				{hostId: "host1", points: []float64{80, ts(1)}},
+				{hostId: "host2", points: []float64{10, ts(1)}},
+				// host3 stopped reporting 30 minutes ago
+				{hostId: "host3", points: []float64{90, ts(31), 95, ts(30)}},
+			},
+			"mem.used_percent": {
+				{hostId: "host1", points: []float64{60, ts(1)}},
+				{hostId: "host2", points: []float64{20, ts(1)}},
+			},
+			// host total of two disks, 300 at 2 minutes ago
+			"diskio.read_bps": {
+				{hostId: "host1", device: "sda", points: []float64{100, ts(2), 40, ts(1)}},
+				{hostId: "host1", device: "sdb", points: []float64{200, ts(2), 10, ts(1)}},
+			},
+			"diskio.write_bps": {
+				{hostId: "host1", device: "sda", points: []float64{50, ts(1)}},
+			},
+			"net.bps_recv": {
+				{hostId: "host2", device: "eth0", points: []float64{600, ts(1)}},
+				{hostId: "host2", device: "eth1", points: []float64{400, ts(1)}},
+			},
+			"net.bps_sent": {
+				{hostId: "host2", device: "eth0", points: []float64{24, ts(1)}},
+			},
+		},
+	}
+	tsdb.RegisterTsdbQueryEndpoint(fakeDataSourceType, func(*tsdb.DataSource) (tsdb.TsdbQueryEndpoint, error) {
+		return endpoint, nil
+	})
+	man := NewHostMetricsManager(func() (*tsdb.DataSource, error) {
+		return &tsdb.DataSource{Type: fakeDataSourceType, Database: "telegraf"}, nil
+	}, time.Minute, "15m")
+
+	if err := man.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("SyncOnce: %v", err)
+	}
+
+	cases := []struct {
+		hostId string
+		want   SHostMetrics
+		stale  bool
+	}{
+		{
+			hostId: "host1",
+			want:   SHostMetrics{CpuUsage: 80, MemUsage: 60, DiskIOBps: 350},
+		},
+		{
+			hostId: "host2",
+			want:   SHostMetrics{CpuUsage: 10, MemUsage: 20, NetBps: 1024},
+		},
+		{
+			hostId: "host3",
+			want:   SHostMetrics{CpuUsage: 95},
+			stale:  true,
+		},
+	}
+	for _, c := range cases {
+		got := man.Get(c.hostId)
+		if got == nil {
+			t.Errorf("host %s metrics not found", c.hostId)
+			continue
+		}
+		if got.CpuUsage != c.want.CpuUsage || got.MemUsage != c.want.MemUsage || got.DiskIOBps != c.want.DiskIOBps || got.NetBps != c.want.NetBps {
+			t.Errorf("host %s got %#v, want %#v", c.hostId, got, c.want)
+		}
+		if got.IsStale(5*time.Minute) != c.stale {
+			t.Errorf("host %s metrics updated at %s, want stale %v", c.hostId, got.UpdatedAt, c.stale)
+		}
+	}
+	if man.Get("host4") != nil {
+		t.Errorf("host4 should have no metrics")
+	}
+	if !man.Get("host4").IsStale(time.Minute) {
+		t.Errorf("nil metrics should be stale")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	seq := func(n int) []float64 {
+		values := make([]float64, n)
+		for i := range values {
+			values[n-1-i] = float64(i + 1)
+		}
+		return values
+	}
+	cases := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{name: "empty", want: 0},
+		{name: "single", values: []float64{3}, want: 3},
+		{name: "two", values: []float64{1, 2}, want: 2},
+		{name: "twenty", values: seq(20), want: 19},
+		{name: "hundred", values: seq(100), want: 95},
+	}
+	for _, c := range cases {
+		if got := percentile(c.values, METRICS_PERCENTILE); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
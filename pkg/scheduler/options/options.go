@@ -22,6 +22,12 @@ import (
 	"yunion.io/x/onecloud/pkg/compute/options"
 )
 
+const (
+	NUMA_FIT_POLICY_REQUIRE = "require"
+	NUMA_FIT_POLICY_PREFER  = "prefer"
+	NUMA_FIT_POLICY_NONE    = "none"
+)
+
 type SchedulerOptions struct {
 	options.ComputeOptions
 
@@ -90,6 +96,15 @@ type SchedOptions struct {
 
 	SkuRefreshInterval string `help:"Server SKU refresh interval" default:"12h"`
 
+	// host utilization metrics options
+	DefaultLoadPriority        string `help:"Default way to score host load" default:"commit" choices:"commit|metrics"`
+	HostMetricsDatabase        string `help:"TSDB database of host utilization metrics" default:"telegraf"`
+	HostMetricsRefreshInterval string `help:"Host utilization metrics refresh interval" default:"1m"`
+	HostMetricsTimeRange       string `help:"Time range used to calculate p95 of host utilization metrics" default:"15m"`
+	HostMetricsStaleTimeout    string `help:"Host utilization metrics older than this fallback to commit rate" default:"5m"`
+
+	NumaFitPolicy string `help:"How to place guest into a single host numa node, require filters out hosts, prefer only scores them" default:"prefer" choices:"require|prefer|none"`
+
 	OpenstackOptions
 }
 
@@ -38,10 +38,12 @@ import (
 	_ "yunion.io/x/onecloud/pkg/compute/guestdrivers"
 	_ "yunion.io/x/onecloud/pkg/compute/hostdrivers"
 	computemodels "yunion.io/x/onecloud/pkg/compute/models"
+	_ "yunion.io/x/onecloud/pkg/monitor/tsdb/driver/influxdb"
 	_ "yunion.io/x/onecloud/pkg/scheduler/algorithmprovider"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudaccount"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudprovider"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/cloudregion"
+	"yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/netinterface"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/network"
 	"yunion.io/x/onecloud/pkg/scheduler/data_manager/schedtag"
@@ -121,6 +123,10 @@ func StartService() error {
 			ctx := context.Background()
 			go skuman.Start(utils.ToDuration(o.Options.SkuRefreshInterval))
 			go schedtag.Start(ctx, utils.ToDuration("30s"))
+			hostmetrics.Start(ctx,
+				hostmetrics.NewInfluxdbDataSourceGetter(o.Options.Region, o.Options.HostMetricsDatabase),
+				utils.ToDuration(o.Options.HostMetricsRefreshInterval),
+				o.Options.HostMetricsTimeRange)
 
 			for _, f := range []func(ctx context.Context){
 				cloudregion.Manager.Start,
@@ -39,6 +39,7 @@ import (
 	models "yunion.io/x/onecloud/pkg/compute/models"
 	api "yunion.io/x/onecloud/pkg/scheduler/api"
 	core "yunion.io/x/onecloud/pkg/scheduler/core"
+	hostmetrics "yunion.io/x/onecloud/pkg/scheduler/data_manager/hostmetrics"
 	sku "yunion.io/x/onecloud/pkg/scheduler/data_manager/sku"
 	models0 "yunion.io/x/onecloud/pkg/scheduler/models"
 )
@@ -66,6 +67,20 @@ func (m *MockCandidatePropertyGetter) EXPECT() *MockCandidatePropertyGetterMockR
 	return m.recorder
 }
 
+// BalloonedMemorySize mocks base method
+func (m *MockCandidatePropertyGetter) BalloonedMemorySize() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BalloonedMemorySize")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// BalloonedMemorySize indicates an expected call of BalloonedMemorySize
+func (mr *MockCandidatePropertyGetterMockRecorder) BalloonedMemorySize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BalloonedMemorySize", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).BalloonedMemorySize))
+}
+
 // Cloudprovider mocks base method
 func (m *MockCandidatePropertyGetter) Cloudprovider() *models.SCloudprovider {
 	m.ctrl.T.Helper()
@@ -299,6 +314,20 @@ func (mr *MockCandidatePropertyGetterMockRecorder) Host() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Host", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Host))
 }
 
+// HostMetrics mocks base method
+func (m *MockCandidatePropertyGetter) HostMetrics() *hostmetrics.SHostMetrics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostMetrics")
+	ret0, _ := ret[0].(*hostmetrics.SHostMetrics)
+	return ret0
+}
+
+// HostMetrics indicates an expected call of HostMetrics
+func (mr *MockCandidatePropertyGetterMockRecorder) HostMetrics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostMetrics", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).HostMetrics))
+}
+
 // HostSchedtags mocks base method
 func (m *MockCandidatePropertyGetter) HostSchedtags() []models.SSchedtag {
 	m.ctrl.T.Helper()
@@ -468,6 +497,34 @@ func (mr *MockCandidatePropertyGetterMockRecorder) Networks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Networks", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).Networks))
 }
 
+// NumaNodes mocks base method
+func (m *MockCandidatePropertyGetter) NumaNodes() []*core.NumaNodeResource {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumaNodes")
+	ret0, _ := ret[0].([]*core.NumaNodeResource)
+	return ret0
+}
+
+// NumaNodes indicates an expected call of NumaNodes
+func (mr *MockCandidatePropertyGetterMockRecorder) NumaNodes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumaNodes", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).NumaNodes))
+}
+
+// OvmfSecbootCapable mocks base method
+func (m *MockCandidatePropertyGetter) OvmfSecbootCapable() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OvmfSecbootCapable")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// OvmfSecbootCapable indicates an expected call of OvmfSecbootCapable
+func (mr *MockCandidatePropertyGetterMockRecorder) OvmfSecbootCapable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OvmfSecbootCapable", reflect.TypeOf((*MockCandidatePropertyGetter)(nil).OvmfSecbootCapable))
+}
+
 // OvnCapable mocks base method
 func (m *MockCandidatePropertyGetter) OvnCapable() bool {
 	m.ctrl.T.Helper()
@@ -0,0 +1,279 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"yunion.io/x/pkg/utils"
+
+	"yunion.io/x/onecloud/pkg/apis/compute"
+	apisdu "yunion.io/x/onecloud/pkg/apis/scheduler"
+	"yunion.io/x/onecloud/pkg/compute/rebalancer"
+	"yunion.io/x/onecloud/pkg/scheduler/api"
+	"yunion.io/x/onecloud/pkg/scheduler/core"
+)
+
+// rebalanceScheduler answers migrate forecast with generic scheduler
+type rebalanceScheduler struct {
+	scheduler  *core.GenericScheduler
+	guests     map[string]*rebalancer.SGuest
+	candidates map[string]core.Candidater
+}
+
+func (s *rebalanceScheduler) CanMigrate(ctx context.Context, guestId string, targetHostId string) (bool, error) {
+	guest := s.guests[guestId]
+	info := &api.SchedInfo{
+		ScheduleInput: &apisdu.ScheduleInput{
+			ServerConfig: apisdu.ServerConfig{
+				ServerConfigs: &compute.ServerConfigs{
+					Hypervisor: compute.HYPERVISOR_KVM,
+					Count:      1,
+				},
+				Memory:  int(guest.VmemSizeMb),
+				Ncpu:    int(guest.VcpuCount),
+				Project: GlobalProject,
+				Domain:  GlobalDoamin,
+			},
+			LiveMigrate: true,
+		},
+	}
+	res, err := s.scheduler.Schedule(preSchedule(info, []core.Candidater{s.candidates[targetHostId]}, true))
+	if err != nil {
+		return false, err
+	}
+	return res.ForecastResult.CanCreate, nil
+}
+
+// fakeMigrator records migrations, which are executed concurrently
+type fakeMigrator struct {
+	lock     sync.Mutex
+	calls    []string
+	failures map[string]bool
+}
+
+func (m *fakeMigrator) LiveMigrate(ctx context.Context, guestId string, targetHostId string) error {
+	call := fmt.Sprintf("%s->%s", guestId, targetHostId)
+	m.lock.Lock()
+	m.calls = append(m.calls, call)
+	m.lock.Unlock()
+	if m.failures[call] {
+		return fmt.Errorf("migrate %s failed", call)
+	}
+	return nil
+}
+
+func buildRebalanceHosts() ([]*rebalancer.SHost, map[string]*rebalancer.SGuest) {
+	group := rebalancer.SInstanceGroup{Id: "group01", Granularity: 1, ForceDispersion: true}
+	newGuest := func(id string, cpu, mem int64, groups ...rebalancer.SInstanceGroup) *rebalancer.SGuest {
+		return &rebalancer.SGuest{Id: id, Name: id, VcpuCount: cpu, VmemSizeMb: mem, Movable: true, InstanceGroups: groups}
+	}
+	hosts := []*rebalancer.SHost{
+		{
+			Id: "host01",
+			Guests: []*rebalancer.SGuest{
+				newGuest("guest01", 4, 8192, group),
+				newGuest("guest02", 4, 8192, group),
+				newGuest("guest03", 4, 8192),
+				newGuest("guest04", 2, 4096),
+			},
+		},
+		{Id: "host02", Guests: []*rebalancer.SGuest{newGuest("guest05", 2, 4096, group)}},
+		{Id: "host03", Guests: []*rebalancer.SGuest{newGuest("guest06", 2, 4096)}},
+		{Id: "host04"},
+	}
+	guests := initRebalanceHosts(hosts)
+	// hosts without metrics
+	for _, h := range hosts {
+		h.SetCommitUsage()
+	}
+	return hosts, guests
+}
+
+func initRebalanceHosts(hosts []*rebalancer.SHost) map[string]*rebalancer.SGuest {
+	guests := make(map[string]*rebalancer.SGuest)
+	for _, h := range hosts {
+		h.Name = h.Id
+		h.CpuCount = 16
+		h.MemSizeMb = 32768
+		h.PhysicalCpuCount = 16
+		h.PhysicalMemSizeMb = 32768
+		for _, g := range h.Guests {
+			guests[g.Id] = g
+		}
+	}
+	return guests
+}
+
+func newRebalanceScheduler(t *testing.T, ctrl *gomock.Controller, hosts []*rebalancer.SHost, guests map[string]*rebalancer.SGuest, offlineHosts ...string) *rebalanceScheduler {
+	candidates := make(map[string]core.Candidater)
+	for _, h := range hosts {
+		param := sGetterParams{
+			HostId:          h.Id,
+			HostName:        h.Name,
+			Domain:          GlobalDoamin,
+			PublicScope:     "system",
+			Zone:            GlobalZone,
+			CloudRegion:     GlobalCloudregion,
+			HostType:        compute.HOST_TYPE_HYPERVISOR,
+			TotalCPUCount:   h.CpuCount,
+			FreeCPUCount:    h.CpuCount,
+			TotalMemorySize: h.MemSizeMb,
+			FreeMemorySize:  h.MemSizeMb,
+		}
+		if utils.IsInStringArray(h.Id, offlineHosts) {
+			param.HostStatus = compute.HOST_OFFLINE
+		}
+		candidates[h.Id] = buildCandidate(ctrl, param)
+	}
+	genericScheduler, err := core.NewGenericScheduler(buildScheduler(ctrl, nil, HostStatus, CPU, Memory))
+	if err != nil {
+		t.Fatalf("NewGenericScheduler: %v", err)
+	}
+	return &rebalanceScheduler{
+		scheduler:  genericScheduler,
+		guests:     guests,
+		candidates: candidates,
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hosts, guests := buildRebalanceHosts()
+	scheduler := newRebalanceScheduler(t, ctrl, hosts, guests, "host03")
+
+	plan, err := rebalancer.Plan(context.Background(), hosts, scheduler, rebalancer.SPlanOptions{Threshold: 10})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	assert := assert.New(t)
+	// guest02 can't go to host02 or host04 because of instance group, host03 is offline
+	assert.Equal([]string{"guest01->host04", "guest03->host02"}, planMoves(plan))
+	assert.InDelta(59.375, plan.ImbalanceBefore, 0.001)
+	assert.InDelta(9.375, plan.ImbalanceAfter, 0.001)
+
+	t.Run("max migrations", func(t *testing.T) {
+		hosts, _ := buildRebalanceHosts()
+		plan, err := rebalancer.Plan(context.Background(), hosts, scheduler, rebalancer.SPlanOptions{Threshold: 10, MaxMigrations: 1})
+		if err != nil {
+			t.Fatalf("Plan: %v", err)
+		}
+		assert.Equal([]string{"guest01->host04"}, planMoves(plan))
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		migrator := &fakeMigrator{}
+		err := rebalancer.Execute(context.Background(), copyPlan(plan), migrator, 2)
+		assert.NoError(err)
+		assert.ElementsMatch([]string{"guest01->host04", "guest03->host02"}, migrator.calls)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		migrator := &fakeMigrator{failures: map[string]bool{"guest03->host02": true}}
+		result := copyPlan(plan)
+		err := rebalancer.Execute(context.Background(), result, migrator, 1)
+		assert.Error(err)
+		assert.Equal([]string{"guest01->host04", "guest03->host02", "guest01->host01"}, migrator.calls)
+		assert.Equal(compute.REBALANCE_MIGRATION_ROLLED_BACK, result.Migrations[0].Status)
+		assert.Equal(compute.REBALANCE_MIGRATION_FAILED, result.Migrations[1].Status)
+	})
+}
+
+func TestRebalanceActualUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newGuest := func(id string) *rebalancer.SGuest {
+		return &rebalancer.SGuest{Id: id, Name: id, VcpuCount: 4, VmemSizeMb: 8192, Movable: true}
+	}
+	hosts := []*rebalancer.SHost{
+		{Id: "host01", Guests: []*rebalancer.SGuest{newGuest("guest01"), newGuest("guest02")}},
+		{Id: "host02", Guests: []*rebalancer.SGuest{newGuest("guest03"), newGuest("guest04")}},
+		{Id: "host03"},
+	}
+	guests := initRebalanceHosts(hosts)
+	// hosts commit the same, but guests of host01 are much busier
+	hosts[0].SetUsage(90, 50)
+	hosts[1].SetUsage(20, 50)
+	hosts[2].SetUsage(10, 10)
+	scheduler := newRebalanceScheduler(t, ctrl, hosts, guests)
+
+	plan, err := rebalancer.Plan(context.Background(), hosts, scheduler, rebalancer.SPlanOptions{Threshold: 10})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	assert := assert.New(t)
+	assert.Equal([]string{"guest01->host03"}, planMoves(plan))
+	assert.InDelta(40, plan.ImbalanceBefore, 0.001)
+	assert.InDelta(5, plan.ImbalanceAfter, 0.001)
+	assert.InDelta(7.2, guests["guest01"].CpuUsage, 0.001)
+}
+
+func TestRebalanceSoftAntiAffinity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	group := rebalancer.SInstanceGroup{Id: "group01", Granularity: 1}
+	newGuest := func(id string, cpu, mem int64, groups ...rebalancer.SInstanceGroup) *rebalancer.SGuest {
+		return &rebalancer.SGuest{Id: id, Name: id, VcpuCount: cpu, VmemSizeMb: mem, Movable: true, InstanceGroups: groups}
+	}
+	hosts := []*rebalancer.SHost{
+		{
+			Id: "host01",
+			Guests: []*rebalancer.SGuest{
+				newGuest("guest01", 4, 8192, group),
+				newGuest("guest02", 4, 8192),
+				newGuest("guest03", 4, 8192),
+			},
+		},
+		{Id: "host02", Guests: []*rebalancer.SGuest{newGuest("guest04", 1, 2048, group)}},
+		{Id: "host03", Guests: []*rebalancer.SGuest{newGuest("guest05", 2, 4096)}},
+	}
+	guests := initRebalanceHosts(hosts)
+	for _, h := range hosts {
+		h.SetCommitUsage()
+	}
+	scheduler := newRebalanceScheduler(t, ctrl, hosts, guests)
+
+	plan, err := rebalancer.Plan(context.Background(), hosts, scheduler, rebalancer.SPlanOptions{Threshold: 10})
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	// guest01 prefers busier host03 to host02 which holds guest of the same group
+	assert.Equal(t, []string{"guest01->host03", "guest02->host02"}, planMoves(plan))
+}
+
+func planMoves(plan *compute.RebalancePlan) []string {
+	moves := make([]string, 0, len(plan.Migrations))
+	for _, m := range plan.Migrations {
+		moves = append(moves, fmt.Sprintf("%s->%s", m.GuestId, m.TargetHostId))
+	}
+	return moves
+}
+
+func copyPlan(plan *compute.RebalancePlan) *compute.RebalancePlan {
+	ret := *plan
+	ret.Migrations = append([]compute.RebalanceMigration{}, plan.Migrations...)
+	return &ret
+}
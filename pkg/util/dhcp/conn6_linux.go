@@ -0,0 +1,181 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/mdlayher/raw"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// Conn6 receives DHCPv6 client messages and router solicitations from
+// guests on a bridge and answers them from the link local address of the
+// bridge.
+type Conn6 struct {
+	conn *raw.Conn
+
+	iface *net.Interface
+	ip    net.IP
+}
+
+func NewConn6(iface string) (*Conn6, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("interface by name: %v", err)
+	}
+
+	// ip6 and ((udp dst port 547) or (icmp6 and ip6[40] == 133)),
+	// ipv6 extension headers are not expected from guests
+	filter, err := bpf.Assemble([]bpf.Instruction{
+		// ether type
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd, SkipFalse: 9},
+		// ipv6 next header
+		bpf.LoadAbsolute{Off: 20, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.IPProtocolUDP), SkipFalse: 3},
+		// udp dport
+		bpf.LoadAbsolute{Off: 56, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: DHCPV6_SERVER_PORT, SkipFalse: 5},
+		bpf.RetConstant{Val: 0x40000},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.IPProtocolICMPv6), SkipFalse: 3},
+		// icmpv6 type
+		bpf.LoadAbsolute{Off: 54, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: layers.ICMPv6TypeRouterSolicitation, SkipFalse: 1},
+		bpf.RetConstant{Val: 0x40000},
+		bpf.RetConstant{Val: 0},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assemble bpf: %v", err)
+	}
+
+	conn, err := raw.ListenPacket(ifi, unix.ETH_P_IPV6, &raw.Config{
+		NoCumulativeStats: true,
+		Filter:            filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listen packet: %v", err)
+	}
+	return &Conn6{
+		conn:  conn,
+		iface: ifi,
+		ip:    interfaceToIPv6LinkLocalAddr(ifi),
+	}, nil
+}
+
+func interfaceToIPv6LinkLocalAddr(ifi *net.Interface) net.IP {
+	addrs, _ := ifi.Addrs()
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() == nil && ipnet.IP.IsLinkLocalUnicast() {
+			return ipnet.IP
+		}
+	}
+	return LinkLocalAddrFromMac(ifi.HardwareAddr)
+}
+
+func (c *Conn6) GetHardwareAddr() net.HardwareAddr {
+	return c.iface.HardwareAddr
+}
+
+func (c *Conn6) Close() error {
+	return c.conn.Close()
+}
+
+// Recv returns the decoded packet along with the source mac and ipv6 address
+func (c *Conn6) Recv(b []byte) (gopacket.Packet, net.HardwareAddr, net.IP, error) {
+	n, addr, err := c.conn.ReadFrom(b)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Read from errror: %s", err)
+	}
+	srcMac, err := net.ParseMAC(addr.String())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Parse mac error: %s", err)
+	}
+	p := gopacket.NewPacket(b[:n], layers.LayerTypeEthernet, gopacket.Default)
+	if p.ErrorLayer() != nil {
+		return nil, nil, nil, fmt.Errorf("Failed to decode packet: %v", p.ErrorLayer().Error())
+	}
+	ipLayer := p.Layer(layers.LayerTypeIPv6)
+	if ipLayer == nil {
+		return nil, nil, nil, fmt.Errorf("Fetch ipv6 layer failed")
+	}
+	return p, srcMac, ipLayer.(*layers.IPv6).SrcIP, nil
+}
+
+func (c *Conn6) send(dstIp net.IP, dstMac net.HardwareAddr, nextHeader layers.IPProtocol, hopLimit uint8, ls ...gopacket.SerializableLayer) error {
+	var eth = &layers.Ethernet{
+		EthernetType: layers.EthernetTypeIPv6,
+		SrcMAC:       c.iface.HardwareAddr,
+		DstMAC:       dstMac,
+	}
+	var ip = &layers.IPv6{
+		Version:    6,
+		HopLimit:   hopLimit,
+		SrcIP:      c.ip,
+		DstIP:      dstIp,
+		NextHeader: nextHeader,
+	}
+	for _, l := range ls {
+		switch v := l.(type) {
+		case *layers.UDP:
+			v.SetNetworkLayerForChecksum(ip)
+		case *layers.ICMPv6:
+			v.SetNetworkLayerForChecksum(ip)
+		}
+	}
+
+	var (
+		buf  = gopacket.NewSerializeBuffer()
+		opts = gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+	)
+	if err := gopacket.SerializeLayers(buf, opts, append([]gopacket.SerializableLayer{eth, ip}, ls...)...); err != nil {
+		return fmt.Errorf("SerializeLayers error: %s", err)
+	}
+	if _, err := c.conn.WriteTo(buf.Bytes(), &raw.Addr{HardwareAddr: dstMac}); err != nil {
+		return fmt.Errorf("Send packet error %s", err)
+	}
+	return nil
+}
+
+func (c *Conn6) SendDHCPv6(pkt *layers.DHCPv6, dstIp net.IP, dstMac net.HardwareAddr) error {
+	var udp = &layers.UDP{
+		SrcPort: DHCPV6_SERVER_PORT,
+		DstPort: DHCPV6_CLIENT_PORT,
+	}
+	return c.send(dstIp, dstMac, layers.IPProtocolUDP, 64, udp, pkt)
+}
+
+// SendRouterAdvertisement sends the ra to a single guest, the ip destination
+// may be the all nodes multicast address while the ethernet frame is still
+// unicast, so that guests of other networks on the bridge never see it.
+func (c *Conn6) SendRouterAdvertisement(ra *layers.ICMPv6RouterAdvertisement, dstIp net.IP, dstMac net.HardwareAddr) error {
+	var icmp = &layers.ICMPv6{
+		TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeRouterAdvertisement, 0),
+	}
+	// rfc4861 section 6.1.2, hop limit of neighbor discovery must be 255
+	return c.send(dstIp, dstMac, layers.IPProtocolICMPv6, 255, icmp, ra)
+}
+
+func (c *Conn6) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
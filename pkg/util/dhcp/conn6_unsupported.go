@@ -0,0 +1,59 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package dhcp
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var errConn6NotSupported = errors.New("raw socket Conn6 not supported on this OS")
+
+type Conn6 struct{}
+
+func NewConn6(iface string) (*Conn6, error) {
+	return nil, errConn6NotSupported
+}
+
+func (c *Conn6) GetHardwareAddr() net.HardwareAddr {
+	return nil
+}
+
+func (c *Conn6) Close() error {
+	return errConn6NotSupported
+}
+
+func (c *Conn6) Recv(b []byte) (gopacket.Packet, net.HardwareAddr, net.IP, error) {
+	return nil, nil, nil, errConn6NotSupported
+}
+
+func (c *Conn6) SendDHCPv6(pkt *layers.DHCPv6, dstIp net.IP, dstMac net.HardwareAddr) error {
+	return errConn6NotSupported
+}
+
+func (c *Conn6) SendRouterAdvertisement(ra *layers.ICMPv6RouterAdvertisement, dstIp net.IP, dstMac net.HardwareAddr) error {
+	return errConn6NotSupported
+}
+
+func (c *Conn6) SetReadDeadline(t time.Time) error {
+	return errConn6NotSupported
+}
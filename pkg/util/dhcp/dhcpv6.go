@@ -0,0 +1,199 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	DHCPV6_CLIENT_PORT = 546
+	DHCPV6_SERVER_PORT = 547
+)
+
+var (
+	// All_DHCP_Relay_Agents_and_Servers, rfc3315 section 5.1
+	AllDHCPv6RelayAgentsAndServers = net.ParseIP("ff02::1:2")
+	// All nodes on the link, rfc4291 section 2.7.1
+	AllNodesMulticast = net.ParseIP("ff02::1")
+)
+
+// https://datatracker.ietf.org/doc/html/rfc3315
+// https://datatracker.ietf.org/doc/html/rfc3646
+type ResponseConfig6 struct {
+	ServerMac         net.HardwareAddr // OptServerID 2, DUID-LL
+	ClientIP          net.IP           // OptIANA 3 / OptIAAddr 5
+	PreferredLifetime time.Duration
+	ValidLifetime     time.Duration
+	DNSServers        []net.IP // OptDNSServers 23
+	DomainSearch      []string // OptDomainList 24
+}
+
+func (conf *ResponseConfig6) GetServerDUID() []byte {
+	duid := &layers.DHCPv6DUID{
+		Type:             layers.DHCPv6DUIDTypeLL,
+		HardwareType:     []byte{0, 1},
+		LinkLayerAddress: conf.ServerMac,
+	}
+	return duid.Encode()
+}
+
+func GetDHCPv6Option(pkt *layers.DHCPv6, code layers.DHCPv6Opt) []byte {
+	for _, opt := range pkt.Options {
+		if opt.Code == code {
+			return opt.Data
+		}
+	}
+	return nil
+}
+
+func hasDHCPv6Option(pkt *layers.DHCPv6, code layers.DHCPv6Opt) bool {
+	for _, opt := range pkt.Options {
+		if opt.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeDHCPv6SubOption(code layers.DHCPv6Opt, data []byte) []byte {
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint16(buf[0:2], uint16(code))
+	binary.BigEndian.PutUint16(buf[2:4], uint16(len(data)))
+	copy(buf[4:], data)
+	return buf
+}
+
+func getDHCPv6StatusCode(code layers.DHCPv6StatusCode, msg string) []byte {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf[0:2], uint16(code))
+	copy(buf[2:], msg)
+	return buf
+}
+
+// getDHCPv6IANA builds an IA_NA option carrying a single IAAddr for the
+// IAID the client asked with, T1/T2 follow the rfc3315 recommendation.
+func getDHCPv6IANA(iaid []byte, conf *ResponseConfig6) []byte {
+	buf := make([]byte, 12)
+	copy(buf[0:4], iaid)
+	binary.BigEndian.PutUint32(buf[4:8], uint32(conf.PreferredLifetime/2/time.Second))
+	binary.BigEndian.PutUint32(buf[8:12], uint32(conf.PreferredLifetime*4/5/time.Second))
+
+	addr := make([]byte, 24)
+	copy(addr[0:16], conf.ClientIP.To16())
+	binary.BigEndian.PutUint32(addr[16:20], uint32(conf.PreferredLifetime/time.Second))
+	binary.BigEndian.PutUint32(addr[20:24], uint32(conf.ValidLifetime/time.Second))
+	return append(buf, encodeDHCPv6SubOption(layers.DHCPv6OptIAAddr, addr)...)
+}
+
+func GetOptIPv6s(ips []net.IP) []byte {
+	buf := make([]byte, 0)
+	for _, ip := range ips {
+		buf = append(buf, []byte(ip.To16())...)
+	}
+	return buf
+}
+
+// GetOptDomainList encodes search domains in DNS wire format, rfc1035 section 3.1
+func GetOptDomainList(domains []string) []byte {
+	buf := make([]byte, 0)
+	for _, domain := range domains {
+		for _, label := range strings.Split(strings.Trim(domain, "."), ".") {
+			if len(label) == 0 {
+				continue
+			}
+			buf = append(buf, byte(len(label)))
+			buf = append(buf, []byte(label)...)
+		}
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+func IsDHCPv6ClientPacket(pkt *layers.DHCPv6) bool {
+	switch pkt.MsgType {
+	case layers.DHCPv6MsgTypeSolicit, layers.DHCPv6MsgTypeRequest,
+		layers.DHCPv6MsgTypeConfirm, layers.DHCPv6MsgTypeRenew,
+		layers.DHCPv6MsgTypeRebind, layers.DHCPv6MsgTypeRelease,
+		layers.DHCPv6MsgTypeDecline, layers.DHCPv6MsgTypeInformationRequest:
+		return true
+	}
+	return false
+}
+
+// MakeDHCPv6ReplyPacket answers a client message with the address allocated
+// by the region, a nil packet means the message is not for this server.
+func MakeDHCPv6ReplyPacket(pkt *layers.DHCPv6, conf *ResponseConfig6) (*layers.DHCPv6, error) {
+	if !IsDHCPv6ClientPacket(pkt) {
+		return nil, errors.Wrapf(errors.ErrNotSupported, "dhcpv6 message type %s", pkt.MsgType)
+	}
+	serverId := conf.GetServerDUID()
+	if reqServerId := GetDHCPv6Option(pkt, layers.DHCPv6OptServerID); reqServerId != nil {
+		if !bytes.Equal(reqServerId, serverId) {
+			return nil, nil
+		}
+	}
+	clientId := GetDHCPv6Option(pkt, layers.DHCPv6OptClientID)
+	if clientId == nil && pkt.MsgType != layers.DHCPv6MsgTypeInformationRequest {
+		return nil, errors.Wrap(errors.ErrInvalidFormat, "dhcpv6 message without client id")
+	}
+
+	resp := &layers.DHCPv6{
+		MsgType:       layers.DHCPv6MsgTypeReply,
+		TransactionID: pkt.TransactionID,
+	}
+	if clientId != nil {
+		resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptClientID, clientId))
+	}
+	resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptServerID, serverId))
+
+	switch pkt.MsgType {
+	case layers.DHCPv6MsgTypeSolicit, layers.DHCPv6MsgTypeRequest,
+		layers.DHCPv6MsgTypeRenew, layers.DHCPv6MsgTypeRebind:
+		if pkt.MsgType == layers.DHCPv6MsgTypeSolicit {
+			if hasDHCPv6Option(pkt, layers.DHCPv6OptRapidCommit) {
+				resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptRapidCommit, nil))
+			} else {
+				resp.MsgType = layers.DHCPv6MsgTypeAdverstise
+			}
+		}
+		iana := GetDHCPv6Option(pkt, layers.DHCPv6OptIANA)
+		if len(iana) < 4 {
+			// only stateful IA_NA is served, answer the other options
+			break
+		}
+		resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptIANA, getDHCPv6IANA(iana[0:4], conf)))
+	case layers.DHCPv6MsgTypeConfirm, layers.DHCPv6MsgTypeRelease, layers.DHCPv6MsgTypeDecline:
+		resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptStatusCode,
+			getDHCPv6StatusCode(layers.DHCPv6StatusCodeSuccess, "success")))
+		return resp, nil
+	}
+
+	if len(conf.DNSServers) > 0 {
+		resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptDNSServers, GetOptIPv6s(conf.DNSServers)))
+	}
+	if len(conf.DomainSearch) > 0 {
+		resp.Options = append(resp.Options, layers.NewDHCPv6Option(layers.DHCPv6OptDomainList, GetOptDomainList(conf.DomainSearch)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func newTestResponseConfig6() *ResponseConfig6 {
+	mac, _ := net.ParseMAC("00:22:33:44:55:66")
+	return &ResponseConfig6{
+		ServerMac:         mac,
+		ClientIP:          net.ParseIP("fd00:1::10"),
+		PreferredLifetime: time.Hour,
+		ValidLifetime:     2 * time.Hour,
+		DNSServers:        []net.IP{net.ParseIP("fd00:1::53")},
+		DomainSearch:      []string{"cloud.local"},
+	}
+}
+
+func newTestDHCPv6Request(msgType layers.DHCPv6MsgType, opts ...layers.DHCPv6Option) *layers.DHCPv6 {
+	clientId := []byte{0, 3, 0, 1, 0, 0x22, 0x33, 0x44, 0x55, 0x77}
+	pkt := &layers.DHCPv6{
+		MsgType:       msgType,
+		TransactionID: []byte{1, 2, 3},
+		Options:       []layers.DHCPv6Option{layers.NewDHCPv6Option(layers.DHCPv6OptClientID, clientId)},
+	}
+	pkt.Options = append(pkt.Options, opts...)
+	return pkt
+}
+
+func TestMakeDHCPv6ReplyPacket(t *testing.T) {
+	conf := newTestResponseConfig6()
+	iana := layers.NewDHCPv6Option(layers.DHCPv6OptIANA, []byte{0, 0, 0, 9, 0, 0, 0, 0, 0, 0, 0, 0})
+
+	cases := []struct {
+		name    string
+		req     *layers.DHCPv6
+		msgType layers.DHCPv6MsgType
+		addr    bool
+	}{
+		{
+			name:    "solicit",
+			req:     newTestDHCPv6Request(layers.DHCPv6MsgTypeSolicit, iana),
+			msgType: layers.DHCPv6MsgTypeAdverstise,
+			addr:    true,
+		},
+		{
+			name:    "solicit rapid commit",
+			req:     newTestDHCPv6Request(layers.DHCPv6MsgTypeSolicit, iana, layers.NewDHCPv6Option(layers.DHCPv6OptRapidCommit, nil)),
+			msgType: layers.DHCPv6MsgTypeReply,
+			addr:    true,
+		},
+		{
+			name:    "request",
+			req:     newTestDHCPv6Request(layers.DHCPv6MsgTypeRequest, iana, layers.NewDHCPv6Option(layers.DHCPv6OptServerID, conf.GetServerDUID())),
+			msgType: layers.DHCPv6MsgTypeReply,
+			addr:    true,
+		},
+		{
+			name:    "information request",
+			req:     newTestDHCPv6Request(layers.DHCPv6MsgTypeInformationRequest),
+			msgType: layers.DHCPv6MsgTypeReply,
+		},
+	}
+	for _, c := range cases {
+		resp, err := MakeDHCPv6ReplyPacket(c.req, conf)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if resp.MsgType != c.msgType {
+			t.Errorf("%s: want msg type %s, got %s", c.name, c.msgType, resp.MsgType)
+		}
+		// serialize and decode to verify the option encoding
+		buf := gopacket.NewSerializeBuffer()
+		if err := resp.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+			t.Fatalf("%s: serialize %v", c.name, err)
+		}
+		decoded := &layers.DHCPv6{}
+		if err := decoded.DecodeFromBytes(buf.Bytes(), gopacket.NilDecodeFeedback); err != nil {
+			t.Fatalf("%s: decode %v", c.name, err)
+		}
+		ia := GetDHCPv6Option(decoded, layers.DHCPv6OptIANA)
+		if !c.addr {
+			if ia != nil {
+				t.Errorf("%s: unexpected IA_NA", c.name)
+			}
+		} else {
+			if len(ia) != 12+4+24 {
+				t.Fatalf("%s: invalid IA_NA length %d", c.name, len(ia))
+			}
+			if binary.BigEndian.Uint32(ia[0:4]) != 9 {
+				t.Errorf("%s: IAID not kept", c.name)
+			}
+			if ip := net.IP(ia[16:32]); !ip.Equal(conf.ClientIP) {
+				t.Errorf("%s: want address %s, got %s", c.name, conf.ClientIP, ip)
+			}
+		}
+		if dns := GetDHCPv6Option(decoded, layers.DHCPv6OptDNSServers); !net.IP(dns).Equal(conf.DNSServers[0]) {
+			t.Errorf("%s: invalid dns servers %v", c.name, dns)
+		}
+	}
+}
+
+func TestMakeDHCPv6ReplyPacketOtherServer(t *testing.T) {
+	conf := newTestResponseConfig6()
+	req := newTestDHCPv6Request(layers.DHCPv6MsgTypeRequest, layers.NewDHCPv6Option(layers.DHCPv6OptServerID, []byte{0, 3, 0, 1, 1, 2, 3, 4, 5, 6}))
+	resp, err := MakeDHCPv6ReplyPacket(req, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Errorf("request to other server should be ignored")
+	}
+}
+
+func TestGetOptDomainList(t *testing.T) {
+	want := []byte{5, 'c', 'l', 'o', 'u', 'd', 5, 'l', 'o', 'c', 'a', 'l', 0}
+	if err := compareBytes(GetOptDomainList([]string{"cloud.local."}), want); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMakeRouterAdvertisement(t *testing.T) {
+	mac, _ := net.ParseMAC("00:22:33:44:55:66")
+	ra := MakeRouterAdvertisement(&RouterAdvertisementConfig{
+		SourceMac:         mac,
+		Prefix:            net.ParseIP("fd00:1::10"),
+		PrefixLen:         64,
+		MTU:               1450,
+		Managed:           true,
+		Other:             true,
+		ValidLifetime:     2 * time.Hour,
+		PreferredLifetime: time.Hour,
+	})
+	if ra.Flags != RA_FLAG_MANAGED|RA_FLAG_OTHER {
+		t.Errorf("invalid flags %x", ra.Flags)
+	}
+	if ra.RouterLifetime != 0 {
+		t.Errorf("invalid router lifetime %d", ra.RouterLifetime)
+	}
+	for _, opt := range ra.Options {
+		if (len(opt.Data)+2)%8 != 0 {
+			t.Errorf("option %s not padded to 8 octets", opt.Type)
+		}
+		if opt.Type == layers.ICMPv6OptPrefixInfo {
+			if prefix := net.IP(opt.Data[14:30]); !prefix.Equal(net.ParseIP("fd00:1::")) {
+				t.Errorf("invalid prefix %s", prefix)
+			}
+			if opt.Data[1]&RA_PREFIX_FLAG_AUTONOMOUS != 0 {
+				t.Errorf("slaac should be disabled")
+			}
+		}
+	}
+}
+
+func TestLinkLocalAddrFromMac(t *testing.T) {
+	mac, _ := net.ParseMAC("00:22:33:44:55:66")
+	if ip := LinkLocalAddrFromMac(mac); ip.String() != "fe80::222:33ff:fe44:5566" {
+		t.Errorf("invalid link local address %s", ip)
+	}
+}
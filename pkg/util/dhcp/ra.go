@@ -0,0 +1,129 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// https://datatracker.ietf.org/doc/html/rfc4861#section-4.2
+	RA_FLAG_MANAGED = 0x80
+	RA_FLAG_OTHER   = 0x40
+
+	// https://datatracker.ietf.org/doc/html/rfc4861#section-4.6.2
+	RA_PREFIX_FLAG_ONLINK     = 0x80
+	RA_PREFIX_FLAG_AUTONOMOUS = 0x40
+
+	RA_DEFAULT_HOP_LIMIT = 64
+)
+
+// https://datatracker.ietf.org/doc/html/rfc4861#section-4.2
+type RouterAdvertisementConfig struct {
+	SourceMac net.HardwareAddr // OptSourceAddress 1
+	Prefix    net.IP           // OptPrefixInfo 3
+	PrefixLen uint8
+	MTU       uint32 // OptMTU 5
+
+	// Managed tells the guest to get its address from DHCPv6, Other to get
+	// dns and other config from DHCPv6
+	Managed bool
+	Other   bool
+	// Autonomous enables SLAAC on the advertised prefix
+	Autonomous bool
+
+	// RouterLifetime 0 means this node is not a default router
+	RouterLifetime    time.Duration
+	ValidLifetime     time.Duration
+	PreferredLifetime time.Duration
+}
+
+func getRAOptPrefixInfo(conf *RouterAdvertisementConfig) []byte {
+	// prefix length, flags, valid lifetime, preferred lifetime, reserved, prefix
+	buf := make([]byte, 30)
+	buf[0] = conf.PrefixLen
+	buf[1] = RA_PREFIX_FLAG_ONLINK
+	if conf.Autonomous {
+		buf[1] |= RA_PREFIX_FLAG_AUTONOMOUS
+	}
+	binary.BigEndian.PutUint32(buf[2:6], uint32(conf.ValidLifetime/time.Second))
+	binary.BigEndian.PutUint32(buf[6:10], uint32(conf.PreferredLifetime/time.Second))
+	prefix := conf.Prefix.To16().Mask(net.CIDRMask(int(conf.PrefixLen), 8*net.IPv6len))
+	copy(buf[14:30], prefix)
+	return buf
+}
+
+func getRAOptMTU(mtu uint32) []byte {
+	// 2 bytes reserved
+	buf := make([]byte, 6)
+	binary.BigEndian.PutUint32(buf[2:6], mtu)
+	return buf
+}
+
+func MakeRouterAdvertisement(conf *RouterAdvertisementConfig) *layers.ICMPv6RouterAdvertisement {
+	ra := &layers.ICMPv6RouterAdvertisement{
+		HopLimit:       RA_DEFAULT_HOP_LIMIT,
+		RouterLifetime: uint16(conf.RouterLifetime / time.Second),
+	}
+	if conf.Managed {
+		ra.Flags |= RA_FLAG_MANAGED
+	}
+	if conf.Other {
+		ra.Flags |= RA_FLAG_OTHER
+	}
+	if len(conf.SourceMac) > 0 {
+		ra.Options = append(ra.Options, layers.ICMPv6Option{
+			Type: layers.ICMPv6OptSourceAddress,
+			Data: []byte(conf.SourceMac),
+		})
+	}
+	if conf.MTU > 0 {
+		ra.Options = append(ra.Options, layers.ICMPv6Option{
+			Type: layers.ICMPv6OptMTU,
+			Data: getRAOptMTU(conf.MTU),
+		})
+	}
+	if len(conf.Prefix) > 0 && conf.PrefixLen > 0 {
+		ra.Options = append(ra.Options, layers.ICMPv6Option{
+			Type: layers.ICMPv6OptPrefixInfo,
+			Data: getRAOptPrefixInfo(conf),
+		})
+	}
+	return ra
+}
+
+// LinkLocalAddrFromMac generates the modified EUI-64 link local address of
+// a mac address, rfc4291 appendix A
+func LinkLocalAddrFromMac(mac net.HardwareAddr) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfe
+	ip[1] = 0x80
+	if len(mac) != 6 {
+		return ip
+	}
+	ip[8] = mac[0] ^ 0x02
+	ip[9] = mac[1]
+	ip[10] = mac[2]
+	ip[11] = 0xff
+	ip[12] = 0xfe
+	ip[13] = mac[3]
+	ip[14] = mac[4]
+	ip[15] = mac[5]
+	return ip
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"runtime/debug"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"yunion.io/x/log"
+)
+
+type DHCP6Server struct {
+	conn *Conn6
+}
+
+// raw socket
+func NewDHCP6Server(iface string) (*DHCP6Server, error) {
+	conn, err := NewConn6(iface)
+	if err != nil {
+		return nil, fmt.Errorf("New DHCPv6 connection error: %v", err)
+	}
+	return &DHCP6Server{
+		conn: conn,
+	}, nil
+}
+
+type DHCP6Handler interface {
+	ServeDHCPv6(pkt *layers.DHCPv6, cliMac net.HardwareAddr, srvMac net.HardwareAddr) (*layers.DHCPv6, error)
+	ServeRouterSolicitation(cliMac net.HardwareAddr, srvMac net.HardwareAddr) (*layers.ICMPv6RouterAdvertisement, error)
+}
+
+func (s *DHCP6Server) ListenAndServe(handler DHCP6Handler) error {
+	defer s.conn.Close()
+	return s.serve(handler)
+}
+
+func (s *DHCP6Server) serve(handler DHCP6Handler) error {
+	for {
+		buf := make([]byte, 1500)
+		pkt, mac, srcIp, err := s.conn.Recv(buf)
+		if err != nil {
+			log.Errorf("Receiving DHCPv6 packet: %s", err)
+			continue
+		}
+
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("Serve panic error: %v", r)
+					debug.PrintStack()
+				}
+			}()
+
+			s.servePacket(handler, pkt, mac, srcIp)
+		}()
+	}
+}
+
+func (s *DHCP6Server) servePacket(handler DHCP6Handler, pkt gopacket.Packet, mac net.HardwareAddr, srcIp net.IP) {
+	if l := pkt.Layer(layers.LayerTypeDHCPv6); l != nil {
+		resp, err := handler.ServeDHCPv6(l.(*layers.DHCPv6), mac, s.conn.GetHardwareAddr())
+		if err != nil {
+			log.Warningf("[DHCPv6] handler serve error: %v", err)
+			return
+		}
+		if resp == nil {
+			return
+		}
+		if err := s.conn.SendDHCPv6(resp, srcIp, mac); err != nil {
+			log.Errorf("[DHCPv6] failed to response packet for %s: %v", mac, err)
+		}
+		return
+	}
+	if l := pkt.Layer(layers.LayerTypeICMPv6RouterSolicitation); l != nil {
+		ra, err := handler.ServeRouterSolicitation(mac, s.conn.GetHardwareAddr())
+		if err != nil {
+			log.Warningf("[RA] handler serve error: %v", err)
+			return
+		}
+		if ra == nil {
+			return
+		}
+		dstIp := srcIp
+		if dstIp.IsUnspecified() {
+			dstIp = AllNodesMulticast
+		}
+		if err := s.conn.SendRouterAdvertisement(ra, dstIp, mac); err != nil {
+			log.Errorf("[RA] failed to response packet for %s: %v", mac, err)
+		}
+	}
+}
@@ -234,6 +234,7 @@ const (
 	ACT_RECOVERY = "recovery"
 	ACT_PACK     = "pack"
 	ACT_UNPACK   = "unpack"
+	ACT_VERIFY   = "verify"
 
 	ACT_SYNC_CLASS_METADATA = "sync_class_metadata"
 
@@ -254,15 +255,22 @@ const (
 	ACT_CANCEL = "cancel"
 	ACT_START  = "start"
 	ACT_DONE   = "done"
+	ACT_PAUSE  = "pause"
+	ACT_RESUME = "resume"
 
 	ACT_ASSOCIATE  = "associate"
 	ACT_DISSOCIATE = "dissociate"
 
 	ACT_BIND     = "bind"
+	ACT_UNBIND   = "unbind"
 	ACT_PROGRESS = "progress"
 
 	ACT_ADD_BASTION_SERVER = "add_bastion_server"
 
+	ACT_DR_TEST_FAILOVER = "dr_test_failover"
+	ACT_DR_FAILOVER      = "dr_failover"
+	ACT_DR_FAILBACK      = "dr_failback"
+
 	ACT_SYNC_TRAFFIC_LIMIT = "sync_traffic_limit"
 
 	ACT_GENERATE_REPORT     = "generate_report"
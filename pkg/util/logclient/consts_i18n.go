@@ -1341,6 +1341,11 @@ func init() {
 		CN("导入主机"),
 	)
 
+	o.Set(ACT_VERIFY, i18n.NewTableEntry().
+		EN("Verify backup").
+		CN("备份校验"),
+	)
+
 	o.Set(ACT_ENCRYPTION, i18n.NewTableEntry().
 		EN("Encryption").
 		CN("加密"),
@@ -1396,11 +1401,31 @@ func init() {
 		CN("关联"),
 	)
 
+	o.Set(ACT_UNBIND, i18n.NewTableEntry().
+		EN("Unbind").
+		CN("解除关联"),
+	)
+
 	o.Set(ACT_PROGRESS, i18n.NewTableEntry().
 		EN("Progress").
 		CN("进展"),
 	)
 
+	o.Set(ACT_DR_TEST_FAILOVER, i18n.NewTableEntry().
+		EN("DR Test Failover").
+		CN("容灾演练"),
+	)
+
+	o.Set(ACT_DR_FAILOVER, i18n.NewTableEntry().
+		EN("DR Failover").
+		CN("容灾切换"),
+	)
+
+	o.Set(ACT_DR_FAILBACK, i18n.NewTableEntry().
+		EN("DR Failback").
+		CN("容灾回切"),
+	)
+
 	o.Set(ACT_ADD_BASTION_SERVER, i18n.NewTableEntry().
 		EN("Add Bastionhost Server").
 		CN("添加实例到堡垒机"),
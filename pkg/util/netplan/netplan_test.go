@@ -99,3 +99,12 @@ network:
 	assert := assert.New(t)
 	assert.YAMLEq(yamlStr, c.YAMLString())
 }
+
+func TestDualStackEthernetConfig(t *testing.T) {
+	c := NewDHCP4EthernetConfig()
+	c.DHCP6 = true
+	c.Gateway6 = "fd00:1::1"
+
+	assert := assert.New(t)
+	assert.YAMLEq("dhcp4: true\ndhcp6: true\ngateway6: fd00:1::1", c.YAMLString())
+}
@@ -19,6 +19,7 @@ import (
 
 	"yunion.io/x/pkg/util/secrules"
 
+	computeapis "yunion.io/x/onecloud/pkg/apis/compute"
 	compute_models "yunion.io/x/onecloud/pkg/compute/models"
 )
 
@@ -57,12 +58,40 @@ func (el *SecurityGroup) securityGroupRules(basePriority int64) []*SecurityGroup
 	rs := make([]*SecurityGroupRule, 0, len(el.SecurityGroupRules))
 	for _, r := range el.SecurityGroupRules {
 		r = r.Copy()
+		r.SecurityGroup = el
 		r.Priority += basePriority
 		rs = append(rs, r)
 	}
 	return rs
 }
 
+// FlowLogDefaultDeny tells whether traffic dropped by the implicit deny rule
+// should be logged.  It's the case when any attached security group logs
+// denied traffic
+func (el *Guest) FlowLogDefaultDeny() bool {
+	for _, secgroup := range el.SecurityGroups {
+		if secgroup.FlowLogVerdict(string(secrules.SecurityRuleDeny)) {
+			return true
+		}
+	}
+	if el.AdminSecurityGroup != nil {
+		return el.AdminSecurityGroup.FlowLogVerdict(string(secrules.SecurityRuleDeny))
+	}
+	return false
+}
+
+// FlowLogVerdict tells whether traffic hit by rules of the security group
+// with the action should be logged
+func (el *SecurityGroup) FlowLogVerdict(action string) bool {
+	switch el.FlowLog {
+	case computeapis.SECGROUP_FLOW_LOG_ALL:
+		return true
+	case computeapis.SECGROUP_FLOW_LOG_DENY:
+		return action == string(secrules.SecurityRuleDeny)
+	}
+	return false
+}
+
 func SecurityGroupRuleLessFunc(rs []*SecurityGroupRule) func(i, j int) bool {
 	return func(i, j int) bool {
 		return rs[i].Priority < rs[i].Priority
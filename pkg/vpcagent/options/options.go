@@ -40,9 +40,13 @@ type VpcAgentOptions struct {
 	// TODO: set this to true, becuase https://github.com/yunionio/cloudpods/issues/17273 is not fixed
 	FetchDataFromComputeService bool `default:"true"`
 
-	OvnWorkerCheckInterval int    `default:"180"`
-	OvnNorthDatabase       string `help:"address for accessing ovn north database.  Default to local unix socket"`
-	OvnUnderlayMtu         int    `help:"mtu of ovn underlay network" default:"1500"`
+	OvnWorkerCheckInterval    int    `default:"180"`
+	OvnWorkerFullSyncInterval int    `help:"interval in seconds of full mark and sweep of ovn north database, vpcs not changed are not reconciled by regular checks" default:"3600"`
+	OvnNorthDatabase          string `help:"address for accessing ovn north database.  Default to local unix socket"`
+	OvnUnderlayMtu            int    `help:"mtu of ovn underlay network" default:"1500"`
+	OvnEipGatewayChassis      string `help:"name of ovn chassis where the eip gateway runs, nat gateways of vpcs are centralized on it"`
+	OvnNbMonitor              bool   `help:"keep an in-memory copy of ovn north database with native ovsdb monitor instead of dumping it with ovn-nbctl on each run" default:"true"`
+	OvnAclLogRate             int    `help:"rate limit in packets per second of security group flow logs, shared by all guest ports" default:"100"`
 }
 
 type Options struct {
@@ -67,6 +71,13 @@ func (opts *Options) ValidateThenInit() error {
 	if opts.OvnWorkerCheckInterval <= 60 {
 		opts.OvnWorkerCheckInterval = 60
 	}
+	if opts.OvnWorkerFullSyncInterval < opts.OvnWorkerCheckInterval {
+		opts.OvnWorkerFullSyncInterval = opts.OvnWorkerCheckInterval
+	}
+
+	if opts.OvnAclLogRate <= 0 {
+		opts.OvnAclLogRate = 100
+	}
 
 	if opts.OvnUnderlayMtu <= 576 {
 		opts.OvnUnderlayMtu = 576
@@ -17,6 +17,7 @@ package ovn
 import (
 	apis "yunion.io/x/onecloud/pkg/apis/compute"
 	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/options"
 )
 
 func vpcHasDistgw(vpc *agentmodels.Vpc) bool {
@@ -42,3 +43,18 @@ func vpcHasEipgw(vpc *agentmodels.Vpc) bool {
 		return false
 	}
 }
+
+// vpcHasNatgw returns whether nat gateways of the vpc should be realized.
+//
+// NAT rules are centralized on the eip gateway chassis, so it must be
+// configured.  In eip-distgw mode guests without eip are routed to distgw,
+// which conflicts with snat, so only eip mode is supported
+func vpcHasNatgw(vpc *agentmodels.Vpc, opts *options.Options) bool {
+	if vpc.ExternalAccessMode != apis.VPC_EXTERNAL_ACCESS_MODE_EIP {
+		return false
+	}
+	if opts.OvnEipGatewayChassis == "" {
+		return false
+	}
+	return len(vpc.NatGateways) > 0
+}
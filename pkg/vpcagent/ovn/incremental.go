@@ -0,0 +1,238 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+const ovnNbEchoTimeout = 8 * time.Second
+
+type versionedModel interface {
+	GetUpdatedAt() time.Time
+	GetUpdateVersion() int
+}
+
+// vpcDigest records versions of models the realization of a vpc depends on.
+// Keys are in the form of "<kind>/<id>"
+type vpcDigest map[string]string
+
+func (d vpcDigest) add(kind, id string, m versionedModel) {
+	d[kind+"/"+id] = fmt.Sprintf("%s.%d", m.GetUpdatedAt(), m.GetUpdateVersion())
+}
+
+func (d vpcDigest) addSecgroup(secgroup *agentmodels.SecurityGroup) {
+	if secgroup == nil {
+		return
+	}
+	d.add("secgroup", secgroup.Id, secgroup)
+	for id, rule := range secgroup.SecurityGroupRules {
+		d.add("secgrouprule", id, rule)
+	}
+}
+
+func (d vpcDigest) addGuest(guest *agentmodels.Guest) {
+	d.add("guest", guest.Id, guest)
+	if guest.Host != nil {
+		d.add("host", guest.Host.Id, guest.Host)
+	}
+	for _, secgroup := range guest.SecurityGroups {
+		d.addSecgroup(secgroup)
+	}
+	d.addSecgroup(guest.AdminSecurityGroup)
+	for id, group := range guest.Groups {
+		d.add("group", id, group)
+		for key, groupnetwork := range group.Groupnetworks {
+			d.add("groupnetwork", key, groupnetwork)
+		}
+	}
+}
+
+func newVpcDigest(vpc *agentmodels.Vpc) vpcDigest {
+	d := vpcDigest{}
+	d.add("vpc", vpc.Id, vpc)
+	if vpc.RouteTable != nil {
+		d.add("routetable", vpc.RouteTable.Id, vpc.RouteTable)
+	}
+	for _, network := range vpc.Networks {
+		d.add("network", network.Id, network)
+		for key, guestnetwork := range network.Guestnetworks {
+			d.add("guestnetwork", key, guestnetwork)
+			if guestnetwork.Guest != nil {
+				d.addGuest(guestnetwork.Guest)
+			}
+			if eip := guestnetwork.Elasticip; eip != nil {
+				d.add("eip", eip.Id, eip)
+			}
+			for id, na := range guestnetwork.SubIPs {
+				d.add("networkaddress", id, na)
+			}
+		}
+		for key, groupnetwork := range network.Groupnetworks {
+			d.add("groupnetwork", key, groupnetwork)
+			if eip := groupnetwork.Elasticip; eip != nil {
+				d.add("eip", eip.Id, eip)
+			}
+			for _, guestnetwork := range groupnetwork.GetGuestNetworks() {
+				d.add("guestnetwork", fmt.Sprintf("%d", guestnetwork.RowId), guestnetwork)
+			}
+		}
+		for key, lbnetwork := range network.LoadbalancerNetworks {
+			d.add("lbnetwork", key, lbnetwork)
+			if eip := lbnetwork.Elasticip; eip != nil {
+				d.add("eip", eip.Id, eip)
+			}
+			for id, listener := range lbnetwork.LoadbalancerListeners {
+				d.add("lblistener", id, listener)
+				if acl := listener.LoadbalancerAcl; acl != nil {
+					d.add("lbacl", acl.Id, acl)
+				}
+			}
+		}
+	}
+	for id, natgw := range vpc.NatGateways {
+		d.add("natgw", id, natgw)
+		for id, sentry := range natgw.NatSEntries {
+			d.add("natsentry", id, sentry)
+		}
+		for id, dentry := range natgw.NatDEntries {
+			d.add("natdentry", id, dentry)
+		}
+	}
+	for id, peering := range vpc.VpcPeeringConnections {
+		d.add("peering", id, peering)
+	}
+	return d
+}
+
+// vpcDigests maps vpc id to its digest.  The entry with empty key is for
+// models realized across vpcs
+type vpcDigests map[string]vpcDigest
+
+func newVpcDigests(mss *agentmodels.ModelSets) vpcDigests {
+	ds := vpcDigests{}
+	for _, vpc := range mss.Vpcs {
+		if vpc.Id == apis.DEFAULT_VPC_ID {
+			continue
+		}
+		ds[vpc.Id] = newVpcDigest(vpc)
+	}
+	global := vpcDigest{}
+	for id, peering := range mss.VpcPeeringConnections {
+		global.add("peering", id, peering)
+	}
+	for id, dnsrecord := range mss.DnsRecords {
+		global.add("dnsrecord", id, dnsrecord)
+	}
+	ds[""] = global
+	return ds
+}
+
+// diff compares digests of last run with current ones.  It returns ids of
+// vpcs that have models added or changed.  When any model is gone, or those
+// realized across vpcs changed, full is true as only a full mark/sweep can
+// tell what to clean up
+func (ds vpcDigests) diff(dsNew vpcDigests) (dirty []string, full bool) {
+	for vpcId, d := range ds {
+		dNew, ok := dsNew[vpcId]
+		if !ok {
+			return nil, true
+		}
+		for k := range d {
+			if _, ok := dNew[k]; !ok {
+				return nil, true
+			}
+		}
+	}
+	for vpcId, dNew := range dsNew {
+		d, ok := ds[vpcId]
+		if !ok {
+			if vpcId == "" {
+				return nil, true
+			}
+			dirty = append(dirty, vpcId)
+			continue
+		}
+		for k, v := range dNew {
+			if d[k] != v {
+				if vpcId == "" {
+					return nil, true
+				}
+				dirty = append(dirty, vpcId)
+				break
+			}
+		}
+	}
+	sort.Strings(dirty)
+	return dirty, false
+}
+
+// LimitSweepToVpc adds logical switches and routers of the vpc and ports,
+// acls, qos rules, static routes, nat rules, dns records and load balancers
+// they refer to, plus dhcp options of vpc networks into scope of Sweep.
+// Once called, rows out of the scope are left alone by Sweep
+func (keeper *OVNNorthboundKeeper) LimitSweepToVpc(ctx context.Context, vpc *agentmodels.Vpc) {
+	db := &keeper.DB
+	lsNames := map[string]bool{
+		vpcExtLsName(vpc.Id):  true,
+		vpcHostLsName(vpc.Id): true,
+		vpcEipLsName(vpc.Id):  true,
+	}
+	dhcpRefs := map[string]bool{}
+	for _, network := range vpc.Networks {
+		lsNames[netLsName(network.Id)] = true
+		dhcpRefs[network.Id] = true
+		dhcpRefs[netDhcp6OptRef(network.Id)] = true
+	}
+	lrNames := map[string]bool{
+		vpcLrName(vpc.Id):    true,
+		vpcExtLrName(vpc.Id): true,
+	}
+
+	if keeper.sweepScope == nil {
+		keeper.sweepScope = map[string]bool{}
+	}
+	addScope := func(uuids ...[]string) {
+		for _, l := range uuids {
+			for _, uuid := range l {
+				keeper.sweepScope[uuid] = true
+			}
+		}
+	}
+	for i := range db.LogicalSwitch {
+		ls := &db.LogicalSwitch[i]
+		if lsNames[ls.Name] {
+			addScope([]string{ls.Uuid}, ls.Ports, ls.Acls, ls.QosRules, ls.DnsRecords, ls.LoadBalancer)
+		}
+	}
+	for i := range db.LogicalRouter {
+		lr := &db.LogicalRouter[i]
+		if lrNames[lr.Name] {
+			addScope([]string{lr.Uuid}, lr.Ports, lr.StaticRoutes, lr.Nat, lr.LoadBalancer)
+		}
+	}
+	for i := range db.DHCPOptions {
+		dhcpopts := &db.DHCPOptions[i]
+		if ref, ok := dhcpopts.GetExternalId(externalKeyOcRef); ok && dhcpRefs[ref] {
+			addScope([]string{dhcpopts.Uuid})
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+func newTestDigestModelSets() *agentmodels.ModelSets {
+	mss := agentmodels.NewModelSets()
+	for _, vpcId := range []string{"vpc0", "vpc1"} {
+		vpc := &agentmodels.Vpc{}
+		vpc.Id = vpcId
+		network := &agentmodels.Network{Vpc: vpc}
+		network.Id = "net-" + vpcId
+		vpc.Networks = agentmodels.Networks{network.Id: network}
+		mss.Vpcs[vpc.Id] = vpc
+	}
+	natgw := newTestNatGateway()
+	mss.Vpcs["vpc0"].NatGateways = natgw.Vpc.NatGateways
+	return mss
+}
+
+func TestVpcDigestsDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(mss *agentmodels.ModelSets)
+		dirty  []string
+		full   bool
+	}{
+		{
+			name:   "unchanged",
+			change: func(mss *agentmodels.ModelSets) {},
+		},
+		{
+			name: "network updated",
+			change: func(mss *agentmodels.ModelSets) {
+				mss.Vpcs["vpc1"].Networks["net-vpc1"].UpdateVersion += 1
+			},
+			dirty: []string{"vpc1"},
+		},
+		{
+			name: "dnat entry added",
+			change: func(mss *agentmodels.ModelSets) {
+				natgw := mss.Vpcs["vpc0"].NatGateways["natgw0"]
+				dnat := &agentmodels.NatDEntry{NatGateway: natgw}
+				dnat.Id = "dnat2"
+				natgw.NatDEntries[dnat.Id] = dnat
+			},
+			dirty: []string{"vpc0"},
+		},
+		{
+			name: "vpc added",
+			change: func(mss *agentmodels.ModelSets) {
+				vpc := &agentmodels.Vpc{}
+				vpc.Id = "vpc2"
+				mss.Vpcs[vpc.Id] = vpc
+			},
+			dirty: []string{"vpc2"},
+		},
+		{
+			name: "snat entry removed",
+			change: func(mss *agentmodels.ModelSets) {
+				delete(mss.Vpcs["vpc0"].NatGateways["natgw0"].NatSEntries, "snat1")
+			},
+			full: true,
+		},
+		{
+			name: "vpc removed",
+			change: func(mss *agentmodels.ModelSets) {
+				delete(mss.Vpcs, "vpc1")
+			},
+			full: true,
+		},
+		{
+			name: "dns record added",
+			change: func(mss *agentmodels.ModelSets) {
+				dnsrecord := &agentmodels.DnsRecord{}
+				dnsrecord.Id = "dns0"
+				mss.DnsRecords[dnsrecord.Id] = dnsrecord
+			},
+			full: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ds := newVpcDigests(newTestDigestModelSets())
+			mss := newTestDigestModelSets()
+			c.change(mss)
+			dirty, full := ds.diff(newVpcDigests(mss))
+			if full != c.full {
+				t.Fatalf("want full %v, got %v", c.full, full)
+			}
+			if !reflect.DeepEqual(dirty, c.dirty) {
+				t.Errorf("want dirty %v, got %v", c.dirty, dirty)
+			}
+		})
+	}
+}
+
+func TestLimitSweepToVpc(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("other vpc", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, natgwRecordedNbctlList)
+		vpc := &agentmodels.Vpc{}
+		vpc.Id = "vpc1"
+		keeper.Mark(ctx)
+		keeper.LimitSweepToVpc(ctx, vpc)
+		keeper.Sweep(ctx)
+		if len(recorder.writes) != 0 {
+			t.Errorf("rows of vpcs not reconciled should be kept, got %q", recorder.writes)
+		}
+	})
+
+	t.Run("natgw vpc", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, natgwRecordedNbctlList)
+		natgw := newTestNatGateway()
+		keeper.Mark(ctx)
+		keeper.LimitSweepToVpc(ctx, natgw.Vpc)
+		// the router is claimed by ClaimVpc
+		keeper.DB.LogicalRouter[0].SetExternalId(externalKeyOcVersion, "claimed")
+		keeper.ClaimNatGateway(ctx, natgw)
+		if len(recorder.writes) != 0 {
+			t.Fatalf("realized nat gateway should not be changed, got %q", recorder.writes)
+		}
+		keeper.Sweep(ctx)
+		if len(recorder.writes) != 1 {
+			t.Fatalf("want 1 write, got %q", recorder.writes)
+		}
+		cmd := strings.Join(recorder.writes[0], " ")
+		if want := "remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03"; !strings.Contains(cmd, want) {
+			t.Errorf("missing %s in\n%s", want, cmd)
+		}
+		// claimed load balancers are kept
+		if strings.Contains(cmd, "Load_Balancer") {
+			t.Errorf("unexpected load balancer sweep:\n%s", cmd)
+		}
+	})
+
+	t.Run("unclaimed load balancers", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, natgwRecordedNbctlList)
+		natgw := newTestNatGateway()
+		keeper.Mark(ctx)
+		keeper.LimitSweepToVpc(ctx, natgw.Vpc)
+		keeper.Sweep(ctx)
+		for _, want := range []string{
+			"destroy Load_Balancer 9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a10",
+			"destroy Load_Balancer 9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a11",
+		} {
+			if !recorder.hasWrite(want) {
+				t.Errorf("missing %s in %q", want, recorder.writes)
+			}
+		}
+	})
+
+	t.Run("dhcp options and dns", func(t *testing.T) {
+		lists := map[string]string{
+			"Logical_Switch": `{"data":[[["uuid","2e4a6c8e-1b3d-4f5a-9c7e-0d2f4a6b8c01"],["set",[]],["set",[["uuid","3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d01"],["uuid","3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d02"]]],["map",[]],["set",[]],"subnet/net0",["map",[]],["set",[]],["set",[]]]],"headings":["_uuid","acls","dns_records","external_ids","load_balancer","name","other_config","ports","qos_rules"]}`,
+			"DNS":            `{"data":[[["uuid","3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d01"],["map",[["oc-version","vpc0.1"]]],["map",[["guest0","192.168.0.5"]]]],[["uuid","3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d02"],["map",[]],["map",[["guest1","192.168.0.6"]]]]],"headings":["_uuid","external_ids","records"]}`,
+			"DHCP_Options":   `{"data":[[["uuid","4a6c8e0a-3d5f-4b7c-9e1a-2f4b6c8d0e01"],"192.168.0.0/24",["map",[["oc-ref","net0"]]],["map",[]]],[["uuid","4a6c8e0a-3d5f-4b7c-9e1a-2f4b6c8d0e02"],"192.168.9.0/24",["map",[["oc-ref","net9"]]],["map",[]]]],"headings":["_uuid","cidr","external_ids","options"]}`,
+		}
+		keeper, recorder := newTestKeeper(t, lists)
+		vpc := &agentmodels.Vpc{}
+		vpc.Id = "vpc0"
+		network := &agentmodels.Network{Vpc: vpc}
+		network.Id = "net0"
+		vpc.Networks = agentmodels.Networks{network.Id: network}
+		keeper.Mark(ctx)
+		keeper.LimitSweepToVpc(ctx, vpc)
+		// the switch is claimed by ClaimNetwork
+		keeper.DB.LogicalSwitch[0].SetExternalId(externalKeyOcVersion, "claimed")
+		keeper.Sweep(ctx)
+		for _, want := range []string{
+			"destroy DHCP_Options 4a6c8e0a-3d5f-4b7c-9e1a-2f4b6c8d0e01",
+			"destroy DNS 3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d01",
+			"destroy DNS 3f5b7d9f-2c4e-4a6b-8d0f-1e3a5b7c9d02",
+		} {
+			if !recorder.hasWrite(want) {
+				t.Errorf("missing %s in %q", want, recorder.writes)
+			}
+		}
+		if recorder.hasWrite("4a6c8e0a-3d5f-4b7c-9e1a-2f4b6c8d0e02") {
+			t.Errorf("dhcp options of other vpcs should be kept, got %q", recorder.writes)
+		}
+	})
+}
@@ -17,6 +17,7 @@ package ovn
 import (
 	"context"
 	"fmt"
+	"net"
 	"sort"
 	"strings"
 
@@ -39,16 +40,23 @@ import (
 const (
 	externalKeyOcVersion = "oc-version"
 	externalKeyOcRef     = "oc-ref"
+	externalKeyOcAclLog  = "oc-acl-log"
 )
 
 type OVNNorthboundKeeper struct {
 	DB  ovn_nb.OVNNorthbound
 	cli *ovnutil.OvnNbCtl
+
+	// ovsdb when set is used for applying sweep as a single native
+	// transaction
+	ovsdb *ovnutil.OvsdbClient
+	// sweepScope when set limits sweep to rows with these uuids
+	sweepScope map[string]bool
 }
 
-func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthboundKeeper, error) {
-	db := ovn_nb.OVNNorthbound{}
-	itbls := []types.ITable{
+// ovnNorthboundTables returns tables vpcagent manages
+func ovnNorthboundTables(db *ovn_nb.OVNNorthbound) []types.ITable {
+	return []types.ITable{
 		&db.LogicalSwitch,
 		&db.LogicalSwitchPort,
 		&db.LogicalRouter,
@@ -58,7 +66,27 @@ func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthbou
 		&db.DHCPOptions,
 		&db.QoS,
 		&db.DNS,
+		&db.NAT,
+		&db.LoadBalancer,
+		&db.Meter,
+		&db.MeterBand,
 	}
+}
+
+// OVNNorthboundTableNames returns names of tables vpcagent manages, for
+// setting up monitors
+func OVNNorthboundTableNames() []string {
+	itbls := ovnNorthboundTables(&ovn_nb.OVNNorthbound{})
+	r := make([]string, len(itbls))
+	for i, itbl := range itbls {
+		r[i] = itbl.OvsdbTableName()
+	}
+	return r
+}
+
+func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthboundKeeper, error) {
+	db := ovn_nb.OVNNorthbound{}
+	itbls := ovnNorthboundTables(&db)
 	args := []string{"--format=json", "list", "<tbl>"}
 	for _, itbl := range itbls {
 		tbl := itbl.OvsdbTableName()
@@ -76,6 +104,21 @@ func DumpOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl) (*OVNNorthbou
 	return keeper, nil
 }
 
+// LoadOVNNorthbound makes a keeper from rows of the monitor cache instead of
+// listing every table with ovn-nbctl
+func LoadOVNNorthbound(ctx context.Context, cli *ovnutil.OvnNbCtl, ovsdb *ovnutil.OvsdbClient, cache *ovnutil.OvsdbCache) (*OVNNorthboundKeeper, error) {
+	db := ovn_nb.OVNNorthbound{}
+	if err := cache.Fill(ovnNorthboundTables(&db)...); err != nil {
+		return nil, errors.Wrap(err, "fill from ovsdb cache")
+	}
+	keeper := &OVNNorthboundKeeper{
+		DB:    db,
+		cli:   cli,
+		ovsdb: ovsdb,
+	}
+	return keeper, nil
+}
+
 func ptr(s string) *string {
 	return &s
 }
@@ -87,7 +130,7 @@ func ovnCreateArgs(irow types.IRow, idRef string) []string {
 	return args
 }
 
-func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodels.Vpc) error {
+func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodels.Vpc, opts *options.Options) error {
 	var (
 		args      []string
 		ocVersion = fmt.Sprintf("%s.%d", vpc.UpdatedAt, vpc.UpdateVersion)
@@ -207,6 +250,12 @@ func (keeper *OVNNorthboundKeeper) ClaimVpc(ctx context.Context, vpc *agentmodel
 			Mac:      apis.VpcEipGatewayMac,
 			Networks: []string{fmt.Sprintf("%s/%d", apis.VpcEipGatewayIP(), apis.VpcEipGatewayIPMask)},
 		}
+		if vpcHasNatgw(vpc, opts) {
+			// make it a distributed gateway port for nat rules
+			vpcRep.Options = map[string]string{
+				"redirect-chassis": opts.OvnEipGatewayChassis,
+			}
+		}
 		vpcErp = &ovn_nb.LogicalSwitchPort{
 			Name:      vpcErpName(vpc.Id),
 			Type:      "router",
@@ -364,18 +413,47 @@ func (keeper *OVNNorthboundKeeper) ClaimNetwork(ctx context.Context, network *ag
 		}
 	}
 
+	// dual-stack subnet, guests get their address allocated by region with
+	// stateful DHCPv6 and the default route from router advertisement
+	var dhcp6opts *ovn_nb.DHCPOptions
+	if network.IsSupportIPv6() && network.GuestGateway6 != "" {
+		netRnp.Networks = append(netRnp.Networks, fmt.Sprintf("%s/%d", network.GuestGateway6, network.GuestIp6Mask))
+		netRnp.Ipv6RaConfigs = map[string]string{
+			"address_mode":  "dhcpv6_stateful",
+			"send_periodic": "true",
+			"mtu":           fmt.Sprintf("%d", mtu),
+		}
+		prefix6 := net.ParseIP(network.GuestIp6Start).Mask(net.CIDRMask(int(network.GuestIp6Mask), 128))
+		dhcp6opts = &ovn_nb.DHCPOptions{
+			Cidr: fmt.Sprintf("%s/%d", prefix6, network.GuestIp6Mask),
+			Options: map[string]string{
+				"server_id": dhcpMac,
+			},
+			ExternalIds: map[string]string{
+				externalKeyOcRef: netDhcp6OptRef(network.Id),
+			},
+		}
+		if network.GuestDns6 != "" {
+			dhcp6opts.Options["dns_server"] = "{" + network.GuestDns6 + "}"
+		}
+	}
+
 	var (
 		args      []string
 		ocVersion = fmt.Sprintf("%s.%d", network.UpdatedAt, network.UpdateVersion)
 	)
-	allFound, args := cmp(&keeper.DB, ocVersion,
+	irows := []types.IRow{
 		netLs,
 		netRnp,
 		netNrp,
 		netMdp,
 		dhcpopts,
 		vpcExtBackRoute,
-	)
+	}
+	if dhcp6opts != nil {
+		irows = append(irows, dhcp6opts)
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, irows...)
 	if allFound {
 		return nil
 	}
@@ -384,6 +462,9 @@ func (keeper *OVNNorthboundKeeper) ClaimNetwork(ctx context.Context, network *ag
 	args = append(args, ovnCreateArgs(netNrp, netNrp.Name)...)
 	args = append(args, ovnCreateArgs(netMdp, netMdp.Name)...)
 	args = append(args, ovnCreateArgs(dhcpopts, "dhcpopts")...)
+	if dhcp6opts != nil {
+		args = append(args, ovnCreateArgs(dhcp6opts, "dhcp6opts")...)
+	}
 	args = append(args, ovnCreateArgs(vpcExtBackRoute, "vpcExtBackRoute")...)
 	args = append(args, "--", "add", "Logical_Switch", netLs.Name, "ports", "@"+netNrp.Name, "@"+netMdp.Name)
 	args = append(args, "--", "add", "Logical_Router", vpcLrName(vpc.Id), "ports", "@"+netRnp.Name)
@@ -464,6 +545,7 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 		ocQosRef        = fmt.Sprintf("qos/%s/%s/%s", network.Id, guestnetwork.GuestId, guestnetwork.Ifname)
 		ocQosEipRef     = fmt.Sprintf("qos-eip/%s/%s/%s/v2", vpc.Id, guestnetwork.GuestId, guestnetwork.Ifname)
 		dhcpOpt         string
+		dhcp6Opt        string
 	)
 
 	{
@@ -486,6 +568,26 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 			return fmt.Errorf("cannot find dhcpopt for subnet %s", guestnetwork.NetworkId)
 		}
 	}
+	if guestnetwork.Ip6Addr != "" && network.IsSupportIPv6() && network.GuestGateway6 != "" {
+		dhcp6OptQuery := &ovn_nb.DHCPOptions{
+			ExternalIds: map[string]string{
+				externalKeyOcRef: netDhcp6OptRef(guestnetwork.NetworkId),
+			},
+		}
+		if m := keeper.DB.DHCPOptions.FindOneMatchNonZeros(dhcp6OptQuery); m != nil {
+			dhcp6Opt = m.OvsdbUuid()
+		} else {
+			args := []string{
+				"--bare", "--columns=_uuid", "find", "DHCP_Options",
+				fmt.Sprintf("external_ids:%s=%q", externalKeyOcRef, netDhcp6OptRef(guestnetwork.NetworkId)),
+			}
+			res := keeper.cli.Must(ctx, "find dhcp6opt", args)
+			dhcp6Opt = strings.TrimSpace(res.Output)
+		}
+		if dhcp6Opt == "" {
+			return fmt.Errorf("cannot find dhcp6opt for subnet %s", guestnetwork.NetworkId)
+		}
+	}
 
 	var (
 		subIPs  = []string{guestnetwork.IpAddr}
@@ -498,12 +600,19 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	subIPms = append(subIPms, guestnetwork.Guest.GetVips()...)
 	sort.Strings(subIPs[1:])
 	sort.Strings(subIPms[1:])
+	if dhcp6Opt != "" {
+		subIPs = append(subIPs, guestnetwork.Ip6Addr)
+		subIPms = append(subIPms, fmt.Sprintf("%s/%d", guestnetwork.Ip6Addr, network.GuestIp6Mask))
+	}
 	gnp := &ovn_nb.LogicalSwitchPort{
 		Name:          lportName,
 		Addresses:     []string{fmt.Sprintf("%s %s", guestnetwork.MacAddr, strings.Join(subIPs, " "))},
 		Dhcpv4Options: &dhcpOpt,
 		Options:       map[string]string{},
 	}
+	if dhcp6Opt != "" {
+		gnp.Dhcpv6Options = &dhcp6Opt
+	}
 	if guest.SrcMacCheck.IsFalse() {
 		gnp.Addresses = append(gnp.Addresses, "unknown")
 		// empty, not nil, as match condition
@@ -622,6 +731,7 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	var acls []*ovn_nb.ACL
 	{
 		sgrs := guest.OrderedSecurityGroupRules()
+		logDefaultDeny := guest.FlowLogDefaultDeny()
 		for _, sgr := range sgrs {
 			// kvm not support peer secgroup
 			acl, err := ruleToAcl(lportName, sgr)
@@ -632,6 +742,9 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 			acl.ExternalIds = map[string]string{
 				externalKeyOcRef: ocAclRef,
 			}
+			if name, ok := ruleAclLogName(sgr, logDefaultDeny); ok {
+				aclSetLog(acl, name)
+			}
 			acls = append(acls, acl)
 		}
 	}
@@ -681,6 +794,172 @@ func (keeper *OVNNorthboundKeeper) ClaimGuestnetwork(ctx context.Context, guestn
 	return keeper.cli.Must(ctx, "ClaimGuestnetwork", args)
 }
 
+// ClaimAclLogMeter makes sure the meter rate limiting acl logs exists.  The
+// meter is shared by acls of all guest ports and is left alone by Sweep
+func (keeper *OVNNorthboundKeeper) ClaimAclLogMeter(ctx context.Context, opts *options.Options) error {
+	var (
+		unit = "pktps"
+		band = &ovn_nb.MeterBand{
+			Action: "drop",
+			Rate:   int64(opts.OvnAclLogRate),
+		}
+	)
+	if m := keeper.DB.Meter.GetByName(&ovn_nb.Meter{Name: aclLogMeterName}); m != nil {
+		if m.Unit == unit && len(m.Bands) == 1 {
+			b := keeper.DB.MeterBand.FindOneMatchNonZeros(&ovn_nb.MeterBand{Uuid: m.Bands[0]})
+			if b != nil && b.MatchNonZeros(band) {
+				return nil
+			}
+		}
+		// old bands are garbage collected once unreferenced
+		var args []string
+		args = append(args, ovnCreateArgs(band, "aclLogBand")...)
+		args = append(args, "--", "set", "Meter", m.Uuid, "unit="+unit, "bands=@aclLogBand")
+		return keeper.cli.Must(ctx, "ClaimAclLogMeter", args)
+	}
+	meter := &ovn_nb.Meter{
+		Name: aclLogMeterName,
+		Unit: unit,
+	}
+	var args []string
+	args = append(args, ovnCreateArgs(band, "aclLogBand")...)
+	args = append(args, ovnCreateArgs(meter, "aclLogMeter")...)
+	args = append(args, "bands=@aclLogBand")
+	return keeper.cli.Must(ctx, "ClaimAclLogMeter", args)
+}
+
+// ClaimNatGateway realizes snat and dnat entries of the nat gateway on the
+// vpc external router.  Snat entries become NAT rows, dnat entries become
+// Load_Balancer vips since the NAT table has no port.  Traffic from the
+// source cidrs and dnat backends is routed to the eip gateway
+func (keeper *OVNNorthboundKeeper) ClaimNatGateway(ctx context.Context, natgw *agentmodels.NatGateway) error {
+	var (
+		vpc      = natgw.Vpc
+		eipgwVip = apis.VpcEipGatewayIP3().String()
+
+		ocVersion     = fmt.Sprintf("%s.%d", natgw.UpdatedAt, natgw.UpdateVersion)
+		ocNatRef      = fmt.Sprintf("nat/%s", natgw.Id)
+		ocNatRouteRef = fmt.Sprintf("natRoute/%s", natgw.Id)
+	)
+
+	var (
+		nats      []*ovn_nb.NAT
+		routes    []*ovn_nb.LogicalRouterStaticRoute
+		lbs       []*ovn_nb.LoadBalancer
+		snatCidrs []string
+	)
+	newRoute := func(prefix string) *ovn_nb.LogicalRouterStaticRoute {
+		return &ovn_nb.LogicalRouterStaticRoute{
+			Policy:     ptr("src-ip"),
+			IpPrefix:   prefix,
+			Nexthop:    eipgwVip,
+			OutputPort: ptr(vpcRepName(vpc.Id)),
+			ExternalIds: map[string]string{
+				externalKeyOcRef: ocNatRouteRef,
+			},
+		}
+	}
+	for _, snat := range natgw.OrderedNatSEntries() {
+		cidr, err := natSEntryLogicalIp(snat)
+		if err != nil {
+			log.Errorf("natgateway %s(%s) snat entry %s: %v", natgw.Name, natgw.Id, snat.Id, err)
+			continue
+		}
+		nats = append(nats, &ovn_nb.NAT{
+			Type:       "snat",
+			ExternalIp: snat.IP,
+			LogicalIp:  cidr,
+			ExternalIds: map[string]string{
+				externalKeyOcRef: ocNatRef,
+			},
+		})
+		if !utils.IsInStringArray(cidr, snatCidrs) {
+			snatCidrs = append(snatCidrs, cidr)
+			routes = append(routes, newRoute(cidr))
+		}
+	}
+
+	var (
+		protoVips = map[string]map[string]string{}
+		dnatIps   []string
+		vipsCount int
+	)
+	for _, dnat := range natgw.OrderedNatDEntries() {
+		proto, vips, err := natDEntryVips(dnat)
+		if err != nil {
+			log.Errorf("natgateway %s(%s) dnat entry %s: %v", natgw.Name, natgw.Id, dnat.Id, err)
+			continue
+		}
+		if vipsCount+len(vips) > natgwVipsMax {
+			log.Errorf("natgateway %s(%s) dnat entry %s: vips exceed %d", natgw.Name, natgw.Id, dnat.Id, natgwVipsMax)
+			continue
+		}
+		vipsCount += len(vips)
+		if protoVips[proto] == nil {
+			protoVips[proto] = map[string]string{}
+		}
+		for vip, backend := range vips {
+			protoVips[proto][vip] = backend
+		}
+		dnatIps = append(dnatIps, dnat.InternalIP)
+	}
+	for _, proto := range []string{"tcp", "udp"} {
+		vips, ok := protoVips[proto]
+		if !ok {
+			continue
+		}
+		lbs = append(lbs, &ovn_nb.LoadBalancer{
+			Name:     natLbName(natgw.Id, proto),
+			Protocol: ptr(proto),
+			Vips:     vips,
+			ExternalIds: map[string]string{
+				externalKeyOcRef: ocNatRef,
+			},
+		})
+	}
+	for _, ip := range natDEntryRouteIps(dnatIps, snatCidrs) {
+		routes = append(routes, newRoute(ip+"/32"))
+	}
+
+	var irows []types.IRow
+	for _, nat := range nats {
+		irows = append(irows, nat)
+	}
+	for _, route := range routes {
+		irows = append(irows, route)
+	}
+	for _, lb := range lbs {
+		irows = append(irows, lb)
+	}
+	if len(irows) == 0 {
+		return nil
+	}
+	allFound, args := cmp(&keeper.DB, ocVersion, irows...)
+	if allFound {
+		return nil
+	}
+	lrName := vpcExtLrName(vpc.Id)
+	// nat rows and routes are not destroyed by cmp, detach the old ones
+	// to avoid duplicates
+	args = append(args, keeper.natgwDetachArgs(lrName, ocNatRef, ocNatRouteRef)...)
+	for i, nat := range nats {
+		ref := fmt.Sprintf("nat%d", i)
+		args = append(args, ovnCreateArgs(nat, ref)...)
+		args = append(args, "--", "add", "Logical_Router", lrName, "nat", "@"+ref)
+	}
+	for i, route := range routes {
+		ref := fmt.Sprintf("natRoute%d", i)
+		args = append(args, ovnCreateArgs(route, ref)...)
+		args = append(args, "--", "add", "Logical_Router", lrName, "static_routes", "@"+ref)
+	}
+	for i, lb := range lbs {
+		ref := fmt.Sprintf("natLb%d", i)
+		args = append(args, ovnCreateArgs(lb, ref)...)
+		args = append(args, "--", "add", "Logical_Router", lrName, "load_balancer", "@"+ref)
+	}
+	return keeper.cli.Must(ctx, "ClaimNatGateway", args)
+}
+
 func (keeper *OVNNorthboundKeeper) ClaimRoutes(ctx context.Context, vpc *agentmodels.Vpc, routes resolvedRoutes) error {
 	var irows []types.IRow
 	for _, route := range routes {
@@ -1071,19 +1350,7 @@ func (keeper *OVNNorthboundKeeper) ClaimGroupnetwork(ctx context.Context, groupn
 }
 
 func (keeper *OVNNorthboundKeeper) Mark(ctx context.Context) {
-	db := &keeper.DB
-	itbls := []types.ITable{
-		&db.LogicalSwitch,
-		&db.LogicalSwitchPort,
-		&db.LogicalRouter,
-		&db.LogicalRouterPort,
-		&db.LogicalRouterStaticRoute,
-		&db.ACL,
-		&db.DHCPOptions,
-		&db.QoS,
-		&db.DNS,
-	}
-	for _, itbl := range itbls {
+	for _, itbl := range ovnNorthboundTables(&keeper.DB) {
 		for _, irow := range itbl.Rows() {
 			irow.RemoveExternalId(externalKeyOcVersion)
 		}
@@ -1091,6 +1358,9 @@ func (keeper *OVNNorthboundKeeper) Mark(ctx context.Context) {
 }
 
 func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
+	if keeper.ovsdb != nil {
+		return keeper.sweepTransact(ctx)
+	}
 	db := &keeper.DB
 	// isRoot=false tables at the end
 	itbls := []types.ITable{
@@ -1100,12 +1370,12 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 		&db.LogicalRouter,
 		&db.DHCPOptions,
 		&db.DNS,
+		&db.LoadBalancer,
 	}
 	var irows []types.IRow
 	for _, itbl := range itbls {
 		for _, irow := range itbl.Rows() {
-			_, ok := irow.GetExternalId(externalKeyOcVersion)
-			if !ok {
+			if keeper.sweepable(irow) {
 				irows = append(irows, irow)
 			}
 		}
@@ -1118,8 +1388,7 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 	{
 		var args []string
 		for _, irow := range db.LogicalRouterStaticRoute.Rows() {
-			_, ok := irow.GetExternalId(externalKeyOcVersion)
-			if !ok {
+			if keeper.sweepable(irow) {
 				for _, lr := range db.LogicalRouter.FindLogicalRouterStaticRouteReferrer_static_routes(irow.OvsdbUuid()) {
 					args = append(args, "--", "--if-exists", "remove", "Logical_Router", lr.Name, "static_routes", irow.OvsdbUuid())
 				}
@@ -1132,8 +1401,7 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 	{
 		var args []string
 		for _, irow := range db.ACL.Rows() {
-			_, ok := irow.GetExternalId(externalKeyOcVersion)
-			if !ok {
+			if keeper.sweepable(irow) {
 				for _, ls := range db.LogicalSwitch.FindACLReferrer_acls(irow.OvsdbUuid()) {
 					args = append(args, "--", "--if-exists", "remove", "Logical_Switch", ls.Name, "acls", irow.OvsdbUuid())
 				}
@@ -1143,11 +1411,23 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 			keeper.cli.Must(ctx, "Sweep acls", args)
 		}
 	}
+	{
+		var args []string
+		for _, irow := range db.NAT.Rows() {
+			if keeper.sweepable(irow) {
+				for _, lr := range db.LogicalRouter.FindNATReferrer_nat(irow.OvsdbUuid()) {
+					args = append(args, "--", "--if-exists", "remove", "Logical_Router", lr.Name, "nat", irow.OvsdbUuid())
+				}
+			}
+		}
+		if len(args) > 0 {
+			keeper.cli.Must(ctx, "Sweep nat", args)
+		}
+	}
 	{ //  remove unused QoS rows
 		var args []string
 		for _, irow := range db.QoS.Rows() {
-			_, ok := irow.GetExternalId(externalKeyOcVersion)
-			if !ok {
+			if keeper.sweepable(irow) {
 				for _, ls := range db.LogicalSwitch.FindQoSReferrer_qos_rules(irow.OvsdbUuid()) {
 					args = append(args, "--", "--if-exists", "remove", "Logical_Switch", ls.Name, "qos_rules", irow.OvsdbUuid())
 				}
@@ -1159,3 +1439,63 @@ func (keeper *OVNNorthboundKeeper) Sweep(ctx context.Context) error {
 	}
 	return nil
 }
+
+// sweepable tells whether irow is unclaimed and in scope of sweep
+func (keeper *OVNNorthboundKeeper) sweepable(irow types.IRow) bool {
+	if _, ok := irow.GetExternalId(externalKeyOcVersion); ok {
+		return false
+	}
+	if keeper.sweepScope != nil && !keeper.sweepScope[irow.OvsdbUuid()] {
+		return false
+	}
+	return true
+}
+
+// sweepTransact removes unmarked rows in a single ovsdb transaction.
+// References to non-root rows are removed first and the server garbage
+// collects them, the same as what lsp-del and "remove" of ovn-nbctl do
+func (keeper *OVNNorthboundKeeper) sweepTransact(ctx context.Context) error {
+	db := &keeper.DB
+	refs := []struct {
+		itbl   types.ITable
+		parent string
+		column string
+	}{
+		{&db.LogicalSwitchPort, "Logical_Switch", "ports"},
+		{&db.LogicalRouterPort, "Logical_Router", "ports"},
+		{&db.LogicalRouterStaticRoute, "Logical_Router", "static_routes"},
+		{&db.ACL, "Logical_Switch", "acls"},
+		{&db.NAT, "Logical_Router", "nat"},
+		{&db.QoS, "Logical_Switch", "qos_rules"},
+	}
+	roots := []types.ITable{
+		&db.LogicalSwitch,
+		&db.LogicalRouter,
+		&db.DHCPOptions,
+		&db.DNS,
+		&db.LoadBalancer,
+	}
+	var ops []ovnutil.OvsdbOp
+	for _, ref := range refs {
+		for _, irow := range ref.itbl.Rows() {
+			if keeper.sweepable(irow) {
+				ops = append(ops, ovnutil.OvsdbOpMutateDeleteRef(ref.parent, ref.column, irow.OvsdbUuid()))
+			}
+		}
+	}
+	for _, itbl := range roots {
+		for _, irow := range itbl.Rows() {
+			if keeper.sweepable(irow) {
+				ops = append(ops, ovnutil.OvsdbOpDelete(itbl.OvsdbTableName(), irow.OvsdbUuid()))
+			}
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := keeper.ovsdb.Transact(ctx, ovnutil.OvsdbNorthboundName, ops...); err != nil {
+		return errors.Wrap(err, "Sweep")
+	}
+	log.Infof("Sweep:\n%s", ovnutil.OvsdbOpsString(ops))
+	return nil
+}
@@ -39,6 +39,10 @@ func HashVpcHostDistgwMac(hostId string) string {
 	return HashMac(hostId)
 }
 
+func HashVpcPeerRouterPortMac(peeringId string, vpcId string) string {
+	return HashMac(peeringId, vpcId, "peer")
+}
+
 func HashSubnetRouterPortMac(netId string) string {
 	return HashMac(netId, "rp")
 }
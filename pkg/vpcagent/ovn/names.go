@@ -81,6 +81,24 @@ func vpcEipLspName(vpcId string, eipgwId string) string {
 	return fmt.Sprintf("vpc-ep/%s/%s", vpcId, eipgwId)
 }
 
+// peering
+func vpcPeerLsName(peeringId string) string {
+	return fmt.Sprintf("vpc-peer/%s", peeringId)
+}
+
+func vpcPeerRpName(peeringId string, vpcId string) string {
+	return fmt.Sprintf("vpc-peer-rp/%s/%s", peeringId, vpcId)
+}
+
+func vpcPeerPrName(peeringId string, vpcId string) string {
+	return fmt.Sprintf("vpc-peer-pr/%s/%s", peeringId, vpcId)
+}
+
+// natgw
+func natLbName(natgwId string, proto string) string {
+	return fmt.Sprintf("nat-lb/%s/%s", natgwId, proto)
+}
+
 func netLsName(netId string) string {
 	return fmt.Sprintf("subnet/%s", netId)
 }
@@ -97,6 +115,12 @@ func netMdpName(netId string) string {
 	return fmt.Sprintf("subnet-md/%s", netId)
 }
 
+// netDhcp6OptRef returns oc-ref of the DHCPv6 DHCP_Options of a subnet, it
+// must differ from the DHCPv4 one which is referenced by network id
+func netDhcp6OptRef(netId string) string {
+	return fmt.Sprintf("dhcpv6/%s", netId)
+}
+
 // gnpName returns Logical_Switch_Port name for guestnetwork
 //
 // The name must match what's going to be set on each chassis
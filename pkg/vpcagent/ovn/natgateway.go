@@ -0,0 +1,154 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	api "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+// natgwDetachArgs returns args removing nat rows, static routes and load
+// balancers with the oc-ref from the logical router.  They are also unmarked
+// for sweeping
+func (keeper *OVNNorthboundKeeper) natgwDetachArgs(lrName string, ocNatRef, ocNatRouteRef string) []string {
+	var (
+		db   = &keeper.DB
+		args []string
+	)
+	for _, irow := range db.NAT.Rows() {
+		if ref, _ := irow.GetExternalId(externalKeyOcRef); ref == ocNatRef {
+			irow.RemoveExternalId(externalKeyOcVersion)
+			args = append(args, "--", "--if-exists", "remove", "Logical_Router", lrName, "nat", irow.OvsdbUuid())
+		}
+	}
+	for _, irow := range db.LogicalRouterStaticRoute.Rows() {
+		if ref, _ := irow.GetExternalId(externalKeyOcRef); ref == ocNatRouteRef {
+			irow.RemoveExternalId(externalKeyOcVersion)
+			args = append(args, "--", "--if-exists", "remove", "Logical_Router", lrName, "static_routes", irow.OvsdbUuid())
+		}
+	}
+	for _, irow := range db.LoadBalancer.Rows() {
+		if ref, _ := irow.GetExternalId(externalKeyOcRef); ref == ocNatRef {
+			irow.RemoveExternalId(externalKeyOcVersion)
+			args = append(args, "--", "--if-exists", "remove", "Logical_Router", lrName, "load_balancer", irow.OvsdbUuid())
+		}
+	}
+	return args
+}
+
+// natSEntryLogicalIp returns the source cidr of the snat entry, it's either
+// the cidr of the network or the source cidr specified
+func natSEntryLogicalIp(snat *agentmodels.NatSEntry) (string, error) {
+	var prefix netutils.IPV4Prefix
+	if network := snat.Network; network != nil {
+		if network.Vpc == nil || network.Vpc.Id != snat.NatGateway.VpcId {
+			return "", errors.Errorf("network %s(%s) is not in vpc %s", network.Name, network.Id, snat.NatGateway.VpcId)
+		}
+		addr, err := netutils.NewIPV4Addr(network.GuestIpStart)
+		if err != nil {
+			return "", errors.Wrapf(err, "network %s(%s) guest_ip_start", network.Name, network.Id)
+		}
+		prefix = netutils.IPV4Prefix{
+			Address: addr.NetAddr(network.GuestIpMask),
+			MaskLen: network.GuestIpMask,
+		}
+	} else {
+		var err error
+		prefix, err = netutils.NewIPV4Prefix(snat.SourceCIDR)
+		if err != nil {
+			return "", errors.Wrapf(err, "source cidr %q", snat.SourceCIDR)
+		}
+	}
+	return fmt.Sprintf("%s/%d", prefix.Address.NetAddr(prefix.MaskLen), prefix.MaskLen), nil
+}
+
+// natgwVipsMax limits load balancer vips of one nat gateway, they are all
+// passed to one ovn-nbctl command, which must not exceed ARG_MAX
+const natgwVipsMax = 16 * api.NAT_DNAT_PORT_RANGE_MAX
+
+// natDEntryVips returns load balancer protocol and vips of the dnat entry.
+// A port range is expanded to one vip for each port
+func natDEntryVips(dnat *agentmodels.NatDEntry) (string, map[string]string, error) {
+	proto := strings.ToLower(dnat.IpProtocol)
+	switch proto {
+	case "tcp", "udp":
+	default:
+		return "", nil, errors.Errorf("unsupported protocol %q", dnat.IpProtocol)
+	}
+	if dnat.ExternalPort <= 0 || dnat.InternalPort <= 0 {
+		return "", nil, errors.Errorf("invalid port %d -> %d", dnat.ExternalPort, dnat.InternalPort)
+	}
+	portEnd := dnat.ExternalPort
+	if dnat.ExternalPortEnd > portEnd {
+		portEnd = dnat.ExternalPortEnd
+	}
+	if dnat.InternalPort+portEnd-dnat.ExternalPort > 65535 {
+		return "", nil, errors.Errorf("invalid port range %d-%d -> %d", dnat.ExternalPort, portEnd, dnat.InternalPort)
+	}
+	if portEnd-dnat.ExternalPort+1 > api.NAT_DNAT_PORT_RANGE_MAX {
+		return "", nil, errors.Errorf("port range %d-%d exceeds %d ports", dnat.ExternalPort, portEnd, api.NAT_DNAT_PORT_RANGE_MAX)
+	}
+	vips := map[string]string{}
+	for port := dnat.ExternalPort; port <= portEnd; port++ {
+		vip := fmt.Sprintf("%s:%d", dnat.ExternalIP, port)
+		vips[vip] = fmt.Sprintf("%s:%d", dnat.InternalIP, dnat.InternalPort+port-dnat.ExternalPort)
+	}
+	return proto, vips, nil
+}
+
+// natDEntryRouteIps returns internal addresses of dnat entries not covered
+// by snat cidrs.  Replies from them must also be routed to eip gateway for
+// un-dnat
+func natDEntryRouteIps(dnatIps []string, snatCidrs []string) []string {
+	var prefixes []netutils.IPV4Prefix
+	for _, cidr := range snatCidrs {
+		if prefix, err := netutils.NewIPV4Prefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	var (
+		r   []string
+		has = map[string]struct{}{}
+	)
+	for _, ip := range dnatIps {
+		if _, ok := has[ip]; ok {
+			continue
+		}
+		has[ip] = struct{}{}
+		addr, err := netutils.NewIPV4Addr(ip)
+		if err != nil {
+			continue
+		}
+		covered := false
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			r = append(r, ip)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
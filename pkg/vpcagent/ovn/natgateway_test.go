@@ -0,0 +1,351 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/options"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovnutil"
+)
+
+// recorded output of "ovn-nbctl --format=json list <tbl>" after the nat
+// gateway built by newTestNatGateway was realized, with a stale snat row
+// from a deleted nat gateway
+var natgwRecordedNbctlList = map[string]string{
+	"Logical_Router":              `{"data":[[["uuid","1b8f6c4e-6c0b-4f55-8d4f-3c6e3f1a0e01"],["set",[]],["map",[]],["set",[["uuid","9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a10"],["uuid","9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a11"]]],"vpc-ext-r/vpc0",["set",[["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c01"],["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c02"],["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03"]]],["map",[]],["set",[]],["set",[]],["set",[["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e01"],["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e02"],["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e03"]]]]],"headings":["_uuid","enabled","external_ids","load_balancer","name","nat","options","policies","ports","static_routes"]}`,
+	"NAT":                         `{"data":[[["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c01"],["map",[["oc-ref","nat/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"10.168.0.10",["set",[]],"192.168.0.0/24",["set",[]],"snat"],[["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c02"],["map",[["oc-ref","nat/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"10.168.0.11",["set",[]],"192.168.1.0/24",["set",[]],"snat"],[["uuid","5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03"],["map",[["oc-ref","nat/natgw1"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"10.168.0.20",["set",[]],"192.168.3.0/24",["set",[]],"snat"]],"headings":["_uuid","external_ids","external_ip","external_mac","logical_ip","logical_port","type"]}`,
+	"Logical_Router_Static_Route": `{"data":[[["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e01"],["map",[["oc-ref","natRoute/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"192.168.0.0/24","100.64.128.3","vpc-re/vpc0","src-ip"],[["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e02"],["map",[["oc-ref","natRoute/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"192.168.1.0/24","100.64.128.3","vpc-re/vpc0","src-ip"],[["uuid","7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e03"],["map",[["oc-ref","natRoute/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"192.168.2.5/32","100.64.128.3","vpc-re/vpc0","src-ip"]],"headings":["_uuid","external_ids","ip_prefix","nexthop","output_port","policy"]}`,
+	"Load_Balancer":               `{"data":[[["uuid","9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a10"],["map",[["oc-ref","nat/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"nat-lb/natgw0/tcp","tcp",["map",[["10.168.0.12:80","192.168.0.5:8080"]]]],[["uuid","9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a11"],["map",[["oc-ref","nat/natgw0"],["oc-version","2021-01-01 00:00:00 +0000 UTC.1"]]],"nat-lb/natgw0/udp","udp",["map",[["10.168.0.12:1000","192.168.2.5:2000"],["10.168.0.12:1001","192.168.2.5:2001"],["10.168.0.12:1002","192.168.2.5:2002"]]]]],"headings":["_uuid","external_ids","name","protocol","vips"]}`,
+}
+
+func newTestNatGateway() *agentmodels.NatGateway {
+	vpc := &agentmodels.Vpc{}
+	vpc.Id = "vpc0"
+	vpc.ExternalAccessMode = apis.VPC_EXTERNAL_ACCESS_MODE_EIP
+
+	network := &agentmodels.Network{Vpc: vpc}
+	network.Id = "net0"
+	network.GuestIpStart = "192.168.0.2"
+	network.GuestIpMask = 24
+
+	natgw := &agentmodels.NatGateway{Vpc: vpc}
+	natgw.Id = "natgw0"
+	natgw.VpcId = vpc.Id
+
+	snat0 := &agentmodels.NatSEntry{NatGateway: natgw, Network: network}
+	snat0.Id = "snat0"
+	snat0.IP = "10.168.0.10"
+	snat0.NetworkId = network.Id
+	snat1 := &agentmodels.NatSEntry{NatGateway: natgw}
+	snat1.Id = "snat1"
+	snat1.IP = "10.168.0.11"
+	snat1.SourceCIDR = "192.168.1.0/24"
+	natgw.NatSEntries = agentmodels.NatSEntries{
+		snat0.Id: snat0,
+		snat1.Id: snat1,
+	}
+
+	// backend covered by snat0
+	dnat0 := &agentmodels.NatDEntry{NatGateway: natgw}
+	dnat0.Id = "dnat0"
+	dnat0.ExternalIP = "10.168.0.12"
+	dnat0.ExternalPort = 80
+	dnat0.InternalIP = "192.168.0.5"
+	dnat0.InternalPort = 8080
+	dnat0.IpProtocol = "TCP"
+	// port range
+	dnat1 := &agentmodels.NatDEntry{NatGateway: natgw}
+	dnat1.Id = "dnat1"
+	dnat1.ExternalIP = "10.168.0.12"
+	dnat1.ExternalPort = 1000
+	dnat1.ExternalPortEnd = 1002
+	dnat1.InternalIP = "192.168.2.5"
+	dnat1.InternalPort = 2000
+	dnat1.IpProtocol = "udp"
+	natgw.NatDEntries = agentmodels.NatDEntries{
+		dnat0.Id: dnat0,
+		dnat1.Id: dnat1,
+	}
+
+	vpc.NatGateways = agentmodels.NatGateways{natgw.Id: natgw}
+	return natgw
+}
+
+type testNbctlRecorder struct {
+	lists  map[string]string
+	writes [][]string
+}
+
+func (r *testNbctlRecorder) run(ctx context.Context, args []string) *ovnutil.CmdResult {
+	if len(args) == 3 && args[0] == "--format=json" && args[1] == "list" {
+		output, ok := r.lists[args[2]]
+		if !ok {
+			output = `{"data":[],"headings":[]}`
+		}
+		return &ovnutil.CmdResult{Output: output}
+	}
+	r.writes = append(r.writes, args)
+	return &ovnutil.CmdResult{}
+}
+
+func newTestKeeper(t *testing.T, lists map[string]string) (*OVNNorthboundKeeper, *testNbctlRecorder) {
+	recorder := &testNbctlRecorder{lists: lists}
+	cli := ovnutil.NewOvnNbCtl("")
+	cli.SetCmdRunner(recorder.run)
+	keeper, err := DumpOVNNorthbound(context.Background(), cli)
+	if err != nil {
+		t.Fatalf("dump northbound: %v", err)
+	}
+	return keeper, recorder
+}
+
+func TestVpcHasNatgw(t *testing.T) {
+	natgw := newTestNatGateway()
+	vpc := natgw.Vpc
+	opts := &options.Options{}
+	if vpcHasNatgw(vpc, opts) {
+		t.Errorf("nat gateway should not be realized without eip gateway chassis")
+	}
+	opts.OvnEipGatewayChassis = "eipgw0"
+	if !vpcHasNatgw(vpc, opts) {
+		t.Errorf("nat gateway should be realized")
+	}
+	vpc.ExternalAccessMode = apis.VPC_EXTERNAL_ACCESS_MODE_EIP_DISTGW
+	if vpcHasNatgw(vpc, opts) {
+		t.Errorf("nat gateway should not be realized in eip-distgw mode")
+	}
+}
+
+func TestClaimNatGatewayCreate(t *testing.T) {
+	ctx := context.Background()
+	keeper, recorder := newTestKeeper(t, nil)
+	keeper.Mark(ctx)
+	natgw := newTestNatGateway()
+	keeper.ClaimNatGateway(ctx, natgw)
+	if len(recorder.writes) != 1 {
+		t.Fatalf("want 1 write, got %d", len(recorder.writes))
+	}
+	cmd := strings.Join(recorder.writes[0], " ")
+	for _, want := range []string{
+		`create NAT external_ids:"oc-ref"="nat/natgw0" external_ip="10.168.0.10" logical_ip="192.168.0.0/24" type="snat"`,
+		`create NAT external_ids:"oc-ref"="nat/natgw0" external_ip="10.168.0.11" logical_ip="192.168.1.0/24" type="snat"`,
+		`add Logical_Router vpc-ext-r/vpc0 nat @nat0`,
+		`ip_prefix="192.168.0.0/24" nexthop="100.64.128.3" output_port="vpc-re/vpc0" policy="src-ip"`,
+		`ip_prefix="192.168.2.5/32" nexthop="100.64.128.3"`,
+		`add Logical_Router vpc-ext-r/vpc0 static_routes @natRoute2`,
+		`name="nat-lb/natgw0/tcp" protocol="tcp" vips:"10.168.0.12:80"="192.168.0.5:8080"`,
+		`vips:"10.168.0.12:1002"="192.168.2.5:2002"`,
+		`add Logical_Router vpc-ext-r/vpc0 load_balancer @natLb1`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("missing %s in\n%s", want, cmd)
+		}
+	}
+	if strings.Contains(cmd, "192.168.0.5/32") {
+		t.Errorf("dnat backend covered by snat should not have route:\n%s", cmd)
+	}
+}
+
+func TestClaimNatGatewayLargePortRange(t *testing.T) {
+	ctx := context.Background()
+	keeper, recorder := newTestKeeper(t, nil)
+	keeper.Mark(ctx)
+	natgw := newTestNatGateway()
+	newDnat := func(id, ip string, port, portEnd int) *agentmodels.NatDEntry {
+		dnat := &agentmodels.NatDEntry{NatGateway: natgw}
+		dnat.Id = id
+		dnat.ExternalIP = ip
+		dnat.ExternalPort = port
+		dnat.ExternalPortEnd = portEnd
+		dnat.InternalIP = "192.168.0.6"
+		dnat.InternalPort = port
+		dnat.IpProtocol = "tcp"
+		return dnat
+	}
+	// full range entry left in db before the range was capped
+	full := newDnat("dnat2-full", "10.168.0.13", 1, 65535)
+	natgw.NatDEntries[full.Id] = full
+	// capped ranges on many eips exceeding vips limit of the nat gateway
+	for i := 0; i < natgwVipsMax/apis.NAT_DNAT_PORT_RANGE_MAX+2; i++ {
+		dnat := newDnat(fmt.Sprintf("dnat3-range%02d", i), fmt.Sprintf("10.168.1.%d", i), 10000, 10000+apis.NAT_DNAT_PORT_RANGE_MAX-1)
+		natgw.NatDEntries[dnat.Id] = dnat
+	}
+	keeper.ClaimNatGateway(ctx, natgw)
+	if len(recorder.writes) != 1 {
+		t.Fatalf("want 1 write, got %d", len(recorder.writes))
+	}
+	vips := 0
+	for _, arg := range recorder.writes[0] {
+		if strings.HasPrefix(arg, "vips:") {
+			vips++
+		}
+	}
+	// 4 vips of dnat0 and dnat1 are claimed before the ranges
+	if want := natgwVipsMax + 4; vips > want {
+		t.Errorf("want at most %d vips, got %d", want, vips)
+	}
+	cmd := strings.Join(recorder.writes[0], " ")
+	for _, want := range []string{
+		`vips:"10.168.0.12:80"="192.168.0.5:8080"`,
+		`vips:"10.168.1.0:10000"="192.168.0.6:10000"`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(cmd, "10.168.0.13:") {
+		t.Errorf("full port range should not be realized")
+	}
+}
+
+func TestNatDEntryVips(t *testing.T) {
+	cases := []struct {
+		name    string
+		port    int
+		portEnd int
+		vips    int
+		wantErr bool
+	}{
+		{name: "single port", port: 80, vips: 1},
+		{name: "range", port: 1000, portEnd: 1002, vips: 3},
+		{name: "max range", port: 1, portEnd: apis.NAT_DNAT_PORT_RANGE_MAX, vips: apis.NAT_DNAT_PORT_RANGE_MAX},
+		{name: "exceeds max range", port: 1, portEnd: apis.NAT_DNAT_PORT_RANGE_MAX + 1, wantErr: true},
+		{name: "full range", port: 1, portEnd: 65535, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dnat := &agentmodels.NatDEntry{}
+			dnat.ExternalIP = "10.168.0.12"
+			dnat.ExternalPort = c.port
+			dnat.ExternalPortEnd = c.portEnd
+			dnat.InternalIP = "192.168.0.5"
+			dnat.InternalPort = c.port
+			dnat.IpProtocol = "tcp"
+			_, vips, err := natDEntryVips(dnat)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("want error, got %d vips", len(vips))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("natDEntryVips: %v", err)
+			}
+			if len(vips) != c.vips {
+				t.Errorf("want %d vips, got %d", c.vips, len(vips))
+			}
+		})
+	}
+}
+
+func (r *testNbctlRecorder) hasWrite(cmd string) bool {
+	for _, args := range r.writes {
+		if strings.Contains(strings.Join(args, " "), cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClaimNatGatewaySweep(t *testing.T) {
+	cases := []struct {
+		name    string
+		remove  []string
+		removed []string
+	}{
+		{
+			name: "stale natgw",
+			removed: []string{
+				"-- --if-exists remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03",
+			},
+		},
+		{
+			name:   "stale snat entry",
+			remove: []string{"snat1"},
+			removed: []string{
+				"-- --if-exists remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c02",
+				"-- --if-exists remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03",
+				"-- --if-exists remove Logical_Router vpc-ext-r/vpc0 static_routes 7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e02",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			keeper, recorder := newTestKeeper(t, natgwRecordedNbctlList)
+			keeper.Mark(ctx)
+			natgw := newTestNatGateway()
+			for _, id := range c.remove {
+				delete(natgw.NatSEntries, id)
+			}
+			keeper.ClaimNatGateway(ctx, natgw)
+			if len(recorder.writes) != 0 {
+				t.Fatalf("realized nat gateway should not be changed, got %q", recorder.writes)
+			}
+			keeper.Sweep(ctx)
+			for _, cmd := range c.removed {
+				if !recorder.hasWrite(cmd) {
+					t.Errorf("missing %s in %q", cmd, recorder.writes)
+				}
+			}
+			for _, args := range recorder.writes {
+				cmd := strings.Join(args, " ")
+				for _, uuid := range []string{
+					"5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c01",
+					"7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e01",
+					"9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a10",
+				} {
+					if strings.Contains(cmd, uuid) {
+						t.Errorf("claimed row %s should not be swept: %s", uuid, cmd)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClaimNatGatewayUpdate(t *testing.T) {
+	ctx := context.Background()
+	keeper, recorder := newTestKeeper(t, natgwRecordedNbctlList)
+	keeper.Mark(ctx)
+	natgw := newTestNatGateway()
+	natgw.NatDEntries["dnat0"].InternalPort = 8081
+	keeper.ClaimNatGateway(ctx, natgw)
+	if len(recorder.writes) != 1 {
+		t.Fatalf("want 1 write, got %q", recorder.writes)
+	}
+	cmd := strings.Join(recorder.writes[0], " ")
+	for _, want := range []string{
+		"remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c01",
+		"remove Logical_Router vpc-ext-r/vpc0 nat 5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c02",
+		"remove Logical_Router vpc-ext-r/vpc0 static_routes 7c4d2e1f-3a5b-4c6d-9e8f-0a1b2c3d4e03",
+		"remove Logical_Router vpc-ext-r/vpc0 load_balancer 9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a10",
+		"destroy Load_Balancer 9a3e7f8b-5c41-4f0b-a7f6-2d0c5b1e6a11",
+		`vips:"10.168.0.12:80"="192.168.0.5:8081"`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("missing %s in\n%s", want, cmd)
+		}
+	}
+	if strings.Contains(cmd, "5d2b1c3a-0e7f-4a9b-8c6d-1e2f3a4b5c03") {
+		t.Errorf("nat rows of other nat gateway should be left to sweep:\n%s", cmd)
+	}
+}
@@ -61,6 +61,16 @@ func resolveRoutes(vpc *agentmodels.Vpc, mss *agentmodels.ModelSets) resolvedRou
 					Guestnetwork: gn,
 				})
 			}
+		case computeapis.NEXT_HOP_TYPE_VPCPEERING:
+			peering, ok := vpc.VpcPeeringConnections[routeModel.NextHopId]
+			if !ok || !vpcPeeringIsActive(peering) {
+				break
+			}
+			_, nexthop := vpcPeerTransitIPs(peering, vpc.Id)
+			r = append(r, resolvedRoute{
+				Cidr:    routeModel.Cidr,
+				NextHop: nexthop,
+			})
 		default:
 			return nil
 		}
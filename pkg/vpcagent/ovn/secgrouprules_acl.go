@@ -23,6 +23,7 @@ import (
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/secrules"
 
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
 	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
 )
 
@@ -35,6 +36,17 @@ const (
 	aclDirFromLport = "from-lport"
 )
 
+const (
+	// aclLogMeterName names the meter rate limiting acl logs of all ports
+	aclLogMeterName = "acl-log"
+	// aclLogNamePrefix prefixes rule id in acl names.  ovn-controller
+	// puts the name in acl logs for the host to tell the rule hit
+	aclLogNamePrefix = "sgr/"
+
+	aclLogSeverityAllow = "info"
+	aclLogSeverityDrop  = "warning"
+)
+
 func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL, error) {
 	var (
 		dir    string
@@ -175,3 +187,37 @@ func ruleToAcl(lport string, rule *agentmodels.SecurityGroupRule) (*ovn_nb.ACL,
 
 	return acl, nil
 }
+
+// ruleAclLogName returns the name of acl for logging traffic hit by the
+// rule.  Rules without security group are builtin ones, only the implicit
+// deny rule is logged when logDefaultDeny is true
+func ruleAclLogName(rule *agentmodels.SecurityGroupRule, logDefaultDeny bool) (string, bool) {
+	if rule.SecurityGroup == nil {
+		if logDefaultDeny && rule.Protocol == "" && rule.Action == string(secrules.SecurityRuleDeny) {
+			return aclLogNamePrefix + apis.SECGROUP_FLOW_LOG_DEFAULT_DENY_RULE, true
+		}
+		return "", false
+	}
+	if !rule.SecurityGroup.FlowLogVerdict(rule.Action) {
+		return "", false
+	}
+	return aclLogNamePrefix + rule.Id, true
+}
+
+// aclSetLog enables logging of the acl.  The log external id makes the acl
+// not matching the same one without logging, so that switching flow log
+// off and on both cause acls to be recreated
+func aclSetLog(acl *ovn_nb.ACL, name string) {
+	acl.Log = true
+	acl.Name = ptr(name)
+	acl.Meter = ptr(aclLogMeterName)
+	if acl.Action == "drop" {
+		acl.Severity = ptr(aclLogSeverityDrop)
+	} else {
+		acl.Severity = ptr(aclLogSeverityAllow)
+	}
+	if acl.ExternalIds == nil {
+		acl.ExternalIds = map[string]string{}
+	}
+	acl.ExternalIds[externalKeyOcAclLog] = "true"
+}
@@ -29,16 +29,20 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"yunion.io/x/jsonutils"
 	"yunion.io/x/ovsdb/schema/ovn_nb"
 	"yunion.io/x/pkg/util/secrules"
 
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
 	"yunion.io/x/onecloud/pkg/compute/models"
 	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/options"
 )
 
 func TestRuleToACL(t *testing.T) {
@@ -133,3 +137,133 @@ func TestRuleToACL(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleAclLogName(t *testing.T) {
+	newRule := func(flowLog, action string) *agentmodels.SecurityGroupRule {
+		secgroup := &agentmodels.SecurityGroup{}
+		secgroup.Id = "sg0"
+		secgroup.FlowLog = flowLog
+		rule := &agentmodels.SecurityGroupRule{SecurityGroup: secgroup}
+		rule.Id = "sgr0"
+		rule.Direction = string(secrules.SecurityRuleIngress)
+		rule.Action = action
+		rule.Protocol = secrules.PROTO_ANY
+		return rule
+	}
+	guest := &agentmodels.Guest{}
+	builtins := guest.OrderedSecurityGroupRules()
+	cases := []struct {
+		name           string
+		rule           *agentmodels.SecurityGroupRule
+		logDefaultDeny bool
+		want           string
+	}{
+		{
+			name: "log off",
+			rule: newRule(apis.SECGROUP_FLOW_LOG_OFF, string(secrules.SecurityRuleDeny)),
+		},
+		{
+			name: "log deny, allow rule",
+			rule: newRule(apis.SECGROUP_FLOW_LOG_DENY, string(secrules.SecurityRuleAllow)),
+		},
+		{
+			name: "log deny, deny rule",
+			rule: newRule(apis.SECGROUP_FLOW_LOG_DENY, string(secrules.SecurityRuleDeny)),
+			want: "sgr/sgr0",
+		},
+		{
+			name: "log all, allow rule",
+			rule: newRule(apis.SECGROUP_FLOW_LOG_ALL, string(secrules.SecurityRuleAllow)),
+			want: "sgr/sgr0",
+		},
+		{
+			name: "default deny",
+			rule: builtins[0],
+		},
+		{
+			name:           "default deny logged",
+			rule:           builtins[0],
+			logDefaultDeny: true,
+			want:           "sgr/default-deny",
+		},
+		{
+			name:           "arp",
+			rule:           builtins[1],
+			logDefaultDeny: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := ruleAclLogName(c.rule, c.logDefaultDeny)
+			if ok != (c.want != "") || got != c.want {
+				t.Errorf("want %q, got %q (%v)", c.want, got, ok)
+			}
+		})
+	}
+
+	acl, err := ruleToAcl("lport0", newRule(apis.SECGROUP_FLOW_LOG_DENY, string(secrules.SecurityRuleDeny)))
+	if err != nil {
+		t.Fatalf("ruleToAcl: %v", err)
+	}
+	acl.ExternalIds = map[string]string{externalKeyOcRef: "acl/net0/guest0/eth0"}
+	plain := *acl
+	plain.ExternalIds = map[string]string{externalKeyOcRef: "acl/net0/guest0/eth0"}
+	aclSetLog(acl, "sgr/sgr0")
+	if !acl.Log || *acl.Name != "sgr/sgr0" || *acl.Meter != aclLogMeterName || *acl.Severity != aclLogSeverityDrop {
+		t.Errorf("unexpected acl %s", jsonutils.Marshal(acl))
+	}
+	if acl.MatchNonZeros(&plain) {
+		t.Errorf("logged acl should not match the one with log off")
+	}
+}
+
+func TestClaimAclLogMeter(t *testing.T) {
+	ctx := context.Background()
+	opts := &options.Options{}
+	opts.OvnAclLogRate = 100
+	lists := map[string]string{
+		"Meter":      `{"data":[[["uuid","3e5f6a7b-8c9d-4e0f-a1b2-c3d4e5f60001"],["uuid","3e5f6a7b-8c9d-4e0f-a1b2-c3d4e5f60002"],["map",[]],"acl-log","pktps"]],"headings":["_uuid","bands","external_ids","name","unit"]}`,
+		"Meter_Band": `{"data":[[["uuid","3e5f6a7b-8c9d-4e0f-a1b2-c3d4e5f60002"],"drop",0,["map",[]],100]],"headings":["_uuid","action","burst_size","external_ids","rate"]}`,
+	}
+
+	t.Run("create", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, nil)
+		keeper.ClaimAclLogMeter(ctx, opts)
+		if len(recorder.writes) != 1 {
+			t.Fatalf("want 1 write, got %q", recorder.writes)
+		}
+		cmd := strings.Join(recorder.writes[0], " ")
+		for _, want := range []string{
+			`--id=@aclLogBand create Meter_Band action="drop" burst_size=0 rate=100`,
+			`--id=@aclLogMeter create Meter name="acl-log" unit="pktps" bands=@aclLogBand`,
+		} {
+			if !strings.Contains(cmd, want) {
+				t.Errorf("missing %s in\n%s", want, cmd)
+			}
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, lists)
+		keeper.Mark(ctx)
+		keeper.ClaimAclLogMeter(ctx, opts)
+		keeper.Sweep(ctx)
+		if len(recorder.writes) != 0 {
+			t.Errorf("want no write, got %q", recorder.writes)
+		}
+	})
+
+	t.Run("rate changed", func(t *testing.T) {
+		keeper, recorder := newTestKeeper(t, lists)
+		opts := &options.Options{}
+		opts.OvnAclLogRate = 20
+		keeper.ClaimAclLogMeter(ctx, opts)
+		if len(recorder.writes) != 1 {
+			t.Fatalf("want 1 write, got %q", recorder.writes)
+		}
+		cmd := strings.Join(recorder.writes[0], " ")
+		if want := `set Meter 3e5f6a7b-8c9d-4e0f-a1b2-c3d4e5f60001 unit=pktps bands=@aclLogBand`; !strings.Contains(cmd, want) {
+			t.Errorf("missing %s in\n%s", want, cmd)
+		}
+	})
+}
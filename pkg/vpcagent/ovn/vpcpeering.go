@@ -0,0 +1,179 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"yunion.io/x/ovsdb/schema/ovn_nb"
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+	"yunion.io/x/pkg/util/netutils"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+	"yunion.io/x/onecloud/pkg/vpcagent/ovn/mac"
+)
+
+// vpcPeeringIsActive returns true if the peering connection should be
+// realized.  The region allocates transit subnet before marking it active
+func vpcPeeringIsActive(peering *agentmodels.VpcPeeringConnection) bool {
+	if peering.Status != apis.VPC_PEERING_CONNECTION_STATUS_ACTIVE {
+		return false
+	}
+	if peering.TransitIndex == apis.VpcPeerTransitIndexNone {
+		return false
+	}
+	if peering.VpcId == apis.DEFAULT_VPC_ID || peering.PeerVpcId == apis.DEFAULT_VPC_ID {
+		return false
+	}
+	return true
+}
+
+// vpcPeerTransitIPs returns transit addresses of router port of the vpc and
+// that of the peer vpc
+func vpcPeerTransitIPs(peering *agentmodels.VpcPeeringConnection, vpcId string) (string, string) {
+	ip1, ip2 := apis.VpcPeerTransitIPs(peering.TransitIndex)
+	if vpcId == peering.VpcId {
+		return ip1.String(), ip2.String()
+	}
+	return ip2.String(), ip1.String()
+}
+
+func networkCidr(network *agentmodels.Network) (string, error) {
+	addr, err := netutils.NewIPV4Addr(network.GuestIpStart)
+	if err != nil {
+		return "", errors.Wrapf(err, "network %s(%s) guest_ip_start", network.Name, network.Id)
+	}
+	return fmt.Sprintf("%s/%d", addr.NetAddr(network.GuestIpMask), network.GuestIpMask), nil
+}
+
+// vpcPeerRoutes returns routes on router of vpc to networks of peerVpc
+func vpcPeerRoutes(peering *agentmodels.VpcPeeringConnection, vpc, peerVpc *agentmodels.Vpc) ([]*ovn_nb.LogicalRouterStaticRoute, error) {
+	var (
+		_, nexthop = vpcPeerTransitIPs(peering, vpc.Id)
+		ocRef      = fmt.Sprintf("vpcPeerRoute/%s", peering.Id)
+		cidrs      []string
+	)
+	for _, network := range peerVpc.Networks {
+		cidr, err := networkCidr(network)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	routes := make([]*ovn_nb.LogicalRouterStaticRoute, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		routes = append(routes, &ovn_nb.LogicalRouterStaticRoute{
+			Policy:     ptr("dst-ip"),
+			IpPrefix:   cidr,
+			Nexthop:    nexthop,
+			OutputPort: ptr(vpcPeerRpName(peering.Id, vpc.Id)),
+			ExternalIds: map[string]string{
+				externalKeyOcRef: ocRef,
+			},
+		})
+	}
+	return routes, nil
+}
+
+// vpcPeerDetachArgs returns args removing static routes of the peering
+// connection from vpc routers.  They are also unmarked for sweeping
+func (keeper *OVNNorthboundKeeper) vpcPeerDetachArgs(peering *agentmodels.VpcPeeringConnection) []string {
+	var (
+		db    = &keeper.DB
+		ocRef = fmt.Sprintf("vpcPeerRoute/%s", peering.Id)
+		args  []string
+	)
+	for _, irow := range db.LogicalRouterStaticRoute.Rows() {
+		if ref, _ := irow.GetExternalId(externalKeyOcRef); ref != ocRef {
+			continue
+		}
+		irow.RemoveExternalId(externalKeyOcVersion)
+		for _, vpcId := range []string{peering.VpcId, peering.PeerVpcId} {
+			args = append(args, "--", "--if-exists", "remove", "Logical_Router", vpcLrName(vpcId), "static_routes", irow.OvsdbUuid())
+		}
+	}
+	return args
+}
+
+// ClaimVpcPeeringConnection connects routers of the two vpcs with a transit
+// logical switch, and routes networks of each vpc to the other.  Addresses
+// are not translated, security group rules with cidr of networks in the peer
+// vpc apply as is
+func (keeper *OVNNorthboundKeeper) ClaimVpcPeeringConnection(ctx context.Context, peering *agentmodels.VpcPeeringConnection) error {
+	var (
+		ocVersion = fmt.Sprintf("%s.%d", peering.UpdatedAt, peering.UpdateVersion)
+		vpcs      = []*agentmodels.Vpc{peering.Vpc, peering.PeerVpc}
+
+		peerLs = &ovn_nb.LogicalSwitch{
+			Name: vpcPeerLsName(peering.Id),
+		}
+		rps    []*ovn_nb.LogicalRouterPort
+		prs    []*ovn_nb.LogicalSwitchPort
+		routes [][]*ovn_nb.LogicalRouterStaticRoute
+	)
+	irows := []types.IRow{peerLs}
+	for i, vpc := range vpcs {
+		ip, _ := vpcPeerTransitIPs(peering, vpc.Id)
+		rp := &ovn_nb.LogicalRouterPort{
+			Name:     vpcPeerRpName(peering.Id, vpc.Id),
+			Mac:      mac.HashVpcPeerRouterPortMac(peering.Id, vpc.Id),
+			Networks: []string{fmt.Sprintf("%s/%d", ip, apis.VpcPeerTransitMask)},
+		}
+		pr := &ovn_nb.LogicalSwitchPort{
+			Name:      vpcPeerPrName(peering.Id, vpc.Id),
+			Type:      "router",
+			Addresses: []string{"router"},
+			Options: map[string]string{
+				"router-port": rp.Name,
+			},
+		}
+		vpcRoutes, err := vpcPeerRoutes(peering, vpc, vpcs[1-i])
+		if err != nil {
+			return err
+		}
+		rps = append(rps, rp)
+		prs = append(prs, pr)
+		routes = append(routes, vpcRoutes)
+		irows = append(irows, rp, pr)
+		for _, route := range vpcRoutes {
+			irows = append(irows, route)
+		}
+	}
+
+	allFound, args := cmp(&keeper.DB, ocVersion, irows...)
+	if allFound {
+		return nil
+	}
+	args = append(args, keeper.vpcPeerDetachArgs(peering)...)
+	args = append(args, ovnCreateArgs(peerLs, peerLs.Name)...)
+	for i, vpc := range vpcs {
+		lrName := vpcLrName(vpc.Id)
+		args = append(args, ovnCreateArgs(rps[i], rps[i].Name)...)
+		args = append(args, ovnCreateArgs(prs[i], prs[i].Name)...)
+		args = append(args, "--", "add", "Logical_Router", lrName, "ports", "@"+rps[i].Name)
+		args = append(args, "--", "add", "Logical_Switch", peerLs.Name, "ports", "@"+prs[i].Name)
+		for j, route := range routes[i] {
+			ref := fmt.Sprintf("vpcPeerRoute%d_%d", i, j)
+			args = append(args, ovnCreateArgs(route, ref)...)
+			args = append(args, "--", "add", "Logical_Router", lrName, "static_routes", "@"+ref)
+		}
+	}
+	return keeper.cli.Must(ctx, "ClaimVpcPeeringConnection", args)
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License
+
+package ovn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apis "yunion.io/x/onecloud/pkg/apis/compute"
+	agentmodels "yunion.io/x/onecloud/pkg/vpcagent/models"
+)
+
+func newTestVpcPeering() *agentmodels.VpcPeeringConnection {
+	newVpc := func(vpcId, netId, ipStart string) *agentmodels.Vpc {
+		vpc := &agentmodels.Vpc{}
+		vpc.Id = vpcId
+		network := &agentmodels.Network{Vpc: vpc}
+		network.Id = netId
+		network.GuestIpStart = ipStart
+		network.GuestIpMask = 24
+		vpc.Networks = agentmodels.Networks{netId: network}
+		vpc.VpcPeeringConnections = agentmodels.VpcPeeringConnections{}
+		return vpc
+	}
+	vpc := newVpc("vpc0", "net0", "192.168.0.2")
+	peerVpc := newVpc("vpc1", "net1", "192.168.1.2")
+
+	peering := &agentmodels.VpcPeeringConnection{
+		Vpc:     vpc,
+		PeerVpc: peerVpc,
+	}
+	peering.Id = "peer0"
+	peering.VpcId = vpc.Id
+	peering.PeerVpcId = peerVpc.Id
+	peering.Status = apis.VPC_PEERING_CONNECTION_STATUS_ACTIVE
+	peering.TransitIndex = 2
+	vpc.VpcPeeringConnections[peering.Id] = peering
+	peerVpc.VpcPeeringConnections[peering.Id] = peering
+	return peering
+}
+
+func TestVpcPeerTransitIPs(t *testing.T) {
+	peering := newTestVpcPeering()
+	cases := []struct {
+		vpcId  string
+		local  string
+		remote string
+	}{
+		{"vpc0", "100.65.128.9", "100.65.128.10"},
+		{"vpc1", "100.65.128.10", "100.65.128.9"},
+	}
+	for _, c := range cases {
+		local, remote := vpcPeerTransitIPs(peering, c.vpcId)
+		if local != c.local || remote != c.remote {
+			t.Errorf("%s: want %s %s, got %s %s", c.vpcId, c.local, c.remote, local, remote)
+		}
+	}
+	ip1, ip2 := apis.VpcPeerTransitIPs(apis.VpcPeerTransitIndexMax)
+	if ip1.String() != "100.65.255.253" || ip2.String() != "100.65.255.254" {
+		t.Errorf("max index: got %s %s", ip1, ip2)
+	}
+}
+
+func TestVpcPeeringIsActive(t *testing.T) {
+	peering := newTestVpcPeering()
+	if !vpcPeeringIsActive(peering) {
+		t.Errorf("peering should be active")
+	}
+	peering.TransitIndex = apis.VpcPeerTransitIndexNone
+	if vpcPeeringIsActive(peering) {
+		t.Errorf("peering without transit subnet should not be active")
+	}
+	peering = newTestVpcPeering()
+	peering.Status = apis.VPC_PEERING_CONNECTION_STATUS_CREATING
+	if vpcPeeringIsActive(peering) {
+		t.Errorf("creating peering should not be active")
+	}
+}
+
+func TestResolveRoutesVpcPeering(t *testing.T) {
+	peering := newTestVpcPeering()
+	vpc := peering.PeerVpc
+	vpc.RouteTable = &agentmodels.RouteTable{Vpc: vpc}
+	vpc.RouteTable.Routes = &apis.SRoutes{
+		{
+			Cidr:        "10.0.0.0/8",
+			NextHopType: apis.NEXT_HOP_TYPE_VPCPEERING,
+			NextHopId:   peering.Id,
+		},
+	}
+	routes := resolveRoutes(vpc, agentmodels.NewModelSets())
+	if len(routes) != 1 {
+		t.Fatalf("want 1 route, got %d", len(routes))
+	}
+	if routes[0].Cidr != "10.0.0.0/8" || routes[0].NextHop != "100.65.128.9" {
+		t.Errorf("got route %#v", routes[0])
+	}
+}
+
+func TestClaimVpcPeeringConnection(t *testing.T) {
+	ctx := context.Background()
+	keeper, recorder := newTestKeeper(t, nil)
+	keeper.Mark(ctx)
+	keeper.ClaimVpcPeeringConnection(ctx, newTestVpcPeering())
+	if len(recorder.writes) != 1 {
+		t.Fatalf("want 1 write, got %d", len(recorder.writes))
+	}
+	cmd := strings.Join(recorder.writes[0], " ")
+	for _, want := range []string{
+		`create Logical_Switch name="vpc-peer/peer0"`,
+		`name="vpc-peer-rp/peer0/vpc0" networks=["100.65.128.9/30"]`,
+		`name="vpc-peer-rp/peer0/vpc1" networks=["100.65.128.10/30"]`,
+		`add Logical_Router vpc-r/vpc0 ports @vpc-peer-rp/peer0/vpc0`,
+		`add Logical_Switch vpc-peer/peer0 ports @vpc-peer-pr/peer0/vpc1`,
+		`ip_prefix="192.168.1.0/24" nexthop="100.65.128.10" output_port="vpc-peer-rp/peer0/vpc0" policy="dst-ip"`,
+		`ip_prefix="192.168.0.0/24" nexthop="100.65.128.9" output_port="vpc-peer-rp/peer0/vpc1" policy="dst-ip"`,
+		`add Logical_Router vpc-r/vpc1 static_routes @vpcPeerRoute1_0`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("missing %s in\n%s", want, cmd)
+		}
+	}
+}
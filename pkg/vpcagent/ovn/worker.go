@@ -18,12 +18,14 @@ import (
 	"context"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
 	"yunion.io/x/log"
 	"yunion.io/x/pkg/errors"
 	"yunion.io/x/pkg/util/httputils"
+	"yunion.io/x/pkg/utils"
 
 	"yunion.io/x/onecloud/pkg/apihelper"
 	apis "yunion.io/x/onecloud/pkg/apis/compute"
@@ -40,6 +42,14 @@ type Worker struct {
 	opts *options.Options
 
 	apih *apihelper.APIHelper
+
+	nbCache     *ovnutil.OvsdbCache
+	nbOvsdb     *ovnutil.OvsdbClient
+	nbOvsdbLock sync.Mutex
+
+	// digests of vpcs realized by last successful run, nil to force a
+	// full mark/sweep run
+	digests vpcDigests
 }
 
 func NewWorker(opts *options.Options) worker.IWorker {
@@ -59,8 +69,9 @@ func NewWorker(opts *options.Options) worker.IWorker {
 		return nil
 	}
 	w := &Worker{
-		opts: opts,
-		apih: apih,
+		opts:    opts,
+		apih:    apih,
+		nbCache: ovnutil.NewOvsdbCache(),
 	}
 	return w
 }
@@ -75,34 +86,126 @@ func (w *Worker) Start(ctx context.Context, app *appsrv.Application, prefix stri
 	wg.Add(1)
 	go w.apih.Start(ctx, app, httputils.JoinPath(prefix, "api"))
 
+	if w.opts.OvnNbMonitor {
+		wg.Add(1)
+		go w.monitorNorthbound(ctx)
+	}
+
 	tickDuration := time.Duration(w.opts.OvnWorkerCheckInterval) * time.Second
 	tick := time.NewTimer(tickDuration)
 	defer tick.Stop()
 
+	fullSyncDuration := time.Duration(w.opts.OvnWorkerFullSyncInterval) * time.Second
+	fullSync := time.NewTimer(fullSyncDuration)
+	defer fullSync.Stop()
+
 	var mss *agentmodels.ModelSets
 	for {
 		select {
 		case imss := <-w.apih.ModelSets():
 			log.Infof("ovn: got new data from api helper")
 			mss = imss.(*agentmodels.ModelSets)
-			if err := w.run(ctx, mss); err != nil {
+			if err := w.run(ctx, mss, false); err != nil {
 				log.Errorf("ovn: %v", err)
 			}
 		case <-tick.C:
 			if mss != nil {
 				log.Infof("ovn: tick check")
-				if err := w.run(ctx, mss); err != nil {
+				if err := w.run(ctx, mss, false); err != nil {
 					log.Errorf("ovn: %v", err)
 				}
 			}
 			tick.Reset(tickDuration)
+		case <-fullSync.C:
+			if mss != nil {
+				log.Infof("ovn: full sync")
+				if err := w.run(ctx, mss, true); err != nil {
+					log.Errorf("ovn: %v", err)
+				}
+			}
+			fullSync.Reset(fullSyncDuration)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error) {
+// monitorNorthbound keeps nbCache in sync with the north database through
+// ovsdb monitor, reconnecting when the connection is lost
+func (w *Worker) monitorNorthbound(ctx context.Context) {
+	wg := ctx.Value("wg").(*sync.WaitGroup)
+	defer func() {
+		log.Infoln("ovn: northbound monitor bye")
+		wg.Done()
+	}()
+
+	const retryInterval = 10 * time.Second
+	for {
+		cli, err := ovnutil.DialOvsdb(ctx, w.opts.OvnNorthDatabase)
+		if err == nil {
+			w.nbCache.Reset()
+			err = cli.Monitor(ctx, ovnutil.OvsdbNorthboundName, OVNNorthboundTableNames(), w.nbCache.Update)
+			if err != nil {
+				cli.Close()
+			}
+		}
+		if err != nil {
+			log.Errorf("ovn: monitor northbound: %v", err)
+		} else {
+			log.Infof("ovn: monitoring northbound database")
+			w.nbCache.SetReady()
+			w.setNorthboundOvsdb(cli)
+
+			select {
+			case <-cli.Done():
+				log.Errorf("ovn: northbound monitor connection lost: %v", cli.Err())
+			case <-ctx.Done():
+			}
+			w.setNorthboundOvsdb(nil)
+			w.nbCache.Reset()
+			cli.Close()
+		}
+		select {
+		case <-time.After(retryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Worker) setNorthboundOvsdb(cli *ovnutil.OvsdbClient) {
+	w.nbOvsdbLock.Lock()
+	defer w.nbOvsdbLock.Unlock()
+	w.nbOvsdb = cli
+}
+
+func (w *Worker) northboundOvsdb() *ovnutil.OvsdbClient {
+	w.nbOvsdbLock.Lock()
+	defer w.nbOvsdbLock.Unlock()
+	return w.nbOvsdb
+}
+
+// loadNorthbound prefers the monitor cache and falls back to dumping the
+// database with ovn-nbctl
+func (w *Worker) loadNorthbound(ctx context.Context, ovnnbctl *ovnutil.OvnNbCtl) (*OVNNorthboundKeeper, error) {
+	if ovsdb := w.northboundOvsdb(); ovsdb != nil {
+		echoCtx, cancel := context.WithTimeout(ctx, ovnNbEchoTimeout)
+		defer cancel()
+		// make sure writes of the last run have reached the cache
+		if err := ovsdb.Echo(echoCtx); err != nil {
+			log.Warningf("ovn: northbound echo: %v", err)
+		} else if keeper, err := LoadOVNNorthbound(ctx, ovnnbctl, ovsdb, w.nbCache); err != nil {
+			log.Warningf("ovn: load northbound from cache: %v", err)
+		} else {
+			return keeper, nil
+		}
+	}
+	return DumpOVNNorthbound(ctx, ovnnbctl)
+}
+
+// run reconciles the north database with mss.  Unless full is requested,
+// only vpcs changed since last run are claimed and swept
+func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets, full bool) (err error) {
 	defer func() {
 		if panicVal := recover(); panicVal != nil {
 			if panicErr, ok := panicVal.(runtime.Error); ok {
@@ -115,78 +218,139 @@ func (w *Worker) run(ctx context.Context, mss *agentmodels.ModelSets) (err error
 		}
 	}()
 
+	var (
+		digests = newVpcDigests(mss)
+		dirty   []string
+	)
+	if w.digests == nil {
+		full = true
+	}
+	if !full {
+		dirty, full = w.digests.diff(digests)
+		if !full && len(dirty) == 0 {
+			w.digests = digests
+			return nil
+		}
+	}
+	// set on success only, a failed run is followed by a full one
+	w.digests = nil
+
 	ovnnbctl := ovnutil.NewOvnNbCtl(w.opts.OvnNorthDatabase)
-	ovndb, err := DumpOVNNorthbound(ctx, ovnnbctl)
+	ovndb, err := w.loadNorthbound(ctx, ovnnbctl)
 	if err != nil {
 		return err
 	}
 
-	ovndb.Mark(ctx)
-	for _, vpc := range mss.Vpcs {
-		if vpc.Id == apis.DEFAULT_VPC_ID {
-			continue
+	if full {
+		ovndb.Mark(ctx)
+		ovndb.ClaimAclLogMeter(ctx, w.opts)
+		for _, vpc := range mss.Vpcs {
+			if vpc.Id == apis.DEFAULT_VPC_ID {
+				continue
+			}
+			w.claimVpc(ctx, ovndb, vpc, mss)
 		}
-		ovndb.ClaimVpc(ctx, vpc)
-		if vpcHasEipgw(vpc) {
-			ovndb.ClaimVpcEipgw(ctx, vpc)
+		for _, peering := range mss.VpcPeeringConnections {
+			if !vpcPeeringIsActive(peering) {
+				continue
+			}
+			ovndb.ClaimVpcPeeringConnection(ctx, peering)
 		}
-		for _, network := range vpc.Networks {
-			ovndb.ClaimNetwork(ctx, network, w.opts)
-			for _, guestnetwork := range network.Guestnetworks {
-				if guestnetwork.Guest == nil {
+		for _, vpc := range mss.Vpcs {
+			if vpc.Id == apis.DEFAULT_VPC_ID {
+				continue
+			}
+			ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
+		}
+		ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
+	} else {
+		log.Infof("ovn: incremental run for vpcs %s", strings.Join(dirty, ","))
+		ovndb.Mark(ctx)
+		for _, vpcId := range dirty {
+			ovndb.LimitSweepToVpc(ctx, mss.Vpcs[vpcId])
+		}
+		ovndb.ClaimAclLogMeter(ctx, w.opts)
+		for _, vpcId := range dirty {
+			vpc := mss.Vpcs[vpcId]
+			w.claimVpc(ctx, ovndb, vpc, mss)
+			ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
+		}
+		for _, peering := range mss.VpcPeeringConnections {
+			if !vpcPeeringIsActive(peering) {
+				continue
+			}
+			if !utils.IsInStringArray(peering.VpcId, dirty) && !utils.IsInStringArray(peering.PeerVpcId, dirty) {
+				continue
+			}
+			ovndb.ClaimVpcPeeringConnection(ctx, peering)
+		}
+		// the row is shared by all vpcs and may be in scope of the sweep
+		ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
+	}
+	if err := ovndb.Sweep(ctx); err != nil {
+		return err
+	}
+	w.digests = digests
+	return nil
+}
+
+func (w *Worker) claimVpc(ctx context.Context, ovndb *OVNNorthboundKeeper, vpc *agentmodels.Vpc, mss *agentmodels.ModelSets) {
+	ovndb.ClaimVpc(ctx, vpc, w.opts)
+	if vpcHasEipgw(vpc) {
+		ovndb.ClaimVpcEipgw(ctx, vpc)
+	}
+	for _, network := range vpc.Networks {
+		ovndb.ClaimNetwork(ctx, network, w.opts)
+		for _, guestnetwork := range network.Guestnetworks {
+			if guestnetwork.Guest == nil {
+				continue
+			}
+
+			if vpcHasDistgw(vpc) {
+				var (
+					guest   = guestnetwork.Guest
+					network = guestnetwork.Network
+					vpc     = network.Vpc
+					host    = guest.Host
+				)
+				if host.OvnVersion == "" {
+					// Just in case.  This should never happen
+					log.Errorf("host %s(%s) of vpc guestnetwork (%s,%s) has no ovn support",
+						host.Id, host.Name, guestnetwork.NetworkId, guestnetwork.IpAddr)
 					continue
 				}
-
-				if vpcHasDistgw(vpc) {
-					var (
-						guest   = guestnetwork.Guest
-						network = guestnetwork.Network
-						vpc     = network.Vpc
-						host    = guest.Host
-					)
-					if host.OvnVersion == "" {
-						// Just in case.  This should never happen
-						log.Errorf("host %s(%s) of vpc guestnetwork (%s,%s) has no ovn support",
-							host.Id, host.Name, guestnetwork.NetworkId, guestnetwork.IpAddr)
+				if host.OvnMappedIpAddr == "" {
+					// trigger ovn mapped ip addr allocation
+					// apiVersion := "v2"
+					s := auth.GetAdminSession(ctx, w.opts.Region)
+					j, err := mcclient_modules.Hosts.Update(s, host.Id, nil)
+					if err != nil {
+						log.Errorf("host %s(%s) dummy update err: %v", host.Id, host.Name, err)
 						continue
 					}
+					j.Unmarshal(host) // update local copy in place
 					if host.OvnMappedIpAddr == "" {
-						// trigger ovn mapped ip addr allocation
-						// apiVersion := "v2"
-						s := auth.GetAdminSession(ctx, w.opts.Region)
-						j, err := mcclient_modules.Hosts.Update(s, host.Id, nil)
-						if err != nil {
-							log.Errorf("host %s(%s) dummy update err: %v", host.Id, host.Name, err)
-							continue
-						}
-						j.Unmarshal(host) // update local copy in place
-						if host.OvnMappedIpAddr == "" {
-							log.Errorf("host %s(%s) has no mapped addr", host.Id, host.Name)
-							continue
-						}
+						log.Errorf("host %s(%s) has no mapped addr", host.Id, host.Name)
+						continue
 					}
-
-					ovndb.ClaimVpcHost(ctx, vpc, host)
 				}
-				ovndb.ClaimGuestnetwork(ctx, guestnetwork)
-			}
-			for _, groupnetwork := range network.Groupnetworks {
-				ovndb.ClaimGroupnetwork(ctx, groupnetwork)
-			}
-			for _, loadbalancerNetwork := range network.LoadbalancerNetworks {
-				ovndb.ClaimLoadbalancerNetwork(ctx, loadbalancerNetwork)
+
+				ovndb.ClaimVpcHost(ctx, vpc, host)
 			}
+			ovndb.ClaimGuestnetwork(ctx, guestnetwork)
+		}
+		for _, groupnetwork := range network.Groupnetworks {
+			ovndb.ClaimGroupnetwork(ctx, groupnetwork)
+		}
+		for _, loadbalancerNetwork := range network.LoadbalancerNetworks {
+			ovndb.ClaimLoadbalancerNetwork(ctx, loadbalancerNetwork)
 		}
-		routes := resolveRoutes(vpc, mss)
-		ovndb.ClaimRoutes(ctx, vpc, routes)
 	}
-	for _, vpc := range mss.Vpcs {
-		if vpc.Id == apis.DEFAULT_VPC_ID {
-			continue
+	if vpcHasNatgw(vpc, w.opts) {
+		for _, natgw := range vpc.NatGateways {
+			ovndb.ClaimNatGateway(ctx, natgw)
 		}
-		ovndb.ClaimVpcGuestDnsRecords(ctx, vpc)
 	}
-	ovndb.ClaimDnsRecords(ctx, mss.Vpcs, mss.DnsRecords)
-	ovndb.Sweep(ctx)
-	return nil
+	routes := resolveRoutes(vpc, mss)
+	ovndb.ClaimRoutes(ctx, vpc, routes)
 }
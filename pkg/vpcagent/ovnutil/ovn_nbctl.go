@@ -39,8 +39,13 @@ func (res *CmdResult) Error() string {
 	return fmt.Sprintf("err: %v, output: %s", res.Err, res.Output)
 }
 
+// CmdRunner runs ovn-nbctl with args
+type CmdRunner func(ctx context.Context, args []string) *CmdResult
+
 type OvnNbCtl struct {
 	db string
+
+	runner CmdRunner
 }
 
 func NewOvnNbCtl(db string) *OvnNbCtl {
@@ -50,6 +55,12 @@ func NewOvnNbCtl(db string) *OvnNbCtl {
 	return cli
 }
 
+// SetCmdRunner replaces the execution of ovn-nbctl command, mainly for
+// replaying recorded output in tests
+func (cli *OvnNbCtl) SetCmdRunner(runner CmdRunner) {
+	cli.runner = runner
+}
+
 func (cli *OvnNbCtl) prepArgs(args []string) []string {
 	var r []string
 	if cli.db != "" {
@@ -67,6 +78,9 @@ func (cli *OvnNbCtl) run(ctx context.Context, args []string) *CmdResult {
 	defer cancel()
 
 	args = cli.prepArgs(args)
+	if cli.runner != nil {
+		return cli.runner(ctx, args)
+	}
 	cmd := exec.CommandContext(ctx, "ovn-nbctl", args...)
 	combined, err := cmd.CombinedOutput()
 	res := &CmdResult{
@@ -162,6 +176,7 @@ func OvnNbctlArgsDestroy(irows []types.IRow) []string {
 		case *ovn_nb.LogicalRouterStaticRoute:
 		case *ovn_nb.ACL:
 		case *ovn_nb.QoS:
+		case *ovn_nb.NAT:
 		default:
 			if !irow.OvsdbIsRoot() {
 				panic(irow.OvsdbTableName())
@@ -0,0 +1,135 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovnutil
+
+import (
+	"sort"
+	"sync"
+
+	"yunion.io/x/ovsdb/types"
+	"yunion.io/x/pkg/errors"
+)
+
+const ErrOvsdbCacheNotReady = errors.Error("ovsdb cache not ready")
+
+type ovsdbCacheRow map[string]interface{}
+
+// OvsdbCache is an in-memory copy of monitored ovsdb tables.  Rows are kept
+// in ovsdb json notation and decoded into typed rows on Fill, so that each
+// caller gets its own copy to mark
+type OvsdbCache struct {
+	mu     sync.RWMutex
+	tables map[string]map[string]ovsdbCacheRow
+	ready  bool
+}
+
+func NewOvsdbCache() *OvsdbCache {
+	cache := &OvsdbCache{
+		tables: map[string]map[string]ovsdbCacheRow{},
+	}
+	return cache
+}
+
+// Reset drops all rows.  It's called when the monitor connection is lost,
+// the cache is not ready until the next SetReady
+func (cache *OvsdbCache) Reset() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.tables = map[string]map[string]ovsdbCacheRow{}
+	cache.ready = false
+}
+
+// SetReady marks that initial contents of the monitor has been applied
+func (cache *OvsdbCache) SetReady() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.ready = true
+}
+
+func (cache *OvsdbCache) Ready() bool {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return cache.ready
+}
+
+// Update applies monitor initial contents or update notifications
+func (cache *OvsdbCache) Update(updates OvsdbTableUpdates) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for tbl, rowUpdates := range updates {
+		rows, ok := cache.tables[tbl]
+		if !ok {
+			rows = map[string]ovsdbCacheRow{}
+			cache.tables[tbl] = rows
+		}
+		for uuid, rowUpdate := range rowUpdates {
+			if rowUpdate == nil || rowUpdate.New == nil {
+				delete(rows, uuid)
+				continue
+			}
+			// "new" of modify may only carry changed columns with
+			// older servers, merge them into a fresh copy
+			row := ovsdbCacheRow{}
+			for col, val := range rows[uuid] {
+				row[col] = val
+			}
+			for col, val := range rowUpdate.New {
+				row[col] = val
+			}
+			rows[uuid] = row
+		}
+	}
+}
+
+func (cache *OvsdbCache) Len(tbl string) int {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return len(cache.tables[tbl])
+}
+
+// Fill appends cached rows to itbls.  Columns unknown to the generated
+// schema are ignored to be tolerant to newer ovsdb-server
+func (cache *OvsdbCache) Fill(itbls ...types.ITable) error {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	if !cache.ready {
+		return ErrOvsdbCacheNotReady
+	}
+	for _, itbl := range itbls {
+		tbl := itbl.OvsdbTableName()
+		rows := cache.tables[tbl]
+		uuids := make([]string, 0, len(rows))
+		for uuid := range rows {
+			uuids = append(uuids, uuid)
+		}
+		sort.Strings(uuids)
+		for _, uuid := range uuids {
+			irow := itbl.NewRow()
+			if err := irow.SetColumn("_uuid", OvsdbUuid(uuid)); err != nil {
+				return errors.Wrapf(err, "%s: set _uuid", tbl)
+			}
+			for col, val := range rows[uuid] {
+				if err := irow.SetColumn(col, val); err != nil {
+					if errors.Cause(err) == types.ErrUnknownColumn {
+						continue
+					}
+					return errors.Wrapf(err, "%s %s", tbl, uuid)
+				}
+			}
+			itbl.AppendRow(irow)
+		}
+	}
+	return nil
+}
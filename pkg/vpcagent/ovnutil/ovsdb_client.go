@@ -0,0 +1,387 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovnutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"yunion.io/x/log"
+	"yunion.io/x/pkg/errors"
+)
+
+const (
+	OvsdbNorthboundName = "OVN_Northbound"
+
+	ErrOvsdbUnsupportedAddr = errors.Error("unsupported ovsdb address")
+	ErrOvsdbClosed          = errors.Error("ovsdb connection closed")
+	ErrOvsdbRpc             = errors.Error("ovsdb rpc error")
+)
+
+// ovsdbNbSockets are where ovn-nbctl looks for the northbound database when
+// no --db is given, newer ovn packages use /var/run/ovn
+var ovsdbNbSockets = []string{
+	"/var/run/ovn/ovnnb_db.sock",
+	"/var/run/openvswitch/ovnnb_db.sock",
+}
+
+// OvsdbRowUpdate is the <row-update> object of RFC 7047 monitor replies and
+// update notifications.  Column values are in ovsdb json notation, the same
+// as what "ovn-nbctl --format=json list" outputs
+type OvsdbRowUpdate struct {
+	Old map[string]interface{} `json:"old,omitempty"`
+	New map[string]interface{} `json:"new,omitempty"`
+}
+
+// OvsdbTableUpdates maps table name to row updates keyed by row uuid
+type OvsdbTableUpdates map[string]map[string]*OvsdbRowUpdate
+
+// OvsdbOp is a single operation of a "transact" request
+type OvsdbOp map[string]interface{}
+
+type ovsdbRpcMessage struct {
+	Id     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+type ovsdbRpcCall struct {
+	ch chan *ovsdbRpcMessage
+	// onResult when set is invoked in the reader goroutine before the
+	// response is handed over, so that monitor initial contents are
+	// applied before any update notification following it
+	onResult func(json.RawMessage) error
+}
+
+// OvsdbClient is a minimal RFC 7047 JSON-RPC client.  It supports
+// "monitor", "transact" and "echo", which is what vpcagent needs for
+// keeping an in-memory copy of the northbound database
+type OvsdbClient struct {
+	db   string
+	conn net.Conn
+
+	mu       sync.Mutex
+	enc      *json.Encoder
+	nextId   int64
+	pending  map[int64]*ovsdbRpcCall
+	onUpdate func(OvsdbTableUpdates)
+	err      error
+	done     chan struct{}
+}
+
+func ovsdbDialArgs(db string) (string, string, error) {
+	switch {
+	case db == "":
+		for _, sock := range ovsdbNbSockets {
+			if _, err := os.Stat(sock); err == nil {
+				return "unix", sock, nil
+			}
+		}
+		return "unix", ovsdbNbSockets[0], nil
+	case strings.HasPrefix(db, "tcp:"):
+		return "tcp", db[len("tcp:"):], nil
+	case strings.HasPrefix(db, "unix:"):
+		return "unix", db[len("unix:"):], nil
+	default:
+		return "", "", errors.Wrap(ErrOvsdbUnsupportedAddr, db)
+	}
+}
+
+// DialOvsdb connects to ovsdb-server at db, which is in the same format as
+// the --db argument of ovn-nbctl.  ssl connections are not supported
+func DialOvsdb(ctx context.Context, db string) (*OvsdbClient, error) {
+	network, addr, err := ovsdbDialArgs(db)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %s", db)
+	}
+	cli := &OvsdbClient{
+		db:      db,
+		conn:    conn,
+		enc:     json.NewEncoder(conn),
+		pending: map[int64]*ovsdbRpcCall{},
+		done:    make(chan struct{}),
+	}
+	go cli.readLoop()
+	return cli, nil
+}
+
+// Done is closed when the connection is lost or closed
+func (cli *OvsdbClient) Done() <-chan struct{} {
+	return cli.done
+}
+
+// Err returns why the connection was closed
+func (cli *OvsdbClient) Err() error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	return cli.err
+}
+
+func (cli *OvsdbClient) Close() error {
+	cli.shutdown(ErrOvsdbClosed)
+	return nil
+}
+
+func (cli *OvsdbClient) shutdown(err error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	if cli.err != nil {
+		return
+	}
+	cli.err = err
+	cli.conn.Close()
+	for id, call := range cli.pending {
+		close(call.ch)
+		delete(cli.pending, id)
+	}
+	close(cli.done)
+}
+
+func (cli *OvsdbClient) readLoop() {
+	dec := json.NewDecoder(cli.conn)
+	for {
+		msg := &ovsdbRpcMessage{}
+		if err := dec.Decode(msg); err != nil {
+			cli.shutdown(errors.Wrap(err, "read"))
+			return
+		}
+		if msg.Method != "" {
+			cli.handleRequest(msg)
+			continue
+		}
+		var id int64
+		if err := json.Unmarshal(msg.Id, &id); err != nil {
+			log.Warningf("ovsdb: response with unexpected id %s", msg.Id)
+			continue
+		}
+		cli.mu.Lock()
+		call, ok := cli.pending[id]
+		delete(cli.pending, id)
+		cli.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if call.onResult != nil && ovsdbRpcIsNull(msg.Error) {
+			if err := call.onResult(msg.Result); err != nil {
+				msg.Error, _ = json.Marshal(err.Error())
+			}
+		}
+		call.ch <- msg
+	}
+}
+
+func (cli *OvsdbClient) handleRequest(msg *ovsdbRpcMessage) {
+	switch msg.Method {
+	case "echo":
+		reply := map[string]interface{}{
+			"id":     msg.Id,
+			"result": msg.Params,
+			"error":  nil,
+		}
+		if err := cli.send(reply); err != nil {
+			log.Warningf("ovsdb: reply echo: %v", err)
+		}
+	case "update":
+		var params []json.RawMessage
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params) != 2 {
+			log.Errorf("ovsdb: bad update notification: %s", msg.Params)
+			return
+		}
+		updates := OvsdbTableUpdates{}
+		if err := json.Unmarshal(params[1], &updates); err != nil {
+			log.Errorf("ovsdb: decode table updates: %v", err)
+			return
+		}
+		cli.mu.Lock()
+		onUpdate := cli.onUpdate
+		cli.mu.Unlock()
+		if onUpdate != nil {
+			onUpdate(updates)
+		}
+	default:
+		log.Warningf("ovsdb: unexpected request %s", msg.Method)
+	}
+}
+
+func (cli *OvsdbClient) send(v interface{}) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+	if cli.err != nil {
+		return cli.err
+	}
+	return cli.enc.Encode(v)
+}
+
+func (cli *OvsdbClient) call(ctx context.Context, call *ovsdbRpcCall, method string, params ...interface{}) (json.RawMessage, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	call.ch = make(chan *ovsdbRpcMessage, 1)
+
+	cli.mu.Lock()
+	if cli.err != nil {
+		cli.mu.Unlock()
+		return nil, cli.err
+	}
+	cli.nextId += 1
+	id := cli.nextId
+	cli.pending[id] = call
+	err := cli.enc.Encode(map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		delete(cli.pending, id)
+	}
+	cli.mu.Unlock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "send %s", method)
+	}
+
+	select {
+	case msg, ok := <-call.ch:
+		if !ok {
+			return nil, cli.Err()
+		}
+		if !ovsdbRpcIsNull(msg.Error) {
+			return nil, errors.Wrapf(ErrOvsdbRpc, "%s: %s", method, msg.Error)
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		cli.mu.Lock()
+		delete(cli.pending, id)
+		cli.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func ovsdbRpcIsNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// Echo does a round trip to the server.  As the server handles requests of
+// a session in order, update notifications for transactions committed
+// before the echo was received are delivered by the time it returns
+func (cli *OvsdbClient) Echo(ctx context.Context) error {
+	_, err := cli.call(ctx, &ovsdbRpcCall{}, "echo")
+	return err
+}
+
+// Monitor subscribes to all columns of tables in database dbName.  The
+// initial contents and all following updates are passed to onUpdate in
+// order, from the reader goroutine
+func (cli *OvsdbClient) Monitor(ctx context.Context, dbName string, tables []string, onUpdate func(OvsdbTableUpdates)) error {
+	cli.mu.Lock()
+	cli.onUpdate = onUpdate
+	cli.mu.Unlock()
+
+	reqs := map[string]interface{}{}
+	for _, tbl := range tables {
+		reqs[tbl] = map[string]interface{}{}
+	}
+	call := &ovsdbRpcCall{
+		onResult: func(result json.RawMessage) error {
+			updates := OvsdbTableUpdates{}
+			if err := json.Unmarshal(result, &updates); err != nil {
+				return errors.Wrap(err, "decode monitor initial contents")
+			}
+			onUpdate(updates)
+			return nil
+		},
+	}
+	_, err := cli.call(ctx, call, "monitor", dbName, dbName, reqs)
+	return err
+}
+
+// Transact executes ops in a single transaction on database dbName
+func (cli *OvsdbClient) Transact(ctx context.Context, dbName string, ops ...OvsdbOp) error {
+	params := make([]interface{}, 0, len(ops)+1)
+	params = append(params, dbName)
+	for _, op := range ops {
+		params = append(params, op)
+	}
+	result, err := cli.call(ctx, &ovsdbRpcCall{}, "transact", params...)
+	if err != nil {
+		return err
+	}
+	var opResults []map[string]interface{}
+	if err := json.Unmarshal(result, &opResults); err != nil {
+		return errors.Wrap(err, "decode transact result")
+	}
+	for i, opResult := range opResults {
+		if opResult == nil {
+			continue
+		}
+		if e, ok := opResult["error"]; ok {
+			if i < len(ops) {
+				return errors.Wrapf(ErrOvsdbRpc, "op %d (%v): %v: %v", i, ops[i]["op"], e, opResult["details"])
+			}
+			return errors.Wrapf(ErrOvsdbRpc, "commit: %v: %v", e, opResult["details"])
+		}
+	}
+	return nil
+}
+
+func OvsdbUuid(uuid string) []interface{} {
+	return []interface{}{"uuid", uuid}
+}
+
+// OvsdbOpDelete returns op deleting row of table by uuid
+func OvsdbOpDelete(table, uuid string) OvsdbOp {
+	return OvsdbOp{
+		"op":    "delete",
+		"table": table,
+		"where": []interface{}{
+			[]interface{}{"_uuid", "==", OvsdbUuid(uuid)},
+		},
+	}
+}
+
+// OvsdbOpMutateDeleteRef returns op removing uuid from the set column of
+// table rows referring to it.  Non-root rows are garbage collected by the
+// server when the last reference to them is gone
+func OvsdbOpMutateDeleteRef(table, column, uuid string) OvsdbOp {
+	return OvsdbOp{
+		"op":    "mutate",
+		"table": table,
+		"where": []interface{}{
+			[]interface{}{column, "includes", OvsdbUuid(uuid)},
+		},
+		"mutations": []interface{}{
+			[]interface{}{column, "delete", OvsdbUuid(uuid)},
+		},
+	}
+}
+
+func OvsdbOpsString(ops []OvsdbOp) string {
+	lines := make([]string, len(ops))
+	for i, op := range ops {
+		lines[i] = fmt.Sprintf("\t%s %s %v", op["op"], op["table"], op["where"])
+	}
+	return strings.Join(lines, "\n")
+}
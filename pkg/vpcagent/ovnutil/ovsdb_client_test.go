@@ -0,0 +1,230 @@
+// Copyright 2019 Yunion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovnutil
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yunion.io/x/ovsdb/schema/ovn_nb"
+)
+
+func TestOvsdbCacheUpdate(t *testing.T) {
+	cache := NewOvsdbCache()
+	tbl := &ovn_nb.LogicalSwitchTable{}
+	if err := cache.Fill(tbl); err != ErrOvsdbCacheNotReady {
+		t.Fatalf("want not ready error, got %v", err)
+	}
+
+	apply := func(s string) {
+		updates := OvsdbTableUpdates{}
+		if err := json.Unmarshal([]byte(s), &updates); err != nil {
+			t.Fatalf("decode %s: %v", s, err)
+		}
+		cache.Update(updates)
+	}
+	apply(`{"Logical_Switch":{
+		"a0000000-0000-0000-0000-000000000001":{"new":{"name":"ls0","ports":["set",[]],"external_ids":["map",[["oc-ref","net0"]]],"unknown_column":1}},
+		"a0000000-0000-0000-0000-000000000002":{"new":{"name":"ls1","ports":["uuid","b0000000-0000-0000-0000-000000000001"],"external_ids":["map",[]]}}
+	}}`)
+	cache.SetReady()
+	// modify with only changed columns, and delete
+	apply(`{"Logical_Switch":{
+		"a0000000-0000-0000-0000-000000000001":{"old":{"ports":["set",[]]},"new":{"ports":["set",[["uuid","b0000000-0000-0000-0000-000000000002"],["uuid","b0000000-0000-0000-0000-000000000003"]]]}},
+		"a0000000-0000-0000-0000-000000000002":{"old":{"name":"ls1"}}
+	}}`)
+
+	if err := cache.Fill(tbl); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if len(*tbl) != 1 {
+		t.Fatalf("want 1 row, got %d", len(*tbl))
+	}
+	ls := (*tbl)[0]
+	if ls.Uuid != "a0000000-0000-0000-0000-000000000001" || ls.Name != "ls0" {
+		t.Errorf("unexpected row %#v", ls)
+	}
+	if len(ls.Ports) != 2 {
+		t.Errorf("want 2 ports, got %v", ls.Ports)
+	}
+	if ref, _ := ls.GetExternalId("oc-ref"); ref != "net0" {
+		t.Errorf("columns not in update should be kept, got external_ids %v", ls.ExternalIds)
+	}
+
+	// rows filled are copies
+	ls.SetExternalId("oc-version", "x")
+	tbl2 := &ovn_nb.LogicalSwitchTable{}
+	if err := cache.Fill(tbl2); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if _, ok := (*tbl2)[0].GetExternalId("oc-version"); ok {
+		t.Errorf("marks on filled rows should not leak into cache")
+	}
+}
+
+// startTestOvsdbServer starts ovsdb-server serving an empty north database
+// on a unix socket and returns the --db address
+func startTestOvsdbServer(t *testing.T) string {
+	for _, prog := range []string{"ovsdb-tool", "ovsdb-server"} {
+		if _, err := exec.LookPath(prog); err != nil {
+			t.Skipf("%s not found", prog)
+		}
+	}
+	var schema string
+	for _, p := range []string{
+		os.Getenv("OVN_NB_SCHEMA"),
+		"/usr/share/ovn/ovn-nb.ovsschema",
+		"/usr/share/openvswitch/ovn-nb.ovsschema",
+		"../../../vendor/yunion.io/x/ovsdb/types/ovn-nb.ovsschema",
+	} {
+		if p == "" {
+			continue
+		}
+		if _, err := os.Stat(p); err == nil {
+			schema = p
+			break
+		}
+	}
+	if schema == "" {
+		t.Skip("ovn-nb.ovsschema not found")
+	}
+
+	dir := t.TempDir()
+	var (
+		dbFile = filepath.Join(dir, "ovnnb_db.db")
+		sock   = filepath.Join(dir, "ovnnb_db.sock")
+	)
+	if output, err := exec.Command("ovsdb-tool", "create", dbFile, schema).CombinedOutput(); err != nil {
+		t.Fatalf("ovsdb-tool create: %v: %s", err, output)
+	}
+	cmd := exec.Command("ovsdb-server",
+		"--no-chdir",
+		"--remote=punix:"+sock,
+		"--unixctl="+filepath.Join(dir, "ovsdb-server.ctl"),
+		"--log-file="+filepath.Join(dir, "ovsdb-server.log"),
+		dbFile,
+	)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start ovsdb-server: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(sock); err == nil {
+			return "unix:" + sock
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("ovsdb-server did not create %s", sock)
+	return ""
+}
+
+func TestOvsdbClient(t *testing.T) {
+	db := startTestOvsdbServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cli, err := DialOvsdb(ctx, db)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer cli.Close()
+
+	cache := NewOvsdbCache()
+	tables := []string{"Logical_Switch", "Logical_Switch_Port"}
+	if err := cli.Monitor(ctx, OvsdbNorthboundName, tables, cache.Update); err != nil {
+		t.Fatalf("monitor: %v", err)
+	}
+	cache.SetReady()
+
+	err = cli.Transact(ctx, OvsdbNorthboundName,
+		OvsdbOp{
+			"op":        "insert",
+			"table":     "Logical_Switch_Port",
+			"uuid-name": "lsp0",
+			"row": map[string]interface{}{
+				"name":      "lsp0",
+				"addresses": "00:22:00:00:00:01 192.168.0.2",
+			},
+		},
+		OvsdbOp{
+			"op":    "insert",
+			"table": "Logical_Switch",
+			"row": map[string]interface{}{
+				"name":         "ls0",
+				"ports":        []interface{}{"named-uuid", "lsp0"},
+				"external_ids": []interface{}{"map", []interface{}{[]interface{}{"oc-ref", "net0"}}},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("transact insert: %v", err)
+	}
+	if err := cli.Echo(ctx); err != nil {
+		t.Fatalf("echo: %v", err)
+	}
+
+	nb := ovn_nb.OVNNorthbound{}
+	if err := cache.Fill(&nb.LogicalSwitch, &nb.LogicalSwitchPort); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if len(nb.LogicalSwitch) != 1 || len(nb.LogicalSwitchPort) != 1 {
+		t.Fatalf("want 1 switch and 1 port, got %d, %d", len(nb.LogicalSwitch), len(nb.LogicalSwitchPort))
+	}
+	ls, lsp := nb.LogicalSwitch[0], nb.LogicalSwitchPort[0]
+	if ls.Name != "ls0" || len(ls.Ports) != 1 || ls.Ports[0] != lsp.Uuid {
+		t.Errorf("unexpected switch %#v", ls)
+	}
+	if ref, _ := ls.GetExternalId("oc-ref"); ref != "net0" {
+		t.Errorf("unexpected external_ids %v", ls.ExternalIds)
+	}
+	if len(lsp.Addresses) != 1 || lsp.Addresses[0] != "00:22:00:00:00:01 192.168.0.2" {
+		t.Errorf("unexpected port addresses %v", lsp.Addresses)
+	}
+
+	// the way sweep works: drop references first, then root rows
+	err = cli.Transact(ctx, OvsdbNorthboundName,
+		OvsdbOpMutateDeleteRef("Logical_Switch", "ports", lsp.Uuid),
+		OvsdbOpDelete("Logical_Switch", ls.Uuid),
+	)
+	if err != nil {
+		t.Fatalf("transact delete: %v", err)
+	}
+	if err := cli.Echo(ctx); err != nil {
+		t.Fatalf("echo: %v", err)
+	}
+	for _, tbl := range tables {
+		if n := cache.Len(tbl); n != 0 {
+			t.Errorf("%s: want empty, got %d rows", tbl, n)
+		}
+	}
+
+	// errors of ops are reported
+	err = cli.Transact(ctx, OvsdbNorthboundName, OvsdbOp{
+		"op":    "insert",
+		"table": "Logical_Switch",
+		"row":   map[string]interface{}{"no_such_column": "x"},
+	})
+	if err == nil {
+		t.Errorf("want error for bad column")
+	}
+}